@@ -227,10 +227,10 @@ func (a *API) registerDeployRoutes(router forge.Router) {
 
 	_ = g.POST("/instances/:instanceId/deploy", a.deployInstance,
 		forge.WithSummary("Deploy to instance"),
-		forge.WithDescription("Creates a new deployment for an instance."),
+		forge.WithDescription("Creates a release and queues its deployment. The deployment is returned pending; poll it for progress."),
 		forge.WithOperationID("deployInstance"),
 		forge.WithRequestSchema(DeployAPIRequest{}),
-		forge.WithCreatedResponse(deploy.Deployment{}),
+		forge.WithResponseSchema(http.StatusAccepted, "Queued deployment", deploy.Deployment{}),
 		forge.WithErrorResponses(),
 	)
 
@@ -253,18 +253,19 @@ func (a *API) registerDeployRoutes(router forge.Router) {
 
 	_ = g.POST("/deployments/:deploymentId/cancel", a.cancelDeployment,
 		forge.WithSummary("Cancel deployment"),
-		forge.WithDescription("Cancels an in-progress deployment."),
+		forge.WithDescription("Cancels a pending or running deployment, optionally rolling back the services it already updated. The runner stops the strategy and marks the deployment cancelled; poll it for the outcome."),
 		forge.WithOperationID("cancelDeployment"),
-		forge.WithNoContentResponse(),
+		forge.WithRequestSchema(CancelDeploymentRequest{}),
+		forge.WithAcceptedResponse(),
 		forge.WithErrorResponses(),
 	)
 
 	_ = g.POST("/instances/:instanceId/rollback", a.rollback,
 		forge.WithSummary("Rollback instance"),
-		forge.WithDescription("Rolls back an instance to a previous release."),
+		forge.WithDescription("Queues a rollback of an instance to a previous release. The rollback deployment is returned pending; poll it for progress."),
 		forge.WithOperationID("rollbackInstance"),
 		forge.WithRequestSchema(RollbackRequest{}),
-		forge.WithCreatedResponse(deploy.Deployment{}),
+		forge.WithResponseSchema(http.StatusAccepted, "Queued rollback deployment", deploy.Deployment{}),
 		forge.WithErrorResponses(),
 	)
 
@@ -16,6 +16,9 @@ func (a *API) deployInstance(ctx forge.Context, req *DeployAPIRequest) (*deploy.
 		Strategy:   req.Strategy,
 		Notes:      req.Notes,
 		CommitSHA:  req.CommitSHA,
+		Canary:     req.Canary,
+		BlueGreen:  req.BlueGreen,
+		Bake:       req.Bake,
 	}
 
 	deployment, err := a.cp.Deploys.Deploy(ctx.Context(), domainReq)
@@ -23,7 +26,8 @@ func (a *API) deployInstance(ctx forge.Context, req *DeployAPIRequest) (*deploy.
 		return nil, mapError(err)
 	}
 
-	_ = ctx.JSON(http.StatusCreated, deployment)
+	// The deployment runs in the background; it is returned pending.
+	_ = ctx.JSON(http.StatusAccepted, deployment)
 
 	//nolint:nilnil // response already written via ctx.JSON/ctx.NoContent.
 	return nil, nil
@@ -61,11 +65,14 @@ func (a *API) getDeployment(ctx forge.Context, req *GetDeploymentRequest) (*depl
 
 // cancelDeployment handles POST /v1/deployments/:deploymentId/cancel.
 func (a *API) cancelDeployment(ctx forge.Context, req *CancelDeploymentRequest) (*deploy.Deployment, error) {
-	if err := a.cp.Deploys.Cancel(ctx.Context(), req.DeploymentID); err != nil {
+	opts := deploy.CancelOptions{Rollback: req.Rollback}
+
+	if err := a.cp.Deploys.Cancel(ctx.Context(), req.DeploymentID, opts); err != nil {
 		return nil, mapError(err)
 	}
 
-	_ = ctx.NoContent(http.StatusNoContent)
+	// The runner stops the deployment and records the outcome.
+	_ = ctx.NoContent(http.StatusAccepted)
 
 	//nolint:nilnil // response already written via ctx.JSON/ctx.NoContent.
 	return nil, nil
@@ -78,7 +85,7 @@ func (a *API) rollback(ctx forge.Context, req *RollbackRequest) (*deploy.Deploym
 		return nil, mapError(err)
 	}
 
-	_ = ctx.JSON(http.StatusCreated, deployment)
+	_ = ctx.JSON(http.StatusAccepted, deployment)
 
 	//nolint:nilnil // response already written via ctx.JSON/ctx.NoContent.
 	return nil, nil
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/xraph/ctrlplane/admin"
+	"github.com/xraph/ctrlplane/deploy"
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/instance"
 	"github.com/xraph/ctrlplane/provider"
@@ -75,6 +76,9 @@ type DeployAPIRequest struct {
 	Strategy   string                       `description:"Deploy strategy"         json:"strategy,omitempty"`
 	Notes      string                       `description:"Deploy notes"            json:"notes,omitempty"`
 	CommitSHA  string                       `description:"Git commit SHA"          json:"commit_sha,omitempty"`
+	Canary     *deploy.CanaryConfig         `description:"Canary schedule"         json:"canary,omitempty"`
+	BlueGreen  *deploy.BlueGreenConfig      `description:"Blue-green settings"     json:"blue_green,omitempty"`
+	Bake       *deploy.BakeConfig           `description:"Post-deploy bake window" json:"bake,omitempty"`
 }
 
 // ListDeploymentsRequest binds path + query for GET /v1/instances/:instanceId/deployments.
@@ -89,9 +93,10 @@ type GetDeploymentRequest struct {
 	DeploymentID id.ID `description:"Deployment identifier" path:"deploymentId"`
 }
 
-// CancelDeploymentRequest binds the path for POST /v1/deployments/:deploymentId/cancel.
+// CancelDeploymentRequest binds path + body for POST /v1/deployments/:deploymentId/cancel.
 type CancelDeploymentRequest struct {
-	DeploymentID id.ID `description:"Deployment identifier" path:"deploymentId"`
+	DeploymentID id.ID `description:"Deployment identifier"                          path:"deploymentId"`
+	Rollback     bool  `description:"Roll back the services already updated" json:"rollback,omitempty"`
 }
 
 // RollbackRequest binds path + body for POST /v1/instances/:instanceId/rollback.
@@ -2,10 +2,14 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
+	gu "github.com/xraph/go-utils/metrics"
+
 	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/admin"
 	audithook "github.com/xraph/ctrlplane/audit_hook"
@@ -22,6 +26,7 @@ import (
 	"github.com/xraph/ctrlplane/network"
 	"github.com/xraph/ctrlplane/plugin"
 	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/provider/middleware"
 	"github.com/xraph/ctrlplane/providerhealth"
 	"github.com/xraph/ctrlplane/secrets"
 	"github.com/xraph/ctrlplane/secrets/memoryvault"
@@ -50,6 +55,12 @@ type CtrlPlane struct {
 	// WithBootstrapHook can populate it during construction.
 	bootstrapHooks *bootstrap.Registry
 
+	// providerMiddleware and providerMetrics configure the call chain
+	// installed on the provider registry; circuits is its breaker.
+	providerMiddleware []provider.Middleware
+	providerMetrics    gu.MetricFactory
+	circuits           *middleware.Breaker
+
 	// Services are the public subsystem interfaces.
 	Datacenters    datacenter.Service
 	Instances      instance.Service
@@ -89,6 +100,10 @@ func New(opts ...Option) (*CtrlPlane, error) {
 
 	cp.pendingExts = nil
 
+	if err := cp.installProviderMiddleware(); err != nil {
+		return nil, err
+	}
+
 	cp.wireServices()
 
 	return cp, nil
@@ -109,6 +124,12 @@ func (cp *CtrlPlane) Providers() *provider.Registry {
 	return cp.providers
 }
 
+// ProviderCircuits returns the circuit breaker guarding provider
+// calls, for inspecting or resetting a provider's circuit.
+func (cp *CtrlPlane) ProviderCircuits() *middleware.Breaker {
+	return cp.circuits
+}
+
 // Events returns the event bus.
 func (cp *CtrlPlane) Events() event.Bus {
 	return cp.events
@@ -192,6 +213,18 @@ func (cp *CtrlPlane) Start(ctx context.Context) error {
 		go cp.ProviderHealth.Run(ctx)
 	}
 
+	// Providers implementing provider.Watcher push state changes
+	// (crashes, OOM kills, out-of-band deletes) so persisted state
+	// and events follow the backend within seconds rather than on
+	// the next Status call.
+	if w, ok := cp.Instances.(interface{ WatchProviders(ctx context.Context) }); ok {
+		go w.WatchProviders(ctx)
+	}
+
+	// Again for providers registered after New.
+	cp.setTenantQuotaResolvers()
+	cp.setInstanceResolvers()
+
 	return cp.scheduler.Start(ctx)
 }
 
@@ -209,6 +242,35 @@ func (cp *CtrlPlane) Stop(ctx context.Context) error {
 	return cp.events.Close()
 }
 
+// installProviderMiddleware puts every provider call behind the
+// default policy: metrics outermost so a retried call is recorded
+// once, then retries for idempotent calls, the circuit breaker, and a
+// per-attempt timeout. Without it a hung backend API blocks the
+// request calling it indefinitely. Middleware from
+// WithProviderMiddleware runs innermost.
+func (cp *CtrlPlane) installProviderMiddleware() error {
+	breaker, err := middleware.NewBreaker()
+	if err != nil {
+		return err
+	}
+
+	cp.circuits = breaker
+
+	if cp.providerMetrics == nil {
+		cp.providerMetrics = gu.NewMetricsCollector("ctrlplane/provider")
+	}
+
+	cp.providers.Use(
+		middleware.Metrics(cp.providerMetrics),
+		middleware.Retry(middleware.DefaultRetryPolicy()),
+		breaker.Middleware(),
+		middleware.Timeout(middleware.DefaultTimeouts()),
+	)
+	cp.providers.Use(cp.providerMiddleware...)
+
+	return nil
+}
+
 // wireServices instantiates all service implementations and background workers.
 func (cp *CtrlPlane) wireServices() {
 	// Default to an in-memory vault when none was provided via WithVault.
@@ -232,8 +294,6 @@ func (cp *CtrlPlane) wireServices() {
 	// Deploy service with strategies.
 	deploySvc := deploy.NewService(cp.store, cp.store, cp.providers, cp.events, cp.auth, cp.vault)
 	deploySvc.RegisterStrategy(strategies.NewRolling())
-	deploySvc.RegisterStrategy(strategies.NewBlueGreen())
-	deploySvc.RegisterStrategy(strategies.NewCanary())
 	deploySvc.RegisterStrategy(strategies.NewRecreate())
 	cp.Deploys = deploySvc
 
@@ -256,12 +316,28 @@ func (cp *CtrlPlane) wireServices() {
 	// that don't call Start() get a cold cache (Get returns
 	// ok=false → handlers degrade to "unknown").
 	cp.ProviderHealth = providerhealth.NewCache(cp.providers, providerhealth.DefaultConfig())
+	cp.ProviderHealth.SetCircuits(cp.circuits)
 
 	// Network service (no external router by default). Declared
 	// before Workloads so the workload service can read aggregated
 	// domains/routes per replica via cp.Network.
 	cp.Network = network.NewService(cp.store, nil, cp.events, cp.auth)
 
+	// Blue-green and canary move traffic through Network and watch
+	// Health (and Metrics), so they register once those exist.
+	deploySvc.RegisterStrategy(strategies.NewBlueGreen(
+		strategies.WithBlueGreenRoutes(cp.Network),
+		strategies.WithBlueGreenHealth(cp.Health),
+	))
+	deploySvc.RegisterStrategy(strategies.NewCanary(
+		strategies.WithCanaryRoutes(cp.Network),
+		strategies.WithCanaryAnalysis(cp.Health, cp.Metrics),
+	))
+
+	// Deployments with a bake window watch the same signals once they
+	// succeed.
+	deploySvc.SetBakeAnalysis(cp.Health, cp.Metrics)
+
 	// Template service — workload blueprints. Constructed before
 	// Workloads so it can be passed in for FromTemplateID flows; the
 	// reverse-direction WorkloadSpecReader is registered after the
@@ -277,6 +353,10 @@ func (cp *CtrlPlane) wireServices() {
 	wlSvc := workload.NewService(cp.store, cp.Instances, cp.Deploys, cp.Templates, cp.Health, cp.Metrics, cp.Network, cp.events, cp.auth)
 	cp.Workloads = wlSvc
 
+	// Deployments run in the background; workloads follow them from
+	// StateDeploying to active or failed through their events.
+	cp.events.Subscribe(wlSvc.HandleDeployEvent, workload.DeployEvents...)
+
 	// Now that the workload service exists, register the spec reader
 	// so template.CreateFromWorkload can fork from a live workload.
 	tplSvc.SetWorkloadReader(workload.NewSpecReader(wlSvc))
@@ -287,6 +367,17 @@ func (cp *CtrlPlane) wireServices() {
 	// Secrets service backed by the configured vault.
 	cp.Secrets = secrets.NewService(cp.store, cp.vault, cp.auth)
 
+	// Registry-type secrets become pull credentials on every provider
+	// call that may pull an image: provision (Instances) and
+	// deploy/rollback (Deploys).
+	for _, svc := range []any{cp.Instances, cp.Deploys} {
+		if setter, ok := svc.(interface {
+			SetRegistryResolver(r provider.RegistryResolver)
+		}); ok {
+			setter.SetRegistryResolver(cp.Secrets)
+		}
+	}
+
 	// Admin service. Wire the providerhealth cache as the live
 	// source for ListProviders so the dashboard's Providers page
 	// reflects actual reachability instead of always showing
@@ -302,6 +393,17 @@ func (cp *CtrlPlane) wireServices() {
 
 	cp.Admin = adminSvc
 
+	// Providers that isolate tenants on the backend (a namespace per
+	// tenant on Kubernetes) size that isolation from the tenant's
+	// admin quota, and tear it down once the tenant is deleted.
+	cp.setTenantQuotaResolvers()
+	cp.events.Subscribe(cp.deleteProviderTenant, event.TenantDeleted)
+
+	// Provider wrappers that pick instances by label (fault injection)
+	// and providers that need an instance's services read them from
+	// the instance store.
+	cp.setInstanceResolvers()
+
 	// Background workers.
 	healthInterval := cp.config.HealthInterval
 	if healthInterval == 0 {
@@ -320,6 +422,10 @@ func (cp *CtrlPlane) wireServices() {
 	cp.scheduler.Register(worker.NewGarbageCollector(cp.store, cp.Instances, cp.store, cp.events, 5*time.Minute, worker.GCConfig{}))
 	cp.scheduler.Register(worker.NewCertRenewer(cp.Network, cp.events, 12*time.Hour))
 
+	// Deploy only queues a deployment; the runner claims and executes
+	// it, and picks up whatever a previous process left running.
+	cp.scheduler.Register(deploySvc.Runner(deploy.RunnerConfig{}))
+
 	// Default audit-trail plugin: bridges every lifecycle event to
 	// admin.AuditEntry rows in the store. Without this nothing
 	// writes to the audit log table — the dashboard's Audit Log
@@ -414,6 +520,127 @@ func (r *storeAuditRecorder) Record(ctx context.Context, evt *audithook.AuditEve
 	return r.store.InsertAuditEntry(ctx, entry)
 }
 
+// tenantIsolators returns the registered providers that keep
+// per-tenant state on the backend.
+func (cp *CtrlPlane) tenantIsolators() []provider.TenantIsolator {
+	var isolators []provider.TenantIsolator
+
+	for _, p := range cp.providers.All() {
+		if iso, ok := provider.Unwrap(p).(provider.TenantIsolator); ok {
+			isolators = append(isolators, iso)
+		}
+	}
+
+	return isolators
+}
+
+// setTenantQuotaResolvers points every tenant isolator at the store's
+// tenant quotas.
+func (cp *CtrlPlane) setTenantQuotaResolvers() {
+	for _, iso := range cp.tenantIsolators() {
+		iso.SetTenantQuotaResolver(tenantQuotaAdapter{store: cp.store})
+	}
+}
+
+// setInstanceResolvers points every label- or service-aware layer of
+// every registered provider at the instance store.
+func (cp *CtrlPlane) setInstanceResolvers() {
+	for _, p := range cp.providers.All() {
+		for {
+			if aware, ok := p.(provider.InstanceLabelAware); ok {
+				aware.SetInstanceLabelResolver(instanceAdapter{store: cp.store})
+			}
+
+			if aware, ok := p.(provider.InstanceServiceAware); ok {
+				aware.SetInstanceServiceResolver(instanceAdapter{store: cp.store})
+			}
+
+			w, ok := p.(interface{ Unwrap() provider.Provider })
+			if !ok {
+				break
+			}
+
+			p = w.Unwrap()
+		}
+	}
+}
+
+// deleteProviderTenant removes a deleted tenant's backend state from
+// every tenant isolator.
+func (cp *CtrlPlane) deleteProviderTenant(ctx context.Context, ev *event.Event) error {
+	if ev.TenantID == "" {
+		return nil
+	}
+
+	var errs []error
+
+	for _, iso := range cp.tenantIsolators() {
+		if err := iso.DeleteTenant(ctx, ev.TenantID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// instanceAdapter serves instance labels and services to providers
+// and their wrappers, which don't import instance. The tenant comes
+// from the call's claims; a call without one can't find the instance.
+type instanceAdapter struct {
+	store instance.Store
+}
+
+func (a instanceAdapter) InstanceLabels(ctx context.Context, instanceID id.ID) (map[string]string, error) {
+	inst, err := a.get(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return inst.Labels, nil
+}
+
+func (a instanceAdapter) InstanceServices(ctx context.Context, instanceID id.ID) ([]provider.ServiceSpec, error) {
+	inst, err := a.get(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return inst.Services, nil
+}
+
+func (a instanceAdapter) get(ctx context.Context, instanceID id.ID) (*instance.Instance, error) {
+	claims := auth.ClaimsFrom(ctx)
+	if claims == nil || claims.TenantID == "" {
+		return nil, fmt.Errorf("%w: instance %s: no tenant in context", ctrlplane.ErrNotFound, instanceID)
+	}
+
+	return a.store.GetByID(ctx, claims.TenantID, instanceID)
+}
+
+// tenantQuotaAdapter serves admin tenant quotas to providers, which
+// don't import admin. A tenant that no longer exists has no quota.
+type tenantQuotaAdapter struct {
+	store admin.Store
+}
+
+func (a tenantQuotaAdapter) TenantQuota(ctx context.Context, tenantID string) (*provider.TenantQuota, error) {
+	t, err := a.store.GetTenant(ctx, tenantID)
+	if errors.Is(err, ctrlplane.ErrNotFound) {
+		return nil, nil //nolint:nilnil // no tenant, no quota
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.TenantQuota{
+		MaxInstances: t.Quota.MaxInstances,
+		MaxCPUMillis: t.Quota.MaxCPUMillis,
+		MaxMemoryMB:  t.Quota.MaxMemoryMB,
+		MaxDiskMB:    t.Quota.MaxDiskMB,
+	}, nil
+}
+
 // providerHealthAdapter bridges providerhealth.Cache to admin's
 // ProviderHealthGetter shape. The two packages have intentionally
 // disjoint Status types (admin doesn't import providerhealth); the
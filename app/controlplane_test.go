@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/admin"
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/store/memory"
+)
+
+// isolatorProvider records the tenant isolation calls it receives.
+type isolatorProvider struct {
+	provider.Provider
+
+	resolver provider.TenantQuotaResolver
+	deleted  []string
+}
+
+func (p *isolatorProvider) SetTenantQuotaResolver(r provider.TenantQuotaResolver) {
+	p.resolver = r
+}
+
+func (p *isolatorProvider) DeleteTenant(_ context.Context, tenantID string) error {
+	p.deleted = append(p.deleted, tenantID)
+
+	return nil
+}
+
+// TestTenantIsolation_Wiring verifies a tenant isolator behind the
+// provider middleware gets the admin quota resolver and is told when a
+// tenant is deleted.
+func TestTenantIsolation_Wiring(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st := memory.New()
+	iso := &isolatorProvider{}
+
+	cp, err := New(WithStore(st), WithProvider("k8s", iso))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if iso.resolver == nil {
+		t.Fatal("quota resolver not set")
+	}
+
+	tenant := &admin.Tenant{
+		Entity: ctrlplane.NewEntity(id.PrefixTenant),
+		Slug:   "acme",
+		Quota:  admin.Quota{MaxInstances: 3, MaxCPUMillis: 1500, MaxDomains: 2},
+	}
+	if err := st.InsertTenant(ctx, tenant); err != nil {
+		t.Fatalf("InsertTenant: %v", err)
+	}
+
+	q, err := iso.resolver.TenantQuota(ctx, tenant.ID.String())
+	if err != nil {
+		t.Fatalf("TenantQuota: %v", err)
+	}
+
+	if q == nil || q.MaxInstances != 3 || q.MaxCPUMillis != 1500 {
+		t.Errorf("quota = %+v, want 3 instances and 1500m CPU", q)
+	}
+
+	if q, err := iso.resolver.TenantQuota(ctx, "ten_missing"); err != nil || q != nil {
+		t.Errorf("missing tenant quota = %+v, %v; want nil, nil", q, err)
+	}
+
+	if err := cp.Events().Publish(ctx, event.NewEvent(event.TenantDeleted, "ten_gone")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if len(iso.deleted) != 1 || iso.deleted[0] != "ten_gone" {
+		t.Errorf("deleted tenants = %v, want [ten_gone]", iso.deleted)
+	}
+}
+
+// labelAwareWrapper is a provider wrapper that records the label
+// resolver it's given.
+type labelAwareWrapper struct {
+	provider.Provider
+
+	resolver provider.InstanceLabelResolver
+}
+
+func (w *labelAwareWrapper) Unwrap() provider.Provider { return w.Provider }
+
+func (w *labelAwareWrapper) SetInstanceLabelResolver(r provider.InstanceLabelResolver) {
+	w.resolver = r
+}
+
+// TestInstanceLabelResolver_Wiring verifies a label-aware wrapper under
+// the provider middleware gets a resolver that reads instance labels
+// from the store within the caller's tenant.
+func TestInstanceLabelResolver_Wiring(t *testing.T) {
+	t.Parallel()
+
+	st := memory.New()
+	wrapper := &labelAwareWrapper{}
+
+	_, err := New(WithStore(st), WithProvider("fake", &isolatorProvider{}, func(p provider.Provider) provider.Provider {
+		wrapper.Provider = p
+
+		return wrapper
+	}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if wrapper.resolver == nil {
+		t.Fatal("label resolver not set")
+	}
+
+	inst := &instance.Instance{
+		Entity:   ctrlplane.NewEntity(id.PrefixInstance),
+		TenantID: "ten_1",
+		Name:     "web",
+		Labels:   map[string]string{"chaos": "on"},
+	}
+	if err := st.Insert(context.Background(), inst); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	ctx := auth.WithClaims(context.Background(), &auth.Claims{TenantID: "ten_1"})
+
+	labels, err := wrapper.resolver.InstanceLabels(ctx, inst.ID)
+	if err != nil || labels["chaos"] != "on" {
+		t.Fatalf("InstanceLabels = %v, %v; want chaos=on", labels, err)
+	}
+
+	if _, err := wrapper.resolver.InstanceLabels(context.Background(), inst.ID); !errors.Is(err, ctrlplane.ErrNotFound) {
+		t.Errorf("without a tenant: err = %v, want ErrNotFound", err)
+	}
+}
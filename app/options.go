@@ -1,6 +1,8 @@
 package app
 
 import (
+	gu "github.com/xraph/go-utils/metrics"
+
 	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/auth"
 	"github.com/xraph/ctrlplane/bootstrap"
@@ -41,15 +43,47 @@ func WithAuth(p auth.Provider) Option {
 	}
 }
 
-// WithProvider registers a named infrastructure provider.
-func WithProvider(name string, p provider.Provider) Option {
+// ProviderWrapper decorates a provider before it is registered, e.g.
+// faultinject.Injector.Wrap for chaos testing.
+type ProviderWrapper func(provider.Provider) provider.Provider
+
+// WithProvider registers a named infrastructure provider. Wrappers are
+// applied in order, so the last one is outermost and sees every call
+// first.
+func WithProvider(name string, p provider.Provider, wrappers ...ProviderWrapper) Option {
 	return func(cp *CtrlPlane) error {
+		for _, wrap := range wrappers {
+			p = wrap(p)
+		}
+
 		cp.providers.Register(name, p)
 
 		return nil
 	}
 }
 
+// WithProviderMiddleware adds middleware to every registered
+// provider's call chain. It runs inside the built-in timeout, retry
+// and circuit-breaker middleware, closest to the provider, in the
+// order given.
+func WithProviderMiddleware(mws ...provider.Middleware) Option {
+	return func(cp *CtrlPlane) error {
+		cp.providerMiddleware = append(cp.providerMiddleware, mws...)
+
+		return nil
+	}
+}
+
+// WithProviderMetrics sets where provider call metrics are recorded.
+// Defaults to a private go-utils collector.
+func WithProviderMetrics(factory gu.MetricFactory) Option {
+	return func(cp *CtrlPlane) error {
+		cp.providerMetrics = factory
+
+		return nil
+	}
+}
+
 // WithEventBus replaces the default in-memory event bus.
 func WithEventBus(b event.Bus) Option {
 	return func(cp *CtrlPlane) error {
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// namedProvider is a provider stand-in; only its identity matters.
+type namedProvider struct {
+	provider.Provider
+
+	name  string
+	inner provider.Provider
+}
+
+// TestWithProvider_AppliesWrappersInOrder verifies wrappers decorate
+// the provider before registration, the last one outermost.
+func TestWithProvider_AppliesWrappersInOrder(t *testing.T) {
+	t.Parallel()
+
+	wrapAs := func(name string) ProviderWrapper {
+		return func(p provider.Provider) provider.Provider {
+			return &namedProvider{name: name, inner: p}
+		}
+	}
+
+	base := &namedProvider{name: "base"}
+	cp := &CtrlPlane{providers: provider.NewRegistry()}
+
+	if err := WithProvider("fake", base, wrapAs("first"), wrapAs("second"))(cp); err != nil {
+		t.Fatalf("WithProvider: %v", err)
+	}
+
+	got, err := cp.Providers().Get("fake")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	outer, _ := got.(*namedProvider)
+	if outer == nil || outer.name != "second" {
+		t.Fatalf("outermost = %+v, want second", got)
+	}
+
+	inner, _ := outer.inner.(*namedProvider)
+	if inner == nil || inner.name != "first" || inner.inner != base {
+		t.Fatalf("chain = %+v, want second(first(base))", outer.inner)
+	}
+}
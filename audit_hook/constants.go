@@ -51,6 +51,7 @@ const (
 	ActionDeploySucceeded  = "ctrlplane.deploy.succeeded"
 	ActionDeployFailed     = "ctrlplane.deploy.failed"
 	ActionDeployRolledBack = "ctrlplane.deploy.rolled_back"
+	ActionDeployCancelled  = "ctrlplane.deploy.cancelled"
 )
 
 // Health action constants.
@@ -37,6 +37,7 @@ var (
 	_ plugin.DeploySucceeded     = (*Extension)(nil)
 	_ plugin.DeployFailed        = (*Extension)(nil)
 	_ plugin.DeployRolledBack    = (*Extension)(nil)
+	_ plugin.DeployCancelled     = (*Extension)(nil)
 	_ plugin.HealthCheckPassed   = (*Extension)(nil)
 	_ plugin.HealthCheckFailed   = (*Extension)(nil)
 	_ plugin.HealthDegraded      = (*Extension)(nil)
@@ -248,6 +249,11 @@ func (e *Extension) OnDeployRolledBack(ctx context.Context, evt *event.Event) er
 		ResourceDeployment, CategoryDeploy, evt)
 }
 
+func (e *Extension) OnDeployCancelled(ctx context.Context, evt *event.Event) error {
+	return e.recordEvent(ctx, ActionDeployCancelled, SeverityWarning, OutcomeSuccess,
+		ResourceDeployment, CategoryDeploy, evt)
+}
+
 // ──────────────────────────────────────────────────
 // Health hooks
 // ──────────────────────────────────────────────────
@@ -21,6 +21,7 @@ import (
 	"github.com/xraph/ctrlplane/dashboard/widgets"
 	"github.com/xraph/ctrlplane/datacenter"
 	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/dispatch"
 	"github.com/xraph/ctrlplane/event"
 	"github.com/xraph/ctrlplane/health"
 	"github.com/xraph/ctrlplane/id"
@@ -268,6 +269,15 @@ func (c *Contributor) renderInstanceDetail(ctx context.Context, params contribut
 
 	// Load tab-specific data.
 	switch tab {
+	case "info":
+		// Best-effort live status for the replica/autoscaling rows —
+		// an unreachable provider just leaves them at the stored spec.
+		if p, provErr := c.cp.Providers().Get(inst.ProviderName); provErr == nil {
+			if st, stErr := dispatch.Status(ctx, p, inst.Source.Type, inst.ID); stErr == nil {
+				data.Status = st
+			}
+		}
+
 	case "deploys":
 		deploys, listErr := c.cp.Deploys.ListDeployments(ctx, instanceID, deploy.ListOptions{Limit: 20})
 		if listErr != nil {
@@ -452,7 +462,9 @@ func (c *Contributor) renderDeploymentDetail(ctx context.Context, params contrib
 
 	// Handle cancel action.
 	if action := params.QueryParams["action"]; action == "cancel" {
-		if cancelErr := c.cp.Deploys.Cancel(ctx, deployID); cancelErr != nil {
+		opts := deploy.CancelOptions{Rollback: params.QueryParams["rollback"] == "true"}
+
+		if cancelErr := c.cp.Deploys.Cancel(ctx, deployID, opts); cancelErr != nil {
 			return nil, fmt.Errorf("dashboard: cancel deployment: %w", cancelErr)
 		}
 	}
@@ -877,12 +889,14 @@ func (c *Contributor) renderDeployCreate(ctx context.Context, params contributor
 				Notes:     params.FormData["notes"],
 			}
 
-			_, deployErr := c.cp.Deploys.Deploy(ctx, req)
+			// The deployment is only queued; send the operator to it
+			// to follow the rollout.
+			dep, deployErr := c.cp.Deploys.Deploy(ctx, req)
 			if deployErr != nil {
 				data.Error = deployErr.Error()
 			} else {
-				data.Success = "Deployment created successfully"
-				data.RedirectURL = "./deployments"
+				data.Success = "Deployment queued"
+				data.RedirectURL = "./deployments/detail?deployment_id=" + dep.ID.String()
 			}
 		}
 
@@ -1149,6 +1163,7 @@ func (c *Contributor) renderEvents(ctx context.Context, params contributor.Param
 		eventTypes = []event.Type{
 			event.DeployStarted, event.DeploySucceeded,
 			event.DeployFailed, event.DeployRolledBack,
+			event.DeployCancelled,
 		}
 	case "health":
 		eventTypes = []event.Type{
@@ -137,6 +137,61 @@ func compactResourceSummary(r provider.ResourceSpec) string {
 	return strings.Join(parts, " / ")
 }
 
+// replicaSummary describes the Main service's replica count. With a
+// live status it reads like "2 of 3 ready, scaling to 4"; without one
+// it falls back to the stored spec.
+func replicaSummary(r provider.ResourceSpec, st *provider.InstanceStatus) string {
+	if st == nil || (st.Replicas == 0 && st.DesiredReplicas == 0) {
+		return strconv.Itoa(r.Replicas)
+	}
+
+	out := fmt.Sprintf("%d of %d ready", st.ReadyReplicas, st.Replicas)
+	if st.DesiredReplicas > 0 && st.DesiredReplicas != st.Replicas {
+		out += fmt.Sprintf(", scaling to %d", st.DesiredReplicas)
+	}
+
+	return out
+}
+
+// autoscaleSummary describes the Main service's autoscaling bounds and
+// targets, e.g. "2–8 replicas · CPU 60%", followed by the autoscaler's
+// observed utilization when the live status carries it. Returns ""
+// when autoscaling is off.
+func autoscaleSummary(r provider.ResourceSpec, st *provider.InstanceStatus) string {
+	a := r.Autoscale
+	if !a.Enabled() {
+		return ""
+	}
+
+	parts := []string{fmt.Sprintf("%d–%d replicas", a.MinReplicas, a.MaxReplicas)}
+
+	if a.TargetCPUPercent > 0 {
+		parts = append(parts, fmt.Sprintf("CPU %d%%", a.TargetCPUPercent))
+	}
+
+	if a.TargetMemoryPercent > 0 {
+		parts = append(parts, fmt.Sprintf("memory %d%%", a.TargetMemoryPercent))
+	}
+
+	if st == nil || st.Autoscale == nil {
+		return strings.Join(parts, " · ")
+	}
+
+	if cpu := st.Autoscale.CPUPercent; cpu != nil {
+		parts = append(parts, fmt.Sprintf("CPU at %d%%", *cpu))
+	}
+
+	if mem := st.Autoscale.MemoryPercent; mem != nil {
+		parts = append(parts, fmt.Sprintf("memory at %d%%", *mem))
+	}
+
+	if t := st.Autoscale.LastScaleTime; t != nil {
+		parts = append(parts, "last scaled "+t.Format("Jan 02 15:04"))
+	}
+
+	return strings.Join(parts, " · ")
+}
+
 // --- JSON Serializer Helpers ---
 
 // envToJSON converts an env map to a JSON string for the hidden form field.
@@ -7,6 +7,7 @@ import (
 	"github.com/xraph/ctrlplane/health"
 	"github.com/xraph/ctrlplane/instance"
 	"github.com/xraph/ctrlplane/network"
+	"github.com/xraph/ctrlplane/provider"
 	"github.com/xraph/ctrlplane/secrets"
 	"github.com/xraph/ctrlplane/telemetry"
 	"github.com/xraph/ctrlplane/template"
@@ -27,6 +28,10 @@ type InstanceDetailData struct {
 	Secrets            []secrets.Secret
 	TelemetryDashboard *telemetry.DashboardData
 
+	// Status is the provider's live view of the instance, loaded for
+	// the info tab. Nil when the provider couldn't be reached.
+	Status *provider.InstanceStatus
+
 	// ParentWorkload is set when this instance is a replica of a
 	// workload (i.e., it carries the ctrlplane.workload label).
 	// Nil for standalone instances. Surfaced as a "Part of …"
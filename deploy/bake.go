@@ -0,0 +1,240 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/health"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/metrics"
+)
+
+// DefaultBakeWindow is how long a release is watched after its
+// deployment succeeds when BakeConfig.Window is unset.
+const DefaultBakeWindow = 10 * time.Minute
+
+// MinBakeWindow is the shortest bake window accepted. Anything shorter
+// is a unit mistake rather than a window.
+const MinBakeWindow = time.Second
+
+// BakeConfig keeps watching a release after its deployment succeeds.
+// For the window the instance's health check results and metric
+// samples are held to the thresholds; the first breach rolls the
+// instance back to the previous release and marks the deployment
+// DeployRolledBack. A check fails when it reports unhealthy, or
+// degraded DegradedAfter times in a row.
+type BakeConfig struct {
+	// Window is how long the release is watched, at least
+	// MinBakeWindow. Zero means DefaultBakeWindow.
+	Window Duration `json:"window,omitempty"`
+
+	// MaxErrorRate is the highest error rate (0–1) a metric sample may
+	// report. Zero leaves metrics unwatched.
+	MaxErrorRate float64 `json:"max_error_rate,omitempty"`
+
+	// MaxFailingChecks is the number of health checks allowed to fail
+	// at once, so the zero value rolls back on any.
+	MaxFailingChecks int `json:"max_failing_checks,omitempty"`
+
+	// DegradedAfter is the number of degraded results in a row after
+	// which a check counts as failing. Zero never counts degraded
+	// results against the release.
+	DegradedAfter int `json:"degraded_after,omitempty"`
+}
+
+// Duration returns how long the release is watched.
+func (c *BakeConfig) Duration() time.Duration {
+	if c == nil || c.Window <= 0 {
+		return DefaultBakeWindow
+	}
+
+	return c.Window.Std()
+}
+
+// Validate checks the window and thresholds. A nil config is valid.
+func (c *BakeConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.Window < 0 || (c.Window > 0 && c.Window.Std() < MinBakeWindow) {
+		return fmt.Errorf("%w: bake window %s is under the %s minimum", ctrlplane.ErrInvalidConfig, c.Window, MinBakeWindow)
+	}
+
+	if c.MaxErrorRate < 0 || c.MaxErrorRate > 1 || c.MaxFailingChecks < 0 || c.DegradedAfter < 0 {
+		return fmt.Errorf("%w: bake thresholds must be non-negative, error rate at most 1", ctrlplane.ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// bakeSignal is the regression a bake rolls back on.
+type bakeSignal struct {
+	source string // "health" or "metrics"
+	reason string
+}
+
+// regression is a deployment being rolled back by its bake, and why.
+type regression struct {
+	dep    *Deployment
+	signal *bakeSignal
+}
+
+// SetBakeAnalysis sets the health and metrics services a baking
+// release is watched through. A nil service leaves its signal out;
+// with neither, a bake only waits out its window.
+func (s *service) SetBakeAnalysis(h health.Service, m metrics.Service) {
+	s.health = h
+	s.metrics = m
+}
+
+// bake watches dep's release for what is left of its bake window and
+// rolls back on the first regression. Like runJob it reports whether
+// the deployment got to its final state: false when ctx ended first,
+// so the job's next claim watches the rest of the window.
+func (s *service) bake(ctx context.Context, dep *Deployment) bool {
+	left := time.Until(dep.FinishedAt.Add(dep.Bake.Duration()))
+	if left <= 0 {
+		return true
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, left)
+	defer cancel()
+
+	sig := s.watchRelease(watchCtx, dep)
+	if sig == nil {
+		return ctx.Err() == nil
+	}
+
+	return s.rollbackRegression(ctx, dep, sig)
+}
+
+// watchRelease follows the instance's health results and metric
+// samples until ctx ends, returning the first breach of dep's bake
+// thresholds. A watch that can't be opened leaves its signal out, and
+// is recorded on the deployment so a bake that watched nothing doesn't
+// pass for a clean one.
+func (s *service) watchRelease(ctx context.Context, dep *Deployment) *bakeSignal {
+	cfg := dep.Bake
+
+	var unwatched []string
+
+	var results <-chan *health.HealthResult
+	if s.health != nil {
+		var err error
+		if results, err = s.health.Watch(ctx, dep.InstanceID); err != nil {
+			unwatched = append(unwatched, fmt.Sprintf("health watch failed: %v", err))
+		}
+	}
+
+	var samples <-chan metrics.Sample
+	if s.metrics != nil && cfg.MaxErrorRate > 0 {
+		var err error
+		if samples, err = s.metrics.Watch(ctx, dep.InstanceID); err != nil {
+			unwatched = append(unwatched, fmt.Sprintf("metrics watch failed: %v", err))
+		}
+	}
+
+	if len(unwatched) > 0 {
+		dep.Error = "bake: " + strings.Join(unwatched, "; ")
+		_ = s.saveDeployment(context.WithoutCancel(ctx), dep)
+	}
+
+	// failing holds the last message of every check whose latest
+	// result failed; degraded counts each check's degraded results
+	// in a row.
+	failing := make(map[id.ID]string)
+	degraded := make(map[id.ID]int)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case r, ok := <-results:
+			if !ok {
+				results = nil
+
+				continue
+			}
+
+			switch r.Status {
+			case health.StatusUnhealthy:
+				delete(degraded, r.CheckID)
+			case health.StatusDegraded:
+				degraded[r.CheckID]++
+				if cfg.DegradedAfter == 0 || degraded[r.CheckID] < cfg.DegradedAfter {
+					delete(failing, r.CheckID)
+
+					continue
+				}
+			default:
+				delete(failing, r.CheckID)
+				delete(degraded, r.CheckID)
+
+				continue
+			}
+
+			failing[r.CheckID] = r.Message
+
+			if len(failing) > cfg.MaxFailingChecks {
+				return &bakeSignal{
+					source: "health",
+					reason: fmt.Sprintf("%d failing health checks, %d allowed; check %s is %s: %s", len(failing), cfg.MaxFailingChecks, r.CheckID, r.Status, r.Message),
+				}
+			}
+		case smp, ok := <-samples:
+			if !ok {
+				samples = nil
+
+				continue
+			}
+
+			// Samples without traffic carry no application signal.
+			if smp.RequestsPerSec > 0 && smp.ErrorRate > cfg.MaxErrorRate {
+				return &bakeSignal{
+					source: "metrics",
+					reason: fmt.Sprintf("error rate %.4f above %.4f", smp.ErrorRate, cfg.MaxErrorRate),
+				}
+			}
+		}
+	}
+}
+
+// rollbackRegression queues the rollback of the instance to the
+// release dep replaced. A release that has since been replaced itself
+// is left to its successor. A rollback that can't be queued is kept in
+// dep.Error; the rollback's runner records how a queued one went. The
+// writes outlive ctx.
+func (s *service) rollbackRegression(ctx context.Context, dep *Deployment, sig *bakeSignal) bool {
+	ctx = context.WithoutCancel(ctx)
+
+	rel, err := s.store.GetRelease(ctx, dep.TenantID, dep.ReleaseID)
+	if err != nil {
+		return false
+	}
+
+	next, err := s.store.NextReleaseVersion(ctx, dep.TenantID, dep.InstanceID)
+	if err != nil {
+		return false
+	}
+
+	if next > rel.Version+1 {
+		return true
+	}
+
+	prev, err := s.previousRelease(ctx, dep.TenantID, dep.InstanceID, dep.ReleaseID)
+	if err == nil {
+		_, err = s.rollback(ctx, dep.InstanceID, prev.ID, &regression{dep: dep, signal: sig})
+	}
+
+	if err != nil {
+		dep.Error = fmt.Sprintf("bake: %s; rollback failed: %v", sig.reason, err)
+
+		return s.saveDeployment(ctx, dep) == nil
+	}
+
+	return true
+}
@@ -0,0 +1,432 @@
+package deploy_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/health"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/metrics"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/store/memory"
+)
+
+// watchHealth is a health.Service whose Watch hands out results, or
+// fails with err when set.
+type watchHealth struct {
+	health.Service
+
+	results chan *health.HealthResult
+	err     error
+}
+
+func (f *watchHealth) Watch(context.Context, id.ID) (<-chan *health.HealthResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return f.results, nil
+}
+
+// watchMetrics is a metrics.Service whose Watch hands out samples.
+type watchMetrics struct {
+	metrics.Service
+
+	samples chan metrics.Sample
+}
+
+func (f *watchMetrics) Watch(context.Context, id.ID) (<-chan metrics.Sample, error) {
+	return f.samples, nil
+}
+
+// bakeFixture is a deploy service with stepStrategy registered and
+// bake analysis wired to watchHealth and watchMetrics, over an instance
+// whose v1 release runs myapp:1.0, and the deploy.rolled_back events
+// it publishes.
+type bakeFixture struct {
+	store   *memory.Store
+	inst    *instance.Instance
+	health  *watchHealth
+	metrics *watchMetrics
+	runner  *deploy.Runner
+	svc     deploy.Service
+
+	mu         sync.Mutex
+	rolledBack []*event.Event
+}
+
+func newBakeFixture(t *testing.T) *bakeFixture {
+	t.Helper()
+
+	ctx := adminCtxDeploy()
+	f := &bakeFixture{
+		store:   memory.New(),
+		health:  &watchHealth{results: make(chan *health.HealthResult, 1)},
+		metrics: &watchMetrics{samples: make(chan metrics.Sample, 1)},
+	}
+
+	f.inst = &instance.Instance{
+		Entity:       ctrlplane.NewEntity(id.PrefixInstance),
+		TenantID:     "ten_test",
+		Name:         "web-1",
+		ProviderName: "fake",
+		State:        provider.StateRunning,
+		Services:     []provider.ServiceSpec{{Name: "main", Image: "myapp:1.0", Role: provider.RoleMain}},
+	}
+	if err := f.store.Insert(ctx, f.inst); err != nil {
+		t.Fatalf("insert instance: %v", err)
+	}
+
+	providers := provider.NewRegistry()
+	providers.Register("fake", &rollbackerProvider{})
+
+	bus := event.NewInMemoryBus()
+	bus.Subscribe(func(_ context.Context, evt *event.Event) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.rolledBack = append(f.rolledBack, evt)
+
+		return nil
+	}, event.DeployRolledBack)
+
+	svc := deploy.NewService(f.store, f.store, providers, bus, &auth.NoopProvider{}, nil)
+	svc.RegisterStrategy(&stepStrategy{})
+	svc.SetBakeAnalysis(f.health, f.metrics)
+
+	f.svc = svc
+	f.runner = svc.Runner(deploy.RunnerConfig{Lease: time.Minute})
+
+	if _, err := f.svc.RecordInitial(ctx, f.inst.ID); err != nil {
+		t.Fatalf("RecordInitial: %v", err)
+	}
+
+	return f
+}
+
+// deploy queues myapp:2.0 with bake and runs it to success.
+func (f *bakeFixture) deploy(t *testing.T, bake *deploy.BakeConfig) *deploy.Deployment {
+	t.Helper()
+
+	ctx := adminCtxDeploy()
+
+	dep, err := f.svc.Deploy(ctx, deploy.DeployRequest{
+		InstanceID: f.inst.ID,
+		Strategy:   "step",
+		Services:   []provider.ServiceDeploySpec{{Name: "main", Image: "myapp:2.0"}},
+		Bake:       bake,
+	})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	if err := f.runner.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if got := awaitDeployment(t, f.store, dep.ID); got.State != deploy.DeploySucceeded {
+		t.Fatalf("deployment: want succeeded, got %s (error %q)", got.State, got.Error)
+	}
+
+	return dep
+}
+
+func (f *bakeFixture) events() []*event.Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.rolledBack)
+}
+
+// awaitRolledBack polls the store until dep is rolled back, running
+// the runner meanwhile so it claims the rollback the bake queues.
+func (f *bakeFixture) awaitRolledBack(t *testing.T, dep *deploy.Deployment) *deploy.Deployment {
+	t.Helper()
+
+	ctx := adminCtxDeploy()
+
+	var got *deploy.Deployment
+
+	for range 200 {
+		if err := f.runner.Run(ctx); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+
+		var err error
+
+		got, err = f.store.GetDeployment(ctx, "ten_test", dep.ID)
+		if err != nil {
+			t.Fatalf("get deployment: %v", err)
+		}
+
+		if got.State == deploy.DeployRolledBack {
+			return got
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("deployment: want rolled back, still %s (error %q)", got.State, got.Error)
+
+	return nil
+}
+
+// TestBake_RollsBackOnRegression verifies a release that fails a
+// health check or spikes its error rate during its bake window is
+// rolled back to the previous release, its deployment marked rolled
+// back, and deploy.rolled_back published with the triggering signal.
+func TestBake_RollsBackOnRegression(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		signal  func(f *bakeFixture, dep *deploy.Deployment)
+		trigger string
+	}{
+		{
+			name: "failing health check",
+			signal: func(f *bakeFixture, dep *deploy.Deployment) {
+				f.health.results <- &health.HealthResult{
+					CheckID:    id.New(id.PrefixHealthCheck),
+					InstanceID: dep.InstanceID,
+					Status:     health.StatusUnhealthy,
+					Message:    "connection refused",
+				}
+			},
+			trigger: "health",
+		},
+		{
+			name: "error rate spike",
+			signal: func(f *bakeFixture, _ *deploy.Deployment) {
+				f.metrics.samples <- metrics.Sample{RequestsPerSec: 40, ErrorRate: 0.3}
+			},
+			trigger: "metrics",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newBakeFixture(t)
+			dep := f.deploy(t, &deploy.BakeConfig{Window: deploy.Duration(time.Minute), MaxErrorRate: 0.05})
+
+			tt.signal(f, dep)
+
+			got := f.awaitRolledBack(t, dep)
+			if got.Error == "" {
+				t.Error("rolled back deployment: want the signal in its error")
+			}
+
+			evts := f.events()
+			if len(evts) != 1 {
+				t.Fatalf("deploy.rolled_back events = %d, want 1", len(evts))
+			}
+
+			p := evts[0].Payload
+			if p["trigger"] != tt.trigger || p["rolled_back_deployment_id"] != dep.ID.String() || p["reason"] == "" {
+				t.Fatalf("deploy.rolled_back payload = %v", p)
+			}
+
+			rel, err := f.store.GetRelease(context.Background(), "ten_test", dep.ReleaseID)
+			if err != nil {
+				t.Fatalf("GetRelease: %v", err)
+			}
+
+			if p["release_id"] == rel.ID.String() {
+				t.Fatalf("rolled back to the baking release %s", rel.ID)
+			}
+		})
+	}
+}
+
+// TestBake_DegradedResults verifies degraded health results only roll
+// a release back once one check has reported degraded DegradedAfter
+// times in a row, and never when DegradedAfter is zero. Runs that
+// must not roll back on health end with an error-rate spike, so the
+// rollback's trigger tells which signal got there first.
+func TestBake_DegradedResults(t *testing.T) {
+	t.Parallel()
+
+	const (
+		degraded = health.StatusDegraded
+		healthy  = health.StatusHealthy
+	)
+
+	tests := []struct {
+		name          string
+		degradedAfter int
+		results       []health.Status
+		trigger       string
+	}{
+		{name: "ignored by default", results: []health.Status{degraded, degraded, degraded}, trigger: "metrics"},
+		{name: "sustained", degradedAfter: 3, results: []health.Status{degraded, degraded, degraded}, trigger: "health"},
+		{name: "interrupted", degradedAfter: 3, results: []health.Status{degraded, degraded, healthy, degraded, degraded}, trigger: "metrics"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newBakeFixture(t)
+			dep := f.deploy(t, &deploy.BakeConfig{
+				Window:        deploy.Duration(time.Minute),
+				MaxErrorRate:  0.05,
+				DegradedAfter: tt.degradedAfter,
+			})
+
+			checkID := id.New(id.PrefixHealthCheck)
+			for _, st := range tt.results {
+				f.health.results <- &health.HealthResult{CheckID: checkID, InstanceID: dep.InstanceID, Status: st, Message: "slow"}
+			}
+
+			if tt.trigger == "metrics" {
+				f.metrics.samples <- metrics.Sample{RequestsPerSec: 40, ErrorRate: 0.3}
+			}
+
+			f.awaitRolledBack(t, dep)
+
+			evts := f.events()
+			if len(evts) != 1 || evts[0].Payload["trigger"] != tt.trigger {
+				t.Fatalf("deploy.rolled_back events = %v, want one triggered by %s", evts, tt.trigger)
+			}
+		})
+	}
+}
+
+// jobHeld reports whether the deployment still has a job, by trying
+// to queue another one for it.
+func jobHeld(t *testing.T, store *memory.Store, dep *deploy.Deployment) bool {
+	t.Helper()
+
+	probe := &deploy.Job{DeploymentID: dep.ID, TenantID: dep.TenantID, CreatedAt: time.Now().UTC()}
+
+	err := store.EnqueueJob(context.Background(), probe)
+	if errors.Is(err, ctrlplane.ErrAlreadyExists) {
+		return true
+	}
+
+	if err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+
+	_ = store.DeleteJob(context.Background(), dep.ID)
+
+	return false
+}
+
+// TestBake_CleanWindowKeepsRelease verifies a release that stays
+// within its thresholds keeps its job through the bake window, and its
+// deployment stays succeeded once the job is gone.
+func TestBake_CleanWindowKeepsRelease(t *testing.T) {
+	t.Parallel()
+
+	f := newBakeFixture(t)
+	dep := f.deploy(t, &deploy.BakeConfig{Window: deploy.Duration(deploy.MinBakeWindow), MaxErrorRate: 0.05})
+
+	if !jobHeld(t, f.store, dep) {
+		t.Fatal("job removed before the bake window was over")
+	}
+
+	f.health.results <- &health.HealthResult{CheckID: id.New(id.PrefixHealthCheck), Status: health.StatusHealthy}
+	f.metrics.samples <- metrics.Sample{RequestsPerSec: 40, ErrorRate: 0.01}
+
+	held := true
+	for i := 0; held && i < 300; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		held = jobHeld(t, f.store, dep)
+	}
+
+	if held {
+		t.Fatal("job still held after the bake window")
+	}
+
+	got, err := f.store.GetDeployment(context.Background(), "ten_test", dep.ID)
+	if err != nil {
+		t.Fatalf("GetDeployment: %v", err)
+	}
+
+	if got.State != deploy.DeploySucceeded || len(f.events()) != 0 {
+		t.Fatalf("deployment: want succeeded and no rollback, got %s after %d rollbacks", got.State, len(f.events()))
+	}
+}
+
+// TestBake_RecordsFailedWatch verifies a health watch that can't be
+// opened is recorded on the baking deployment, while the metrics watch
+// still rolls back a regression.
+func TestBake_RecordsFailedWatch(t *testing.T) {
+	t.Parallel()
+
+	f := newBakeFixture(t)
+	f.health.err = errors.New("health store unavailable")
+
+	dep := f.deploy(t, &deploy.BakeConfig{Window: deploy.Duration(time.Minute), MaxErrorRate: 0.05})
+
+	var got *deploy.Deployment
+
+	for range 200 {
+		var err error
+
+		got, err = f.store.GetDeployment(context.Background(), "ten_test", dep.ID)
+		if err != nil {
+			t.Fatalf("GetDeployment: %v", err)
+		}
+
+		if got.Error != "" {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if want := "bake: health watch failed: health store unavailable"; got.Error != want {
+		t.Fatalf("deployment error: want %q, got %q", want, got.Error)
+	}
+
+	if got.State != deploy.DeploySucceeded {
+		t.Fatalf("deployment: want succeeded while baking, got %s", got.State)
+	}
+
+	f.metrics.samples <- metrics.Sample{RequestsPerSec: 40, ErrorRate: 0.5}
+
+	f.awaitRolledBack(t, dep)
+}
+
+func TestBakeConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     *deploy.BakeConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"default window", &deploy.BakeConfig{MaxErrorRate: 0.05}, false},
+		{"minimum window", &deploy.BakeConfig{Window: deploy.Duration(deploy.MinBakeWindow)}, false},
+		{"nanoseconds", &deploy.BakeConfig{Window: 900}, true},
+		{"negative window", &deploy.BakeConfig{Window: deploy.Duration(-time.Minute)}, true},
+		{"error rate over 1", &deploy.BakeConfig{MaxErrorRate: 2}, true},
+		{"negative degraded count", &deploy.BakeConfig{DegradedAfter: -1}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.cfg.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		if err != nil && !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", tt.name, err)
+		}
+	}
+}
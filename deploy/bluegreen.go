@@ -0,0 +1,43 @@
+package deploy
+
+import (
+	"fmt"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// DefaultBakePeriod is how long blue is kept after the cutover when
+// BlueGreenConfig.BakePeriod is unset.
+const DefaultBakePeriod = 5 * time.Minute
+
+// MinBakePeriod is the shortest bake period accepted; anything shorter
+// leaves no time to switch back to blue.
+const MinBakePeriod = 10 * time.Second
+
+// BlueGreenConfig tunes a blue-green rollout. After traffic flips to
+// green, blue stays up for BakePeriod so a failing green can be
+// switched back instantly; only then is blue retired.
+type BlueGreenConfig struct {
+	// BakePeriod is how long blue is kept after the cutover, at least
+	// MinBakePeriod. Zero means DefaultBakePeriod.
+	BakePeriod Duration `json:"bake_period,omitempty"`
+}
+
+// Bake returns how long blue is kept after the cutover.
+func (c *BlueGreenConfig) Bake() time.Duration {
+	if c == nil || c.BakePeriod <= 0 {
+		return DefaultBakePeriod
+	}
+
+	return c.BakePeriod.Std()
+}
+
+// Validate checks the bake period. A nil config is valid.
+func (c *BlueGreenConfig) Validate() error {
+	if c != nil && (c.BakePeriod < 0 || (c.BakePeriod > 0 && c.BakePeriod.Std() < MinBakePeriod)) {
+		return fmt.Errorf("%w: blue-green bake period %s is under the %s minimum", ctrlplane.ErrInvalidConfig, c.BakePeriod, MinBakePeriod)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package deploy_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/deploy"
+)
+
+func TestBlueGreenConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     *deploy.BlueGreenConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"default", &deploy.BlueGreenConfig{}, false},
+		{"minimum", &deploy.BlueGreenConfig{BakePeriod: deploy.Duration(deploy.MinBakePeriod)}, false},
+		{"under minimum", &deploy.BlueGreenConfig{BakePeriod: deploy.Duration(time.Second)}, true},
+		{"nanoseconds", &deploy.BlueGreenConfig{BakePeriod: 600}, true},
+		{"negative", &deploy.BlueGreenConfig{BakePeriod: deploy.Duration(-time.Minute)}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.cfg.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		if err != nil && !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", tt.name, err)
+		}
+	}
+
+	if got := (&deploy.BlueGreenConfig{}).Bake(); got != deploy.DefaultBakePeriod {
+		t.Errorf("default bake = %s", got)
+	}
+}
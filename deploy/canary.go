@@ -0,0 +1,108 @@
+package deploy
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// DefaultCanaryPause is how long a canary step bakes before it is
+// analysed when CanaryConfig.Pause is unset.
+const DefaultCanaryPause = time.Minute
+
+// MinCanaryPause is the shortest step pause accepted: the metrics poll
+// interval, so every step is analysed on at least one sample.
+const MinCanaryPause = 10 * time.Second
+
+// minLatencyThreshold is the lowest p95 latency threshold accepted.
+// Anything below a millisecond is a unit mistake, not a target.
+const minLatencyThreshold = time.Millisecond
+
+// defaultCanarySteps is the traffic schedule used when
+// CanaryConfig.Steps is empty.
+var defaultCanarySteps = []int{5, 25, 50, 100}
+
+// CanaryConfig drives a traffic-weighted canary rollout. The new
+// release runs beside the live one and receives Steps[i] percent of
+// each route's traffic in turn; every step bakes for Pause and must
+// pass Analysis before the next. A breach restores all traffic to the
+// live release and fails the deployment.
+type CanaryConfig struct {
+	// Steps are the canary's traffic percentages, strictly ascending
+	// within 1–100. Empty means 5, 25, 50, 100. The release is
+	// promoted after the last step passes, whether or not it is 100.
+	Steps []int `json:"steps,omitempty"`
+
+	// Pause is how long each step bakes, at least MinCanaryPause. Zero
+	// means DefaultCanaryPause.
+	Pause Duration `json:"pause,omitempty"`
+
+	Analysis CanaryAnalysis `json:"analysis"`
+}
+
+// CanaryAnalysis holds the thresholds a canary step is held to. The
+// metric thresholds are disabled at zero and pass when the window has
+// no samples; MaxFailingChecks is the number of failing health checks
+// tolerated, so the zero value aborts on any.
+type CanaryAnalysis struct {
+	// MaxErrorRate is the highest mean error rate (0–1) over the step.
+	MaxErrorRate float64 `json:"max_error_rate,omitempty"`
+
+	// MaxLatencyP95 is the highest p95 latency seen during the step,
+	// at least a millisecond.
+	MaxLatencyP95 Duration `json:"max_latency_p95,omitempty"`
+
+	MaxFailingChecks int `json:"max_failing_checks,omitempty"`
+}
+
+// StepWeights returns the canary's traffic schedule.
+func (c *CanaryConfig) StepWeights() []int {
+	if len(c.Steps) == 0 {
+		return slices.Clone(defaultCanarySteps)
+	}
+
+	return c.Steps
+}
+
+// StepPause returns how long each step bakes.
+func (c *CanaryConfig) StepPause() time.Duration {
+	if c.Pause <= 0 {
+		return DefaultCanaryPause
+	}
+
+	return c.Pause.Std()
+}
+
+// Validate checks the schedule and thresholds. A nil config is valid.
+func (c *CanaryConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	prev := 0
+
+	for _, w := range c.Steps {
+		if w <= prev || w > 100 {
+			return fmt.Errorf("%w: canary steps must ascend within 1-100, got %v", ctrlplane.ErrInvalidConfig, c.Steps)
+		}
+
+		prev = w
+	}
+
+	if c.Pause < 0 || (c.Pause > 0 && c.Pause.Std() < MinCanaryPause) {
+		return fmt.Errorf("%w: canary pause %s is under the %s minimum", ctrlplane.ErrInvalidConfig, c.Pause, MinCanaryPause)
+	}
+
+	a := c.Analysis
+	if a.MaxLatencyP95 < 0 || (a.MaxLatencyP95 > 0 && a.MaxLatencyP95.Std() < minLatencyThreshold) {
+		return fmt.Errorf("%w: canary p95 latency threshold %s is under %s", ctrlplane.ErrInvalidConfig, a.MaxLatencyP95, minLatencyThreshold)
+	}
+
+	if a.MaxErrorRate < 0 || a.MaxErrorRate > 1 || a.MaxFailingChecks < 0 {
+		return fmt.Errorf("%w: canary thresholds must be non-negative, error rate at most 1", ctrlplane.ErrInvalidConfig)
+	}
+
+	return nil
+}
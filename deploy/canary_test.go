@@ -0,0 +1,56 @@
+package deploy_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/deploy"
+)
+
+func TestCanaryConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     *deploy.CanaryConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"defaults", &deploy.CanaryConfig{}, false},
+		{"full", &deploy.CanaryConfig{
+			Steps:    []int{1, 10, 100},
+			Pause:    deploy.Duration(30 * time.Second),
+			Analysis: deploy.CanaryAnalysis{MaxErrorRate: 0.01, MaxLatencyP95: deploy.Duration(time.Second), MaxFailingChecks: 1},
+		}, false},
+		{"descending", &deploy.CanaryConfig{Steps: []int{50, 25}}, true},
+		{"repeated", &deploy.CanaryConfig{Steps: []int{25, 25}}, true},
+		{"over 100", &deploy.CanaryConfig{Steps: []int{50, 150}}, true},
+		{"zero step", &deploy.CanaryConfig{Steps: []int{0, 50}}, true},
+		{"error rate over 1", &deploy.CanaryConfig{Analysis: deploy.CanaryAnalysis{MaxErrorRate: 5}}, true},
+		{"negative pause", &deploy.CanaryConfig{Pause: deploy.Duration(-time.Second)}, true},
+		{"pause under minimum", &deploy.CanaryConfig{Pause: deploy.Duration(time.Second)}, true},
+		{"latency in nanoseconds", &deploy.CanaryConfig{Analysis: deploy.CanaryAnalysis{MaxLatencyP95: 200}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.cfg.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		if err != nil && !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", tt.name, err)
+		}
+	}
+
+	cfg := &deploy.CanaryConfig{}
+	if got := cfg.StepWeights(); len(got) != 4 || got[len(got)-1] != 100 {
+		t.Errorf("default steps = %v", got)
+	}
+
+	if cfg.StepPause() != deploy.DefaultCanaryPause {
+		t.Errorf("default pause = %s", cfg.StepPause())
+	}
+}
@@ -0,0 +1,210 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// CancelOptions tunes Cancel.
+type CancelOptions struct {
+	// Rollback redeploys the previous release to the services the
+	// deployment had already started updating. Services it never
+	// reached, and services the previous release didn't run, are left
+	// as they are.
+	Rollback bool `json:"rollback,omitempty"`
+}
+
+// cancelRequest is the cause a running deployment's context is
+// cancelled with when Cancel stops it.
+type cancelRequest struct {
+	rollback bool
+	by       string
+}
+
+func (c *cancelRequest) Error() string {
+	return "deployment cancelled"
+}
+
+// track registers the cancel func of a deployment running in this
+// process, so Cancel can interrupt its strategy.
+func (s *service) track(deploymentID id.ID, cancel context.CancelCauseFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running[deploymentID] = cancel
+}
+
+// untrack removes a deployment registered by track.
+func (s *service) untrack(deploymentID id.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.running, deploymentID)
+}
+
+// interrupt cancels a deployment running in this process and reports
+// whether there was one.
+func (s *service) interrupt(deploymentID id.ID, req *cancelRequest) bool {
+	s.mu.Lock()
+	cancel, ok := s.running[deploymentID]
+	s.mu.Unlock()
+
+	if ok {
+		cancel(req)
+	}
+
+	return ok
+}
+
+// cancelled records that dep was cancelled and publishes it. When req
+// asks for a rollback, the services dep already reached get the
+// previous release back first; a failed rollback is kept in
+// dep.Error. Like finish, the writes outlive ctx and it reports
+// whether the outcome was stored.
+func (s *service) cancelled(ctx context.Context, dep *Deployment, req *cancelRequest) bool {
+	ctx = context.WithoutCancel(ctx)
+
+	payload := map[string]any{
+		"deployment_id": dep.ID.String(),
+		"rollback":      req.rollback,
+	}
+
+	if req.rollback {
+		reverted, err := s.revertStarted(ctx, dep)
+		if err != nil {
+			dep.Error = fmt.Sprintf("rollback after cancel: %v", err)
+			payload["error"] = dep.Error
+		}
+
+		payload["services_reverted"] = reverted
+	}
+
+	finished := time.Now().UTC()
+	dep.State = DeployCancelled
+	dep.FinishedAt = &finished
+
+	if err := s.saveDeployment(ctx, dep); err != nil {
+		return false
+	}
+
+	_ = s.events.Publish(ctx, event.NewEvent(event.DeployCancelled, dep.TenantID).
+		WithInstance(dep.InstanceID).
+		WithActor(req.by).
+		WithPayload(payload))
+
+	return true
+}
+
+// revertStarted redeploys the previous release's snapshot of every
+// service dep moved past pending, and returns their names.
+func (s *service) revertStarted(ctx context.Context, dep *Deployment) ([]string, error) {
+	prev, err := s.previousRelease(ctx, dep.TenantID, dep.InstanceID, dep.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []provider.ServiceSnapshot
+
+	for _, sd := range dep.Services {
+		if state := dep.ServiceProgress[sd.Name]; state == "" || state == ServiceStatePending {
+			continue
+		}
+
+		if i := slices.IndexFunc(prev.Services, func(p provider.ServiceSnapshot) bool { return p.Name == sd.Name }); i >= 0 {
+			snaps = append(snaps, prev.Services[i])
+		}
+	}
+
+	if len(snaps) == 0 {
+		return nil, nil
+	}
+
+	inst, err := s.instStore.GetByID(ctx, dep.TenantID, dep.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("get instance %s: %w", dep.InstanceID, err)
+	}
+
+	prov, err := s.providers.Get(inst.ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("get provider %s: %w", inst.ProviderName, err)
+	}
+
+	snaps, err = s.loadConfigFiles(ctx, snaps)
+	if err != nil {
+		return nil, fmt.Errorf("load config files: %w", err)
+	}
+
+	snaps, err = s.resolveSnapshotRegistryAuth(ctx, inst, snaps)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = prov.Deploy(ctx, provider.DeployRequest{
+		InstanceID: dep.InstanceID,
+		ReleaseID:  prev.ID,
+		Services:   snapshotSpecs(snaps),
+		Strategy:   "recreate",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redeploy release v%d: %w", prev.Version, err)
+	}
+
+	names := make([]string, len(snaps))
+	for i := range snaps {
+		names[i] = snaps[i].Name
+	}
+
+	return names, nil
+}
+
+// previousRelease returns the release releaseID replaced: the newest
+// of the instance's active releases older than it.
+func (s *service) previousRelease(ctx context.Context, tenantID string, instanceID, releaseID id.ID) (*Release, error) {
+	cur, err := s.store.GetRelease(ctx, tenantID, releaseID)
+	if err != nil {
+		return nil, fmt.Errorf("get release %s: %w", releaseID, err)
+	}
+
+	list, err := s.store.ListReleases(ctx, tenantID, instanceID, ListOptions{Limit: 100})
+	if err != nil {
+		return nil, fmt.Errorf("list releases: %w", err)
+	}
+
+	var prev *Release
+
+	for _, r := range list.Items {
+		if r.Active && r.Version < cur.Version && (prev == nil || r.Version > prev.Version) {
+			prev = r
+		}
+	}
+
+	if prev == nil {
+		return nil, fmt.Errorf("release v%d has no earlier release to roll back to", cur.Version)
+	}
+
+	return prev, nil
+}
+
+// snapshotSpecs turns release snapshots into the specs a provider
+// deploys.
+func snapshotSpecs(snaps []provider.ServiceSnapshot) []provider.ServiceDeploySpec {
+	specs := make([]provider.ServiceDeploySpec, len(snaps))
+
+	for i, snap := range snaps {
+		specs[i] = provider.ServiceDeploySpec{
+			Name:         snap.Name,
+			Image:        snap.Image,
+			Env:          snap.Env,
+			ConfigFiles:  snap.ConfigFiles,
+			RegistryAuth: snap.RegistryAuth,
+		}
+	}
+
+	return specs
+}
@@ -0,0 +1,312 @@
+package deploy_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/store/memory"
+)
+
+// blockStrategy marks every service running, closes started and holds
+// until its context is cancelled.
+type blockStrategy struct {
+	started chan struct{}
+	runs    int
+}
+
+func (s *blockStrategy) Name() string { return "block" }
+
+func (s *blockStrategy) Execute(ctx context.Context, params deploy.StrategyParams) error {
+	s.runs++
+
+	for _, sd := range params.Deployment.Services {
+		params.OnServiceProgress(sd.Name, deploy.ServiceStateRunning)
+	}
+
+	close(s.started)
+	<-ctx.Done()
+
+	return context.Cause(ctx)
+}
+
+// deployRecorder is a rollbackerProvider that keeps every Deploy
+// request, for a test to read while the runner writes.
+type deployRecorder struct {
+	rollbackerProvider
+
+	mu   sync.Mutex
+	reqs []provider.DeployRequest
+}
+
+func (f *deployRecorder) Deploy(_ context.Context, req provider.DeployRequest) (*provider.DeployResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reqs = append(f.reqs, req)
+
+	return &provider.DeployResult{}, nil
+}
+
+func (f *deployRecorder) requests() []provider.DeployRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.reqs)
+}
+
+// cancelFixture is a deploy service with blockStrategy registered, over
+// an instance whose v1 release runs myapp:1.0, and the deploy.cancelled
+// events it publishes.
+type cancelFixture struct {
+	store    *memory.Store
+	inst     *instance.Instance
+	prov     *deployRecorder
+	strategy *blockStrategy
+	runner   *deploy.Runner
+	svc      deploy.Service
+
+	mu        sync.Mutex
+	cancelled []*event.Event
+}
+
+func newCancelFixture(t *testing.T) *cancelFixture {
+	t.Helper()
+
+	ctx := adminCtxDeploy()
+	f := &cancelFixture{
+		store:    memory.New(),
+		prov:     &deployRecorder{},
+		strategy: &blockStrategy{started: make(chan struct{})},
+	}
+
+	f.inst = &instance.Instance{
+		Entity:       ctrlplane.NewEntity(id.PrefixInstance),
+		TenantID:     "ten_test",
+		Name:         "web-1",
+		ProviderName: "fake",
+		State:        provider.StateRunning,
+		Services:     []provider.ServiceSpec{{Name: "main", Image: "myapp:1.0", Role: provider.RoleMain}},
+	}
+	if err := f.store.Insert(ctx, f.inst); err != nil {
+		t.Fatalf("insert instance: %v", err)
+	}
+
+	providers := provider.NewRegistry()
+	providers.Register("fake", f.prov)
+
+	bus := event.NewInMemoryBus()
+	bus.Subscribe(func(_ context.Context, evt *event.Event) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.cancelled = append(f.cancelled, evt)
+
+		return nil
+	}, event.DeployCancelled)
+
+	svc := deploy.NewService(f.store, f.store, providers, bus, &auth.NoopProvider{}, nil)
+	svc.RegisterStrategy(f.strategy)
+
+	f.svc = svc
+	f.runner = svc.Runner(deploy.RunnerConfig{Lease: time.Minute})
+
+	if _, err := f.svc.RecordInitial(ctx, f.inst.ID); err != nil {
+		t.Fatalf("RecordInitial: %v", err)
+	}
+
+	return f
+}
+
+func (f *cancelFixture) deploy(t *testing.T) *deploy.Deployment {
+	t.Helper()
+
+	dep, err := f.svc.Deploy(adminCtxDeploy(), deploy.DeployRequest{
+		InstanceID: f.inst.ID,
+		Strategy:   "block",
+		Services:   []provider.ServiceDeploySpec{{Name: "main", Image: "myapp:2.0"}},
+	})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	return dep
+}
+
+func (f *cancelFixture) events() []*event.Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.cancelled)
+}
+
+// TestCancel_InterruptsRunningDeployment verifies Cancel stops a
+// running strategy, redeploys the previous release to the services it
+// had reached when asked to roll back, and publishes deploy.cancelled.
+func TestCancel_InterruptsRunningDeployment(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	f := newCancelFixture(t)
+	dep := f.deploy(t)
+
+	if err := f.runner.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	select {
+	case <-f.strategy.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("strategy never started")
+	}
+
+	if err := f.svc.Cancel(ctx, dep.ID, deploy.CancelOptions{Rollback: true}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	got := awaitDeployment(t, f.store, dep.ID)
+	if got.State != deploy.DeployCancelled || got.FinishedAt == nil || got.Error != "" {
+		t.Fatalf("deployment: state=%s finished=%v error=%q", got.State, got.FinishedAt, got.Error)
+	}
+
+	reqs := f.prov.requests()
+	if len(reqs) != 1 || reqs[0].Services[0].Image != "myapp:1.0" || reqs[0].ReleaseID == dep.ReleaseID {
+		t.Fatalf("rollback deploys = %+v, want one of the previous release", reqs)
+	}
+
+	evts := f.events()
+	if len(evts) != 1 || evts[0].ActorID != "test-user" {
+		t.Fatalf("deploy.cancelled events = %+v", evts)
+	}
+
+	if reverted, _ := evts[0].Payload["services_reverted"].([]string); !slices.Equal(reverted, []string{"main"}) {
+		t.Errorf("services_reverted = %v, want [main]", evts[0].Payload["services_reverted"])
+	}
+}
+
+// TestCancel_PendingDeploymentNeverRuns verifies a deployment cancelled
+// before a runner claims it is recorded cancelled without its strategy
+// running or the provider being called.
+func TestCancel_PendingDeploymentNeverRuns(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	f := newCancelFixture(t)
+	dep := f.deploy(t)
+
+	if err := f.svc.Cancel(ctx, dep.ID, deploy.CancelOptions{Rollback: true}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if err := f.runner.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := awaitDeployment(t, f.store, dep.ID)
+	if got.State != deploy.DeployCancelled {
+		t.Fatalf("deployment: want cancelled, got %s (error %q)", got.State, got.Error)
+	}
+
+	if f.strategy.runs != 0 || len(f.prov.requests()) != 0 {
+		t.Fatalf("strategy ran %d times, provider got %d deploys", f.strategy.runs, len(f.prov.requests()))
+	}
+
+	if len(f.events()) != 1 {
+		t.Fatalf("deploy.cancelled events = %d, want 1", len(f.events()))
+	}
+}
+
+// blockingRollbacker is a rollbackerProvider whose RollbackRelease
+// closes started and holds until its context ends.
+type blockingRollbacker struct {
+	rollbackerProvider
+
+	started chan struct{}
+}
+
+func (f *blockingRollbacker) RollbackRelease(ctx context.Context, _ provider.RollbackRequest) (*provider.DeployResult, error) {
+	close(f.started)
+	<-ctx.Done()
+
+	return nil, ctx.Err()
+}
+
+// TestCancel_InterruptsRollback verifies Cancel stops a running
+// rollback, which records it cancelled rather than succeeded, and
+// refuses to roll a rollback back.
+func TestCancel_InterruptsRollback(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	prov := &blockingRollbacker{started: make(chan struct{})}
+	store, inst, rel, runner, svc := newRollbackFixture(t, prov)
+
+	rb, err := svc.Rollback(ctx, inst.ID, rel.ID)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if err := runner.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	select {
+	case <-prov.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("rollback never started")
+	}
+
+	if err := svc.Cancel(ctx, rb.ID, deploy.CancelOptions{Rollback: true}); !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Fatalf("Cancel with rollback: want ErrInvalidConfig, got %v", err)
+	}
+
+	if err := svc.Cancel(ctx, rb.ID, deploy.CancelOptions{}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if got := awaitDeployment(t, store, rb.ID); got.State != deploy.DeployCancelled {
+		t.Fatalf("rollback deployment: want cancelled, got %s (error %q)", got.State, got.Error)
+	}
+}
+
+// TestCancel_DeploymentWithoutJob verifies a deployment left running
+// without a job, which nothing will ever finish, is cancelled at once.
+func TestCancel_DeploymentWithoutJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	f := newCancelFixture(t)
+	dep := f.deploy(t)
+
+	if err := f.store.DeleteJob(ctx, dep.ID); err != nil {
+		t.Fatalf("DeleteJob: %v", err)
+	}
+
+	dep.State = deploy.DeployRunning
+	if err := f.store.UpdateDeployment(ctx, dep); err != nil {
+		t.Fatalf("UpdateDeployment: %v", err)
+	}
+
+	if err := f.svc.Cancel(ctx, dep.ID, deploy.CancelOptions{}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	got, err := f.store.GetDeployment(ctx, "ten_test", dep.ID)
+	if err != nil {
+		t.Fatalf("GetDeployment: %v", err)
+	}
+
+	if got.State != deploy.DeployCancelled || len(f.events()) != 1 {
+		t.Fatalf("deployment: want cancelled with one event, got %s and %d", got.State, len(f.events()))
+	}
+}
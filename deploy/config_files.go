@@ -0,0 +1,160 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// errNoVault is returned when a deploy carries config files but the
+// service has no vault to version them in.
+var errNoVault = errors.New("config files need a vault; configure one with SetVault")
+
+// configFileVaultKey returns the vault key a config file's content is
+// stored under. The Release ID is part of the key, so each Release's
+// copy is immutable and a rollback mounts exactly what that Release
+// ran. Same `<tenant>/<instance>/...` layout as secrets.
+func configFileVaultKey(tenantID string, instanceID, releaseID id.ID, serviceName, fileName string) string {
+	return fmt.Sprintf("%s/%s/config/%s/%s/%s", tenantID, instanceID, releaseID, serviceName, fileName)
+}
+
+// storeConfigFiles writes every config file in services to the vault
+// under releaseID and returns a copy of services with each file's
+// VaultKey and Checksum filled in. The caller's slice is not modified.
+func (s *service) storeConfigFiles(ctx context.Context, tenantID string, instanceID, releaseID id.ID, services []provider.ServiceDeploySpec) ([]provider.ServiceDeploySpec, error) {
+	out := slices.Clone(services)
+
+	for i := range out {
+		if len(out[i].ConfigFiles) == 0 {
+			continue
+		}
+
+		files, err := s.storeServiceConfigFiles(ctx, tenantID, instanceID, releaseID, out[i].Name, out[i].ConfigFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i].ConfigFiles = files
+	}
+
+	return out, nil
+}
+
+// storeServiceConfigFiles writes one service's config files to the
+// vault and returns copies carrying their VaultKey and Checksum.
+func (s *service) storeServiceConfigFiles(ctx context.Context, tenantID string, instanceID, releaseID id.ID, serviceName string, files []provider.ConfigFile) ([]provider.ConfigFile, error) {
+	if s.vault == nil {
+		return nil, fmt.Errorf("service %q: %w", serviceName, errNoVault)
+	}
+
+	out := slices.Clone(files)
+
+	for i := range out {
+		key := configFileVaultKey(tenantID, instanceID, releaseID, serviceName, out[i].Name)
+
+		if err := s.vault.Store(ctx, key, []byte(out[i].Content)); err != nil {
+			return nil, fmt.Errorf("service %q: store config file %q: %w", serviceName, out[i].Name, err)
+		}
+
+		out[i].VaultKey = key
+		out[i].Checksum = provider.ConfigFileChecksum(out[i].Content)
+	}
+
+	return out, nil
+}
+
+// loadConfigFiles returns a copy of snaps with every config file's
+// Content read back from the vault, ready to hand to a provider.
+// Files recorded without a vault key (nothing was stored) are dropped.
+func (s *service) loadConfigFiles(ctx context.Context, snaps []provider.ServiceSnapshot) ([]provider.ServiceSnapshot, error) {
+	out := slices.Clone(snaps)
+
+	for i := range out {
+		files, err := s.loadServiceConfigFiles(ctx, out[i].Name, out[i].ConfigFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i].ConfigFiles = files
+	}
+
+	return out, nil
+}
+
+// loadSpecConfigFiles is loadConfigFiles for a deployment's specs.
+func (s *service) loadSpecConfigFiles(ctx context.Context, specs []provider.ServiceDeploySpec) ([]provider.ServiceDeploySpec, error) {
+	out := slices.Clone(specs)
+
+	for i := range out {
+		files, err := s.loadServiceConfigFiles(ctx, out[i].Name, out[i].ConfigFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i].ConfigFiles = files
+	}
+
+	return out, nil
+}
+
+// loadServiceConfigFiles reads one service's config files back from
+// the vault.
+func (s *service) loadServiceConfigFiles(ctx context.Context, serviceName string, refs []provider.ConfigFile) ([]provider.ConfigFile, error) {
+	if len(refs) == 0 {
+		return refs, nil
+	}
+
+	if s.vault == nil {
+		return nil, fmt.Errorf("service %q: %w", serviceName, errNoVault)
+	}
+
+	files := make([]provider.ConfigFile, 0, len(refs))
+
+	for _, f := range refs {
+		if f.VaultKey == "" {
+			continue
+		}
+
+		content, err := s.vault.Retrieve(ctx, f.VaultKey)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: retrieve config file %q: %w", serviceName, f.Name, err)
+		}
+
+		f.Content = string(content)
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// configFileRefs strips Content from config files, leaving the
+// references a Release snapshot records.
+func configFileRefs(files []provider.ConfigFile) []provider.ConfigFile {
+	if len(files) == 0 {
+		return nil
+	}
+
+	out := make([]provider.ConfigFile, len(files))
+	for i, f := range files {
+		f.Content = ""
+		out[i] = f
+	}
+
+	return out
+}
+
+// specRefs returns a copy of specs with config file Content stripped,
+// as a Deployment records them: the content lives in the vault only.
+func specRefs(specs []provider.ServiceDeploySpec) []provider.ServiceDeploySpec {
+	out := slices.Clone(specs)
+
+	for i := range out {
+		out[i].ConfigFiles = configFileRefs(out[i].ConfigFiles)
+	}
+
+	return out
+}
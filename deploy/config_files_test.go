@@ -0,0 +1,161 @@
+package deploy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/secrets/memoryvault"
+	"github.com/xraph/ctrlplane/store/memory"
+)
+
+// TestRollback_LoadsConfigFilesFromVault verifies a Release records
+// only vault references for its config files, and that rolling back
+// to it hands the provider the content read back from the vault.
+func TestRollback_LoadsConfigFilesFromVault(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	store := memory.New()
+	vault := memoryvault.New()
+
+	inst := &instance.Instance{
+		Entity:       ctrlplane.NewEntity(id.PrefixInstance),
+		TenantID:     "ten_test",
+		Name:         "web-1",
+		ProviderName: "fake",
+		State:        provider.StateRunning,
+		Services: []provider.ServiceSpec{{
+			Name:        "main",
+			Image:       "myapp:1.0",
+			Role:        provider.RoleMain,
+			ConfigFiles: []provider.ConfigFile{{Name: "app.yaml", Path: "/etc/app/app.yaml", Content: "v: 1"}},
+		}},
+	}
+	if err := store.Insert(ctx, inst); err != nil {
+		t.Fatalf("insert instance: %v", err)
+	}
+
+	fake := &rollbackerProvider{}
+	providers := provider.NewRegistry()
+	providers.Register("fake", fake)
+
+	svc := deploy.NewService(store, store, providers, event.NewInMemoryBus(), &auth.NoopProvider{}, vault)
+
+	rel, err := svc.RecordInitial(ctx, inst.ID)
+	if err != nil {
+		t.Fatalf("RecordInitial: %v", err)
+	}
+
+	ref := rel.Services[0].ConfigFiles[0]
+	if ref.Content != "" || ref.VaultKey == "" || ref.Checksum != provider.ConfigFileChecksum("v: 1") {
+		t.Fatalf("release config file ref: %+v", ref)
+	}
+
+	stored, err := vault.Retrieve(ctx, ref.VaultKey)
+	if err != nil || string(stored) != "v: 1" {
+		t.Fatalf("vault content: %q, %v", stored, err)
+	}
+
+	dep, err := svc.Rollback(ctx, inst.ID, rel.ID)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if err := svc.Runner(deploy.RunnerConfig{Lease: time.Minute}).Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if got := awaitDeployment(t, store, dep.ID); got.State != deploy.DeploySucceeded {
+		t.Fatalf("rollback: want succeeded, got %s (error %q)", got.State, got.Error)
+	}
+
+	files := fake.got.Services[0].ConfigFiles
+	if len(files) != 1 || files[0].Content != "v: 1" || files[0].Path != "/etc/app/app.yaml" {
+		t.Fatalf("rollback config files: %+v", files)
+	}
+}
+
+// providerStrategy hands the deployment's services to the provider in
+// one Deploy call.
+type providerStrategy struct{}
+
+func (providerStrategy) Name() string { return "provider" }
+
+func (providerStrategy) Execute(ctx context.Context, params deploy.StrategyParams) error {
+	_, err := params.Provider.Deploy(ctx, provider.DeployRequest{
+		InstanceID: params.Deployment.InstanceID,
+		ReleaseID:  params.Deployment.ReleaseID,
+		Services:   params.Deployment.Services,
+	})
+
+	return err
+}
+
+// TestDeploy_StoresConfigFileRefsOnly verifies a Deployment, like its
+// Release, records only vault references for its config files, while
+// the strategy still gets the content.
+func TestDeploy_StoresConfigFileRefsOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	store := memory.New()
+
+	inst := &instance.Instance{
+		Entity:       ctrlplane.NewEntity(id.PrefixInstance),
+		TenantID:     "ten_test",
+		Name:         "web-1",
+		ProviderName: "fake",
+		State:        provider.StateRunning,
+		Services:     []provider.ServiceSpec{{Name: "main", Image: "myapp:1.0", Role: provider.RoleMain}},
+	}
+	if err := store.Insert(ctx, inst); err != nil {
+		t.Fatalf("insert instance: %v", err)
+	}
+
+	fake := &deployRecorder{}
+	providers := provider.NewRegistry()
+	providers.Register("fake", fake)
+
+	svc := deploy.NewService(store, store, providers, event.NewInMemoryBus(), &auth.NoopProvider{}, memoryvault.New())
+	svc.RegisterStrategy(providerStrategy{})
+
+	dep, err := svc.Deploy(ctx, deploy.DeployRequest{
+		InstanceID: inst.ID,
+		Strategy:   "provider",
+		Services: []provider.ServiceDeploySpec{{
+			Name:        "main",
+			Image:       "myapp:2.0",
+			ConfigFiles: []provider.ConfigFile{{Name: "app.yaml", Path: "/etc/app/app.yaml", Content: "password: hunter2"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	if err := svc.Runner(deploy.RunnerConfig{Lease: time.Minute}).Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := awaitDeployment(t, store, dep.ID)
+	if got.State != deploy.DeploySucceeded {
+		t.Fatalf("deployment: want succeeded, got %s (error %q)", got.State, got.Error)
+	}
+
+	ref := got.Services[0].ConfigFiles[0]
+	if ref.Content != "" || ref.VaultKey == "" {
+		t.Fatalf("stored deployment config file: %+v", ref)
+	}
+
+	reqs := fake.requests()
+	if len(reqs) != 1 || reqs[0].Services[0].ConfigFiles[0].Content != "password: hunter2" {
+		t.Fatalf("provider deploys = %+v, want the config file content", reqs)
+	}
+}
@@ -24,7 +24,8 @@ const (
 	// DeployFailed indicates the deployment failed.
 	DeployFailed DeployState = "failed"
 
-	// DeployRolledBack indicates the deployment was rolled back.
+	// DeployRolledBack indicates the deployment was rolled back,
+	// automatically when its release regressed during its bake window.
 	DeployRolledBack DeployState = "rolled_back"
 
 	// DeployCancelled indicates the deployment was cancelled.
@@ -36,7 +37,12 @@ const (
 // Services is the per-service slice of the rollout — partial deploys
 // list only the services being changed. ServiceProgress tracks each
 // service's state independently so canary/rolling strategies can
-// report which services have made it through.
+// report which services have made it through. Canary is the traffic
+// schedule of a weighted canary rollout and BlueGreen the settings of
+// a blue-green one; both are nil for every other rollout. Bake keeps
+// the release watched after the deployment succeeds, and is nil when
+// nothing is. Phase and Percent are the strategy's last reported
+// progress.
 type Deployment struct {
 	ctrlplane.Entity
 
@@ -47,9 +53,14 @@ type Deployment struct {
 	Strategy        string                       `db:"strategy"         json:"strategy"`
 	Services        []provider.ServiceDeploySpec `db:"services"         json:"services"`
 	ServiceProgress map[string]string            `db:"service_progress" json:"service_progress,omitempty"`
+	Phase           string                       `db:"phase"            json:"phase,omitempty"`
+	Percent         int                          `db:"percent"          json:"percent"`
 	ProviderRef     string                       `db:"provider_ref"     json:"provider_ref,omitempty"`
 	StartedAt       *time.Time                   `db:"started_at"       json:"started_at,omitempty"`
 	FinishedAt      *time.Time                   `db:"finished_at"      json:"finished_at,omitempty"`
 	Error           string                       `db:"error"            json:"error,omitempty"`
 	Initiator       string                       `db:"initiator"        json:"initiator"`
+	Canary          *CanaryConfig                `db:"canary"           json:"canary,omitempty"`
+	BlueGreen       *BlueGreenConfig             `db:"blue_green"       json:"blue_green,omitempty"`
+	Bake            *BakeConfig                  `db:"bake"             json:"bake,omitempty"`
 }
@@ -0,0 +1,40 @@
+package deploy
+
+import (
+	"fmt"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// Duration is a time.Duration that reads and writes as a Go duration
+// string such as "90s" or "5m", so API clients don't pass integer
+// nanoseconds. A JSON number is refused.
+type Duration time.Duration
+
+// Std returns d as a time.Duration.
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String formats d like time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("%w: duration %q: want a value like \"90s\" or \"5m\"", ctrlplane.ErrInvalidConfig, text)
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package deploy_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/xraph/ctrlplane/deploy"
+)
+
+func TestDuration_JSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: `"90s"`, want: 90 * time.Second},
+		{in: `"5m"`, want: 5 * time.Minute},
+		{in: `"1h30m"`, want: 90 * time.Minute},
+		{in: `300`, wantErr: true},
+		{in: `"5 minutes"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var d deploy.Duration
+
+		err := json.Unmarshal([]byte(tt.in), &d)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Unmarshal err = %v, wantErr %v", tt.in, err, tt.wantErr)
+
+			continue
+		}
+
+		if err == nil && d.Std() != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.in, d, tt.want)
+		}
+	}
+
+	out, err := json.Marshal(deploy.CanaryConfig{Pause: deploy.Duration(2 * time.Minute)})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	if want := `{"pause":"2m0s","analysis":{}}`; string(out) != want {
+		t.Errorf("Marshal = %s, want %s", out, want)
+	}
+}
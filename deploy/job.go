@@ -0,0 +1,54 @@
+package deploy
+
+import (
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+)
+
+// Job is a queued deployment waiting for, or held by, a Runner. Deploy
+// and Rollback enqueue one per Deployment; the runner that finishes
+// the deployment deletes it.
+//
+// A job is claimable while it has no lease or its lease has expired,
+// so a job whose runner died is picked up by another once the lease
+// runs out. Every claim bumps Attempts, which also serves as the
+// fencing token: a renewal only succeeds while Owner and Attempts
+// still match the claim being renewed.
+//
+// CancelRequested is set by Cancel, with the caller and whether they
+// asked for a rollback. The runner holding the job learns of it on its
+// next renewal, and one that claims it later cancels the deployment
+// instead of running it.
+//
+// A rollback's job records the release it restores and whether the
+// provider reverts natively or the release is redeployed with the
+// recreate strategy. A rollback a bake started also records the
+// deployment it rolls back and the regression that set it off.
+type Job struct {
+	DeploymentID    id.ID      `db:"deployment_id"    json:"deployment_id"`
+	TenantID        string     `db:"tenant_id"        json:"tenant_id"`
+	Owner           string     `db:"owner"            json:"owner,omitempty"`
+	Attempts        int        `db:"attempts"         json:"attempts"`
+	LeaseExpiresAt  *time.Time `db:"lease_expires_at" json:"lease_expires_at,omitempty"`
+	CancelRequested bool       `db:"cancel_requested" json:"cancel_requested,omitempty"`
+	CancelRollback  bool       `db:"cancel_rollback"  json:"cancel_rollback,omitempty"`
+	CancelledBy     string     `db:"cancelled_by"     json:"cancelled_by,omitempty"`
+	CreatedAt       time.Time  `db:"created_at"       json:"created_at"`
+
+	RollbackReleaseID id.ID  `db:"rollback_release_id" json:"rollback_release_id,omitzero"`
+	RollbackNative    bool   `db:"rollback_native"     json:"rollback_native,omitempty"`
+	RollbackOf        id.ID  `db:"rollback_of"         json:"rollback_of,omitzero"`
+	RollbackTrigger   string `db:"rollback_trigger"    json:"rollback_trigger,omitempty"`
+	RollbackReason    string `db:"rollback_reason"     json:"rollback_reason,omitempty"`
+}
+
+// IsRollback reports whether the job runs a rollback.
+func (j *Job) IsRollback() bool {
+	return !j.RollbackReleaseID.IsNil()
+}
+
+// Claimable reports whether the job may be claimed at now.
+func (j *Job) Claimable(now time.Time) bool {
+	return j.LeaseExpiresAt == nil || !j.LeaseExpiresAt.After(now)
+}
@@ -0,0 +1,84 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/secrets"
+)
+
+// SetRegistryResolver sets the resolver used to turn a service's
+// registry-type secrets into pull credentials. Without one, providers
+// pull anonymously.
+func (s *service) SetRegistryResolver(r provider.RegistryResolver) {
+	s.registry = r
+}
+
+// serviceSecrets indexes the instance's secret references by service
+// name; deploy specs and snapshots carry only the name.
+func serviceSecrets(inst *instance.Instance) map[string][]provider.SecretRef {
+	refs := make(map[string][]provider.SecretRef, len(inst.Services))
+	for _, svc := range inst.Services {
+		refs[svc.Name] = svc.Secrets
+	}
+
+	return refs
+}
+
+// resolveRegistryAuth returns a copy of specs with each one's
+// RegistryAuth resolved for its (possibly new) image.
+func (s *service) resolveRegistryAuth(ctx context.Context, inst *instance.Instance, specs []provider.ServiceDeploySpec) ([]provider.ServiceDeploySpec, error) {
+	if s.registry == nil {
+		return specs, nil
+	}
+
+	refs := serviceSecrets(inst)
+	out := slices.Clone(specs)
+
+	for i := range out {
+		cred, err := s.resolveImageCredential(ctx, inst.ID, refs[out[i].Name], out[i].Name, out[i].Image)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i].RegistryAuth = cred
+	}
+
+	return out, nil
+}
+
+// resolveSnapshotRegistryAuth is resolveRegistryAuth for a rollback
+// target's snapshots.
+func (s *service) resolveSnapshotRegistryAuth(ctx context.Context, inst *instance.Instance, snaps []provider.ServiceSnapshot) ([]provider.ServiceSnapshot, error) {
+	if s.registry == nil {
+		return snaps, nil
+	}
+
+	refs := serviceSecrets(inst)
+	out := slices.Clone(snaps)
+
+	for i := range out {
+		cred, err := s.resolveImageCredential(ctx, inst.ID, refs[out[i].Name], out[i].Name, out[i].Image)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i].RegistryAuth = cred
+	}
+
+	return out, nil
+}
+
+// resolveImageCredential resolves one service's pull credential.
+func (s *service) resolveImageCredential(ctx context.Context, instanceID id.ID, refs []provider.SecretRef, serviceName, image string) (*secrets.RegistryCredential, error) {
+	cred, err := provider.ResolveRegistryCredential(ctx, s.registry, instanceID, refs, image)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: resolve registry credential: %w", serviceName, err)
+	}
+
+	return cred, nil
+}
@@ -0,0 +1,304 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Rollback reverts to a specific release by queueing a new deployment
+// of it, returned in DeployPending; a Runner executes it. Providers
+// implementing provider.Rollbacker roll back natively from the Release
+// snapshot; all others redeploy it with the recreate strategy.
+func (s *service) Rollback(ctx context.Context, instanceID id.ID, releaseID id.ID) (*Deployment, error) {
+	return s.rollback(ctx, instanceID, releaseID, nil)
+}
+
+// rollback implements Rollback. When a bake triggered it, reg is
+// recorded on the job, so the runner marks reg's deployment
+// DeployRolledBack once the rollback succeeds and the DeployRolledBack
+// event carries what set it off.
+func (s *service) rollback(ctx context.Context, instanceID id.ID, releaseID id.ID, reg *regression) (*Deployment, error) {
+	claims, err := auth.RequireClaims(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: authenticate: %w", err)
+	}
+
+	// Verify the instance exists.
+	inst, err := s.instStore.GetByID(ctx, claims.TenantID, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: get instance %s: %w", instanceID, err)
+	}
+
+	// Retrieve the original release to roll back to.
+	rel, err := s.store.GetRelease(ctx, claims.TenantID, releaseID)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: get release %s: %w", releaseID, err)
+	}
+
+	// The runner reads the config files and credentials again; reading
+	// them here fails a rollback that couldn't run.
+	snaps, err := s.loadConfigFiles(ctx, rel.Services)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: load config files: %w", err)
+	}
+
+	if _, err := s.resolveSnapshotRegistryAuth(ctx, inst, snaps); err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+
+	// Resolve the infrastructure provider.
+	prov, err := s.providers.Get(inst.ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: get provider %s: %w", inst.ProviderName, err)
+	}
+
+	// Providers that can revert natively (revision history, job
+	// versions) do so in one call; everyone else gets the snapshot
+	// redeployed through the recreate strategy.
+	_, native := prov.(provider.Rollbacker)
+
+	strategy := nativeRollbackStrategy
+	if !native {
+		strategy = "recreate"
+
+		if _, ok := s.strategies[strategy]; !ok {
+			return nil, fmt.Errorf("rollback: unknown strategy %q: %w", strategy, ctrlplane.ErrDeploymentFailed)
+		}
+	}
+
+	// Rollback restores every service in the target Release.
+	services := snapshotSpecs(rel.Services)
+
+	progress := make(map[string]string, len(services))
+	for _, sd := range services {
+		progress[sd.Name] = "pending"
+	}
+
+	dep := &Deployment{
+		Entity:          ctrlplane.NewEntity(id.PrefixDeployment),
+		TenantID:        claims.TenantID,
+		InstanceID:      instanceID,
+		ReleaseID:       releaseID,
+		State:           DeployPending,
+		Strategy:        strategy,
+		Services:        services,
+		ServiceProgress: progress,
+		Initiator:       claims.SubjectID,
+	}
+
+	if err := s.store.InsertDeployment(ctx, dep); err != nil {
+		return nil, fmt.Errorf("rollback: insert deployment: %w", err)
+	}
+
+	job := &Job{
+		DeploymentID:      dep.ID,
+		TenantID:          dep.TenantID,
+		CreatedAt:         time.Now().UTC(),
+		RollbackReleaseID: releaseID,
+		RollbackNative:    native,
+	}
+
+	if reg != nil {
+		job.RollbackOf = reg.dep.ID
+		job.RollbackTrigger = reg.signal.source
+		job.RollbackReason = reg.signal.reason
+	}
+
+	if err := s.store.EnqueueJob(ctx, job); err != nil {
+		// Nothing will ever run it; don't leave it pending.
+		s.finish(ctx, dep, fmt.Errorf("enqueue: %w", err))
+
+		return nil, fmt.Errorf("rollback: enqueue: %w", err)
+	}
+
+	// Publish the deploy-started event.
+	_ = s.events.Publish(ctx, event.NewEvent(event.DeployStarted, claims.TenantID).
+		WithInstance(instanceID).
+		WithActor(claims.SubjectID).
+		WithPayload(map[string]any{
+			"deployment_id": dep.ID.String(),
+			"release_id":    releaseID.String(),
+			"rollback":      true,
+		}))
+
+	return dep, nil
+}
+
+// finishJob records dep's outcome the way its job calls for: finish
+// for a deployment, finishRollback for a rollback.
+func (s *service) finishJob(ctx context.Context, job *Job, dep *Deployment, execErr error) bool {
+	if job.IsRollback() {
+		return s.finishRollback(ctx, job, dep, execErr)
+	}
+
+	return s.finish(ctx, dep, execErr)
+}
+
+// cancelledJob is cancelled for the deployment job runs. A cancelled
+// rollback is noted on the deployment whose bake asked for it.
+func (s *service) cancelledJob(ctx context.Context, job *Job, dep *Deployment, req *cancelRequest) bool {
+	if !s.cancelled(ctx, dep, req) {
+		return false
+	}
+
+	return s.noteRollback(ctx, job, false, "rollback cancelled")
+}
+
+// finishRollback records a rollback's outcome. A failed one is
+// finished like any deployment; a successful one publishes
+// DeployRolledBack instead of DeploySucceeded. Either way the
+// deployment whose bake asked for it learns how it went.
+func (s *service) finishRollback(ctx context.Context, job *Job, dep *Deployment, execErr error) bool {
+	ctx = context.WithoutCancel(ctx)
+
+	if execErr != nil {
+		if !s.finish(ctx, dep, execErr) {
+			return false
+		}
+
+		return s.noteRollback(ctx, job, false, fmt.Sprintf("rollback failed: %v", execErr))
+	}
+
+	finished := time.Now().UTC()
+	dep.FinishedAt = &finished
+	dep.State = DeploySucceeded
+
+	if err := s.saveDeployment(ctx, dep); err != nil {
+		return false
+	}
+
+	if !s.noteRollback(ctx, job, true, "") {
+		return false
+	}
+
+	payload := map[string]any{
+		"deployment_id": dep.ID.String(),
+		"release_id":    job.RollbackReleaseID.String(),
+	}
+
+	if !job.RollbackOf.IsNil() {
+		payload["rolled_back_deployment_id"] = job.RollbackOf.String()
+		payload["trigger"] = job.RollbackTrigger
+		payload["reason"] = job.RollbackReason
+	}
+
+	_ = s.events.Publish(ctx, event.NewEvent(event.DeployRolledBack, dep.TenantID).
+		WithInstance(dep.InstanceID).
+		WithActor(dep.Initiator).
+		WithPayload(payload))
+
+	return true
+}
+
+// noteRollback records on the deployment whose bake started job's
+// rollback how the rollback went: DeployRolledBack once it succeeded,
+// and what became of it otherwise. A rollback nobody's bake asked for,
+// or whose deployment is gone, has nothing to note.
+func (s *service) noteRollback(ctx context.Context, job *Job, succeeded bool, outcome string) bool {
+	if job.RollbackOf.IsNil() {
+		return true
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	regressed, err := s.store.GetDeployment(ctx, job.TenantID, job.RollbackOf)
+	if errors.Is(err, ctrlplane.ErrNotFound) {
+		return true
+	}
+
+	if err != nil {
+		return false
+	}
+
+	if succeeded {
+		regressed.State = DeployRolledBack
+		regressed.Error = fmt.Sprintf("rolled back during bake: %s", job.RollbackReason)
+	} else {
+		regressed.Error = fmt.Sprintf("bake: %s; %s", job.RollbackReason, outcome)
+	}
+
+	return s.saveDeployment(ctx, regressed) == nil
+}
+
+// nativeRollbackStrategy is the Deployment.Strategy recorded when the
+// provider reverted the instance itself via provider.Rollbacker.
+const nativeRollbackStrategy = "native-rollback"
+
+// nativeRollback is the strategy a rollback job runs when the provider
+// reverts the instance itself. It hands the target Release's snapshot
+// to the provider as one RollbackRelease call; the provider reverts
+// every service at once, so per-service progress moves in lockstep.
+// It is never registered, so Deploy can't select it.
+type nativeRollback struct{}
+
+// Name returns the strategy identifier.
+func (nativeRollback) Name() string { return nativeRollbackStrategy }
+
+// Resumable reports that an interrupted native rollback can run again:
+// it reverts to the same snapshot.
+func (nativeRollback) Resumable(*Deployment) bool { return true }
+
+// Execute reverts the instance to the deployment's release.
+func (nativeRollback) Execute(ctx context.Context, params StrategyParams) error {
+	dep := params.Deployment
+
+	rb, ok := params.Provider.(provider.Rollbacker)
+	if !ok {
+		return errors.New("native rollback: provider no longer rolls back natively")
+	}
+
+	for _, sd := range dep.Services {
+		params.OnServiceProgress(sd.Name, ServiceStateRunning)
+	}
+
+	res, err := rb.RollbackRelease(ctx, provider.RollbackRequest{
+		InstanceID: dep.InstanceID,
+		ReleaseID:  dep.ReleaseID,
+		Services:   specSnapshots(dep.Services),
+	})
+
+	final := ServiceStateSucceeded
+	if err != nil {
+		final = ServiceStateFailed
+	}
+
+	for _, sd := range dep.Services {
+		params.OnServiceProgress(sd.Name, final)
+	}
+
+	if err != nil {
+		return fmt.Errorf("native rollback: %w", err)
+	}
+
+	if res != nil {
+		dep.ProviderRef = res.ProviderRef
+	}
+
+	return nil
+}
+
+// specSnapshots turns the specs a rollback deploys back into the
+// release snapshots they were made from; see snapshotSpecs.
+func specSnapshots(specs []provider.ServiceDeploySpec) []provider.ServiceSnapshot {
+	snaps := make([]provider.ServiceSnapshot, len(specs))
+
+	for i, sd := range specs {
+		snaps[i] = provider.ServiceSnapshot{
+			Name:         sd.Name,
+			Image:        sd.Image,
+			Env:          sd.Env,
+			ConfigFiles:  sd.ConfigFiles,
+			RegistryAuth: sd.RegistryAuth,
+		}
+	}
+
+	return snaps
+}
@@ -0,0 +1,184 @@
+package deploy_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/store/memory"
+)
+
+// rollbackerProvider is a provider.Provider that also implements
+// provider.Rollbacker and records the request it was handed.
+type rollbackerProvider struct {
+	got     *provider.RollbackRequest
+	deploys int
+}
+
+func (f *rollbackerProvider) Info() provider.ProviderInfo         { return provider.ProviderInfo{Name: "fake"} }
+func (f *rollbackerProvider) Capabilities() []provider.Capability { return nil }
+func (f *rollbackerProvider) Provision(_ context.Context, _ provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+	return &provider.ProvisionResult{}, nil
+}
+func (f *rollbackerProvider) Deprovision(_ context.Context, _ id.ID) error { return nil }
+func (f *rollbackerProvider) Start(_ context.Context, _ id.ID) error       { return nil }
+func (f *rollbackerProvider) Stop(_ context.Context, _ id.ID) error        { return nil }
+func (f *rollbackerProvider) Restart(_ context.Context, _ id.ID) error     { return nil }
+func (f *rollbackerProvider) Status(_ context.Context, _ id.ID) (*provider.InstanceStatus, error) {
+	return &provider.InstanceStatus{}, nil
+}
+func (f *rollbackerProvider) Deploy(_ context.Context, _ provider.DeployRequest) (*provider.DeployResult, error) {
+	f.deploys++
+
+	return &provider.DeployResult{}, nil
+}
+func (f *rollbackerProvider) Rollback(_ context.Context, _ id.ID, _ id.ID) error { return nil }
+func (f *rollbackerProvider) Scale(_ context.Context, _ id.ID, _ provider.ResourceSpec) error {
+	return nil
+}
+func (f *rollbackerProvider) Resources(_ context.Context, _ id.ID) (*provider.ResourceUsage, error) {
+	return &provider.ResourceUsage{}, nil
+}
+func (f *rollbackerProvider) Logs(_ context.Context, _ id.ID, _ provider.LogOptions) (io.ReadCloser, error) {
+	return nil, nil
+}
+func (f *rollbackerProvider) Exec(_ context.Context, _ id.ID, _ provider.ExecRequest) (*provider.ExecResult, error) {
+	return &provider.ExecResult{}, nil
+}
+func (f *rollbackerProvider) RollbackRelease(_ context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	f.got = &req
+
+	return &provider.DeployResult{ProviderRef: "fake:ref", Status: "rolled_back"}, nil
+}
+
+// newRollbackFixture returns a store holding one instance whose v1
+// release runs myapp:1.0, and a deploy service over prov.
+func newRollbackFixture(t *testing.T, prov provider.Provider) (*memory.Store, *instance.Instance, *deploy.Release, *deploy.Runner, deploy.Service) {
+	t.Helper()
+
+	ctx := adminCtxDeploy()
+	store := memory.New()
+
+	inst := &instance.Instance{
+		Entity:       ctrlplane.NewEntity(id.PrefixInstance),
+		TenantID:     "ten_test",
+		Name:         "web-1",
+		ProviderName: "fake",
+		State:        provider.StateRunning,
+		Services: []provider.ServiceSpec{
+			{Name: "main", Image: "myapp:1.0", Role: provider.RoleMain},
+		},
+	}
+	if err := store.Insert(ctx, inst); err != nil {
+		t.Fatalf("insert instance: %v", err)
+	}
+
+	providers := provider.NewRegistry()
+	providers.Register("fake", prov)
+
+	svc := deploy.NewService(store, store, providers, event.NewInMemoryBus(), &auth.NoopProvider{}, nil)
+
+	rel, err := svc.RecordInitial(ctx, inst.ID)
+	if err != nil {
+		t.Fatalf("RecordInitial: %v", err)
+	}
+
+	return store, inst, rel, svc.Runner(deploy.RunnerConfig{Lease: time.Minute}), svc
+}
+
+// TestRollback_UsesNativeProviderRollback verifies Rollback queues the
+// rollback, and a provider that implements provider.Rollbacker gets
+// the target Release's snapshot in a single RollbackRelease call — no
+// strategy, no Deploy calls — with the Deployment recording the
+// native path.
+func TestRollback_UsesNativeProviderRollback(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	fake := &rollbackerProvider{}
+	store, inst, rel, runner, svc := newRollbackFixture(t, fake)
+
+	dep, err := svc.Rollback(ctx, inst.ID, rel.ID)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if dep.State != deploy.DeployPending || fake.got != nil {
+		t.Fatalf("Rollback: want it queued, got state %s", dep.State)
+	}
+
+	if err := runner.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	dep = awaitDeployment(t, store, dep.ID)
+
+	if fake.got == nil {
+		t.Fatal("RollbackRelease was not called")
+	}
+
+	if fake.deploys != 0 {
+		t.Fatalf("Deploy calls: want 0 on the native path, got %d", fake.deploys)
+	}
+
+	if fake.got.ReleaseID != rel.ID || len(fake.got.Services) != 1 || fake.got.Services[0].Image != "myapp:1.0" {
+		t.Fatalf("rollback request: %+v", fake.got)
+	}
+
+	if dep.State != deploy.DeploySucceeded || dep.Strategy != "native-rollback" || dep.ProviderRef != "fake:ref" {
+		t.Fatalf("deployment: state=%s strategy=%s ref=%s", dep.State, dep.Strategy, dep.ProviderRef)
+	}
+
+	if dep.ServiceProgress["main"] != deploy.ServiceStateSucceeded {
+		t.Fatalf("progress: want main succeeded, got %v", dep.ServiceProgress)
+	}
+}
+
+// TestRollback_ResumesAfterRestart verifies a rollback left running by
+// a dead runner is run again by the next one, rather than staying
+// running forever.
+func TestRollback_ResumesAfterRestart(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	fake := &rollbackerProvider{}
+	store, inst, rel, runner, svc := newRollbackFixture(t, fake)
+
+	dep, err := svc.Rollback(ctx, inst.ID, rel.ID)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	// A runner that claimed the rollback, marked it running and died:
+	// its lease has already run out.
+	job, err := store.ClaimJob(ctx, "dead", -time.Second)
+	if err != nil {
+		t.Fatalf("ClaimJob: %v", err)
+	}
+
+	if job.RollbackReleaseID != rel.ID || !job.RollbackNative {
+		t.Fatalf("rollback job: release %s native %v", job.RollbackReleaseID, job.RollbackNative)
+	}
+
+	dep.State = deploy.DeployRunning
+	if err := store.UpdateDeployment(ctx, dep); err != nil {
+		t.Fatalf("UpdateDeployment: %v", err)
+	}
+
+	if err := runner.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := awaitDeployment(t, store, dep.ID)
+	if got.State != deploy.DeploySucceeded || fake.got == nil {
+		t.Fatalf("deployment: want succeeded after a native rollback, got %s (error %q)", got.State, got.Error)
+	}
+}
@@ -0,0 +1,217 @@
+package deploy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// RunnerConfig tunes a Runner. Zero fields take their defaults.
+type RunnerConfig struct {
+	// PollInterval is how often the runner looks for claimable jobs.
+	// Default 2s.
+	PollInterval time.Duration
+
+	// Lease is how long a claim holds before another runner may take
+	// the job over. It is renewed every third of its length while the
+	// deployment runs. Default 30s.
+	Lease time.Duration
+
+	// Concurrency caps how many deployments one runner executes at
+	// once. Deployments watched through their bake window don't count.
+	// Default 4.
+	Concurrency int
+
+	// MaxAttempts is how many claims a job gets. A deployment whose
+	// runners keep dying is failed once it is claimed again past this.
+	// Default 3.
+	MaxAttempts int
+}
+
+// defaultRunnerConfig returns the values a zero RunnerConfig field
+// falls back to.
+func defaultRunnerConfig() RunnerConfig {
+	return RunnerConfig{
+		PollInterval: 2 * time.Second,
+		Lease:        30 * time.Second,
+		Concurrency:  4,
+		MaxAttempts:  3,
+	}
+}
+
+// errLeaseLost cancels a deployment whose job was claimed by another
+// runner, so two runners never drive the same rollout.
+var errLeaseLost = errors.New("deploy: job lease lost")
+
+// Runner executes the deployments Deploy and Rollback queue. It claims
+// jobs from the store with leases, runs each one's strategy and
+// records the outcome, and satisfies worker.Worker so the scheduler
+// polls it.
+//
+// Deployments survive a control-plane restart. A job whose runner died
+// is claimed again once its lease expires, and one whose runner shut
+// down is released at once. A deployment found still running was
+// interrupted mid-rollout: it is run again from the start if its
+// strategy is a Resumer that allows it, and failed otherwise. Either
+// way the outcome depends only on the stored deployment, not on which
+// runner picks it up.
+//
+// A deployment with a BakeConfig keeps its job through the bake
+// window, so a bake interrupted the same way is picked up where it
+// left off and can still roll the release back.
+type Runner struct {
+	svc   *service
+	owner string
+	cfg   RunnerConfig
+	slots chan struct{}
+}
+
+// Runner returns a Runner for the service's queued deployments.
+func (s *service) Runner(cfg RunnerConfig) *Runner {
+	def := defaultRunnerConfig()
+
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = def.PollInterval
+	}
+
+	if cfg.Lease <= 0 {
+		cfg.Lease = def.Lease
+	}
+
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = def.Concurrency
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = def.MaxAttempts
+	}
+
+	return &Runner{
+		svc:   s,
+		owner: runnerOwner(),
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.Concurrency),
+	}
+}
+
+// runnerOwner names this process in the leases it takes.
+func runnerOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Name returns the worker name.
+func (r *Runner) Name() string {
+	return "deploy_runner"
+}
+
+// Interval returns how often the runner polls for jobs.
+func (r *Runner) Interval() time.Duration {
+	return r.cfg.PollInterval
+}
+
+// Run claims jobs until none is claimable or every slot is busy, and
+// starts each in the background. Deployments run on ctx, so stopping
+// the scheduler interrupts them and releases their jobs.
+func (r *Runner) Run(ctx context.Context) error {
+	for {
+		select {
+		case r.slots <- struct{}{}:
+		default:
+			return nil
+		}
+
+		job, err := r.svc.store.ClaimJob(ctx, r.owner, r.cfg.Lease)
+		if err != nil {
+			<-r.slots
+
+			if errors.Is(err, ctrlplane.ErrNotFound) {
+				return nil
+			}
+
+			return fmt.Errorf("deploy runner: claim job: %w", err)
+		}
+
+		go r.execute(ctx, job)
+	}
+}
+
+// execute runs one claimed job while keeping its lease, then deletes
+// the job if the deployment finished and releases it otherwise. A
+// deployment left only baking gives its slot up early.
+func (r *Runner) execute(ctx context.Context, job *Job) {
+	freeSlot := sync.OnceFunc(func() { <-r.slots })
+	defer freeSlot()
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// The lease is held until runJob returns, also while a cancelled
+	// deployment rolls back and while the runner shuts down.
+	leaseCtx, stopLease := context.WithCancel(context.WithoutCancel(ctx))
+	leaseDone := make(chan struct{})
+
+	go func() {
+		defer close(leaseDone)
+
+		r.keepLease(leaseCtx, cancel, *job)
+	}()
+
+	finished := r.svc.runJob(runCtx, job, r.cfg.MaxAttempts, freeSlot)
+
+	stopLease()
+	<-leaseDone
+
+	// The job outlives ctx: it must be settled even while shutting down.
+	storeCtx := context.WithoutCancel(ctx)
+
+	switch {
+	case finished:
+		_ = r.svc.store.DeleteJob(storeCtx, job.DeploymentID)
+	case errors.Is(context.Cause(runCtx), errLeaseLost):
+		// Another runner owns the job now.
+	default:
+		_ = r.svc.store.RenewJob(storeCtx, job, 0)
+	}
+}
+
+// keepLease renews the job's lease until ctx ends, cancelling the
+// deployment with errLeaseLost if the job was claimed away, and with
+// a cancelRequest once Cancel was called for it. A failed renewal is
+// retried on the next tick; the lease outlasts two of them.
+func (r *Runner) keepLease(ctx context.Context, cancel context.CancelCauseFunc, job Job) {
+	t := time.NewTicker(r.cfg.Lease / 3)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			err := r.svc.store.RenewJob(ctx, &job, r.cfg.Lease)
+			if errors.Is(err, ctrlplane.ErrNotFound) {
+				cancel(errLeaseLost)
+
+				return
+			}
+
+			if job.CancelRequested {
+				cancel(&cancelRequest{rollback: job.CancelRollback, by: job.CancelledBy})
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package deploy_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/store/memory"
+)
+
+// stepStrategy reports progress halfway and at the end, and counts its
+// runs. resume is what it answers as a deploy.Resumer.
+type stepStrategy struct {
+	resume bool
+	runs   atomic.Int32
+}
+
+func (s *stepStrategy) Name() string { return "step" }
+
+func (s *stepStrategy) Resumable(*deploy.Deployment) bool { return s.resume }
+
+func (s *stepStrategy) Execute(_ context.Context, params deploy.StrategyParams) error {
+	s.runs.Add(1)
+	params.OnProgress("rolling", 50, "halfway")
+	params.OnProgress("complete", 100, "done")
+
+	return nil
+}
+
+// newRunnerFixture returns a store holding one instance and a deploy
+// service with stepStrategy registered.
+func newRunnerFixture(t *testing.T, st *stepStrategy) (*memory.Store, *instance.Instance, *deploy.Runner, deploy.Service) {
+	t.Helper()
+
+	ctx := adminCtxDeploy()
+	store := memory.New()
+
+	inst := &instance.Instance{
+		Entity:       ctrlplane.NewEntity(id.PrefixInstance),
+		TenantID:     "ten_test",
+		Name:         "web-1",
+		ProviderName: "fake",
+		State:        provider.StateRunning,
+		Services:     []provider.ServiceSpec{{Name: "main", Image: "myapp:1.0", Role: provider.RoleMain}},
+	}
+	if err := store.Insert(ctx, inst); err != nil {
+		t.Fatalf("insert instance: %v", err)
+	}
+
+	providers := provider.NewRegistry()
+	providers.Register("fake", &rollbackerProvider{})
+
+	svc := deploy.NewService(store, store, providers, event.NewInMemoryBus(), &auth.NoopProvider{}, nil)
+	svc.RegisterStrategy(st)
+
+	return store, inst, svc.Runner(deploy.RunnerConfig{Lease: time.Minute}), svc
+}
+
+// awaitDeployment polls the store until the deployment leaves the
+// pending and running states.
+func awaitDeployment(t *testing.T, store *memory.Store, depID id.ID) *deploy.Deployment {
+	t.Helper()
+
+	for range 200 {
+		dep, err := store.GetDeployment(context.Background(), "ten_test", depID)
+		if err != nil {
+			t.Fatalf("get deployment: %v", err)
+		}
+
+		if dep.State != deploy.DeployPending && dep.State != deploy.DeployRunning {
+			return dep
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("deployment never finished")
+
+	return nil
+}
+
+// TestRunner_ExecutesQueuedDeployment verifies Deploy only queues the
+// deployment, and the runner executes it, persisting the strategy's
+// progress and removing the job.
+func TestRunner_ExecutesQueuedDeployment(t *testing.T) {
+	t.Parallel()
+
+	ctx := adminCtxDeploy()
+	st := &stepStrategy{}
+	store, inst, runner, svc := newRunnerFixture(t, st)
+
+	dep, err := svc.Deploy(ctx, deploy.DeployRequest{
+		InstanceID: inst.ID,
+		Strategy:   "step",
+		Services:   []provider.ServiceDeploySpec{{Name: "main", Image: "myapp:2.0"}},
+	})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	if dep.State != deploy.DeployPending || st.runs.Load() != 0 {
+		t.Fatalf("Deploy: want a pending deployment and no run, got %s after %d runs", dep.State, st.runs.Load())
+	}
+
+	if err := runner.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := awaitDeployment(t, store, dep.ID)
+
+	if got.State != deploy.DeploySucceeded || got.Phase != "complete" || got.Percent != 100 || got.StartedAt == nil {
+		t.Fatalf("deployment: state=%s phase=%q percent=%d started=%v", got.State, got.Phase, got.Percent, got.StartedAt)
+	}
+
+	// The job goes once the outcome is stored; wait for the runner to
+	// let go of it.
+	for range 200 {
+		_, err = store.ClaimJob(ctx, "probe", time.Minute)
+		if errors.Is(err, ctrlplane.ErrNotFound) {
+			return
+		}
+
+		if err == nil {
+			t.Fatal("job still claimable after the deployment finished")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("ClaimJob: want ErrNotFound, got %v", err)
+}
+
+// TestRunner_ResolvesOrphans verifies a deployment left running by a
+// dead runner is run again when its strategy can resume and failed
+// when it can't, or when it has been interrupted too often.
+func TestRunner_ResolvesOrphans(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		resume    bool
+		crashes   int
+		wantState deploy.DeployState
+		wantRuns  int32
+		wantErr   string
+	}{
+		{name: "resumable", resume: true, crashes: 1, wantState: deploy.DeploySucceeded, wantRuns: 1},
+		{name: "not resumable", resume: false, crashes: 1, wantState: deploy.DeployFailed, wantErr: "can't resume"},
+		{name: "out of attempts", resume: true, crashes: 3, wantState: deploy.DeployFailed, wantErr: "giving up"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := adminCtxDeploy()
+			st := &stepStrategy{resume: tt.resume}
+			store, inst, runner, svc := newRunnerFixture(t, st)
+
+			dep, err := svc.Deploy(ctx, deploy.DeployRequest{
+				InstanceID: inst.ID,
+				Strategy:   "step",
+				Services:   []provider.ServiceDeploySpec{{Name: "main", Image: "myapp:2.0"}},
+			})
+			if err != nil {
+				t.Fatalf("Deploy: %v", err)
+			}
+
+			// Runners that claimed the job, marked the deployment
+			// running and died: their leases have already run out.
+			for range tt.crashes {
+				if _, err := store.ClaimJob(ctx, "dead", -time.Second); err != nil {
+					t.Fatalf("ClaimJob: %v", err)
+				}
+			}
+
+			dep.State = deploy.DeployRunning
+			if err := store.UpdateDeployment(ctx, dep); err != nil {
+				t.Fatalf("UpdateDeployment: %v", err)
+			}
+
+			if err := runner.Run(ctx); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			got := awaitDeployment(t, store, dep.ID)
+
+			if got.State != tt.wantState || st.runs.Load() != tt.wantRuns {
+				t.Fatalf("deployment: want %s after %d runs, got %s after %d (error %q)", tt.wantState, tt.wantRuns, got.State, st.runs.Load(), got.Error)
+			}
+
+			if !strings.Contains(got.Error, tt.wantErr) {
+				t.Fatalf("error: want %q in %q", tt.wantErr, got.Error)
+			}
+		})
+	}
+}
@@ -9,7 +9,9 @@ import (
 
 // Service manages deployments and releases for instances.
 type Service interface {
-	// Deploy creates a new release and deploys it to the instance.
+	// Deploy creates a new release and queues its deployment, which
+	// is returned in DeployPending. A Runner executes it; poll
+	// GetDeployment for its phase, percent and outcome.
 	Deploy(ctx context.Context, req DeployRequest) (*Deployment, error)
 
 	// RecordInitial persists the v1 Release + a synthetic
@@ -31,11 +33,15 @@ type Service interface {
 	// by a prior workload create).
 	RecordInitial(ctx context.Context, instanceID id.ID) (*Release, error)
 
-	// Rollback reverts to a specific release.
+	// Rollback reverts to a specific release. Like Deploy it queues the
+	// rollback and returns its Deployment in DeployPending.
 	Rollback(ctx context.Context, instanceID id.ID, releaseID id.ID) (*Deployment, error)
 
-	// Cancel aborts an in-progress deployment.
-	Cancel(ctx context.Context, deploymentID id.ID) error
+	// Cancel stops a pending or running deployment, interrupting its
+	// strategy. It returns once the request is recorded; the deployment
+	// becomes DeployCancelled when its runner has stopped it. A
+	// rollback can only be cancelled without opts.Rollback.
+	Cancel(ctx context.Context, deploymentID id.ID, opts CancelOptions) error
 
 	// GetDeployment returns a specific deployment.
 	GetDeployment(ctx context.Context, deploymentID id.ID) (*Deployment, error)
@@ -52,13 +58,20 @@ type Service interface {
 
 // DeployRequest holds the parameters for initiating a deployment.
 // Services lists only the services being changed; services not listed
-// inherit their snapshot from the prior Release.
+// inherit their snapshot from the prior Release. Canary turns the
+// "canary" strategy into a traffic-weighted rollout and BlueGreen
+// tunes the "blue-green" strategy; each is rejected with any other
+// strategy. Bake, with any strategy, watches the release once the
+// deployment succeeds and rolls it back on a regression.
 type DeployRequest struct {
 	InstanceID id.ID                        `json:"instance_id"          validate:"required"`
 	Services   []provider.ServiceDeploySpec `json:"services"             validate:"required,min=1"`
 	Strategy   string                       `json:"strategy,omitempty"`
 	Notes      string                       `json:"notes,omitempty"`
 	CommitSHA  string                       `json:"commit_sha,omitempty"`
+	Canary     *CanaryConfig                `json:"canary,omitempty"`
+	BlueGreen  *BlueGreenConfig             `json:"blue_green,omitempty"`
+	Bake       *BakeConfig                  `json:"bake,omitempty"`
 }
 
 // ListOptions configures deployment or release listing with pagination.
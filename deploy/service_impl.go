@@ -2,14 +2,19 @@ package deploy
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/auth"
 	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/health"
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/metrics"
 	"github.com/xraph/ctrlplane/provider"
 	"github.com/xraph/ctrlplane/secrets"
 )
@@ -22,7 +27,17 @@ type service struct {
 	events     event.Bus
 	auth       auth.Provider
 	vault      secrets.Vault
+	registry   provider.RegistryResolver
 	strategies map[string]Strategy
+
+	// health and metrics watch releases through their bake windows.
+	health  health.Service
+	metrics metrics.Service
+
+	// running holds the cancel funcs of the deployments this process
+	// is executing, keyed by deployment ID.
+	mu      sync.Mutex
+	running map[id.ID]context.CancelCauseFunc
 }
 
 // NewService creates a deploy service with the given dependencies.
@@ -42,6 +57,7 @@ func NewService(
 		auth:       authProvider,
 		vault:      vault,
 		strategies: make(map[string]Strategy),
+		running:    make(map[id.ID]context.CancelCauseFunc),
 	}
 }
 
@@ -55,36 +71,109 @@ func (s *service) RegisterStrategy(st Strategy) {
 	s.strategies[st.Name()] = st
 }
 
-// Deploy creates a new release and deploys it to the instance.
+// Deploy creates a new release and queues its deployment, returning the
+// Deployment in DeployPending; a Runner executes it. Everything that
+// can be checked up front is, so a bad request fails here rather than
+// in the runner.
 func (s *service) Deploy(ctx context.Context, req DeployRequest) (*Deployment, error) {
 	claims, err := auth.RequireClaims(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("deploy: authenticate: %w", err)
 	}
 
+	if req.Canary != nil && req.Strategy != "canary" {
+		return nil, fmt.Errorf("deploy: canary config needs the canary strategy, got %q: %w", req.Strategy, ctrlplane.ErrInvalidConfig)
+	}
+
+	if req.BlueGreen != nil && req.Strategy != "blue-green" {
+		return nil, fmt.Errorf("deploy: blue-green config needs the blue-green strategy, got %q: %w", req.Strategy, ctrlplane.ErrInvalidConfig)
+	}
+
+	if err := req.Canary.Validate(); err != nil {
+		return nil, fmt.Errorf("deploy: %w", err)
+	}
+
+	if err := req.BlueGreen.Validate(); err != nil {
+		return nil, fmt.Errorf("deploy: %w", err)
+	}
+
+	if err := req.Bake.Validate(); err != nil {
+		return nil, fmt.Errorf("deploy: %w", err)
+	}
+
 	// Verify the instance exists.
 	inst, err := s.instStore.GetByID(ctx, claims.TenantID, req.InstanceID)
 	if err != nil {
 		return nil, fmt.Errorf("deploy: get instance %s: %w", req.InstanceID, err)
 	}
 
+	// Choose the deployment strategy.
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = "rolling"
+	}
+
+	st, ok := s.strategies[strategy]
+	if !ok {
+		return nil, fmt.Errorf("deploy: unknown strategy %q: %w", strategy, ctrlplane.ErrDeploymentFailed)
+	}
+
+	prov, err := s.providers.Get(inst.ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("deploy: get provider %s: %w", inst.ProviderName, err)
+	}
+
+	if c, ok := st.(Checker); ok {
+		draft := &Deployment{
+			TenantID:   claims.TenantID,
+			InstanceID: req.InstanceID,
+			Strategy:   strategy,
+			Services:   req.Services,
+			Canary:     req.Canary,
+			BlueGreen:  req.BlueGreen,
+			Bake:       req.Bake,
+		}
+
+		if err := c.Check(ctx, draft, prov); err != nil {
+			return nil, fmt.Errorf("deploy: strategy %s: %w", strategy, err)
+		}
+	}
+
 	// Determine the next release version.
 	version, err := s.store.NextReleaseVersion(ctx, claims.TenantID, req.InstanceID)
 	if err != nil {
 		return nil, fmt.Errorf("deploy: next release version: %w", err)
 	}
 
+	entity := ctrlplane.NewEntity(id.PrefixRelease)
+
+	// Persist any per-service ConfigFiles into the vault, keyed by the
+	// new Release so each Release's files stay immutable. The returned
+	// specs carry each file's VaultKey + Checksum; the Release and the
+	// Deployment keep only those, and the runner reads the content back.
+	deploySpecs, err := s.storeConfigFiles(ctx, claims.TenantID, req.InstanceID, entity.ID, req.Services)
+	if err != nil {
+		return nil, fmt.Errorf("deploy: store config files: %w", err)
+	}
+
+	// Credentials are never stored, so the runner resolves them again;
+	// resolving here fails a request whose credentials are missing.
+	deploySpecs, err = s.resolveRegistryAuth(ctx, inst, deploySpecs)
+	if err != nil {
+		return nil, fmt.Errorf("deploy: %w", err)
+	}
+
 	// Build the new Release's per-service snapshot. Services listed in
 	// req replace the prior Release's snapshot for that service name;
 	// services not listed inherit from the prior Release.
-	services, err := s.buildReleaseSnapshot(ctx, claims.TenantID, req.InstanceID, req.Services)
+	services, err := s.buildReleaseSnapshot(ctx, claims.TenantID, req.InstanceID, deploySpecs)
 	if err != nil {
 		return nil, fmt.Errorf("deploy: build release snapshot: %w", err)
 	}
 
 	// Create the immutable release snapshot.
 	rel := &Release{
-		Entity:     ctrlplane.NewEntity(id.PrefixRelease),
+		Entity:     entity,
 		TenantID:   claims.TenantID,
 		InstanceID: req.InstanceID,
 		Version:    version,
@@ -98,12 +187,6 @@ func (s *service) Deploy(ctx context.Context, req DeployRequest) (*Deployment, e
 		return nil, fmt.Errorf("deploy: insert release: %w", err)
 	}
 
-	// Choose the deployment strategy.
-	strategy := req.Strategy
-	if strategy == "" {
-		strategy = "rolling"
-	}
-
 	// Initial per-service progress map: every service in this rollout
 	// starts pending; the strategy bumps each entry as it runs.
 	progress := make(map[string]string, len(req.Services))
@@ -119,15 +202,30 @@ func (s *service) Deploy(ctx context.Context, req DeployRequest) (*Deployment, e
 		ReleaseID:       rel.ID,
 		State:           DeployPending,
 		Strategy:        strategy,
-		Services:        req.Services,
+		Services:        specRefs(deploySpecs),
 		ServiceProgress: progress,
 		Initiator:       claims.SubjectID,
+		Canary:          req.Canary,
+		BlueGreen:       req.BlueGreen,
+		Bake:            req.Bake,
 	}
 
 	if err := s.store.InsertDeployment(ctx, dep); err != nil {
 		return nil, fmt.Errorf("deploy: insert deployment: %w", err)
 	}
 
+	err = s.store.EnqueueJob(ctx, &Job{
+		DeploymentID: dep.ID,
+		TenantID:     dep.TenantID,
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		// Nothing will ever run it; don't leave it pending.
+		s.finish(ctx, dep, fmt.Errorf("enqueue: %w", err))
+
+		return nil, fmt.Errorf("deploy: enqueue: %w", err)
+	}
+
 	// Publish the deploy-started event.
 	deployedNames := make([]string, len(req.Services))
 	for i := range req.Services {
@@ -143,58 +241,153 @@ func (s *service) Deploy(ctx context.Context, req DeployRequest) (*Deployment, e
 			"services_deployed": deployedNames,
 		}))
 
-	// Persist any per-service ConfigFiles into the vault. ConfigFiles
-	// live on the workload's Services spec; deployment time is when
-	// they get written so the providers can mount them on next start.
-	if s.vault != nil {
-		for _, sd := range req.Services {
-			_ = sd // stub: ServiceDeploySpec doesn't carry ConfigFiles today
-		}
+	return dep, nil
+}
+
+// runJob drives a claimed job's deployment, or rollback, to a final
+// state and reports whether it got there. It returns false when ctx
+// ended first or the store could not be reached, leaving the
+// deployment for the job's next claim. A deployment with a BakeConfig
+// is final once its bake window has passed; idle is called when only
+// the bake is left.
+func (s *service) runJob(ctx context.Context, job *Job, maxAttempts int, idle func()) bool {
+	dep, err := s.store.GetDeployment(ctx, job.TenantID, job.DeploymentID)
+	if errors.Is(err, ctrlplane.ErrNotFound) {
+		return true
 	}
 
-	// Look up the strategy implementation.
-	st, ok := s.strategies[strategy]
-	if !ok {
-		return nil, fmt.Errorf("deploy: unknown strategy %q: %w", strategy, ctrlplane.ErrDeploymentFailed)
+	if err != nil {
+		return false
+	}
+
+	// The runner acts for whoever asked for the deployment.
+	ctx = auth.WithClaims(ctx, &auth.Claims{SubjectID: dep.Initiator, TenantID: dep.TenantID})
+
+	st, ok := s.strategies[dep.Strategy]
+	if job.RollbackNative {
+		st, ok = nativeRollback{}, true
+	}
+
+	switch {
+	case dep.State == DeploySucceeded && dep.Bake != nil:
+		// Interrupted while baking.
+		idle()
+
+		return s.bake(ctx, dep)
+	case dep.State != DeployPending && dep.State != DeployRunning:
+		// Already finished.
+		return true
+	case job.CancelRequested:
+		return s.cancelledJob(ctx, job, dep, &cancelRequest{rollback: job.CancelRollback, by: job.CancelledBy})
+	case !ok:
+		return s.finishJob(ctx, job, dep, fmt.Errorf("unknown strategy %q", dep.Strategy))
+	case job.Attempts > maxAttempts:
+		return s.finishJob(ctx, job, dep, fmt.Errorf("interrupted %d times, giving up", maxAttempts))
+	case dep.State == DeployRunning && !resumable(st, dep):
+		return s.finishJob(ctx, job, dep, fmt.Errorf("interrupted by a control-plane restart; strategy %s can't resume", dep.Strategy))
+	}
+
+	inst, err := s.instStore.GetByID(ctx, dep.TenantID, dep.InstanceID)
+	if err != nil {
+		return s.finishJob(ctx, job, dep, fmt.Errorf("get instance %s: %w", dep.InstanceID, err))
 	}
 
-	// Resolve the infrastructure provider for this instance.
 	prov, err := s.providers.Get(inst.ProviderName)
 	if err != nil {
-		return nil, fmt.Errorf("deploy: get provider %s: %w", inst.ProviderName, err)
+		return s.finishJob(ctx, job, dep, fmt.Errorf("get provider %s: %w", inst.ProviderName, err))
 	}
 
-	// Transition to running state.
-	now := time.Now().UTC()
-	dep.State = DeployRunning
-	dep.StartedAt = &now
+	dep.Services, err = s.resolveRegistryAuth(ctx, inst, dep.Services)
+	if err != nil {
+		return s.finishJob(ctx, job, dep, err)
+	}
 
-	if err := s.store.UpdateDeployment(ctx, dep); err != nil {
-		return nil, fmt.Errorf("deploy: update deployment to running: %w", err)
+	// The strategy deploys the config files themselves; the stored
+	// deployment only references them.
+	dep.Services, err = s.loadSpecConfigFiles(ctx, dep.Services)
+	if err != nil {
+		return s.finishJob(ctx, job, dep, fmt.Errorf("load config files: %w", err))
 	}
 
-	// Execute the deployment strategy. The OnServiceProgress callback
-	// updates Deployment.ServiceProgress as the strategy advances each
-	// service through its lifecycle so dashboards / observers can see
-	// per-service granularity (especially useful for canary rollouts
-	// that promote one service at a time).
-	execErr := st.Execute(ctx, StrategyParams{
-		Deployment: dep,
-		Provider:   prov,
-		OnProgress: func(string, int, string) {},
-		OnServiceProgress: func(serviceName, state string) {
-			if dep.ServiceProgress == nil {
-				dep.ServiceProgress = make(map[string]string, 1)
-			}
+	if dep.State == DeployPending {
+		now := time.Now().UTC()
+		dep.State = DeployRunning
+		dep.StartedAt = &now
+	} else {
+		dep.Phase = PhaseResuming
+	}
 
-			dep.ServiceProgress[serviceName] = state
+	if err := s.saveDeployment(ctx, dep); err != nil {
+		return false
+	}
 
-			// Best-effort persist; a failed update doesn't fail the
-			// rollout itself — the in-memory state still drives the
-			// final UpdateDeployment call below.
-			_ = s.store.UpdateDeployment(ctx, dep)
-		},
-	})
+	// Cancel interrupts the strategy through this context.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	s.track(dep.ID, cancel)
+	defer s.untrack(dep.ID)
+
+	execErr := st.Execute(ctx, s.strategyParams(ctx, dep, inst, prov))
+
+	var req *cancelRequest
+	if errors.As(context.Cause(ctx), &req) {
+		return s.cancelledJob(ctx, job, dep, req)
+	}
+
+	if execErr != nil && ctx.Err() != nil {
+		return false
+	}
+
+	if !s.finishJob(ctx, job, dep, execErr) {
+		return false
+	}
+
+	if execErr != nil || dep.Bake == nil {
+		return true
+	}
+
+	idle()
+
+	return s.bake(ctx, dep)
+}
+
+// resumable reports whether st may run dep again from the start.
+func resumable(st Strategy, dep *Deployment) bool {
+	r, ok := st.(Resumer)
+
+	return ok && r.Resumable(dep)
+}
+
+// saveDeployment writes dep the way stored records it. dep keeps the
+// config file content the runner loaded for its strategy.
+func (s *service) saveDeployment(ctx context.Context, dep *Deployment) error {
+	rec := stored(dep)
+	if err := s.store.UpdateDeployment(ctx, rec); err != nil {
+		return err
+	}
+
+	dep.UpdatedAt = rec.UpdatedAt
+
+	return nil
+}
+
+// stored returns a copy of dep without config file content. The
+// content is in the vault under each file's VaultKey; a Deployment
+// only references it, as a Release does.
+func stored(dep *Deployment) *Deployment {
+	rec := *dep
+	rec.Services = specRefs(dep.Services)
+
+	return &rec
+}
+
+// finish records dep's outcome — failed with execErr, or succeeded —
+// and publishes it. The writes outlive ctx, so an outcome reached just
+// as the runner stops is still recorded. It reports whether the
+// outcome was stored.
+func (s *service) finish(ctx context.Context, dep *Deployment, execErr error) bool {
+	ctx = context.WithoutCancel(ctx)
 
 	finished := time.Now().UTC()
 	dep.FinishedAt = &finished
@@ -203,36 +396,76 @@ func (s *service) Deploy(ctx context.Context, req DeployRequest) (*Deployment, e
 		dep.State = DeployFailed
 		dep.Error = execErr.Error()
 
-		if updateErr := s.store.UpdateDeployment(ctx, dep); updateErr != nil {
-			return nil, fmt.Errorf("deploy: update deployment after failure: %w", updateErr)
+		if err := s.saveDeployment(ctx, dep); err != nil {
+			return false
 		}
 
-		_ = s.events.Publish(ctx, event.NewEvent(event.DeployFailed, claims.TenantID).
-			WithInstance(req.InstanceID).
-			WithActor(claims.SubjectID).
+		_ = s.events.Publish(ctx, event.NewEvent(event.DeployFailed, dep.TenantID).
+			WithInstance(dep.InstanceID).
+			WithActor(dep.Initiator).
 			WithPayload(map[string]any{
 				"deployment_id": dep.ID.String(),
 				"error":         execErr.Error(),
 			}))
 
-		return dep, fmt.Errorf("deploy: strategy execute: %w", ctrlplane.ErrDeploymentFailed)
+		return true
 	}
 
 	dep.State = DeploySucceeded
 
-	if err := s.store.UpdateDeployment(ctx, dep); err != nil {
-		return nil, fmt.Errorf("deploy: update deployment after success: %w", err)
+	if err := s.saveDeployment(ctx, dep); err != nil {
+		return false
 	}
 
-	_ = s.events.Publish(ctx, event.NewEvent(event.DeploySucceeded, claims.TenantID).
-		WithInstance(req.InstanceID).
-		WithActor(claims.SubjectID).
+	_ = s.events.Publish(ctx, event.NewEvent(event.DeploySucceeded, dep.TenantID).
+		WithInstance(dep.InstanceID).
+		WithActor(dep.Initiator).
 		WithPayload(map[string]any{
 			"deployment_id": dep.ID.String(),
-			"release_id":    rel.ID.String(),
+			"release_id":    dep.ReleaseID.String(),
 		}))
 
-	return dep, nil
+	return true
+}
+
+// strategyParams wires a strategy's callbacks to dep and inst. Every
+// callback persists best-effort: a failed write doesn't fail the
+// rollout, and the final UpdateDeployment carries the in-memory state.
+// The writes outlive ctx, so a strategy cleaning up after a cancel
+// still records where it left things.
+func (s *service) strategyParams(ctx context.Context, dep *Deployment, inst *instance.Instance, prov provider.Provider) StrategyParams {
+	ctx = context.WithoutCancel(ctx)
+
+	return StrategyParams{
+		Deployment: dep,
+		Provider:   prov,
+		OnProgress: func(phase string, percent int, _ string) {
+			dep.Phase = phase
+			dep.Percent = percent
+
+			_ = s.saveDeployment(ctx, dep)
+		},
+		OnServiceProgress: func(serviceName, state string) {
+			if dep.ServiceProgress == nil {
+				dep.ServiceProgress = make(map[string]string, 1)
+			}
+
+			dep.ServiceProgress[serviceName] = state
+
+			_ = s.saveDeployment(ctx, dep)
+		},
+		OnTrackEndpoints: func(track string, endpoints []provider.Endpoint) {
+			inst.Endpoints = slices.DeleteFunc(inst.Endpoints, func(e provider.Endpoint) bool {
+				return e.Track == track
+			})
+			inst.Endpoints = append(inst.Endpoints, endpoints...)
+			inst.UpdatedAt = time.Now().UTC()
+
+			// Routes to the track just don't resolve until the next
+			// successful update.
+			_ = s.instStore.Update(ctx, inst)
+		},
+	}
 }
 
 // RecordInitial persists the v1 Release + a synthetic
@@ -277,19 +510,35 @@ func (s *service) RecordInitial(ctx context.Context, instanceID id.ID) (*Release
 	// provider was asked to provision. Releases are immutable, so
 	// every later Deploy will inherit un-listed services from this
 	// row exactly as they ran on day one.
+	entity := ctrlplane.NewEntity(id.PrefixRelease)
 	snapshots := make([]provider.ServiceSnapshot, 0, len(inst.Services))
 
 	for _, svc := range inst.Services {
 		// Per-service ports/health-check/etc. live on the workload
 		// spec, not on the Release. Releases only carry the bits
-		// that change across deploys: image + env. Match Deploy()'s
-		// snapshot shape exactly so a rollback target is byte-equal
-		// to what a normal Deploy would have produced.
-		snapshots = append(snapshots, provider.ServiceSnapshot{
+		// that change across deploys: image + env + config files.
+		// Match Deploy()'s snapshot shape exactly so a rollback
+		// target is byte-equal to what a normal Deploy would have
+		// produced.
+		snap := provider.ServiceSnapshot{
 			Name:  svc.Name,
 			Image: svc.Image,
 			Env:   svc.Env,
-		})
+		}
+
+		// Config files are versioned only when there's a vault to
+		// hold them; without one the provider still mounted them at
+		// provision, there's just no copy to roll back to.
+		if len(svc.ConfigFiles) > 0 && s.vault != nil {
+			files, storeErr := s.storeServiceConfigFiles(ctx, claims.TenantID, instanceID, entity.ID, svc.Name, svc.ConfigFiles)
+			if storeErr != nil {
+				return nil, fmt.Errorf("record initial release: %w", storeErr)
+			}
+
+			snap.ConfigFiles = configFileRefs(files)
+		}
+
+		snapshots = append(snapshots, snap)
 	}
 
 	// Version bumps off NextReleaseVersion so a future legacy row
@@ -301,7 +550,7 @@ func (s *service) RecordInitial(ctx context.Context, instanceID id.ID) (*Release
 	}
 
 	rel := &Release{
-		Entity:     ctrlplane.NewEntity(id.PrefixRelease),
+		Entity:     entity,
 		TenantID:   claims.TenantID,
 		InstanceID: instanceID,
 		Version:    version,
@@ -362,154 +611,46 @@ func (s *service) RecordInitial(ctx context.Context, instanceID id.ID) (*Release
 	return rel, nil
 }
 
-// Rollback reverts to a specific release by creating a new deployment.
-func (s *service) Rollback(ctx context.Context, instanceID id.ID, releaseID id.ID) (*Deployment, error) {
+// Cancel stops a pending or running deployment. The cancellation is
+// recorded on its job: the runner holding the job interrupts the
+// strategy, rolls back first if opts ask for it, and marks the
+// deployment cancelled; a queued job is cancelled by whichever runner
+// claims it next. A rollback can't be rolled back itself.
+func (s *service) Cancel(ctx context.Context, deploymentID id.ID, opts CancelOptions) error {
 	claims, err := auth.RequireClaims(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("rollback: authenticate: %w", err)
-	}
-
-	// Verify the instance exists.
-	inst, err := s.instStore.GetByID(ctx, claims.TenantID, instanceID)
-	if err != nil {
-		return nil, fmt.Errorf("rollback: get instance %s: %w", instanceID, err)
-	}
-
-	// Retrieve the original release to roll back to.
-	rel, err := s.store.GetRelease(ctx, claims.TenantID, releaseID)
-	if err != nil {
-		return nil, fmt.Errorf("rollback: get release %s: %w", releaseID, err)
-	}
-
-	// Rollback restores every service in the target Release —
-	// translate each ServiceSnapshot into a ServiceDeploySpec.
-	services := make([]provider.ServiceDeploySpec, len(rel.Services))
-
-	for i, snap := range rel.Services {
-		services[i] = provider.ServiceDeploySpec{
-			Name:  snap.Name,
-			Image: snap.Image,
-			Env:   snap.Env,
-		}
-	}
-
-	progress := make(map[string]string, len(services))
-	for _, sd := range services {
-		progress[sd.Name] = "pending"
-	}
-
-	// Create a rollback deployment using the recreate strategy.
-	dep := &Deployment{
-		Entity:          ctrlplane.NewEntity(id.PrefixDeployment),
-		TenantID:        claims.TenantID,
-		InstanceID:      instanceID,
-		ReleaseID:       releaseID,
-		State:           DeployPending,
-		Strategy:        "recreate",
-		Services:        services,
-		ServiceProgress: progress,
-		Initiator:       claims.SubjectID,
-	}
-
-	if err := s.store.InsertDeployment(ctx, dep); err != nil {
-		return nil, fmt.Errorf("rollback: insert deployment: %w", err)
-	}
-
-	// Publish the deploy-started event.
-	_ = s.events.Publish(ctx, event.NewEvent(event.DeployStarted, claims.TenantID).
-		WithInstance(instanceID).
-		WithActor(claims.SubjectID).
-		WithPayload(map[string]any{
-			"deployment_id": dep.ID.String(),
-			"release_id":    releaseID.String(),
-			"rollback":      true,
-		}))
-
-	// Look up the recreate strategy.
-	st, ok := s.strategies["recreate"]
-	if !ok {
-		return nil, fmt.Errorf("rollback: unknown strategy %q: %w", "recreate", ctrlplane.ErrDeploymentFailed)
+		return fmt.Errorf("cancel: authenticate: %w", err)
 	}
 
-	// Resolve the infrastructure provider.
-	prov, err := s.providers.Get(inst.ProviderName)
+	dep, err := s.store.GetDeployment(ctx, claims.TenantID, deploymentID)
 	if err != nil {
-		return nil, fmt.Errorf("rollback: get provider %s: %w", inst.ProviderName, err)
+		return fmt.Errorf("cancel: get deployment %s: %w", deploymentID, err)
 	}
 
-	// Transition to running state.
-	now := time.Now().UTC()
-	dep.State = DeployRunning
-	dep.StartedAt = &now
-
-	if err := s.store.UpdateDeployment(ctx, dep); err != nil {
-		return nil, fmt.Errorf("rollback: update deployment to running: %w", err)
+	if dep.State != DeployPending && dep.State != DeployRunning {
+		return fmt.Errorf("cancel: deployment in state %s: %w", dep.State, ctrlplane.ErrInvalidState)
 	}
 
-	// Execute the rollback deployment.
-	execErr := st.Execute(ctx, StrategyParams{
-		Deployment: dep,
-		Provider:   prov,
-		OnProgress: func(string, int, string) {},
-		OnServiceProgress: func(serviceName, state string) {
-			if dep.ServiceProgress == nil {
-				dep.ServiceProgress = make(map[string]string, 1)
-			}
-
-			dep.ServiceProgress[serviceName] = state
-
-			_ = s.store.UpdateDeployment(ctx, dep)
-		},
-	})
-
-	finished := time.Now().UTC()
-	dep.FinishedAt = &finished
+	req := &cancelRequest{rollback: opts.Rollback, by: claims.SubjectID}
 
-	if execErr != nil {
-		dep.State = DeployFailed
-		dep.Error = execErr.Error()
-
-		if updateErr := s.store.UpdateDeployment(ctx, dep); updateErr != nil {
-			return nil, fmt.Errorf("rollback: update deployment after failure: %w", updateErr)
-		}
-
-		_ = s.events.Publish(ctx, event.NewEvent(event.DeployFailed, claims.TenantID).
-			WithInstance(instanceID).
-			WithActor(claims.SubjectID).
-			WithPayload(map[string]any{
-				"deployment_id": dep.ID.String(),
-				"error":         execErr.Error(),
-			}))
+	err = s.store.CancelJob(ctx, deploymentID, opts.Rollback, claims.SubjectID)
+	if err == nil {
+		// When the runner is in this process, stop it now rather than
+		// at its next lease renewal.
+		s.interrupt(deploymentID, req)
 
-		return dep, fmt.Errorf("rollback: strategy execute: %w", ctrlplane.ErrDeploymentFailed)
+		return nil
 	}
 
-	dep.State = DeploySucceeded
-
-	if err := s.store.UpdateDeployment(ctx, dep); err != nil {
-		return nil, fmt.Errorf("rollback: update deployment after success: %w", err)
+	if !errors.Is(err, ctrlplane.ErrNotFound) {
+		return fmt.Errorf("cancel: %w", err)
 	}
 
-	// Publish the rolled-back event on success.
-	_ = s.events.Publish(ctx, event.NewEvent(event.DeployRolledBack, claims.TenantID).
-		WithInstance(instanceID).
-		WithActor(claims.SubjectID).
-		WithPayload(map[string]any{
-			"deployment_id": dep.ID.String(),
-			"release_id":    releaseID.String(),
-		}))
-
-	return dep, nil
-}
-
-// Cancel aborts an in-progress deployment.
-func (s *service) Cancel(ctx context.Context, deploymentID id.ID) error {
-	claims, err := auth.RequireClaims(ctx)
-	if err != nil {
-		return fmt.Errorf("cancel: authenticate: %w", err)
-	}
-
-	dep, err := s.store.GetDeployment(ctx, claims.TenantID, deploymentID)
+	// A runner deletes a job only once it recorded the outcome, so the
+	// deployment has either just finished or has no job at all, like a
+	// rollback started before rollbacks were queued. Nothing will ever
+	// finish the latter; it is cancelled here.
+	dep, err = s.store.GetDeployment(ctx, claims.TenantID, deploymentID)
 	if err != nil {
 		return fmt.Errorf("cancel: get deployment %s: %w", deploymentID, err)
 	}
@@ -518,12 +659,12 @@ func (s *service) Cancel(ctx context.Context, deploymentID id.ID) error {
 		return fmt.Errorf("cancel: deployment in state %s: %w", dep.State, ctrlplane.ErrInvalidState)
 	}
 
-	now := time.Now().UTC()
-	dep.State = DeployCancelled
-	dep.FinishedAt = &now
+	if opts.Rollback {
+		return fmt.Errorf("cancel: deployment %s has no job and can't be rolled back: %w", deploymentID, ctrlplane.ErrInvalidConfig)
+	}
 
-	if err := s.store.UpdateDeployment(ctx, dep); err != nil {
-		return fmt.Errorf("cancel: update deployment: %w", err)
+	if !s.cancelled(ctx, dep, req) {
+		return fmt.Errorf("cancel: deployment %s could not be updated", deploymentID)
 	}
 
 	return nil
@@ -604,26 +745,39 @@ func (s *service) buildReleaseSnapshot(ctx context.Context, tenantID string, ins
 		return nil, fmt.Errorf("look up prior release: %w", err)
 	}
 
+	var previous []provider.ServiceSnapshot
+	if prior != nil && len(prior.Items) > 0 {
+		previous = prior.Items[0].Services
+	}
+
 	out := make([]provider.ServiceSnapshot, 0, len(updates))
 	covered := make(map[string]struct{}, len(updates))
 
 	for _, u := range updates {
+		// A deploy that doesn't touch a service's config files keeps
+		// the prior Release's, the same way unlisted services do.
+		files := configFileRefs(u.ConfigFiles)
+		if len(files) == 0 {
+			if i := slices.IndexFunc(previous, func(p provider.ServiceSnapshot) bool { return p.Name == u.Name }); i >= 0 {
+				files = previous[i].ConfigFiles
+			}
+		}
+
 		out = append(out, provider.ServiceSnapshot{
-			Name:  u.Name,
-			Image: u.Image,
-			Env:   u.Env,
+			Name:        u.Name,
+			Image:       u.Image,
+			Env:         u.Env,
+			ConfigFiles: files,
 		})
 		covered[u.Name] = struct{}{}
 	}
 
-	if prior != nil && len(prior.Items) > 0 {
-		for _, prev := range prior.Items[0].Services {
-			if _, replaced := covered[prev.Name]; replaced {
-				continue
-			}
-
-			out = append(out, prev)
+	for _, prev := range previous {
+		if _, replaced := covered[prev.Name]; replaced {
+			continue
 		}
+
+		out = append(out, prev)
 	}
 
 	return out, nil
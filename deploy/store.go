@@ -2,6 +2,7 @@ package deploy
 
 import (
 	"context"
+	"time"
 
 	"github.com/xraph/ctrlplane/id"
 )
@@ -31,4 +32,28 @@ type Store interface {
 
 	// NextReleaseVersion returns the next auto-incrementing version number for an instance.
 	NextReleaseVersion(ctx context.Context, tenantID string, instanceID id.ID) (int, error)
+
+	// EnqueueJob persists a new, unclaimed job.
+	EnqueueJob(ctx context.Context, j *Job) error
+
+	// ClaimJob leases the oldest claimable job, across all tenants, to
+	// owner for lease and increments its Attempts. It returns
+	// ctrlplane.ErrNotFound when no job is claimable.
+	ClaimJob(ctx context.Context, owner string, lease time.Duration) (*Job, error)
+
+	// RenewJob moves the lease of the claim j describes to lease from
+	// now; a zero lease releases the job for the next claim. It copies
+	// a cancellation requested since into j, and never clears one. It
+	// returns ctrlplane.ErrNotFound when the job was deleted or claimed
+	// again.
+	RenewJob(ctx context.Context, j *Job, lease time.Duration) error
+
+	// CancelJob records a cancellation request on a job, leaving its
+	// claim alone. It returns ctrlplane.ErrNotFound when there is no
+	// job for the deployment, and ctrlplane.ErrInvalidConfig, recording
+	// nothing, when rollback is asked of a job that runs a rollback.
+	CancelJob(ctx context.Context, deploymentID id.ID, rollback bool, by string) error
+
+	// DeleteJob removes a job. A missing job is not an error.
+	DeleteJob(ctx context.Context, deploymentID id.ID) error
 }
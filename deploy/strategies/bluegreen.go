@@ -2,19 +2,71 @@ package strategies
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/health"
+	"github.com/xraph/ctrlplane/network"
 	"github.com/xraph/ctrlplane/provider"
 )
 
-// BlueGreen implements a blue-green deployment strategy that provisions a new
-// environment and switches traffic once ready.
-type BlueGreen struct{}
+// BlueGreen implements a blue-green deployment strategy.
+//
+// The live services are blue. The release is provisioned as green on
+// the provider's green track (provider.TrackGreen) beside them, and
+// once every green replica is ready each of the instance's routes is
+// flipped to green in a single update. Blue keeps running for the
+// deployment's bake period; if green stops being ready or a health
+// check fails meanwhile, the routes flip straight back to blue and the
+// deployment fails. After a clean bake blue is moved to the release,
+// the routes return to it and green is torn down, so the instance
+// ends up on its usual workload. Should moving blue fail, blue is no
+// longer fit to take traffic back: the routes stay on green, green
+// keeps running and the deployment fails with green live. The next
+// blue-green rollout moves blue to its release while green serves,
+// then returns the routes to blue.
+//
+// The provider must advertise provider.CapBlueGreen and run tracks
+// (provider.TrackDeployer); Deploy refuses the strategy up front on
+// any other. An instance without routes has no traffic to switch and
+// is deployed in place. Per-service progress advances in lockstep —
+// the cutover is atomic across all services.
+type BlueGreen struct {
+	routes Routes
+	health health.Service
+
+	// pause waits between readiness and bake polls; tests replace it.
+	pause func(ctx context.Context, d time.Duration) error
+}
+
+// BlueGreenOption configures a BlueGreen.
+type BlueGreenOption func(*BlueGreen)
+
+// WithBlueGreenRoutes sets the route service the cutover flips. The
+// strategy fails without one.
+func WithBlueGreenRoutes(r Routes) BlueGreenOption {
+	return func(s *BlueGreen) { s.routes = r }
+}
+
+// WithBlueGreenHealth sets the health service watched during the bake.
+// Without one only green's readiness is watched.
+func WithBlueGreenHealth(h health.Service) BlueGreenOption {
+	return func(s *BlueGreen) { s.health = h }
+}
 
 // NewBlueGreen returns a new blue-green deployment strategy.
-func NewBlueGreen() *BlueGreen {
-	return &BlueGreen{}
+func NewBlueGreen(opts ...BlueGreenOption) *BlueGreen {
+	s := &BlueGreen{pause: sleep}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Name returns the strategy identifier.
@@ -22,13 +74,246 @@ func (s *BlueGreen) Name() string {
 	return "blue-green"
 }
 
-// Execute performs a blue-green deployment by spinning up a new
-// environment and switching traffic to it. Per-service progress
-// advances in lockstep — the cutover is atomic across all services.
+// Resumable reports that an interrupted blue-green rollout can run
+// again: the rerun first puts any route left on green back on blue and
+// removes green, then starts over. Blue may already be partly on the
+// release, which the rerun's promotion converges.
+func (s *BlueGreen) Resumable(*deploy.Deployment) bool {
+	return true
+}
+
+// Check refuses a rollout the provider can't run: one with routes to
+// switch on a provider without blue-green tracks.
+func (s *BlueGreen) Check(ctx context.Context, dep *deploy.Deployment, prov provider.Provider) error {
+	if s.routes == nil {
+		return errors.New("blue-green needs a route service")
+	}
+
+	_, err := newTrafficSplit(ctx, s.routes, dep, provider.TrackGreen)
+	if errors.Is(err, errNoRoutes) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = blueGreenTracks(prov)
+
+	return err
+}
+
+// blueGreenTracks returns the provider's TrackDeployer if it supports
+// blue-green.
+func blueGreenTracks(prov provider.Provider) (provider.TrackDeployer, error) {
+	if !provider.HasCapability(prov, provider.CapBlueGreen) {
+		return nil, fmt.Errorf("provider %s does not support blue-green deployments: %w", prov.Info().Name, ctrlplane.ErrInvalidConfig)
+	}
+
+	return trackDeployer(prov)
+}
+
+// Execute provisions green, cuts traffic over, bakes and retires blue.
 func (s *BlueGreen) Execute(ctx context.Context, params deploy.StrategyParams) error {
-	params.OnProgress("provisioning", 0, "provisioning new environment")
+	if err := s.execute(ctx, params); err != nil {
+		return fmt.Errorf("strategy %s: %w", s.Name(), err)
+	}
+
+	return nil
+}
+
+// execute runs the rollout. Every exit after green starts goes through
+// cleanup, which runs on a context that outlives ctx so traffic is
+// back on blue even when ctx was cancelled.
+func (s *BlueGreen) execute(ctx context.Context, params deploy.StrategyParams) error {
+	dep := params.Deployment
+
+	if s.routes == nil {
+		return errors.New("blue-green needs a route service")
+	}
+
+	if dep.Phase == deploy.PhaseResuming {
+		if err := s.recover(ctx, params); err != nil {
+			return fmt.Errorf("recover interrupted rollout: %w", err)
+		}
+	} else if live, err := s.greenLive(ctx, dep); err != nil {
+		return err
+	} else if live {
+		return s.promoteUnderGreen(ctx, params)
+	}
+
+	split, err := newTrafficSplit(ctx, s.routes, dep, provider.TrackGreen)
+	if errors.Is(err, errNoRoutes) {
+		return s.inPlace(ctx, params)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	td, err := blueGreenTracks(params.Provider)
+	if err != nil {
+		return err
+	}
 
 	markAll(params, deploy.ServiceStateRunning)
+	params.OnProgress("provisioning", 0, "provisioning green environment")
+
+	req := provider.DeployRequest{
+		InstanceID: dep.InstanceID,
+		ReleaseID:  dep.ReleaseID,
+		Services:   dep.Services,
+		Strategy:   "blue-green",
+	}
+
+	// Cancelled before the promotion, blue never changed and goes back
+	// to pending, so a rollback leaves it alone.
+	fail := func(err error) error {
+		if cancelled(ctx) != nil {
+			markAll(params, deploy.ServiceStatePending)
+		} else {
+			markAll(params, deploy.ServiceStateFailed)
+		}
+
+		if cerr := s.cleanup(context.WithoutCancel(ctx), params, td, split); cerr != nil {
+			return fmt.Errorf("%w (restoring blue: %w)", err, cerr)
+		}
+
+		return err
+	}
+
+	res, err := td.DeployTrack(ctx, provider.TrackGreen, req)
+	if err != nil {
+		return fail(fmt.Errorf("deploy green: %w", err))
+	}
+
+	if params.OnTrackEndpoints != nil {
+		params.OnTrackEndpoints(provider.TrackGreen, res.Endpoints)
+	}
+
+	greenStatus := func(ctx context.Context) (*provider.InstanceStatus, error) {
+		return td.TrackStatus(ctx, dep.InstanceID, provider.TrackGreen)
+	}
+
+	if err := waitReady(ctx, s.pause, greenStatus); err != nil {
+		return fail(fmt.Errorf("green: %w", err))
+	}
+
+	if err := cancelled(ctx); err != nil {
+		return fail(err)
+	}
+
+	params.OnProgress("switching", 40, "switching traffic to green")
+
+	if err := split.cutover(ctx); err != nil {
+		return fail(err)
+	}
+
+	bake := dep.BlueGreen.Bake()
+	params.OnProgress("baking", 50, fmt.Sprintf("green live, keeping blue for %s", bake))
+
+	if err := s.bake(ctx, dep, bake, greenStatus); err != nil {
+		params.OnProgress("rolling back", 50, err.Error())
+
+		return fail(fmt.Errorf("switched back to blue: %w", err))
+	}
+
+	// Blue moves to the release while green serves; traffic returns to
+	// it only once every replica is ready.
+	if err := cancelled(ctx); err != nil {
+		return fail(err)
+	}
+
+	params.OnProgress("promoting", 80, "retiring blue")
+
+	// From here blue may be partly on the release, so a failure
+	// leaves traffic on green rather than sending it back.
+	if err := s.promote(ctx, params, req); err != nil {
+		return stranded(params, err)
+	}
+
+	if err := s.cleanup(ctx, params, td, split); err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	markAll(params, deploy.ServiceStateSucceeded)
+	params.OnProgress("complete", 100, "blue-green deployment complete")
+
+	return nil
+}
+
+// promote moves blue to the release and waits until it is ready.
+func (s *BlueGreen) promote(ctx context.Context, params deploy.StrategyParams, req provider.DeployRequest) error {
+	if _, err := params.Provider.Deploy(ctx, req); err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	err := waitReady(ctx, s.pause, func(ctx context.Context) (*provider.InstanceStatus, error) {
+		return params.Provider.Status(ctx, params.Deployment.InstanceID)
+	})
+	if err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	return nil
+}
+
+// stranded fails a rollout whose promotion failed, leaving the routes
+// and green as they are.
+func stranded(params deploy.StrategyParams, err error) error {
+	markAll(params, deploy.ServiceStateFailed)
+	params.OnProgress("promoting", 80, "promotion failed, green left live")
+
+	return fmt.Errorf("%w (green left live)", err)
+}
+
+// greenLive reports whether any of the instance's routes is still on
+// green outside a rollout, as a failed promotion leaves them.
+func (s *BlueGreen) greenLive(ctx context.Context, dep *deploy.Deployment) (bool, error) {
+	all, err := s.routes.ListRoutes(ctx, dep.InstanceID)
+	if err != nil {
+		return false, fmt.Errorf("list routes: %w", err)
+	}
+
+	return slices.ContainsFunc(all, func(r network.Route) bool {
+		return r.Track == provider.TrackGreen
+	}), nil
+}
+
+// promoteUnderGreen finishes what a failed promotion left: blue moves
+// to the release while green keeps serving, then the routes return to
+// blue and green is removed. Should blue fail again, green stays live.
+func (s *BlueGreen) promoteUnderGreen(ctx context.Context, params deploy.StrategyParams) error {
+	dep := params.Deployment
+
+	markAll(params, deploy.ServiceStateRunning)
+	params.OnProgress("promoting", 80, "green still live, moving blue to the release")
+
+	err := s.promote(ctx, params, provider.DeployRequest{
+		InstanceID: dep.InstanceID,
+		ReleaseID:  dep.ReleaseID,
+		Services:   dep.Services,
+		Strategy:   "blue-green",
+	})
+	if err != nil {
+		return stranded(params, err)
+	}
+
+	if err := s.recover(ctx, params); err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	markAll(params, deploy.ServiceStateSucceeded)
+	params.OnProgress("complete", 100, "blue-green deployment complete")
+
+	return nil
+}
+
+// inPlace deploys the release straight to the live services, for an
+// instance without routes to switch.
+func (s *BlueGreen) inPlace(ctx context.Context, params deploy.StrategyParams) error {
+	markAll(params, deploy.ServiceStateRunning)
+	params.OnProgress("deploying", 0, "no routes to switch, deploying in place")
 
 	_, err := params.Provider.Deploy(ctx, provider.DeployRequest{
 		InstanceID: params.Deployment.InstanceID,
@@ -39,12 +324,102 @@ func (s *BlueGreen) Execute(ctx context.Context, params deploy.StrategyParams) e
 	if err != nil {
 		markAll(params, deploy.ServiceStateFailed)
 
-		return fmt.Errorf("strategy %s: %w", s.Name(), err)
+		return fmt.Errorf("deploy: %w", err)
 	}
 
 	markAll(params, deploy.ServiceStateSucceeded)
-	params.OnProgress("switching", 50, "switching traffic")
 	params.OnProgress("complete", 100, "blue-green deployment complete")
 
 	return nil
 }
+
+// recover undoes what an interrupted run left behind: routes cut over
+// to green go back to blue, and green is removed. The cutover never
+// changes weights, so the track is all there is to put back.
+func (s *BlueGreen) recover(ctx context.Context, params deploy.StrategyParams) error {
+	dep := params.Deployment
+
+	all, err := s.routes.ListRoutes(ctx, dep.InstanceID)
+	if err != nil {
+		return fmt.Errorf("list routes: %w", err)
+	}
+
+	live := ""
+
+	for _, r := range all {
+		if r.Track != provider.TrackGreen {
+			continue
+		}
+
+		if _, err := s.routes.UpdateRoute(ctx, r.ID, network.UpdateRouteRequest{Track: &live}); err != nil {
+			return fmt.Errorf("cut route %s back: %w", r.Path, err)
+		}
+	}
+
+	td, err := blueGreenTracks(params.Provider)
+	if err != nil {
+		// Nothing ran on a green track to remove.
+		return nil //nolint:nilerr // the provider never had one
+	}
+
+	if err := td.RemoveTrack(ctx, dep.InstanceID, provider.TrackGreen); err != nil {
+		return fmt.Errorf("remove green: %w", err)
+	}
+
+	if params.OnTrackEndpoints != nil {
+		params.OnTrackEndpoints(provider.TrackGreen, nil)
+	}
+
+	return nil
+}
+
+// bake watches green for d after the cutover, returning the first
+// regression: green no longer ready or a failing health check.
+func (s *BlueGreen) bake(ctx context.Context, dep *deploy.Deployment, d time.Duration, status func(context.Context) (*provider.InstanceStatus, error)) error {
+	for left := d; left > 0; left -= trackPollInterval {
+		if err := s.pause(ctx, min(left, trackPollInterval)); err != nil {
+			return err
+		}
+
+		st, err := status(ctx)
+		if err != nil {
+			return fmt.Errorf("green status: %w", err)
+		}
+
+		if !st.Ready || st.ReadyReplicas < st.DesiredReplicas {
+			return fmt.Errorf("green not ready: %d/%d replicas", st.ReadyReplicas, st.DesiredReplicas)
+		}
+
+		if s.health == nil {
+			continue
+		}
+
+		h, err := s.health.GetHealth(ctx, dep.InstanceID)
+		if err != nil {
+			return fmt.Errorf("get health: %w", err)
+		}
+
+		for _, c := range h.Checks {
+			if c.Status == health.StatusUnhealthy {
+				return fmt.Errorf("health check %s unhealthy", c.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// cleanup puts traffic back on blue and tears green down.
+func (s *BlueGreen) cleanup(ctx context.Context, params deploy.StrategyParams, td provider.TrackDeployer, split *trafficSplit) error {
+	err := split.restore(ctx)
+
+	if rerr := td.RemoveTrack(ctx, params.Deployment.InstanceID, provider.TrackGreen); rerr != nil && err == nil {
+		err = fmt.Errorf("remove green: %w", rerr)
+	}
+
+	if params.OnTrackEndpoints != nil {
+		params.OnTrackEndpoints(provider.TrackGreen, nil)
+	}
+
+	return err
+}
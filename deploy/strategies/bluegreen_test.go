@@ -0,0 +1,318 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/health"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/network"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+func blueGreenDeployment(instanceID id.ID, bake time.Duration) *deploy.Deployment {
+	return &deploy.Deployment{
+		InstanceID: instanceID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   []provider.ServiceDeploySpec{{Name: "api", Image: "api:v2"}},
+		BlueGreen:  &deploy.BlueGreenConfig{BakePeriod: deploy.Duration(bake)},
+	}
+}
+
+// TestBlueGreen_CutsOverAndRetiresBlue verifies green is provisioned,
+// traffic flips to it, blue bakes and is moved to the release, and the
+// routes end back on the live workload with green removed.
+func TestBlueGreen_CutsOverAndRetiresBlue(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	prov := &trackProvider{}
+	routes := newFakeRoutes(instID)
+	endpoints := map[string][]provider.Endpoint{}
+
+	var baked time.Duration
+
+	s := NewBlueGreen(
+		WithBlueGreenRoutes(routes),
+		WithBlueGreenHealth(&fakeHealth{statuses: []health.Status{health.StatusHealthy}}),
+	)
+	s.pause = func(_ context.Context, d time.Duration) error {
+		baked += d
+
+		return nil
+	}
+
+	err := s.Execute(context.Background(), deploy.StrategyParams{
+		Deployment: blueGreenDeployment(instID, 5*time.Second),
+		Provider:   prov,
+		OnProgress: func(string, int, string) {},
+		OnTrackEndpoints: func(track string, eps []provider.Endpoint) {
+			endpoints[track] = eps
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if len(prov.tracks) != 1 || prov.tracks[0].Services[0].Image != "api:v2" {
+		t.Fatalf("green deploys = %+v", prov.tracks)
+	}
+
+	if got, want := routes.cutovers, []string{provider.TrackGreen, ""}; !slices.Equal(got, want) {
+		t.Errorf("cutovers = %q, want %q", got, want)
+	}
+
+	if baked != 5*time.Second {
+		t.Errorf("baked %s, want 5s", baked)
+	}
+
+	if prov.callCount != 1 {
+		t.Errorf("blue Deploy calls = %d, want 1", prov.callCount)
+	}
+
+	if live := routes.live(t); live.Weight != 100 {
+		t.Errorf("live weight = %d, want 100", live.Weight)
+	}
+
+	if prov.removed != 1 || endpoints[provider.TrackGreen] != nil {
+		t.Errorf("green not torn down: removed %d, endpoints %v", prov.removed, endpoints)
+	}
+}
+
+// TestBlueGreen_SwitchesBackOnRegression verifies a regression during
+// the bake flips traffic back to blue without touching it.
+func TestBlueGreen_SwitchesBackOnRegression(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		health    []health.Status
+		downAfter int
+	}{
+		{name: "failing check", health: []health.Status{health.StatusUnhealthy}},
+		// The first poll is the readiness wait; the second is the bake.
+		{name: "green not ready", downAfter: 2},
+	} {
+		instID := id.New(id.PrefixInstance)
+		prov := &trackProvider{downAfter: tt.downAfter}
+		routes := newFakeRoutes(instID)
+		progress := map[string]string{}
+
+		s := NewBlueGreen(WithBlueGreenRoutes(routes), WithBlueGreenHealth(&fakeHealth{statuses: tt.health}))
+		s.pause = noPause
+
+		err := s.Execute(context.Background(), deploy.StrategyParams{
+			Deployment: blueGreenDeployment(instID, time.Minute),
+			Provider:   prov,
+			OnProgress: func(string, int, string) {},
+			OnServiceProgress: func(name, state string) {
+				progress[name] = state
+			},
+		})
+		if err == nil {
+			t.Errorf("%s: Execute succeeded, want a switch back", tt.name)
+
+			continue
+		}
+
+		if got, want := routes.cutovers, []string{provider.TrackGreen, ""}; !slices.Equal(got, want) {
+			t.Errorf("%s: cutovers = %q, want %q", tt.name, got, want)
+		}
+
+		if prov.callCount != 0 {
+			t.Errorf("%s: blue was moved to the release", tt.name)
+		}
+
+		routes.live(t)
+
+		if prov.removed != 1 || progress["api"] != deploy.ServiceStateFailed {
+			t.Errorf("%s: removed %d, progress %v", tt.name, prov.removed, progress)
+		}
+	}
+}
+
+// TestBlueGreen_KeepsGreenLiveOnFailedPromotion verifies a failed
+// promotion leaves the routes and green in place and fails, and that
+// the next rollout moves blue to its release before returning the
+// routes to it.
+func TestBlueGreen_KeepsGreenLiveOnFailedPromotion(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	prov := &trackProvider{fakeProvider: fakeProvider{failOnNumber: 1}}
+	routes := newFakeRoutes(instID)
+	progress := map[string]string{}
+	endpoints := map[string][]provider.Endpoint{}
+
+	s := NewBlueGreen(WithBlueGreenRoutes(routes))
+	s.pause = noPause
+
+	params := deploy.StrategyParams{
+		Deployment: blueGreenDeployment(instID, time.Second),
+		Provider:   prov,
+		OnProgress: func(string, int, string) {},
+		OnServiceProgress: func(name, state string) {
+			progress[name] = state
+		},
+		OnTrackEndpoints: func(track string, eps []provider.Endpoint) {
+			endpoints[track] = eps
+		},
+	}
+
+	if err := s.Execute(context.Background(), params); err == nil {
+		t.Fatal("Execute succeeded, want the promotion to fail")
+	}
+
+	if got, want := routes.cutovers, []string{provider.TrackGreen}; !slices.Equal(got, want) {
+		t.Errorf("cutovers = %q, want %q", got, want)
+	}
+
+	if prov.removed != 0 || endpoints[provider.TrackGreen] == nil {
+		t.Errorf("green torn down: removed %d, endpoints %v", prov.removed, endpoints)
+	}
+
+	if progress["api"] != deploy.ServiceStateFailed {
+		t.Errorf("progress = %v, want api failed", progress)
+	}
+
+	params.Deployment = blueGreenDeployment(instID, time.Second)
+
+	if err := s.Execute(context.Background(), params); err != nil {
+		t.Fatalf("next Execute: %v", err)
+	}
+
+	if len(prov.tracks) != 1 || prov.callCount != 2 {
+		t.Errorf("next rollout: %d green deploys, %d blue deploys; want 1 and 2", len(prov.tracks), prov.callCount)
+	}
+
+	if got, want := routes.cutovers, []string{provider.TrackGreen, ""}; !slices.Equal(got, want) {
+		t.Errorf("cutovers = %q, want %q", got, want)
+	}
+
+	if live := routes.live(t); live.Weight != 100 || prov.removed != 1 {
+		t.Errorf("live weight = %d, green removed %d times; want 100 and 1", live.Weight, prov.removed)
+	}
+}
+
+// TestBlueGreen_NeedsCapability verifies a provider without
+// CapBlueGreen is refused before anything is provisioned.
+func TestBlueGreen_NeedsCapability(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	routes := newFakeRoutes(instID)
+	prov := &fakeProvider{}
+
+	err := NewBlueGreen(WithBlueGreenRoutes(routes)).Execute(context.Background(), deploy.StrategyParams{
+		Deployment: blueGreenDeployment(instID, 0),
+		Provider:   prov,
+		OnProgress: func(string, int, string) {},
+	})
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Fatalf("err = %v, want ErrInvalidConfig", err)
+	}
+
+	if len(routes.cutovers) != 0 || prov.callCount != 0 {
+		t.Errorf("traffic moved or provider called: cutovers %v, deploys %d", routes.cutovers, prov.callCount)
+	}
+}
+
+// TestBlueGreen_DeploysInPlaceWithoutRoutes verifies an instance
+// without routes gets the release deployed to its live services, on
+// any provider, instead of failing.
+func TestBlueGreen_DeploysInPlaceWithoutRoutes(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	routes := &fakeRoutes{routes: map[id.ID]*network.Route{}}
+	prov := &fakeProvider{}
+	s := NewBlueGreen(WithBlueGreenRoutes(routes))
+	dep := blueGreenDeployment(instID, 0)
+
+	if err := s.Check(context.Background(), dep, prov); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	err := s.Execute(context.Background(), deploy.StrategyParams{
+		Deployment: dep,
+		Provider:   prov,
+		OnProgress: func(string, int, string) {},
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if prov.callCount != 1 || len(routes.cutovers) != 0 {
+		t.Fatalf("deploys = %d, cutovers = %v; want one in-place deploy", prov.callCount, routes.cutovers)
+	}
+}
+
+// TestBlueGreen_CheckRefusesProviderWithoutTracks verifies a rollout
+// with routes to switch is refused up front on a provider that can't
+// run green beside blue.
+func TestBlueGreen_CheckRefusesProviderWithoutTracks(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	s := NewBlueGreen(WithBlueGreenRoutes(newFakeRoutes(instID)))
+
+	err := s.Check(context.Background(), blueGreenDeployment(instID, 0), &fakeProvider{})
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Fatalf("Check: want ErrInvalidConfig, got %v", err)
+	}
+
+	if err := s.Check(context.Background(), blueGreenDeployment(instID, 0), &trackProvider{}); err != nil {
+		t.Fatalf("Check with tracks: %v", err)
+	}
+}
+
+// TestBlueGreen_ResumesAfterCutover verifies a rollout interrupted with
+// traffic on green puts the routes back on blue and removes green
+// before running again, and ends on the live workload.
+func TestBlueGreen_ResumesAfterCutover(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	prov := &trackProvider{}
+	routes := newFakeRoutes(instID)
+
+	for _, r := range routes.routes {
+		r.Track = provider.TrackGreen
+	}
+
+	s := NewBlueGreen(WithBlueGreenRoutes(routes))
+	s.pause = noPause
+
+	dep := blueGreenDeployment(instID, time.Second)
+	dep.Phase = deploy.PhaseResuming
+
+	if !s.Resumable(dep) {
+		t.Fatal("blue-green should be resumable")
+	}
+
+	err := s.Execute(context.Background(), deploy.StrategyParams{
+		Deployment: dep,
+		Provider:   prov,
+		OnProgress: func(string, int, string) {},
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if got, want := routes.cutovers, []string{"", provider.TrackGreen, ""}; !slices.Equal(got, want) {
+		t.Errorf("cutovers = %q, want %q", got, want)
+	}
+
+	if prov.removed != 2 || len(prov.tracks) != 1 {
+		t.Errorf("green removed %d times after %d deploys, want 2 after 1", prov.removed, len(prov.tracks))
+	}
+
+	if live := routes.live(t); live.Weight != 100 {
+		t.Errorf("live weight = %d, want 100", live.Weight)
+	}
+}
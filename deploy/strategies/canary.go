@@ -2,26 +2,74 @@ package strategies
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/health"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/metrics"
 	"github.com/xraph/ctrlplane/provider"
 )
 
-// Canary implements a canary deployment strategy that rolls services
-// out one at a time, marking each as succeeded before moving to the
-// next. If any service fails, the strategy aborts — services not yet
-// rolled out stay pending; the deploy.Service marks the deployment
-// itself Failed so callers can rollback the whole release.
+// Canary implements a canary deployment strategy.
 //
-// Canary uses partial-deploy semantics: each Deploy call to the
-// provider lists exactly one service. The provider patches that
-// service's container/task in place without disturbing the rest.
-type Canary struct{}
+// A deployment carrying a deploy.CanaryConfig gets a traffic-weighted
+// canary: the new release runs on the provider's canary track beside
+// the live one (provider.TrackDeployer), a route to the track is added
+// next to each of the instance's routes, and the track's share of
+// traffic is raised step by step. Each step bakes for the configured
+// pause and is then held to the analysis thresholds against the
+// instance's health checks and metrics. Once the last step passes the
+// release is deployed to the live services and the track removed; a
+// breach or error restores the routes' original weights instead.
+// Metrics are per instance, so a step is judged on the canary and the
+// live release together.
+//
+// Without a CanaryConfig, Canary rolls services out one at a time,
+// marking each as succeeded before moving to the next. If any service
+// fails, the strategy aborts — services not yet rolled out stay
+// pending; the deploy.Service marks the deployment itself Failed so
+// callers can rollback the whole release. Each Deploy call to the
+// provider lists exactly one service, which the provider patches in
+// place without disturbing the rest.
+type Canary struct {
+	routes  Routes
+	health  health.Service
+	metrics metrics.Service
+
+	// pause waits between steps and readiness polls; tests replace it.
+	pause func(ctx context.Context, d time.Duration) error
+}
+
+// CanaryOption configures a Canary.
+type CanaryOption func(*Canary)
+
+// WithCanaryRoutes sets the route service a weighted canary shifts
+// traffic through. Weighted canaries fail without one.
+func WithCanaryRoutes(r Routes) CanaryOption {
+	return func(s *Canary) { s.routes = r }
+}
+
+// WithCanaryAnalysis sets the health and metrics services canary steps
+// are analysed against. A nil service skips its thresholds.
+func WithCanaryAnalysis(h health.Service, m metrics.Service) CanaryOption {
+	return func(s *Canary) {
+		s.health = h
+		s.metrics = m
+	}
+}
 
 // NewCanary returns a new canary deployment strategy.
-func NewCanary() *Canary {
-	return &Canary{}
+func NewCanary(opts ...CanaryOption) *Canary {
+	s := &Canary{pause: sleep}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Name returns the strategy identifier.
@@ -29,9 +77,24 @@ func (s *Canary) Name() string {
 	return "canary"
 }
 
-// Execute rolls services out one at a time, advancing
-// ServiceProgress as each completes.
+// Resumable reports whether an interrupted canary can run again. One
+// service at a time it can; a weighted canary can't, because the route
+// weights it left behind would be taken for the originals.
+func (s *Canary) Resumable(dep *deploy.Deployment) bool {
+	return dep.Canary == nil
+}
+
+// Execute runs a weighted canary when the deployment has a
+// CanaryConfig, and rolls services out one at a time otherwise.
 func (s *Canary) Execute(ctx context.Context, params deploy.StrategyParams) error {
+	if params.Deployment.Canary != nil {
+		if err := s.executeWeighted(ctx, params); err != nil {
+			return fmt.Errorf("strategy %s: %w", s.Name(), err)
+		}
+
+		return nil
+	}
+
 	params.OnProgress("canary", 0, "starting canary deployment")
 
 	total := len(params.Deployment.Services)
@@ -40,6 +103,10 @@ func (s *Canary) Execute(ctx context.Context, params deploy.StrategyParams) erro
 	}
 
 	for i, sd := range params.Deployment.Services {
+		if err := cancelled(ctx); err != nil {
+			return fmt.Errorf("strategy %s: %w", s.Name(), err)
+		}
+
 		// Mark this service running; everything not yet started stays
 		// "pending" by default (the deploy service initialises the
 		// progress map that way).
@@ -73,3 +140,199 @@ func (s *Canary) Execute(ctx context.Context, params deploy.StrategyParams) erro
 
 	return nil
 }
+
+// executeWeighted runs the traffic-weighted canary. Every exit after
+// the track starts goes through cleanup, which runs on a context that
+// outlives ctx so traffic is restored even when ctx was cancelled.
+func (s *Canary) executeWeighted(ctx context.Context, params deploy.StrategyParams) error {
+	dep := params.Deployment
+	cfg := dep.Canary
+
+	if s.routes == nil {
+		return errors.New("weighted canary needs a route service")
+	}
+
+	td, err := trackDeployer(params.Provider)
+	if err != nil {
+		return err
+	}
+
+	split, err := newTrafficSplit(ctx, s.routes, dep, provider.TrackCanary)
+	if err != nil {
+		return err
+	}
+
+	markAll(params, deploy.ServiceStateRunning)
+	params.OnProgress("canary", 0, "starting canary track")
+
+	req := provider.DeployRequest{
+		InstanceID: dep.InstanceID,
+		ReleaseID:  dep.ReleaseID,
+		Services:   dep.Services,
+		Strategy:   "canary",
+	}
+
+	// promoting is set once the live services start moving to the
+	// release. Cancelled before then, they never changed and go back
+	// to pending, so a rollback leaves them alone.
+	promoting := false
+
+	fail := func(err error) error {
+		if cancelled(ctx) != nil && !promoting {
+			markAll(params, deploy.ServiceStatePending)
+		} else {
+			markAll(params, deploy.ServiceStateFailed)
+		}
+
+		if cerr := s.cleanup(context.WithoutCancel(ctx), params, td, split); cerr != nil {
+			return fmt.Errorf("%w (restoring traffic: %w)", err, cerr)
+		}
+
+		return err
+	}
+
+	res, err := td.DeployTrack(ctx, provider.TrackCanary, req)
+	if err != nil {
+		return fail(fmt.Errorf("deploy canary track: %w", err))
+	}
+
+	if params.OnTrackEndpoints != nil {
+		params.OnTrackEndpoints(provider.TrackCanary, res.Endpoints)
+	}
+
+	err = waitReady(ctx, s.pause, func(ctx context.Context) (*provider.InstanceStatus, error) {
+		return td.TrackStatus(ctx, dep.InstanceID, provider.TrackCanary)
+	})
+	if err != nil {
+		return fail(fmt.Errorf("canary track: %w", err))
+	}
+
+	for _, weight := range cfg.StepWeights() {
+		if err := cancelled(ctx); err != nil {
+			return fail(err)
+		}
+
+		if err := split.shift(ctx, weight); err != nil {
+			return fail(err)
+		}
+
+		params.OnProgress("canary", weight, fmt.Sprintf("canary receiving %d%% of traffic", weight))
+
+		since := time.Now()
+
+		if err := s.pause(ctx, cfg.StepPause()); err != nil {
+			return fail(err)
+		}
+
+		if err := s.analyze(ctx, dep.InstanceID, since, cfg.Analysis); err != nil {
+			params.OnProgress("aborting", weight, err.Error())
+
+			return fail(fmt.Errorf("aborted at %d%%: %w", weight, err))
+		}
+	}
+
+	// The live services roll to the release while the canary keeps its
+	// share of traffic; nothing moves back until they are ready.
+	if err := cancelled(ctx); err != nil {
+		return fail(err)
+	}
+
+	promoting = true
+
+	params.OnProgress("promoting", 100, "promoting canary release")
+
+	if _, err := params.Provider.Deploy(ctx, req); err != nil {
+		return fail(fmt.Errorf("promote: %w", err))
+	}
+
+	err = waitReady(ctx, s.pause, func(ctx context.Context) (*provider.InstanceStatus, error) {
+		return params.Provider.Status(ctx, dep.InstanceID)
+	})
+	if err != nil {
+		return fail(fmt.Errorf("promote: %w", err))
+	}
+
+	if err := s.cleanup(ctx, params, td, split); err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	markAll(params, deploy.ServiceStateSucceeded)
+	params.OnProgress("complete", 100, "canary promotion complete")
+
+	return nil
+}
+
+// cleanup returns all traffic to the live services and removes the
+// canary track.
+func (s *Canary) cleanup(ctx context.Context, params deploy.StrategyParams, td provider.TrackDeployer, split *trafficSplit) error {
+	err := split.restore(ctx)
+
+	if rerr := td.RemoveTrack(ctx, params.Deployment.InstanceID, provider.TrackCanary); rerr != nil && err == nil {
+		err = fmt.Errorf("remove canary track: %w", rerr)
+	}
+
+	if params.OnTrackEndpoints != nil {
+		params.OnTrackEndpoints(provider.TrackCanary, nil)
+	}
+
+	return err
+}
+
+// analyze holds the instance's health checks and the metrics sampled
+// since the step began to the thresholds, returning the first breach.
+func (s *Canary) analyze(ctx context.Context, instanceID id.ID, since time.Time, a deploy.CanaryAnalysis) error {
+	if s.health != nil {
+		h, err := s.health.GetHealth(ctx, instanceID)
+		if err != nil {
+			return fmt.Errorf("get health: %w", err)
+		}
+
+		failing := 0
+
+		for _, c := range h.Checks {
+			if c.Status == health.StatusUnhealthy || c.Status == health.StatusDegraded {
+				failing++
+			}
+		}
+
+		if failing > a.MaxFailingChecks {
+			return fmt.Errorf("%d failing health checks, %d allowed", failing, a.MaxFailingChecks)
+		}
+	}
+
+	if s.metrics == nil || (a.MaxErrorRate == 0 && a.MaxLatencyP95 == 0) {
+		return nil
+	}
+
+	series, err := s.metrics.Range(ctx, instanceID, metrics.RangeQuery{Since: since, Until: time.Now()})
+	if err != nil {
+		return fmt.Errorf("get metrics: %w", err)
+	}
+
+	var (
+		errSum  float64
+		errN    int
+		worstMs float64
+	)
+
+	for _, smp := range series {
+		// Samples without traffic carry no application signal.
+		if smp.RequestsPerSec > 0 {
+			errSum += smp.ErrorRate
+			errN++
+		}
+
+		worstMs = max(worstMs, smp.LatencyP95Ms)
+	}
+
+	if a.MaxErrorRate > 0 && errN > 0 && errSum/float64(errN) > a.MaxErrorRate {
+		return fmt.Errorf("error rate %.4f above %.4f", errSum/float64(errN), a.MaxErrorRate)
+	}
+
+	worst := time.Duration(worstMs * float64(time.Millisecond))
+	if a.MaxLatencyP95 > 0 && worst > a.MaxLatencyP95.Std() {
+		return fmt.Errorf("p95 latency %s above %s", worst, a.MaxLatencyP95)
+	}
+
+	return nil
+}
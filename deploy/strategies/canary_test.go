@@ -4,10 +4,16 @@ import (
 	"context"
 	"errors"
 	"io"
+	"slices"
 	"testing"
+	"time"
 
+	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/health"
 	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/metrics"
+	"github.com/xraph/ctrlplane/network"
 	"github.com/xraph/ctrlplane/provider"
 )
 
@@ -200,3 +206,401 @@ func TestRolling_AdvancesAllInLockstep(t *testing.T) {
 		}
 	}
 }
+
+// trackProvider is a fakeProvider that can also run a track. Once
+// trackPolls reaches downAfter (when set) the track reports not ready.
+type trackProvider struct {
+	fakeProvider
+
+	tracks     []provider.DeployRequest
+	removed    int
+	trackPolls int
+	downAfter  int
+}
+
+func (f *trackProvider) Capabilities() []provider.Capability {
+	return []provider.Capability{provider.CapCanary, provider.CapBlueGreen}
+}
+
+func (f *trackProvider) DeployTrack(_ context.Context, track string, req provider.DeployRequest) (*provider.DeployResult, error) {
+	f.tracks = append(f.tracks, req)
+
+	return &provider.DeployResult{Endpoints: []provider.Endpoint{{ServiceName: "api", Track: track, Port: 80}}}, nil
+}
+
+func (f *trackProvider) Status(_ context.Context, _ id.ID) (*provider.InstanceStatus, error) {
+	return &provider.InstanceStatus{Ready: true}, nil
+}
+
+func (f *trackProvider) TrackStatus(_ context.Context, _ id.ID, _ string) (*provider.InstanceStatus, error) {
+	f.trackPolls++
+	if f.downAfter > 0 && f.trackPolls >= f.downAfter {
+		return &provider.InstanceStatus{Ready: false, DesiredReplicas: 1}, nil
+	}
+
+	return &provider.InstanceStatus{Ready: true, ReadyReplicas: 1, DesiredReplicas: 1}, nil
+}
+
+func (f *trackProvider) RemoveTrack(_ context.Context, _ id.ID, _ string) error {
+	f.removed++
+
+	return nil
+}
+
+// fakeRoutes is an in-memory route service recording every weight set
+// on the canary routes and every track a live route is moved to.
+type fakeRoutes struct {
+	routes        map[id.ID]*network.Route
+	canaryWeights []int
+	cutovers      []string
+}
+
+func newFakeRoutes(instanceID id.ID) *fakeRoutes {
+	live := &network.Route{Entity: ctrlplane.NewEntity(id.PrefixRoute), InstanceID: instanceID, Path: "/", Port: 80, Weight: 100}
+
+	return &fakeRoutes{routes: map[id.ID]*network.Route{live.ID: live}}
+}
+
+func (f *fakeRoutes) ListRoutes(_ context.Context, _ id.ID) ([]network.Route, error) {
+	out := make([]network.Route, 0, len(f.routes))
+	for _, r := range f.routes {
+		out = append(out, *r)
+	}
+
+	return out, nil
+}
+
+func (f *fakeRoutes) AddRoute(_ context.Context, req network.AddRouteRequest) (*network.Route, error) {
+	r := &network.Route{
+		Entity:     ctrlplane.NewEntity(id.PrefixRoute),
+		InstanceID: req.InstanceID,
+		Path:       req.Path,
+		Port:       req.Port,
+		Weight:     req.Weight,
+		Track:      req.Track,
+	}
+	f.routes[r.ID] = r
+	f.canaryWeights = append(f.canaryWeights, r.Weight)
+
+	return r, nil
+}
+
+func (f *fakeRoutes) UpdateRoute(_ context.Context, routeID id.ID, req network.UpdateRouteRequest) (*network.Route, error) {
+	r := f.routes[routeID]
+
+	if req.Track != nil {
+		r.Track = *req.Track
+		f.cutovers = append(f.cutovers, r.Track)
+	}
+
+	if req.Weight != nil {
+		r.Weight = *req.Weight
+
+		if r.Track != "" {
+			f.canaryWeights = append(f.canaryWeights, r.Weight)
+		}
+	}
+
+	return r, nil
+}
+
+func (f *fakeRoutes) RemoveRoute(_ context.Context, routeID id.ID) error {
+	delete(f.routes, routeID)
+
+	return nil
+}
+
+// live returns the instance's only live route.
+func (f *fakeRoutes) live(t *testing.T) *network.Route {
+	t.Helper()
+
+	var live []*network.Route
+
+	for _, r := range f.routes {
+		if r.Track == "" {
+			live = append(live, r)
+		}
+	}
+
+	if len(live) != 1 || len(f.routes) != 1 {
+		t.Fatalf("routes = %d (%d live), want only the live route", len(f.routes), len(live))
+	}
+
+	return live[0]
+}
+
+// fakeHealth reports a fixed set of check statuses; the rest of
+// health.Service is unused by the canary.
+type fakeHealth struct {
+	health.Service
+
+	statuses []health.Status
+}
+
+func (f *fakeHealth) GetHealth(_ context.Context, instanceID id.ID) (*health.InstanceHealth, error) {
+	h := &health.InstanceHealth{InstanceID: instanceID}
+	for _, s := range f.statuses {
+		h.Checks = append(h.Checks, health.CheckSummary{Status: s})
+	}
+
+	return h, nil
+}
+
+// fakeMetrics returns a fixed series from Range.
+type fakeMetrics struct {
+	metrics.Service
+
+	series metrics.Series
+}
+
+func (f *fakeMetrics) Range(_ context.Context, _ id.ID, _ metrics.RangeQuery) (metrics.Series, error) {
+	return f.series, nil
+}
+
+func noPause(context.Context, time.Duration) error { return nil }
+
+func weightedDeployment(instanceID id.ID, cfg *deploy.CanaryConfig) *deploy.Deployment {
+	return &deploy.Deployment{
+		InstanceID: instanceID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   []provider.ServiceDeploySpec{{Name: "api", Image: "api:v2"}},
+		Canary:     cfg,
+	}
+}
+
+// TestCanary_WeightedPromotes verifies a passing canary walks the
+// traffic schedule, promotes the release to the live services and
+// hands all traffic back to them.
+func TestCanary_WeightedPromotes(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	prov := &trackProvider{}
+	routes := newFakeRoutes(instID)
+	endpoints := map[string][]provider.Endpoint{}
+
+	s := NewCanary(
+		WithCanaryRoutes(routes),
+		WithCanaryAnalysis(&fakeHealth{statuses: []health.Status{health.StatusHealthy}}, &fakeMetrics{series: metrics.Series{
+			{RequestsPerSec: 10, ErrorRate: 0.001, LatencyP95Ms: 80},
+		}}),
+	)
+	s.pause = noPause
+
+	err := s.Execute(context.Background(), deploy.StrategyParams{
+		Deployment: weightedDeployment(instID, &deploy.CanaryConfig{
+			Steps:    []int{10, 50, 100},
+			Analysis: deploy.CanaryAnalysis{MaxErrorRate: 0.01, MaxLatencyP95: deploy.Duration(200 * time.Millisecond)},
+		}),
+		Provider:   prov,
+		OnProgress: func(string, int, string) {},
+		OnTrackEndpoints: func(track string, eps []provider.Endpoint) {
+			endpoints[track] = eps
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if len(prov.tracks) != 1 || prov.tracks[0].Services[0].Image != "api:v2" {
+		t.Fatalf("track deploys = %+v", prov.tracks)
+	}
+
+	if got, want := routes.canaryWeights, []int{10, 50, 100}; !slices.Equal(got, want) {
+		t.Errorf("canary weights = %v, want %v", got, want)
+	}
+
+	if prov.callCount != 1 {
+		t.Errorf("promote Deploy calls = %d, want 1", prov.callCount)
+	}
+
+	if live := routes.live(t); live.Weight != 100 {
+		t.Errorf("live weight = %d, want 100", live.Weight)
+	}
+
+	if prov.removed != 1 || endpoints[provider.TrackCanary] != nil {
+		t.Errorf("track not cleaned up: removed %d, endpoints %v", prov.removed, endpoints)
+	}
+}
+
+// TestCanary_TrackCallsPassThroughMiddleware verifies the canary
+// calls a provider's tracks through the middleware it is wrapped in,
+// rather than around it.
+func TestCanary_TrackCallsPassThroughMiddleware(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	prov := &trackProvider{}
+
+	var methods []string
+
+	record := func(ctx context.Context, call provider.Call, next provider.Invoker) (any, error) {
+		methods = append(methods, call.Method)
+
+		return next(ctx)
+	}
+
+	s := NewCanary(
+		WithCanaryRoutes(newFakeRoutes(instID)),
+		WithCanaryAnalysis(&fakeHealth{statuses: []health.Status{health.StatusHealthy}}, &fakeMetrics{}),
+	)
+	s.pause = noPause
+
+	err := s.Execute(context.Background(), deploy.StrategyParams{
+		Deployment:       weightedDeployment(instID, &deploy.CanaryConfig{Steps: []int{100}}),
+		Provider:         provider.Chain("fake", prov, record),
+		OnProgress:       func(string, int, string) {},
+		OnTrackEndpoints: func(string, []provider.Endpoint) {},
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	for _, m := range []string{provider.MethodDeployTrack, provider.MethodTrackStatus, provider.MethodRemoveTrack} {
+		if !slices.Contains(methods, m) {
+			t.Errorf("middleware saw %v, want %s among them", methods, m)
+		}
+	}
+
+	if len(prov.tracks) != 1 || prov.removed != 1 {
+		t.Fatalf("track deploys %d, removals %d; want 1 each", len(prov.tracks), prov.removed)
+	}
+}
+
+// TestCanary_WeightedAbortsOnBreach verifies each threshold aborts the
+// canary, restores the live route and never promotes.
+func TestCanary_WeightedAbortsOnBreach(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		health  []health.Status
+		sample  metrics.Sample
+		allowed int
+	}{
+		{name: "failing check", health: []health.Status{health.StatusHealthy, health.StatusUnhealthy}},
+		{name: "error rate", sample: metrics.Sample{RequestsPerSec: 10, ErrorRate: 0.2}},
+		{name: "latency", sample: metrics.Sample{RequestsPerSec: 10, LatencyP95Ms: 900}},
+		{name: "checks over allowance", health: []health.Status{health.StatusDegraded, health.StatusUnhealthy}, allowed: 1},
+	} {
+		instID := id.New(id.PrefixInstance)
+		prov := &trackProvider{}
+		routes := newFakeRoutes(instID)
+
+		s := NewCanary(
+			WithCanaryRoutes(routes),
+			WithCanaryAnalysis(&fakeHealth{statuses: tt.health}, &fakeMetrics{series: metrics.Series{tt.sample}}),
+		)
+		s.pause = noPause
+
+		progress := map[string]string{}
+
+		err := s.Execute(context.Background(), deploy.StrategyParams{
+			Deployment: weightedDeployment(instID, &deploy.CanaryConfig{
+				Analysis: deploy.CanaryAnalysis{
+					MaxErrorRate:     0.05,
+					MaxLatencyP95:    deploy.Duration(500 * time.Millisecond),
+					MaxFailingChecks: tt.allowed,
+				},
+			}),
+			Provider:   prov,
+			OnProgress: func(string, int, string) {},
+			OnServiceProgress: func(name, state string) {
+				progress[name] = state
+			},
+		})
+		if err == nil {
+			t.Errorf("%s: Execute succeeded, want an abort", tt.name)
+
+			continue
+		}
+
+		// The default schedule's first step is where it stops.
+		if !slices.Equal(routes.canaryWeights, []int{5}) {
+			t.Errorf("%s: canary weights = %v, want [5]", tt.name, routes.canaryWeights)
+		}
+
+		if prov.callCount != 0 {
+			t.Errorf("%s: promoted after a breach", tt.name)
+		}
+
+		if live := routes.live(t); live.Weight != 100 {
+			t.Errorf("%s: live weight = %d, want 100", tt.name, live.Weight)
+		}
+
+		if prov.removed != 1 || progress["api"] != deploy.ServiceStateFailed {
+			t.Errorf("%s: removed %d, progress %v", tt.name, prov.removed, progress)
+		}
+	}
+}
+
+// TestCanary_WeightedNeedsTrackProvider verifies a provider that can't
+// run a track is refused before any traffic moves.
+func TestCanary_WeightedNeedsTrackProvider(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	routes := newFakeRoutes(instID)
+	prov := &fakeProvider{}
+
+	err := NewCanary(WithCanaryRoutes(routes)).Execute(context.Background(), deploy.StrategyParams{
+		Deployment: weightedDeployment(instID, &deploy.CanaryConfig{}),
+		Provider:   prov,
+		OnProgress: func(string, int, string) {},
+	})
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Fatalf("err = %v, want ErrInvalidConfig", err)
+	}
+
+	if len(routes.canaryWeights) != 0 || prov.callCount != 0 {
+		t.Errorf("traffic moved or provider called: weights %v, deploys %d", routes.canaryWeights, prov.callCount)
+	}
+}
+
+// TestCanary_WeightedStopsWhenCancelled verifies a canary cancelled
+// during a step restores the live route, never promotes, and leaves
+// the untouched live services pending.
+func TestCanary_WeightedStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	prov := &trackProvider{}
+	routes := newFakeRoutes(instID)
+	stop := errors.New("stop")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	s := NewCanary(WithCanaryRoutes(routes))
+	s.pause = func(ctx context.Context, _ time.Duration) error {
+		cancel(stop)
+
+		return context.Cause(ctx)
+	}
+
+	progress := map[string]string{}
+
+	err := s.Execute(ctx, deploy.StrategyParams{
+		Deployment: weightedDeployment(instID, &deploy.CanaryConfig{Steps: []int{10, 50, 100}}),
+		Provider:   prov,
+		OnProgress: func(string, int, string) {},
+		OnServiceProgress: func(name, state string) {
+			progress[name] = state
+		},
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("err = %v, want the cancellation cause", err)
+	}
+
+	if !slices.Equal(routes.canaryWeights, []int{10}) || prov.callCount != 0 {
+		t.Errorf("kept going after the cancel: weights %v, deploys %d", routes.canaryWeights, prov.callCount)
+	}
+
+	if live := routes.live(t); live.Weight != 100 {
+		t.Errorf("live weight = %d, want 100", live.Weight)
+	}
+
+	if prov.removed != 1 || progress["api"] != deploy.ServiceStatePending {
+		t.Errorf("removed %d, progress %v", prov.removed, progress)
+	}
+}
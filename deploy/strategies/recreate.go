@@ -22,11 +22,21 @@ func (s *Recreate) Name() string {
 	return "recreate"
 }
 
+// Resumable reports that an interrupted recreate deployment can run
+// again, for the same reason as Rolling's.
+func (s *Recreate) Resumable(*deploy.Deployment) bool {
+	return true
+}
+
 // Execute performs a recreate deployment by stopping the current version
 // and starting the new one. Like Rolling, this advances every service
 // through the same state in lockstep — the provider does the actual
 // stop/start.
 func (s *Recreate) Execute(ctx context.Context, params deploy.StrategyParams) error {
+	if err := cancelled(ctx); err != nil {
+		return fmt.Errorf("strategy %s: %w", s.Name(), err)
+	}
+
 	params.OnProgress("stopping", 0, "stopping current version")
 
 	markAll(params, deploy.ServiceStateRunning)
@@ -22,12 +22,22 @@ func (s *Rolling) Name() string {
 	return "rolling"
 }
 
+// Resumable reports that an interrupted rolling deployment can run
+// again: handing the provider the same services twice is idempotent.
+func (s *Rolling) Resumable(*deploy.Deployment) bool {
+	return true
+}
+
 // Execute performs a rolling deployment by handing the entire Services
 // slice to the provider in one shot — the provider's runtime
 // (k8s rollingUpdate, nomad update, docker recreate-per-service) does
 // the per-replica gradient. ServiceProgress is updated in lockstep:
 // every service goes pending → running → succeeded together.
 func (s *Rolling) Execute(ctx context.Context, params deploy.StrategyParams) error {
+	if err := cancelled(ctx); err != nil {
+		return fmt.Errorf("strategy %s: %w", s.Name(), err)
+	}
+
 	params.OnProgress("deploying", 0, "starting rolling update")
 
 	markAll(params, deploy.ServiceStateRunning)
@@ -50,6 +60,17 @@ func (s *Rolling) Execute(ctx context.Context, params deploy.StrategyParams) err
 	return nil
 }
 
+// cancelled returns why ctx was cancelled, or nil while it is live.
+// Strategies check it before each step that touches the provider, so a
+// cancelled deployment stops where it is.
+func cancelled(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+
+	return context.Cause(ctx)
+}
+
 // markAll updates every service in the deployment to the same state.
 // Strategies that don't have per-service granularity (rolling /
 // recreate / blue-green) use this; canary sets state per-service.
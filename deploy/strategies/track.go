@@ -0,0 +1,225 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/network"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+const (
+	// trackReadyTimeout bounds how long a strategy waits for pods or
+	// containers to become ready.
+	trackReadyTimeout = 5 * time.Minute
+
+	// trackPollInterval is how often readiness is polled.
+	trackPollInterval = 2 * time.Second
+)
+
+// Routes is the part of network.Service the traffic-shifting
+// strategies drive. Weight changes go through the service so the
+// stored routes and the router agree.
+type Routes interface {
+	ListRoutes(ctx context.Context, instanceID id.ID) ([]network.Route, error)
+	AddRoute(ctx context.Context, req network.AddRouteRequest) (*network.Route, error)
+	UpdateRoute(ctx context.Context, routeID id.ID, req network.UpdateRouteRequest) (*network.Route, error)
+	RemoveRoute(ctx context.Context, routeID id.ID) error
+}
+
+// errNoRoutes is returned by newTrafficSplit for an instance without
+// routes to the services being deployed.
+var errNoRoutes = errors.New("no routes to shift traffic on")
+
+// trackDeployer returns the provider's TrackDeployer. Middleware and
+// fault injection forward it, so track calls go through them.
+func trackDeployer(p provider.Provider) (provider.TrackDeployer, error) {
+	td, ok := p.(provider.TrackDeployer)
+	if !ok {
+		return nil, fmt.Errorf("provider %s can't run a release beside the live one: %w", p.Info().Name, ctrlplane.ErrInvalidConfig)
+	}
+
+	return td, nil
+}
+
+// sleep waits for d or until ctx is done, returning why it was.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-t.C:
+		return nil
+	}
+}
+
+// waitReady polls status until every desired replica is ready, giving
+// up after trackReadyTimeout.
+func waitReady(ctx context.Context, pause func(context.Context, time.Duration) error, status func(context.Context) (*provider.InstanceStatus, error)) error {
+	for range trackReadyTimeout / trackPollInterval {
+		st, err := status(ctx)
+		if err == nil && st.Ready && st.ReadyReplicas >= st.DesiredReplicas {
+			return nil
+		}
+
+		if err := pause(ctx, trackPollInterval); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("not ready after %s", trackReadyTimeout)
+}
+
+// trafficSplit pairs each live route of an instance with a route to a
+// track on the same path, and moves traffic between the two.
+type trafficSplit struct {
+	routes Routes
+	track  string
+	pairs  []routePair
+}
+
+// routePair is a live route, its weight before the split, and the
+// track route beside it once created. cut is set while the live route
+// itself points at the track.
+type routePair struct {
+	live   network.Route
+	weight int
+	track  *network.Route
+	cut    bool
+}
+
+// newTrafficSplit collects the instance's live routes to the services
+// being deployed — a route naming no service goes to Main, which any
+// deploy may change. It fails with errNoRoutes when there is nothing
+// to split.
+func newTrafficSplit(ctx context.Context, routes Routes, dep *deploy.Deployment, track string) (*trafficSplit, error) {
+	all, err := routes.ListRoutes(ctx, dep.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("list routes: %w", err)
+	}
+
+	s := &trafficSplit{routes: routes, track: track}
+
+	for _, r := range all {
+		if r.Track != "" {
+			continue
+		}
+
+		deployed := slices.ContainsFunc(dep.Services, func(sd provider.ServiceDeploySpec) bool {
+			return sd.Name == r.ServiceName
+		})
+		if r.ServiceName == "" || deployed {
+			s.pairs = append(s.pairs, routePair{live: r, weight: r.Weight})
+		}
+	}
+
+	if len(s.pairs) == 0 {
+		return nil, fmt.Errorf("instance %s: %w: %w", dep.InstanceID, errNoRoutes, ctrlplane.ErrInvalidConfig)
+	}
+
+	return s, nil
+}
+
+// shift sends percent of each route's traffic to the track, creating
+// the track routes on first use. The track route is raised before the
+// live one is lowered, so a path never goes without a backend.
+func (s *trafficSplit) shift(ctx context.Context, percent int) error {
+	for i := range s.pairs {
+		p := &s.pairs[i]
+		trackWeight := max(p.weight*percent/100, 1)
+
+		if p.track == nil {
+			r, err := s.routes.AddRoute(ctx, network.AddRouteRequest{
+				InstanceID:  p.live.InstanceID,
+				ServiceName: p.live.ServiceName,
+				Path:        p.live.Path,
+				Port:        p.live.Port,
+				Protocol:    p.live.Protocol,
+				Weight:      trackWeight,
+				StripPrefix: p.live.StripPrefix,
+				Track:       s.track,
+			})
+			if err != nil {
+				return fmt.Errorf("add %s route %s: %w", s.track, p.live.Path, err)
+			}
+
+			p.track = r
+		} else if err := s.setWeight(ctx, p.track.ID, trackWeight); err != nil {
+			return err
+		}
+
+		if err := s.setWeight(ctx, p.live.ID, p.weight-p.weight*percent/100); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cutover points every live route at the track's endpoints. Each
+// route flips in a single update, so a path moves all at once.
+func (s *trafficSplit) cutover(ctx context.Context) error {
+	for i := range s.pairs {
+		p := &s.pairs[i]
+
+		if _, err := s.routes.UpdateRoute(ctx, p.live.ID, network.UpdateRouteRequest{Track: &s.track}); err != nil {
+			return fmt.Errorf("cut route %s over to %s: %w", p.live.Path, s.track, err)
+		}
+
+		p.cut = true
+	}
+
+	return nil
+}
+
+// restore gives every live route its original weight and backend back
+// and removes the track routes. It keeps going past failures so as
+// much traffic as possible is restored, and returns the first.
+func (s *trafficSplit) restore(ctx context.Context) error {
+	var first error
+
+	for i := range s.pairs {
+		p := &s.pairs[i]
+
+		if p.cut {
+			live := ""
+			if _, err := s.routes.UpdateRoute(ctx, p.live.ID, network.UpdateRouteRequest{Weight: &p.weight, Track: &live}); err != nil {
+				if first == nil {
+					first = fmt.Errorf("cut route %s back: %w", p.live.Path, err)
+				}
+			} else {
+				p.cut = false
+			}
+		} else if err := s.setWeight(ctx, p.live.ID, p.weight); err != nil && first == nil {
+			first = err
+		}
+
+		if p.track == nil {
+			continue
+		}
+
+		if err := s.routes.RemoveRoute(ctx, p.track.ID); err != nil && first == nil {
+			first = fmt.Errorf("remove %s route %s: %w", s.track, p.live.Path, err)
+		}
+
+		p.track = nil
+	}
+
+	return first
+}
+
+func (s *trafficSplit) setWeight(ctx context.Context, routeID id.ID, weight int) error {
+	if _, err := s.routes.UpdateRoute(ctx, routeID, network.UpdateRouteRequest{Weight: &weight}); err != nil {
+		return fmt.Errorf("update route %s: %w", routeID, err)
+	}
+
+	return nil
+}
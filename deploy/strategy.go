@@ -26,11 +26,17 @@ type Strategy interface {
 // per-service granularity. Strategies that don't have per-service
 // granularity (rolling, recreate) update every service to the same
 // state in lockstep.
+//
+// OnTrackEndpoints (optional) is invoked when a strategy starts or
+// removes a track (see provider.TrackDeployer): the deploy service
+// replaces the instance's endpoints on that track with endpoints, so
+// routers can resolve routes to the track. Nil endpoints clear them.
 type StrategyParams struct {
 	Deployment        *Deployment
 	Provider          provider.Provider
 	OnProgress        func(phase string, percent int, message string)
 	OnServiceProgress func(serviceName string, state string)
+	OnTrackEndpoints  func(track string, endpoints []provider.Endpoint)
 }
 
 // Service-level progress states. Match the keys used in
@@ -41,3 +47,25 @@ const (
 	ServiceStateSucceeded = "succeeded"
 	ServiceStateFailed    = "failed"
 )
+
+// Resumer is implemented by strategies that can run a deployment again
+// from the start after a control-plane restart interrupted it. Their
+// steps converge on the release, so a second run finishes what the
+// first began. A Runner fails interrupted deployments of every other
+// strategy.
+type Resumer interface {
+	Resumable(dep *Deployment) bool
+}
+
+// PhaseResuming is the Deployment.Phase a Runner sets before it runs
+// an interrupted deployment again, so a Resumer can tell a rerun from
+// a first run.
+const PhaseResuming = "resuming"
+
+// Checker is implemented by strategies that can tell before a
+// deployment is queued that it can't run on the instance's provider,
+// so Deploy refuses the request rather than queueing a deployment
+// bound to fail. dep has no ID or release yet.
+type Checker interface {
+	Check(ctx context.Context, dep *Deployment, prov provider.Provider) error
+}
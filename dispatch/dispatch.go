@@ -99,10 +99,11 @@ func Provision(ctx context.Context, p provider.Provider, req Request) (*provider
 }
 
 // Deprovision routes teardown by source type. An empty sourceType (legacy
-// instances that predate Source) is treated as services.
+// instances that predate Source) is treated as services, as is compose,
+// which renders to services.
 func Deprovision(ctx context.Context, p provider.Provider, sourceType provider.SourceType, instanceID id.ID) error {
 	switch sourceType {
-	case provider.SourceServices, "":
+	case provider.SourceServices, provider.SourceCompose, "":
 		return p.Deprovision(ctx, instanceID)
 	case provider.SourceManifests:
 		eng, ok := manifestEngine(p)
@@ -130,11 +131,11 @@ func Deprovision(ctx context.Context, p provider.Provider, sourceType provider.S
 	}
 }
 
-// Status routes a status read by source type. An empty sourceType is treated
-// as services.
+// Status routes a status read by source type. An empty sourceType and
+// compose are treated as services.
 func Status(ctx context.Context, p provider.Provider, sourceType provider.SourceType, instanceID id.ID) (*provider.InstanceStatus, error) {
 	switch sourceType {
-	case provider.SourceServices, "":
+	case provider.SourceServices, provider.SourceCompose, "":
 		return p.Status(ctx, instanceID)
 	case provider.SourceManifests:
 		eng, ok := manifestEngine(p)
@@ -168,6 +168,7 @@ func TestDeprovision_RoutesByType(t *testing.T) {
 	}{
 		{"services", provider.SourceServices, "Deprovision"},
 		{"empty-legacy", "", "Deprovision"},
+		{"compose", provider.SourceCompose, "Deprovision"},
 		{"manifests", provider.SourceManifests, "DeleteManifests"},
 		{"helm", provider.SourceHelm, "HelmUninstall"},
 		{"argocd", provider.SourceArgoCD, "ArgoDelete"},
@@ -195,6 +196,7 @@ func TestStatus_RoutesByType(t *testing.T) {
 		want string
 	}{
 		{"services", provider.SourceServices, "Status"},
+		{"compose", provider.SourceCompose, "Status"},
 		{"manifests", provider.SourceManifests, "ManifestStatus"},
 		{"helm", provider.SourceHelm, "HelmStatus"},
 		{"argocd", provider.SourceArgoCD, "ArgoStatus"},
@@ -47,6 +47,7 @@ const (
 	DeploySucceeded  Type = "deploy.succeeded"
 	DeployFailed     Type = "deploy.failed"
 	DeployRolledBack Type = "deploy.rolled_back"
+	DeployCancelled  Type = "deploy.cancelled"
 )
 
 // Health events.
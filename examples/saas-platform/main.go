@@ -193,6 +193,7 @@ func subscribeEvents(events event.Bus) {
 		event.DeploySucceeded,
 		event.DeployFailed,
 		event.DeployRolledBack,
+		event.DeployCancelled,
 	)
 
 	// Health events — log warnings for failures and degraded state.
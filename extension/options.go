@@ -20,10 +20,19 @@ func WithAuthProvider(p auth.Provider) ExtOption {
 	}
 }
 
-// WithProvider registers a cloud/orchestrator provider.
-func WithProvider(name string, p provider.Provider) ExtOption {
+// WithProvider registers a cloud/orchestrator provider, decorated by
+// any wrappers (see app.WithProvider).
+func WithProvider(name string, p provider.Provider, wrappers ...app.ProviderWrapper) ExtOption {
 	return func(e *Extension) {
-		e.opts = append(e.opts, app.WithProvider(name, p))
+		e.opts = append(e.opts, app.WithProvider(name, p, wrappers...))
+	}
+}
+
+// WithProviderMiddleware adds middleware to every provider's call
+// chain (see app.WithProviderMiddleware).
+func WithProviderMiddleware(mws ...provider.Middleware) ExtOption {
+	return func(e *Extension) {
+		e.opts = append(e.opts, app.WithProviderMiddleware(mws...))
 	}
 }
 
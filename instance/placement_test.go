@@ -0,0 +1,63 @@
+package instance
+
+import (
+	"errors"
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestCreate_HardPlacementNeedsCapability verifies hard placement is
+// refused before anything is stored when the provider can't honour it,
+// and passed through when it can.
+func TestCreate_HardPlacementNeedsCapability(t *testing.T) {
+	req := CreateRequest{
+		Name:         "web",
+		ProviderName: "kubernetes",
+		Services: []provider.ServiceSpec{{
+			Name:      "web",
+			Image:     "web:1",
+			Placement: &provider.Placement{NodeSelector: map[string]string{"pool": "gpu"}},
+		}},
+	}
+
+	for _, tt := range []struct {
+		name string
+		caps []provider.Capability
+		ok   bool
+	}{
+		{"without capability", []provider.Capability{provider.CapProvision}, false},
+		{"with capability", []provider.Capability{provider.CapProvision, provider.CapPlacement}, true},
+	} {
+		prov := &scaleProvider{caps: tt.caps}
+		registry := provider.NewRegistry()
+		registry.Register("kubernetes", prov)
+
+		st := newDelStore()
+		svc := NewService(st, registry, event.NewInMemoryBus(), nil, nil)
+
+		_, err := svc.Create(srcCtx(), req)
+
+		if tt.ok {
+			if err != nil {
+				t.Fatalf("%s: create: %v", tt.name, err)
+			}
+
+			if got := prov.provisioned[0].Placement; got == nil || got.NodeSelector["pool"] != "gpu" {
+				t.Errorf("%s: provisioned placement = %+v", tt.name, got)
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", tt.name, err)
+		}
+
+		if len(st.workloads) != 0 {
+			t.Errorf("%s: %d instances stored, want none", tt.name, len(st.workloads))
+		}
+	}
+}
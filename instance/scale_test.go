@@ -0,0 +1,124 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// scaleProvider records the spec passed to Scale and advertises caps.
+type scaleProvider struct {
+	srcProvider
+
+	caps   []provider.Capability
+	scaled []provider.ResourceSpec
+}
+
+func (p *scaleProvider) Capabilities() []provider.Capability { return p.caps }
+
+func (p *scaleProvider) Scale(_ context.Context, _ id.ID, spec provider.ResourceSpec) error {
+	p.scaled = append(p.scaled, spec)
+
+	return nil
+}
+
+func newScaleService(t *testing.T, caps ...provider.Capability) (Service, *scaleProvider, *Instance) {
+	t.Helper()
+
+	prov := &scaleProvider{caps: caps}
+	registry := provider.NewRegistry()
+	registry.Register("kubernetes", prov)
+
+	svc := NewService(newDelStore(), registry, event.NewInMemoryBus(), nil, nil)
+
+	inst, err := svc.Create(srcCtx(), CreateRequest{
+		Name:         "web",
+		ProviderName: "kubernetes",
+		Services: []provider.ServiceSpec{{
+			Name:      "web",
+			Image:     "web:1",
+			Resources: provider.ResourceSpec{CPUMillis: 250, MemoryMB: 256, Replicas: 2},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	return svc, prov, inst
+}
+
+func TestScale_Autoscale(t *testing.T) {
+	svc, prov, inst := newScaleService(t, provider.CapScale, provider.CapAutoScale)
+
+	err := svc.Scale(srcCtx(), inst.ID, ScaleRequest{
+		Autoscale: &provider.AutoscaleSpec{MinReplicas: 2, MaxReplicas: 8, TargetCPUPercent: 60},
+	})
+	if err != nil {
+		t.Fatalf("scale: %v", err)
+	}
+
+	got := prov.scaled[0]
+	if !got.Autoscale.Enabled() || got.Autoscale.MaxReplicas != 8 || got.CPUMillis != 250 || got.Replicas != 2 {
+		t.Fatalf("provider spec = %+v, want the stored resources plus autoscale", got)
+	}
+
+	stored, err := svc.Get(srcCtx(), inst.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	if a := stored.MainService().Resources.Autoscale; !a.Enabled() || a.MinReplicas != 2 {
+		t.Fatalf("stored autoscale = %+v", a)
+	}
+
+	// A later CPU-only resize keeps autoscaling on.
+	cpu := 500
+	if err := svc.Scale(srcCtx(), inst.ID, ScaleRequest{CPUMillis: &cpu}); err != nil {
+		t.Fatalf("resize: %v", err)
+	}
+
+	if !prov.scaled[1].Autoscale.Enabled() {
+		t.Fatal("resize dropped the autoscale spec")
+	}
+
+	// A zero MaxReplicas turns it off.
+	if err := svc.Scale(srcCtx(), inst.ID, ScaleRequest{Autoscale: &provider.AutoscaleSpec{}}); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+
+	if prov.scaled[2].Autoscale != nil {
+		t.Fatalf("disable passed autoscale %+v, want nil", prov.scaled[2].Autoscale)
+	}
+}
+
+func TestScale_AutoscaleRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		caps []provider.Capability
+		spec provider.AutoscaleSpec
+	}{
+		{"unsupported provider", []provider.Capability{provider.CapScale}, provider.AutoscaleSpec{MinReplicas: 1, MaxReplicas: 3}},
+		{"max below min", []provider.Capability{provider.CapAutoScale}, provider.AutoscaleSpec{MinReplicas: 4, MaxReplicas: 3}},
+		{"zero min", []provider.Capability{provider.CapAutoScale}, provider.AutoscaleSpec{MaxReplicas: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, prov, inst := newScaleService(t, tt.caps...)
+
+			err := svc.Scale(srcCtx(), inst.ID, ScaleRequest{Autoscale: &tt.spec})
+			if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+				t.Fatalf("err = %v, want ErrInvalidConfig", err)
+			}
+
+			if len(prov.scaled) != 0 {
+				t.Fatal("provider Scale called for a rejected request")
+			}
+		})
+	}
+}
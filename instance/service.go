@@ -90,7 +90,7 @@ type DatacenterResolver interface {
 // CreateRequest holds the parameters for creating a new instance.
 //
 // A non-services deployment is described via Source (helm | manifests |
-// argocd) plus optional Variables/VariableValues resolved at provision
+// argocd | compose) plus optional Variables/VariableValues resolved at provision
 // time. For backward compatibility, callers may instead populate Services
 // alone — Create projects them onto a services Source.
 type CreateRequest struct {
@@ -120,10 +120,15 @@ type UpdateRequest struct {
 }
 
 // ScaleRequest holds the parameters for scaling an instance.
+//
+// Autoscale replaces the Main service's autoscaling bounds; a spec
+// with a zero MaxReplicas turns autoscaling off and hands the replica
+// count back to Replicas.
 type ScaleRequest struct {
-	CPUMillis *int `json:"cpu_millis,omitempty"`
-	MemoryMB  *int `json:"memory_mb,omitempty"`
-	Replicas  *int `json:"replicas,omitempty"`
+	CPUMillis *int                    `json:"cpu_millis,omitempty"`
+	MemoryMB  *int                    `json:"memory_mb,omitempty"`
+	Replicas  *int                    `json:"replicas,omitempty"`
+	Autoscale *provider.AutoscaleSpec `json:"autoscale,omitempty"`
 }
 
 // ListOptions configures instance listing with optional filters and pagination.
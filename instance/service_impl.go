@@ -25,6 +25,7 @@ type service struct {
 	datacenters DatacenterResolver
 	events      event.Bus
 	auth        auth.Provider
+	registry    provider.RegistryResolver
 }
 
 // NewService creates a new instance service.
@@ -39,6 +40,13 @@ func NewService(store Store, providers *provider.Registry, events event.Bus, aut
 	}
 }
 
+// SetRegistryResolver sets the resolver used to turn a service's
+// registry-type secrets into pull credentials at provision time.
+// Without one, providers pull anonymously.
+func (s *service) SetRegistryResolver(r provider.RegistryResolver) {
+	s.registry = r
+}
+
 // Create provisions a new instance on the resolved provider.
 func (s *service) Create(ctx context.Context, req CreateRequest) (*Instance, error) {
 	claims, err := auth.RequireClaims(ctx)
@@ -85,6 +93,12 @@ func (s *service) Create(ctx context.Context, req CreateRequest) (*Instance, err
 		return nil, fmt.Errorf("create instance: %w", err)
 	}
 
+	// Refuse hard placement up front rather than persist an instance
+	// the provider is bound to fail.
+	if placement, _ := provider.WorkloadPlacement(source.Services); placement.Hard() && !provider.HasCapability(p, provider.CapPlacement) {
+		return nil, fmt.Errorf("create instance: %w: provider %s does not support placement constraints", ctrlplane.ErrInvalidConfig, p.Info().Name)
+	}
+
 	kind := req.Kind
 	if kind == "" {
 		kind = provider.KindDeployment
@@ -179,6 +193,22 @@ func (s *service) provisionSource(
 		return nil, fmt.Errorf("render source: %w", err)
 	}
 
+	// A compose file is only translated at render time; keep the
+	// resulting specs on the instance so deploys, restarts and
+	// rollbacks see its services like any services instance's.
+	if source.Type == provider.SourceCompose {
+		inst.Services = rendered.Services
+	}
+
+	// Pull credentials are resolved after rendering (the image may be
+	// templated) and only on the request — they're never stored.
+	if rendered.Type == provider.SourceServices {
+		rendered.Services, err = provider.ResolveServiceRegistryAuth(ctx, s.registry, inst.ID, rendered.Services)
+		if err != nil {
+			return nil, fmt.Errorf("resolve registry credentials: %w", err)
+		}
+	}
+
 	return dispatch.Provision(ctx, p, dispatch.Request{
 		InstanceID: inst.ID,
 		TenantID:   inst.TenantID,
@@ -525,11 +555,26 @@ func (s *service) Scale(ctx context.Context, instanceID id.ID, req ScaleRequest)
 		spec.Replicas = *req.Replicas
 	}
 
+	if req.Autoscale != nil {
+		if err := req.Autoscale.Validate(); err != nil {
+			return fmt.Errorf("scale instance: %w", err)
+		}
+
+		spec.Autoscale = nil
+		if req.Autoscale.Enabled() {
+			spec.Autoscale = req.Autoscale
+		}
+	}
+
 	p, err := s.providers.Get(inst.ProviderName)
 	if err != nil {
 		return fmt.Errorf("scale instance: resolve provider: %w", err)
 	}
 
+	if spec.Autoscale.Enabled() && !provider.HasCapability(p, provider.CapAutoScale) {
+		return fmt.Errorf("scale instance: %w: provider %s does not support autoscaling", ctrlplane.ErrInvalidConfig, inst.ProviderName)
+	}
+
 	if err := p.Scale(ctx, inst.ID, spec); err != nil {
 		return fmt.Errorf("scale instance: provider scale: %w", err)
 	}
@@ -549,6 +594,7 @@ func (s *service) Scale(ctx context.Context, instanceID id.ID, req ScaleRequest)
 			"cpu_millis": spec.CPUMillis,
 			"memory_mb":  spec.MemoryMB,
 			"replicas":   spec.Replicas,
+			"autoscale":  spec.Autoscale,
 		}))
 
 	return nil
@@ -659,9 +705,10 @@ func (s *service) Logs(ctx context.Context, instanceID id.ID, opts LogsOptions)
 	}
 
 	rc, err := p.Logs(ctx, inst.ID, provider.LogOptions{
-		Follow: opts.Follow,
-		Since:  opts.Since,
-		Tail:   opts.Tail,
+		ServiceName: opts.ServiceName,
+		Follow:      opts.Follow,
+		Since:       opts.Since,
+		Tail:        opts.Tail,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("logs: provider: %w", err)
@@ -19,6 +19,9 @@ type srcProvider struct {
 	applied  bool
 	deleted  bool
 	manifest bool
+
+	// provisioned records the services of the last core Provision.
+	provisioned []provider.ServiceSpec
 }
 
 func (p *srcProvider) Info() provider.ProviderInfo { return provider.ProviderInfo{Name: "kubernetes"} }
@@ -27,7 +30,9 @@ func (p *srcProvider) Capabilities() []provider.Capability {
 	return []provider.Capability{provider.CapProvision, provider.CapManifests}
 }
 
-func (p *srcProvider) Provision(context.Context, provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+func (p *srcProvider) Provision(_ context.Context, req provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+	p.provisioned = req.Services
+
 	return &provider.ProvisionResult{ProviderRef: "core"}, nil
 }
 func (p *srcProvider) Deprovision(context.Context, id.ID) error { return nil }
@@ -117,3 +122,38 @@ func TestCreate_ManifestsSource_DispatchesToEngine(t *testing.T) {
 		t.Error("expected DeleteManifests to be called on delete")
 	}
 }
+
+func TestCreate_ComposeSource_ProvisionsServices(t *testing.T) {
+	store := newDelStore()
+	prov := &srcProvider{}
+	registry := provider.NewRegistry()
+	registry.Register("kubernetes", prov)
+
+	svc := NewService(store, registry, event.NewInMemoryBus(), nil, nil)
+
+	inst, err := svc.Create(srcCtx(), CreateRequest{
+		Name:         "compose",
+		ProviderName: "kubernetes",
+		Source: provider.DeploymentSource{
+			Type:    provider.SourceCompose,
+			Compose: &provider.ComposeSource{File: "services:\n  web:\n    image: web:{{ .var.tag }}\n"},
+		},
+		Variables:      []vars.Definition{{Name: "tag", Type: vars.TypeString, Default: "1"}},
+		VariableValues: map[string]any{"tag": "2"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if len(prov.provisioned) != 1 || prov.provisioned[0].Image != "web:2" {
+		t.Fatalf("provisioned services = %+v, want web:2 through the core Provision", prov.provisioned)
+	}
+
+	if inst.Source.Type != provider.SourceCompose {
+		t.Errorf("instance source type = %q, want compose", inst.Source.Type)
+	}
+
+	if len(inst.Services) != 1 || inst.Services[0].Role != provider.RoleMain {
+		t.Errorf("instance services = %+v, want the translated web service", inst.Services)
+	}
+}
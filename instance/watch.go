@@ -0,0 +1,189 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// watchActor is the ActorID stamped on events published for changes a
+// provider Watcher observed, so they're distinguishable from
+// user-driven transitions in the audit trail.
+const watchActor = "system:watcher"
+
+// Backoff between re-watch attempts after a provider's stream breaks.
+const (
+	watchRetryBase = time.Second
+	watchRetryMax  = time.Minute
+)
+
+// WatchProviders consumes the state-change stream of every registered
+// provider that implements provider.Watcher, until ctx is done. It is
+// not part of Service; the control plane starts it from Start.
+//
+// A broken stream is re-opened with capped exponential backoff. A
+// provider whose Watch reports ctrlplane.ErrNotImplemented (a wrapper
+// around a provider that can't watch) is skipped for the rest of the
+// run. Providers registered after WatchProviders starts aren't
+// watched.
+func (s *service) WatchProviders(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for name, p := range s.providers.All() {
+		w, ok := p.(provider.Watcher)
+		if !ok {
+			continue
+		}
+
+		wg.Go(func() { s.watchProvider(ctx, name, w) })
+	}
+
+	wg.Wait()
+}
+
+// watchProvider runs one provider's watch loop.
+func (s *service) watchProvider(ctx context.Context, name string, w provider.Watcher) {
+	delay := watchRetryBase
+
+	for ctx.Err() == nil {
+		changes, err := w.Watch(ctx)
+		if errors.Is(err, ctrlplane.ErrNotImplemented) {
+			return
+		}
+
+		if err == nil {
+			received := false
+
+			for c := range changes {
+				received = true
+
+				s.applyChange(ctx, name, c)
+			}
+
+			// A stream that delivered anything was healthy; start
+			// the next attempt's backoff from the bottom.
+			if received {
+				delay = watchRetryBase
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, watchRetryMax)
+	}
+}
+
+// applyChange persists and publishes one observed change. Changes for
+// instances the store doesn't know, or that belong to another
+// provider, are dropped.
+func (s *service) applyChange(ctx context.Context, providerName string, c provider.StateChange) {
+	// Every store lookup is tenant-scoped; a backend object without
+	// the tenant label can't be resolved.
+	if c.TenantID == "" {
+		return
+	}
+
+	inst, err := s.store.GetByID(ctx, c.TenantID, c.InstanceID)
+	if err != nil || inst.ProviderName != providerName {
+		return
+	}
+
+	switch c.Kind {
+	case provider.ChangeState:
+		s.applyObservedState(ctx, inst, c)
+	case provider.ChangeRestart, provider.ChangeOOMKilled:
+		// The backend's restart policy usually brings the service
+		// back, so the instance's state is left alone; the event is
+		// the signal.
+		_ = s.events.Publish(ctx, event.NewEvent(event.InstanceFailed, inst.TenantID).
+			WithInstance(inst.ID).
+			WithActor(watchActor).
+			WithPayload(map[string]any{
+				"reason":   string(c.Kind),
+				"service":  c.Service,
+				"restarts": c.Restarts,
+				"message":  c.Message,
+				"at":       c.At,
+			}))
+	}
+}
+
+// applyObservedState moves a settled instance to the state its
+// provider reports. Instances in a transient state (provisioning,
+// starting, stopping, destroying) are owned by the operation in
+// flight, which persists its own outcome, so observations are ignored
+// for them. Observed state is fact rather than a request, so
+// ValidateTransition is not consulted.
+func (s *service) applyObservedState(ctx context.Context, inst *Instance, c provider.StateChange) {
+	if c.Status == nil || !settledState(inst.State) {
+		return
+	}
+
+	next, ok := observedState(c.Status.State)
+	if !ok || next == inst.State {
+		return
+	}
+
+	prev := inst.State
+	inst.State = next
+	inst.UpdatedAt = time.Now().UTC()
+
+	if err := s.store.Update(ctx, inst); err != nil {
+		return
+	}
+
+	evtType := event.InstanceStarted
+
+	switch next {
+	case provider.StateStopped:
+		evtType = event.InstanceStopped
+	case provider.StateFailed:
+		evtType = event.InstanceFailed
+	}
+
+	_ = s.events.Publish(ctx, event.NewEvent(evtType, inst.TenantID).
+		WithInstance(inst.ID).
+		WithActor(watchActor).
+		WithPayload(map[string]any{
+			"reason":         "provider_watch",
+			"previous_state": string(prev),
+			"observed_state": string(c.Status.State),
+			"message":        c.Status.Message,
+		}))
+}
+
+// settledState reports whether no lifecycle operation is in flight
+// for an instance in state st.
+func settledState(st provider.InstanceState) bool {
+	switch st {
+	case provider.StateRunning, provider.StateStopped, provider.StateFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// observedState maps a provider-reported state to the state persisted
+// for a settled instance. Transient states (a rollout or restart in
+// progress) aren't persisted — the backend settles them on its own.
+// A workload that vanished out of band is a failure: the row still
+// exists, so the instance was never deleted through ctrlplane.
+func observedState(st provider.InstanceState) (provider.InstanceState, bool) {
+	switch st {
+	case provider.StateRunning, provider.StateStopped, provider.StateFailed:
+		return st, true
+	case provider.StateDestroyed:
+		return provider.StateFailed, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,159 @@
+package instance
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestWatchProviders_AppliesObservedChanges asserts that a running
+// instance whose provider reports it failed is persisted as failed
+// with an instance.failed event, that an OOM kill publishes an event
+// without touching state, and that an instance mid-operation is left
+// to the operation.
+func TestWatchProviders_AppliesObservedChanges(t *testing.T) {
+	t.Parallel()
+
+	store := newDelStore()
+	prov := &watchingProvider{delProvider: newDelProvider("docker")}
+	registry := provider.NewRegistry()
+	registry.Register(prov.info.Name, prov)
+
+	running := watchInstance(prov.info.Name, provider.StateRunning)
+	stopping := watchInstance(prov.info.Name, provider.StateStopping)
+	store.put(running)
+	store.put(stopping)
+
+	prov.changes = []provider.StateChange{
+		{
+			Kind:       provider.ChangeOOMKilled,
+			InstanceID: running.ID,
+			TenantID:   running.TenantID,
+			Service:    "main",
+			Message:    "container exceeded its memory limit",
+		},
+		{
+			Kind:       provider.ChangeState,
+			InstanceID: running.ID,
+			TenantID:   running.TenantID,
+			Status:     &provider.InstanceStatus{State: provider.StateFailed, Message: "exited"},
+		},
+		{
+			Kind:       provider.ChangeState,
+			InstanceID: stopping.ID,
+			TenantID:   stopping.TenantID,
+			Status:     &provider.InstanceStatus{State: provider.StateStopped},
+		},
+		{
+			// Unknown instance: dropped.
+			Kind:       provider.ChangeState,
+			InstanceID: id.New(id.PrefixInstance),
+			TenantID:   running.TenantID,
+			Status:     &provider.InstanceStatus{State: provider.StateRunning},
+		},
+	}
+
+	bus := event.NewInMemoryBus()
+	svc := NewService(store, registry, bus, nil, nil).(*service)
+
+	// The fake delivers one stream and then reports ErrNotImplemented,
+	// so WatchProviders returns on its own.
+	svc.WatchProviders(t.Context())
+
+	if got := store.workloads[running.ID.String()].State; got != provider.StateFailed {
+		t.Fatalf("running instance: want StateFailed, got %s", got)
+	}
+
+	if got := store.workloads[stopping.ID.String()].State; got != provider.StateStopping {
+		t.Fatalf("stopping instance: want StateStopping (owned by the operation), got %s", got)
+	}
+
+	evts := bus.RecentEvents(10, event.InstanceFailed)
+	if len(evts) != 2 {
+		t.Fatalf("instance.failed events: want 2, got %d", len(evts))
+	}
+
+	reasons := map[any]bool{}
+	for _, e := range evts {
+		if e.ActorID != watchActor {
+			t.Fatalf("ActorID: want %q, got %q", watchActor, e.ActorID)
+		}
+
+		reasons[e.Payload["reason"]] = true
+	}
+
+	if !reasons[string(provider.ChangeOOMKilled)] || !reasons["provider_watch"] {
+		t.Fatalf("event reasons: want oom_killed and provider_watch, got %v", reasons)
+	}
+
+	if got := prov.watches.Load(); got != 2 {
+		t.Fatalf("Watch calls: want 2 (stream, then not implemented), got %d", got)
+	}
+}
+
+// TestObservedState_MapsProviderStates asserts which provider states
+// are persisted for a settled instance.
+func TestObservedState_MapsProviderStates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   provider.InstanceState
+		want provider.InstanceState
+		ok   bool
+	}{
+		{provider.StateRunning, provider.StateRunning, true},
+		{provider.StateStopped, provider.StateStopped, true},
+		{provider.StateFailed, provider.StateFailed, true},
+		{provider.StateDestroyed, provider.StateFailed, true},
+		{provider.StateStarting, "", false},
+		{provider.StateProvisioning, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := observedState(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("observedState(%s) = %s, %v; want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func watchInstance(providerName string, state provider.InstanceState) *Instance {
+	return &Instance{
+		Entity:       ctrlplane.NewEntity(id.PrefixInstance),
+		TenantID:     "ten_test",
+		Name:         "watched-" + string(state),
+		ProviderName: providerName,
+		State:        state,
+		Services:     []provider.ServiceSpec{{Name: "main", Image: "x", Role: provider.RoleMain}},
+	}
+}
+
+// watchingProvider is a delProvider that implements provider.Watcher.
+// The first Watch delivers changes and closes the stream; later calls
+// report ErrNotImplemented.
+type watchingProvider struct {
+	*delProvider
+
+	changes []provider.StateChange
+	watches atomic.Int32
+}
+
+func (p *watchingProvider) Watch(context.Context) (<-chan provider.StateChange, error) {
+	if p.watches.Add(1) > 1 {
+		return nil, ctrlplane.ErrNotImplemented
+	}
+
+	ch := make(chan provider.StateChange, len(p.changes))
+	for _, c := range p.changes {
+		ch <- c
+	}
+
+	close(ch)
+
+	return ch, nil
+}
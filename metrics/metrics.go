@@ -19,9 +19,9 @@ import (
 
 // Sample is one point-in-time measurement for a single instance.
 // Resource fields (CPU/Memory/Network) come from the provider's
-// Resources() call. Application fields (RequestsPerSec, LatencyP95Ms)
-// come from optional /metrics scraping — they're zero-valued when
-// no scraper is configured for the workload.
+// Resources() call. Application fields (RequestsPerSec, LatencyP95Ms,
+// ErrorRate) come from optional /metrics scraping — they're
+// zero-valued when no scraper is configured for the workload.
 type Sample struct {
 	At time.Time `json:"at"`
 
@@ -34,9 +34,11 @@ type Sample struct {
 
 	// Application — populated only when the workload exposes a
 	// /metrics endpoint AND ctrlplane is configured to scrape it.
-	// Zero values mean "no signal", not "zero rate".
+	// Zero values mean "no signal", not "zero rate". ErrorRate is the
+	// fraction (0–1) of requests that failed.
 	RequestsPerSec float64 `json:"requests_per_sec,omitempty"`
 	LatencyP95Ms   float64 `json:"latency_p95_ms,omitempty"`
+	ErrorRate      float64 `json:"error_rate,omitempty"`
 }
 
 // Series is a sorted slice of Samples. Callers should treat the
@@ -117,6 +117,7 @@ func (r *ringBuffer) downsample(since, until time.Time, resolution time.Duration
 		cpu, memUsed, memLimit float64
 		netIn, netOut          float64
 		reqRate, latP95        float64
+		errRate                float64
 	}
 
 	buckets := map[time.Time]*acc{}
@@ -142,6 +143,7 @@ func (r *ringBuffer) downsample(since, until time.Time, resolution time.Duration
 		a.netOut += s.NetworkOutBytesPerSec
 		a.reqRate += s.RequestsPerSec
 		a.latP95 += s.LatencyP95Ms
+		a.errRate += s.ErrorRate
 	}
 
 	out := make([]Sample, 0, len(buckets))
@@ -156,6 +158,7 @@ func (r *ringBuffer) downsample(since, until time.Time, resolution time.Duration
 			NetworkOutBytesPerSec: a.netOut / c,
 			RequestsPerSec:        a.reqRate / c,
 			LatencyP95Ms:          a.latP95 / c,
+			ErrorRate:             a.errRate / c,
 		})
 	}
 
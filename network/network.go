@@ -1,6 +1,7 @@
 package network
 
 import (
+	"slices"
 	"time"
 
 	ctrlplane "github.com/xraph/ctrlplane"
@@ -26,6 +27,11 @@ type Domain struct {
 // optionally targets a specific service inside a multi-service
 // instance — empty resolves to the instance's Main service so single-
 // service workloads keep working without explicit configuration.
+//
+// Track, when set, sends the route to the instance's endpoints on that
+// track (a canary release running beside the live one) instead of the
+// live services. Routes sharing a path split its traffic in
+// proportion to their Weight.
 type Route struct {
 	ctrlplane.Entity
 
@@ -37,6 +43,7 @@ type Route struct {
 	Protocol    string `db:"protocol"     json:"protocol"`
 	Weight      int    `db:"weight"       json:"weight"`
 	StripPrefix bool   `db:"strip_prefix" json:"strip_prefix"`
+	Track       string `db:"track"        json:"track,omitempty"`
 	// Hostname, when set, scopes the route to a single host (the
 	// workspace's API hostname). The OctopusRouter uses it as the
 	// Gateway API HTTPRoute's `hostnames` entry so per-workspace path
@@ -73,8 +80,15 @@ type Certificate struct {
 //     legacy routes that don't specify a port still resolve to
 //     "the obvious one".
 //
-// Returns nil when `endpoints` is empty.
+// Only endpoints on the route's Track are eligible. Returns nil when
+// none are.
 func SelectEndpoint(route *Route, endpoints []provider.Endpoint) *provider.Endpoint {
+	var track string
+	if route != nil {
+		track = route.Track
+	}
+
+	endpoints = onTrack(endpoints, track)
 	if len(endpoints) == 0 {
 		return nil
 	}
@@ -104,3 +118,20 @@ func SelectEndpoint(route *Route, endpoints []provider.Endpoint) *provider.Endpo
 
 	return &endpoints[0]
 }
+
+// onTrack returns the endpoints on track, reusing the slice when every
+// endpoint is.
+func onTrack(endpoints []provider.Endpoint, track string) []provider.Endpoint {
+	if !slices.ContainsFunc(endpoints, func(e provider.Endpoint) bool { return e.Track != track }) {
+		return endpoints
+	}
+
+	out := make([]provider.Endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		if e.Track == track {
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
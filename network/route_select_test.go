@@ -90,3 +90,24 @@ func TestSelectEndpoint_NilRoute(t *testing.T) {
 		t.Fatalf("want first endpoint when route nil, got %+v", got)
 	}
 }
+
+func TestSelectEndpoint_Track(t *testing.T) {
+	t.Parallel()
+
+	endpoints := []provider.Endpoint{
+		{ServiceName: "main", Port: 8080},
+		{ServiceName: "main", Port: 8080, Track: "canary", URL: "canary"},
+	}
+
+	if got := SelectEndpoint(&Route{ServiceName: "main", Port: 8080}, endpoints); got == nil || got.Track != "" {
+		t.Fatalf("live route: want the live endpoint, got %+v", got)
+	}
+
+	if got := SelectEndpoint(&Route{Port: 8080, Track: "canary"}, endpoints); got == nil || got.URL != "canary" {
+		t.Fatalf("canary route: want the canary endpoint, got %+v", got)
+	}
+
+	if got := SelectEndpoint(&Route{Track: "green"}, endpoints); got != nil {
+		t.Fatalf("route to a missing track: want nil, got %+v", got)
+	}
+}
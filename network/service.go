@@ -50,7 +50,8 @@ type AddDomainRequest struct {
 //
 // ServiceName optionally targets a specific service inside a
 // multi-service instance — leave empty to route to the instance's
-// Main service (the default for single-service workloads).
+// Main service (the default for single-service workloads). Track
+// routes to the endpoints of a release running on that track.
 type AddRouteRequest struct {
 	InstanceID  id.ID  `json:"instance_id"            validate:"required"`
 	ServiceName string `json:"service_name,omitempty"`
@@ -60,12 +61,16 @@ type AddRouteRequest struct {
 	Weight      int    `default:"100"                 json:"weight"`
 	StripPrefix bool   `json:"strip_prefix,omitempty"`
 	Hostname    string `json:"hostname,omitempty"`
+	Track       string `json:"track,omitempty"`
 }
 
 // UpdateRouteRequest holds the parameters for modifying a route.
+// Changing Track moves the route to another track's endpoints in one
+// router update.
 type UpdateRouteRequest struct {
 	ServiceName *string `json:"service_name,omitempty"`
 	Path        *string `json:"path,omitempty"`
 	Weight      *int    `json:"weight,omitempty"`
 	StripPrefix *bool   `json:"strip_prefix,omitempty"`
+	Track       *string `json:"track,omitempty"`
 }
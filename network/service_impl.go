@@ -189,6 +189,7 @@ func (s *service) AddRoute(ctx context.Context, req AddRouteRequest) (*Route, er
 		Weight:      weight,
 		StripPrefix: req.StripPrefix,
 		Hostname:    req.Hostname,
+		Track:       req.Track,
 	}
 
 	if err := s.store.InsertRoute(ctx, route); err != nil {
@@ -243,6 +244,10 @@ func (s *service) UpdateRoute(ctx context.Context, routeID id.ID, req UpdateRout
 		route.ServiceName = *req.ServiceName
 	}
 
+	if req.Track != nil {
+		route.Track = *req.Track
+	}
+
 	route.UpdatedAt = time.Now().UTC()
 
 	if err := s.store.UpdateRoute(ctx, route); err != nil {
@@ -26,6 +26,7 @@ var (
 	_ plugin.DeploySucceeded     = (*MetricsExtension)(nil)
 	_ plugin.DeployFailed        = (*MetricsExtension)(nil)
 	_ plugin.DeployRolledBack    = (*MetricsExtension)(nil)
+	_ plugin.DeployCancelled     = (*MetricsExtension)(nil)
 	_ plugin.HealthCheckPassed   = (*MetricsExtension)(nil)
 	_ plugin.HealthCheckFailed   = (*MetricsExtension)(nil)
 	_ plugin.HealthDegraded      = (*MetricsExtension)(nil)
@@ -58,6 +59,7 @@ type MetricsExtension struct {
 	DeploySucceededCount  gu.Counter
 	DeployFailedCount     gu.Counter
 	DeployRolledBackCount gu.Counter
+	DeployCancelledCount  gu.Counter
 
 	// Health counters.
 	HealthCheckPassedCount gu.Counter
@@ -100,6 +102,7 @@ func NewMetricsExtensionWithFactory(factory gu.MetricFactory) *MetricsExtension
 		DeploySucceededCount:  factory.Counter("ctrlplane.deploy.succeeded"),
 		DeployFailedCount:     factory.Counter("ctrlplane.deploy.failed"),
 		DeployRolledBackCount: factory.Counter("ctrlplane.deploy.rolled_back"),
+		DeployCancelledCount:  factory.Counter("ctrlplane.deploy.cancelled"),
 
 		HealthCheckPassedCount: factory.Counter("ctrlplane.health.passed"),
 		HealthCheckFailedCount: factory.Counter("ctrlplane.health.failed"),
@@ -202,6 +205,12 @@ func (m *MetricsExtension) OnDeployRolledBack(_ context.Context, _ *event.Event)
 	return nil
 }
 
+func (m *MetricsExtension) OnDeployCancelled(_ context.Context, _ *event.Event) error {
+	m.DeployCancelledCount.Inc()
+
+	return nil
+}
+
 // ──────────────────────────────────────────────────
 // Health hooks
 // ──────────────────────────────────────────────────
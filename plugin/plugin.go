@@ -160,6 +160,11 @@ type DeployRolledBack interface {
 	OnDeployRolledBack(ctx context.Context, evt *event.Event) error
 }
 
+// DeployCancelled is called when a deployment is cancelled.
+type DeployCancelled interface {
+	OnDeployCancelled(ctx context.Context, evt *event.Event) error
+}
+
 // ──────────────────────────────────────────────────
 // Health lifecycle hooks
 // ──────────────────────────────────────────────────
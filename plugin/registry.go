@@ -129,6 +129,11 @@ type deployRolledBackEntry struct {
 	hook DeployRolledBack
 }
 
+type deployCancelledEntry struct {
+	name string
+	hook DeployCancelled
+}
+
 type healthCheckPassedEntry struct {
 	name string
 	hook HealthCheckPassed
@@ -231,6 +236,7 @@ type Registry struct {
 	deploySucceeded     []deploySucceededEntry
 	deployFailed        []deployFailedEntry
 	deployRolledBack    []deployRolledBackEntry
+	deployCancelled     []deployCancelledEntry
 	healthCheckPassed   []healthCheckPassedEntry
 	healthCheckFailed   []healthCheckFailedEntry
 	healthDegraded      []healthDegradedEntry
@@ -354,6 +360,10 @@ func (r *Registry) Register(e Extension) {
 		r.deployRolledBack = append(r.deployRolledBack, deployRolledBackEntry{name, h})
 	}
 
+	if h, ok := e.(DeployCancelled); ok {
+		r.deployCancelled = append(r.deployCancelled, deployCancelledEntry{name, h})
+	}
+
 	if h, ok := e.(HealthCheckPassed); ok {
 		r.healthCheckPassed = append(r.healthCheckPassed, healthCheckPassedEntry{name, h})
 	}
@@ -646,6 +656,15 @@ func (r *Registry) EmitDeployRolledBack(ctx context.Context, evt *event.Event) {
 	}
 }
 
+// EmitDeployCancelled notifies all plugins that implement DeployCancelled.
+func (r *Registry) EmitDeployCancelled(ctx context.Context, evt *event.Event) {
+	for _, e := range r.deployCancelled {
+		if err := e.hook.OnDeployCancelled(ctx, evt); err != nil {
+			r.logHookError("OnDeployCancelled", e.name, err)
+		}
+	}
+}
+
 // ──────────────────────────────────────────────────
 // Health lifecycle emitters
 // ──────────────────────────────────────────────────
@@ -845,6 +864,8 @@ func (r *Registry) HandleEvent(ctx context.Context, evt *event.Event) error {
 		r.EmitDeployFailed(ctx, evt)
 	case event.DeployRolledBack:
 		r.EmitDeployRolledBack(ctx, evt)
+	case event.DeployCancelled:
+		r.EmitDeployCancelled(ctx, evt)
 	case event.HealthCheckPassed:
 		r.EmitHealthCheckPassed(ctx, evt)
 	case event.HealthCheckFailed:
@@ -28,7 +28,8 @@ const (
 	// CapGPU indicates the provider supports GPU workloads.
 	CapGPU Capability = "gpu"
 
-	// CapBlueGreen indicates the provider supports blue-green deployments.
+	// CapBlueGreen indicates the provider supports blue-green deployments
+	// by running the release on TrackGreen through TrackDeployer.
 	CapBlueGreen Capability = "strategy:blue-green"
 
 	// CapCanary indicates the provider supports canary deployments.
@@ -40,6 +41,10 @@ const (
 	// CapAutoScale indicates the provider supports autoscaling.
 	CapAutoScale Capability = "autoscale"
 
+	// CapPlacement indicates the provider honours hard placement
+	// constraints (node selectors, required affinity and spread).
+	CapPlacement Capability = "placement"
+
 	// CapCustomDomains indicates the provider supports custom domains.
 	CapCustomDomains Capability = "custom-domains"
 
@@ -10,4 +10,11 @@ type Config struct {
 
 	// Registry is the default image registry prefix.
 	Registry string `env:"CP_DOCKER_REGISTRY" json:"registry,omitempty"`
+
+	// ConfigDir is the host directory ServiceSpec.ConfigFiles are
+	// written to before being bind-mounted into containers. Bind
+	// sources resolve on the daemon's host, so this only works when
+	// ctrlplane shares a filesystem with the docker daemon. Defaults
+	// to a directory under os.TempDir().
+	ConfigDir string `env:"CP_DOCKER_CONFIG_DIR" json:"config_dir,omitempty"`
 }
@@ -0,0 +1,178 @@
+package docker
+
+// configfiles.go materialises ServiceSpec.ConfigFiles as read-only
+// bind mounts. Each service's file set is written to
+//
+//	<ConfigDir>/cp-<instanceID>/<service>/<checksum>/<file>
+//
+// and every file is bind-mounted at its Path. The checksum directory
+// makes each set immutable: a deploy that changes a file writes a new
+// directory and recreates the container onto it, so a container that
+// is set aside (RollbackRelease) or still running keeps the exact
+// files it started with. Older directories are pruned once the new
+// set is live; Deprovision removes the project's directory.
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// labelConfigChecksum records the checksum of the config files a
+// container was created with, so Deploy can tell when they changed.
+const labelConfigChecksum = "ctrlplane.config-checksum"
+
+// configChecksumDirLen is how much of the checksum names a file-set
+// directory: plenty to keep a service's sets apart, short enough to
+// read in `docker inspect`.
+const configChecksumDirLen = 16
+
+// invalidConfigFileName matches characters kept out of on-disk file
+// names.
+var invalidConfigFileName = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// configRoot returns the host directory config files live under.
+func (p *Provider) configRoot() string {
+	if p.cfg.ConfigDir != "" {
+		return p.cfg.ConfigDir
+	}
+
+	return filepath.Join(os.TempDir(), "ctrlplane-docker-configs")
+}
+
+// serviceConfigDir returns the directory holding every file set of one
+// service.
+func (p *Provider) serviceConfigDir(instanceID id.ID, serviceName string) string {
+	return filepath.Join(p.configRoot(), projectName(instanceID), serviceName)
+}
+
+// configFileName returns the on-disk name of a file within its set:
+// its Name, or the base of its Path when unnamed.
+func configFileName(f provider.ConfigFile) string {
+	name := f.Name
+	if name == "" {
+		name = path.Base(f.Path)
+	}
+
+	return invalidConfigFileName.ReplaceAllString(name, "_")
+}
+
+// writeConfigFiles writes one service's file set to its checksum
+// directory and returns the bind mounts for it. Writing an existing
+// set again is harmless — the content is identical by construction.
+func (p *Provider) writeConfigFiles(instanceID id.ID, serviceName string, files []provider.ConfigFile) ([]mount.Mount, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	checksum := provider.ConfigFilesChecksum(files)
+	dir := filepath.Join(p.serviceConfigDir(instanceID, serviceName), checksum[:configChecksumDirLen])
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create config dir %s: %w", dir, err)
+	}
+
+	mounts := make([]mount.Mount, 0, len(files))
+
+	for _, f := range files {
+		hostPath := filepath.Join(dir, configFileName(f))
+
+		if err := os.WriteFile(hostPath, []byte(f.Content), 0o644); err != nil { //nolint:gosec // mounted read-only into the container, which must be able to read it
+			return nil, fmt.Errorf("write config file %s: %w", hostPath, err)
+		}
+
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   hostPath,
+			Target:   f.Path,
+			ReadOnly: true,
+		})
+	}
+
+	return mounts, nil
+}
+
+// applyConfigFiles points a replacement container at a new file set:
+// the set is written, the predecessor's config-file mounts are
+// swapped for the new ones, and the checksum label is updated. No-op
+// when files is empty, which keeps the current files.
+func (p *Provider) applyConfigFiles(instanceID id.ID, serviceName string, files []provider.ConfigFile, cfg *container.Config, hostCfg *container.HostConfig) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	mounts, err := p.writeConfigFiles(instanceID, serviceName, files)
+	if err != nil {
+		return err
+	}
+
+	hostCfg.Mounts = append(withoutConfigMounts(hostCfg.Mounts, p.serviceConfigDir(instanceID, serviceName)), mounts...)
+
+	if cfg.Labels == nil {
+		cfg.Labels = make(map[string]string, 1)
+	}
+
+	cfg.Labels[labelConfigChecksum] = provider.ConfigFilesChecksum(files)
+
+	return nil
+}
+
+// withoutConfigMounts returns mounts minus the bind mounts sourced
+// from a service's config directory.
+func withoutConfigMounts(mounts []mount.Mount, serviceDir string) []mount.Mount {
+	prefix := serviceDir + string(filepath.Separator)
+
+	return slices.DeleteFunc(slices.Clone(mounts), func(m mount.Mount) bool {
+		return m.Type == mount.TypeBind && strings.HasPrefix(m.Source, prefix)
+	})
+}
+
+// configFilesChanged reports whether files differ from the set a
+// container was created with. An empty files means "keep the current
+// files", so it never counts as a change.
+func configFilesChanged(labels map[string]string, files []provider.ConfigFile) bool {
+	return len(files) > 0 && labels[labelConfigChecksum] != provider.ConfigFilesChecksum(files)
+}
+
+// pruneConfigDirs removes a service's file-set directories other than
+// the one for files. Best-effort: a leftover directory costs disk, not
+// correctness.
+func (p *Provider) pruneConfigDirs(instanceID id.ID, serviceName string, files []provider.ConfigFile) {
+	if len(files) == 0 {
+		return
+	}
+
+	keep := provider.ConfigFilesChecksum(files)[:configChecksumDirLen]
+	dir := p.serviceConfigDir(instanceID, serviceName)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != keep {
+			_ = os.RemoveAll(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+// removeProjectConfigs deletes every config file written for a project.
+func (p *Provider) removeProjectConfigs(instanceID id.ID) error {
+	dir := filepath.Join(p.configRoot(), projectName(instanceID))
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("docker: remove config dir %s: %w", dir, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestApplyConfigFiles_WritesVersionedSet verifies a file set is
+// written under its checksum directory, bind-mounted read-only, and
+// that a second set replaces the first set's mounts while leaving
+// other mounts and the first set's files in place.
+func TestApplyConfigFiles_WritesVersionedSet(t *testing.T) {
+	t.Parallel()
+
+	p := &Provider{cfg: Config{ConfigDir: t.TempDir()}}
+	iid := id.New(id.PrefixInstance)
+
+	v1 := []provider.ConfigFile{{Name: "app.yaml", Path: "/etc/app/app.yaml", Content: "v: 1"}}
+	v2 := []provider.ConfigFile{{Name: "app.yaml", Path: "/etc/app/app.yaml", Content: "v: 2"}}
+
+	data := mount.Mount{Type: mount.TypeVolume, Source: volumeName(iid, "data", 0), Target: "/data"}
+	cfg := &container.Config{}
+	hostCfg := &container.HostConfig{Mounts: []mount.Mount{data}}
+
+	if err := p.applyConfigFiles(iid, "web", v1, cfg, hostCfg); err != nil {
+		t.Fatalf("apply v1: %v", err)
+	}
+
+	first := hostCfg.Mounts[1].Source
+
+	if err := p.applyConfigFiles(iid, "web", v2, cfg, hostCfg); err != nil {
+		t.Fatalf("apply v2: %v", err)
+	}
+
+	if len(hostCfg.Mounts) != 2 || hostCfg.Mounts[0] != data {
+		t.Fatalf("mounts: want volume + one config file, got %+v", hostCfg.Mounts)
+	}
+
+	m := hostCfg.Mounts[1]
+	if m.Type != mount.TypeBind || !m.ReadOnly || m.Target != "/etc/app/app.yaml" || m.Source == first {
+		t.Fatalf("config mount: %+v", m)
+	}
+
+	if got, _ := os.ReadFile(m.Source); string(got) != "v: 2" {
+		t.Fatalf("v2 content: got %q", got)
+	}
+
+	if got, _ := os.ReadFile(first); string(got) != "v: 1" {
+		t.Fatalf("v1 set must survive for rollback, got %q", got)
+	}
+
+	if cfg.Labels[labelConfigChecksum] != provider.ConfigFilesChecksum(v2) {
+		t.Fatalf("checksum label: got %q", cfg.Labels[labelConfigChecksum])
+	}
+
+	if configFilesChanged(cfg.Labels, v2) || !configFilesChanged(cfg.Labels, v1) || configFilesChanged(cfg.Labels, nil) {
+		t.Fatal("configFilesChanged disagrees with the checksum label")
+	}
+
+	p.pruneConfigDirs(iid, "web", v2)
+
+	if _, err := os.Stat(filepath.Dir(first)); !os.IsNotExist(err) {
+		t.Fatalf("prune: v1 set still present (%v)", err)
+	}
+}
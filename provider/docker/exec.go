@@ -0,0 +1,133 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// execPollInterval is how often waitExecExit re-inspects an exec
+// instance whose output stream has closed but which docker still
+// reports as running. The gap is usually a few milliseconds — the
+// stream EOFs slightly before the daemon records the exit code.
+const execPollInterval = 50 * time.Millisecond
+
+// attachExec starts the exec instance by attaching to it, pumps
+// cmd.Stdin into the hijacked connection (and cmd.Resize into the
+// exec's TTY), and collects the output until the command closes its
+// streams.
+//
+// Cancelling ctx closes the hijacked connection so a command that
+// never exits (or a reader that never returns EOF) can't pin the
+// caller forever.
+func (p *Provider) attachExec(ctx context.Context, execID string, cmd provider.ExecRequest) ([]byte, []byte, error) {
+	attach, err := p.cli.ContainerExecAttach(ctx, execID, container.ExecAttachOptions{Tty: cmd.TTY})
+	if err != nil {
+		return nil, nil, fmt.Errorf("docker: exec attach: %w", err)
+	}
+	defer attach.Close()
+
+	stop := context.AfterFunc(ctx, attach.Close)
+	defer stop()
+
+	if cmd.TTY && cmd.Resize != nil {
+		resizeCtx, stopResize := context.WithCancel(ctx)
+		defer stopResize()
+
+		go p.forwardExecResize(resizeCtx, execID, cmd.Resize)
+	}
+
+	if cmd.Stdin != nil {
+		go func() {
+			_, _ = io.Copy(attach.Conn, cmd.Stdin)
+			// Half-close so the command sees EOF on its stdin while
+			// we keep reading its output.
+			_ = attach.CloseWrite()
+		}()
+	}
+
+	stdout, stderr, err := collectExecOutput(attach.Reader, cmd.TTY)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, fmt.Errorf("docker: exec: %w", ctxErr)
+		}
+
+		return nil, nil, fmt.Errorf("docker: exec read output: %w", err)
+	}
+
+	return stdout, stderr, nil
+}
+
+// forwardExecResize applies terminal size changes to a TTY exec
+// until ctx ends (attachExec returns) or the channel closes.
+// Resize failures are ignored — a missed resize only mis-wraps the
+// terminal, it doesn't affect the command.
+func (p *Provider) forwardExecResize(ctx context.Context, execID string, resize <-chan provider.TerminalSize) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case size, ok := <-resize:
+			if !ok {
+				return
+			}
+
+			_ = p.cli.ContainerExecResize(ctx, execID, container.ResizeOptions{
+				Height: uint(size.Height),
+				Width:  uint(size.Width),
+			})
+		}
+	}
+}
+
+// waitExecExit inspects the exec instance until docker reports it
+// finished and returns its exit code.
+func (p *Provider) waitExecExit(ctx context.Context, execID string) (int, error) {
+	for {
+		inspect, err := p.cli.ContainerExecInspect(ctx, execID)
+		if err != nil {
+			return 0, fmt.Errorf("docker: exec inspect: %w", err)
+		}
+
+		if !inspect.Running {
+			return inspect.ExitCode, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("docker: exec: %w", ctx.Err())
+		case <-time.After(execPollInterval):
+		}
+	}
+}
+
+// collectExecOutput reads an exec attach stream to EOF. Without a
+// TTY docker multiplexes stdout/stderr with the same 8-byte frame
+// header the logs API uses (see dockerStreamHeader), so stdcopy
+// splits them; with a TTY the stream is raw terminal output and is
+// returned entirely as stdout. Pure function so it's testable
+// without a docker socket.
+func collectExecOutput(r io.Reader, tty bool) ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	if tty {
+		if _, err := io.Copy(&stdout, r); err != nil {
+			return nil, nil, err
+		}
+
+		return stdout.Bytes(), nil, nil
+	}
+
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, r); err != nil {
+		return nil, nil, err
+	}
+
+	return stdout.Bytes(), stderr.Bytes(), nil
+}
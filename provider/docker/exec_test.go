@@ -0,0 +1,57 @@
+package docker
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// TestCollectExecOutput_DemuxesStreams verifies a non-TTY exec stream
+// is split into separate stdout and stderr buffers, preserving the
+// order of writes within each stream.
+func TestCollectExecOutput_DemuxesStreams(t *testing.T) {
+	t.Parallel()
+
+	var raw bytes.Buffer
+
+	outW := stdcopy.NewStdWriter(&raw, stdcopy.Stdout)
+	errW := stdcopy.NewStdWriter(&raw, stdcopy.Stderr)
+
+	_, _ = outW.Write([]byte("hello "))
+	_, _ = errW.Write([]byte("oops\n"))
+	_, _ = outW.Write([]byte("world\n"))
+
+	stdout, stderr, err := collectExecOutput(&raw, false)
+	if err != nil {
+		t.Fatalf("collectExecOutput: %v", err)
+	}
+
+	if got, want := string(stdout), "hello world\n"; got != want {
+		t.Fatalf("stdout: want %q, got %q", want, got)
+	}
+
+	if got, want := string(stderr), "oops\n"; got != want {
+		t.Fatalf("stderr: want %q, got %q", want, got)
+	}
+}
+
+// TestCollectExecOutput_TTYIsRaw verifies a TTY exec stream is passed
+// through unparsed — docker doesn't frame TTY output, so running it
+// through the demuxer would corrupt it.
+func TestCollectExecOutput_TTYIsRaw(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr, err := collectExecOutput(bytes.NewBufferString("\x1b[1mprompt$\x1b[0m "), true)
+	if err != nil {
+		t.Fatalf("collectExecOutput: %v", err)
+	}
+
+	if got, want := string(stdout), "\x1b[1mprompt$\x1b[0m "; got != want {
+		t.Fatalf("stdout: want %q, got %q", want, got)
+	}
+
+	if len(stderr) != 0 {
+		t.Fatalf("stderr: want empty for TTY, got %q", stderr)
+	}
+}
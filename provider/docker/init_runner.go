@@ -60,13 +60,21 @@ func (p *Provider) runOneInit(ctx context.Context, req provider.ProvisionRequest
 		return err
 	}
 
-	_ = p.pullImage(ctx, svc.Image)
+	_ = p.pullImage(ctx, svc.Image, svc.RegistryAuth)
+
+	if err := p.ensureVolumes(ctx, req, svc.Volumes); err != nil {
+		return err
+	}
 
 	cfg, hostCfg, netCfg, err := p.buildServiceContainerConfig(req, svc)
 	if err != nil {
 		return err
 	}
 
+	if err := p.applyConfigFiles(req.InstanceID, svc.Name, svc.ConfigFiles, cfg, hostCfg); err != nil {
+		return err
+	}
+
 	created, err := p.cli.ContainerCreate(ctx, cfg, hostCfg, netCfg, nil, name)
 	if err != nil {
 		return fmt.Errorf("create init: %w", err)
@@ -3,12 +3,13 @@ package docker
 import (
 	"bufio"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
 	"time"
+
+	"github.com/xraph/ctrlplane/provider"
 )
 
 // dockerStreamHeader is the 8-byte frame header that prefixes every
@@ -23,17 +24,8 @@ import (
 // instead of arbitrary byte chunks.
 const dockerStreamHeader = 8
 
-// logEvent is the JSON shape emitted on the wire (one per SSE event
-// or one per line, depending on the consumer). Keeping it small +
-// stable — adding fields is fine, removing isn't.
-type logEvent struct {
-	Timestamp string `json:"ts"`
-	Stream    string `json:"stream"` // "stdout" | "stderr"
-	Line      string `json:"line"`
-}
-
 // demuxedDockerStream wraps the multiplexed docker logs stream into
-// a stream of JSON-shaped log events (one object per line, '\n'
+// a stream of provider.LogEvent lines (one object per line, '\n'
 // terminated). Caller reads via the returned io.ReadCloser; the
 // producer goroutine exits cleanly when src returns EOF or io.Pipe
 // is closed by the consumer.
@@ -53,14 +45,11 @@ func demuxedDockerStream(src io.ReadCloser) io.ReadCloser {
 		if err := streamDockerLogs(src, w); err != nil && !errors.Is(err, io.EOF) {
 			// Best-effort: surface the parse error as a final event
 			// so the consumer sees why the stream ended.
-			ev := logEvent{
+			_ = provider.WriteLogEvent(w, provider.LogEvent{
 				Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
 				Stream:    "stderr",
 				Line:      "ctrlplane/docker: log stream ended: " + err.Error(),
-			}
-			if data, mErr := json.Marshal(ev); mErr == nil {
-				_, _ = w.Write(append(data, '\n'))
-			}
+			})
 		}
 	}()
 
@@ -105,19 +94,13 @@ func streamDockerLogs(src io.Reader, dst io.Writer) error {
 				continue
 			}
 
-			ev := logEvent{
+			err := provider.WriteLogEvent(dst, provider.LogEvent{
 				Timestamp: ts,
 				Stream:    streamType,
 				Line:      line,
-			}
-
-			data, err := json.Marshal(ev)
+			})
 			if err != nil {
-				return fmt.Errorf("marshal log event: %w", err)
-			}
-
-			if _, err := dst.Write(append(data, '\n')); err != nil {
-				return fmt.Errorf("write log event: %w", err)
+				return err
 			}
 		}
 	}
@@ -40,6 +40,20 @@ func WithRegistry(registry string) Option {
 	}
 }
 
+// WithConfigDir sets the host directory config files are written to
+// for bind-mounting. See Config.ConfigDir.
+func WithConfigDir(dir string) Option {
+	return func(p *Provider) error {
+		if dir == "" {
+			return fmt.Errorf("docker: %w: config dir must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.ConfigDir = dir
+
+		return nil
+	}
+}
+
 // WithConfig applies all non-zero fields from a Config struct.
 // This is useful when loading configuration from files or environment variables.
 func WithConfig(cfg Config) Option {
@@ -56,6 +70,10 @@ func WithConfig(cfg Config) Option {
 			p.cfg.Registry = cfg.Registry
 		}
 
+		if cfg.ConfigDir != "" {
+			p.cfg.ConfigDir = cfg.ConfigDir
+		}
+
 		return nil
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"strconv"
 	"time"
 
@@ -12,22 +13,26 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
 
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/secrets"
 )
 
 // ErrLogsNotImplemented is returned when log streaming is requested
 // but the docker exec/logs path hasn't been wired yet.
 var ErrLogsNotImplemented = errors.New("docker: logs not implemented")
 
-// Compile-time check that Provider implements provider.Provider
-// and provider.HealthChecker.
+// Compile-time check that Provider implements provider.Provider,
+// provider.HealthChecker and provider.Rollbacker.
 var (
 	_ provider.Provider      = (*Provider)(nil)
 	_ provider.HealthChecker = (*Provider)(nil)
+	_ provider.Rollbacker    = (*Provider)(nil)
+	_ provider.Watcher       = (*Provider)(nil)
 )
 
 // HealthCheck pings the docker daemon and reports reachability.
@@ -119,6 +124,7 @@ func (p *Provider) Capabilities() []provider.Capability {
 		provider.CapScale,
 		provider.CapLogs,
 		provider.CapExec,
+		provider.CapVolumes,
 	}
 }
 
@@ -146,6 +152,10 @@ func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest)
 		return nil, errors.New("docker: provision requires exactly one Main service")
 	}
 
+	if err := provider.RejectHardPlacement(req.Services); err != nil {
+		return nil, fmt.Errorf("docker: %w", err)
+	}
+
 	return p.provisionProject(ctx, req)
 }
 
@@ -161,9 +171,10 @@ func pickMainService(services []provider.ServiceSpec) *provider.ServiceSpec {
 	return nil
 }
 
-// Deprovision tears down every container in the project + the project
-// network. Not-found is treated as success so deprovision is
-// convergent — the goal is "project gone", not strict accounting.
+// Deprovision tears down every container in the project, the project
+// network, the project's config files and its named volumes.
+// Not-found is treated as success so deprovision is convergent — the
+// goal is "project gone", not strict accounting. This is the only place volumes are removed.
 func (p *Provider) Deprovision(ctx context.Context, instanceID id.ID) error {
 	containers, err := p.listProjectContainers(ctx, instanceID)
 	if err != nil {
@@ -194,7 +205,13 @@ func (p *Provider) Deprovision(ctx context.Context, instanceID id.ID) error {
 		RemoveVolumes: true,
 	})
 
-	return nil
+	if err := p.removeProjectConfigs(instanceID); err != nil {
+		return err
+	}
+
+	// Volumes last: docker refuses to remove one a container still
+	// mounts.
+	return p.removeProjectVolumes(ctx, instanceID)
 }
 
 // Start starts every Main + Sidecar container in the project.
@@ -267,6 +284,10 @@ func (p *Provider) Restart(ctx context.Context, instanceID id.ID) error {
 // only when every Main + Sidecar reports Running. Init containers
 // don't gate readiness once they've completed (they appear as
 // Stopped/exited 0 — the non-Init aggregation ignores them).
+//
+// Replicas of a service fold into one ServiceStatus (see
+// addReplicaStatus) carrying the replica counts; the instance-level
+// counts are the Main service's.
 func (p *Provider) Status(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
 	containers, err := p.listProjectContainers(ctx, instanceID)
 	if err != nil {
@@ -282,11 +303,13 @@ func (p *Provider) Status(ctx context.Context, instanceID id.ID) (*provider.Inst
 		anyStarting bool
 		anyStopped  bool
 		allRunning  = true
+		mainService string
 		endpoints   []provider.Endpoint
 		services    = make(map[string]provider.ServiceStatus, len(containers))
+		groups      = serviceReplicas(containers)
 	)
 
-	for _, c := range containers {
+	for _, c := range flattenReplicas(groups) {
 		inspect, ierr := p.cli.ContainerInspect(ctx, c.ID)
 		if ierr != nil {
 			if cerrdefs.IsNotFound(ierr) {
@@ -307,12 +330,16 @@ func (p *Provider) Status(ctx context.Context, instanceID id.ID) (*provider.Inst
 			svcState = provider.StateFailed
 		}
 
-		services[c.ServiceName] = provider.ServiceStatus{
+		services[c.ServiceName] = addReplicaStatus(services[c.ServiceName], provider.ServiceStatus{
 			State:       svcState,
 			Ready:       inspect.State.Running,
 			Restarts:    inspect.RestartCount,
 			ProviderRef: c.ID,
 			Message:     inspect.State.Error,
+		})
+
+		if mainService == "" && (c.Role == provider.RoleMain || c.Role == "") {
+			mainService = c.ServiceName
 		}
 
 		// Init that exited 0 is not a ready failure — skip its
@@ -355,10 +382,12 @@ func (p *Provider) Status(ctx context.Context, instanceID id.ID) (*provider.Inst
 	}
 
 	return &provider.InstanceStatus{
-		State:     state,
-		Ready:     allRunning,
-		Endpoints: endpoints,
-		Services:  services,
+		State:         state,
+		Ready:         allRunning,
+		Endpoints:     endpoints,
+		Services:      services,
+		Replicas:      services[mainService].Replicas,
+		ReadyReplicas: services[mainService].ReadyReplicas,
 	}, nil
 }
 
@@ -392,20 +421,24 @@ func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*pro
 		return nil, err
 	}
 
-	bySvc := make(map[string]projectContainer, len(existing))
-	for _, c := range existing {
-		bySvc[c.ServiceName] = c
-	}
+	bySvc := serviceReplicas(existing)
 
 	for _, target := range req.Services {
-		current, ok := bySvc[target.Name]
+		replicas, ok := bySvc[target.Name]
 		if !ok {
 			return nil, fmt.Errorf("docker: deploy: unknown service %q in project %s", target.Name, projectName(req.InstanceID))
 		}
 
-		if err := p.recreateServiceContainer(ctx, req, target, current); err != nil {
-			return nil, fmt.Errorf("docker: deploy service %q: %w", target.Name, err)
+		// Replicas roll one at a time so a scaled service keeps
+		// serving through the deploy.
+		for _, current := range replicas {
+			if err := p.recreateServiceContainer(ctx, req, target, current); err != nil {
+				return nil, fmt.Errorf("docker: deploy service %q: %w", target.Name, err)
+			}
 		}
+
+		// Every replica is on the new file set; older sets can go.
+		p.pruneConfigDirs(req.InstanceID, target.Name, target.ConfigFiles)
 	}
 
 	return &provider.DeployResult{
@@ -424,47 +457,81 @@ func (p *Provider) recreateServiceContainer(ctx context.Context, req provider.De
 		return fmt.Errorf("inspect %s: %w", current.Name, err)
 	}
 
-	// Skip recreate when the image already matches — saves a noisy
-	// stop+start on every Deploy that follows immediate Provision.
-	if inspect.Config != nil && inspect.Config.Image == target.Image {
+	// Skip recreate when the image and config files already match —
+	// saves a noisy stop+start on every Deploy that follows immediate
+	// Provision.
+	if inspect.Config != nil && inspect.Config.Image == target.Image && !configFilesChanged(inspect.Config.Labels, target.ConfigFiles) {
 		return nil
 	}
 
-	_ = p.pullImage(ctx, target.Image)
+	_ = p.pullImage(ctx, target.Image, target.RegistryAuth)
+
+	cfg, hostCfg := recreateConfig(inspect, req.InstanceID, req.ReleaseID, target.Image, target.Env)
+
+	// Write the new file set before the old container goes, so a write
+	// failure leaves the service running on its current files.
+	if err := p.applyConfigFiles(req.InstanceID, target.Name, target.ConfigFiles, cfg, hostCfg); err != nil {
+		return err
+	}
 
 	if err := p.cli.ContainerRemove(ctx, current.ID, container.RemoveOptions{Force: true}); err != nil && !cerrdefs.IsNotFound(err) {
 		return fmt.Errorf("remove old %s: %w", current.Name, err)
 	}
 
-	envList := make([]string, 0, len(target.Env))
-	for k, v := range target.Env {
+	return p.startReplacementContainer(ctx, req.InstanceID, current, cfg, hostCfg)
+}
+
+// recreateConfig derives the create-time config for a replacement
+// container: image and env come from the new target, everything else
+// (labels, ports, entrypoint, host config) from the inspected
+// predecessor. An empty env keeps the predecessor's env. The release
+// label is re-stamped so `docker ps` shows which Release a container
+// belongs to.
+func recreateConfig(inspect container.InspectResponse, instanceID, releaseID id.ID, image string, env map[string]string) (*container.Config, *container.HostConfig) {
+	envList := make([]string, 0, len(env))
+	for k, v := range env {
 		envList = append(envList, k+"="+v)
 	}
 
-	if len(envList) == 0 && inspect.Config != nil {
-		envList = inspect.Config.Env
+	cfg := &container.Config{Image: image, Env: envList}
+
+	if inspect.Config != nil {
+		if len(envList) == 0 {
+			cfg.Env = inspect.Config.Env
+		}
+
+		cfg.Labels = maps.Clone(inspect.Config.Labels)
+		cfg.ExposedPorts = inspect.Config.ExposedPorts
+		cfg.Entrypoint = inspect.Config.Entrypoint
+		cfg.Cmd = inspect.Config.Cmd
 	}
 
-	cfg := &container.Config{
-		Image:        target.Image,
-		Env:          envList,
-		Labels:       inspect.Config.Labels,
-		ExposedPorts: inspect.Config.ExposedPorts,
-		Entrypoint:   inspect.Config.Entrypoint,
-		Cmd:          inspect.Config.Cmd,
+	if !releaseID.IsNil() {
+		if cfg.Labels == nil {
+			cfg.Labels = make(map[string]string, 1)
+		}
+
+		cfg.Labels["ctrlplane.release"] = releaseID.String()
 	}
 
 	hostCfg := inspect.HostConfig
 	if hostCfg == nil {
 		hostCfg = &container.HostConfig{
-			NetworkMode:   container.NetworkMode(projectNetwork(req.InstanceID)),
+			NetworkMode:   container.NetworkMode(projectNetwork(instanceID)),
 			RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyUnlessStopped},
 		}
 	}
 
+	return cfg, hostCfg
+}
+
+// startReplacementContainer creates and starts a service's container
+// under current's name on the project network, aliased by service
+// name so siblings keep resolving it.
+func (p *Provider) startReplacementContainer(ctx context.Context, instanceID id.ID, current projectContainer, cfg *container.Config, hostCfg *container.HostConfig) error {
 	created, err := p.cli.ContainerCreate(ctx, cfg, hostCfg, &network.NetworkingConfig{
 		EndpointsConfig: map[string]*network.EndpointSettings{
-			projectNetwork(req.InstanceID): {Aliases: []string{current.ServiceName}},
+			projectNetwork(instanceID): {Aliases: []string{current.ServiceName}},
 		},
 	}, nil, current.Name)
 	if err != nil {
@@ -478,18 +545,12 @@ func (p *Provider) recreateServiceContainer(ctx context.Context, req provider.De
 	return nil
 }
 
-// Rollback is a no-op today — docker has no built-in release history,
-// so rollback would have to recreate using the previous image which
-// the deploy service already knows about and could route through Deploy.
-func (p *Provider) Rollback(_ context.Context, _ id.ID, _ id.ID) error {
-	return nil
-}
-
-// Scale is a no-op for the docker provider — single-container
-// instances don't have a horizontal scale axis. The kubernetes
-// provider is the right home for replica scaling.
-func (p *Provider) Scale(_ context.Context, _ id.ID, _ provider.ResourceSpec) error {
-	return nil
+// Rollback reverts the instance to releaseID. Docker keeps no
+// release history of its own, so without the Release's snapshot
+// there is nothing to restore from — deploy.Service calls
+// RollbackRelease (see rollback.go) with the snapshot instead.
+func (p *Provider) Rollback(_ context.Context, instanceID id.ID, releaseID id.ID) error {
+	return fmt.Errorf("docker: rollback %s to release %s: snapshot required, use RollbackRelease", projectName(instanceID), releaseID)
 }
 
 // Resources returns a one-shot point-in-time sample of the
@@ -540,7 +601,7 @@ func (p *Provider) Logs(ctx context.Context, instanceID id.ID, opts provider.Log
 		dockerOpts.Since = opts.Since.UTC().Format(time.RFC3339Nano)
 	}
 
-	target, err := p.resolveLogTarget(ctx, instanceID, opts.ServiceName)
+	target, err := p.resolveServiceContainer(ctx, instanceID, opts.ServiceName, "logs")
 	if err != nil {
 		return nil, err
 	}
@@ -557,11 +618,12 @@ func (p *Provider) Logs(ctx context.Context, instanceID id.ID, opts provider.Log
 	return demuxedDockerStream(rc), nil
 }
 
-// resolveLogTarget picks the container ID to stream logs from. Empty
-// serviceName picks the project's Main service. A non-existent service
-// name surfaces a clear error rather than docker's generic "no such
-// container".
-func (p *Provider) resolveLogTarget(ctx context.Context, instanceID id.ID, serviceName string) (string, error) {
+// resolveServiceContainer picks the container ID a per-service
+// operation (logs, exec) targets. Empty serviceName picks the
+// project's Main service. A non-existent service name surfaces a
+// clear error rather than docker's generic "no such container"; op
+// names the calling operation in that error.
+func (p *Provider) resolveServiceContainer(ctx context.Context, instanceID id.ID, serviceName, op string) (string, error) {
 	containers, err := p.listProjectContainers(ctx, instanceID)
 	if err != nil {
 		return "", err
@@ -575,33 +637,89 @@ func (p *Provider) resolveLogTarget(ctx context.Context, instanceID id.ID, servi
 	}
 
 	if serviceName == "" {
-		// Default to the Main service.
-		for _, c := range containers {
-			if c.Role == provider.RoleMain || c.Role == "" {
-				return c.ID, nil
-			}
-		}
-		// Fall back to the first non-Init container so logs stream
-		// from something even when Role labels are absent.
-		for _, c := range containers {
-			if c.Role != provider.RoleInit {
-				return c.ID, nil
-			}
+		serviceName = defaultServiceName(containers)
+	}
+
+	// Replica 0 is the stable target when a service is scaled out.
+	if replicas := serviceReplicas(containers)[serviceName]; len(replicas) > 0 {
+		return replicas[0].ID, nil
+	}
+
+	return "", fmt.Errorf("docker: %s: service %q not found in project %s", op, serviceName, projectName(instanceID))
+}
+
+// defaultServiceName picks the service a per-service operation targets
+// when the caller names none: the Main service, falling back to the
+// first non-Init service so logs stream from something even when Role
+// labels are absent.
+func defaultServiceName(containers []projectContainer) string {
+	for _, c := range containers {
+		if c.Role == provider.RoleMain || c.Role == "" {
+			return c.ServiceName
 		}
 	}
 
 	for _, c := range containers {
-		if c.ServiceName == serviceName {
-			return c.ID, nil
+		if c.Role != provider.RoleInit {
+			return c.ServiceName
 		}
 	}
 
-	return "", fmt.Errorf("docker: logs: service %q not found in project %s", serviceName, projectName(instanceID))
+	return ""
 }
 
-// Exec is not yet implemented.
-func (p *Provider) Exec(_ context.Context, _ id.ID, _ provider.ExecRequest) (*provider.ExecResult, error) {
-	return &provider.ExecResult{ExitCode: 0}, nil
+// Exec runs a one-off command inside one of the instance's
+// containers (cmd.ServiceName, defaulting to Main) via the docker
+// exec API and blocks until it exits. Stdin is
+// streamed from cmd.Stdin when set (and half-closed on EOF so
+// commands reading to end-of-input terminate); stdout and stderr are
+// demultiplexed into separate buffers unless cmd.TTY is set, in
+// which case docker merges them into one raw stream reported as
+// Stdout.
+//
+// A non-zero exit is not an error — it's reported via
+// ExecResult.ExitCode exactly as the command returned it. Errors are
+// reserved for "the command could not be run at all".
+func (p *Provider) Exec(ctx context.Context, instanceID id.ID, cmd provider.ExecRequest) (*provider.ExecResult, error) {
+	if len(cmd.Command) == 0 {
+		return nil, errors.New("docker: exec requires a command")
+	}
+
+	target, err := p.resolveServiceContainer(ctx, instanceID, cmd.ServiceName, "exec")
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := p.cli.ContainerExecCreate(ctx, target, container.ExecOptions{
+		Cmd:          cmd.Command,
+		Tty:          cmd.TTY,
+		AttachStdin:  cmd.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("docker: exec: container not found: %w", err)
+		}
+
+		return nil, fmt.Errorf("docker: exec create: %w", err)
+	}
+
+	stdout, stderr, err := p.attachExec(ctx, created.ID, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	exitCode, err := p.waitExecExit(ctx, created.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.ExecResult{
+		ExitCode: exitCode,
+		Stdout:   stdout,
+		Stderr:   stderr,
+	}, nil
 }
 
 // --- internals ---
@@ -614,11 +732,28 @@ func containerName(instanceID id.ID) string {
 	return "cp-" + instanceID.String()
 }
 
-// pullImage pulls the image from its registry. Errors are returned
-// to callers but the docker provider treats them as soft failures
-// (cached image often suffices for re-provisioning).
-func (p *Provider) pullImage(ctx context.Context, ref string) error {
-	body, err := p.cli.ImagePull(ctx, ref, image.PullOptions{})
+// pullImage pulls the image from its registry, authenticating with
+// cred when the service has one. Errors are returned to callers but
+// the docker provider treats them as soft failures (cached image often
+// suffices for re-provisioning).
+func (p *Provider) pullImage(ctx context.Context, ref string, cred *secrets.RegistryCredential) error {
+	opts := image.PullOptions{}
+
+	if cred != nil {
+		auth, err := registry.EncodeAuthConfig(registry.AuthConfig{
+			Username:      cred.Username,
+			Password:      cred.Password,
+			Email:         cred.Email,
+			ServerAddress: cred.Server,
+		})
+		if err != nil {
+			return fmt.Errorf("docker: encode registry auth for %s: %w", ref, err)
+		}
+
+		opts.RegistryAuth = auth
+	}
+
+	body, err := p.cli.ImagePull(ctx, ref, opts)
 	if err != nil {
 		return fmt.Errorf("docker: pull %s: %w", ref, err)
 	}
@@ -0,0 +1,74 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+
+	"github.com/xraph/ctrlplane/secrets"
+)
+
+// TestPullImage_SendsRegistryAuth verifies a service's registry
+// credential is sent as the X-Registry-Auth header, and that a pull
+// without one stays anonymous.
+func TestPullImage_SendsRegistryAuth(t *testing.T) {
+	t.Parallel()
+
+	var headers []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/images/create") {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		headers = append(headers, r.Header.Get(registry.AuthHeader))
+		_, _ = w.Write([]byte(`{"status":"done"}`))
+	}))
+	defer srv.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.47"))
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+
+	p := &Provider{cli: cli}
+	cred := &secrets.RegistryCredential{Server: "ghcr.io", Username: "bot", Password: "pw"}
+
+	if err := p.pullImage(t.Context(), "ghcr.io/acme/api:v1", cred); err != nil {
+		t.Fatalf("pull with auth: %v", err)
+	}
+
+	if err := p.pullImage(t.Context(), "nginx:1", nil); err != nil {
+		t.Fatalf("anonymous pull: %v", err)
+	}
+
+	if len(headers) != 2 {
+		t.Fatalf("pulls: want 2, got %d", len(headers))
+	}
+
+	raw, err := base64.URLEncoding.DecodeString(headers[0])
+	if err != nil {
+		t.Fatalf("decode auth header: %v", err)
+	}
+
+	var got registry.AuthConfig
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("unmarshal auth header: %v", err)
+	}
+
+	if got.Username != "bot" || got.Password != "pw" || got.ServerAddress != "ghcr.io" {
+		t.Fatalf("auth config: %+v", got)
+	}
+
+	if headers[1] != "" {
+		t.Fatalf("anonymous pull sent auth %q", headers[1])
+	}
+}
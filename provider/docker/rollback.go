@@ -0,0 +1,242 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// rollbackSuffix is appended to a container's name while its
+// replacement starts. Keeping the old container (stopped, renamed)
+// rather than removing it up front is what makes RollbackRelease
+// all-or-nothing: any failure renames it back and restarts it.
+const rollbackSuffix = "-rollback-prev"
+
+// rollbackStep pairs a live container with the snapshot it must be
+// rebuilt from.
+type rollbackStep struct {
+	current  projectContainer
+	snapshot provider.ServiceSnapshot
+}
+
+// RollbackRelease recreates the instance's containers from the target
+// Release's snapshot images, env and config files, as one
+// all-or-nothing action.
+//
+// Every container whose image, env or config files differ from the
+// snapshot is stopped and set aside under a temporary name, then
+// replaced. If any replacement fails, the new containers are removed
+// and the originals restored, so the project is never left half on
+// one Release and half on another. Ports, volumes, labels and the rest of the spec carry
+// over from the live containers, same as Deploy.
+func (p *Provider) RollbackRelease(ctx context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	if len(req.Services) == 0 {
+		return nil, fmt.Errorf("docker: rollback %s: release %s has no service snapshot", projectName(req.InstanceID), req.ReleaseID)
+	}
+
+	existing, err := p.listProjectContainers(ctx, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := rollbackPlan(existing, req.Services)
+	if err != nil {
+		return nil, fmt.Errorf("docker: rollback: %w", err)
+	}
+
+	var (
+		pending  []rollbackStep
+		inspects = make(map[string]container.InspectResponse, len(steps))
+	)
+
+	for _, st := range steps {
+		inspect, err := p.cli.ContainerInspect(ctx, st.current.ID)
+		if err != nil {
+			return nil, fmt.Errorf("docker: rollback: inspect %s: %w", st.current.Name, err)
+		}
+
+		if inspect.Config != nil && inspect.Config.Image == st.snapshot.Image &&
+			envMatches(inspect.Config.Env, p.imageEnv(ctx, inspect.Image), st.snapshot.Env) &&
+			!configFilesChanged(inspect.Config.Labels, st.snapshot.ConfigFiles) {
+			continue
+		}
+
+		// Pull before touching anything so a missing image fails the
+		// rollback while every container is still running.
+		if err := p.pullImage(ctx, st.snapshot.Image, st.snapshot.RegistryAuth); err != nil {
+			if _, inspectErr := p.cli.ImageInspect(ctx, st.snapshot.Image); inspectErr != nil {
+				return nil, fmt.Errorf("docker: rollback: %w", err)
+			}
+		}
+
+		inspects[st.current.ID] = inspect
+		pending = append(pending, st)
+	}
+
+	if err := p.replaceContainers(ctx, req, pending, inspects); err != nil {
+		return nil, fmt.Errorf("docker: rollback %s to release %s: %w", projectName(req.InstanceID), req.ReleaseID, err)
+	}
+
+	return &provider.DeployResult{
+		ProviderRef: "docker:" + projectName(req.InstanceID),
+		Status:      "rolled_back",
+	}, nil
+}
+
+// replaceContainers sets each pending container aside and starts its
+// replacement, undoing everything on the first failure.
+func (p *Provider) replaceContainers(ctx context.Context, req provider.RollbackRequest, pending []rollbackStep, inspects map[string]container.InspectResponse) error {
+	var setAside, replaced []rollbackStep
+
+	undo := func() {
+		// Cleanup must run even when ctx is what failed.
+		cleanupCtx := context.WithoutCancel(ctx)
+
+		for _, st := range replaced {
+			_ = p.cli.ContainerRemove(cleanupCtx, st.current.Name, container.RemoveOptions{Force: true})
+		}
+
+		for _, st := range setAside {
+			_ = p.cli.ContainerRename(cleanupCtx, st.current.ID, st.current.Name)
+			_ = p.cli.ContainerStart(cleanupCtx, st.current.ID, container.StartOptions{})
+		}
+	}
+
+	for _, st := range pending {
+		cfg, hostCfg := recreateConfig(inspects[st.current.ID], req.InstanceID, req.ReleaseID, st.snapshot.Image, st.snapshot.Env)
+		if len(st.snapshot.Env) == 0 {
+			// recreateConfig keeps the live env for an empty one, as
+			// Deploy wants; a rollback drops it for the image's own.
+			cfg.Env = nil
+		}
+		if err := p.applyConfigFiles(req.InstanceID, st.snapshot.Name, st.snapshot.ConfigFiles, cfg, hostCfg); err != nil {
+			undo()
+
+			return fmt.Errorf("service %q: %w", st.snapshot.Name, err)
+		}
+
+		if err := p.cli.ContainerStop(ctx, st.current.ID, container.StopOptions{}); err != nil && !cerrdefs.IsNotFound(err) {
+			undo()
+
+			return fmt.Errorf("stop %s: %w", st.current.Name, err)
+		}
+
+		if err := p.cli.ContainerRename(ctx, st.current.ID, st.current.Name+rollbackSuffix); err != nil {
+			_ = p.cli.ContainerStart(context.WithoutCancel(ctx), st.current.ID, container.StartOptions{})
+			undo()
+
+			return fmt.Errorf("set aside %s: %w", st.current.Name, err)
+		}
+
+		setAside = append(setAside, st)
+
+		if err := p.startReplacementContainer(ctx, req.InstanceID, st.current, cfg, hostCfg); err != nil {
+			// A created-but-unstarted container holds the name too.
+			replaced = append(replaced, st)
+			undo()
+
+			return fmt.Errorf("service %q: %w", st.snapshot.Name, err)
+		}
+
+		replaced = append(replaced, st)
+	}
+
+	// Every replacement is up — the set-aside originals can go.
+	var errs []error
+
+	for _, st := range setAside {
+		if err := p.cli.ContainerRemove(ctx, st.current.ID, container.RemoveOptions{Force: true}); err != nil && !cerrdefs.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("remove previous %s: %w", st.current.Name, err))
+		}
+	}
+
+	for _, st := range pending {
+		p.pruneConfigDirs(req.InstanceID, st.snapshot.Name, st.snapshot.ConfigFiles)
+	}
+
+	return errors.Join(errs...)
+}
+
+// rollbackPlan matches every snapshot to its live containers, one step
+// per replica. A snapshot naming a service the project doesn't run is an error:
+// restoring it would need the full ServiceSpec, which a Release
+// doesn't carry. Live services absent from the snapshot are left
+// alone. Pure function so it's testable without a docker socket.
+func rollbackPlan(existing []projectContainer, snapshots []provider.ServiceSnapshot) ([]rollbackStep, error) {
+	// serviceReplicas drops leftover set-aside containers from an
+	// interrupted rollback, so they're never targeted.
+	bySvc := serviceReplicas(existing)
+
+	steps := make([]rollbackStep, 0, len(snapshots))
+
+	for _, snap := range snapshots {
+		replicas, ok := bySvc[snap.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q in project", snap.Name)
+		}
+
+		for _, current := range replicas {
+			steps = append(steps, rollbackStep{current: current, snapshot: snap})
+		}
+	}
+
+	return steps, nil
+}
+
+// imageEnv returns the env baked into a container's image, or nil when
+// the image can't be inspected.
+func (p *Provider) imageEnv(ctx context.Context, imageID string) []string {
+	img, err := p.cli.ImageInspect(ctx, imageID)
+	if err != nil || img.Config == nil {
+		return nil
+	}
+
+	return img.Config.Env
+}
+
+// envMatches reports whether a container's env list is exactly the
+// image's env overlaid with want: every key=value in want is present,
+// and every other variable is the image's own. A variable a later
+// deploy added, or an image default it overrode, doesn't match, so the
+// rollback replaces env outright like Nomad's does. Without the
+// image's env every variable outside want counts as added.
+func envMatches(current, imageEnv []string, want map[string]string) bool {
+	have := parseEnv(current)
+	base := parseEnv(imageEnv)
+
+	for k, v := range want {
+		if got, ok := have[k]; !ok || got != v {
+			return false
+		}
+	}
+
+	for k, v := range have {
+		if _, ok := want[k]; ok {
+			continue
+		}
+
+		if def, ok := base[k]; !ok || def != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseEnv turns a KEY=value list into a map.
+func parseEnv(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+
+	return m
+}
@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestRollbackPlan_MatchesSnapshotsToContainers verifies each
+// snapshot is paired with its live container, leftover set-aside
+// containers are never targeted, and an unknown service fails the
+// whole plan before anything is touched.
+func TestRollbackPlan_MatchesSnapshotsToContainers(t *testing.T) {
+	t.Parallel()
+
+	existing := []projectContainer{
+		{ID: "old", Name: "cp-x-api" + rollbackSuffix, ServiceName: "api"},
+		{ID: "c1", Name: "cp-x-api", ServiceName: "api"},
+		{ID: "c2", Name: "cp-x-envoy", ServiceName: "envoy"},
+	}
+
+	steps, err := rollbackPlan(existing, []provider.ServiceSnapshot{{Name: "api", Image: "api:v1"}})
+	if err != nil {
+		t.Fatalf("rollbackPlan: %v", err)
+	}
+
+	if len(steps) != 1 || steps[0].current.ID != "c1" {
+		t.Fatalf("steps: want [c1], got %+v", steps)
+	}
+
+	if _, err := rollbackPlan(existing, []provider.ServiceSnapshot{{Name: "worker"}}); err == nil {
+		t.Fatal("unknown service: want error, got nil")
+	}
+}
+
+// TestEnvMatches verifies the container env must be the image's env
+// overlaid with the snapshot's: image-defined variables don't force a
+// recreate, but a variable a later deploy added or overrode does.
+func TestEnvMatches(t *testing.T) {
+	t.Parallel()
+
+	image := []string{"PATH=/usr/bin", "LANG=C"}
+	current := []string{"PATH=/usr/bin", "LANG=C", "MODE=v1", "EMPTY="}
+
+	tests := []struct {
+		name     string
+		current  []string
+		imageEnv []string
+		want     map[string]string
+		match    bool
+	}{
+		{name: "image env plus snapshot", current: current, imageEnv: image, want: map[string]string{"MODE": "v1", "EMPTY": ""}, match: true},
+		{name: "changed value", current: current, imageEnv: image, want: map[string]string{"MODE": "v2", "EMPTY": ""}},
+		{name: "missing key", current: current, imageEnv: image, want: map[string]string{"MODE": "v1", "EMPTY": "", "MISSING": "x"}},
+		{name: "added key", current: current, imageEnv: image, want: map[string]string{"MODE": "v1"}},
+		{name: "overridden image default", current: []string{"PATH=/usr/bin", "LANG=en_US"}, imageEnv: image, want: map[string]string{}},
+		{name: "unknown image env", current: current, want: map[string]string{"MODE": "v1", "EMPTY": ""}},
+	}
+
+	for _, tt := range tests {
+		if got := envMatches(tt.current, tt.imageEnv, tt.want); got != tt.match {
+			t.Errorf("%s: envMatches = %v, want %v", tt.name, got, tt.match)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package docker
+
+// scale.go implements Scale for Compose-style projects. Docker has no
+// replica primitive, so a service scaled to N runs N containers:
+//
+//   - replica 0 keeps the provision-time name `cp-<instanceID>-<service>`,
+//     replica i > 0 is `cp-<instanceID>-<service>-<i>`;
+//   - every replica carries the `ctrlplane.replica` label and the same
+//     `<service>` network alias, so siblings resolving the service name
+//     are spread across replicas by docker's embedded DNS;
+//   - extra replicas are cloned from the lowest-numbered replica, so
+//     they inherit its image, env, volumes and resource limits
+//     (StatefulSet-kind replicas get volumes of their own, see
+//     volumes.go).
+//
+// CPU and memory changes never recreate anything: they go through the
+// container update API and apply to the running containers in place.
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// labelReplica records a container's replica index within its service.
+// Containers without it (created before replicas existed) are replica 0.
+const labelReplica = "ctrlplane.replica"
+
+// replicaContainerName returns the container name for one replica of a
+// service. Replica 0 is the plain service container name so single-
+// replica projects look exactly as they did before scaling existed.
+func replicaContainerName(instanceID id.ID, serviceName string, index int) string {
+	if index == 0 {
+		return serviceContainerName(instanceID, serviceName)
+	}
+
+	return serviceContainerName(instanceID, serviceName) + "-" + strconv.Itoa(index)
+}
+
+// Scale applies a ResourceSpec to every Main and Sidecar service in
+// the project. CPUMillis and MemoryMB are updated in place on the live
+// containers; Replicas, when set, grows or shrinks each service to
+// that many containers. Zero-valued fields are left unchanged, matching
+// the kubernetes provider. Init services are never scaled.
+func (p *Provider) Scale(ctx context.Context, instanceID id.ID, spec provider.ResourceSpec) error {
+	containers, err := p.listProjectContainers(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	if len(containers) == 0 {
+		return fmt.Errorf("docker: scale: project %s has no containers", projectName(instanceID))
+	}
+
+	groups := serviceReplicas(containers)
+
+	if spec.CPUMillis > 0 || spec.MemoryMB > 0 {
+		update := container.UpdateConfig{Resources: resourcesFor(spec)}
+
+		for _, replicas := range groups {
+			for _, c := range replicas {
+				if c.Role == provider.RoleInit {
+					continue
+				}
+
+				if _, err := p.cli.ContainerUpdate(ctx, c.ID, update); err != nil {
+					return fmt.Errorf("docker: update resources %s: %w", c.Name, err)
+				}
+			}
+		}
+	}
+
+	if spec.Replicas > 0 {
+		for _, name := range slices.Sorted(maps.Keys(groups)) {
+			replicas := groups[name]
+			if replicas[0].Role == provider.RoleInit {
+				continue
+			}
+
+			if _, err := p.scaleService(ctx, instanceID, replicas, spec.Replicas); err != nil {
+				return fmt.Errorf("docker: scale service %q: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// scaleService converges one service to want replicas: missing
+// indices below want are cloned from the lowest-numbered replica,
+// indices at or above want are removed. Returns the endpoints of any
+// replicas it started.
+func (p *Provider) scaleService(ctx context.Context, instanceID id.ID, replicas []projectContainer, want int) ([]provider.Endpoint, error) {
+	add, remove := replicaPlan(replicas, want)
+
+	var endpoints []provider.Endpoint
+
+	if len(add) > 0 {
+		template, err := p.cli.ContainerInspect(ctx, replicas[0].ID)
+		if err != nil {
+			return nil, fmt.Errorf("inspect %s: %w", replicas[0].Name, err)
+		}
+
+		for _, index := range add {
+			eps, err := p.startReplica(ctx, instanceID, replicas[0].ServiceName, index, template)
+			if err != nil {
+				return nil, err
+			}
+
+			endpoints = append(endpoints, eps...)
+		}
+	}
+
+	for _, c := range remove {
+		if err := p.cli.ContainerRemove(ctx, c.ID, container.RemoveOptions{
+			Force:         true,
+			RemoveVolumes: true,
+		}); err != nil && !cerrdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("remove replica %s: %w", c.Name, err)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// startReplica creates and starts replica index of a service as a copy
+// of template.
+func (p *Provider) startReplica(ctx context.Context, instanceID id.ID, serviceName string, index int, template container.InspectResponse) ([]provider.Endpoint, error) {
+	name := replicaContainerName(instanceID, serviceName, index)
+
+	image := ""
+	if template.Config != nil {
+		image = template.Config.Image
+	}
+
+	cfg, hostCfg := recreateConfig(template, instanceID, id.Nil, image, nil)
+	if cfg.Labels == nil {
+		cfg.Labels = make(map[string]string, 1)
+	}
+
+	cfg.Labels[labelReplica] = strconv.Itoa(index)
+	hostCfg = replicaHostConfig(hostCfg)
+
+	if cfg.Labels[labelKind] == string(provider.KindStatefulSet) {
+		mounts, err := p.replicaMounts(ctx, instanceID, hostCfg.Mounts, index)
+		if err != nil {
+			return nil, err
+		}
+
+		hostCfg.Mounts = mounts
+	}
+
+	if err := p.removeIfExists(ctx, name); err != nil {
+		return nil, err
+	}
+
+	created, err := p.cli.ContainerCreate(ctx, cfg, hostCfg, &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			projectNetwork(instanceID): {Aliases: []string{serviceName}},
+		},
+	}, nil, name)
+	if err != nil {
+		return nil, fmt.Errorf("create replica %s: %w", name, err)
+	}
+
+	if err := p.cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		_ = p.cli.ContainerRemove(ctx, created.ID, container.RemoveOptions{Force: true})
+
+		return nil, fmt.Errorf("start replica %s: %w", name, err)
+	}
+
+	endpoints, err := p.endpointsFor(ctx, created.ID, name)
+	if err != nil {
+		return nil, fmt.Errorf("inspect endpoints %s: %w", name, err)
+	}
+
+	for i := range endpoints {
+		endpoints[i].ServiceName = serviceName
+	}
+
+	return endpoints, nil
+}
+
+// replicaHostConfig copies a template's host config for a new replica.
+// A fixed host port can only be bound once, so every binding is reset
+// to an ephemeral port; each replica gets its own public endpoint.
+func replicaHostConfig(hostCfg *container.HostConfig) *container.HostConfig {
+	out := *hostCfg
+	out.PortBindings = make(nat.PortMap, len(hostCfg.PortBindings))
+
+	for port, bindings := range hostCfg.PortBindings {
+		reset := make([]nat.PortBinding, len(bindings))
+		for i, b := range bindings {
+			reset[i] = nat.PortBinding{HostIP: b.HostIP}
+		}
+
+		out.PortBindings[port] = reset
+	}
+
+	return &out
+}
+
+// resourcesFor translates a ResourceSpec into docker cgroup limits.
+// Swap is pinned to the memory limit (i.e. no swap) so the limit means
+// the same thing it does on kubernetes.
+func resourcesFor(spec provider.ResourceSpec) container.Resources {
+	var res container.Resources
+
+	if spec.CPUMillis > 0 {
+		res.NanoCPUs = int64(spec.CPUMillis) * 1_000_000
+	}
+
+	if spec.MemoryMB > 0 {
+		res.Memory = int64(spec.MemoryMB) << 20
+		res.MemorySwap = res.Memory
+	}
+
+	return res
+}
+
+// serviceReplicas groups a project's containers by service, each group
+// ordered by replica index. Containers set aside by an interrupted
+// rollback are skipped. Pure function so it's testable without a
+// docker socket.
+func serviceReplicas(containers []projectContainer) map[string][]projectContainer {
+	out := make(map[string][]projectContainer)
+
+	for _, c := range containers {
+		if strings.HasSuffix(c.Name, rollbackSuffix) {
+			continue
+		}
+
+		out[c.ServiceName] = append(out[c.ServiceName], c)
+	}
+
+	for _, replicas := range out {
+		slices.SortFunc(replicas, func(a, b projectContainer) int { return a.Replica - b.Replica })
+	}
+
+	return out
+}
+
+// flattenReplicas lists grouped containers with services in name order
+// and replicas in index order, so callers walking them see replica 0
+// of each service first.
+func flattenReplicas(groups map[string][]projectContainer) []projectContainer {
+	var out []projectContainer
+
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		out = append(out, groups[name]...)
+	}
+
+	return out
+}
+
+// replicaPlan returns the replica indices to create and the containers
+// to remove for a service to end up with exactly want replicas,
+// numbered 0..want-1.
+func replicaPlan(replicas []projectContainer, want int) (add []int, remove []projectContainer) {
+	have := make(map[int]bool, len(replicas))
+
+	for _, c := range replicas {
+		if c.Replica >= want {
+			remove = append(remove, c)
+
+			continue
+		}
+
+		have[c.Replica] = true
+	}
+
+	for i := range want {
+		if !have[i] {
+			add = append(add, i)
+		}
+	}
+
+	return add, remove
+}
+
+// replicaIndex parses the replica label, treating a missing or
+// malformed value as replica 0.
+func replicaIndex(labels map[string]string) int {
+	n, err := strconv.Atoi(labels[labelReplica])
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return n
+}
+
+// addReplicaStatus folds one replica's status into its service's
+// aggregate: counts add up, the worst state wins, and the service is
+// Ready only when every replica is. ProviderRef stays replica 0's.
+func addReplicaStatus(agg provider.ServiceStatus, replica provider.ServiceStatus) provider.ServiceStatus {
+	if agg.Replicas == 0 {
+		replica.Replicas = 1
+		replica.ReadyReplicas = 0
+
+		if replica.Ready {
+			replica.ReadyReplicas = 1
+		}
+
+		return replica
+	}
+
+	agg.Replicas++
+
+	if replica.Ready {
+		agg.ReadyReplicas++
+	}
+
+	agg.Ready = agg.ReadyReplicas == agg.Replicas
+	agg.Restarts += replica.Restarts
+
+	if stateSeverity(replica.State) > stateSeverity(agg.State) {
+		agg.State = replica.State
+	}
+
+	if agg.Message == "" {
+		agg.Message = replica.Message
+	}
+
+	return agg
+}
+
+// stateSeverity orders container states for worst-of aggregation,
+// using the same precedence as Status.
+func stateSeverity(s provider.InstanceState) int {
+	switch s {
+	case provider.StateFailed:
+		return 3
+	case provider.StateStarting:
+		return 2
+	case provider.StateStopped:
+		return 1
+	default:
+		return 0
+	}
+}
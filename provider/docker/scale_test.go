@@ -0,0 +1,145 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestReplicaContainerName verifies replica 0 keeps the plain service
+// container name and later replicas get a numeric suffix.
+func TestReplicaContainerName(t *testing.T) {
+	t.Parallel()
+
+	iid := id.New(id.PrefixInstance)
+
+	if got, want := replicaContainerName(iid, "api", 0), serviceContainerName(iid, "api"); got != want {
+		t.Fatalf("replica 0: want %q, got %q", want, got)
+	}
+
+	if got, want := replicaContainerName(iid, "api", 2), serviceContainerName(iid, "api")+"-2"; got != want {
+		t.Fatalf("replica 2: want %q, got %q", want, got)
+	}
+}
+
+// TestReplicaPlan verifies scaling adds the missing low indices and
+// removes everything at or above the target.
+func TestReplicaPlan(t *testing.T) {
+	t.Parallel()
+
+	replicas := []projectContainer{{ID: "r0", Replica: 0}, {ID: "r2", Replica: 2}, {ID: "r3", Replica: 3}}
+
+	add, remove := replicaPlan(replicas, 3)
+	if len(add) != 1 || add[0] != 1 {
+		t.Fatalf("add: want [1], got %v", add)
+	}
+
+	if len(remove) != 1 || remove[0].ID != "r3" {
+		t.Fatalf("remove: want [r3], got %+v", remove)
+	}
+
+	add, remove = replicaPlan(replicas, 1)
+	if len(add) != 0 || len(remove) != 2 {
+		t.Fatalf("scale to 1: add=%v remove=%+v", add, remove)
+	}
+}
+
+// TestServiceReplicas_GroupsAndOrders verifies containers are grouped
+// per service in replica order and set-aside rollback containers are
+// dropped.
+func TestServiceReplicas_GroupsAndOrders(t *testing.T) {
+	t.Parallel()
+
+	groups := serviceReplicas([]projectContainer{
+		{ID: "a2", Name: "cp-x-api-2", ServiceName: "api", Replica: 2},
+		{ID: "a0", Name: "cp-x-api", ServiceName: "api"},
+		{ID: "old", Name: "cp-x-api" + rollbackSuffix, ServiceName: "api"},
+		{ID: "a1", Name: "cp-x-api-1", ServiceName: "api", Replica: 1},
+		{ID: "w0", Name: "cp-x-web", ServiceName: "web"},
+	})
+
+	api := groups["api"]
+	if len(api) != 3 || api[0].ID != "a0" || api[1].ID != "a1" || api[2].ID != "a2" {
+		t.Fatalf("api replicas: %+v", api)
+	}
+
+	flat := flattenReplicas(groups)
+	if len(flat) != 4 || flat[0].ID != "a0" || flat[3].ID != "w0" {
+		t.Fatalf("flatten: %+v", flat)
+	}
+}
+
+// TestReplicaHostConfig_ResetsHostPorts verifies a replica's port
+// bindings drop fixed host ports without mutating the template.
+func TestReplicaHostConfig_ResetsHostPorts(t *testing.T) {
+	t.Parallel()
+
+	port := nat.Port("8080/tcp")
+	template := &container.HostConfig{
+		PortBindings: nat.PortMap{port: {{HostIP: "0.0.0.0", HostPort: "18080"}}},
+		Resources:    container.Resources{Memory: 64 << 20},
+	}
+
+	got := replicaHostConfig(template)
+
+	if b := got.PortBindings[port][0]; b.HostPort != "" || b.HostIP != "0.0.0.0" {
+		t.Fatalf("replica binding: %+v", b)
+	}
+
+	if template.PortBindings[port][0].HostPort != "18080" {
+		t.Fatal("template binding was mutated")
+	}
+
+	if got.Memory != 64<<20 {
+		t.Fatalf("resources should carry over, got memory %d", got.Memory)
+	}
+}
+
+// TestResourcesFor verifies millicores and megabytes map onto docker's
+// nano-CPU and byte limits, with swap pinned to the memory limit.
+func TestResourcesFor(t *testing.T) {
+	t.Parallel()
+
+	res := resourcesFor(provider.ResourceSpec{CPUMillis: 500, MemoryMB: 256})
+
+	if res.NanoCPUs != 500_000_000 {
+		t.Fatalf("NanoCPUs: want 5e8, got %d", res.NanoCPUs)
+	}
+
+	if res.Memory != 256<<20 || res.MemorySwap != res.Memory {
+		t.Fatalf("memory: %d swap: %d", res.Memory, res.MemorySwap)
+	}
+
+	if zero := resourcesFor(provider.ResourceSpec{Replicas: 3}); zero.NanoCPUs != 0 || zero.Memory != 0 {
+		t.Fatalf("unset fields should stay zero, got %+v", zero)
+	}
+}
+
+// TestAddReplicaStatus_AggregatesWorstOf verifies replica statuses
+// fold into counts, summed restarts, worst-of state and replica 0's
+// provider ref.
+func TestAddReplicaStatus_AggregatesWorstOf(t *testing.T) {
+	t.Parallel()
+
+	var agg provider.ServiceStatus
+
+	agg = addReplicaStatus(agg, provider.ServiceStatus{State: provider.StateRunning, Ready: true, Restarts: 1, ProviderRef: "r0"})
+	agg = addReplicaStatus(agg, provider.ServiceStatus{State: provider.StateFailed, Restarts: 2, ProviderRef: "r1", Message: "oom"})
+	agg = addReplicaStatus(agg, provider.ServiceStatus{State: provider.StateRunning, Ready: true, ProviderRef: "r2"})
+
+	if agg.Replicas != 3 || agg.ReadyReplicas != 2 {
+		t.Fatalf("counts: %d/%d", agg.ReadyReplicas, agg.Replicas)
+	}
+
+	if agg.Ready || agg.State != provider.StateFailed || agg.Restarts != 3 {
+		t.Fatalf("aggregate: %+v", agg)
+	}
+
+	if agg.ProviderRef != "r0" || agg.Message != "oom" {
+		t.Fatalf("ref/message: %+v", agg)
+	}
+}
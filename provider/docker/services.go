@@ -83,7 +83,8 @@ func projectLabels(instanceID id.ID, tenantID, serviceName string, role provider
 }
 
 // provisionProject creates the project's network, runs Inits to
-// completion, then creates + starts every Main/Sidecar container.
+// completion, then creates + starts every Main/Sidecar container —
+// one per replica when the Main service asks for more than one.
 // Returns the per-service container IDs so the caller can populate
 // Instance.ServiceRefs.
 //
@@ -107,6 +108,7 @@ func (p *Provider) provisionProject(ctx context.Context, req provider.ProvisionR
 
 	serviceRefs := make(map[string]string, len(mains)+len(sidecars))
 	endpoints := make([]provider.Endpoint, 0)
+	replicas := provisionReplicas(req.Services)
 
 	// Main first, then Sidecars. Sidecars typically depend on Main
 	// (for shared volumes or network namespace) — starting in this
@@ -121,6 +123,22 @@ func (p *Provider) provisionProject(ctx context.Context, req provider.ProvisionR
 		serviceRefs[svc.Name] = ref
 
 		endpoints = append(endpoints, eps...)
+
+		if replicas > 1 {
+			first := projectContainer{
+				ID:          ref,
+				Name:        serviceContainerName(req.InstanceID, svc.Name),
+				ServiceName: svc.Name,
+				Role:        svc.Role,
+			}
+
+			eps, err := p.scaleService(ctx, req.InstanceID, []projectContainer{first}, replicas)
+			if err != nil {
+				return nil, fmt.Errorf("docker: service %q: %w", svc.Name, err)
+			}
+
+			endpoints = append(endpoints, eps...)
+		}
 	}
 
 	return &provider.ProvisionResult{
@@ -130,6 +148,17 @@ func (p *Provider) provisionProject(ctx context.Context, req provider.ProvisionR
 	}, nil
 }
 
+// provisionReplicas returns how many replicas each Main and Sidecar
+// service starts with: the Main service's Resources.Replicas, or 1.
+// Like a kubernetes pod, the whole project scales together.
+func provisionReplicas(services []provider.ServiceSpec) int {
+	if main := pickMainService(services); main != nil {
+		return max(main.Resources.Replicas, 1)
+	}
+
+	return 1
+}
+
 // classifyServices splits a service slice into init / main / sidecar
 // groups, sorted by DependsOn so siblings declared after their
 // dependencies still come up in the right order.
@@ -207,13 +236,21 @@ func (p *Provider) createServiceContainer(ctx context.Context, req provider.Prov
 		return "", nil, err
 	}
 
-	_ = p.pullImage(ctx, svc.Image)
+	_ = p.pullImage(ctx, svc.Image, svc.RegistryAuth)
+
+	if err := p.ensureVolumes(ctx, req, svc.Volumes); err != nil {
+		return "", nil, err
+	}
 
 	cfg, hostCfg, netCfg, err := p.buildServiceContainerConfig(req, svc)
 	if err != nil {
 		return "", nil, err
 	}
 
+	if err := p.applyConfigFiles(req.InstanceID, svc.Name, svc.ConfigFiles, cfg, hostCfg); err != nil {
+		return "", nil, err
+	}
+
 	created, err := p.cli.ContainerCreate(ctx, cfg, hostCfg, netCfg, nil, name)
 	if err != nil {
 		return "", nil, fmt.Errorf("create container %s: %w", name, err)
@@ -265,10 +302,15 @@ func (p *Provider) buildServiceContainerConfig(req provider.ProvisionRequest, sv
 		Cmd:          svc.Args,
 	}
 
+	if req.Kind != "" {
+		cfg.Labels[labelKind] = string(req.Kind)
+	}
+
 	hostCfg := &container.HostConfig{
 		PortBindings:  portBindings,
 		NetworkMode:   container.NetworkMode(projectNetwork(req.InstanceID)),
 		RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyUnlessStopped},
+		Mounts:        volumeMounts(req.InstanceID, svc.Volumes),
 	}
 
 	if svc.Role == provider.RoleInit {
@@ -324,6 +366,7 @@ func (p *Provider) listProjectContainers(ctx context.Context, instanceID id.ID)
 			Name:        firstName(c.Names),
 			ServiceName: c.Labels["ctrlplane.service"],
 			Role:        provider.ServiceRole(c.Labels["ctrlplane.role"]),
+			Replica:     replicaIndex(c.Labels),
 			State:       c.State,
 		})
 	}
@@ -339,6 +382,7 @@ type projectContainer struct {
 	Name        string
 	ServiceName string
 	Role        provider.ServiceRole
+	Replica     int
 	State       string
 }
 
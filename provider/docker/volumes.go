@@ -0,0 +1,178 @@
+package docker
+
+// volumes.go backs ServiceSpec.Volumes with docker named volumes.
+// Every VolumeSpec becomes a volume named
+// `cp-<instanceID>-vol-<name>`, carrying the project labels so
+// Deprovision can find it without the ServiceSpec. Services declaring
+// the same volume name share one volume, the docker equivalent of a
+// pod volume mounted into both Main and a Sidecar.
+//
+// Volumes outlive their containers: Deploy, RollbackRelease and Scale
+// recreate or remove containers but never volumes, and the mounts
+// ride along in the inspected HostConfig. Only Deprovision removes
+// them.
+//
+// A StatefulSet-kind project gives each replica its own volumes, as
+// volumeClaimTemplates do on kubernetes: replica i > 0 mounts
+// `cp-<instanceID>-vol-<name>-<i>`. Scaling down keeps those volumes,
+// so scaling back up reattaches a replica's data. Deployment-kind
+// replicas all share the instance's volumes.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+const (
+	// labelVolume records the VolumeSpec name a docker volume backs.
+	labelVolume = "ctrlplane.volume"
+
+	// labelKind records the project's WorkloadKind on its containers
+	// so Scale knows whether new replicas get volumes of their own.
+	labelKind = "ctrlplane.kind"
+)
+
+// volumeName returns the docker volume name backing one VolumeSpec for
+// one replica. Replica 0's is also the volume every replica of a
+// Deployment-kind project shares.
+func volumeName(instanceID id.ID, name string, replica int) string {
+	base := projectName(instanceID) + "-vol-" + name
+	if replica == 0 {
+		return base
+	}
+
+	return base + "-" + strconv.Itoa(replica)
+}
+
+// volumeMounts converts a service's VolumeSpecs into docker mounts of
+// the project's named volumes.
+func volumeMounts(instanceID id.ID, volumes []provider.VolumeSpec) []mount.Mount {
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	mounts := make([]mount.Mount, 0, len(volumes))
+	for _, v := range volumes {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: volumeName(instanceID, v.Name, 0),
+			Target: v.MountPath,
+		})
+	}
+
+	return mounts
+}
+
+// ensureVolumes creates the labelled named volumes a service mounts.
+// Docker would create a missing volume on container create by itself,
+// but unlabelled, which would hide it from Deprovision. VolumeCreate
+// returns the existing volume when the name is taken, so this is
+// idempotent across re-provisions.
+func (p *Provider) ensureVolumes(ctx context.Context, req provider.ProvisionRequest, volumes []provider.VolumeSpec) error {
+	for _, v := range volumes {
+		if err := p.createVolume(ctx, volumeName(req.InstanceID, v.Name, 0), volumeLabels(req.InstanceID, req.TenantID, v.Name, 0, req.Labels)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createVolume creates one named volume with the given labels.
+func (p *Provider) createVolume(ctx context.Context, name string, labels map[string]string) error {
+	if _, err := p.cli.VolumeCreate(ctx, volume.CreateOptions{
+		Name:   name,
+		Driver: "local",
+		Labels: labels,
+	}); err != nil {
+		return fmt.Errorf("create volume %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// volumeLabels returns the labels stamped on a project volume: the
+// project labels plus the VolumeSpec name and, for per-replica
+// volumes, the replica index.
+func volumeLabels(instanceID id.ID, tenantID, name string, replica int, extra map[string]string) map[string]string {
+	labels := projectLabels(instanceID, tenantID, "", "", extra)
+	labels[labelVolume] = name
+
+	if replica > 0 {
+		labels[labelReplica] = strconv.Itoa(replica)
+	}
+
+	return labels
+}
+
+// replicaMounts returns the mounts for a new replica of a
+// StatefulSet-kind service: each project volume in the template's
+// mounts is swapped for the replica's own, created on first use.
+// Mounts of anything other than a project volume are kept as-is.
+func (p *Provider) replicaMounts(ctx context.Context, instanceID id.ID, template []mount.Mount, replica int) ([]mount.Mount, error) {
+	out := make([]mount.Mount, 0, len(template))
+
+	for _, m := range template {
+		if m.Type != mount.TypeVolume || m.Source == "" {
+			out = append(out, m)
+
+			continue
+		}
+
+		vol, err := p.cli.VolumeInspect(ctx, m.Source)
+		if err != nil {
+			return nil, fmt.Errorf("inspect volume %s: %w", m.Source, err)
+		}
+
+		name := vol.Labels[labelVolume]
+		if name == "" || vol.Labels["ctrlplane.project"] != projectName(instanceID) {
+			out = append(out, m)
+
+			continue
+		}
+
+		m.Source = volumeName(instanceID, name, replica)
+
+		labels := volumeLabels(instanceID, vol.Labels["ctrlplane.tenant"], name, replica, nil)
+		if err := p.createVolume(ctx, m.Source, labels); err != nil {
+			return nil, err
+		}
+
+		out = append(out, m)
+	}
+
+	return out, nil
+}
+
+// removeProjectVolumes deletes every volume labelled with the
+// project. Called by Deprovision once the containers using them are
+// gone; a volume that's already gone counts as removed.
+func (p *Provider) removeProjectVolumes(ctx context.Context, instanceID id.ID) error {
+	args := filters.NewArgs()
+	args.Add("label", "ctrlplane.project="+projectName(instanceID))
+
+	resp, err := p.cli.VolumeList(ctx, volume.ListOptions{Filters: args})
+	if err != nil {
+		return fmt.Errorf("docker: list project volumes: %w", err)
+	}
+
+	var errs []error
+
+	for _, v := range resp.Volumes {
+		if rmErr := p.cli.VolumeRemove(ctx, v.Name, true); rmErr != nil && !cerrdefs.IsNotFound(rmErr) {
+			errs = append(errs, fmt.Errorf("docker: remove volume %s: %w", v.Name, rmErr))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,129 @@
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestBuildServiceContainerConfig_MountsNamedVolumes verifies each
+// VolumeSpec becomes a mount of the project's named volume, and the
+// workload kind is stamped for Scale.
+func TestBuildServiceContainerConfig_MountsNamedVolumes(t *testing.T) {
+	t.Parallel()
+
+	p := &Provider{}
+	iid := id.New(id.PrefixInstance)
+
+	req := provider.ProvisionRequest{InstanceID: iid, Kind: provider.KindStatefulSet}
+	svc := provider.ServiceSpec{
+		Name:    "db",
+		Image:   "postgres:16",
+		Volumes: []provider.VolumeSpec{{Name: "data", MountPath: "/var/lib/postgresql/data", SizeMB: 1024}},
+	}
+
+	cfg, hostCfg, _, err := p.buildServiceContainerConfig(req, svc)
+	if err != nil {
+		t.Fatalf("buildServiceContainerConfig: %v", err)
+	}
+
+	want := mount.Mount{Type: mount.TypeVolume, Source: "cp-" + iid.String() + "-vol-data", Target: "/var/lib/postgresql/data"}
+	if len(hostCfg.Mounts) != 1 || hostCfg.Mounts[0] != want {
+		t.Fatalf("mounts: want [%+v], got %+v", want, hostCfg.Mounts)
+	}
+
+	if cfg.Labels[labelKind] != string(provider.KindStatefulSet) {
+		t.Fatalf("kind label: got %q", cfg.Labels[labelKind])
+	}
+}
+
+// TestVolumeName_PerReplica verifies replica 0 owns the base volume
+// name and later replicas get a numeric suffix.
+func TestVolumeName_PerReplica(t *testing.T) {
+	t.Parallel()
+
+	iid := id.New(id.PrefixInstance)
+
+	if got, want := volumeName(iid, "data", 0), "cp-"+iid.String()+"-vol-data"; got != want {
+		t.Fatalf("replica 0: want %q, got %q", want, got)
+	}
+
+	if got, want := volumeName(iid, "data", 3), "cp-"+iid.String()+"-vol-data-3"; got != want {
+		t.Fatalf("replica 3: want %q, got %q", want, got)
+	}
+
+	labels := volumeLabels(iid, "ten_t", "data", 3, nil)
+	if labels[labelVolume] != "data" || labels[labelReplica] != "3" || labels["ctrlplane.project"] != projectName(iid) {
+		t.Fatalf("labels: %v", labels)
+	}
+}
+
+// TestRemoveProjectVolumes_FiltersByProject verifies Deprovision's
+// volume sweep lists by the project label and force-removes every
+// volume it gets back.
+func TestRemoveProjectVolumes_FiltersByProject(t *testing.T) {
+	t.Parallel()
+
+	iid := id.New(id.PrefixInstance)
+
+	var (
+		mu      sync.Mutex
+		filter  string
+		removed []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/volumes"):
+			filter = r.URL.Query().Get("filters")
+			_ = json.NewEncoder(w).Encode(volume.ListResponse{Volumes: []*volume.Volume{
+				{Name: volumeName(iid, "data", 0)},
+				{Name: volumeName(iid, "data", 1)},
+			}})
+		case r.Method == http.MethodDelete:
+			removed = append(removed, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]+"?force="+r.URL.Query().Get("force"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.47"))
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+
+	p := &Provider{cli: cli}
+
+	if err := p.removeProjectVolumes(t.Context(), iid); err != nil {
+		t.Fatalf("removeProjectVolumes: %v", err)
+	}
+
+	if !strings.Contains(filter, "ctrlplane.project="+projectName(iid)) {
+		t.Fatalf("filter: want project label, got %s", filter)
+	}
+
+	slices.Sort(removed)
+
+	want := []string{volumeName(iid, "data", 0) + "?force=1", volumeName(iid, "data", 1) + "?force=1"}
+	slices.Sort(want)
+
+	if !slices.Equal(removed, want) {
+		t.Fatalf("removed: want %v, got %v", want, removed)
+	}
+}
@@ -0,0 +1,179 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// watchedActions are the container events that can move an
+// instance's state. Exec, attach and top events fire constantly on a
+// busy host and never do.
+var watchedActions = []events.Action{
+	events.ActionStart,
+	events.ActionRestart,
+	events.ActionStop,
+	events.ActionKill,
+	events.ActionDie,
+	events.ActionOOM,
+	events.ActionPause,
+	events.ActionUnPause,
+	events.ActionDestroy,
+}
+
+// Watch streams state changes from the Docker events API, filtered to
+// containers carrying the ctrlplane.instance label. Each relevant
+// event re-reads the instance's Status, so the reported state is the
+// same aggregate Status returns; a non-zero "die" additionally
+// reports a restart and an "oom" event an OOM kill.
+func (p *Provider) Watch(ctx context.Context) (<-chan provider.StateChange, error) {
+	args := filters.NewArgs(
+		filters.Arg("type", string(events.ContainerEventType)),
+		filters.Arg("label", "ctrlplane.instance"),
+	)
+	for _, a := range watchedActions {
+		args.Add("event", string(a))
+	}
+
+	msgs, errs := p.cli.Events(ctx, events.ListOptions{Filters: args})
+
+	// Events returns before the daemon answers; surface an immediate
+	// refusal (daemon down, bad filter) as an error rather than a
+	// stream that closes straight away.
+	select {
+	case err := <-errs:
+		if err != nil {
+			return nil, fmt.Errorf("docker: watch events: %w", err)
+		}
+	default:
+	}
+
+	stream := provider.NewWatchStream(64)
+
+	go func() {
+		defer stream.Close()
+
+		// killed records containers sent a signal by `docker stop`,
+		// Stop or Deprovision, so their "die" isn't reported as a
+		// crash, and containers already reported as OOM-killed, so
+		// the same death isn't reported twice.
+		killed := make(map[string]bool)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-errs:
+				return
+			case msg := <-msgs:
+				if msg.Action == events.ActionKill {
+					killed[msg.Actor.ID] = true
+
+					continue
+				}
+
+				wasKilled := killed[msg.Actor.ID]
+
+				switch msg.Action {
+				case events.ActionOOM:
+					killed[msg.Actor.ID] = true
+				case events.ActionDie:
+					delete(killed, msg.Actor.ID)
+				}
+
+				if !p.forwardEvent(ctx, stream, msg, wasKilled) {
+					return
+				}
+			}
+		}
+	}()
+
+	return stream.C(), nil
+}
+
+// forwardEvent turns one Docker event into StateChanges. It reports
+// false once the consumer has gone away.
+func (p *Provider) forwardEvent(ctx context.Context, stream *provider.WatchStream, msg events.Message, killed bool) bool {
+	instanceID, ok := eventInstance(msg)
+	if !ok {
+		return true
+	}
+
+	tenantID := msg.Actor.Attributes["ctrlplane.tenant"]
+
+	if c, ok := eventChange(msg, killed); ok {
+		c.InstanceID, c.TenantID = instanceID, tenantID
+		if !stream.Send(ctx, c) {
+			return false
+		}
+	}
+
+	status, err := p.Status(ctx, instanceID)
+	if err != nil {
+		// A transient inspect failure shouldn't end the watch; the
+		// next event for this instance re-reads it.
+		return ctx.Err() == nil
+	}
+
+	return stream.Send(ctx, provider.StateChange{
+		Kind:       provider.ChangeState,
+		InstanceID: instanceID,
+		TenantID:   tenantID,
+		Status:     status,
+	})
+}
+
+// eventInstance returns the instance a container event belongs to.
+func eventInstance(msg events.Message) (id.ID, bool) {
+	raw := msg.Actor.Attributes["ctrlplane.instance"]
+	if raw == "" {
+		return id.Nil, false
+	}
+
+	instanceID, err := id.Parse(raw)
+	if err != nil {
+		return id.Nil, false
+	}
+
+	return instanceID, true
+}
+
+// eventChange maps the container events that are news in their own
+// right — a crash, an OOM kill — to a StateChange. A "die" after a
+// "kill" is a requested stop, not a crash. Pure function so it's
+// testable without a daemon.
+func eventChange(msg events.Message, killed bool) (provider.StateChange, bool) {
+	attrs := msg.Actor.Attributes
+	c := provider.StateChange{Service: attrs["ctrlplane.service"]}
+
+	if msg.TimeNano > 0 {
+		c.At = time.Unix(0, msg.TimeNano).UTC()
+	}
+
+	switch msg.Action {
+	case events.ActionOOM:
+		c.Kind = provider.ChangeOOMKilled
+		c.Message = "container exceeded its memory limit"
+
+		return c, true
+	case events.ActionDie:
+		code, _ := strconv.Atoi(attrs["exitCode"])
+		if code == 0 || killed || attrs["ctrlplane.role"] == string(provider.RoleInit) {
+			return c, false
+		}
+
+		c.Kind = provider.ChangeRestart
+		c.Message = "container exited with code " + attrs["exitCode"]
+
+		return c, true
+	default:
+		return c, false
+	}
+}
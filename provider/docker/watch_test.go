@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestEventChange_ClassifiesContainerEvents verifies a non-zero exit
+// is a restart, an OOM event an OOM kill, and a requested stop, a
+// clean exit or a finished init is not news on its own.
+func TestEventChange_ClassifiesContainerEvents(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	msg := func(action events.Action, attrs map[string]string) events.Message {
+		a := map[string]string{"ctrlplane.instance": instID.String(), "ctrlplane.service": "api"}
+		for k, v := range attrs {
+			a[k] = v
+		}
+
+		return events.Message{Action: action, Actor: events.Actor{ID: "c1", Attributes: a}}
+	}
+
+	tests := []struct {
+		name   string
+		msg    events.Message
+		killed bool
+		want   provider.ChangeKind
+	}{
+		{"crash", msg(events.ActionDie, map[string]string{"exitCode": "1"}), false, provider.ChangeRestart},
+		{"oom", msg(events.ActionOOM, nil), false, provider.ChangeOOMKilled},
+		{"requested stop", msg(events.ActionDie, map[string]string{"exitCode": "143"}), true, ""},
+		{"clean exit", msg(events.ActionDie, map[string]string{"exitCode": "0"}), false, ""},
+		{"init finished", msg(events.ActionDie, map[string]string{"exitCode": "2", "ctrlplane.role": "init"}), false, ""},
+		{"start", msg(events.ActionStart, nil), false, ""},
+	}
+
+	for _, tt := range tests {
+		c, ok := eventChange(tt.msg, tt.killed)
+		if (tt.want != "") != ok || (ok && c.Kind != tt.want) {
+			t.Errorf("%s: got kind=%q ok=%v, want %q", tt.name, c.Kind, ok, tt.want)
+		}
+
+		if ok && c.Service != "api" {
+			t.Errorf("%s: service = %q, want api", tt.name, c.Service)
+		}
+	}
+
+	if got, ok := eventInstance(msg(events.ActionStart, nil)); !ok || got != instID {
+		t.Fatalf("eventInstance = %s, %v", got, ok)
+	}
+}
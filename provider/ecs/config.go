@@ -0,0 +1,54 @@
+package ecs
+
+// Config holds configuration for the AWS ECS provider.
+type Config struct {
+	// Region is the AWS region the cluster runs in. Reported as the
+	// provider's region.
+	Region string `default:"us-east-1" env:"CP_AWS_REGION" json:"region"`
+
+	// Cluster is the ECS cluster name or ARN instance services are
+	// created in.
+	Cluster string `default:"default" env:"CP_AWS_ECS_CLUSTER" json:"cluster"`
+
+	// Endpoint overrides the ECS API endpoint. Empty uses the
+	// region's; tests point it at a local stub.
+	Endpoint string `env:"CP_AWS_ECS_ENDPOINT" json:"endpoint,omitempty"`
+
+	// LogsEndpoint overrides the CloudWatch Logs API endpoint.
+	LogsEndpoint string `env:"CP_AWS_LOGS_ENDPOINT" json:"logs_endpoint,omitempty"`
+
+	// AccessKeyID and SecretAccessKey are static credentials. When
+	// empty, the SDK's default credential chain is used (env, shared
+	// config, task or instance role).
+	AccessKeyID     string `env:"CP_AWS_ACCESS_KEY_ID"     json:"-"`
+	SecretAccessKey string `env:"CP_AWS_SECRET_ACCESS_KEY" json:"-"`
+
+	// SubnetIDs and SecurityGroups place tasks in the VPC (awsvpc
+	// network mode). Use CP_AWS_SUBNET_IDS / CP_AWS_SECURITY_GROUPS
+	// (comma-separated) to configure via env.
+	SubnetIDs      []string `env:"CP_AWS_SUBNET_IDS"      json:"subnet_ids,omitempty"`
+	SecurityGroups []string `env:"CP_AWS_SECURITY_GROUPS" json:"security_groups,omitempty"`
+
+	// AssignPublicIP gives each task a public IP. Needed in public
+	// subnets without a NAT gateway so tasks can pull images.
+	AssignPublicIP bool `env:"CP_AWS_ASSIGN_PUBLIC_IP" json:"assign_public_ip,omitempty"`
+
+	// LaunchType is FARGATE or EC2.
+	LaunchType string `default:"FARGATE" env:"CP_AWS_ECS_LAUNCH_TYPE" json:"launch_type"`
+
+	// ExecutionRoleARN is the task execution role ECS pulls images
+	// and writes logs with.
+	ExecutionRoleARN string `env:"CP_AWS_EXEC_ROLE_ARN" json:"execution_role_arn,omitempty"`
+
+	// TaskRoleARN is the IAM role the running containers assume.
+	TaskRoleARN string `env:"CP_AWS_TASK_ROLE_ARN" json:"task_role_arn,omitempty"`
+
+	// LogGroup is the CloudWatch log group every container logs to
+	// through the awslogs driver. It must exist, or the execution
+	// role must be allowed to create it.
+	LogGroup string `default:"/ctrlplane/ecs" env:"CP_AWS_LOG_GROUP" json:"log_group"`
+
+	// NamePrefix prefixes the per-instance service and task
+	// definition family names.
+	NamePrefix string `default:"cp" env:"CP_AWS_ECS_NAME_PREFIX" json:"name_prefix"`
+}
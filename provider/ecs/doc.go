@@ -0,0 +1,36 @@
+// Package ecs is an AWS ECS-backed provider.Provider.
+//
+// Each ctrlplane Instance maps to one ECS service named
+// <NamePrefix>-<instanceID> in the configured cluster, running a task
+// definition of the same family. Tasks use awsvpc networking in the
+// configured subnets, on Fargate by default.
+//
+// Mapping from the container model:
+//
+//   - Every ServiceSpec becomes a container of the one task
+//     definition. Init services are non-essential containers, and
+//     every long-lived container waits for them to exit successfully;
+//     DependsOn becomes a START container dependency.
+//   - The task is sized from the sum of the long-lived services' CPU
+//     and memory, rounded up to a valid Fargate size. Per-container
+//     CPU and memory are set when the service requests them.
+//   - PortSpecs become port mappings. An HTTP HealthCheck becomes a
+//     container health check running curl (or wget) in the container.
+//   - Deploy and RollbackRelease register a new task definition
+//     revision tagged with the Release and point the service at it;
+//     ECS's rolling update, with the deployment circuit breaker on,
+//     replaces the tasks. Rollback reuses the revision tagged with the
+//     target Release when it is still registered. Scale sets the
+//     desired count and, for CPU or memory, registers a resized
+//     revision. Stop scales to zero and Start restores the count.
+//
+// Logs are read from CloudWatch: every container logs to the
+// configured group through the awslogs driver with the family as
+// stream prefix. Exec (which needs the SSM session protocol), config
+// files with content, volumes and registry credentials are not
+// mapped; images on ECR are pulled with the execution role.
+//
+// Config.Endpoint and Config.LogsEndpoint override the API endpoints,
+// so the provider can run against a local stub of the ECS and
+// CloudWatch Logs JSON APIs.
+package ecs
@@ -0,0 +1,450 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// fakeARNPrefix prefixes every ARN the fake hands out.
+const fakeARNPrefix = "arn:aws:ecs:eu-west-1:123456789012:"
+
+// fakeAWS is an in-memory stand-in for the ECS and CloudWatch Logs
+// JSON APIs. Services converge immediately: the running count always
+// equals the desired count.
+type fakeAWS struct {
+	mu       sync.Mutex
+	families map[string][]*types.TaskDefinition
+	tdTags   map[string][]types.Tag
+	services map[string]*types.Service
+	events   []cwtypes.FilteredLogEvent
+}
+
+// newFakeAWS starts a fake API and returns it with a provider pointed
+// at it for both ECS and CloudWatch Logs.
+func newFakeAWS(t *testing.T) (*fakeAWS, *Provider) {
+	t.Helper()
+
+	f := &fakeAWS{
+		families: make(map[string][]*types.TaskDefinition),
+		tdTags:   make(map[string][]types.Tag),
+		services: make(map[string]*types.Service),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+
+	p, err := New(
+		WithRegion("eu-west-1"),
+		WithCluster("tenants"),
+		WithEndpoint(srv.URL),
+		WithLogsEndpoint(srv.URL),
+		WithStaticCredentials("AKIDTEST", "secret"),
+		WithNetwork([]string{"subnet-a"}, []string{"sg-1"}, false),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	p.logPoll = 10 * time.Millisecond
+
+	return f, p
+}
+
+// service returns a copy of the named service.
+func (f *fakeAWS) service(name string) types.Service {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return *f.services[name]
+}
+
+// taskDefinition returns a copy of the task definition arn names.
+func (f *fakeAWS) taskDefinition(arn string) (types.TaskDefinition, []types.Tag) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	td := f.lookup(arn)
+
+	return *td, f.tdTags[arn]
+}
+
+// revisions returns how many revisions of family are registered.
+func (f *fakeAWS) revisions(family string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.families[family])
+}
+
+// addLog appends a log event to stream.
+func (f *fakeAWS) addLog(stream string, ts int64, msg string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.events = append(f.events, cwtypes.FilteredLogEvent{
+		EventId:       aws.String(fmt.Sprintf("ev-%d", len(f.events))),
+		LogStreamName: aws.String(stream),
+		Timestamp:     aws.Int64(ts),
+		Message:       aws.String(msg),
+	})
+}
+
+func (f *fakeAWS) serve(w http.ResponseWriter, r *http.Request) {
+	target := r.Header.Get("X-Amz-Target")
+	_, op, _ := strings.Cut(target, ".")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var (
+		out any
+		err *fakeError
+	)
+
+	switch op {
+	case "RegisterTaskDefinition":
+		out, err = decodeAnd(r, f.registerTaskDefinition)
+	case "DescribeTaskDefinition":
+		out, err = decodeAnd(r, f.describeTaskDefinition)
+	case "ListTaskDefinitions":
+		out, err = decodeAnd(r, f.listTaskDefinitions)
+	case "DeregisterTaskDefinition":
+		out, err = decodeAnd(r, f.deregisterTaskDefinition)
+	case "CreateService":
+		out, err = decodeAnd(r, f.createService)
+	case "UpdateService":
+		out, err = decodeAnd(r, f.updateService)
+	case "DescribeServices":
+		out, err = decodeAnd(r, f.describeServices)
+	case "DeleteService":
+		out, err = decodeAnd(r, f.deleteService)
+	case "TagResource":
+		out, err = decodeAnd(r, f.tagResource)
+	case "DescribeClusters":
+		out, err = decodeAnd(r, f.describeClusters)
+	case "FilterLogEvents":
+		out, err = decodeAnd(r, f.filterLogEvents)
+	default:
+		err = &fakeError{"InvalidAction", "unsupported operation " + target}
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"__type": err.kind, "message": err.message})
+
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(awsJSON(out))
+}
+
+type fakeError struct {
+	kind    string
+	message string
+}
+
+// decodeAnd decodes the request body into In and calls fn. The SDK
+// sends camelCase members; encoding/json matches them to the SDK
+// types' field names case-insensitively.
+func decodeAnd[In any](r *http.Request, fn func(*In) (any, *fakeError)) (any, *fakeError) {
+	in := new(In)
+	if err := json.NewDecoder(r.Body).Decode(in); err != nil {
+		return nil, &fakeError{"ClientException", err.Error()}
+	}
+
+	return fn(in)
+}
+
+// awsJSON re-encodes v with camelCase member names and without nulls,
+// the shape the SDK's JSON 1.1 deserializers expect.
+func awsJSON(v any) any {
+	raw, _ := json.Marshal(v)
+
+	var generic any
+	_ = json.Unmarshal(raw, &generic)
+
+	return camelKeys(generic)
+}
+
+func camelKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+
+		for k, val := range v {
+			if val == nil || val == "" {
+				continue
+			}
+
+			r, size := utf8.DecodeRuneInString(k)
+			out[string(unicode.ToLower(r))+k[size:]] = camelKeys(val)
+		}
+
+		return out
+	case []any:
+		for i := range v {
+			v[i] = camelKeys(v[i])
+		}
+
+		return v
+	default:
+		return v
+	}
+}
+
+func (f *fakeAWS) lookup(arn string) *types.TaskDefinition {
+	family := familyOf(arn)
+
+	for _, td := range f.families[family] {
+		if aws.ToString(td.TaskDefinitionArn) == arn {
+			return td
+		}
+	}
+
+	// A bare family resolves to its latest revision.
+	if revs := f.families[arn]; len(revs) > 0 {
+		return revs[len(revs)-1]
+	}
+
+	return nil
+}
+
+func (f *fakeAWS) registerTaskDefinition(in *awsecs.RegisterTaskDefinitionInput) (any, *fakeError) {
+	family := aws.ToString(in.Family)
+	rev := int32(len(f.families[family]) + 1)
+	arn := fmt.Sprintf("%stask-definition/%s:%d", fakeARNPrefix, family, rev)
+
+	td := &types.TaskDefinition{
+		TaskDefinitionArn:       aws.String(arn),
+		Family:                  in.Family,
+		Revision:                rev,
+		Status:                  types.TaskDefinitionStatusActive,
+		ContainerDefinitions:    in.ContainerDefinitions,
+		Cpu:                     in.Cpu,
+		Memory:                  in.Memory,
+		NetworkMode:             in.NetworkMode,
+		RequiresCompatibilities: in.RequiresCompatibilities,
+		ExecutionRoleArn:        in.ExecutionRoleArn,
+		TaskRoleArn:             in.TaskRoleArn,
+	}
+
+	f.families[family] = append(f.families[family], td)
+	f.tdTags[arn] = in.Tags
+
+	return map[string]any{"TaskDefinition": td, "Tags": in.Tags}, nil
+}
+
+func (f *fakeAWS) describeTaskDefinition(in *awsecs.DescribeTaskDefinitionInput) (any, *fakeError) {
+	td := f.lookup(aws.ToString(in.TaskDefinition))
+	if td == nil {
+		return nil, &fakeError{"ClientException", "Unable to describe task definition."}
+	}
+
+	return map[string]any{"TaskDefinition": td, "Tags": f.tdTags[aws.ToString(td.TaskDefinitionArn)]}, nil
+}
+
+func (f *fakeAWS) listTaskDefinitions(in *awsecs.ListTaskDefinitionsInput) (any, *fakeError) {
+	var arns []string
+
+	for family, revs := range f.families {
+		if !strings.HasPrefix(family, aws.ToString(in.FamilyPrefix)) {
+			continue
+		}
+
+		for i := len(revs) - 1; i >= 0; i-- {
+			if revs[i].Status == types.TaskDefinitionStatusActive {
+				arns = append(arns, aws.ToString(revs[i].TaskDefinitionArn))
+			}
+		}
+	}
+
+	return map[string]any{"TaskDefinitionArns": arns}, nil
+}
+
+func (f *fakeAWS) deregisterTaskDefinition(in *awsecs.DeregisterTaskDefinitionInput) (any, *fakeError) {
+	td := f.lookup(aws.ToString(in.TaskDefinition))
+	if td == nil {
+		return nil, &fakeError{"ClientException", "task definition not found"}
+	}
+
+	td.Status = types.TaskDefinitionStatusInactive
+
+	return map[string]any{"TaskDefinition": td}, nil
+}
+
+func (f *fakeAWS) createService(in *awsecs.CreateServiceInput) (any, *fakeError) {
+	name := aws.ToString(in.ServiceName)
+	if svc, ok := f.services[name]; ok && aws.ToString(svc.Status) == serviceActive {
+		return nil, &fakeError{"InvalidParameterException", "Creation of service was not idempotent."}
+	}
+
+	if f.lookup(aws.ToString(in.TaskDefinition)) == nil {
+		return nil, &fakeError{"ClientException", "task definition not found"}
+	}
+
+	svc := &types.Service{
+		ServiceName:          in.ServiceName,
+		ServiceArn:           aws.String(fakeARNPrefix + "service/tenants/" + name),
+		Status:               aws.String(serviceActive),
+		TaskDefinition:       in.TaskDefinition,
+		DesiredCount:         aws.ToInt32(in.DesiredCount),
+		LaunchType:           in.LaunchType,
+		NetworkConfiguration: in.NetworkConfiguration,
+		Tags:                 in.Tags,
+	}
+
+	f.converge(svc)
+	f.services[name] = svc
+
+	return map[string]any{"Service": svc}, nil
+}
+
+// converge brings svc to its desired state at once.
+func (f *fakeAWS) converge(svc *types.Service) {
+	svc.RunningCount = svc.DesiredCount
+	svc.Deployments = []types.Deployment{{
+		Id:             aws.String("ecs-svc/1"),
+		Status:         aws.String("PRIMARY"),
+		TaskDefinition: svc.TaskDefinition,
+		DesiredCount:   svc.DesiredCount,
+		RunningCount:   svc.DesiredCount,
+		RolloutState:   types.DeploymentRolloutStateCompleted,
+	}}
+}
+
+func (f *fakeAWS) activeService(name string) (*types.Service, *fakeError) {
+	svc, ok := f.services[name]
+	if !ok {
+		return nil, &fakeError{"ServiceNotFoundException", "Service not found."}
+	}
+
+	if aws.ToString(svc.Status) != serviceActive {
+		return nil, &fakeError{"ServiceNotActiveException", "Service was not ACTIVE."}
+	}
+
+	return svc, nil
+}
+
+func (f *fakeAWS) updateService(in *awsecs.UpdateServiceInput) (any, *fakeError) {
+	svc, err := f.activeService(aws.ToString(in.Service))
+	if err != nil {
+		return nil, err
+	}
+
+	if in.TaskDefinition != nil {
+		td := f.lookup(aws.ToString(in.TaskDefinition))
+		if td == nil {
+			return nil, &fakeError{"ClientException", "task definition not found"}
+		}
+
+		svc.TaskDefinition = td.TaskDefinitionArn
+	}
+
+	if in.DesiredCount != nil {
+		svc.DesiredCount = *in.DesiredCount
+	}
+
+	f.converge(svc)
+
+	return map[string]any{"Service": svc}, nil
+}
+
+func (f *fakeAWS) describeServices(in *awsecs.DescribeServicesInput) (any, *fakeError) {
+	var (
+		services []types.Service
+		failures []types.Failure
+	)
+
+	for _, name := range in.Services {
+		svc, ok := f.services[name]
+		if !ok {
+			failures = append(failures, types.Failure{Arn: aws.String(name), Reason: aws.String("MISSING")})
+
+			continue
+		}
+
+		services = append(services, *svc)
+	}
+
+	return map[string]any{"Services": services, "Failures": failures}, nil
+}
+
+func (f *fakeAWS) deleteService(in *awsecs.DeleteServiceInput) (any, *fakeError) {
+	svc, err := f.activeService(aws.ToString(in.Service))
+	if err != nil {
+		return nil, err
+	}
+
+	svc.Status = aws.String("INACTIVE")
+	svc.DesiredCount = 0
+	f.converge(svc)
+
+	return map[string]any{"Service": svc}, nil
+}
+
+func (f *fakeAWS) tagResource(in *awsecs.TagResourceInput) (any, *fakeError) {
+	for _, svc := range f.services {
+		if aws.ToString(svc.ServiceArn) != aws.ToString(in.ResourceArn) {
+			continue
+		}
+
+		for _, t := range in.Tags {
+			svc.Tags = setTag(svc.Tags, aws.ToString(t.Key), aws.ToString(t.Value))
+		}
+
+		return map[string]any{}, nil
+	}
+
+	return nil, &fakeError{"ResourceNotFoundException", "resource not found"}
+}
+
+func (f *fakeAWS) describeClusters(in *awsecs.DescribeClustersInput) (any, *fakeError) {
+	var clusters []types.Cluster
+
+	for _, name := range in.Clusters {
+		if name == "tenants" {
+			clusters = append(clusters, types.Cluster{ClusterName: aws.String(name), Status: aws.String(serviceActive)})
+		}
+	}
+
+	return map[string]any{"Clusters": clusters}, nil
+}
+
+// filterLogEvents serves matching events in one page.
+func (f *fakeAWS) filterLogEvents(in *cloudwatchlogs.FilterLogEventsInput) (any, *fakeError) {
+	if aws.ToString(in.LogGroupName) != "/ctrlplane/ecs" {
+		return nil, &fakeError{"ResourceNotFoundException", "The specified log group does not exist."}
+	}
+
+	var events []cwtypes.FilteredLogEvent
+
+	for _, e := range f.events {
+		if !strings.HasPrefix(aws.ToString(e.LogStreamName), aws.ToString(in.LogStreamNamePrefix)) {
+			continue
+		}
+
+		if in.StartTime != nil && aws.ToInt64(e.Timestamp) < *in.StartTime {
+			continue
+		}
+
+		events = append(events, e)
+	}
+
+	return map[string]any{"Events": events}, nil
+}
@@ -0,0 +1,199 @@
+package ecs
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// logPollInterval is how often a follow stream asks CloudWatch for
+// events newer than the last one seen. FilterLogEvents is paged, not
+// pushed.
+const logPollInterval = 2 * time.Second
+
+// Logs streams one container's CloudWatch log events.
+// opts.ServiceName picks the container (empty = the Main service).
+//
+// The awslogs driver names streams <family>/<container>/<task id>,
+// so every task of the service is read through one stream-name
+// prefix. awslogs doesn't keep stdout and stderr apart; every event
+// is reported as stdout.
+func (p *Provider) Logs(ctx context.Context, instanceID id.ID, opts provider.LogOptions) (io.ReadCloser, error) {
+	svc, err := p.requireService(ctx, instanceID, "logs")
+	if err != nil {
+		return nil, err
+	}
+
+	td, err := p.ecs.DescribeTaskDefinition(ctx, &awsecs.DescribeTaskDefinitionInput{TaskDefinition: svc.TaskDefinition})
+	if err != nil {
+		return nil, fmt.Errorf("ecs: logs: describe task definition: %w", err)
+	}
+
+	container := opts.ServiceName
+	if container == "" {
+		container = tagValue(svc.Tags, tagMain)
+	}
+
+	if !slices.ContainsFunc(td.TaskDefinition.ContainerDefinitions, func(c types.ContainerDefinition) bool { return aws.ToString(c.Name) == container }) {
+		return nil, fmt.Errorf("ecs: logs: service %q not found", container)
+	}
+
+	in := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:        aws.String(p.cfg.LogGroup),
+		LogStreamNamePrefix: aws.String(aws.ToString(svc.ServiceName) + "/" + container + "/"),
+	}
+
+	if !opts.Since.IsZero() {
+		in.StartTime = aws.Int64(opts.Since.UnixMilli())
+	}
+
+	// Read the backlog up front so a missing log group or denied
+	// call fails the call rather than ending the stream.
+	backlog, err := p.filterEvents(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("ecs: logs %s: %w", container, err)
+	}
+
+	if opts.Tail > 0 && len(backlog) > opts.Tail {
+		backlog = backlog[len(backlog)-opts.Tail:]
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	r, w := io.Pipe()
+
+	go func() {
+		defer w.Close()
+
+		if err := p.streamLogs(streamCtx, w, in, backlog, opts.Follow); err != nil && streamCtx.Err() == nil {
+			// Best-effort: surface the error as a final event so the
+			// consumer sees why the stream ended.
+			_ = provider.WriteLogEvent(w, provider.LogEvent{
+				Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+				Stream:    "stderr",
+				Line:      "ctrlplane/ecs: log stream ended: " + err.Error(),
+			})
+		}
+	}()
+
+	return &logStream{PipeReader: r, cancel: cancel}, nil
+}
+
+// logStream stops the polling loop when the consumer closes the
+// reader, so a follow stream doesn't outlive its reader.
+type logStream struct {
+	*io.PipeReader
+
+	cancel context.CancelFunc
+}
+
+// Close stops polling and closes the pipe.
+func (s *logStream) Close() error {
+	s.cancel()
+
+	return s.PipeReader.Close()
+}
+
+// streamLogs writes backlog, then — when following — every newer
+// event until ctx ends. Events sharing the last seen millisecond are
+// remembered by ID, since StartTime is inclusive.
+func (p *Provider) streamLogs(ctx context.Context, dst io.Writer, in *cloudwatchlogs.FilterLogEventsInput, backlog []cwtypes.FilteredLogEvent, follow bool) error {
+	var (
+		last int64
+		seen = make(map[string]bool)
+	)
+
+	write := func(events []cwtypes.FilteredLogEvent) error {
+		for _, e := range events {
+			ts := aws.ToInt64(e.Timestamp)
+			if ts < last || seen[aws.ToString(e.EventId)] {
+				continue
+			}
+
+			if ts > last {
+				last = ts
+				clear(seen)
+			}
+
+			seen[aws.ToString(e.EventId)] = true
+
+			if err := provider.WriteLogEvent(dst, provider.LogEvent{
+				Timestamp: time.UnixMilli(ts).UTC().Format(time.RFC3339Nano),
+				Stream:    "stdout",
+				Line:      aws.ToString(e.Message),
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := write(backlog); err != nil {
+		return err
+	}
+
+	if !follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(p.logPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next := *in
+		if last > 0 {
+			next.StartTime = aws.Int64(last)
+		}
+
+		events, err := p.filterEvents(ctx, &next)
+		if err != nil {
+			return err
+		}
+
+		if err := write(events); err != nil {
+			return err
+		}
+	}
+}
+
+// filterEvents reads every page of a FilterLogEvents query, oldest
+// event first.
+func (p *Provider) filterEvents(ctx context.Context, in *cloudwatchlogs.FilterLogEventsInput) ([]cwtypes.FilteredLogEvent, error) {
+	var events []cwtypes.FilteredLogEvent
+
+	pages := cloudwatchlogs.NewFilterLogEventsPaginator(p.logs, in)
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, page.Events...)
+	}
+
+	// Events from several streams come back interleaved per stream;
+	// order them by time.
+	slices.SortStableFunc(events, func(a, b cwtypes.FilteredLogEvent) int {
+		return cmp.Compare(aws.ToInt64(a.Timestamp), aws.ToInt64(b.Timestamp))
+	})
+
+	return events, nil
+}
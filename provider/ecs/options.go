@@ -0,0 +1,197 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// Option configures an ECS provider.
+type Option func(*Provider) error
+
+// WithRegion sets the AWS region.
+func WithRegion(region string) Option {
+	return func(p *Provider) error {
+		if region == "" {
+			return fmt.Errorf("ecs: %w: region must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.Region = region
+
+		return nil
+	}
+}
+
+// WithCluster sets the ECS cluster instance services are created in.
+func WithCluster(cluster string) Option {
+	return func(p *Provider) error {
+		if cluster == "" {
+			return fmt.Errorf("ecs: %w: cluster must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.Cluster = cluster
+
+		return nil
+	}
+}
+
+// WithEndpoint overrides the ECS API endpoint.
+func WithEndpoint(endpoint string) Option {
+	return func(p *Provider) error {
+		p.cfg.Endpoint = endpoint
+
+		return nil
+	}
+}
+
+// WithLogsEndpoint overrides the CloudWatch Logs API endpoint.
+func WithLogsEndpoint(endpoint string) Option {
+	return func(p *Provider) error {
+		p.cfg.LogsEndpoint = endpoint
+
+		return nil
+	}
+}
+
+// WithStaticCredentials sets an access key pair instead of the
+// default credential chain.
+func WithStaticCredentials(accessKeyID, secretAccessKey string) Option {
+	return func(p *Provider) error {
+		if accessKeyID == "" || secretAccessKey == "" {
+			return fmt.Errorf("ecs: %w: access key id and secret must both be set", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.AccessKeyID = accessKeyID
+		p.cfg.SecretAccessKey = secretAccessKey
+
+		return nil
+	}
+}
+
+// WithNetwork sets the subnets and security groups tasks are placed
+// in, and whether they get a public IP.
+func WithNetwork(subnetIDs, securityGroups []string, assignPublicIP bool) Option {
+	return func(p *Provider) error {
+		if len(subnetIDs) == 0 {
+			return fmt.Errorf("ecs: %w: at least one subnet is required", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.SubnetIDs = slices.Clone(subnetIDs)
+		p.cfg.SecurityGroups = slices.Clone(securityGroups)
+		p.cfg.AssignPublicIP = assignPublicIP
+
+		return nil
+	}
+}
+
+// WithLaunchType sets the launch type, FARGATE or EC2.
+func WithLaunchType(launchType string) Option {
+	return func(p *Provider) error {
+		if launchType != launchFargate && launchType != launchEC2 {
+			return fmt.Errorf("ecs: %w: launch type must be %s or %s", ctrlplane.ErrInvalidConfig, launchFargate, launchEC2)
+		}
+
+		p.cfg.LaunchType = launchType
+
+		return nil
+	}
+}
+
+// WithRoles sets the task execution role and the task role.
+func WithRoles(executionRoleARN, taskRoleARN string) Option {
+	return func(p *Provider) error {
+		p.cfg.ExecutionRoleARN = executionRoleARN
+		p.cfg.TaskRoleARN = taskRoleARN
+
+		return nil
+	}
+}
+
+// WithLogGroup sets the CloudWatch log group containers log to.
+func WithLogGroup(group string) Option {
+	return func(p *Provider) error {
+		if group == "" {
+			return fmt.Errorf("ecs: %w: log group must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.LogGroup = group
+
+		return nil
+	}
+}
+
+// WithNamePrefix sets the prefix of the per-instance service and task
+// definition family names.
+func WithNamePrefix(prefix string) Option {
+	return func(p *Provider) error {
+		if prefix == "" {
+			return fmt.Errorf("ecs: %w: name prefix must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.NamePrefix = prefix
+
+		return nil
+	}
+}
+
+// WithConfig applies all non-zero fields from a Config struct.
+// This is useful when loading configuration from files or environment variables.
+func WithConfig(cfg Config) Option {
+	return func(p *Provider) error {
+		if cfg.Region != "" {
+			p.cfg.Region = cfg.Region
+		}
+
+		if cfg.Cluster != "" {
+			p.cfg.Cluster = cfg.Cluster
+		}
+
+		if cfg.Endpoint != "" {
+			p.cfg.Endpoint = cfg.Endpoint
+		}
+
+		if cfg.LogsEndpoint != "" {
+			p.cfg.LogsEndpoint = cfg.LogsEndpoint
+		}
+
+		if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
+			p.cfg.AccessKeyID = cfg.AccessKeyID
+			p.cfg.SecretAccessKey = cfg.SecretAccessKey
+		}
+
+		if len(cfg.SubnetIDs) > 0 {
+			p.cfg.SubnetIDs = slices.Clone(cfg.SubnetIDs)
+		}
+
+		if len(cfg.SecurityGroups) > 0 {
+			p.cfg.SecurityGroups = slices.Clone(cfg.SecurityGroups)
+		}
+
+		if cfg.AssignPublicIP {
+			p.cfg.AssignPublicIP = true
+		}
+
+		if cfg.LaunchType != "" {
+			p.cfg.LaunchType = cfg.LaunchType
+		}
+
+		if cfg.ExecutionRoleARN != "" {
+			p.cfg.ExecutionRoleARN = cfg.ExecutionRoleARN
+		}
+
+		if cfg.TaskRoleARN != "" {
+			p.cfg.TaskRoleARN = cfg.TaskRoleARN
+		}
+
+		if cfg.LogGroup != "" {
+			p.cfg.LogGroup = cfg.LogGroup
+		}
+
+		if cfg.NamePrefix != "" {
+			p.cfg.NamePrefix = cfg.NamePrefix
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,697 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Service statuses ECS reports.
+const (
+	serviceActive   = "ACTIVE"
+	serviceDraining = "DRAINING"
+)
+
+// Compile-time check that Provider implements provider.Provider,
+// provider.HealthChecker and provider.Rollbacker.
+var (
+	_ provider.Provider      = (*Provider)(nil)
+	_ provider.HealthChecker = (*Provider)(nil)
+	_ provider.Rollbacker    = (*Provider)(nil)
+)
+
+// Provider is an AWS ECS infrastructure provider.
+type Provider struct {
+	cfg Config
+
+	ecs  *awsecs.Client
+	logs *cloudwatchlogs.Client
+
+	// logPoll is how often a follow log stream polls for new events.
+	logPoll time.Duration
+}
+
+// New creates a new ECS provider with the given options. Without any
+// options, defaults are used (region: us-east-1, cluster: default,
+// launch type: FARGATE, log group: /ctrlplane/ecs, name prefix: cp)
+// and credentials come from the SDK's default chain. Subnets are
+// needed before anything can be provisioned.
+func New(opts ...Option) (*Provider, error) {
+	p := &Provider{
+		cfg: Config{
+			Region:     "us-east-1",
+			Cluster:    "default",
+			LaunchType: launchFargate,
+			LogGroup:   "/ctrlplane/ecs",
+			NamePrefix: "cp",
+		},
+		logPoll: logPollInterval,
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	awsCfg, err := p.awsConfig()
+	if err != nil {
+		return nil, fmt.Errorf("ecs: load aws config: %w", err)
+	}
+
+	p.ecs = awsecs.NewFromConfig(awsCfg, func(o *awsecs.Options) {
+		if p.cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(p.cfg.Endpoint)
+		}
+	})
+
+	p.logs = cloudwatchlogs.NewFromConfig(awsCfg, func(o *cloudwatchlogs.Options) {
+		if p.cfg.LogsEndpoint != "" {
+			o.BaseEndpoint = aws.String(p.cfg.LogsEndpoint)
+		}
+	})
+
+	return p, nil
+}
+
+// awsConfig builds the SDK config: static credentials when set,
+// otherwise the default chain.
+func (p *Provider) awsConfig() (aws.Config, error) {
+	if p.cfg.AccessKeyID != "" {
+		return aws.Config{
+			Region:      p.cfg.Region,
+			Credentials: credentials.NewStaticCredentialsProvider(p.cfg.AccessKeyID, p.cfg.SecretAccessKey, ""),
+		}, nil
+	}
+
+	return awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(p.cfg.Region))
+}
+
+// Info returns metadata about this provider.
+func (p *Provider) Info() provider.ProviderInfo {
+	return provider.ProviderInfo{
+		Name:    "ecs",
+		Version: "0.1.0",
+		Region:  p.cfg.Region,
+	}
+}
+
+// Capabilities returns the set of features this provider supports.
+func (p *Provider) Capabilities() []provider.Capability {
+	return []provider.Capability{
+		provider.CapProvision,
+		provider.CapDeploy,
+		provider.CapScale,
+		provider.CapLogs,
+		provider.CapRolling,
+	}
+}
+
+// HealthCheck describes the configured cluster, which checks that
+// the ECS API is reachable, the credentials are accepted and the
+// cluster exists.
+func (p *Provider) HealthCheck(ctx context.Context) (*provider.HealthStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	out, err := p.ecs.DescribeClusters(ctx, &awsecs.DescribeClustersInput{Clusters: []string{p.cfg.Cluster}})
+	latency := time.Since(start)
+	now := time.Now().UTC()
+
+	status := &provider.HealthStatus{Latency: latency, CheckedAt: now}
+
+	switch {
+	case err != nil:
+		status.Message = fmt.Sprintf("ecs api unavailable: %v", err)
+	case len(out.Clusters) == 0 || aws.ToString(out.Clusters[0].Status) != serviceActive:
+		status.Message = fmt.Sprintf("ecs cluster %s not found or not active", p.cfg.Cluster)
+	default:
+		status.Healthy = true
+		status.Message = "ecs cluster " + p.cfg.Cluster + " active"
+	}
+
+	return status, nil
+}
+
+// Provision registers the instance's task definition — one container
+// per service — and creates an ECS service running Replicas copies of
+// it. Re-provisioning an instance whose service is still active
+// updates the service to the new revision instead.
+func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+	if len(req.Services) == 0 {
+		return nil, errors.New("ecs: provision requires at least one service")
+	}
+
+	main := pickMain(req.Services)
+	if main == nil {
+		return nil, errors.New("ecs: provision requires exactly one Main service")
+	}
+
+	if err := provider.RejectHardPlacement(req.Services); err != nil {
+		return nil, fmt.Errorf("ecs: %w", err)
+	}
+
+	if len(p.cfg.SubnetIDs) == 0 {
+		return nil, fmt.Errorf("ecs: provision: %w: no subnets configured", ctrlplane.ErrInvalidConfig)
+	}
+
+	for _, svc := range req.Services {
+		if err := checkConfigFiles(svc.Name, svc.ConfigFiles); err != nil {
+			return nil, fmt.Errorf("ecs: provision: %w", err)
+		}
+	}
+
+	name := p.serviceName(req.InstanceID)
+
+	td, err := p.ecs.RegisterTaskDefinition(ctx, buildTaskDefinition(p.cfg, name, req, main))
+	if err != nil {
+		return nil, fmt.Errorf("ecs: register task definition %s: %w", name, err)
+	}
+
+	tdARN := aws.ToString(td.TaskDefinition.TaskDefinitionArn)
+	desired := int32(max(min(main.Resources.Replicas, 1<<16), 1)) //nolint:gosec // clamped
+
+	existing, err := p.describeService(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("ecs: provision: %w", err)
+	}
+
+	if existing != nil {
+		_, err = p.ecs.UpdateService(ctx, &awsecs.UpdateServiceInput{
+			Cluster:            aws.String(p.cfg.Cluster),
+			Service:            aws.String(name),
+			TaskDefinition:     aws.String(tdARN),
+			DesiredCount:       aws.Int32(desired),
+			ForceNewDeployment: true,
+		})
+	} else {
+		_, err = p.ecs.CreateService(ctx, p.createServiceInput(name, tdARN, desired, req))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("ecs: create service %s: %w", name, err)
+	}
+
+	serviceRefs := make(map[string]string, len(req.Services))
+	for _, svc := range req.Services {
+		serviceRefs[svc.Name] = name + "/" + svc.Name
+	}
+
+	return &provider.ProvisionResult{
+		ProviderRef: "ecs:" + name,
+		ServiceRefs: serviceRefs,
+		Metadata: map[string]string{
+			"cluster":         p.cfg.Cluster,
+			"service":         name,
+			"task_definition": tdARN,
+			"region":          p.cfg.Region,
+		},
+	}, nil
+}
+
+// createServiceInput builds the CreateService call for a new
+// instance. Rolling updates keep the full desired count running
+// while new tasks start, and the deployment circuit breaker rolls a
+// failing revision back on its own.
+func (p *Provider) createServiceInput(name, tdARN string, desired int32, req provider.ProvisionRequest) *awsecs.CreateServiceInput {
+	assign := types.AssignPublicIpDisabled
+	if p.cfg.AssignPublicIP {
+		assign = types.AssignPublicIpEnabled
+	}
+
+	return &awsecs.CreateServiceInput{
+		Cluster:        aws.String(p.cfg.Cluster),
+		ServiceName:    aws.String(name),
+		TaskDefinition: aws.String(tdARN),
+		DesiredCount:   aws.Int32(desired),
+		LaunchType:     types.LaunchType(p.cfg.LaunchType),
+		NetworkConfiguration: &types.NetworkConfiguration{
+			AwsvpcConfiguration: &types.AwsVpcConfiguration{
+				Subnets:        p.cfg.SubnetIDs,
+				SecurityGroups: p.cfg.SecurityGroups,
+				AssignPublicIp: assign,
+			},
+		},
+		DeploymentConfiguration: &types.DeploymentConfiguration{
+			MinimumHealthyPercent: aws.Int32(100),
+			MaximumPercent:        aws.Int32(200),
+			DeploymentCircuitBreaker: &types.DeploymentCircuitBreaker{
+				Enable:   true,
+				Rollback: true,
+			},
+		},
+		PropagateTags: types.PropagateTagsService,
+		Tags:          instanceTags(req.InstanceID, req.TenantID, pickMain(req.Services).Name),
+	}
+}
+
+// Deprovision deletes the instance's service, stopping its tasks, and
+// deregisters every revision of its task definition.
+func (p *Provider) Deprovision(ctx context.Context, instanceID id.ID) error {
+	name := p.serviceName(instanceID)
+
+	_, err := p.ecs.DeleteService(ctx, &awsecs.DeleteServiceInput{
+		Cluster: aws.String(p.cfg.Cluster),
+		Service: aws.String(name),
+		Force:   aws.Bool(true),
+	})
+	if err != nil && !isServiceGone(err) {
+		return fmt.Errorf("ecs: delete service %s: %w", name, err)
+	}
+
+	arns, err := p.taskDefinitions(ctx, name)
+	if err != nil {
+		return fmt.Errorf("ecs: list task definitions of %s: %w", name, err)
+	}
+
+	for _, arn := range arns {
+		if _, err := p.ecs.DeregisterTaskDefinition(ctx, &awsecs.DeregisterTaskDefinitionInput{TaskDefinition: aws.String(arn)}); err != nil {
+			return fmt.Errorf("ecs: deregister %s: %w", arn, err)
+		}
+	}
+
+	return nil
+}
+
+// Start restores the desired count Stop recorded (one when there is
+// none). A running instance is left alone.
+func (p *Provider) Start(ctx context.Context, instanceID id.ID) error {
+	svc, err := p.requireService(ctx, instanceID, "start")
+	if err != nil {
+		return err
+	}
+
+	if svc.DesiredCount > 0 {
+		return nil
+	}
+
+	desired := int32(1)
+	if n, err := strconv.Atoi(tagValue(svc.Tags, tagReplicas)); err == nil && n > 0 {
+		desired = int32(min(n, 1<<16)) //nolint:gosec // clamped
+	}
+
+	return p.setDesiredCount(ctx, svc, desired, "start")
+}
+
+// Stop scales the service to zero tasks, recording the desired count
+// as a tag so Start brings the same number back.
+func (p *Provider) Stop(ctx context.Context, instanceID id.ID) error {
+	svc, err := p.requireService(ctx, instanceID, "stop")
+	if err != nil {
+		return err
+	}
+
+	if svc.DesiredCount == 0 {
+		return nil
+	}
+
+	if _, err := p.ecs.TagResource(ctx, &awsecs.TagResourceInput{
+		ResourceArn: svc.ServiceArn,
+		Tags:        []types.Tag{{Key: aws.String(tagReplicas), Value: aws.String(strconv.Itoa(int(svc.DesiredCount)))}},
+	}); err != nil {
+		return fmt.Errorf("ecs: stop: record desired count: %w", err)
+	}
+
+	return p.setDesiredCount(ctx, svc, 0, "stop")
+}
+
+// Restart forces a new deployment of the current revision, which
+// replaces every task in a rolling fashion.
+func (p *Provider) Restart(ctx context.Context, instanceID id.ID) error {
+	svc, err := p.requireService(ctx, instanceID, "restart")
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.ecs.UpdateService(ctx, &awsecs.UpdateServiceInput{
+		Cluster:            aws.String(p.cfg.Cluster),
+		Service:            svc.ServiceName,
+		ForceNewDeployment: true,
+	}); err != nil {
+		return fmt.Errorf("ecs: restart %s: %w", aws.ToString(svc.ServiceName), err)
+	}
+
+	return nil
+}
+
+// Status maps the service's counts and deployments onto an
+// InstanceStatus. ECS schedules a task's containers together, so
+// every service reports the aggregate; Replicas is the desired count
+// and ReadyReplicas the running one.
+func (p *Provider) Status(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
+	name := p.serviceName(instanceID)
+
+	svc, err := p.describeService(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("ecs: status: %w", err)
+	}
+
+	if svc == nil {
+		return &provider.InstanceStatus{State: provider.StateDestroyed}, nil
+	}
+
+	state, message := serviceState(svc)
+
+	st := &provider.InstanceStatus{
+		State:   state,
+		Ready:   state == provider.StateRunning,
+		Message: message,
+		Metadata: map[string]string{
+			"cluster":         p.cfg.Cluster,
+			"service":         name,
+			"task_definition": aws.ToString(svc.TaskDefinition),
+		},
+		Replicas:      int(svc.DesiredCount),
+		ReadyReplicas: int(svc.RunningCount),
+	}
+
+	if svc.CreatedAt != nil {
+		st.StartedAt = svc.CreatedAt
+	}
+
+	td, err := p.ecs.DescribeTaskDefinition(ctx, &awsecs.DescribeTaskDefinitionInput{TaskDefinition: svc.TaskDefinition})
+	if err != nil {
+		return nil, fmt.Errorf("ecs: status: describe task definition: %w", err)
+	}
+
+	st.Services = make(map[string]provider.ServiceStatus, len(td.TaskDefinition.ContainerDefinitions))
+
+	for _, c := range td.TaskDefinition.ContainerDefinitions {
+		if !aws.ToBool(c.Essential) {
+			continue // init container
+		}
+
+		st.Services[aws.ToString(c.Name)] = provider.ServiceStatus{
+			State:         state,
+			Ready:         st.Ready,
+			ProviderRef:   name + "/" + aws.ToString(c.Name),
+			Message:       message,
+			Replicas:      st.Replicas,
+			ReadyReplicas: st.ReadyReplicas,
+		}
+	}
+
+	return st, nil
+}
+
+// serviceState folds a service's status, counts and deployments into
+// an InstanceState plus a message explaining anything short of
+// running. Pure function so it's testable without AWS.
+func serviceState(svc *types.Service) (provider.InstanceState, string) {
+	if aws.ToString(svc.Status) == serviceDraining {
+		return provider.StateDestroying, "service is draining"
+	}
+
+	for _, d := range svc.Deployments {
+		if aws.ToString(d.Status) == "PRIMARY" && d.RolloutState == types.DeploymentRolloutStateFailed {
+			return provider.StateFailed, aws.ToString(d.RolloutStateReason)
+		}
+	}
+
+	switch {
+	case svc.DesiredCount == 0 && svc.RunningCount == 0:
+		return provider.StateStopped, ""
+	case svc.DesiredCount == 0:
+		return provider.StateStopping, fmt.Sprintf("%d tasks still running", svc.RunningCount)
+	case svc.RunningCount < svc.DesiredCount:
+		return provider.StateStarting, fmt.Sprintf("%d of %d tasks running", svc.RunningCount, svc.DesiredCount)
+	case len(svc.Deployments) > 1:
+		return provider.StateStarting, "deployment in progress"
+	default:
+		return provider.StateRunning, ""
+	}
+}
+
+// Deploy registers a new task definition revision with the listed
+// services' images and env, and points the service at it. ECS rolls
+// the tasks over; services not listed keep their image but restart
+// with the task.
+func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*provider.DeployResult, error) {
+	if len(req.Services) == 0 {
+		return nil, errors.New("ecs: deploy requires at least one service")
+	}
+
+	updates := make([]serviceUpdate, len(req.Services))
+
+	for i, s := range req.Services {
+		if err := checkConfigFiles(s.Name, s.ConfigFiles); err != nil {
+			return nil, fmt.Errorf("ecs: deploy: %w", err)
+		}
+
+		updates[i] = serviceUpdate{name: s.Name, image: s.Image, env: s.Env}
+	}
+
+	if err := p.rollout(ctx, req.InstanceID, req.ReleaseID, updates); err != nil {
+		return nil, fmt.Errorf("ecs: deploy: %w", err)
+	}
+
+	return &provider.DeployResult{
+		ProviderRef: "ecs:" + p.serviceName(req.InstanceID),
+		Status:      "deployed",
+	}, nil
+}
+
+// Scale sets the service's desired count to spec.Replicas and, when
+// spec sets CPU or memory, registers a revision with every long-lived
+// container resized to it. Zero fields are left alone.
+func (p *Provider) Scale(ctx context.Context, instanceID id.ID, spec provider.ResourceSpec) error {
+	svc, err := p.requireService(ctx, instanceID, "scale")
+	if err != nil {
+		return err
+	}
+
+	in := &awsecs.UpdateServiceInput{
+		Cluster: aws.String(p.cfg.Cluster),
+		Service: svc.ServiceName,
+	}
+
+	if spec.CPUMillis > 0 || spec.MemoryMB > 0 {
+		td, err := p.ecs.DescribeTaskDefinition(ctx, &awsecs.DescribeTaskDefinitionInput{
+			TaskDefinition: svc.TaskDefinition,
+			Include:        []types.TaskDefinitionField{types.TaskDefinitionFieldTags},
+		})
+		if err != nil {
+			return fmt.Errorf("ecs: scale: describe task definition: %w", err)
+		}
+
+		rev := nextRevision(td.TaskDefinition, td.Tags)
+		setResources(rev, spec)
+
+		out, err := p.ecs.RegisterTaskDefinition(ctx, rev)
+		if err != nil {
+			return fmt.Errorf("ecs: scale: register task definition: %w", err)
+		}
+
+		in.TaskDefinition = out.TaskDefinition.TaskDefinitionArn
+	}
+
+	if spec.Replicas > 0 {
+		in.DesiredCount = aws.Int32(int32(min(spec.Replicas, 1<<16))) //nolint:gosec // clamped
+	}
+
+	if in.TaskDefinition == nil && in.DesiredCount == nil {
+		return nil
+	}
+
+	if _, err := p.ecs.UpdateService(ctx, in); err != nil {
+		return fmt.Errorf("ecs: scale %s: %w", aws.ToString(svc.ServiceName), err)
+	}
+
+	return nil
+}
+
+// Resources reports the memory the running tasks are sized for. ECS
+// publishes utilization to CloudWatch metrics rather than the ECS
+// API, so usage fields stay zero.
+func (p *Provider) Resources(ctx context.Context, instanceID id.ID) (*provider.ResourceUsage, error) {
+	svc, err := p.requireService(ctx, instanceID, "resources")
+	if err != nil {
+		return nil, err
+	}
+
+	td, err := p.ecs.DescribeTaskDefinition(ctx, &awsecs.DescribeTaskDefinitionInput{TaskDefinition: svc.TaskDefinition})
+	if err != nil {
+		return nil, fmt.Errorf("ecs: resources: describe task definition: %w", err)
+	}
+
+	memory, _ := strconv.Atoi(aws.ToString(td.TaskDefinition.Memory))
+
+	return &provider.ResourceUsage{MemoryLimitMB: memory * int(svc.RunningCount)}, nil
+}
+
+// Exec is not supported: ECS Exec runs over an SSM Session Manager
+// data channel, which needs the session-manager plugin protocol
+// rather than an API call.
+func (p *Provider) Exec(_ context.Context, instanceID id.ID, _ provider.ExecRequest) (*provider.ExecResult, error) {
+	return nil, fmt.Errorf("ecs: exec in %s: %w", p.serviceName(instanceID), ctrlplane.ErrNotImplemented)
+}
+
+// serviceName returns the instance's ECS service and task definition
+// family.
+func (p *Provider) serviceName(instanceID id.ID) string {
+	return serviceName(p.cfg.NamePrefix, instanceID)
+}
+
+// describeService returns the named service, or nil when it doesn't
+// exist or has been deleted.
+func (p *Provider) describeService(ctx context.Context, name string) (*types.Service, error) {
+	out, err := p.ecs.DescribeServices(ctx, &awsecs.DescribeServicesInput{
+		Cluster:  aws.String(p.cfg.Cluster),
+		Services: []string{name},
+		Include:  []types.ServiceField{types.ServiceFieldTags},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe service %s: %w", name, err)
+	}
+
+	for i := range out.Services {
+		svc := &out.Services[i]
+		if aws.ToString(svc.ServiceName) == name && aws.ToString(svc.Status) != "INACTIVE" {
+			return svc, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil // nil service = not found
+}
+
+// requireService is describeService for operations that need the
+// service to exist. op names the calling operation in errors.
+func (p *Provider) requireService(ctx context.Context, instanceID id.ID, op string) (*types.Service, error) {
+	name := p.serviceName(instanceID)
+
+	svc, err := p.describeService(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("ecs: %s: %w", op, err)
+	}
+
+	if svc == nil {
+		return nil, fmt.Errorf("ecs: %s: service %s: %w", op, name, ctrlplane.ErrNotFound)
+	}
+
+	return svc, nil
+}
+
+func (p *Provider) setDesiredCount(ctx context.Context, svc *types.Service, desired int32, op string) error {
+	if _, err := p.ecs.UpdateService(ctx, &awsecs.UpdateServiceInput{
+		Cluster:      aws.String(p.cfg.Cluster),
+		Service:      svc.ServiceName,
+		DesiredCount: aws.Int32(desired),
+	}); err != nil {
+		return fmt.Errorf("ecs: %s %s: %w", op, aws.ToString(svc.ServiceName), err)
+	}
+
+	return nil
+}
+
+// rollout registers a revision of the current task definition with
+// updates applied and the Release recorded, and moves the service to
+// it. An update naming an unknown service fails before anything is
+// registered.
+func (p *Provider) rollout(ctx context.Context, instanceID, releaseID id.ID, updates []serviceUpdate) error {
+	svc, err := p.describeService(ctx, p.serviceName(instanceID))
+	if err != nil {
+		return err
+	}
+
+	if svc == nil {
+		return fmt.Errorf("service %s: %w", p.serviceName(instanceID), ctrlplane.ErrNotFound)
+	}
+
+	td, err := p.ecs.DescribeTaskDefinition(ctx, &awsecs.DescribeTaskDefinitionInput{
+		TaskDefinition: svc.TaskDefinition,
+		Include:        []types.TaskDefinitionField{types.TaskDefinitionFieldTags},
+	})
+	if err != nil {
+		return fmt.Errorf("describe task definition: %w", err)
+	}
+
+	rev := nextRevision(td.TaskDefinition, setTag(td.Tags, tagRelease, releaseID.String()))
+	if err := applyUpdates(rev.ContainerDefinitions, updates); err != nil {
+		return err
+	}
+
+	out, err := p.ecs.RegisterTaskDefinition(ctx, rev)
+	if err != nil {
+		return fmt.Errorf("register task definition: %w", err)
+	}
+
+	return p.switchRevision(ctx, svc, aws.ToString(out.TaskDefinition.TaskDefinitionArn))
+}
+
+// switchRevision points the service at the task definition arn.
+func (p *Provider) switchRevision(ctx context.Context, svc *types.Service, arn string) error {
+	if _, err := p.ecs.UpdateService(ctx, &awsecs.UpdateServiceInput{
+		Cluster:        aws.String(p.cfg.Cluster),
+		Service:        svc.ServiceName,
+		TaskDefinition: aws.String(arn),
+	}); err != nil {
+		return fmt.Errorf("update service %s: %w", aws.ToString(svc.ServiceName), err)
+	}
+
+	return nil
+}
+
+// taskDefinitions lists the ARNs of every active revision of family,
+// newest first.
+func (p *Provider) taskDefinitions(ctx context.Context, family string) ([]string, error) {
+	var arns []string
+
+	pages := awsecs.NewListTaskDefinitionsPaginator(p.ecs, &awsecs.ListTaskDefinitionsInput{
+		FamilyPrefix: aws.String(family),
+		Sort:         types.SortOrderDesc,
+	})
+
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, arn := range page.TaskDefinitionArns {
+			// FamilyPrefix matches by prefix; keep this family only.
+			if familyOf(arn) == family {
+				arns = append(arns, arn)
+			}
+		}
+	}
+
+	return arns, nil
+}
+
+// checkConfigFiles rejects config files with content: ECS has no way
+// to write a file into a container's filesystem at a given path.
+// Snapshot references without content are ignored.
+func checkConfigFiles(service string, files []provider.ConfigFile) error {
+	for _, f := range files {
+		if f.Content != "" {
+			return fmt.Errorf("service %q config file %s: %w", service, f.Path, ctrlplane.ErrNotImplemented)
+		}
+	}
+
+	return nil
+}
+
+// isServiceGone reports whether err says the service doesn't exist
+// or has already been deleted.
+func isServiceGone(err error) bool {
+	var (
+		notFound  *types.ServiceNotFoundException
+		notActive *types.ServiceNotActiveException
+	)
+
+	return errors.As(err, &notFound) || errors.As(err, &notActive)
+}
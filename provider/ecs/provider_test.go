@@ -0,0 +1,444 @@
+package ecs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/testutil"
+)
+
+// TestConformance certifies the provider against the shared provider
+// contract, with the httptest stand-in as the ECS and CloudWatch Logs
+// APIs.
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	_, p := newFakeAWS(t)
+
+	testutil.RunProviderConformance(t, testutil.ProviderConformance{
+		Provider: p,
+		Services: []provider.ServiceSpec{
+			{Name: "web", Image: "nginx:1.25", Ports: []provider.PortSpec{{Container: 80}}},
+			{Name: "agent", Image: "busybox", Role: provider.RoleSidecar, Command: []string{"sleep", "3600"}},
+		},
+		Timeout:      time.Second,
+		PollInterval: 10 * time.Millisecond,
+	})
+}
+
+func provisionWithInit(t *testing.T, p *Provider, replicas int) (id.ID, string) {
+	t.Helper()
+
+	instID := id.New(id.PrefixInstance)
+
+	_, err := p.Provision(context.Background(), provider.ProvisionRequest{
+		InstanceID: instID,
+		TenantID:   "tenant-1",
+		Services: []provider.ServiceSpec{
+			{Name: "migrate", Image: "web:1", Role: provider.RoleInit, Command: []string{"migrate"}},
+			{Name: "web", Image: "web:1", Env: map[string]string{"MODE": "a"}, DependsOn: []string{"proxy"}, Resources: provider.ResourceSpec{Replicas: replicas}},
+			{Name: "proxy", Image: "envoy:1", Role: provider.RoleSidecar},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	return instID, p.serviceName(instID)
+}
+
+func container(t *testing.T, td types.TaskDefinition, name string) types.ContainerDefinition {
+	t.Helper()
+
+	for _, c := range td.ContainerDefinitions {
+		if aws.ToString(c.Name) == name {
+			return c
+		}
+	}
+
+	t.Fatalf("task definition %s has no container %q", aws.ToString(td.TaskDefinitionArn), name)
+
+	return types.ContainerDefinition{}
+}
+
+// TestProvision_ServiceAndTaskDefinition verifies a multi-service
+// request becomes one task definition with container dependencies and
+// one service running it in the configured network.
+func TestProvision_ServiceAndTaskDefinition(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeAWS(t)
+	_, name := provisionWithInit(t, p, 2)
+
+	svc := f.service(name)
+	if svc.DesiredCount != 2 || svc.LaunchType != types.LaunchTypeFargate {
+		t.Fatalf("service = %+v, want 2 Fargate tasks", svc)
+	}
+
+	if subnets := svc.NetworkConfiguration.AwsvpcConfiguration.Subnets; len(subnets) != 1 || subnets[0] != "subnet-a" {
+		t.Fatalf("subnets = %v, want [subnet-a]", subnets)
+	}
+
+	td, tags := f.taskDefinition(aws.ToString(svc.TaskDefinition))
+	if tagValue(tags, tagMain) != "web" || tagValue(tags, tagTenant) != "tenant-1" {
+		t.Fatalf("task definition tags = %v", tags)
+	}
+
+	if migrate := container(t, td, "migrate"); aws.ToBool(migrate.Essential) {
+		t.Fatal("init container is essential")
+	}
+
+	web := container(t, td, "web")
+	want := []types.ContainerDependency{
+		{ContainerName: aws.String("migrate"), Condition: types.ContainerConditionSuccess},
+		{ContainerName: aws.String("proxy"), Condition: types.ContainerConditionStart},
+	}
+
+	if len(web.DependsOn) != len(want) {
+		t.Fatalf("web DependsOn = %+v, want %+v", web.DependsOn, want)
+	}
+
+	for i, dep := range web.DependsOn {
+		if aws.ToString(dep.ContainerName) != aws.ToString(want[i].ContainerName) || dep.Condition != want[i].Condition {
+			t.Errorf("web DependsOn[%d] = %s/%s, want %s/%s", i,
+				aws.ToString(dep.ContainerName), dep.Condition, aws.ToString(want[i].ContainerName), want[i].Condition)
+		}
+	}
+
+	if group := web.LogConfiguration.Options["awslogs-group"]; group != "/ctrlplane/ecs" {
+		t.Fatalf("awslogs-group = %q", group)
+	}
+}
+
+// TestProvision_RequiresSubnets verifies a provider without subnets
+// refuses to provision rather than letting ECS reject the service.
+func TestProvision_RequiresSubnets(t *testing.T) {
+	t.Parallel()
+
+	_, p := newFakeAWS(t)
+	p.cfg.SubnetIDs = nil
+
+	_, err := p.Provision(context.Background(), provider.ProvisionRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services:   []provider.ServiceSpec{{Name: "web", Image: "web:1"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no subnets") {
+		t.Fatalf("Provision err = %v, want a no-subnets error", err)
+	}
+}
+
+// TestDeploy_RegistersNewRevision verifies a deploy registers the
+// next revision, tagged with the Release, with merged env, and points
+// the service at it.
+func TestDeploy_RegistersNewRevision(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeAWS(t)
+	instID, name := provisionWithInit(t, p, 1)
+	rel := id.New(id.PrefixRelease)
+
+	if _, err := p.Deploy(context.Background(), provider.DeployRequest{
+		InstanceID: instID,
+		ReleaseID:  rel,
+		Services:   []provider.ServiceDeploySpec{{Name: "web", Image: "web:2", Env: map[string]string{"NEW": "1"}}},
+	}); err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	svc := f.service(name)
+	if !strings.HasSuffix(aws.ToString(svc.TaskDefinition), ":2") {
+		t.Fatalf("service task definition = %s, want revision 2", aws.ToString(svc.TaskDefinition))
+	}
+
+	td, tags := f.taskDefinition(aws.ToString(svc.TaskDefinition))
+	if tagValue(tags, tagRelease) != rel.String() {
+		t.Fatalf("release tag = %q, want %s", tagValue(tags, tagRelease), rel)
+	}
+
+	web := container(t, td, "web")
+	if env := envMap(web.Environment); aws.ToString(web.Image) != "web:2" || env["MODE"] != "a" || env["NEW"] != "1" {
+		t.Fatalf("web = %s %v, want web:2 with merged env", aws.ToString(web.Image), env)
+	}
+
+	if proxy := container(t, td, "proxy"); aws.ToString(proxy.Image) != "envoy:1" {
+		t.Fatalf("proxy image = %s, want envoy:1 untouched", aws.ToString(proxy.Image))
+	}
+
+	if _, err := p.Deploy(context.Background(), provider.DeployRequest{
+		InstanceID: instID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   []provider.ServiceDeploySpec{{Name: "nope", Image: "x"}},
+	}); err == nil {
+		t.Fatal("Deploy of unknown service succeeded")
+	}
+}
+
+// TestScale_DesiredCountAndResources verifies replicas set the
+// desired count and resources register a resized revision.
+func TestScale_DesiredCountAndResources(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeAWS(t)
+	instID, name := provisionWithInit(t, p, 1)
+	ctx := context.Background()
+
+	if err := p.Scale(ctx, instID, provider.ResourceSpec{Replicas: 4}); err != nil {
+		t.Fatalf("Scale replicas: %v", err)
+	}
+
+	svc := f.service(name)
+	if svc.DesiredCount != 4 || !strings.HasSuffix(aws.ToString(svc.TaskDefinition), ":1") {
+		t.Fatalf("service = desired %d on %s, want 4 on revision 1", svc.DesiredCount, aws.ToString(svc.TaskDefinition))
+	}
+
+	if err := p.Scale(ctx, instID, provider.ResourceSpec{CPUMillis: 1000, MemoryMB: 1024}); err != nil {
+		t.Fatalf("Scale resources: %v", err)
+	}
+
+	svc = f.service(name)
+	td, _ := f.taskDefinition(aws.ToString(svc.TaskDefinition))
+
+	// web and proxy at 1024 units / 1024 MiB each need a 2 vCPU task.
+	if aws.ToString(td.Cpu) != "2048" || aws.ToString(td.Memory) != "4096" {
+		t.Fatalf("task size = %s/%s, want 2048/4096", aws.ToString(td.Cpu), aws.ToString(td.Memory))
+	}
+
+	if web := container(t, td, "web"); web.Cpu != 1024 || aws.ToInt32(web.Memory) != 1024 {
+		t.Fatalf("web = %d/%d, want 1024/1024", web.Cpu, aws.ToInt32(web.Memory))
+	}
+
+	if migrate := container(t, td, "migrate"); migrate.Cpu != 0 {
+		t.Fatalf("init container cpu = %d, want unset", migrate.Cpu)
+	}
+
+	if svc.DesiredCount != 4 {
+		t.Fatalf("desired count = %d after resize, want 4", svc.DesiredCount)
+	}
+}
+
+// TestStopStart_RestoresCount verifies Stop scales to zero and Start
+// brings back the count Stop scaled down from.
+func TestStopStart_RestoresCount(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeAWS(t)
+	instID, name := provisionWithInit(t, p, 3)
+	ctx := context.Background()
+
+	if err := p.Stop(ctx, instID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	st, err := p.Status(ctx, instID)
+	if err != nil || st.State != provider.StateStopped {
+		t.Fatalf("Status after Stop = %+v, %v; want stopped", st, err)
+	}
+
+	if err := p.Start(ctx, instID); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if got := f.service(name).DesiredCount; got != 3 {
+		t.Fatalf("desired count after Start = %d, want 3", got)
+	}
+}
+
+// TestRollbackRelease_ReusesTaggedRevision verifies a rollback to a
+// Release whose revision is still registered points the service back
+// at that revision without registering a new one.
+func TestRollbackRelease_ReusesTaggedRevision(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeAWS(t)
+	instID, name := provisionWithInit(t, p, 1)
+	ctx := context.Background()
+
+	first := id.New(id.PrefixRelease)
+	for i, rel := range []id.ID{first, id.New(id.PrefixRelease)} {
+		if _, err := p.Deploy(ctx, provider.DeployRequest{
+			InstanceID: instID,
+			ReleaseID:  rel,
+			Services:   []provider.ServiceDeploySpec{{Name: "web", Image: "web:" + string(rune('2'+i))}},
+		}); err != nil {
+			t.Fatalf("Deploy %d: %v", i, err)
+		}
+	}
+
+	if err := p.Rollback(ctx, instID, first); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	svc := f.service(name)
+	if !strings.HasSuffix(aws.ToString(svc.TaskDefinition), ":2") {
+		t.Fatalf("service task definition = %s, want revision 2", aws.ToString(svc.TaskDefinition))
+	}
+
+	if n := f.revisions(name); n != 3 {
+		t.Fatalf("revisions = %d, want 3 (no new registration)", n)
+	}
+
+	if err := p.Rollback(ctx, instID, id.New(id.PrefixRelease)); err == nil {
+		t.Fatal("Rollback to an unknown release without a snapshot succeeded")
+	}
+}
+
+// TestRollbackRelease_SnapshotReplacesEnv verifies a rollback to a
+// Release without a registered revision applies the snapshot and
+// drops variables added after it.
+func TestRollbackRelease_SnapshotReplacesEnv(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeAWS(t)
+	instID, name := provisionWithInit(t, p, 1)
+	ctx := context.Background()
+
+	if _, err := p.Deploy(ctx, provider.DeployRequest{
+		InstanceID: instID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   []provider.ServiceDeploySpec{{Name: "web", Image: "web:2", Env: map[string]string{"NEW": "1"}}},
+	}); err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	rel := id.New(id.PrefixRelease)
+	if _, err := p.RollbackRelease(ctx, provider.RollbackRequest{
+		InstanceID: instID,
+		ReleaseID:  rel,
+		Services:   []provider.ServiceSnapshot{{Name: "web", Image: "web:1", Env: map[string]string{"MODE": "a"}}},
+	}); err != nil {
+		t.Fatalf("RollbackRelease: %v", err)
+	}
+
+	td, tags := f.taskDefinition(aws.ToString(f.service(name).TaskDefinition))
+
+	web := container(t, td, "web")
+	if env := envMap(web.Environment); aws.ToString(web.Image) != "web:1" || env["NEW"] != "" {
+		t.Fatalf("after rollback web = %s %v, want web:1 without NEW", aws.ToString(web.Image), env)
+	}
+
+	if tagValue(tags, tagRelease) != rel.String() {
+		t.Fatalf("release tag = %q, want %s", tagValue(tags, tagRelease), rel)
+	}
+}
+
+// TestLogs_TailAndFollow verifies a tailed read returns the last
+// events of the container's streams and a follow stream picks up
+// events logged afterwards.
+func TestLogs_TailAndFollow(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeAWS(t)
+	instID, name := provisionWithInit(t, p, 1)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	f.addLog(name+"/web/task-a", base+1000, "one")
+	f.addLog(name+"/proxy/task-a", base+1500, "proxy line")
+	f.addLog(name+"/web/task-b", base+2000, "two")
+	f.addLog(name+"/web/task-a", base+3000, "three")
+
+	rc, err := p.Logs(context.Background(), instID, provider.LogOptions{Tail: 2})
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+
+	raw, _ := io.ReadAll(rc)
+	_ = rc.Close()
+
+	events := decodeEvents(t, string(raw))
+	if len(events) != 2 || events[0].Line != "two" || events[1].Line != "three" {
+		t.Fatalf("tail events = %+v", events)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rc, err = p.Logs(ctx, instID, provider.LogOptions{ServiceName: "web", Follow: true, Since: time.UnixMilli(base + 3000)})
+	if err != nil {
+		t.Fatalf("Logs follow: %v", err)
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+
+	if ev := readEvent(t, br); ev.Line != "three" {
+		t.Fatalf("first followed line = %q, want three", ev.Line)
+	}
+
+	f.addLog(name+"/web/task-b", base+3000, "three again")
+	f.addLog(name+"/web/task-a", base+4000, "four")
+
+	if ev := readEvent(t, br); ev.Line != "three again" {
+		t.Fatalf("next followed line = %q, want three again", ev.Line)
+	}
+
+	if ev := readEvent(t, br); ev.Line != "four" {
+		t.Fatalf("next followed line = %q, want four", ev.Line)
+	}
+
+	if _, err := p.Logs(ctx, instID, provider.LogOptions{ServiceName: "nope"}); err == nil {
+		t.Fatal("Logs for unknown service succeeded")
+	}
+}
+
+// TestHealthCheck verifies an active cluster is healthy and a
+// missing one is not.
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	_, p := newFakeAWS(t)
+
+	st, err := p.HealthCheck(context.Background())
+	if err != nil || !st.Healthy {
+		t.Fatalf("HealthCheck = %+v, %v; want healthy", st, err)
+	}
+
+	p.cfg.Cluster = "missing"
+
+	st, err = p.HealthCheck(context.Background())
+	if err != nil || st.Healthy {
+		t.Fatalf("HealthCheck for missing cluster = %+v, %v; want unhealthy", st, err)
+	}
+}
+
+func decodeEvents(t *testing.T, raw string) []provider.LogEvent {
+	t.Helper()
+
+	var events []provider.LogEvent
+
+	for line := range strings.SplitSeq(strings.TrimSpace(raw), "\n") {
+		var ev provider.LogEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+
+		events = append(events, ev)
+	}
+
+	return events
+}
+
+func readEvent(t *testing.T, br *bufio.Reader) provider.LogEvent {
+	t.Helper()
+
+	line, err := br.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+
+	var ev provider.LogEvent
+	if err := json.Unmarshal([]byte(line), &ev); err != nil {
+		t.Fatalf("decode %q: %v", line, err)
+	}
+
+	return ev
+}
@@ -0,0 +1,93 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Rollback points the service back at the task definition revision
+// that ran releaseID, using the revision history only. When that
+// revision has been deregistered there's nothing to revert to;
+// deploy.Service calls RollbackRelease with the Release snapshot,
+// which covers that case.
+func (p *Provider) Rollback(ctx context.Context, instanceID id.ID, releaseID id.ID) error {
+	_, err := p.RollbackRelease(ctx, provider.RollbackRequest{
+		InstanceID: instanceID,
+		ReleaseID:  releaseID,
+	})
+
+	return err
+}
+
+// RollbackRelease moves the service to the newest task definition
+// revision tagged with the target Release — the revision is reused
+// as-is, so ECS rolls back to exactly what ran.
+//
+// If the service already runs the Release this is a no-op. If no
+// active revision ran it, the snapshot's images and env are patched
+// onto the current revision and registered as a new one instead.
+func (p *Provider) RollbackRelease(ctx context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	name := p.serviceName(req.InstanceID)
+	result := &provider.DeployResult{ProviderRef: "ecs:" + name, Status: "rolled_back"}
+
+	svc, err := p.describeService(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("ecs: rollback: %w", err)
+	}
+
+	if svc == nil {
+		return nil, fmt.Errorf("ecs: rollback: service %s: %w", name, ctrlplane.ErrNotFound)
+	}
+
+	arns, err := p.taskDefinitions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("ecs: rollback: list task definitions: %w", err)
+	}
+
+	for _, arn := range arns {
+		td, err := p.ecs.DescribeTaskDefinition(ctx, &awsecs.DescribeTaskDefinitionInput{
+			TaskDefinition: aws.String(arn),
+			Include:        []types.TaskDefinitionField{types.TaskDefinitionFieldTags},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ecs: rollback: describe %s: %w", arn, err)
+		}
+
+		if tagValue(td.Tags, tagRelease) != req.ReleaseID.String() {
+			continue
+		}
+
+		if arn == aws.ToString(svc.TaskDefinition) {
+			return result, nil
+		}
+
+		if err := p.switchRevision(ctx, svc, arn); err != nil {
+			return nil, fmt.Errorf("ecs: rollback: %w", err)
+		}
+
+		return result, nil
+	}
+
+	if len(req.Services) == 0 {
+		return nil, fmt.Errorf("ecs: rollback: no task definition for release %s and no snapshot", req.ReleaseID)
+	}
+
+	updates := make([]serviceUpdate, len(req.Services))
+	for i, s := range req.Services {
+		updates[i] = serviceUpdate{name: s.Name, image: s.Image, env: s.Env, replaceEnv: true}
+	}
+
+	if err := p.rollout(ctx, req.InstanceID, req.ReleaseID, updates); err != nil {
+		return nil, fmt.Errorf("ecs: rollback to release %s: %w", req.ReleaseID, err)
+	}
+
+	return result, nil
+}
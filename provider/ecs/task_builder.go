@@ -0,0 +1,441 @@
+package ecs
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Launch types.
+const (
+	launchFargate = "FARGATE"
+	launchEC2     = "EC2"
+)
+
+// Tag keys the provider stamps on services and task definitions.
+// Every task definition revision records the Release it ran, which is
+// how Rollback maps a Release back to a revision.
+const (
+	tagInstance = "ctrlplane.instance"
+	tagTenant   = "ctrlplane.tenant"
+	tagMain     = "ctrlplane.main"
+	tagRelease  = "ctrlplane.release"
+
+	// tagReplicas records the desired count Stop scaled down from,
+	// so Start can restore it.
+	tagReplicas = "ctrlplane.replicas"
+)
+
+// Container health check bounds, in seconds, as ECS enforces them.
+const (
+	healthIntervalMin = 5
+	healthIntervalMax = 300
+	healthTimeoutMin  = 2
+	healthTimeoutMax  = 60
+	healthRetriesMax  = 10
+)
+
+// fargateSize is one Fargate CPU size with the memory sizes it
+// allows, in MiB.
+type fargateSize struct {
+	cpu    int
+	memory []int
+}
+
+// fargateSizes lists the valid task sizes, smallest first. See
+// https://docs.aws.amazon.com/AmazonECS/latest/developerguide/fargate-tasks-services.html#fargate-tasks-size.
+var fargateSizes = []fargateSize{
+	{256, []int{512, 1024, 2048}},
+	{512, memoryRange(1024, 4096, 1024)},
+	{1024, memoryRange(2048, 8192, 1024)},
+	{2048, memoryRange(4096, 16384, 1024)},
+	{4096, memoryRange(8192, 30720, 1024)},
+	{8192, memoryRange(16384, 61440, 4096)},
+	{16384, memoryRange(32768, 122880, 8192)},
+}
+
+func memoryRange(lo, hi, step int) []int {
+	var out []int
+	for m := lo; m <= hi; m += step {
+		out = append(out, m)
+	}
+
+	return out
+}
+
+// serviceName returns the ECS service and task definition family an
+// instance runs as. Both allow letters, digits, dashes and
+// underscores.
+func serviceName(prefix string, instanceID id.ID) string {
+	return prefix + "-" + instanceID.String()
+}
+
+// taskSize picks the smallest Fargate size holding every long-lived
+// service: CPU and memory are summed (millicores converted to CPU
+// units, 1024 per vCPU), then rounded up. Returns the largest size
+// when nothing fits.
+func taskSize(services []provider.ServiceSpec) (cpu, memory int) {
+	var millis, mem int
+
+	for _, svc := range services {
+		if svc.Role == provider.RoleInit {
+			continue
+		}
+
+		millis += svc.Resources.CPUMillis
+		mem += svc.Resources.MemoryMB
+	}
+
+	units := (millis*1024 + 999) / 1000
+
+	for _, size := range fargateSizes {
+		if size.cpu < units {
+			continue
+		}
+
+		for _, m := range size.memory {
+			if m >= mem {
+				return size.cpu, m
+			}
+		}
+	}
+
+	last := fargateSizes[len(fargateSizes)-1]
+
+	return last.cpu, last.memory[len(last.memory)-1]
+}
+
+// buildTaskDefinition translates a ProvisionRequest into the first
+// revision of the instance's task definition. Pure function so it's
+// testable without AWS.
+func buildTaskDefinition(cfg Config, family string, req provider.ProvisionRequest, main *provider.ServiceSpec) *awsecs.RegisterTaskDefinitionInput {
+	cpu, memory := taskSize(req.Services)
+
+	in := &awsecs.RegisterTaskDefinitionInput{
+		Family:                  aws.String(family),
+		NetworkMode:             types.NetworkModeAwsvpc,
+		RequiresCompatibilities: []types.Compatibility{types.Compatibility(cfg.LaunchType)},
+		Cpu:                     aws.String(strconv.Itoa(cpu)),
+		Memory:                  aws.String(strconv.Itoa(memory)),
+		Tags:                    instanceTags(req.InstanceID, req.TenantID, main.Name),
+	}
+
+	if cfg.ExecutionRoleARN != "" {
+		in.ExecutionRoleArn = aws.String(cfg.ExecutionRoleARN)
+	}
+
+	if cfg.TaskRoleARN != "" {
+		in.TaskRoleArn = aws.String(cfg.TaskRoleARN)
+	}
+
+	roles := make(map[string]provider.ServiceRole, len(req.Services))
+	for _, svc := range req.Services {
+		roles[svc.Name] = svc.Role
+	}
+
+	for _, svc := range req.Services {
+		in.ContainerDefinitions = append(in.ContainerDefinitions, containerDefinition(cfg, family, svc, roles))
+	}
+
+	return in
+}
+
+// containerDefinition maps one ServiceSpec to a container. Init
+// services are non-essential, and every long-lived container waits
+// for them to exit successfully; DependsOn waits for the named
+// container to start (or, for an init, to succeed).
+func containerDefinition(cfg Config, family string, svc provider.ServiceSpec, roles map[string]provider.ServiceRole) types.ContainerDefinition {
+	init := svc.Role == provider.RoleInit
+
+	c := types.ContainerDefinition{
+		Name:        aws.String(svc.Name),
+		Image:       aws.String(svc.Image),
+		Essential:   aws.Bool(!init),
+		EntryPoint:  svc.Command,
+		Command:     svc.Args,
+		Environment: environment(svc.Env),
+		LogConfiguration: &types.LogConfiguration{
+			LogDriver: types.LogDriverAwslogs,
+			Options: map[string]string{
+				"awslogs-group":         cfg.LogGroup,
+				"awslogs-region":        cfg.Region,
+				"awslogs-stream-prefix": family,
+			},
+		},
+	}
+
+	if svc.Resources.CPUMillis > 0 {
+		c.Cpu = int32(min(svc.Resources.CPUMillis*1024/1000, 1<<20)) //nolint:gosec // clamped
+	}
+
+	if svc.Resources.MemoryMB > 0 {
+		c.Memory = aws.Int32(int32(min(svc.Resources.MemoryMB, 1<<20))) //nolint:gosec // clamped
+	}
+
+	for _, port := range svc.Ports {
+		protocol := types.TransportProtocolTcp
+		if strings.EqualFold(port.Protocol, "udp") {
+			protocol = types.TransportProtocolUdp
+		}
+
+		c.PortMappings = append(c.PortMappings, types.PortMapping{
+			ContainerPort: aws.Int32(int32(port.Container)), //nolint:gosec // port numbers fit
+			Protocol:      protocol,
+		})
+	}
+
+	if !init {
+		for _, svcName := range slices.Sorted(maps.Keys(roles)) {
+			if roles[svcName] == provider.RoleInit {
+				c.DependsOn = append(c.DependsOn, types.ContainerDependency{
+					ContainerName: aws.String(svcName),
+					Condition:     types.ContainerConditionSuccess,
+				})
+			}
+		}
+	}
+
+	for _, dep := range svc.DependsOn {
+		depInit := roles[dep] == provider.RoleInit
+		if depInit && !init {
+			continue // already waited on above
+		}
+
+		cond := types.ContainerConditionStart
+		if depInit {
+			cond = types.ContainerConditionSuccess
+		}
+
+		c.DependsOn = append(c.DependsOn, types.ContainerDependency{ContainerName: aws.String(dep), Condition: cond})
+	}
+
+	if hc := healthCheck(svc); hc != nil {
+		c.HealthCheck = hc
+	}
+
+	return c
+}
+
+// healthCheck maps an HTTP HealthCheck to a container health check.
+// ECS checks run a command inside the container, so the probe needs
+// curl or wget in the image. TCP-only checks have no portable
+// command and aren't mapped.
+func healthCheck(svc provider.ServiceSpec) *types.HealthCheck {
+	hc := svc.HealthCheck
+	if hc == nil || hc.Path == "" {
+		return nil
+	}
+
+	port := hc.Port
+	if port == 0 && len(svc.Ports) > 0 {
+		port = svc.Ports[0].Container
+	}
+
+	if port == 0 {
+		return nil
+	}
+
+	target := fmt.Sprintf("http://localhost:%d%s", port, hc.Path)
+	check := &types.HealthCheck{
+		Command: []string{"CMD-SHELL", fmt.Sprintf("curl -fsS -o /dev/null %[1]s || wget -q -O /dev/null %[1]s || exit 1", target)},
+	}
+
+	if hc.Interval > 0 {
+		check.Interval = aws.Int32(clampSeconds(hc.Interval.Seconds(), healthIntervalMin, healthIntervalMax))
+	}
+
+	if hc.Timeout > 0 {
+		check.Timeout = aws.Int32(clampSeconds(hc.Timeout.Seconds(), healthTimeoutMin, healthTimeoutMax))
+	}
+
+	if hc.Retries > 0 {
+		check.Retries = aws.Int32(int32(min(hc.Retries, healthRetriesMax))) //nolint:gosec // clamped
+	}
+
+	return check
+}
+
+func clampSeconds(s float64, lo, hi int32) int32 {
+	return min(max(int32(s), lo), hi)
+}
+
+// environment turns an env map into ECS key/value pairs, sorted so
+// identical env produces identical revisions.
+func environment(env map[string]string) []types.KeyValuePair {
+	out := make([]types.KeyValuePair, 0, len(env))
+
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		out = append(out, types.KeyValuePair{Name: aws.String(k), Value: aws.String(env[k])})
+	}
+
+	return out
+}
+
+// envMap is the inverse of environment.
+func envMap(pairs []types.KeyValuePair) map[string]string {
+	out := make(map[string]string, len(pairs))
+
+	for _, kv := range pairs {
+		out[aws.ToString(kv.Name)] = aws.ToString(kv.Value)
+	}
+
+	return out
+}
+
+func instanceTags(instanceID id.ID, tenantID, main string) []types.Tag {
+	tags := []types.Tag{
+		{Key: aws.String(tagInstance), Value: aws.String(instanceID.String())},
+		{Key: aws.String(tagMain), Value: aws.String(main)},
+	}
+
+	if tenantID != "" {
+		tags = append(tags, types.Tag{Key: aws.String(tagTenant), Value: aws.String(tenantID)})
+	}
+
+	return tags
+}
+
+// tagValue returns the value of key among tags.
+func tagValue(tags []types.Tag, key string) string {
+	for _, t := range tags {
+		if aws.ToString(t.Key) == key {
+			return aws.ToString(t.Value)
+		}
+	}
+
+	return ""
+}
+
+// setTag sets key to value among tags.
+func setTag(tags []types.Tag, key, value string) []types.Tag {
+	tags = slices.DeleteFunc(slices.Clone(tags), func(t types.Tag) bool { return aws.ToString(t.Key) == key })
+
+	return append(tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+}
+
+// nextRevision copies td into the input registering its next
+// revision, with tags as the revision's tags.
+func nextRevision(td *types.TaskDefinition, tags []types.Tag) *awsecs.RegisterTaskDefinitionInput {
+	return &awsecs.RegisterTaskDefinitionInput{
+		Family:                  td.Family,
+		ContainerDefinitions:    slices.Clone(td.ContainerDefinitions),
+		Cpu:                     td.Cpu,
+		Memory:                  td.Memory,
+		NetworkMode:             td.NetworkMode,
+		RequiresCompatibilities: td.RequiresCompatibilities,
+		ExecutionRoleArn:        td.ExecutionRoleArn,
+		TaskRoleArn:             td.TaskRoleArn,
+		Volumes:                 td.Volumes,
+		EphemeralStorage:        td.EphemeralStorage,
+		RuntimePlatform:         td.RuntimePlatform,
+		Tags:                    tags,
+	}
+}
+
+// serviceUpdate is one service's new image and env, applied to a
+// task definition by Deploy and RollbackRelease.
+type serviceUpdate struct {
+	name  string
+	image string
+	env   map[string]string
+
+	// replaceEnv replaces the env outright instead of merging into
+	// it, so a rollback drops variables added after the Release.
+	replaceEnv bool
+}
+
+// applyUpdates patches containers with updates. It returns an error
+// naming a service the task definition doesn't run. Pure function so
+// it's testable without AWS.
+func applyUpdates(containers []types.ContainerDefinition, updates []serviceUpdate) error {
+	for _, u := range updates {
+		i := slices.IndexFunc(containers, func(c types.ContainerDefinition) bool { return aws.ToString(c.Name) == u.name })
+		if i < 0 {
+			return fmt.Errorf("service %q not found", u.name)
+		}
+
+		c := &containers[i]
+		c.Image = aws.String(u.image)
+
+		if u.env == nil {
+			continue
+		}
+
+		env := u.env
+		if !u.replaceEnv {
+			env = envMap(c.Environment)
+			maps.Copy(env, u.env)
+		}
+
+		c.Environment = environment(env)
+	}
+
+	return nil
+}
+
+// setResources sets every long-lived container's CPU and memory to
+// spec — same contract as the Kubernetes provider's Scale, where a
+// multi-service workload gets every app container set to the same
+// spec — and resizes the task to hold them. Zero fields are left
+// alone.
+func setResources(in *awsecs.RegisterTaskDefinitionInput, spec provider.ResourceSpec) {
+	services := make([]provider.ServiceSpec, 0, len(in.ContainerDefinitions))
+
+	for i := range in.ContainerDefinitions {
+		c := &in.ContainerDefinitions[i]
+		if !aws.ToBool(c.Essential) {
+			continue
+		}
+
+		if spec.CPUMillis > 0 {
+			c.Cpu = int32(min(spec.CPUMillis*1024/1000, 1<<20)) //nolint:gosec // clamped
+		}
+
+		if spec.MemoryMB > 0 {
+			c.Memory = aws.Int32(int32(min(spec.MemoryMB, 1<<20))) //nolint:gosec // clamped
+		}
+
+		services = append(services, provider.ServiceSpec{Resources: provider.ResourceSpec{
+			CPUMillis: int(c.Cpu) * 1000 / 1024,
+			MemoryMB:  int(aws.ToInt32(c.Memory)),
+		}})
+	}
+
+	cpu, memory := taskSize(services)
+	in.Cpu = aws.String(strconv.Itoa(cpu))
+	in.Memory = aws.String(strconv.Itoa(memory))
+}
+
+// pickMain finds the first Main service (default-Role-is-Main)
+// in a slice. Returns nil for empty slices or all-Sidecar/Init slices.
+func pickMain(services []provider.ServiceSpec) *provider.ServiceSpec {
+	for i := range services {
+		if services[i].Role == provider.RoleMain || services[i].Role == "" {
+			return &services[i]
+		}
+	}
+
+	return nil
+}
+
+// familyOf returns the family of a task definition ARN
+// (arn:aws:ecs:<region>:<account>:task-definition/<family>:<revision>).
+func familyOf(arn string) string {
+	_, rest, ok := strings.Cut(arn, "task-definition/")
+	if !ok {
+		return ""
+	}
+
+	family, _, _ := strings.Cut(rest, ":")
+
+	return family
+}
@@ -0,0 +1,171 @@
+package ecs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+func testConfig() Config {
+	return Config{Region: "eu-west-1", LaunchType: launchFargate, LogGroup: "/ctrlplane/ecs", ExecutionRoleARN: "arn:exec"}
+}
+
+// TestBuildTaskDefinition_SingleService verifies a lone service maps
+// to an essential container with its command, env, ports, health
+// check and awslogs configuration.
+func TestBuildTaskDefinition_SingleService(t *testing.T) {
+	t.Parallel()
+
+	req := provider.ProvisionRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services: []provider.ServiceSpec{{
+			Name:        "web",
+			Image:       "web:1",
+			Command:     []string{"/bin/web"},
+			Args:        []string{"--port", "8080"},
+			Env:         map[string]string{"B": "2", "A": "1"},
+			Ports:       []provider.PortSpec{{Container: 8080}, {Container: 53, Protocol: "udp"}},
+			HealthCheck: &provider.HealthCheckSpec{Path: "/healthz", Interval: time.Second, Retries: 20},
+			Resources:   provider.ResourceSpec{CPUMillis: 500, MemoryMB: 768},
+		}},
+	}
+
+	in := buildTaskDefinition(testConfig(), "cp-x", req, &req.Services[0])
+
+	if in.NetworkMode != types.NetworkModeAwsvpc || in.RequiresCompatibilities[0] != types.CompatibilityFargate {
+		t.Fatalf("network mode %s, compatibilities %v", in.NetworkMode, in.RequiresCompatibilities)
+	}
+
+	if aws.ToString(in.Cpu) != "512" || aws.ToString(in.Memory) != "1024" {
+		t.Fatalf("task size = %s/%s, want 512/1024", aws.ToString(in.Cpu), aws.ToString(in.Memory))
+	}
+
+	if aws.ToString(in.ExecutionRoleArn) != "arn:exec" || in.TaskRoleArn != nil {
+		t.Fatalf("roles = %v/%v", aws.ToString(in.ExecutionRoleArn), in.TaskRoleArn)
+	}
+
+	c := in.ContainerDefinitions[0]
+	if !aws.ToBool(c.Essential) || c.EntryPoint[0] != "/bin/web" || len(c.Command) != 2 {
+		t.Fatalf("container = %+v", c)
+	}
+
+	if aws.ToString(c.Environment[0].Name) != "A" || aws.ToString(c.Environment[1].Name) != "B" {
+		t.Fatalf("environment not sorted: %+v", c.Environment)
+	}
+
+	if c.Cpu != 512 || aws.ToInt32(c.Memory) != 768 {
+		t.Fatalf("container size = %d/%d, want 512/768", c.Cpu, aws.ToInt32(c.Memory))
+	}
+
+	if len(c.PortMappings) != 2 || c.PortMappings[1].Protocol != types.TransportProtocolUdp {
+		t.Fatalf("port mappings = %+v", c.PortMappings)
+	}
+
+	hc := c.HealthCheck
+	if hc == nil || !strings.Contains(hc.Command[1], "http://localhost:8080/healthz") {
+		t.Fatalf("health check = %+v", hc)
+	}
+
+	if aws.ToInt32(hc.Interval) != healthIntervalMin || aws.ToInt32(hc.Retries) != healthRetriesMax {
+		t.Fatalf("health check interval/retries = %d/%d, want clamped", aws.ToInt32(hc.Interval), aws.ToInt32(hc.Retries))
+	}
+
+	if prefix := c.LogConfiguration.Options["awslogs-stream-prefix"]; prefix != "cp-x" {
+		t.Fatalf("stream prefix = %q, want cp-x", prefix)
+	}
+}
+
+// TestBuildTaskDefinition_InitDependencies verifies an init waited on
+// through DependsOn isn't listed twice and an init depending on
+// another init waits for it to succeed.
+func TestBuildTaskDefinition_InitDependencies(t *testing.T) {
+	t.Parallel()
+
+	req := provider.ProvisionRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services: []provider.ServiceSpec{
+			{Name: "fetch", Image: "busybox", Role: provider.RoleInit},
+			{Name: "migrate", Image: "web:1", Role: provider.RoleInit, DependsOn: []string{"fetch"}},
+			{Name: "web", Image: "web:1", DependsOn: []string{"migrate"}},
+		},
+	}
+
+	in := buildTaskDefinition(testConfig(), "cp-x", req, &req.Services[2])
+
+	migrate := in.ContainerDefinitions[1]
+	if len(migrate.DependsOn) != 1 || migrate.DependsOn[0].Condition != types.ContainerConditionSuccess {
+		t.Fatalf("migrate DependsOn = %+v, want fetch SUCCESS", migrate.DependsOn)
+	}
+
+	web := in.ContainerDefinitions[2]
+	if len(web.DependsOn) != 2 {
+		t.Fatalf("web DependsOn = %+v, want fetch and migrate once each", web.DependsOn)
+	}
+
+	for _, dep := range web.DependsOn {
+		if dep.Condition != types.ContainerConditionSuccess {
+			t.Fatalf("web DependsOn %s = %s, want SUCCESS", aws.ToString(dep.ContainerName), dep.Condition)
+		}
+	}
+}
+
+// TestTaskSize_RoundsUp verifies the summed request is rounded up to
+// the smallest valid Fargate size and init services don't count.
+func TestTaskSize_RoundsUp(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		services    []provider.ServiceSpec
+		cpu, memory int
+	}{
+		{"empty", nil, 256, 512},
+		{"memory bound", []provider.ServiceSpec{{Resources: provider.ResourceSpec{MemoryMB: 3000}}}, 512, 3072},
+		{"summed", []provider.ServiceSpec{
+			{Resources: provider.ResourceSpec{CPUMillis: 600}},
+			{Resources: provider.ResourceSpec{CPUMillis: 600}},
+			{Role: provider.RoleInit, Resources: provider.ResourceSpec{CPUMillis: 4000}},
+		}, 2048, 4096},
+		{"too big", []provider.ServiceSpec{{Resources: provider.ResourceSpec{CPUMillis: 64000}}}, 16384, 122880},
+	}
+
+	for _, tc := range cases {
+		cpu, memory := taskSize(tc.services)
+		if cpu != tc.cpu || memory != tc.memory {
+			t.Errorf("%s: taskSize = %d/%d, want %d/%d", tc.name, cpu, memory, tc.cpu, tc.memory)
+		}
+	}
+}
+
+// TestApplyUpdates_UnknownService verifies a deploy naming a service
+// the task definition doesn't run is rejected.
+func TestApplyUpdates_UnknownService(t *testing.T) {
+	t.Parallel()
+
+	containers := []types.ContainerDefinition{{Name: aws.String("web"), Image: aws.String("web:1")}}
+
+	err := applyUpdates(containers, []serviceUpdate{{name: "worker", image: "worker:2"}})
+	if err == nil || !strings.Contains(err.Error(), `"worker"`) {
+		t.Fatalf("applyUpdates err = %v, want service not found", err)
+	}
+}
+
+// TestFamilyOf verifies the family is parsed out of a task definition
+// ARN.
+func TestFamilyOf(t *testing.T) {
+	t.Parallel()
+
+	if got := familyOf("arn:aws:ecs:us-east-1:123:task-definition/cp-inst_1:7"); got != "cp-inst_1" {
+		t.Fatalf("familyOf = %q, want cp-inst_1", got)
+	}
+
+	if got := familyOf("cp-inst_1"); got != "" {
+		t.Fatalf("familyOf(bare family) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,42 @@
+// Package faultinject decorates a provider.Provider with configurable
+// failures for chaos testing. A staging control plane registers its
+// real (or fake) providers through the decorator and then checks that
+// deploy.Service, workload.Service and the reconciler and GC workers
+// cope with what production backends actually do: answer slowly, fail
+// some of the time, hang until the caller gives up, or apply a change
+// and still report an error.
+//
+// Faults are rules held by an Injector. Each rule matches provider
+// methods and instance labels and applies any mix of:
+//
+//   - Latency (plus random Jitter) before the call.
+//   - ErrorRate: the call fails without reaching the provider.
+//   - HangRate: the call blocks until its context is done.
+//   - PartialRate: the call reaches the provider, then fails anyway —
+//     the "timed out after the backend did the work" case that
+//     exercises idempotency.
+//
+// Rules can be swapped at runtime with SetFaults, so a test (or an
+// operator endpoint in staging) can turn chaos on and off without
+// re-registering providers:
+//
+//	inj, _ := faultinject.New(faultinject.WithFaults(faultinject.Fault{
+//	    Methods:   []faultinject.Method{faultinject.MethodDeploy},
+//	    Labels:    map[string]string{"chaos": "on"},
+//	    ErrorRate: 0.2,
+//	}))
+//	cp, _ := app.New(app.WithProvider("docker", dockerProv, inj.Wrap))
+//
+// The decorator keeps the wrapped provider's shape: it implements
+// HealthChecker, Rollbacker and TrackDeployer only when the wrapped
+// provider does, and the capability-gated engines (ManifestEngine, HelmEngine,
+// ArgoEngine) and Watcher pass through with faults applied.
+//
+// Rules that select by label read an instance's labels on every call
+// from the injector's label resolver, which the control plane points
+// at its instance store, so label changes and instances provisioned
+// before the decorator was installed are matched too. Without a
+// resolver, or when it can't find the instance, labels are learned
+// from the requests that carry them (Provision, ApplyManifests,
+// ArgoApply) or set with SetInstanceLabels.
+package faultinject
@@ -0,0 +1,129 @@
+package faultinject
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// ErrInjected is the default error of a failing call. It wraps
+// ctrlplane.ErrProviderUnavail, so callers handle it as they would a
+// real backend outage.
+var ErrInjected = fmt.Errorf("faultinject: injected fault: %w", ctrlplane.ErrProviderUnavail)
+
+// Method names a decorated provider method.
+type Method string
+
+// Decorated methods. Info and Capabilities never fail: they describe
+// the provider rather than call its backend.
+const (
+	MethodProvision       Method = "Provision"
+	MethodDeprovision     Method = "Deprovision"
+	MethodStart           Method = "Start"
+	MethodStop            Method = "Stop"
+	MethodRestart         Method = "Restart"
+	MethodStatus          Method = "Status"
+	MethodDeploy          Method = "Deploy"
+	MethodRollback        Method = "Rollback"
+	MethodRollbackRelease Method = "RollbackRelease"
+	MethodDeployTrack     Method = "DeployTrack"
+	MethodTrackStatus     Method = "TrackStatus"
+	MethodRemoveTrack     Method = "RemoveTrack"
+	MethodScale           Method = "Scale"
+	MethodResources       Method = "Resources"
+	MethodLogs            Method = "Logs"
+	MethodExec            Method = "Exec"
+	MethodHealthCheck     Method = "HealthCheck"
+	MethodApplyManifests  Method = "ApplyManifests"
+	MethodDeleteManifests Method = "DeleteManifests"
+	MethodManifestStatus  Method = "ManifestStatus"
+	MethodHelmInstall     Method = "HelmInstall"
+	MethodHelmUpgrade     Method = "HelmUpgrade"
+	MethodHelmUninstall   Method = "HelmUninstall"
+	MethodHelmStatus      Method = "HelmStatus"
+	MethodArgoApply       Method = "ArgoApply"
+	MethodArgoDelete      Method = "ArgoDelete"
+	MethodArgoStatus      Method = "ArgoStatus"
+	MethodWatch           Method = "Watch"
+)
+
+// Fault is one injection rule. The match fields select calls; the
+// effect fields say what happens to them. Rates are probabilities in
+// [0, 1], rolled independently per call. When several rules match a
+// call their latencies add up and the first rule whose roll fires
+// decides the outcome.
+type Fault struct {
+	// Methods limits the rule to these methods. Empty matches all.
+	Methods []Method `json:"methods,omitempty"`
+
+	// Labels limits the rule to instances carrying every one of these
+	// labels. Empty matches every call, including calls that aren't
+	// about an instance (HealthCheck).
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Latency is added before the call; Jitter adds up to that much
+	// more, uniformly at random.
+	Latency time.Duration `json:"latency,omitempty"`
+	Jitter  time.Duration `json:"jitter,omitempty"`
+
+	// ErrorRate is the chance the call fails without reaching the
+	// provider.
+	ErrorRate float64 `json:"error_rate,omitempty"`
+
+	// HangRate is the chance the call blocks until its context is
+	// done and then fails with the context's error.
+	HangRate float64 `json:"hang_rate,omitempty"`
+
+	// PartialRate is the chance the call reaches the provider and
+	// then fails even though the provider succeeded.
+	PartialRate float64 `json:"partial_rate,omitempty"`
+
+	// Err is returned by failing calls. Nil uses ErrInjected.
+	Err error `json:"-"`
+}
+
+// validate rejects rates outside [0, 1] and negative durations.
+func (f Fault) validate() error {
+	for name, rate := range map[string]float64{
+		"error rate":   f.ErrorRate,
+		"hang rate":    f.HangRate,
+		"partial rate": f.PartialRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("faultinject: %w: %s %v outside [0, 1]", ctrlplane.ErrInvalidConfig, name, rate)
+		}
+	}
+
+	if f.Latency < 0 || f.Jitter < 0 {
+		return fmt.Errorf("faultinject: %w: latency and jitter must not be negative", ctrlplane.ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// matches reports whether the rule applies to a call of m on an
+// instance carrying labels.
+func (f Fault) matches(m Method, labels map[string]string) bool {
+	if len(f.Methods) > 0 && !slices.Contains(f.Methods, m) {
+		return false
+	}
+
+	for k, v := range f.Labels {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// err returns the error a failing call reports.
+func (f Fault) err() error {
+	if f.Err != nil {
+		return f.Err
+	}
+
+	return ErrInjected
+}
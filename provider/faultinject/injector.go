@@ -0,0 +1,269 @@
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Injector holds the fault rules shared by every provider it wraps.
+// Safe for concurrent use; rules can change while calls are in flight.
+type Injector struct {
+	mu       sync.RWMutex
+	faults   []Fault
+	labels   map[id.ID]map[string]string
+	resolver provider.InstanceLabelResolver
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// Option configures an Injector.
+type Option func(*Injector) error
+
+// WithFaults sets the initial rules.
+func WithFaults(faults ...Fault) Option {
+	return func(inj *Injector) error {
+		return inj.SetFaults(faults...)
+	}
+}
+
+// WithSeed makes the injector's dice deterministic, so a failing
+// chaos run can be replayed.
+func WithSeed(seed uint64) Option {
+	return func(inj *Injector) error {
+		inj.rand = rand.New(rand.NewPCG(seed, seed)) //nolint:gosec // chaos dice, not cryptography
+
+		return nil
+	}
+}
+
+// New creates an Injector. Without WithFaults it injects nothing
+// until SetFaults is called.
+func New(opts ...Option) (*Injector, error) {
+	inj := &Injector{
+		labels: make(map[id.ID]map[string]string),
+		rand:   rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())), //nolint:gosec // chaos dice, not cryptography
+	}
+
+	for _, opt := range opts {
+		if err := opt(inj); err != nil {
+			return nil, err
+		}
+	}
+
+	return inj, nil
+}
+
+// SetFaults replaces every rule. An invalid rule leaves the current
+// rules in place.
+func (inj *Injector) SetFaults(faults ...Fault) error {
+	for i, f := range faults {
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("fault %d: %w", i, err)
+		}
+	}
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.faults = append([]Fault(nil), faults...)
+
+	return nil
+}
+
+// Faults returns the current rules.
+func (inj *Injector) Faults() []Fault {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	return append([]Fault(nil), inj.faults...)
+}
+
+// Clear removes every rule; wrapped providers behave normally again.
+func (inj *Injector) Clear() {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.faults = nil
+}
+
+// SetInstanceLabels records the labels rules match an instance by.
+// Only needed without a label resolver, for instances provisioned
+// before the decorator was installed; later ones are learned from
+// their provision request.
+func (inj *Injector) SetInstanceLabels(instanceID id.ID, labels map[string]string) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	if len(labels) == 0 {
+		delete(inj.labels, instanceID)
+
+		return
+	}
+
+	inj.labels[instanceID] = maps.Clone(labels)
+}
+
+// SetLabelResolver sets where an instance's labels are read from when
+// a rule selects by label, so rules follow label changes and match
+// instances the decorator never saw provisioned. The control plane
+// sets its instance store on every wrapper it's given. Labels the
+// resolver can't supply fall back to the recorded ones.
+func (inj *Injector) SetLabelResolver(r provider.InstanceLabelResolver) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.resolver = r
+}
+
+// instanceLabels returns the labels rules match instanceID by: the
+// resolver's, recorded for calls that can't resolve them, or else
+// the last recorded ones.
+func (inj *Injector) instanceLabels(ctx context.Context, instanceID id.ID) map[string]string {
+	inj.mu.RLock()
+	r, recorded := inj.resolver, inj.labels[instanceID]
+	inj.mu.RUnlock()
+
+	if r == nil || instanceID.IsNil() {
+		return recorded
+	}
+
+	labels, err := r.InstanceLabels(ctx, instanceID)
+	if err != nil {
+		return recorded
+	}
+
+	inj.SetInstanceLabels(instanceID, labels)
+
+	return labels
+}
+
+// selectsByLabel reports whether any rule matches on labels.
+func (inj *Injector) selectsByLabel() bool {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	return slices.ContainsFunc(inj.faults, func(f Fault) bool { return len(f.Labels) > 0 })
+}
+
+// forget drops a removed instance's labels.
+func (inj *Injector) forget(instanceID id.ID) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	delete(inj.labels, instanceID)
+}
+
+// Wrap decorates p with the injector's faults. Its signature matches
+// the wrappers app.WithProvider accepts.
+func (inj *Injector) Wrap(p provider.Provider) provider.Provider {
+	w := &wrapper{inner: p, inj: inj}
+
+	_, hc := p.(provider.HealthChecker)
+	_, rb := p.(provider.Rollbacker)
+	_, td := p.(provider.TrackDeployer)
+	tracks := trackMethods{w}
+
+	switch {
+	case hc && rb && td:
+		return &healthRollbackTrackWrapper{&healthRollbackWrapper{w}, tracks}
+	case hc && rb:
+		return &healthRollbackWrapper{w}
+	case hc && td:
+		return &healthTrackWrapper{&healthWrapper{w}, tracks}
+	case hc:
+		return &healthWrapper{w}
+	case rb && td:
+		return &rollbackTrackWrapper{&rollbackWrapper{w}, tracks}
+	case rb:
+		return &rollbackWrapper{w}
+	case td:
+		return &trackWrapper{w, tracks}
+	default:
+		return w
+	}
+}
+
+// inject applies the matching rules to one call before it reaches the
+// provider. A non-nil err fails the call outright; a non-nil partial
+// is returned after the provider succeeds.
+func (inj *Injector) inject(ctx context.Context, m Method, instanceID id.ID) (partial error, err error) {
+	var labels map[string]string
+	if inj.selectsByLabel() {
+		labels = inj.instanceLabels(ctx, instanceID)
+	}
+
+	inj.mu.RLock()
+
+	var matched []Fault
+
+	for _, f := range inj.faults {
+		if f.matches(m, labels) {
+			matched = append(matched, f)
+		}
+	}
+	inj.mu.RUnlock()
+
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	var delay time.Duration
+	for _, f := range matched {
+		delay += f.Latency
+		if f.Jitter > 0 {
+			delay += time.Duration(inj.float() * float64(f.Jitter))
+		}
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	for _, f := range matched {
+		switch {
+		case inj.roll(f.HangRate):
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		case inj.roll(f.ErrorRate):
+			return nil, fmt.Errorf("%s: %w", m, f.err())
+		case inj.roll(f.PartialRate):
+			return fmt.Errorf("%s (applied): %w", m, f.err()), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// roll reports whether an event with probability rate happens.
+func (inj *Injector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	return inj.float() < rate
+}
+
+// float returns a random number in [0, 1).
+func (inj *Injector) float() float64 {
+	inj.randMu.Lock()
+	defer inj.randMu.Unlock()
+
+	return inj.rand.Float64()
+}
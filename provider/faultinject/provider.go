@@ -0,0 +1,424 @@
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Compile-time interface checks.
+var (
+	_ provider.Provider           = (*wrapper)(nil)
+	_ provider.ManifestEngine     = (*wrapper)(nil)
+	_ provider.HelmEngine         = (*wrapper)(nil)
+	_ provider.ArgoEngine         = (*wrapper)(nil)
+	_ provider.Watcher            = (*wrapper)(nil)
+	_ provider.InstanceLabelAware = (*wrapper)(nil)
+	_ provider.HealthChecker      = (*healthWrapper)(nil)
+	_ provider.Rollbacker         = (*rollbackWrapper)(nil)
+	_ provider.HealthChecker      = (*healthRollbackWrapper)(nil)
+	_ provider.Rollbacker         = (*healthRollbackWrapper)(nil)
+	_ provider.TrackDeployer      = (*trackWrapper)(nil)
+	_ provider.TrackDeployer      = (*healthTrackWrapper)(nil)
+	_ provider.TrackDeployer      = (*rollbackTrackWrapper)(nil)
+	_ provider.TrackDeployer      = (*healthRollbackTrackWrapper)(nil)
+)
+
+// errUnimplemented is returned by engine methods whose wrapped
+// provider doesn't implement the engine. The dispatcher gates engines
+// on Capabilities, which pass through unchanged, so it never sees this.
+var errUnimplemented = errors.New("faultinject: wrapped provider does not implement this engine")
+
+// wrapper is the decorator for providers that implement none of
+// HealthChecker, Rollbacker and TrackDeployer. The other shapes embed
+// it, so callers' type assertions see exactly what the wrapped
+// provider offers.
+type wrapper struct {
+	inner provider.Provider
+	inj   *Injector
+}
+
+// healthWrapper adds HealthChecker.
+type healthWrapper struct{ *wrapper }
+
+// rollbackWrapper adds Rollbacker.
+type rollbackWrapper struct{ *wrapper }
+
+// healthRollbackWrapper adds both.
+type healthRollbackWrapper struct{ *wrapper }
+
+// trackMethods holds the TrackDeployer methods; the track shapes add
+// it to the shape the wrapped provider has otherwise.
+type trackMethods struct{ w *wrapper }
+
+// trackWrapper adds TrackDeployer.
+type trackWrapper struct {
+	*wrapper
+	trackMethods
+}
+
+// healthTrackWrapper adds TrackDeployer to healthWrapper.
+type healthTrackWrapper struct {
+	*healthWrapper
+	trackMethods
+}
+
+// rollbackTrackWrapper adds TrackDeployer to rollbackWrapper.
+type rollbackTrackWrapper struct {
+	*rollbackWrapper
+	trackMethods
+}
+
+// healthRollbackTrackWrapper adds TrackDeployer to
+// healthRollbackWrapper.
+type healthRollbackTrackWrapper struct {
+	*healthRollbackWrapper
+	trackMethods
+}
+
+// Unwrap returns the decorated provider.
+func (w *wrapper) Unwrap() provider.Provider { return w.inner }
+
+// SetInstanceLabelResolver implements provider.InstanceLabelAware by
+// setting the injector's label resolver.
+func (w *wrapper) SetInstanceLabelResolver(r provider.InstanceLabelResolver) {
+	w.inj.SetLabelResolver(r)
+}
+
+// call runs fn under the faults matching m and instanceID.
+func call[T any](ctx context.Context, inj *Injector, m Method, instanceID id.ID, fn func() (T, error)) (T, error) {
+	var zero T
+
+	partial, err := inj.inject(ctx, m, instanceID)
+	if err != nil {
+		return zero, err
+	}
+
+	res, err := fn()
+	if err != nil {
+		return res, err
+	}
+
+	if partial != nil {
+		return zero, partial
+	}
+
+	return res, nil
+}
+
+// call0 is call for methods that only return an error.
+func call0(ctx context.Context, inj *Injector, m Method, instanceID id.ID, fn func() error) error {
+	_, err := call(ctx, inj, m, instanceID, func() (struct{}, error) { return struct{}{}, fn() })
+
+	return err
+}
+
+// Info returns the wrapped provider's metadata.
+func (w *wrapper) Info() provider.ProviderInfo { return w.inner.Info() }
+
+// Capabilities returns the wrapped provider's capabilities.
+func (w *wrapper) Capabilities() []provider.Capability { return w.inner.Capabilities() }
+
+// Provision records the request's labels, then provisions with faults
+// applied — so a rule can target an instance from its first call.
+func (w *wrapper) Provision(ctx context.Context, req provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+	w.inj.SetInstanceLabels(req.InstanceID, req.Labels)
+
+	return call(ctx, w.inj, MethodProvision, req.InstanceID, func() (*provider.ProvisionResult, error) {
+		return w.inner.Provision(ctx, req)
+	})
+}
+
+// Deprovision deprovisions with faults applied and forgets the
+// instance's labels once it's gone.
+func (w *wrapper) Deprovision(ctx context.Context, instanceID id.ID) error {
+	err := call0(ctx, w.inj, MethodDeprovision, instanceID, func() error {
+		return w.inner.Deprovision(ctx, instanceID)
+	})
+	if err == nil {
+		w.inj.forget(instanceID)
+	}
+
+	return err
+}
+
+func (w *wrapper) Start(ctx context.Context, instanceID id.ID) error {
+	return call0(ctx, w.inj, MethodStart, instanceID, func() error { return w.inner.Start(ctx, instanceID) })
+}
+
+func (w *wrapper) Stop(ctx context.Context, instanceID id.ID) error {
+	return call0(ctx, w.inj, MethodStop, instanceID, func() error { return w.inner.Stop(ctx, instanceID) })
+}
+
+func (w *wrapper) Restart(ctx context.Context, instanceID id.ID) error {
+	return call0(ctx, w.inj, MethodRestart, instanceID, func() error { return w.inner.Restart(ctx, instanceID) })
+}
+
+func (w *wrapper) Status(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
+	return call(ctx, w.inj, MethodStatus, instanceID, func() (*provider.InstanceStatus, error) {
+		return w.inner.Status(ctx, instanceID)
+	})
+}
+
+func (w *wrapper) Deploy(ctx context.Context, req provider.DeployRequest) (*provider.DeployResult, error) {
+	return call(ctx, w.inj, MethodDeploy, req.InstanceID, func() (*provider.DeployResult, error) {
+		return w.inner.Deploy(ctx, req)
+	})
+}
+
+func (w *wrapper) Rollback(ctx context.Context, instanceID id.ID, releaseID id.ID) error {
+	return call0(ctx, w.inj, MethodRollback, instanceID, func() error {
+		return w.inner.Rollback(ctx, instanceID, releaseID)
+	})
+}
+
+func (w *wrapper) Scale(ctx context.Context, instanceID id.ID, spec provider.ResourceSpec) error {
+	return call0(ctx, w.inj, MethodScale, instanceID, func() error { return w.inner.Scale(ctx, instanceID, spec) })
+}
+
+func (w *wrapper) Resources(ctx context.Context, instanceID id.ID) (*provider.ResourceUsage, error) {
+	return call(ctx, w.inj, MethodResources, instanceID, func() (*provider.ResourceUsage, error) {
+		return w.inner.Resources(ctx, instanceID)
+	})
+}
+
+// Logs applies faults to opening the stream; once open, the stream is
+// the wrapped provider's own. A partial failure closes it.
+func (w *wrapper) Logs(ctx context.Context, instanceID id.ID, opts provider.LogOptions) (io.ReadCloser, error) {
+	partial, err := w.inj.inject(ctx, MethodLogs, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := w.inner.Logs(ctx, instanceID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if partial != nil {
+		_ = rc.Close()
+
+		return nil, partial
+	}
+
+	return rc, nil
+}
+
+func (w *wrapper) Exec(ctx context.Context, instanceID id.ID, cmd provider.ExecRequest) (*provider.ExecResult, error) {
+	return call(ctx, w.inj, MethodExec, instanceID, func() (*provider.ExecResult, error) {
+		return w.inner.Exec(ctx, instanceID, cmd)
+	})
+}
+
+// HealthCheck applies faults to the wrapped provider's health check.
+// An injected failure is returned as an error, which the health cache
+// records as unhealthy.
+func (w *healthWrapper) HealthCheck(ctx context.Context) (*provider.HealthStatus, error) {
+	return healthCheck(ctx, w.wrapper)
+}
+
+// HealthCheck applies faults to the wrapped provider's health check.
+func (w *healthRollbackWrapper) HealthCheck(ctx context.Context) (*provider.HealthStatus, error) {
+	return healthCheck(ctx, w.wrapper)
+}
+
+func healthCheck(ctx context.Context, w *wrapper) (*provider.HealthStatus, error) {
+	hc, _ := w.inner.(provider.HealthChecker)
+
+	return call(ctx, w.inj, MethodHealthCheck, id.Nil, func() (*provider.HealthStatus, error) {
+		return hc.HealthCheck(ctx)
+	})
+}
+
+// RollbackRelease applies faults to the wrapped provider's native
+// rollback.
+func (w *rollbackWrapper) RollbackRelease(ctx context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	return rollbackRelease(ctx, w.wrapper, req)
+}
+
+// RollbackRelease applies faults to the wrapped provider's native
+// rollback.
+func (w *healthRollbackWrapper) RollbackRelease(ctx context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	return rollbackRelease(ctx, w.wrapper, req)
+}
+
+func rollbackRelease(ctx context.Context, w *wrapper, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	rb, _ := w.inner.(provider.Rollbacker)
+
+	return call(ctx, w.inj, MethodRollbackRelease, req.InstanceID, func() (*provider.DeployResult, error) {
+		return rb.RollbackRelease(ctx, req)
+	})
+}
+
+// DeployTrack applies faults to starting or moving a track.
+func (t trackMethods) DeployTrack(ctx context.Context, track string, req provider.DeployRequest) (*provider.DeployResult, error) {
+	td, _ := t.w.inner.(provider.TrackDeployer)
+
+	return call(ctx, t.w.inj, MethodDeployTrack, req.InstanceID, func() (*provider.DeployResult, error) {
+		return td.DeployTrack(ctx, track, req)
+	})
+}
+
+// TrackStatus applies faults to reading a track's state.
+func (t trackMethods) TrackStatus(ctx context.Context, instanceID id.ID, track string) (*provider.InstanceStatus, error) {
+	td, _ := t.w.inner.(provider.TrackDeployer)
+
+	return call(ctx, t.w.inj, MethodTrackStatus, instanceID, func() (*provider.InstanceStatus, error) {
+		return td.TrackStatus(ctx, instanceID, track)
+	})
+}
+
+// RemoveTrack applies faults to tearing a track down.
+func (t trackMethods) RemoveTrack(ctx context.Context, instanceID id.ID, track string) error {
+	td, _ := t.w.inner.(provider.TrackDeployer)
+
+	return call0(ctx, t.w.inj, MethodRemoveTrack, instanceID, func() error {
+		return td.RemoveTrack(ctx, instanceID, track)
+	})
+}
+
+// unimplemented reports an engine the wrapped provider lacks.
+func (w *wrapper) unimplemented(m Method) error {
+	return fmt.Errorf("%s on %T: %w", m, w.inner, errUnimplemented)
+}
+
+func (w *wrapper) ApplyManifests(ctx context.Context, req provider.ManifestApplyRequest) (*provider.ProvisionResult, error) {
+	eng, ok := w.inner.(provider.ManifestEngine)
+	if !ok {
+		return nil, w.unimplemented(MethodApplyManifests)
+	}
+
+	w.inj.SetInstanceLabels(req.InstanceID, req.Labels)
+
+	return call(ctx, w.inj, MethodApplyManifests, req.InstanceID, func() (*provider.ProvisionResult, error) {
+		return eng.ApplyManifests(ctx, req)
+	})
+}
+
+func (w *wrapper) DeleteManifests(ctx context.Context, instanceID id.ID) error {
+	eng, ok := w.inner.(provider.ManifestEngine)
+	if !ok {
+		return w.unimplemented(MethodDeleteManifests)
+	}
+
+	err := call0(ctx, w.inj, MethodDeleteManifests, instanceID, func() error { return eng.DeleteManifests(ctx, instanceID) })
+	if err == nil {
+		w.inj.forget(instanceID)
+	}
+
+	return err
+}
+
+func (w *wrapper) ManifestStatus(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
+	eng, ok := w.inner.(provider.ManifestEngine)
+	if !ok {
+		return nil, w.unimplemented(MethodManifestStatus)
+	}
+
+	return call(ctx, w.inj, MethodManifestStatus, instanceID, func() (*provider.InstanceStatus, error) {
+		return eng.ManifestStatus(ctx, instanceID)
+	})
+}
+
+func (w *wrapper) HelmInstall(ctx context.Context, req provider.HelmInstallRequest) (*provider.ProvisionResult, error) {
+	eng, ok := w.inner.(provider.HelmEngine)
+	if !ok {
+		return nil, w.unimplemented(MethodHelmInstall)
+	}
+
+	return call(ctx, w.inj, MethodHelmInstall, req.InstanceID, func() (*provider.ProvisionResult, error) {
+		return eng.HelmInstall(ctx, req)
+	})
+}
+
+func (w *wrapper) HelmUpgrade(ctx context.Context, req provider.HelmUpgradeRequest) (*provider.DeployResult, error) {
+	eng, ok := w.inner.(provider.HelmEngine)
+	if !ok {
+		return nil, w.unimplemented(MethodHelmUpgrade)
+	}
+
+	return call(ctx, w.inj, MethodHelmUpgrade, req.InstanceID, func() (*provider.DeployResult, error) {
+		return eng.HelmUpgrade(ctx, req)
+	})
+}
+
+func (w *wrapper) HelmUninstall(ctx context.Context, instanceID id.ID) error {
+	eng, ok := w.inner.(provider.HelmEngine)
+	if !ok {
+		return w.unimplemented(MethodHelmUninstall)
+	}
+
+	err := call0(ctx, w.inj, MethodHelmUninstall, instanceID, func() error { return eng.HelmUninstall(ctx, instanceID) })
+	if err == nil {
+		w.inj.forget(instanceID)
+	}
+
+	return err
+}
+
+func (w *wrapper) HelmStatus(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
+	eng, ok := w.inner.(provider.HelmEngine)
+	if !ok {
+		return nil, w.unimplemented(MethodHelmStatus)
+	}
+
+	return call(ctx, w.inj, MethodHelmStatus, instanceID, func() (*provider.InstanceStatus, error) {
+		return eng.HelmStatus(ctx, instanceID)
+	})
+}
+
+func (w *wrapper) ArgoApply(ctx context.Context, req provider.ArgoApplyRequest) (*provider.ProvisionResult, error) {
+	eng, ok := w.inner.(provider.ArgoEngine)
+	if !ok {
+		return nil, w.unimplemented(MethodArgoApply)
+	}
+
+	w.inj.SetInstanceLabels(req.InstanceID, req.Labels)
+
+	return call(ctx, w.inj, MethodArgoApply, req.InstanceID, func() (*provider.ProvisionResult, error) {
+		return eng.ArgoApply(ctx, req)
+	})
+}
+
+func (w *wrapper) ArgoDelete(ctx context.Context, instanceID id.ID) error {
+	eng, ok := w.inner.(provider.ArgoEngine)
+	if !ok {
+		return w.unimplemented(MethodArgoDelete)
+	}
+
+	err := call0(ctx, w.inj, MethodArgoDelete, instanceID, func() error { return eng.ArgoDelete(ctx, instanceID) })
+	if err == nil {
+		w.inj.forget(instanceID)
+	}
+
+	return err
+}
+
+func (w *wrapper) ArgoStatus(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
+	eng, ok := w.inner.(provider.ArgoEngine)
+	if !ok {
+		return nil, w.unimplemented(MethodArgoStatus)
+	}
+
+	return call(ctx, w.inj, MethodArgoStatus, instanceID, func() (*provider.InstanceStatus, error) {
+		return eng.ArgoStatus(ctx, instanceID)
+	})
+}
+
+// Watch faults opening the stream only; changes on an open stream
+// pass through untouched.
+func (w *wrapper) Watch(ctx context.Context) (<-chan provider.StateChange, error) {
+	watcher, ok := w.inner.(provider.Watcher)
+	if !ok {
+		return nil, fmt.Errorf("%s on %T: %w", MethodWatch, w.inner, ctrlplane.ErrNotImplemented)
+	}
+
+	return call(ctx, w.inj, MethodWatch, id.Nil, func() (<-chan provider.StateChange, error) {
+		return watcher.Watch(ctx)
+	})
+}
@@ -0,0 +1,339 @@
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// fakeProvider counts Deploy calls and succeeds at everything.
+type fakeProvider struct {
+	deploys atomic.Int32
+}
+
+func (f *fakeProvider) Info() provider.ProviderInfo         { return provider.ProviderInfo{Name: "fake"} }
+func (f *fakeProvider) Capabilities() []provider.Capability { return nil }
+func (f *fakeProvider) Provision(_ context.Context, _ provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+	return &provider.ProvisionResult{ProviderRef: "fake"}, nil
+}
+func (f *fakeProvider) Deprovision(_ context.Context, _ id.ID) error { return nil }
+func (f *fakeProvider) Start(_ context.Context, _ id.ID) error       { return nil }
+func (f *fakeProvider) Stop(_ context.Context, _ id.ID) error        { return nil }
+func (f *fakeProvider) Restart(_ context.Context, _ id.ID) error     { return nil }
+func (f *fakeProvider) Status(_ context.Context, _ id.ID) (*provider.InstanceStatus, error) {
+	return &provider.InstanceStatus{State: provider.StateRunning}, nil
+}
+func (f *fakeProvider) Deploy(_ context.Context, _ provider.DeployRequest) (*provider.DeployResult, error) {
+	f.deploys.Add(1)
+
+	return &provider.DeployResult{Status: "deployed"}, nil
+}
+func (f *fakeProvider) Rollback(_ context.Context, _ id.ID, _ id.ID) error { return nil }
+func (f *fakeProvider) Scale(_ context.Context, _ id.ID, _ provider.ResourceSpec) error {
+	return nil
+}
+func (f *fakeProvider) Resources(_ context.Context, _ id.ID) (*provider.ResourceUsage, error) {
+	return &provider.ResourceUsage{}, nil
+}
+func (f *fakeProvider) Logs(_ context.Context, _ id.ID, _ provider.LogOptions) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+func (f *fakeProvider) Exec(_ context.Context, _ id.ID, _ provider.ExecRequest) (*provider.ExecResult, error) {
+	return &provider.ExecResult{}, nil
+}
+
+// rollbackerFake adds native rollback.
+type rollbackerFake struct{ fakeProvider }
+
+func (f *rollbackerFake) RollbackRelease(_ context.Context, _ provider.RollbackRequest) (*provider.DeployResult, error) {
+	return &provider.DeployResult{Status: "rolled_back"}, nil
+}
+
+// trackFake adds tracks to rollbackerFake.
+type trackFake struct{ rollbackerFake }
+
+func (f *trackFake) DeployTrack(_ context.Context, _ string, _ provider.DeployRequest) (*provider.DeployResult, error) {
+	return &provider.DeployResult{Status: "deployed"}, nil
+}
+
+func (f *trackFake) TrackStatus(_ context.Context, _ id.ID, _ string) (*provider.InstanceStatus, error) {
+	return &provider.InstanceStatus{Ready: true}, nil
+}
+
+func (f *trackFake) RemoveTrack(_ context.Context, _ id.ID, _ string) error { return nil }
+
+func newInjector(t *testing.T, faults ...Fault) *Injector {
+	t.Helper()
+
+	inj, err := New(WithFaults(faults...), WithSeed(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return inj
+}
+
+// provision provisions an instance with labels through p.
+func provision(t *testing.T, p provider.Provider, labels map[string]string) id.ID {
+	t.Helper()
+
+	instID := id.New(id.PrefixInstance)
+	if _, err := p.Provision(context.Background(), provider.ProvisionRequest{InstanceID: instID, Labels: labels}); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	return instID
+}
+
+// TestWrap_ErrorRateMatchesMethodAndLabels verifies an always-failing
+// rule fails only the matching method on matching instances, without
+// reaching the provider, and that the error reads as an outage.
+func TestWrap_ErrorRateMatchesMethodAndLabels(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &fakeProvider{}
+	inj := newInjector(t, Fault{
+		Methods:   []Method{MethodDeploy},
+		Labels:    map[string]string{"chaos": "on"},
+		ErrorRate: 1,
+	})
+	p := inj.Wrap(fake)
+
+	chaotic := provision(t, p, map[string]string{"chaos": "on", "team": "a"})
+	calm := provision(t, p, map[string]string{"team": "a"})
+
+	_, err := p.Deploy(ctx, provider.DeployRequest{InstanceID: chaotic})
+	if !errors.Is(err, ErrInjected) || !errors.Is(err, ctrlplane.ErrProviderUnavail) {
+		t.Fatalf("Deploy on labelled instance: err = %v, want injected provider outage", err)
+	}
+
+	if fake.deploys.Load() != 0 {
+		t.Fatal("failed call reached the provider")
+	}
+
+	if _, err := p.Deploy(ctx, provider.DeployRequest{InstanceID: calm}); err != nil {
+		t.Fatalf("Deploy on unlabelled instance: %v", err)
+	}
+
+	if _, err := p.Status(ctx, chaotic); err != nil {
+		t.Fatalf("Status is not targeted: %v", err)
+	}
+
+	inj.Clear()
+
+	if _, err := p.Deploy(ctx, provider.DeployRequest{InstanceID: chaotic}); err != nil {
+		t.Fatalf("Deploy after Clear: %v", err)
+	}
+}
+
+// TestWrap_PartialFailureReachesProvider verifies a partial failure
+// applies the change and still reports an error.
+func TestWrap_PartialFailureReachesProvider(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeProvider{}
+	custom := errors.New("connection reset")
+	p := newInjector(t, Fault{Methods: []Method{MethodDeploy}, PartialRate: 1, Err: custom}).Wrap(fake)
+
+	_, err := p.Deploy(context.Background(), provider.DeployRequest{InstanceID: id.New(id.PrefixInstance)})
+	if !errors.Is(err, custom) {
+		t.Fatalf("err = %v, want the rule's error", err)
+	}
+
+	if fake.deploys.Load() != 1 {
+		t.Fatalf("provider saw %d deploys, want 1", fake.deploys.Load())
+	}
+}
+
+// TestWrap_HangAndLatencyRespectContext verifies hangs last until the
+// caller's deadline and latency delays the call.
+func TestWrap_HangAndLatencyRespectContext(t *testing.T) {
+	t.Parallel()
+
+	inj := newInjector(t, Fault{Methods: []Method{MethodStatus}, HangRate: 1})
+	p := inj.Wrap(&fakeProvider{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := p.Status(ctx, id.New(id.PrefixInstance)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("hung Status: err = %v, want deadline exceeded", err)
+	}
+
+	if err := inj.SetFaults(Fault{Latency: 30 * time.Millisecond}); err != nil {
+		t.Fatalf("SetFaults: %v", err)
+	}
+
+	start := time.Now()
+	if err := p.Start(context.Background(), id.New(id.PrefixInstance)); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("Start took %s, want at least the injected latency", elapsed)
+	}
+}
+
+// TestWrap_KeepsOptionalInterfaces verifies the decorator offers
+// Rollbacker and HealthChecker exactly when the wrapped provider does,
+// so deploy.Service picks the same rollback path it would without it.
+func TestWrap_KeepsOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+
+	inj := newInjector(t)
+
+	if _, ok := inj.Wrap(&fakeProvider{}).(provider.Rollbacker); ok {
+		t.Fatal("wrapped plain provider claims native rollback")
+	}
+
+	if _, ok := inj.Wrap(&fakeProvider{}).(provider.HealthChecker); ok {
+		t.Fatal("wrapped plain provider claims a health check")
+	}
+
+	rb, ok := inj.Wrap(&rollbackerFake{}).(provider.Rollbacker)
+	if !ok {
+		t.Fatal("wrapped rollbacker lost native rollback")
+	}
+
+	res, err := rb.RollbackRelease(context.Background(), provider.RollbackRequest{InstanceID: id.New(id.PrefixInstance)})
+	if err != nil || res.Status != "rolled_back" {
+		t.Fatalf("RollbackRelease = %+v, %v", res, err)
+	}
+
+	eng, _ := inj.Wrap(&fakeProvider{}).(provider.HelmEngine)
+	if _, err := eng.HelmStatus(context.Background(), id.New(id.PrefixInstance)); !errors.Is(err, errUnimplemented) {
+		t.Fatalf("HelmStatus on a provider without Helm: err = %v, want errUnimplemented", err)
+	}
+}
+
+// TestWrap_InjectsTrackFaults verifies the decorator offers
+// TrackDeployer exactly when the wrapped provider does, keeping its
+// other optional interfaces, and applies faults to track calls.
+func TestWrap_InjectsTrackFaults(t *testing.T) {
+	t.Parallel()
+
+	inj := newInjector(t, Fault{Methods: []Method{MethodDeployTrack}, ErrorRate: 1})
+
+	if _, ok := inj.Wrap(&rollbackerFake{}).(provider.TrackDeployer); ok {
+		t.Fatal("wrapped provider without tracks claims them")
+	}
+
+	p := inj.Wrap(&trackFake{})
+
+	if _, ok := p.(provider.Rollbacker); !ok {
+		t.Fatal("wrapped track provider lost native rollback")
+	}
+
+	td, ok := p.(provider.TrackDeployer)
+	if !ok {
+		t.Fatal("wrapped track provider lost its tracks")
+	}
+
+	instID := id.New(id.PrefixInstance)
+
+	if _, err := td.DeployTrack(context.Background(), provider.TrackCanary, provider.DeployRequest{InstanceID: instID}); !errors.Is(err, ErrInjected) {
+		t.Fatalf("DeployTrack: err = %v, want ErrInjected", err)
+	}
+
+	if st, err := td.TrackStatus(context.Background(), instID, provider.TrackCanary); err != nil || !st.Ready {
+		t.Fatalf("TrackStatus = %+v, %v", st, err)
+	}
+}
+
+// mapLabels resolves instance labels from a map; instances missing
+// from it are not found.
+type mapLabels struct {
+	mu     sync.Mutex
+	labels map[id.ID]map[string]string
+}
+
+func (m *mapLabels) InstanceLabels(_ context.Context, instanceID id.ID) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels, ok := m.labels[instanceID]
+	if !ok {
+		return nil, ctrlplane.ErrNotFound
+	}
+
+	return labels, nil
+}
+
+func (m *mapLabels) set(instanceID id.ID, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.labels[instanceID] = labels
+}
+
+// TestWrap_ResolvesLabels verifies label rules match instances the
+// decorator never saw provisioned and follow label changes through the
+// resolver the control plane hands the wrapper, and fall back to
+// recorded labels for instances the resolver can't find.
+func TestWrap_ResolvesLabels(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inj := newInjector(t, Fault{
+		Methods:   []Method{MethodDeploy},
+		Labels:    map[string]string{"chaos": "on"},
+		ErrorRate: 1,
+	})
+	p := inj.Wrap(&fakeProvider{})
+
+	existing := id.New(id.PrefixInstance)
+	resolver := &mapLabels{labels: map[id.ID]map[string]string{existing: {"chaos": "on"}}}
+
+	aware, ok := p.(provider.InstanceLabelAware)
+	if !ok {
+		t.Fatal("wrapper is not label-aware")
+	}
+
+	aware.SetInstanceLabelResolver(resolver)
+
+	if _, err := p.Deploy(ctx, provider.DeployRequest{InstanceID: existing}); !errors.Is(err, ErrInjected) {
+		t.Fatalf("Deploy on a resolved chaos instance: err = %v, want injected", err)
+	}
+
+	resolver.set(existing, map[string]string{"chaos": "off"})
+
+	if _, err := p.Deploy(ctx, provider.DeployRequest{InstanceID: existing}); err != nil {
+		t.Fatalf("Deploy after relabelling: %v", err)
+	}
+
+	recorded := provision(t, p, map[string]string{"chaos": "on"})
+
+	if _, err := p.Deploy(ctx, provider.DeployRequest{InstanceID: recorded}); !errors.Is(err, ErrInjected) {
+		t.Fatalf("Deploy on an unresolvable instance: err = %v, want injected from its recorded labels", err)
+	}
+}
+
+// TestSetFaults_RejectsInvalidRules verifies out-of-range rates are
+// rejected and leave the current rules in place.
+func TestSetFaults_RejectsInvalidRules(t *testing.T) {
+	t.Parallel()
+
+	inj := newInjector(t, Fault{ErrorRate: 0.5})
+
+	if err := inj.SetFaults(Fault{HangRate: 1.5}); !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Fatalf("err = %v, want ErrInvalidConfig", err)
+	}
+
+	if got := inj.Faults(); len(got) != 1 || got[0].ErrorRate != 0.5 {
+		t.Fatalf("rules = %+v, want the original rule kept", got)
+	}
+
+	if _, err := New(WithFaults(Fault{Latency: -time.Second})); !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Fatalf("New with negative latency: err = %v, want ErrInvalidConfig", err)
+	}
+}
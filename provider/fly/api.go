@@ -0,0 +1,271 @@
+package fly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Machine states the provider acts on. See
+// https://fly.io/docs/machines/machine-states/.
+const (
+	machineCreated    = "created"
+	machineStarting   = "starting"
+	machineStarted    = "started"
+	machineStopping   = "stopping"
+	machineStopped    = "stopped"
+	machineSuspended  = "suspended"
+	machineReplacing  = "replacing"
+	machineUpdating   = "updating"
+	machineDestroying = "destroying"
+	machineDestroyed  = "destroyed"
+	machineFailed     = "failed"
+)
+
+// Machine metadata keys the provider stamps. Fly restricts metadata
+// keys to identifier characters, hence underscores rather than the
+// dotted keys the docker provider uses for labels.
+const (
+	metaInstance = "ctrlplane_instance"
+	metaTenant   = "ctrlplane_tenant"
+	metaMain     = "ctrlplane_main"
+	metaRelease  = "ctrlplane_release"
+)
+
+// flyApp is the request body of POST /v1/apps.
+type flyApp struct {
+	AppName string `json:"app_name"`
+	OrgSlug string `json:"org_slug"`
+}
+
+// flyMachine is a Machine as the Machines API returns it.
+type flyMachine struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name,omitempty"`
+	State      string           `json:"state"`
+	Region     string           `json:"region"`
+	InstanceID string           `json:"instance_id,omitempty"`
+	PrivateIP  string           `json:"private_ip,omitempty"`
+	Config     flyMachineConfig `json:"config"`
+	Checks     []flyCheckStatus `json:"checks,omitempty"`
+	CreatedAt  string           `json:"created_at,omitempty"`
+}
+
+// flyMachineConfig is the subset of a Machine's config the provider
+// sets. Machines are created and updated from this type, so fields
+// it doesn't model keep Fly's defaults.
+type flyMachineConfig struct {
+	Image      string              `json:"image"`
+	Env        map[string]string   `json:"env,omitempty"`
+	Init       *flyInit            `json:"init,omitempty"`
+	Guest      *flyGuest           `json:"guest,omitempty"`
+	Services   []flyService        `json:"services,omitempty"`
+	Checks     map[string]flyCheck `json:"checks,omitempty"`
+	Files      []flyFile           `json:"files,omitempty"`
+	Containers []flyContainer      `json:"containers,omitempty"`
+	Metadata   map[string]string   `json:"metadata,omitempty"`
+	Restart    *flyRestart         `json:"restart,omitempty"`
+}
+
+// flyInit overrides the image's ENTRYPOINT and CMD.
+type flyInit struct {
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	Cmd        []string `json:"cmd,omitempty"`
+}
+
+// flyGuest sizes the Machine.
+type flyGuest struct {
+	CPUKind  string `json:"cpu_kind"`
+	CPUs     int    `json:"cpus"`
+	MemoryMB int    `json:"memory_mb"`
+}
+
+// flyService exposes an internal port through fly-proxy.
+type flyService struct {
+	Protocol     string    `json:"protocol"`
+	InternalPort int       `json:"internal_port"`
+	Ports        []flyPort `json:"ports,omitempty"`
+}
+
+// flyPort is one public port of a flyService.
+type flyPort struct {
+	Port     int      `json:"port"`
+	Handlers []string `json:"handlers,omitempty"`
+}
+
+// flyCheck is a Machine-level health check.
+type flyCheck struct {
+	Type     string `json:"type"`
+	Port     int    `json:"port"`
+	Path     string `json:"path,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+}
+
+// flyCheckStatus is the latest result of a flyCheck.
+type flyCheckStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // passing | warning | critical
+	Output string `json:"output,omitempty"`
+}
+
+// flyFile is a file written into the Machine's (or a container's)
+// filesystem. RawValue is base64.
+type flyFile struct {
+	GuestPath string `json:"guest_path"`
+	RawValue  string `json:"raw_value"`
+}
+
+// flyContainer is one container of a multi-container Machine.
+type flyContainer struct {
+	Name       string            `json:"name"`
+	Image      string            `json:"image"`
+	Env        map[string]string `json:"env,omitempty"`
+	Entrypoint []string          `json:"entrypoint,omitempty"`
+	Cmd        []string          `json:"cmd,omitempty"`
+	DependsOn  []flyDependency   `json:"depends_on,omitempty"`
+	Restart    *flyRestart       `json:"restart,omitempty"`
+	Files      []flyFile         `json:"files,omitempty"`
+}
+
+// flyDependency orders a container after another.
+type flyDependency struct {
+	Name      string `json:"name"`
+	Condition string `json:"condition"` // started | healthy | exited_successfully
+}
+
+// flyRestart is a Machine or container restart policy.
+type flyRestart struct {
+	Policy string `json:"policy"` // no | always | on-failure
+}
+
+// flyCreateMachine is the request body of machine create and update.
+type flyCreateMachine struct {
+	Region     string           `json:"region,omitempty"`
+	Config     flyMachineConfig `json:"config"`
+	SkipLaunch bool             `json:"skip_launch,omitempty"`
+}
+
+// flyExecRequest is the request body of POST .../machines/{id}/exec.
+type flyExecRequest struct {
+	Command   []string `json:"command"`
+	Container string   `json:"container,omitempty"`
+	Stdin     string   `json:"stdin,omitempty"`
+	Timeout   int      `json:"timeout,omitempty"`
+}
+
+// flyExecResponse is the result of a Machine exec.
+type flyExecResponse struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+// apiError is returned by doRequest for a non-2xx response.
+type apiError struct {
+	method   string
+	endpoint string
+	status   int
+	message  string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("fly %s %s: status %d: %s", e.method, e.endpoint, e.status, e.message)
+}
+
+func isNotFound(err error) bool {
+	var ae *apiError
+
+	return errors.As(err, &ae) && ae.status == http.StatusNotFound
+}
+
+// isConflict reports whether the API refused to create something that
+// already exists.
+func isConflict(err error) bool {
+	var ae *apiError
+
+	return errors.As(err, &ae) && (ae.status == http.StatusConflict || ae.status == http.StatusUnprocessableEntity)
+}
+
+// appPath returns the Machines API path of app plus suffix.
+func appPath(app, suffix string) string {
+	return "/v1/apps/" + url.PathEscape(app) + suffix
+}
+
+// machinePath returns the Machines API path of one machine plus
+// suffix.
+func machinePath(app, machineID, suffix string) string {
+	return appPath(app, "/machines/"+url.PathEscape(machineID)+suffix)
+}
+
+// doRequest issues a request to the Machines API. When body is
+// non-nil it's JSON-encoded; when result is non-nil the response
+// body is JSON-decoded into it.
+func (p *Provider) doRequest(ctx context.Context, method, endpoint string, body, result any) error {
+	return p.do(ctx, p.client, p.cfg.Address, method, endpoint, body, result)
+}
+
+// do issues an authenticated JSON request against base.
+func (p *Provider) do(ctx context.Context, client *http.Client, base, method, endpoint string, body, result any) error {
+	var reqBody io.Reader
+
+	if body != nil {
+		buf := new(bytes.Buffer)
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+
+		reqBody = buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(base, "/")+endpoint, reqBody)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if p.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.Token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+		// The API wraps messages as {"error": "..."}; fall back to
+		// the raw body for anything else.
+		var wrapped struct {
+			Error string `json:"error"`
+		}
+
+		msg := strings.TrimSpace(string(raw))
+		if json.Unmarshal(raw, &wrapped) == nil && wrapped.Error != "" {
+			msg = wrapped.Error
+		}
+
+		return &apiError{method: method, endpoint: endpoint, status: resp.StatusCode, message: msg}
+	}
+
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package fly
+
+// Config holds configuration for the Fly.io provider.
+type Config struct {
+	// Address is the Machines API endpoint.
+	Address string `default:"https://api.machines.dev" env:"CP_FLY_ADDRESS" json:"address,omitempty"`
+
+	// LogsAddress is the Fly platform API endpoint logs are read
+	// from. The Machines API has no log endpoint of its own.
+	LogsAddress string `default:"https://api.fly.io" env:"CP_FLY_LOGS_ADDRESS" json:"logs_address,omitempty"`
+
+	// Token is the Fly.io API token for authentication.
+	Token string `env:"CP_FLY_TOKEN" json:"-"`
+
+	// OrgSlug is the organization instance apps are created in.
+	OrgSlug string `default:"personal" env:"CP_FLY_ORG" json:"org_slug"`
+
+	// Region is the region Machines are placed in (e.g. iad, lhr,
+	// nrt). Reported as the provider's region.
+	Region string `default:"iad" env:"CP_FLY_REGION" json:"region"`
+
+	// AppPrefix prefixes the per-instance Fly app names.
+	AppPrefix string `default:"cp" env:"CP_FLY_APP_PREFIX" json:"app_prefix"`
+}
@@ -0,0 +1,33 @@
+// Package fly is a Fly.io Machines-backed provider.Provider.
+//
+// Each ctrlplane Instance maps to one Fly App named
+// <AppPrefix>-<instanceID> in the configured organization, holding one
+// Machine per replica in the configured region. An app per instance
+// keeps fly-proxy routing, private DNS (<app>.internal) and deletion
+// scoped to the instance.
+//
+// Mapping from the container model:
+//
+//   - A single-service workload runs as the Machine's own image
+//     (config.image, with Command/Args as init.entrypoint/init.cmd).
+//     A multi-service workload becomes a multi-container Machine: one
+//     entry in config.containers per ServiceSpec. Init containers run
+//     once (restart policy "no") and every other container depends on
+//     them exiting successfully; DependsOn becomes a "started"
+//     dependency.
+//   - The Machine guest is sized from the sum of the long-lived
+//     services' CPU and memory, rounded up to what Fly offers.
+//   - PortSpecs become Machine services: Container is the internal
+//     port, Host (when set) the public port fly-proxy listens on.
+//     Public traffic additionally needs an IP allocated to the app.
+//   - HealthCheck becomes a Machine check (HTTP when it has a Path,
+//     TCP otherwise). ConfigFiles are written as Machine files.
+//   - Scale sets the number of Machines. Deploy and RollbackRelease
+//     update one Machine at a time and wait for each to start again;
+//     Fly restarts a Machine's containers together, so a partial
+//     deploy restarts the services it didn't list as well.
+//
+// Logs are read from the Fly logs API (api.fly.io), which reports per
+// Machine rather than per container. Volumes, GPUs and registry
+// credentials other than Fly's own registry are not mapped.
+package fly
@@ -0,0 +1,114 @@
+package fly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// execTimeout is the command timeout, in seconds, passed to the
+// Machines API exec endpoint. The caller's ctx can end it sooner.
+const execTimeout = 300
+
+// execStdinLimit caps how much of cmd.Stdin is forwarded. The exec
+// endpoint takes stdin as one string field, not a stream.
+const execStdinLimit = 1 << 20
+
+// Exec runs a command in one service of the instance's oldest
+// started Machine (cmd.ServiceName, defaulting to the Main service)
+// through the Machines API exec endpoint, and blocks until it exits.
+//
+// The endpoint is request/response: cmd.Stdin is read up front (at
+// most 1MiB) and sent with the command, and interactive TTY sessions
+// aren't possible — use `fly ssh console` for those.
+func (p *Provider) Exec(ctx context.Context, instanceID id.ID, cmd provider.ExecRequest) (*provider.ExecResult, error) {
+	if len(cmd.Command) == 0 {
+		return nil, errors.New("fly: exec requires a command")
+	}
+
+	if cmd.TTY {
+		return nil, fmt.Errorf("fly: exec: interactive TTY sessions: %w", ctrlplane.ErrNotImplemented)
+	}
+
+	app := p.appName(instanceID)
+
+	machines, err := p.listMachines(ctx, app)
+	if err != nil {
+		return nil, fmt.Errorf("fly: exec: list machines: %w", err)
+	}
+
+	var target *flyMachine
+
+	for i := range machines {
+		if machines[i].State == machineStarted {
+			target = &machines[i]
+
+			break
+		}
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("fly: exec: app %s has no started machine", app)
+	}
+
+	container, err := execContainer(target.Config, cmd.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("fly: exec: %w", err)
+	}
+
+	body := flyExecRequest{Command: cmd.Command, Container: container, Timeout: execTimeout}
+
+	if cmd.Stdin != nil {
+		stdin, err := io.ReadAll(io.LimitReader(cmd.Stdin, execStdinLimit))
+		if err != nil {
+			return nil, fmt.Errorf("fly: exec: read stdin: %w", err)
+		}
+
+		body.Stdin = string(stdin)
+	}
+
+	var res flyExecResponse
+	if err := p.do(ctx, p.streamClient, p.cfg.Address, http.MethodPost, machinePath(app, target.ID, "/exec"), body, &res); err != nil {
+		return nil, fmt.Errorf("fly: exec in machine %s: %w", target.ID, err)
+	}
+
+	return &provider.ExecResult{
+		ExitCode: res.ExitCode,
+		Stdout:   []byte(res.Stdout),
+		Stderr:   []byte(res.Stderr),
+	}, nil
+}
+
+// execContainer resolves serviceName to the container an exec
+// targets. Empty picks the Main service. A single-container Machine
+// has no container names, so the result is empty once the name is
+// validated.
+func execContainer(cfg flyMachineConfig, serviceName string) (string, error) {
+	main := cfg.Metadata[metaMain]
+
+	if len(cfg.Containers) == 0 {
+		if serviceName != "" && serviceName != main {
+			return "", fmt.Errorf("service %q not found", serviceName)
+		}
+
+		return "", nil
+	}
+
+	if serviceName == "" {
+		serviceName = main
+	}
+
+	for _, c := range cfg.Containers {
+		if c.Name == serviceName {
+			return c.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("service %q not found", serviceName)
+}
@@ -0,0 +1,362 @@
+package fly
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFly is an in-memory stand-in for the Machines API and the logs
+// endpoint of the platform API. Machines change state immediately, so
+// /wait answers at once.
+type fakeFly struct {
+	mu       sync.Mutex
+	apps     map[string][]*flyMachine
+	logs     map[string][]flyLogEntry
+	execs    []flyExecRequest
+	requests []string
+	seq      int
+}
+
+// newFakeFly starts a fake API and returns it with a provider pointed
+// at it for both the Machines and the logs endpoints.
+func newFakeFly(t *testing.T) (*fakeFly, *Provider) {
+	t.Helper()
+
+	f := &fakeFly{
+		apps: make(map[string][]*flyMachine),
+		logs: make(map[string][]flyLogEntry),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/apps", f.listApps)
+	mux.HandleFunc("POST /v1/apps", f.createApp)
+	mux.HandleFunc("DELETE /v1/apps/{app}", f.deleteApp)
+	mux.HandleFunc("GET /v1/apps/{app}/machines", f.listMachines)
+	mux.HandleFunc("POST /v1/apps/{app}/machines", f.createMachine)
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}", f.updateMachine)
+	mux.HandleFunc("DELETE /v1/apps/{app}/machines/{id}", f.destroyMachine)
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/{action}", f.machineAction)
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}/wait", f.wait)
+	mux.HandleFunc("GET /api/v1/apps/{app}/logs", f.appLogs)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+
+			return
+		}
+
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+		f.mu.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	p, err := New(WithAddress(srv.URL), WithLogsAddress(srv.URL), WithToken("test-token"), WithRegion("lhr"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	p.logPoll = 10 * time.Millisecond
+
+	return f, p
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// machines returns a snapshot of app's Machines.
+func (f *fakeFly) machines(app string) []flyMachine {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([]flyMachine, 0, len(f.apps[app]))
+	for _, m := range f.apps[app] {
+		out = append(out, *m)
+	}
+
+	return out
+}
+
+// setChecks replaces every Machine's check results in app.
+func (f *fakeFly) setChecks(app string, checks []flyCheckStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, m := range f.apps[app] {
+		m.Checks = checks
+	}
+}
+
+// addLog appends a log line to app.
+func (f *fakeFly) addLog(app, ts, level, msg string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var e flyLogEntry
+	e.ID = fmt.Sprintf("log-%d", len(f.logs[app]))
+	e.Attributes.Timestamp = ts
+	e.Attributes.Level = level
+	e.Attributes.Message = msg
+
+	f.logs[app] = append(f.logs[app], e)
+}
+
+func (f *fakeFly) listApps(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{"total_apps": len(f.apps), "apps": []any{}})
+}
+
+func (f *fakeFly) createApp(w http.ResponseWriter, r *http.Request) {
+	var body flyApp
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.apps[body.AppName]; ok {
+		writeError(w, http.StatusConflict, "app already exists")
+
+		return
+	}
+
+	f.apps[body.AppName] = nil
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *fakeFly) deleteApp(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	app := r.PathValue("app")
+	if _, ok := f.apps[app]; !ok {
+		writeError(w, http.StatusNotFound, "app not found")
+
+		return
+	}
+
+	delete(f.apps, app)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (f *fakeFly) listMachines(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	machines, ok := f.apps[r.PathValue("app")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "app not found")
+
+		return
+	}
+
+	writeJSON(w, machines)
+}
+
+func (f *fakeFly) createMachine(w http.ResponseWriter, r *http.Request) {
+	var body flyCreateMachine
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	app := r.PathValue("app")
+	if _, ok := f.apps[app]; !ok {
+		writeError(w, http.StatusNotFound, "app not found")
+
+		return
+	}
+
+	f.seq++
+	m := &flyMachine{
+		ID:         fmt.Sprintf("m%04d", f.seq),
+		State:      machineStarted,
+		Region:     body.Region,
+		InstanceID: fmt.Sprintf("v%d", f.seq),
+		Config:     body.Config,
+		CreatedAt:  time.Date(2026, 1, 1, 0, 0, f.seq, 0, time.UTC).Format(time.RFC3339),
+	}
+
+	if body.SkipLaunch {
+		m.State = machineStopped
+	}
+
+	f.apps[app] = append(f.apps[app], m)
+	writeJSON(w, m)
+}
+
+// machine finds the Machine a request names, answering 404 itself
+// when there is none. Call with f.mu held.
+func (f *fakeFly) machine(w http.ResponseWriter, r *http.Request) *flyMachine {
+	for _, m := range f.apps[r.PathValue("app")] {
+		if m.ID == r.PathValue("id") {
+			return m
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "machine not found")
+
+	return nil
+}
+
+func (f *fakeFly) updateMachine(w http.ResponseWriter, r *http.Request) {
+	var body flyCreateMachine
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.machine(w, r)
+	if m == nil {
+		return
+	}
+
+	f.seq++
+	m.Config = body.Config
+	m.InstanceID = fmt.Sprintf("v%d", f.seq)
+
+	if !body.SkipLaunch {
+		m.State = machineStarted
+	}
+
+	writeJSON(w, m)
+}
+
+func (f *fakeFly) destroyMachine(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.machine(w, r) == nil {
+		return
+	}
+
+	app := r.PathValue("app")
+	machines := f.apps[app][:0]
+
+	for _, m := range f.apps[app] {
+		if m.ID != r.PathValue("id") {
+			machines = append(machines, m)
+		}
+	}
+
+	f.apps[app] = machines
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+func (f *fakeFly) machineAction(w http.ResponseWriter, r *http.Request) {
+	var exec flyExecRequest
+
+	if r.PathValue("action") == "exec" {
+		if err := json.NewDecoder(r.Body).Decode(&exec); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.machine(w, r)
+	if m == nil {
+		return
+	}
+
+	switch r.PathValue("action") {
+	case "start", "restart":
+		m.State = machineStarted
+	case "stop":
+		m.State = machineStopped
+	case "exec":
+		f.execs = append(f.execs, exec)
+
+		if m.State != machineStarted {
+			writeError(w, http.StatusPreconditionFailed, "machine not started")
+
+			return
+		}
+
+		writeJSON(w, flyExecResponse{Stdout: strings.Join(exec.Command, " ") + "\n" + exec.Stdin})
+
+		return
+	default:
+		writeError(w, http.StatusNotFound, "unknown action")
+
+		return
+	}
+
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+func (f *fakeFly) wait(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.machine(w, r)
+	if m == nil {
+		return
+	}
+
+	if m.State != r.URL.Query().Get("state") {
+		writeError(w, http.StatusRequestTimeout, "timed out waiting for state")
+
+		return
+	}
+
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+// appLogs serves the app's lines from next_token (an index) on; with
+// no token it serves every line, as the latest page.
+func (f *fakeFly) appLogs(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	app := r.PathValue("app")
+	if _, ok := f.apps[app]; !ok {
+		writeError(w, http.StatusNotFound, "app not found")
+
+		return
+	}
+
+	start := 0
+	if tok := r.URL.Query().Get("next_token"); tok != "" {
+		_, _ = fmt.Sscanf(tok, "%d", &start)
+	}
+
+	entries := f.logs[app]
+	start = min(start, len(entries))
+
+	var page flyLogPage
+	page.Data = entries[start:]
+	page.Meta.NextToken = fmt.Sprintf("%d", len(entries))
+
+	writeJSON(w, page)
+}
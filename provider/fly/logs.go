@@ -0,0 +1,192 @@
+package fly
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// logPollInterval is how often a follow stream asks the logs API for
+// lines newer than the last page. The API is paged, not pushed.
+const logPollInterval = time.Second
+
+// flyLogPage is one page of GET /api/v1/apps/{app}/logs. NextToken
+// resumes after the page's last entry.
+type flyLogPage struct {
+	Data []flyLogEntry `json:"data"`
+	Meta struct {
+		NextToken string `json:"next_token"`
+	} `json:"meta"`
+}
+
+// flyLogEntry is one log line of a flyLogPage.
+type flyLogEntry struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Timestamp string `json:"timestamp"`
+		Message   string `json:"message"`
+		Level     string `json:"level"`
+		Instance  string `json:"instance"`
+		Region    string `json:"region"`
+	} `json:"attributes"`
+}
+
+// Logs streams the instance's logs from the Fly logs API. Fly
+// collects logs per Machine, not per container, so opts.ServiceName
+// is validated against the Machine config but every service's lines
+// are returned. Lines logged at error level are reported as stderr.
+//
+// Without opts.Follow the latest page is returned, trimmed to the
+// last opts.Tail lines. With it, the stream keeps polling for newer
+// pages until ctx ends or the reader is closed. opts.Since filters on
+// the timestamp Fly records for each line.
+func (p *Provider) Logs(ctx context.Context, instanceID id.ID, opts provider.LogOptions) (io.ReadCloser, error) {
+	app := p.appName(instanceID)
+
+	machines, err := p.listMachines(ctx, app)
+	if err != nil {
+		return nil, fmt.Errorf("fly: logs: list machines: %w", err)
+	}
+
+	if len(machines) == 0 {
+		return nil, fmt.Errorf("fly: logs: app %s has no machines", app)
+	}
+
+	if opts.ServiceName != "" {
+		if _, err := execContainer(machines[0].Config, opts.ServiceName); err != nil {
+			return nil, fmt.Errorf("fly: logs: %w", err)
+		}
+	}
+
+	// Fetch the first page up front so a bad token or missing app
+	// fails the call rather than ending the stream.
+	page, err := p.logPage(ctx, app, "")
+	if err != nil {
+		return nil, fmt.Errorf("fly: logs %s: %w", app, err)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	r, w := io.Pipe()
+
+	go func() {
+		defer w.Close()
+
+		if err := p.streamLogs(streamCtx, w, app, page, opts); err != nil && streamCtx.Err() == nil {
+			// Best-effort: surface the error as a final event so the
+			// consumer sees why the stream ended.
+			_ = provider.WriteLogEvent(w, provider.LogEvent{
+				Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+				Stream:    "stderr",
+				Line:      "ctrlplane/fly: log stream ended: " + err.Error(),
+			})
+		}
+	}()
+
+	return &logStream{PipeReader: r, cancel: cancel}, nil
+}
+
+// logStream stops the polling loop when the consumer closes the
+// reader, so a follow stream doesn't outlive its reader.
+type logStream struct {
+	*io.PipeReader
+
+	cancel context.CancelFunc
+}
+
+// Close stops polling and closes the pipe.
+func (s *logStream) Close() error {
+	s.cancel()
+
+	return s.PipeReader.Close()
+}
+
+// streamLogs writes first, then — when following — every newer page
+// until ctx ends.
+func (p *Provider) streamLogs(ctx context.Context, dst io.Writer, app string, first *flyLogPage, opts provider.LogOptions) error {
+	entries := first.Data
+	if opts.Tail > 0 && len(entries) > opts.Tail {
+		entries = entries[len(entries)-opts.Tail:]
+	}
+
+	if err := writeLogEntries(dst, entries, opts.Since); err != nil {
+		return err
+	}
+
+	if !opts.Follow {
+		return nil
+	}
+
+	token := first.Meta.NextToken
+	ticker := time.NewTicker(p.logPoll)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		page, err := p.logPage(ctx, app, token)
+		if err != nil {
+			return err
+		}
+
+		if err := writeLogEntries(dst, page.Data, opts.Since); err != nil {
+			return err
+		}
+
+		if page.Meta.NextToken != "" {
+			token = page.Meta.NextToken
+		}
+	}
+}
+
+// logPage fetches the app's log page after token (empty = latest).
+func (p *Provider) logPage(ctx context.Context, app, token string) (*flyLogPage, error) {
+	endpoint := "/api/v1/apps/" + url.PathEscape(app) + "/logs"
+	if token != "" {
+		endpoint += "?next_token=" + url.QueryEscape(token)
+	}
+
+	var page flyLogPage
+	if err := p.do(ctx, p.client, p.cfg.LogsAddress, http.MethodGet, endpoint, nil, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// writeLogEntries writes one provider.LogEvent per entry, skipping
+// entries stamped before since. Pure function so it's testable
+// without the API.
+func writeLogEntries(dst io.Writer, entries []flyLogEntry, since time.Time) error {
+	for _, e := range entries {
+		a := e.Attributes
+
+		if !since.IsZero() {
+			if t, err := time.Parse(time.RFC3339Nano, a.Timestamp); err == nil && t.Before(since) {
+				continue
+			}
+		}
+
+		stream := "stdout"
+		if strings.EqualFold(a.Level, "error") {
+			stream = "stderr"
+		}
+
+		if err := provider.WriteLogEvent(dst, provider.LogEvent{Timestamp: a.Timestamp, Stream: stream, Line: a.Message}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
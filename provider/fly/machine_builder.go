@@ -0,0 +1,315 @@
+package fly
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Guest sizing bounds. Shared-CPU Machines come in power-of-two CPU
+// counts with memory in 256MB steps, up to 2GB per CPU.
+const (
+	guestCPUKind      = "shared"
+	guestMemoryStep   = 256
+	guestMemoryPerCPU = 2048
+	guestMaxCPUs      = 16
+)
+
+// Restart policies.
+const (
+	restartAlways = "always"
+	restartNever  = "no"
+)
+
+// appName returns the Fly app an instance runs in. Fly app names are
+// lowercase letters, digits and dashes.
+func appName(prefix string, instanceID id.ID) string {
+	return prefix + "-" + strings.ReplaceAll(instanceID.String(), "_", "-")
+}
+
+// buildMachineConfig translates a ProvisionRequest into the config
+// every Machine of the instance is created with. Pure function so
+// it's testable without the API.
+func buildMachineConfig(req provider.ProvisionRequest, main *provider.ServiceSpec) flyMachineConfig {
+	cfg := flyMachineConfig{
+		Image:   main.Image,
+		Guest:   guestFor(req.Services),
+		Restart: &flyRestart{Policy: restartAlways},
+		Metadata: map[string]string{
+			metaInstance: req.InstanceID.String(),
+			metaTenant:   req.TenantID,
+			metaMain:     main.Name,
+		},
+	}
+
+	for _, svc := range req.Services {
+		if svc.Role == provider.RoleInit {
+			continue
+		}
+
+		cfg.Services = append(cfg.Services, portServices(svc.Ports)...)
+
+		if check, ok := healthCheck(svc); ok {
+			if cfg.Checks == nil {
+				cfg.Checks = make(map[string]flyCheck)
+			}
+
+			cfg.Checks[svc.Name] = check
+		}
+	}
+
+	if len(req.Services) == 1 {
+		cfg.Env = maps.Clone(main.Env)
+		cfg.Files = configFiles(main.ConfigFiles)
+
+		if len(main.Command) > 0 || len(main.Args) > 0 {
+			cfg.Init = &flyInit{Entrypoint: main.Command, Cmd: main.Args}
+		}
+
+		return cfg
+	}
+
+	var inits []string
+
+	for _, svc := range req.Services {
+		if svc.Role == provider.RoleInit {
+			inits = append(inits, svc.Name)
+		}
+	}
+
+	for _, svc := range req.Services {
+		c := flyContainer{
+			Name:       svc.Name,
+			Image:      svc.Image,
+			Env:        maps.Clone(svc.Env),
+			Entrypoint: svc.Command,
+			Cmd:        svc.Args,
+			Files:      configFiles(svc.ConfigFiles),
+			Restart:    &flyRestart{Policy: restartAlways},
+		}
+
+		if svc.Role == provider.RoleInit {
+			c.Restart = &flyRestart{Policy: restartNever}
+		} else {
+			for _, name := range inits {
+				c.DependsOn = append(c.DependsOn, flyDependency{Name: name, Condition: "exited_successfully"})
+			}
+		}
+
+		for _, dep := range svc.DependsOn {
+			c.DependsOn = append(c.DependsOn, flyDependency{Name: dep, Condition: "started"})
+		}
+
+		cfg.Containers = append(cfg.Containers, c)
+	}
+
+	return cfg
+}
+
+// guestFor sizes a Machine to hold every long-lived service: CPU and
+// memory are summed, then rounded up to the nearest shared-CPU size.
+func guestFor(services []provider.ServiceSpec) *flyGuest {
+	var millis, memory int
+
+	for _, svc := range services {
+		if svc.Role == provider.RoleInit {
+			continue
+		}
+
+		millis += svc.Resources.CPUMillis
+		memory += svc.Resources.MemoryMB
+	}
+
+	memory = max(guestMemoryStep, (memory+guestMemoryStep-1)/guestMemoryStep*guestMemoryStep)
+
+	cpus := 1
+	for cpus < guestMaxCPUs && (cpus*1000 < millis || cpus*guestMemoryPerCPU < memory) {
+		cpus *= 2
+	}
+
+	return &flyGuest{CPUKind: guestCPUKind, CPUs: cpus, MemoryMB: min(memory, cpus*guestMemoryPerCPU)}
+}
+
+// portServices exposes each port through fly-proxy. Only ports with a
+// Host port get a public listener; 443 terminates TLS and 80 speaks
+// HTTP, anything else is passed through as raw TCP.
+func portServices(ports []provider.PortSpec) []flyService {
+	out := make([]flyService, 0, len(ports))
+
+	for _, p := range ports {
+		svc := flyService{
+			Protocol:     strings.ToLower(p.Protocol),
+			InternalPort: p.Container,
+		}
+
+		if svc.Protocol == "" {
+			svc.Protocol = "tcp"
+		}
+
+		if p.Host > 0 {
+			port := flyPort{Port: p.Host}
+
+			switch p.Host {
+			case 443:
+				port.Handlers = []string{"tls", "http"}
+			case 80:
+				port.Handlers = []string{"http"}
+			}
+
+			svc.Ports = []flyPort{port}
+		}
+
+		out = append(out, svc)
+	}
+
+	return out
+}
+
+// healthCheck maps a service's HealthCheck to a Machine check probing
+// its port — the check's own Port, else the service's first port.
+func healthCheck(svc provider.ServiceSpec) (flyCheck, bool) {
+	hc := svc.HealthCheck
+	if hc == nil {
+		return flyCheck{}, false
+	}
+
+	port := hc.Port
+	if port == 0 && len(svc.Ports) > 0 {
+		port = svc.Ports[0].Container
+	}
+
+	if port == 0 {
+		return flyCheck{}, false
+	}
+
+	check := flyCheck{Type: "tcp", Port: port}
+	if hc.Path != "" {
+		check.Type = "http"
+		check.Path = hc.Path
+	}
+
+	if hc.Interval > 0 {
+		check.Interval = hc.Interval.String()
+	}
+
+	if hc.Timeout > 0 {
+		check.Timeout = hc.Timeout.String()
+	}
+
+	return check, true
+}
+
+// configFiles turns config files into Machine files. Files without
+// content (a Release snapshot's vault references) are skipped.
+func configFiles(files []provider.ConfigFile) []flyFile {
+	var out []flyFile
+
+	for _, f := range files {
+		if f.Content == "" {
+			continue
+		}
+
+		out = append(out, flyFile{
+			GuestPath: f.Path,
+			RawValue:  base64.StdEncoding.EncodeToString([]byte(f.Content)),
+		})
+	}
+
+	return out
+}
+
+// serviceUpdate is one service's new image, env and files, applied to
+// a Machine config by Deploy and RollbackRelease.
+type serviceUpdate struct {
+	name  string
+	image string
+	env   map[string]string
+	files []provider.ConfigFile
+
+	// replaceEnv replaces the env outright instead of merging into
+	// it, so a rollback drops variables added after the Release.
+	replaceEnv bool
+}
+
+// applyUpdates patches cfg with updates. A single-container Machine
+// runs the service recorded as its Main; a multi-container Machine is
+// patched per container. It returns an error naming a service the
+// Machine doesn't run. Pure function so it's testable without the
+// API.
+func applyUpdates(cfg *flyMachineConfig, updates []serviceUpdate) error {
+	for _, u := range updates {
+		if len(cfg.Containers) == 0 {
+			if u.name != cfg.Metadata[metaMain] {
+				return fmt.Errorf("service %q not found", u.name)
+			}
+
+			cfg.Image = u.image
+			cfg.Env = mergeEnv(cfg.Env, u.env, u.replaceEnv)
+
+			if files := configFiles(u.files); len(files) > 0 {
+				cfg.Files = files
+			}
+
+			continue
+		}
+
+		found := false
+
+		for i := range cfg.Containers {
+			c := &cfg.Containers[i]
+			if c.Name != u.name {
+				continue
+			}
+
+			found = true
+			c.Image = u.image
+			c.Env = mergeEnv(c.Env, u.env, u.replaceEnv)
+
+			if files := configFiles(u.files); len(files) > 0 {
+				c.Files = files
+			}
+
+			if c.Name == cfg.Metadata[metaMain] {
+				cfg.Image = u.image
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("service %q not found", u.name)
+		}
+	}
+
+	return nil
+}
+
+// mergeEnv returns env updated with next: merged in (a deploy), or
+// replacing it (a rollback). A nil next leaves env alone either way.
+func mergeEnv(env, next map[string]string, replace bool) map[string]string {
+	if next == nil {
+		return env
+	}
+
+	if replace || env == nil {
+		return maps.Clone(next)
+	}
+
+	maps.Copy(env, next)
+
+	return env
+}
+
+// pickMain finds the first Main service (default-Role-is-Main)
+// in a slice. Returns nil for empty slices or all-Sidecar/Init slices.
+func pickMain(services []provider.ServiceSpec) *provider.ServiceSpec {
+	for i := range services {
+		if services[i].Role == provider.RoleMain || services[i].Role == "" {
+			return &services[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package fly
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestBuildMachineConfig_SingleService verifies a lone service runs
+// as the Machine's own image with Command/Args as the init override.
+func TestBuildMachineConfig_SingleService(t *testing.T) {
+	t.Parallel()
+
+	req := provider.ProvisionRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services: []provider.ServiceSpec{{
+			Name:        "web",
+			Image:       "web:1",
+			Command:     []string{"/bin/web"},
+			Args:        []string{"--port", "8080"},
+			Env:         map[string]string{"A": "1"},
+			Ports:       []provider.PortSpec{{Container: 8080, Host: 443}},
+			HealthCheck: &provider.HealthCheckSpec{Path: "/healthz", Interval: 10 * time.Second},
+			ConfigFiles: []provider.ConfigFile{{Path: "/etc/web.conf", Content: "x=1"}},
+		}},
+	}
+
+	cfg := buildMachineConfig(req, &req.Services[0])
+
+	if cfg.Image != "web:1" || len(cfg.Containers) != 0 {
+		t.Fatalf("image = %q, containers = %d; want web:1 and none", cfg.Image, len(cfg.Containers))
+	}
+
+	if cfg.Init == nil || cfg.Init.Entrypoint[0] != "/bin/web" || len(cfg.Init.Cmd) != 2 {
+		t.Fatalf("init = %+v", cfg.Init)
+	}
+
+	if len(cfg.Services) != 1 || cfg.Services[0].InternalPort != 8080 || cfg.Services[0].Ports[0].Handlers[0] != "tls" {
+		t.Fatalf("services = %+v", cfg.Services)
+	}
+
+	if c := cfg.Checks["web"]; c.Type != "http" || c.Port != 8080 || c.Path != "/healthz" || c.Interval != "10s" {
+		t.Fatalf("check = %+v", c)
+	}
+
+	if len(cfg.Files) != 1 || cfg.Files[0].RawValue != base64.StdEncoding.EncodeToString([]byte("x=1")) {
+		t.Fatalf("files = %+v", cfg.Files)
+	}
+}
+
+// TestBuildMachineConfig_InitAndDependencies verifies init containers
+// run once and gate every long-lived container, and DependsOn maps to
+// a started dependency.
+func TestBuildMachineConfig_InitAndDependencies(t *testing.T) {
+	t.Parallel()
+
+	req := provider.ProvisionRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services: []provider.ServiceSpec{
+			{Name: "migrate", Image: "web:1", Role: provider.RoleInit},
+			{Name: "web", Image: "web:1", DependsOn: []string{"proxy"}},
+			{Name: "proxy", Image: "envoy:1", Role: provider.RoleSidecar},
+		},
+	}
+
+	cfg := buildMachineConfig(req, &req.Services[1])
+
+	if len(cfg.Containers) != 3 {
+		t.Fatalf("containers = %d, want 3", len(cfg.Containers))
+	}
+
+	if cfg.Containers[0].Restart.Policy != restartNever {
+		t.Fatalf("init restart = %q, want %q", cfg.Containers[0].Restart.Policy, restartNever)
+	}
+
+	web := cfg.Containers[1]
+	if len(web.DependsOn) != 2 ||
+		web.DependsOn[0] != (flyDependency{Name: "migrate", Condition: "exited_successfully"}) ||
+		web.DependsOn[1] != (flyDependency{Name: "proxy", Condition: "started"}) {
+		t.Fatalf("web depends_on = %+v", web.DependsOn)
+	}
+
+	if got := machineServices(cfg); len(got) != 2 || got[0] != "web" || got[1] != "proxy" {
+		t.Fatalf("machineServices = %v, want [web proxy]", got)
+	}
+}
+
+// TestGuestFor_RoundsUp verifies the guest is sized from the sum of
+// the long-lived services and rounded up to a shared-CPU size.
+func TestGuestFor_RoundsUp(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		services []provider.ServiceSpec
+		cpus     int
+		memory   int
+	}{
+		{"empty", nil, 1, 256},
+		{"memory step", []provider.ServiceSpec{{Resources: provider.ResourceSpec{MemoryMB: 300}}}, 1, 512},
+		{"cpu sum", []provider.ServiceSpec{
+			{Resources: provider.ResourceSpec{CPUMillis: 800}},
+			{Resources: provider.ResourceSpec{CPUMillis: 500}},
+			{Role: provider.RoleInit, Resources: provider.ResourceSpec{CPUMillis: 4000}},
+		}, 2, 256},
+		{"memory per cpu", []provider.ServiceSpec{{Resources: provider.ResourceSpec{MemoryMB: 3000}}}, 2, 3072},
+	}
+
+	for _, tc := range cases {
+		g := guestFor(tc.services)
+		if g.CPUs != tc.cpus || g.MemoryMB != tc.memory {
+			t.Errorf("%s: guest = %d cpus / %dMB, want %d / %dMB", tc.name, g.CPUs, g.MemoryMB, tc.cpus, tc.memory)
+		}
+	}
+}
+
+// TestApplyUpdates_UnknownService verifies an update naming a service
+// the Machine doesn't run fails.
+func TestApplyUpdates_UnknownService(t *testing.T) {
+	t.Parallel()
+
+	single := flyMachineConfig{Image: "web:1", Metadata: map[string]string{metaMain: "web"}}
+	if err := applyUpdates(&single, []serviceUpdate{{name: "worker", image: "w:2"}}); err == nil {
+		t.Fatal("single-container update of unknown service succeeded")
+	}
+
+	multi := flyMachineConfig{
+		Image:      "web:1",
+		Metadata:   map[string]string{metaMain: "web"},
+		Containers: []flyContainer{{Name: "web", Image: "web:1"}, {Name: "proxy", Image: "envoy:1"}},
+	}
+
+	if err := applyUpdates(&multi, []serviceUpdate{{name: "web", image: "web:2"}}); err != nil {
+		t.Fatalf("applyUpdates: %v", err)
+	}
+
+	if multi.Image != "web:2" || multi.Containers[0].Image != "web:2" || multi.Containers[1].Image != "envoy:1" {
+		t.Fatalf("config after update = %+v", multi)
+	}
+
+	if err := applyUpdates(&multi, []serviceUpdate{{name: "worker", image: "w:2"}}); err == nil {
+		t.Fatal("multi-container update of unknown service succeeded")
+	}
+}
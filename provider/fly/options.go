@@ -0,0 +1,117 @@
+package fly
+
+import (
+	"fmt"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// Option configures a Fly.io provider.
+type Option func(*Provider) error
+
+// WithAddress sets the Machines API endpoint.
+func WithAddress(addr string) Option {
+	return func(p *Provider) error {
+		if addr == "" {
+			return fmt.Errorf("fly: %w: address must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.Address = addr
+
+		return nil
+	}
+}
+
+// WithLogsAddress sets the Fly platform API endpoint logs are read
+// from.
+func WithLogsAddress(addr string) Option {
+	return func(p *Provider) error {
+		if addr == "" {
+			return fmt.Errorf("fly: %w: logs address must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.LogsAddress = addr
+
+		return nil
+	}
+}
+
+// WithToken sets the Fly.io API token.
+func WithToken(token string) Option {
+	return func(p *Provider) error {
+		p.cfg.Token = token
+
+		return nil
+	}
+}
+
+// WithOrg sets the organization instance apps are created in.
+func WithOrg(slug string) Option {
+	return func(p *Provider) error {
+		if slug == "" {
+			return fmt.Errorf("fly: %w: org slug must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.OrgSlug = slug
+
+		return nil
+	}
+}
+
+// WithRegion sets the region Machines are placed in.
+func WithRegion(region string) Option {
+	return func(p *Provider) error {
+		if region == "" {
+			return fmt.Errorf("fly: %w: region must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.Region = region
+
+		return nil
+	}
+}
+
+// WithAppPrefix sets the prefix of the per-instance Fly app names.
+func WithAppPrefix(prefix string) Option {
+	return func(p *Provider) error {
+		if prefix == "" {
+			return fmt.Errorf("fly: %w: app prefix must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.AppPrefix = prefix
+
+		return nil
+	}
+}
+
+// WithConfig applies all non-zero fields from a Config struct.
+// This is useful when loading configuration from files or environment variables.
+func WithConfig(cfg Config) Option {
+	return func(p *Provider) error {
+		if cfg.Address != "" {
+			p.cfg.Address = cfg.Address
+		}
+
+		if cfg.LogsAddress != "" {
+			p.cfg.LogsAddress = cfg.LogsAddress
+		}
+
+		if cfg.Token != "" {
+			p.cfg.Token = cfg.Token
+		}
+
+		if cfg.OrgSlug != "" {
+			p.cfg.OrgSlug = cfg.OrgSlug
+		}
+
+		if cfg.Region != "" {
+			p.cfg.Region = cfg.Region
+		}
+
+		if cfg.AppPrefix != "" {
+			p.cfg.AppPrefix = cfg.AppPrefix
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,654 @@
+package fly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// waitTimeout is how long Deploy and Restart wait for each Machine to
+// start again, in seconds — the Machines API caps /wait at 60.
+const waitTimeout = 60
+
+// Compile-time check that Provider implements provider.Provider,
+// provider.HealthChecker and provider.Rollbacker.
+var (
+	_ provider.Provider      = (*Provider)(nil)
+	_ provider.HealthChecker = (*Provider)(nil)
+	_ provider.Rollbacker    = (*Provider)(nil)
+)
+
+// Provider is a Fly.io Machines infrastructure provider.
+type Provider struct {
+	cfg Config
+
+	// client bounds ordinary API calls; streamClient has no overall
+	// timeout for calls the caller's ctx bounds instead (exec, log
+	// follow).
+	client       *http.Client
+	streamClient *http.Client
+
+	// logPoll is how often a follow log stream polls for new lines.
+	logPoll time.Duration
+}
+
+// New creates a new Fly.io provider with the given options. Without
+// any options, defaults are used (address: api.machines.dev, org:
+// personal, region: iad, app prefix: cp); a token is needed for any
+// real API call.
+func New(opts ...Option) (*Provider, error) {
+	p := &Provider{
+		cfg: Config{
+			Address:     "https://api.machines.dev",
+			LogsAddress: "https://api.fly.io",
+			OrgSlug:     "personal",
+			Region:      "iad",
+			AppPrefix:   "cp",
+		},
+		client:       &http.Client{Timeout: 30 * time.Second},
+		streamClient: &http.Client{},
+		logPoll:      logPollInterval,
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Info returns metadata about this provider.
+func (p *Provider) Info() provider.ProviderInfo {
+	return provider.ProviderInfo{
+		Name:    "fly",
+		Version: "0.1.0",
+		Region:  p.cfg.Region,
+	}
+}
+
+// Capabilities returns the set of features this provider supports.
+func (p *Provider) Capabilities() []provider.Capability {
+	return []provider.Capability{
+		provider.CapProvision,
+		provider.CapDeploy,
+		provider.CapScale,
+		provider.CapLogs,
+		provider.CapExec,
+		provider.CapRolling,
+	}
+}
+
+// HealthCheck lists the organization's apps, which checks both that
+// the Machines API is reachable and that the token is accepted.
+func (p *Provider) HealthCheck(ctx context.Context) (*provider.HealthStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := p.doRequest(ctx, http.MethodGet, "/v1/apps?org_slug="+url.QueryEscape(p.cfg.OrgSlug), nil, nil)
+	latency := time.Since(start)
+	now := time.Now().UTC()
+
+	if err != nil {
+		return &provider.HealthStatus{
+			Healthy:   false,
+			Message:   fmt.Sprintf("fly machines api unavailable: %v", err),
+			Latency:   latency,
+			CheckedAt: now,
+		}, nil
+	}
+
+	return &provider.HealthStatus{
+		Healthy:   true,
+		Message:   "fly machines api reachable",
+		Latency:   latency,
+		CheckedAt: now,
+	}, nil
+}
+
+// Provision creates the instance's Fly app and one Machine per
+// replica of the Main service in the configured region.
+// Re-provisioning an instance whose app already exists replaces its
+// Machines.
+func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+	if len(req.Services) == 0 {
+		return nil, errors.New("fly: provision requires at least one service")
+	}
+
+	main := pickMain(req.Services)
+	if main == nil {
+		return nil, errors.New("fly: provision requires exactly one Main service")
+	}
+
+	if err := provider.RejectHardPlacement(req.Services); err != nil {
+		return nil, fmt.Errorf("fly: %w", err)
+	}
+
+	app := p.appName(req.InstanceID)
+
+	if err := p.doRequest(ctx, http.MethodPost, "/v1/apps", flyApp{AppName: app, OrgSlug: p.cfg.OrgSlug}, nil); err != nil {
+		if !isConflict(err) {
+			return nil, fmt.Errorf("fly: create app %s: %w", app, err)
+		}
+
+		if err := p.destroyMachines(ctx, app, 0); err != nil {
+			return nil, fmt.Errorf("fly: re-provision %s: %w", app, err)
+		}
+	}
+
+	cfg := buildMachineConfig(req, main)
+
+	for range max(main.Resources.Replicas, 1) {
+		if err := p.createMachine(ctx, app, p.cfg.Region, cfg); err != nil {
+			return nil, fmt.Errorf("fly: create machine in %s: %w", app, err)
+		}
+	}
+
+	serviceRefs := make(map[string]string, len(req.Services))
+	for _, svc := range req.Services {
+		serviceRefs[svc.Name] = app + "/" + svc.Name
+	}
+
+	return &provider.ProvisionResult{
+		ProviderRef: "fly:" + app,
+		ServiceRefs: serviceRefs,
+		Endpoints:   endpoints(app, req.Services),
+		Metadata:    map[string]string{"app": app, "region": p.cfg.Region},
+	}, nil
+}
+
+// Deprovision deletes the instance's app, which destroys its
+// Machines with it.
+func (p *Provider) Deprovision(ctx context.Context, instanceID id.ID) error {
+	app := p.appName(instanceID)
+
+	if err := p.doRequest(ctx, http.MethodDelete, appPath(app, "?force=true"), nil, nil); err != nil {
+		// 404 = already gone — convergent.
+		if isNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("fly: delete app %s: %w", app, err)
+	}
+
+	return nil
+}
+
+// Start starts every stopped or suspended Machine of the instance.
+func (p *Provider) Start(ctx context.Context, instanceID id.ID) error {
+	return p.eachMachine(ctx, instanceID, "start", func(app string, m flyMachine) error {
+		switch m.State {
+		case machineStopped, machineSuspended, machineCreated:
+			return p.doRequest(ctx, http.MethodPost, machinePath(app, m.ID, "/start"), nil, nil)
+		default:
+			return nil
+		}
+	})
+}
+
+// Stop stops every running Machine of the instance. Fly keeps the
+// Machines, so Start brings the instance back as it was.
+func (p *Provider) Stop(ctx context.Context, instanceID id.ID) error {
+	return p.eachMachine(ctx, instanceID, "stop", func(app string, m flyMachine) error {
+		switch m.State {
+		case machineStarted, machineStarting:
+			return p.doRequest(ctx, http.MethodPost, machinePath(app, m.ID, "/stop"), nil, nil)
+		default:
+			return nil
+		}
+	})
+}
+
+// Restart restarts the running Machines one at a time, waiting for
+// each to start again before moving on.
+func (p *Provider) Restart(ctx context.Context, instanceID id.ID) error {
+	return p.eachMachine(ctx, instanceID, "restart", func(app string, m flyMachine) error {
+		if m.State != machineStarted {
+			return nil
+		}
+
+		if err := p.doRequest(ctx, http.MethodPost, machinePath(app, m.ID, "/restart"), nil, nil); err != nil {
+			return err
+		}
+
+		return p.waitStarted(ctx, app, m)
+	})
+}
+
+// Status lists the instance's Machines and aggregates their state.
+// Worst-of state wins. Fly restarts a Machine's containers together,
+// so every service reports the aggregate; Replicas counts Machines
+// and ReadyReplicas those started with no critical check.
+func (p *Provider) Status(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
+	app := p.appName(instanceID)
+
+	machines, err := p.listMachines(ctx, app)
+	if err != nil {
+		if isNotFound(err) {
+			return &provider.InstanceStatus{State: provider.StateDestroyed}, nil
+		}
+
+		return nil, fmt.Errorf("fly: list machines: %w", err)
+	}
+
+	if len(machines) == 0 {
+		return &provider.InstanceStatus{State: provider.StateProvisioning}, nil
+	}
+
+	var (
+		ready   int
+		message string
+		states  = make([]provider.InstanceState, 0, len(machines))
+	)
+
+	for _, m := range machines {
+		st := mapMachineState(m.State)
+		states = append(states, st)
+
+		msg, healthy := machineHealth(m)
+		if st == provider.StateRunning && healthy {
+			ready++
+		}
+
+		if message == "" && msg != "" {
+			message = msg
+		}
+	}
+
+	state := aggregateState(states)
+	if state == provider.StateRunning && ready == 0 {
+		state = provider.StateStarting
+	}
+
+	services := make(map[string]provider.ServiceStatus)
+	for _, name := range machineServices(machines[0].Config) {
+		services[name] = provider.ServiceStatus{
+			State:         state,
+			Ready:         state == provider.StateRunning,
+			ProviderRef:   app + "/" + name,
+			Message:       message,
+			Replicas:      len(machines),
+			ReadyReplicas: ready,
+		}
+	}
+
+	return &provider.InstanceStatus{
+		State:         state,
+		Ready:         state == provider.StateRunning,
+		Message:       message,
+		Services:      services,
+		Metadata:      map[string]string{"app": app, "region": machines[0].Region},
+		Replicas:      len(machines),
+		ReadyReplicas: ready,
+	}, nil
+}
+
+// Deploy rolls the listed services' new image and env out to the
+// instance's Machines, one Machine at a time. Services not listed
+// keep their image, but restart with their Machine.
+func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*provider.DeployResult, error) {
+	if len(req.Services) == 0 {
+		return nil, errors.New("fly: deploy requires at least one service")
+	}
+
+	updates := make([]serviceUpdate, len(req.Services))
+	for i, s := range req.Services {
+		updates[i] = serviceUpdate{name: s.Name, image: s.Image, env: s.Env, files: s.ConfigFiles}
+	}
+
+	if err := p.rollout(ctx, req.InstanceID, req.ReleaseID, updates); err != nil {
+		return nil, fmt.Errorf("fly: deploy: %w", err)
+	}
+
+	return &provider.DeployResult{
+		ProviderRef: "fly:" + p.appName(req.InstanceID),
+		Status:      "deployed",
+	}, nil
+}
+
+// Rollback needs the Release snapshot: Fly keeps no release history
+// the provider could revert to. deploy.Service calls RollbackRelease
+// with the snapshot instead.
+func (p *Provider) Rollback(_ context.Context, instanceID id.ID, releaseID id.ID) error {
+	return fmt.Errorf("fly: rollback %s to release %s: snapshot required, use RollbackRelease", p.appName(instanceID), releaseID)
+}
+
+// RollbackRelease rolls the Release's images and env out like
+// Deploy. Env is replaced outright: a variable added after the
+// Release must not survive a rollback to it.
+func (p *Provider) RollbackRelease(ctx context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	if len(req.Services) == 0 {
+		return nil, fmt.Errorf("fly: rollback to release %s: snapshot required", req.ReleaseID)
+	}
+
+	updates := make([]serviceUpdate, len(req.Services))
+	for i, s := range req.Services {
+		updates[i] = serviceUpdate{name: s.Name, image: s.Image, env: s.Env, files: s.ConfigFiles, replaceEnv: true}
+	}
+
+	if err := p.rollout(ctx, req.InstanceID, req.ReleaseID, updates); err != nil {
+		return nil, fmt.Errorf("fly: rollback to release %s: %w", req.ReleaseID, err)
+	}
+
+	return &provider.DeployResult{
+		ProviderRef: "fly:" + p.appName(req.InstanceID),
+		Status:      "rolled_back",
+	}, nil
+}
+
+// Scale sets the instance's Machine count to spec.Replicas. New
+// Machines are cloned from the newest one's config; surplus Machines
+// are destroyed newest first. Guest size is set at provision time
+// and not changed by Scale.
+func (p *Provider) Scale(ctx context.Context, instanceID id.ID, spec provider.ResourceSpec) error {
+	if spec.Replicas < 1 {
+		return fmt.Errorf("fly: scale: %w: replicas must be at least 1, use Stop to stop an instance", ctrlplane.ErrInvalidConfig)
+	}
+
+	app := p.appName(instanceID)
+
+	machines, err := p.listMachines(ctx, app)
+	if err != nil {
+		return fmt.Errorf("fly: scale: list machines: %w", err)
+	}
+
+	if len(machines) == 0 {
+		return fmt.Errorf("fly: scale: app %s has no machine to clone", app)
+	}
+
+	template := machines[len(machines)-1]
+
+	for n := len(machines); n < spec.Replicas; n++ {
+		if err := p.createMachine(ctx, app, template.Region, template.Config); err != nil {
+			return fmt.Errorf("fly: scale: create machine: %w", err)
+		}
+	}
+
+	if err := p.destroyMachines(ctx, app, spec.Replicas); err != nil {
+		return fmt.Errorf("fly: scale: %w", err)
+	}
+
+	return nil
+}
+
+// Resources reports the memory the instance's running Machines are
+// sized for. The Machines API exposes no usage samples — those come
+// from Fly's Prometheus endpoint — so usage fields stay zero.
+func (p *Provider) Resources(ctx context.Context, instanceID id.ID) (*provider.ResourceUsage, error) {
+	machines, err := p.listMachines(ctx, p.appName(instanceID))
+	if err != nil {
+		return nil, fmt.Errorf("fly: resources: list machines: %w", err)
+	}
+
+	usage := &provider.ResourceUsage{}
+
+	for _, m := range machines {
+		if m.State == machineStarted && m.Config.Guest != nil {
+			usage.MemoryLimitMB += m.Config.Guest.MemoryMB
+		}
+	}
+
+	return usage, nil
+}
+
+// appName returns the instance's Fly app.
+func (p *Provider) appName(instanceID id.ID) string {
+	return appName(p.cfg.AppPrefix, instanceID)
+}
+
+// rollout applies updates to every Machine of the instance, one at a
+// time. Every Machine's config is patched before the first is
+// updated, so an update naming an unknown service fails without
+// touching anything. Stopped Machines are updated without being
+// launched.
+func (p *Provider) rollout(ctx context.Context, instanceID, releaseID id.ID, updates []serviceUpdate) error {
+	app := p.appName(instanceID)
+
+	machines, err := p.listMachines(ctx, app)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+
+	if len(machines) == 0 {
+		return fmt.Errorf("app %s has no machines", app)
+	}
+
+	for i := range machines {
+		cfg := &machines[i].Config
+		if err := applyUpdates(cfg, updates); err != nil {
+			return fmt.Errorf("machine %s: %w", machines[i].ID, err)
+		}
+
+		if cfg.Metadata == nil {
+			cfg.Metadata = make(map[string]string, 1)
+		}
+
+		cfg.Metadata[metaRelease] = releaseID.String()
+	}
+
+	for _, m := range machines {
+		stopped := m.State == machineStopped || m.State == machineSuspended
+
+		var updated flyMachine
+		if err := p.doRequest(ctx, http.MethodPost, machinePath(app, m.ID, ""),
+			flyCreateMachine{Region: m.Region, Config: m.Config, SkipLaunch: stopped}, &updated); err != nil {
+			return fmt.Errorf("update machine %s: %w", m.ID, err)
+		}
+
+		if stopped {
+			continue
+		}
+
+		if err := p.waitStarted(ctx, app, updated); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eachMachine runs fn on every Machine of the instance, stopping at
+// the first error. op names the calling operation in errors.
+func (p *Provider) eachMachine(ctx context.Context, instanceID id.ID, op string, fn func(app string, m flyMachine) error) error {
+	app := p.appName(instanceID)
+
+	machines, err := p.listMachines(ctx, app)
+	if err != nil {
+		return fmt.Errorf("fly: %s: list machines: %w", op, err)
+	}
+
+	for _, m := range machines {
+		if err := fn(app, m); err != nil {
+			return fmt.Errorf("fly: %s machine %s: %w", op, m.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// listMachines returns the app's Machines, oldest first. Destroyed
+// Machines still listed by the API are left out.
+func (p *Provider) listMachines(ctx context.Context, app string) ([]flyMachine, error) {
+	var machines []flyMachine
+	if err := p.doRequest(ctx, http.MethodGet, appPath(app, "/machines"), nil, &machines); err != nil {
+		return nil, err
+	}
+
+	machines = slices.DeleteFunc(machines, func(m flyMachine) bool {
+		return m.State == machineDestroyed || m.State == machineDestroying
+	})
+
+	// created_at is RFC3339, so string order is time order; ID breaks
+	// ties between Machines created in the same second.
+	slices.SortFunc(machines, func(a, b flyMachine) int {
+		return strings.Compare(a.CreatedAt+a.ID, b.CreatedAt+b.ID)
+	})
+
+	return machines, nil
+}
+
+// createMachine launches one Machine with cfg.
+func (p *Provider) createMachine(ctx context.Context, app, region string, cfg flyMachineConfig) error {
+	return p.doRequest(ctx, http.MethodPost, appPath(app, "/machines"), flyCreateMachine{Region: region, Config: cfg}, nil)
+}
+
+// destroyMachines destroys the app's newest Machines until keep
+// remain.
+func (p *Provider) destroyMachines(ctx context.Context, app string, keep int) error {
+	machines, err := p.listMachines(ctx, app)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+
+	for i := len(machines) - 1; i >= keep; i-- {
+		err := p.doRequest(ctx, http.MethodDelete, machinePath(app, machines[i].ID, "?force=true"), nil, nil)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("destroy machine %s: %w", machines[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// waitStarted blocks until m reaches the started state.
+func (p *Provider) waitStarted(ctx context.Context, app string, m flyMachine) error {
+	q := url.Values{}
+	q.Set("state", machineStarted)
+	q.Set("timeout", strconv.Itoa(waitTimeout))
+
+	if m.InstanceID != "" {
+		q.Set("instance_id", m.InstanceID)
+	}
+
+	if err := p.doRequest(ctx, http.MethodGet, machinePath(app, m.ID, "/wait?"+q.Encode()), nil, nil); err != nil {
+		return fmt.Errorf("wait for machine %s to start: %w", m.ID, err)
+	}
+
+	return nil
+}
+
+// mapMachineState translates a Machine state into ctrlplane's
+// InstanceState.
+func mapMachineState(s string) provider.InstanceState {
+	switch s {
+	case machineStarted:
+		return provider.StateRunning
+	case machineCreated, machineStarting, machineReplacing, machineUpdating:
+		return provider.StateStarting
+	case machineStopping:
+		return provider.StateStopping
+	case machineStopped, machineSuspended:
+		return provider.StateStopped
+	case machineFailed:
+		return provider.StateFailed
+	default:
+		return provider.StateStarting
+	}
+}
+
+// aggregateState folds per-Machine states worst-first: any failure,
+// then anything in flight, then all stopped; otherwise running.
+func aggregateState(states []provider.InstanceState) provider.InstanceState {
+	for _, worst := range []provider.InstanceState{provider.StateFailed, provider.StateStarting, provider.StateStopping} {
+		if slices.Contains(states, worst) {
+			return worst
+		}
+	}
+
+	if !slices.Contains(states, provider.StateRunning) {
+		return provider.StateStopped
+	}
+
+	return provider.StateRunning
+}
+
+// machineHealth reports whether none of the Machine's checks is
+// critical, with the first failing check's output (or a failed
+// Machine's state) as the message.
+func machineHealth(m flyMachine) (string, bool) {
+	for _, c := range m.Checks {
+		if c.Status == "critical" {
+			return fmt.Sprintf("machine %s check %s: %s", m.ID, c.Name, c.Output), false
+		}
+	}
+
+	if m.State == machineFailed {
+		return "machine " + m.ID + " failed", false
+	}
+
+	return "", true
+}
+
+// machineServices returns the services a Machine config runs: its
+// long-lived containers, or the recorded Main service for a
+// single-container Machine.
+func machineServices(cfg flyMachineConfig) []string {
+	if len(cfg.Containers) == 0 {
+		return []string{cfg.Metadata[metaMain]}
+	}
+
+	names := make([]string, 0, len(cfg.Containers))
+
+	for _, c := range cfg.Containers {
+		if c.Restart != nil && c.Restart.Policy == restartNever {
+			continue // init container
+		}
+
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+// endpoints lists each long-lived service's ports on the app's
+// private network address, plus the public address for ports with a
+// Host port.
+func endpoints(app string, services []provider.ServiceSpec) []provider.Endpoint {
+	var out []provider.Endpoint
+
+	for _, svc := range services {
+		if svc.Role == provider.RoleInit {
+			continue
+		}
+
+		for _, port := range svc.Ports {
+			out = append(out, provider.Endpoint{
+				ServiceName: svc.Name,
+				URL:         fmt.Sprintf("http://%s.internal:%d", app, port.Container),
+				Port:        port.Container,
+				Protocol:    "http",
+			})
+
+			if port.Host == 0 {
+				continue
+			}
+
+			public := fmt.Sprintf("http://%s.fly.dev:%d", app, port.Host)
+			if port.Host == 443 {
+				public = "https://" + app + ".fly.dev"
+			}
+
+			out = append(out, provider.Endpoint{
+				ServiceName: svc.Name,
+				URL:         public,
+				Port:        port.Host,
+				Protocol:    "http",
+				Public:      true,
+			})
+		}
+	}
+
+	return out
+}
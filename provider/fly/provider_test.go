@@ -0,0 +1,325 @@
+package fly
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/testutil"
+)
+
+// TestConformance certifies the provider against the shared provider
+// contract, with the httptest stand-in as the Machines API.
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	_, p := newFakeFly(t)
+
+	testutil.RunProviderConformance(t, testutil.ProviderConformance{
+		Provider: p,
+		Services: []provider.ServiceSpec{
+			{Name: "web", Image: "nginx:1.25", Ports: []provider.PortSpec{{Container: 80, Host: 443}}},
+			{Name: "agent", Image: "busybox", Role: provider.RoleSidecar, Command: []string{"sleep", "3600"}},
+		},
+		Timeout:      time.Second,
+		PollInterval: 10 * time.Millisecond,
+	})
+}
+
+func provisionTwo(t *testing.T, p *Provider, replicas int) (id.ID, string) {
+	t.Helper()
+
+	instID := id.New(id.PrefixInstance)
+
+	_, err := p.Provision(context.Background(), provider.ProvisionRequest{
+		InstanceID: instID,
+		TenantID:   "tenant-1",
+		Services: []provider.ServiceSpec{
+			{Name: "web", Image: "web:1", Env: map[string]string{"MODE": "a"}, Resources: provider.ResourceSpec{Replicas: replicas}},
+			{Name: "proxy", Image: "envoy:1", Role: provider.RoleSidecar},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	return instID, p.appName(instID)
+}
+
+// TestProvision_MultiServiceMachinesInRegion verifies a multi-service
+// request becomes one multi-container Machine per replica, placed in
+// the configured region and stamped with the instance metadata.
+func TestProvision_MultiServiceMachinesInRegion(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeFly(t)
+	instID, app := provisionTwo(t, p, 2)
+
+	machines := f.machines(app)
+	if len(machines) != 2 {
+		t.Fatalf("machines = %d, want 2", len(machines))
+	}
+
+	for _, m := range machines {
+		if m.Region != "lhr" {
+			t.Errorf("machine %s region = %q, want lhr", m.ID, m.Region)
+		}
+
+		if got := len(m.Config.Containers); got != 2 {
+			t.Errorf("machine %s containers = %d, want 2", m.ID, got)
+		}
+
+		if m.Config.Metadata[metaInstance] != instID.String() || m.Config.Metadata[metaTenant] != "tenant-1" {
+			t.Errorf("machine %s metadata = %v", m.ID, m.Config.Metadata)
+		}
+	}
+
+	if got := p.Info().Region; got != "lhr" {
+		t.Fatalf("Info().Region = %q, want lhr", got)
+	}
+}
+
+// TestScale_SetsMachineCount verifies Scale clones Machines up and
+// destroys the newest ones down.
+func TestScale_SetsMachineCount(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeFly(t)
+	instID, app := provisionTwo(t, p, 1)
+	first := f.machines(app)[0].ID
+
+	if err := p.Scale(context.Background(), instID, provider.ResourceSpec{Replicas: 3}); err != nil {
+		t.Fatalf("Scale up: %v", err)
+	}
+
+	if got := len(f.machines(app)); got != 3 {
+		t.Fatalf("machines after scale up = %d, want 3", got)
+	}
+
+	if err := p.Scale(context.Background(), instID, provider.ResourceSpec{Replicas: 1}); err != nil {
+		t.Fatalf("Scale down: %v", err)
+	}
+
+	machines := f.machines(app)
+	if len(machines) != 1 || machines[0].ID != first {
+		t.Fatalf("machines after scale down = %+v, want only %s", machines, first)
+	}
+
+	if err := p.Scale(context.Background(), instID, provider.ResourceSpec{}); err == nil {
+		t.Fatal("Scale to 0 succeeded, want an error")
+	}
+}
+
+// TestStatus_CriticalCheckNotReady verifies a started Machine with a
+// critical check isn't counted ready and its output surfaces.
+func TestStatus_CriticalCheckNotReady(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeFly(t)
+	instID, app := provisionTwo(t, p, 1)
+
+	f.setChecks(app, []flyCheckStatus{{Name: "web", Status: "critical", Output: "connection refused"}})
+
+	st, err := p.Status(context.Background(), instID)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+
+	if st.Ready || st.State != provider.StateStarting || st.ReadyReplicas != 0 {
+		t.Fatalf("Status = %+v, want starting and not ready", st)
+	}
+
+	if !strings.Contains(st.Message, "connection refused") {
+		t.Fatalf("Message = %q, want the check output", st.Message)
+	}
+
+	if _, ok := st.Services["proxy"]; !ok {
+		t.Fatalf("Services = %v, want a proxy entry", st.Services)
+	}
+}
+
+// TestRollbackRelease_ReplacesEnv verifies a rollback drops variables
+// added after the Release while a deploy merges them.
+func TestRollbackRelease_ReplacesEnv(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeFly(t)
+	instID, app := provisionTwo(t, p, 1)
+	ctx := context.Background()
+
+	if _, err := p.Deploy(ctx, provider.DeployRequest{
+		InstanceID: instID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   []provider.ServiceDeploySpec{{Name: "web", Image: "web:2", Env: map[string]string{"NEW": "1"}}},
+	}); err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	web := f.machines(app)[0].Config.Containers[0]
+	if web.Image != "web:2" || web.Env["MODE"] != "a" || web.Env["NEW"] != "1" {
+		t.Fatalf("after deploy web = %+v, want web:2 with merged env", web)
+	}
+
+	rel := id.New(id.PrefixRelease)
+	if _, err := p.RollbackRelease(ctx, provider.RollbackRequest{
+		InstanceID: instID,
+		ReleaseID:  rel,
+		Services:   []provider.ServiceSnapshot{{Name: "web", Image: "web:1", Env: map[string]string{"MODE": "a"}}},
+	}); err != nil {
+		t.Fatalf("RollbackRelease: %v", err)
+	}
+
+	cfg := f.machines(app)[0].Config
+	if web := cfg.Containers[0]; web.Image != "web:1" || web.Env["NEW"] != "" {
+		t.Fatalf("after rollback web = %+v, want web:1 without NEW", web)
+	}
+
+	if cfg.Metadata[metaRelease] != rel.String() {
+		t.Fatalf("release metadata = %q, want %s", cfg.Metadata[metaRelease], rel)
+	}
+}
+
+// TestExec_TargetsServiceContainer verifies exec runs in the named
+// service's container, defaults to Main and forwards stdin.
+func TestExec_TargetsServiceContainer(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeFly(t)
+	instID, _ := provisionTwo(t, p, 1)
+	ctx := context.Background()
+
+	res, err := p.Exec(ctx, instID, provider.ExecRequest{Command: []string{"cat"}, Stdin: strings.NewReader("hello")})
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	if string(res.Stdout) != "cat\nhello" {
+		t.Fatalf("Stdout = %q", res.Stdout)
+	}
+
+	if _, err := p.Exec(ctx, instID, provider.ExecRequest{ServiceName: "proxy", Command: []string{"true"}}); err != nil {
+		t.Fatalf("Exec proxy: %v", err)
+	}
+
+	if _, err := p.Exec(ctx, instID, provider.ExecRequest{ServiceName: "nope", Command: []string{"true"}}); err == nil {
+		t.Fatal("Exec in unknown service succeeded")
+	}
+
+	f.mu.Lock()
+	execs := f.execs
+	f.mu.Unlock()
+
+	if len(execs) != 2 || execs[0].Container != "web" || execs[1].Container != "proxy" {
+		t.Fatalf("execs = %+v, want web then proxy", execs)
+	}
+}
+
+// TestLogs_TailAndFollow verifies a tailed read returns the last
+// lines and a follow stream picks up lines logged afterwards.
+func TestLogs_TailAndFollow(t *testing.T) {
+	t.Parallel()
+
+	f, p := newFakeFly(t)
+	instID, app := provisionTwo(t, p, 1)
+
+	f.addLog(app, "2026-01-01T00:00:01Z", "info", "one")
+	f.addLog(app, "2026-01-01T00:00:02Z", "error", "two")
+	f.addLog(app, "2026-01-01T00:00:03Z", "info", "three")
+
+	rc, err := p.Logs(context.Background(), instID, provider.LogOptions{Tail: 2})
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+
+	raw, _ := io.ReadAll(rc)
+	_ = rc.Close()
+
+	events := decodeEvents(t, string(raw))
+	if len(events) != 2 || events[0].Line != "two" || events[0].Stream != "stderr" || events[1].Line != "three" {
+		t.Fatalf("tail events = %+v", events)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rc, err = p.Logs(ctx, instID, provider.LogOptions{Follow: true, Since: time.Date(2026, 1, 1, 0, 0, 3, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Logs follow: %v", err)
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+
+	if ev := readEvent(t, br); ev.Line != "three" {
+		t.Fatalf("first followed line = %q, want three", ev.Line)
+	}
+
+	f.addLog(app, "2026-01-01T00:00:04Z", "info", "four")
+
+	if ev := readEvent(t, br); ev.Line != "four" {
+		t.Fatalf("next followed line = %q, want four", ev.Line)
+	}
+
+	if _, err := p.Logs(ctx, instID, provider.LogOptions{ServiceName: "nope"}); err == nil {
+		t.Fatal("Logs for unknown service succeeded")
+	}
+}
+
+// TestHealthCheck verifies a reachable API with an accepted token is
+// healthy and a rejected token is not.
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	_, p := newFakeFly(t)
+
+	st, err := p.HealthCheck(context.Background())
+	if err != nil || !st.Healthy {
+		t.Fatalf("HealthCheck = %+v, %v; want healthy", st, err)
+	}
+
+	p.cfg.Token = "wrong"
+
+	st, err = p.HealthCheck(context.Background())
+	if err != nil || st.Healthy || !strings.Contains(st.Message, "401") {
+		t.Fatalf("HealthCheck with bad token = %+v, %v; want unhealthy with status", st, err)
+	}
+}
+
+func decodeEvents(t *testing.T, raw string) []provider.LogEvent {
+	t.Helper()
+
+	var events []provider.LogEvent
+
+	for line := range strings.SplitSeq(strings.TrimSpace(raw), "\n") {
+		var ev provider.LogEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+
+		events = append(events, ev)
+	}
+
+	return events
+}
+
+func readEvent(t *testing.T, br *bufio.Reader) provider.LogEvent {
+	t.Helper()
+
+	line, err := br.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+
+	var ev provider.LogEvent
+	if err := json.Unmarshal([]byte(line), &ev); err != nil {
+		t.Fatalf("decode %q: %v", line, err)
+	}
+
+	return ev
+}
@@ -0,0 +1,229 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// defaultTargetCPUPercent is the CPU utilization target used when an
+// AutoscaleSpec names neither target — the same default the HPA
+// controller applies to an empty metrics list.
+const defaultTargetCPUPercent = 80
+
+// mainAutoscale returns the Main service's autoscale spec when it is
+// enabled, nil otherwise.
+func mainAutoscale(req provider.ProvisionRequest) *provider.AutoscaleSpec {
+	for i := range req.Services {
+		if req.Services[i].Role == provider.RoleMain || req.Services[i].Role == "" {
+			if a := req.Services[i].Resources.Autoscale; a.Enabled() {
+				return a
+			}
+
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// buildHPA creates an autoscaling/v2 HorizontalPodAutoscaler that
+// scales the instance's Deployment or StatefulSet (targetKind) by
+// average CPU and/or memory utilization.
+func buildHPA(instanceID id.ID, namespace, targetKind string, labels map[string]string, spec provider.AutoscaleSpec) *autoscalingv2.HorizontalPodAutoscaler {
+	name := deploymentName(instanceID)
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: hpaSpec(name, targetKind, spec),
+	}
+}
+
+// hpaSpec maps an AutoscaleSpec onto an HPA spec targeting name.
+func hpaSpec(name, targetKind string, spec provider.AutoscaleSpec) autoscalingv2.HorizontalPodAutoscalerSpec {
+	cpu, memory := spec.TargetCPUPercent, spec.TargetMemoryPercent
+	if cpu == 0 && memory == 0 {
+		cpu = defaultTargetCPUPercent
+	}
+
+	var metrics []autoscalingv2.MetricSpec
+
+	if cpu > 0 {
+		metrics = append(metrics, utilizationMetric(corev1.ResourceCPU, cpu))
+	}
+
+	if memory > 0 {
+		metrics = append(metrics, utilizationMetric(corev1.ResourceMemory, memory))
+	}
+
+	minReplicas := clampInt32(spec.MinReplicas)
+
+	return autoscalingv2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       targetKind,
+			Name:       name,
+		},
+		MinReplicas: &minReplicas,
+		MaxReplicas: clampInt32(spec.MaxReplicas),
+		Metrics:     metrics,
+	}
+}
+
+// utilizationMetric targets an average utilization percentage of the
+// named resource's requests across the instance's pods.
+func utilizationMetric(name corev1.ResourceName, percent int) autoscalingv2.MetricSpec {
+	utilization := clampInt32(percent)
+
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: &utilization,
+			},
+		},
+	}
+}
+
+// clampInt32 converts n to int32, saturating at the int32 bounds.
+func clampInt32(n int) int32 {
+	const maxInt32 = int(^uint32(0) >> 1)
+
+	return int32(min(max(n, -maxInt32-1), maxInt32)) //nolint:gosec // clamped to int32 range above
+}
+
+// applyAutoscaler creates the instance's HPA or brings an existing one
+// in line with spec. The target kind and labels are read off whichever
+// workload object the instance runs as.
+func (p *Provider) applyAutoscaler(ctx context.Context, instanceID id.ID, spec provider.AutoscaleSpec) error {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	name := deploymentName(instanceID)
+	hpas := p.client.AutoscalingV2().HorizontalPodAutoscalers(ns)
+
+	targetKind, labels, err := p.scaleTarget(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	existing, err := hpas.Get(ctx, name, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err := hpas.Create(ctx, buildHPA(instanceID, ns, targetKind, labels, spec), metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("kubernetes: create autoscaler: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("kubernetes: get autoscaler: %w", err)
+	default:
+		existing.Spec = hpaSpec(name, targetKind, spec)
+
+		if _, err := hpas.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("kubernetes: update autoscaler: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteAutoscaler removes the instance's HPA. NotFound is success —
+// the instance was never autoscaled or already isn't.
+func (p *Provider) deleteAutoscaler(ctx context.Context, instanceID id.ID) error {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	err = p.client.AutoscalingV2().HorizontalPodAutoscalers(ns).
+		Delete(ctx, deploymentName(instanceID), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("kubernetes: delete autoscaler: %w", err)
+	}
+
+	return nil
+}
+
+// scaleTarget reports whether the instance runs as a Deployment or a
+// StatefulSet, along with that object's labels.
+func (p *Provider) scaleTarget(ctx context.Context, instanceID id.ID) (string, map[string]string, error) {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return "", nil, err
+	}
+	name := deploymentName(instanceID)
+
+	dep, depErr := p.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	if depErr == nil {
+		return "Deployment", dep.Labels, nil
+	}
+
+	ss, ssErr := p.client.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+	if ssErr != nil {
+		return "", nil, fmt.Errorf("kubernetes: get workload for autoscaler: deployment: %w; statefulset: %w", depErr, ssErr)
+	}
+
+	return "StatefulSet", ss.Labels, nil
+}
+
+// autoscaleStatus reads the instance's HPA into an AutoscaleStatus.
+// Returns nil when the instance has no HPA or it can't be read —
+// Status still reports the workload either way.
+func (p *Provider) autoscaleStatus(ctx context.Context, instanceID id.ID) *provider.AutoscaleStatus {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return nil
+	}
+
+	hpa, err := p.client.AutoscalingV2().HorizontalPodAutoscalers(ns).
+		Get(ctx, deploymentName(instanceID), metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+
+	st := &provider.AutoscaleStatus{
+		MaxReplicas:     int(hpa.Spec.MaxReplicas),
+		CurrentReplicas: int(hpa.Status.CurrentReplicas),
+		DesiredReplicas: int(hpa.Status.DesiredReplicas),
+	}
+
+	if hpa.Spec.MinReplicas != nil {
+		st.MinReplicas = int(*hpa.Spec.MinReplicas)
+	}
+
+	if hpa.Status.LastScaleTime != nil {
+		t := hpa.Status.LastScaleTime.UTC()
+		st.LastScaleTime = &t
+	}
+
+	for _, m := range hpa.Status.CurrentMetrics {
+		if m.Resource == nil || m.Resource.Current.AverageUtilization == nil {
+			continue
+		}
+
+		percent := int(*m.Resource.Current.AverageUtilization)
+
+		switch m.Resource.Name {
+		case corev1.ResourceCPU:
+			st.CPUPercent = &percent
+		case corev1.ResourceMemory:
+			st.MemoryPercent = &percent
+		}
+	}
+
+	return st
+}
@@ -0,0 +1,239 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+func autoscaledRequest(instID id.ID, kind provider.WorkloadKind) provider.ProvisionRequest {
+	return provider.ProvisionRequest{
+		InstanceID: instID,
+		TenantID:   "ten_abc",
+		Kind:       kind,
+		Services: []provider.ServiceSpec{{
+			Name:  "web",
+			Image: "web:1",
+			Role:  provider.RoleMain,
+			Resources: provider.ResourceSpec{
+				CPUMillis: 250,
+				MemoryMB:  256,
+				Replicas:  1,
+				Autoscale: &provider.AutoscaleSpec{MinReplicas: 2, MaxReplicas: 6, TargetMemoryPercent: 70},
+			},
+		}},
+	}
+}
+
+// TestProvision_CreatesAutoscaler verifies an autoscaled Main service
+// gets an HPA targeting its Deployment and starts within the bounds.
+func TestProvision_CreatesAutoscaler(t *testing.T) {
+	instID := id.New(id.PrefixInstance)
+	name := deploymentName(instID)
+	ctx := context.Background()
+
+	const ns = "default"
+
+	p := &Provider{cfg: Config{Namespace: ns}, client: k8sfake.NewSimpleClientset()}
+
+	if _, err := p.Provision(ctx, autoscaledRequest(instID, provider.KindDeployment)); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	dep, err := p.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+
+	if *dep.Spec.Replicas != 2 {
+		t.Errorf("deployment replicas = %d, want 2 (clamped to min)", *dep.Spec.Replicas)
+	}
+
+	hpa, err := p.client.AutoscalingV2().HorizontalPodAutoscalers(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get hpa: %v", err)
+	}
+
+	ref := hpa.Spec.ScaleTargetRef
+	if ref.Kind != "Deployment" || ref.Name != name || ref.APIVersion != "apps/v1" {
+		t.Errorf("scale target = %+v", ref)
+	}
+
+	if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 6 {
+		t.Errorf("bounds = %d..%d, want 2..6", *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+
+	if len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Resource.Name != corev1.ResourceMemory ||
+		*hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != 70 {
+		t.Errorf("metrics = %+v, want memory at 70%%", hpa.Spec.Metrics)
+	}
+
+	if hpa.Labels[labelInstanceID] != instID.String() {
+		t.Errorf("hpa labels = %v", hpa.Labels)
+	}
+
+	if err := p.Deprovision(ctx, instID); err != nil {
+		t.Fatalf("Deprovision: %v", err)
+	}
+
+	if _, err := p.client.AutoscalingV2().HorizontalPodAutoscalers(ns).Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("hpa after deprovision: err = %v, want NotFound", err)
+	}
+}
+
+// TestProvision_StatefulSetAutoscaler verifies the HPA targets the
+// StatefulSet for stateful instances.
+func TestProvision_StatefulSetAutoscaler(t *testing.T) {
+	instID := id.New(id.PrefixInstance)
+	ctx := context.Background()
+
+	p := &Provider{cfg: Config{Namespace: "default"}, client: k8sfake.NewSimpleClientset()}
+
+	if _, err := p.Provision(ctx, autoscaledRequest(instID, provider.KindStatefulSet)); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	hpa, err := p.client.AutoscalingV2().HorizontalPodAutoscalers("default").Get(ctx, deploymentName(instID), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get hpa: %v", err)
+	}
+
+	if hpa.Spec.ScaleTargetRef.Kind != "StatefulSet" {
+		t.Errorf("scale target kind = %s, want StatefulSet", hpa.Spec.ScaleTargetRef.Kind)
+	}
+}
+
+// TestScale_Autoscale verifies Scale creates and updates the HPA while
+// leaving the replica count alone, and that turning autoscaling off
+// removes the HPA. (The fake clientset can't serve the scale
+// subresource, so the disable step carries no Replicas.)
+func TestScale_Autoscale(t *testing.T) {
+	instID := id.New(id.PrefixInstance)
+	name := deploymentName(instID)
+	ctx := context.Background()
+
+	const ns = "default"
+
+	replicas := int32(3)
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: map[string]string{labelInstanceID: instID.String()}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+
+	p := &Provider{cfg: Config{Namespace: ns}, client: k8sfake.NewSimpleClientset(dep)}
+	hpas := p.client.AutoscalingV2().HorizontalPodAutoscalers(ns)
+
+	spec := provider.ResourceSpec{Replicas: 5, Autoscale: &provider.AutoscaleSpec{MinReplicas: 1, MaxReplicas: 4}}
+	if err := p.Scale(ctx, instID, spec); err != nil {
+		t.Fatalf("Scale (enable): %v", err)
+	}
+
+	hpa, err := hpas.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get hpa: %v", err)
+	}
+
+	if m := hpa.Spec.Metrics; len(m) != 1 || m[0].Resource.Name != corev1.ResourceCPU ||
+		*m[0].Resource.Target.AverageUtilization != defaultTargetCPUPercent {
+		t.Errorf("metrics = %+v, want default CPU target", m)
+	}
+
+	spec.Autoscale = &provider.AutoscaleSpec{MinReplicas: 2, MaxReplicas: 10, TargetCPUPercent: 50}
+	if err := p.Scale(ctx, instID, spec); err != nil {
+		t.Fatalf("Scale (update): %v", err)
+	}
+
+	hpa, err = hpas.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get hpa: %v", err)
+	}
+
+	if hpa.Spec.MaxReplicas != 10 || *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != 50 {
+		t.Errorf("hpa not updated: %+v", hpa.Spec)
+	}
+
+	got, _ := p.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	if *got.Spec.Replicas != 3 {
+		t.Errorf("replicas = %d, want 3 (owned by the HPA)", *got.Spec.Replicas)
+	}
+
+	if err := p.Scale(ctx, instID, provider.ResourceSpec{}); err != nil {
+		t.Fatalf("Scale (disable): %v", err)
+	}
+
+	if _, err := hpas.Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("hpa after disable: err = %v, want NotFound", err)
+	}
+}
+
+// TestStatus_ReportsAutoscaling verifies Status carries the desired
+// replica count and the HPA's live view.
+func TestStatus_ReportsAutoscaling(t *testing.T) {
+	instID := id.New(id.PrefixInstance)
+	name := deploymentName(instID)
+
+	const ns = "default"
+
+	desired, minReplicas, cpu := int32(4), int32(2), int32(91)
+	scaledAt := metav1.NewTime(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec:       appsv1.DeploymentSpec{Replicas: &desired},
+		Status:     appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 2},
+	}
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec:       autoscalingv2.HorizontalPodAutoscalerSpec{MinReplicas: &minReplicas, MaxReplicas: 8},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 3,
+			DesiredReplicas: 4,
+			LastScaleTime:   &scaledAt,
+			CurrentMetrics: []autoscalingv2.MetricStatus{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricStatus{
+					Name:    corev1.ResourceCPU,
+					Current: autoscalingv2.MetricValueStatus{AverageUtilization: &cpu},
+				},
+			}},
+		},
+	}
+
+	p := &Provider{cfg: Config{Namespace: ns}, client: k8sfake.NewSimpleClientset(dep, hpa)}
+
+	st, err := p.Status(context.Background(), instID)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+
+	if st.Replicas != 3 || st.ReadyReplicas != 2 || st.DesiredReplicas != 4 {
+		t.Errorf("replicas = %d/%d/%d, want 3/2/4", st.Replicas, st.ReadyReplicas, st.DesiredReplicas)
+	}
+
+	as := st.Autoscale
+	if as == nil {
+		t.Fatal("Autoscale = nil, want HPA status")
+	}
+
+	if as.MinReplicas != 2 || as.MaxReplicas != 8 || as.CurrentReplicas != 3 || as.DesiredReplicas != 4 {
+		t.Errorf("autoscale = %+v", as)
+	}
+
+	if as.CPUPercent == nil || *as.CPUPercent != 91 || as.MemoryPercent != nil {
+		t.Errorf("utilization = %v/%v, want cpu 91", as.CPUPercent, as.MemoryPercent)
+	}
+
+	if as.LastScaleTime == nil || !as.LastScaleTime.Equal(scaledAt.Time) {
+		t.Errorf("last scale time = %v", as.LastScaleTime)
+	}
+}
@@ -43,6 +43,19 @@ type Config struct {
 	// Use CP_K8S_IMAGE_PULL_SECRETS (comma-separated) to configure via env.
 	ImagePullSecrets []string `env:"CP_K8S_IMAGE_PULL_SECRETS" json:"image_pull_secrets,omitempty"`
 
+	// TenantNamespaces gives every tenant its own namespace, named
+	// Namespace + "-" + the tenant ID and created on the tenant's first
+	// provision with a ResourceQuota from the tenant's quota and a
+	// NetworkPolicy admitting only same-namespace and ingress traffic.
+	// Namespace then holds the ImagePullSecrets copied into each tenant
+	// namespace and the manifest tracking ConfigMaps.
+	TenantNamespaces bool `env:"CP_K8S_TENANT_NAMESPACES" json:"tenant_namespaces,omitempty"`
+
+	// IngressNamespace is the namespace the cluster's ingress controller
+	// runs in. Tenant NetworkPolicies admit traffic from it. Defaults to
+	// "ingress-nginx".
+	IngressNamespace string `default:"ingress-nginx" env:"CP_K8S_INGRESS_NAMESPACE" json:"ingress_namespace,omitempty"`
+
 	// ArgoNamespace is the namespace where ctrlplane creates Argo CD
 	// Application CRs (where Argo CD itself runs). Defaults to "argocd".
 	ArgoNamespace string `default:"argocd" env:"CP_K8S_ARGO_NAMESPACE" json:"argo_namespace,omitempty"`
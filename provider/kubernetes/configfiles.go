@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+const (
+	// configFilesSuffix is appended to per-service config-file
+	// ConfigMap names, next to the "-env" env map.
+	configFilesSuffix = "-files"
+
+	// configFilesVolumePrefix prefixes the pod volume that projects a
+	// service's config-file ConfigMap.
+	configFilesVolumePrefix = "cfg-"
+
+	// annotationConfigChecksumPrefix prefixes the pod-template
+	// annotation holding a service's config-file checksum. ConfigMap
+	// edits don't restart pods on their own (and subPath mounts never
+	// see them), so changing the annotation is what rolls the pods.
+	annotationConfigChecksumPrefix = "ctrlplane.io/config-"
+)
+
+// invalidConfigMapKey matches characters a ConfigMap data key can't hold.
+var invalidConfigMapKey = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// configFilesMapName returns a service's config-file ConfigMap name.
+func configFilesMapName(instanceID id.ID, serviceName string) string {
+	return deploymentName(instanceID) + "-" + serviceName + configFilesSuffix
+}
+
+// configFileKey returns the ConfigMap data key a file is stored under:
+// its Name, or the base of its Path when unnamed, with characters
+// ConfigMap keys reject replaced.
+func configFileKey(f provider.ConfigFile) string {
+	key := f.Name
+	if key == "" {
+		key = path.Base(f.Path)
+	}
+
+	return invalidConfigMapKey.ReplaceAllString(key, "_")
+}
+
+// buildConfigFilesMap builds the ConfigMap holding a service's config
+// files, one data key per file.
+func buildConfigFilesMap(instanceID id.ID, serviceName, namespace string, labels map[string]string, files []provider.ConfigFile) *corev1.ConfigMap {
+	data := make(map[string]string, len(files))
+	for _, f := range files {
+		data[configFileKey(f)] = f.Content
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configFilesMapName(instanceID, serviceName),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Data: data,
+	}
+}
+
+// setServiceConfigFiles wires a service's config files into a pod
+// template: one ConfigMap volume for the service, a read-only subPath
+// mount per file at its Path, and the checksum annotation. Any mounts
+// from a previous file set are replaced, so files dropped from the set
+// stop being mounted. Templates without the service are left alone.
+func setServiceConfigFiles(tmpl *corev1.PodTemplateSpec, instanceID id.ID, serviceName string, files []provider.ConfigFile) {
+	c := findContainer(&tmpl.Spec, serviceName)
+	if c == nil {
+		return
+	}
+
+	volName := configFilesVolumePrefix + serviceName
+
+	c.VolumeMounts = slices.DeleteFunc(c.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name == volName })
+	tmpl.Spec.Volumes = slices.DeleteFunc(tmpl.Spec.Volumes, func(v corev1.Volume) bool { return v.Name == volName })
+
+	for _, f := range files {
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      volName,
+			MountPath: f.Path,
+			SubPath:   configFileKey(f),
+			ReadOnly:  true,
+		})
+	}
+
+	tmpl.Spec.Volumes = append(tmpl.Spec.Volumes, corev1.Volume{
+		Name: volName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configFilesMapName(instanceID, serviceName)},
+			},
+		},
+	})
+
+	if tmpl.Annotations == nil {
+		tmpl.Annotations = make(map[string]string, 1)
+	}
+
+	tmpl.Annotations[annotationConfigChecksumPrefix+serviceName] = provider.ConfigFilesChecksum(files)
+}
+
+// findContainer returns the container or init container named name.
+func findContainer(spec *corev1.PodSpec, name string) *corev1.Container {
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == name {
+			return &spec.Containers[i]
+		}
+	}
+
+	for i := range spec.InitContainers {
+		if spec.InitContainers[i].Name == name {
+			return &spec.InitContainers[i]
+		}
+	}
+
+	return nil
+}
+
+// provisionConfigFiles wires every service's config files into a
+// freshly built workload template.
+func provisionConfigFiles(tmpl *corev1.PodTemplateSpec, req provider.ProvisionRequest) {
+	for i := range req.Services {
+		if len(req.Services[i].ConfigFiles) > 0 {
+			setServiceConfigFiles(tmpl, req.InstanceID, req.Services[i].Name, req.Services[i].ConfigFiles)
+		}
+	}
+}
+
+// applyConfigFileUpdates wires the config files of every update that
+// carries any into tmpl. Updates without files keep their current
+// mounts.
+func applyConfigFileUpdates(tmpl *corev1.PodTemplateSpec, instanceID id.ID, updates []provider.ServiceDeploySpec) {
+	for _, u := range updates {
+		if len(u.ConfigFiles) > 0 {
+			setServiceConfigFiles(tmpl, instanceID, u.Name, u.ConfigFiles)
+		}
+	}
+}
+
+// writeConfigFiles creates or replaces the config-file ConfigMap of
+// every update that carries files.
+func (p *Provider) writeConfigFiles(ctx context.Context, instanceID id.ID, labels map[string]string, updates []provider.ServiceDeploySpec) error {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		if len(u.ConfigFiles) == 0 {
+			continue
+		}
+
+		want := buildConfigFilesMap(instanceID, u.Name, ns, labels, u.ConfigFiles)
+
+		cm, err := p.client.CoreV1().ConfigMaps(ns).Get(ctx, want.Name, metav1.GetOptions{})
+
+		switch {
+		case apierrors.IsNotFound(err):
+			if _, err := p.client.CoreV1().ConfigMaps(ns).Create(ctx, want, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("kubernetes: create configmap %s: %w", want.Name, err)
+			}
+		case err != nil:
+			return fmt.Errorf("kubernetes: get configmap %s: %w", want.Name, err)
+		default:
+			cm.Data = want.Data
+			if _, err := p.client.CoreV1().ConfigMaps(ns).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("kubernetes: update configmap %s: %w", want.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestSetServiceConfigFiles_ReplacesPreviousSet verifies each file is
+// mounted read-only by subPath from the service's ConfigMap volume and
+// that a new set replaces the old mounts and bumps the checksum
+// annotation.
+func TestSetServiceConfigFiles_ReplacesPreviousSet(t *testing.T) {
+	t.Parallel()
+
+	iid := id.New(id.PrefixInstance)
+	tmpl := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}}}
+
+	v1 := []provider.ConfigFile{
+		{Name: "a.conf", Path: "/etc/a.conf", Content: "a"},
+		{Name: "b.conf", Path: "/etc/b.conf", Content: "b"},
+	}
+	v2 := []provider.ConfigFile{{Path: "/etc/app/app.yaml", Content: "v: 2"}}
+
+	setServiceConfigFiles(tmpl, iid, "web", v1)
+	setServiceConfigFiles(tmpl, iid, "web", v2)
+
+	mounts := tmpl.Spec.Containers[0].VolumeMounts
+	if len(mounts) != 1 {
+		t.Fatalf("mounts: want 1, got %+v", mounts)
+	}
+
+	if m := mounts[0]; m.MountPath != "/etc/app/app.yaml" || m.SubPath != "app.yaml" || !m.ReadOnly {
+		t.Fatalf("mount: %+v", m)
+	}
+
+	if len(tmpl.Spec.Volumes) != 1 || tmpl.Spec.Volumes[0].ConfigMap.Name != configFilesMapName(iid, "web") {
+		t.Fatalf("volumes: %+v", tmpl.Spec.Volumes)
+	}
+
+	if got := tmpl.Annotations[annotationConfigChecksumPrefix+"web"]; got != provider.ConfigFilesChecksum(v2) {
+		t.Fatalf("checksum annotation: got %q", got)
+	}
+}
+
+// TestDeploy_WritesConfigFilesMap verifies a deploy carrying config
+// files upserts the files ConfigMap and rolls the pod template onto
+// the new checksum.
+func TestDeploy_WritesConfigFilesMap(t *testing.T) {
+	t.Parallel()
+
+	iid := id.New(id.PrefixInstance)
+	labels := instanceLabels(iid, "ten_test", nil)
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: deploymentName(iid), Namespace: "default", Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "myapp:v1"}}},
+			},
+		},
+	}
+
+	client := k8sfake.NewSimpleClientset(dep)
+	p := &Provider{cfg: Config{Namespace: "default"}, client: client}
+
+	files := []provider.ConfigFile{{Name: "app.yaml", Path: "/etc/app/app.yaml", Content: "v: 2"}}
+
+	if _, err := p.Deploy(context.Background(), provider.DeployRequest{
+		InstanceID: iid,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   []provider.ServiceDeploySpec{{Name: "main", Image: "myapp:v1", ConfigFiles: files}},
+	}); err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), configFilesMapName(iid, "main"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get files configmap: %v", err)
+	}
+
+	if cm.Data["app.yaml"] != "v: 2" {
+		t.Fatalf("configmap data: %v", cm.Data)
+	}
+
+	got, err := client.AppsV1().Deployments("default").Get(context.Background(), deploymentName(iid), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+
+	if got.Spec.Template.Annotations[annotationConfigChecksumPrefix+"main"] != provider.ConfigFilesChecksum(files) {
+		t.Fatalf("template annotations: %v", got.Spec.Template.Annotations)
+	}
+}
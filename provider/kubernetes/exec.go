@@ -0,0 +1,234 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// annotationDefaultContainer is the well-known pod annotation kubectl
+// (and ctrlplane's Exec) use to pick a container when none is named.
+// Stamped on every pod template with the Main service's name so a
+// sidecar declared before Main never becomes the implicit target.
+const annotationDefaultContainer = "kubectl.kubernetes.io/default-container"
+
+// Exec runs a command inside one container of the instance's pod via
+// the API server's pods/exec subresource, streamed over SPDY (the
+// same transport `kubectl exec` uses). cmd.ServiceName picks the
+// container — it's the container name within the Pod — and defaults
+// to the Main service.
+//
+// cmd.Resize feeds the stream's terminal-size queue when cmd.TTY is
+// set. A non-zero exit is not an error: the remote command's status
+// is reported via ExecResult.ExitCode. With cmd.TTY the API server
+// merges stderr into stdout, so Stderr is always empty in that mode.
+func (p *Provider) Exec(ctx context.Context, instanceID id.ID, cmd provider.ExecRequest) (*provider.ExecResult, error) {
+	if len(cmd.Command) == 0 {
+		return nil, errors.New("kubernetes: exec requires a command")
+	}
+
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := p.client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: instanceSelector(instanceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: list pods for exec: %w", err)
+	}
+
+	pod := pickExecPod(pods.Items)
+	if pod == nil {
+		return nil, fmt.Errorf("kubernetes: no running pod found for instance %s", instanceID)
+	}
+
+	containerName, err := p.execContainer(ctx, instanceID, pod, cmd.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &corev1.PodExecOptions{
+		Container: containerName,
+		Command:   cmd.Command,
+		Stdin:     cmd.Stdin != nil,
+		Stdout:    true,
+		Stderr:    !cmd.TTY,
+		TTY:       cmd.TTY,
+	}
+
+	executor, err := p.execExecutor(ns, pod.Name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: build exec stream: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	streamOpts := remotecommand.StreamOptions{
+		Stdin:  cmd.Stdin,
+		Stdout: &stdout,
+		Tty:    cmd.TTY,
+	}
+	if !cmd.TTY {
+		streamOpts.Stderr = &stderr
+	}
+
+	if cmd.TTY && cmd.Resize != nil {
+		resizeCtx, stopResize := context.WithCancel(ctx)
+		defer stopResize()
+
+		streamOpts.TerminalSizeQueue = &resizeQueue{ctx: resizeCtx, ch: cmd.Resize}
+	}
+
+	exitCode := 0
+
+	if err := executor.StreamWithContext(ctx, streamOpts); err != nil {
+		var exitErr utilexec.ExitError
+		if !errors.As(err, &exitErr) || !exitErr.Exited() {
+			return nil, fmt.Errorf("kubernetes: exec in %s/%s: %w", pod.Name, containerName, err)
+		}
+
+		exitCode = exitErr.ExitStatus()
+	}
+
+	return &provider.ExecResult{
+		ExitCode: exitCode,
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+	}, nil
+}
+
+// resizeQueue adapts ExecRequest.Resize to remotecommand's
+// TerminalSizeQueue. Next returning nil ends the resize stream, which
+// happens when the session finishes (ctx) or the caller closes the
+// channel.
+type resizeQueue struct {
+	ctx context.Context //nolint:containedctx // bounds the blocking Next call
+	ch  <-chan provider.TerminalSize
+}
+
+// Next blocks until the next size change.
+func (q *resizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case <-q.ctx.Done():
+		return nil
+	case size, ok := <-q.ch:
+		if !ok {
+			return nil
+		}
+
+		return &remotecommand.TerminalSize{Width: size.Width, Height: size.Height}
+	}
+}
+
+// execExecutor returns the stream executor for one pods/exec call.
+// Tests inject newExecutor to drive Exec without an API server.
+func (p *Provider) execExecutor(namespace, pod string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	if p.newExecutor != nil {
+		return p.newExecutor(namespace, pod, opts)
+	}
+
+	return p.defaultExecutor(namespace, pod, opts)
+}
+
+// defaultExecutor builds an SPDY executor against the cluster's
+// pods/exec subresource.
+func (p *Provider) defaultExecutor(namespace, pod string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	req := p.client.CoreV1().RESTClient().Post().
+		Namespace(namespace).
+		Resource("pods").
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(opts, scheme.ParameterCodec)
+
+	return remotecommand.NewSPDYExecutor(p.restConfig, http.MethodPost, req.URL())
+}
+
+// pickExecPod returns the first Running pod that isn't being deleted.
+// A replica mid-termination would accept the exec and then drop it
+// when the kubelet kills the container.
+func pickExecPod(pods []corev1.Pod) *corev1.Pod {
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning && pods[i].DeletionTimestamp == nil {
+			return &pods[i]
+		}
+	}
+
+	return nil
+}
+
+// execContainer resolves the container an exec targets. The service
+// must match a long-running container — Init services have already
+// exited by the time the pod is Running. Empty falls back to the
+// default-container annotation, then, for pods stamped before it, to
+// the instance's Main service.
+func (p *Provider) execContainer(ctx context.Context, instanceID id.ID, pod *corev1.Pod, serviceName string) (string, error) {
+	if serviceName == "" {
+		serviceName = pod.Annotations[annotationDefaultContainer]
+	}
+
+	if serviceName == "" {
+		main, err := p.mainService(ctx, instanceID)
+		if err != nil {
+			return "", fmt.Errorf("kubernetes: exec: pod %s names no default container: %w", pod.Name, err)
+		}
+
+		serviceName = main
+	}
+
+	found := slices.ContainsFunc(pod.Spec.Containers, func(c corev1.Container) bool {
+		return c.Name == serviceName
+	})
+	if !found {
+		return "", fmt.Errorf("kubernetes: exec: service %q not found in pod %s", serviceName, pod.Name)
+	}
+
+	return serviceName, nil
+}
+
+// SetInstanceServiceResolver sets where an instance's services are
+// read from when a pod doesn't name its Main container. Without one,
+// exec into such a pod needs an explicit service.
+func (p *Provider) SetInstanceServiceResolver(r provider.InstanceServiceResolver) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.services = r
+}
+
+// mainService returns the name of the instance's Main service.
+func (p *Provider) mainService(ctx context.Context, instanceID id.ID) (string, error) {
+	p.mu.RLock()
+	r := p.services
+	p.mu.RUnlock()
+
+	if r == nil {
+		return "", errors.New("no instance service resolver to find the main service")
+	}
+
+	services, err := r.InstanceServices(ctx, instanceID)
+	if err != nil {
+		return "", fmt.Errorf("resolve services of %s: %w", instanceID, err)
+	}
+
+	for i := range services {
+		if services[i].Role == provider.RoleMain || services[i].Role == "" {
+			return services[i].Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("instance %s has no main service", instanceID)
+}
@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// fakeExecutor stands in for the SPDY stream: it echoes stdin to
+// stdout, writes a fixed line to stderr, and exits with exitCode.
+type fakeExecutor struct {
+	exitCode int
+}
+
+func (f *fakeExecutor) Stream(opts remotecommand.StreamOptions) error {
+	return f.StreamWithContext(context.Background(), opts)
+}
+
+func (f *fakeExecutor) StreamWithContext(_ context.Context, opts remotecommand.StreamOptions) error {
+	if opts.Stdin != nil {
+		_, _ = io.Copy(opts.Stdout, opts.Stdin)
+	}
+
+	if opts.Stderr != nil {
+		_, _ = io.WriteString(opts.Stderr, "warn\n")
+	}
+
+	if f.exitCode != 0 {
+		return utilexec.CodeExitError{Err: io.EOF, Code: f.exitCode}
+	}
+
+	return nil
+}
+
+// execTestPod returns a Running pod for instanceID with a sidecar
+// listed ahead of the Main container, as a mesh injector would.
+func execTestPod(instanceID id.ID) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cp-web-abc",
+			Namespace:   "default",
+			Labels:      instanceLabels(instanceID, "ten_test", nil),
+			Annotations: map[string]string{annotationDefaultContainer: "main"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "envoy"}, {Name: "main"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+// TestExec_TargetsServiceAndReportsExitCode verifies Exec resolves the
+// container from ServiceName (falling back to the default-container
+// annotation), captures both streams, and surfaces a non-zero exit as
+// ExitCode rather than an error.
+func TestExec_TargetsServiceAndReportsExitCode(t *testing.T) {
+	t.Parallel()
+
+	instanceID := id.New(id.PrefixInstance)
+
+	var gotContainer string
+
+	p := &Provider{
+		cfg:    Config{Namespace: "default"},
+		client: k8sfake.NewSimpleClientset(execTestPod(instanceID)),
+		newExecutor: func(_, _ string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+			gotContainer = opts.Container
+
+			return &fakeExecutor{exitCode: 3}, nil
+		},
+	}
+
+	res, err := p.Exec(context.Background(), instanceID, provider.ExecRequest{
+		Command: []string{"cat"},
+		Stdin:   strings.NewReader("hello\n"),
+	})
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	if gotContainer != "main" {
+		t.Fatalf("default container: want main, got %q", gotContainer)
+	}
+
+	if res.ExitCode != 3 {
+		t.Fatalf("exit code: want 3, got %d", res.ExitCode)
+	}
+
+	if string(res.Stdout) != "hello\n" || string(res.Stderr) != "warn\n" {
+		t.Fatalf("output: got stdout %q stderr %q", res.Stdout, res.Stderr)
+	}
+
+	if _, err := p.Exec(context.Background(), instanceID, provider.ExecRequest{
+		Command:     []string{"sh"},
+		ServiceName: "envoy",
+	}); err != nil {
+		t.Fatalf("Exec envoy: %v", err)
+	}
+
+	if gotContainer != "envoy" {
+		t.Fatalf("explicit container: want envoy, got %q", gotContainer)
+	}
+
+	if _, err := p.Exec(context.Background(), instanceID, provider.ExecRequest{
+		Command:     []string{"sh"},
+		ServiceName: "missing",
+	}); err == nil {
+		t.Fatal("Exec with unknown service: want error, got nil")
+	}
+}
+
+// servicesStub resolves every instance to the same services.
+type servicesStub []provider.ServiceSpec
+
+func (s servicesStub) InstanceServices(context.Context, id.ID) ([]provider.ServiceSpec, error) {
+	return s, nil
+}
+
+// TestExec_PodWithoutDefaultContainerTargetsMain verifies a pod
+// stamped before the default-container annotation runs exec in the
+// instance's Main service rather than its first container, and that
+// exec fails when Main can't be found instead of guessing.
+func TestExec_PodWithoutDefaultContainerTargetsMain(t *testing.T) {
+	t.Parallel()
+
+	instanceID := id.New(id.PrefixInstance)
+	pod := execTestPod(instanceID)
+	pod.Annotations = nil
+
+	var gotContainer string
+
+	p := &Provider{
+		cfg:    Config{Namespace: "default"},
+		client: k8sfake.NewSimpleClientset(pod),
+		newExecutor: func(_, _ string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+			gotContainer = opts.Container
+
+			return &fakeExecutor{}, nil
+		},
+	}
+
+	req := provider.ExecRequest{Command: []string{"sh"}}
+
+	if _, err := p.Exec(context.Background(), instanceID, req); err == nil {
+		t.Fatal("Exec without a resolver: want error, got nil")
+	}
+
+	p.SetInstanceServiceResolver(servicesStub{
+		{Name: "envoy", Role: provider.RoleSidecar},
+		{Name: "main", Role: provider.RoleMain},
+	})
+
+	if _, err := p.Exec(context.Background(), instanceID, req); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	if gotContainer != "main" {
+		t.Fatalf("container: want main, got %q", gotContainer)
+	}
+
+	p.SetInstanceServiceResolver(servicesStub{{Name: "api", Role: provider.RoleMain}})
+
+	if _, err := p.Exec(context.Background(), instanceID, req); err == nil {
+		t.Fatal("Exec with Main missing from the pod: want error, got nil")
+	}
+}
+
+// TestPodAnnotations_DefaultContainerIsMain verifies the pod template
+// names the Main service as kubectl's default container even when a
+// sidecar is declared first.
+func TestPodAnnotations_DefaultContainerIsMain(t *testing.T) {
+	t.Parallel()
+
+	req := provider.ProvisionRequest{
+		Services: []provider.ServiceSpec{
+			{Name: "envoy", Role: provider.RoleSidecar},
+			{Name: "api", Role: provider.RoleMain},
+		},
+	}
+
+	if got := podAnnotations(req)[annotationDefaultContainer]; got != "api" {
+		t.Fatalf("default container: want api, got %q", got)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/release"
 
+	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/provider"
 )
@@ -21,13 +22,23 @@ func releaseName(instanceID id.ID) string {
 	return deploymentName(instanceID)
 }
 
-// helmNamespace returns the effective namespace for a helm operation.
-func (p *Provider) helmNamespace(reqNamespace string) string {
-	if reqNamespace != "" {
-		return reqNamespace
+// helmNamespace returns the effective namespace for a helm install.
+// With TenantNamespaces set the release goes in the tenant's namespace,
+// and a chart asking for any other namespace is refused.
+func (p *Provider) helmNamespace(ctx context.Context, req provider.HelmInstallRequest) (string, error) {
+	if !p.cfg.TenantNamespaces {
+		if req.Namespace != "" {
+			return req.Namespace, nil
+		}
+
+		return p.cfg.Namespace, nil
+	}
+
+	if req.Namespace != "" && req.Namespace != p.tenantNamespace(req.TenantID) {
+		return "", fmt.Errorf("kubernetes: %w: helm namespace %q is outside the tenant namespace", ctrlplane.ErrInvalidConfig, req.Namespace)
 	}
 
-	return p.cfg.Namespace
+	return p.provisionNamespace(ctx, req.InstanceID, req.TenantID)
 }
 
 // runInstall installs a chart as a new release.
@@ -45,8 +56,11 @@ func runInstall(cfg *action.Configuration, ch *chart.Chart, name, namespace stri
 }
 
 // HelmInstall installs the rendered chart as a new release for the instance.
-func (p *Provider) HelmInstall(_ context.Context, req provider.HelmInstallRequest) (*provider.ProvisionResult, error) {
-	ns := p.helmNamespace(req.Namespace)
+func (p *Provider) HelmInstall(ctx context.Context, req provider.HelmInstallRequest) (*provider.ProvisionResult, error) {
+	ns, err := p.helmNamespace(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
 	cfg, err := p.helmConfig(ns)
 	if err != nil {
@@ -85,8 +99,21 @@ func runUpgrade(cfg *action.Configuration, ch *chart.Chart, name, namespace stri
 }
 
 // HelmUpgrade upgrades the instance's existing release.
-func (p *Provider) HelmUpgrade(_ context.Context, req provider.HelmUpgradeRequest) (*provider.DeployResult, error) {
-	ns := p.helmNamespace(req.Namespace)
+func (p *Provider) HelmUpgrade(ctx context.Context, req provider.HelmUpgradeRequest) (*provider.DeployResult, error) {
+	ns := req.Namespace
+
+	if p.cfg.TenantNamespaces || ns == "" {
+		current, err := p.namespaceFor(ctx, req.InstanceID)
+		if err != nil {
+			return nil, err
+		}
+
+		if ns != "" && ns != current {
+			return nil, fmt.Errorf("kubernetes: %w: helm namespace %q is outside the tenant namespace", ctrlplane.ErrInvalidConfig, ns)
+		}
+
+		ns = current
+	}
 
 	cfg, err := p.helmConfig(ns)
 	if err != nil {
@@ -121,8 +148,13 @@ func runUninstall(cfg *action.Configuration, name string) error {
 }
 
 // HelmUninstall removes the instance's release.
-func (p *Provider) HelmUninstall(_ context.Context, instanceID id.ID) error {
-	cfg, err := p.helmConfig(p.cfg.Namespace)
+func (p *Provider) HelmUninstall(ctx context.Context, instanceID id.ID) error {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := p.helmConfig(ns)
 	if err != nil {
 		return fmt.Errorf("kubernetes: helm config: %w", err)
 	}
@@ -132,6 +164,8 @@ func (p *Provider) HelmUninstall(_ context.Context, instanceID id.ID) error {
 		return fmt.Errorf("kubernetes: helm uninstall %s: %w", name, err)
 	}
 
+	p.forgetNamespace(instanceID)
+
 	return nil
 }
 
@@ -146,8 +180,13 @@ func runStatus(cfg *action.Configuration, name string) (*release.Release, error)
 }
 
 // HelmStatus reports the instance release's state.
-func (p *Provider) HelmStatus(_ context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
-	cfg, err := p.helmConfig(p.cfg.Namespace)
+func (p *Provider) HelmStatus(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := p.helmConfig(ns)
 	if err != nil {
 		return nil, fmt.Errorf("kubernetes: helm config: %w", err)
 	}
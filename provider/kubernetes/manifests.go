@@ -15,6 +15,7 @@ import (
 	"k8s.io/client-go/dynamic"
 	"sigs.k8s.io/yaml"
 
+	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/provider"
 )
@@ -76,11 +77,11 @@ func parseManifests(docs []string) ([]*unstructured.Unstructured, error) {
 	return objs, nil
 }
 
-// resourceFor resolves the dynamic resource interface for an object, using
-// the RESTMapper to map its GVK to a GVR and choosing the namespaced or
-// cluster-scoped interface. A namespaced object with no namespace set
-// inherits (and is stamped with) the provider's default namespace.
-func (p *Provider) resourceFor(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+// placeInNamespace resolves an object's REST mapping and, for a
+// namespaced object, stamps it with ns when it names no namespace and
+// refuses it when it names any other, so manifests only reach the
+// instance's namespace.
+func (p *Provider) placeInNamespace(obj *unstructured.Unstructured, ns string) (*meta.RESTMapping, error) {
 	gvk := obj.GroupVersionKind()
 
 	mapping, err := p.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
@@ -88,19 +89,55 @@ func (p *Provider) resourceFor(obj *unstructured.Unstructured) (dynamic.Resource
 		return nil, fmt.Errorf("kubernetes: rest mapping for %s: %w", gvk, err)
 	}
 
-	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
-		ns := obj.GetNamespace()
-		if ns == "" {
-			ns = p.cfg.Namespace
-			obj.SetNamespace(ns)
-		}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return mapping, nil
+	}
+
+	switch obj.GetNamespace() {
+	case "":
+		obj.SetNamespace(ns)
+	case ns:
+	default:
+		return nil, fmt.Errorf("kubernetes: %w: %s %s targets namespace %s, not the instance namespace %s",
+			ctrlplane.ErrInvalidConfig, obj.GetKind(), obj.GetName(), obj.GetNamespace(), ns)
+	}
+
+	return mapping, nil
+}
+
+// resourceFor resolves the dynamic resource interface for an object in
+// the instance namespace ns, choosing the namespaced or cluster-scoped
+// interface. See placeInNamespace for how the namespace is settled.
+func (p *Provider) resourceFor(obj *unstructured.Unstructured, ns string) (dynamic.ResourceInterface, error) {
+	mapping, err := p.placeInNamespace(obj, ns)
+	if err != nil {
+		return nil, err
+	}
 
-		return p.dynamic.Resource(mapping.Resource).Namespace(ns), nil
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return p.dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
 	}
 
 	return p.dynamic.Resource(mapping.Resource), nil
 }
 
+// confineToNamespace is placeInNamespace that also refuses
+// cluster-scoped objects, so a tenant's manifests can't reach outside
+// its namespace.
+func (p *Provider) confineToNamespace(obj *unstructured.Unstructured, ns string) error {
+	mapping, err := p.placeInNamespace(obj, ns)
+	if err != nil {
+		return err
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return fmt.Errorf("kubernetes: %w: cluster-scoped %s %s is not allowed in a tenant namespace",
+			ctrlplane.ErrInvalidConfig, obj.GetKind(), obj.GetName())
+	}
+
+	return nil
+}
+
 // applyVia creates the object through ri, or updates it in place when it
 // already exists (create-or-update). True server-side apply is deferred to a
 // later iteration; this form is deterministic against the fake dynamic
@@ -130,10 +167,10 @@ func applyVia(ctx context.Context, ri dynamic.ResourceInterface, obj *unstructur
 	return nil
 }
 
-// applyObject resolves the resource interface for an object via the
-// RESTMapper, then applies it create-or-update.
-func (p *Provider) applyObject(ctx context.Context, obj *unstructured.Unstructured) error {
-	ri, err := p.resourceFor(obj)
+// applyObject resolves the resource interface for an object in the
+// instance namespace ns, then applies it create-or-update.
+func (p *Provider) applyObject(ctx context.Context, obj *unstructured.Unstructured, ns string) error {
+	ri, err := p.resourceFor(obj, ns)
 	if err != nil {
 		return err
 	}
@@ -142,8 +179,9 @@ func (p *Provider) applyObject(ctx context.Context, obj *unstructured.Unstructur
 }
 
 // objectRefFor builds the tracking ref for an object, resolving its GVR and
-// effective namespace via the RESTMapper.
-func (p *Provider) objectRefFor(obj *unstructured.Unstructured) (objectRef, error) {
+// effective namespace via the RESTMapper; a namespaced object with no
+// namespace set is in the instance namespace ns.
+func (p *Provider) objectRefFor(obj *unstructured.Unstructured, ns string) (objectRef, error) {
 	gvk := obj.GroupVersionKind()
 
 	mapping, err := p.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
@@ -151,16 +189,19 @@ func (p *Provider) objectRefFor(obj *unstructured.Unstructured) (objectRef, erro
 		return objectRef{}, fmt.Errorf("kubernetes: rest mapping for %s: %w", gvk, err)
 	}
 
-	ns := obj.GetNamespace()
-	if mapping.Scope.Name() == meta.RESTScopeNameNamespace && ns == "" {
-		ns = p.cfg.Namespace
+	refNS := ""
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		refNS = obj.GetNamespace()
+		if refNS == "" {
+			refNS = ns
+		}
 	}
 
 	return objectRef{
 		Group:     mapping.Resource.Group,
 		Version:   mapping.Resource.Version,
 		Resource:  mapping.Resource.Resource,
-		Namespace: ns,
+		Namespace: refNS,
 		Name:      obj.GetName(),
 	}, nil
 }
@@ -178,7 +219,9 @@ func setLabels(obj *unstructured.Unstructured, labels map[string]string) {
 
 // ApplyManifests applies every rendered document for an instance, labels
 // each object, and records the applied refs in a per-instance tracking
-// ConfigMap so they can later be deleted or inspected.
+// ConfigMap so they can later be deleted or inspected. Namespaced objects
+// go in the instance's namespace; one naming another namespace fails the
+// whole apply before anything is written.
 func (p *Provider) ApplyManifests(ctx context.Context, req provider.ManifestApplyRequest) (*provider.ProvisionResult, error) {
 	objs, err := parseManifests(req.Manifests.Docs)
 	if err != nil {
@@ -190,16 +233,33 @@ func (p *Provider) ApplyManifests(ctx context.Context, req provider.ManifestAppl
 	maps.Copy(extra, req.Labels)
 	labels := instanceLabels(req.InstanceID, req.TenantID, extra)
 
+	ns, err := p.provisionNamespace(ctx, req.InstanceID, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objs {
+		if p.cfg.TenantNamespaces {
+			err = p.confineToNamespace(obj, ns)
+		} else {
+			_, err = p.placeInNamespace(obj, ns)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	refs := make([]objectRef, 0, len(objs))
 
 	for _, obj := range objs {
 		setLabels(obj, labels)
 
-		if err := p.applyObject(ctx, obj); err != nil {
+		if err := p.applyObject(ctx, obj, ns); err != nil {
 			return nil, err
 		}
 
-		ref, err := p.objectRefFor(obj)
+		ref, err := p.objectRefFor(obj, ns)
 		if err != nil {
 			return nil, err
 		}
@@ -207,18 +267,19 @@ func (p *Provider) ApplyManifests(ctx context.Context, req provider.ManifestAppl
 		refs = append(refs, ref)
 	}
 
-	if err := p.writeTracking(ctx, req.InstanceID, labels, refs); err != nil {
+	if err := p.writeTracking(ctx, req.InstanceID, ns, labels, refs); err != nil {
 		return nil, err
 	}
 
 	return &provider.ProvisionResult{
-		ProviderRef: providerRef(p.cfg.Namespace, req.InstanceID),
+		ProviderRef: providerRef(ns, req.InstanceID),
 		Metadata:    map[string]string{"objects": strconv.Itoa(len(refs))},
 	}, nil
 }
 
-// writeTracking stores the applied object refs in a per-instance ConfigMap.
-func (p *Provider) writeTracking(ctx context.Context, instanceID id.ID, labels map[string]string, refs []objectRef) error {
+// writeTracking stores the applied object refs in a per-instance
+// ConfigMap in the instance namespace ns.
+func (p *Provider) writeTracking(ctx context.Context, instanceID id.ID, ns string, labels map[string]string, refs []objectRef) error {
 	data, err := json.Marshal(refs)
 	if err != nil {
 		return fmt.Errorf("kubernetes: marshal manifest tracking: %w", err)
@@ -229,19 +290,20 @@ func (p *Provider) writeTracking(ctx context.Context, instanceID id.ID, labels m
 		"kind":       "ConfigMap",
 		"metadata": map[string]any{
 			"name":      trackingName(instanceID),
-			"namespace": p.cfg.Namespace,
+			"namespace": ns,
 		},
 		"data": map[string]any{"refs": string(data)},
 	}}
 	setLabels(cm, labels)
 
-	return p.applyObject(ctx, cm)
+	return p.applyObject(ctx, cm, ns)
 }
 
-// readTracking returns the object refs recorded for an instance, or nil when
-// no tracking ConfigMap exists (nothing was applied, or it was deleted).
-func (p *Provider) readTracking(ctx context.Context, instanceID id.ID) ([]objectRef, error) {
-	cm, err := p.dynamic.Resource(configMapGVR).Namespace(p.cfg.Namespace).
+// readTracking returns the object refs recorded for an instance in its
+// namespace ns, or nil when no tracking ConfigMap exists (nothing was
+// applied, or it was deleted).
+func (p *Provider) readTracking(ctx context.Context, instanceID id.ID, ns string) ([]objectRef, error) {
+	cm, err := p.dynamic.Resource(configMapGVR).Namespace(ns).
 		Get(ctx, trackingName(instanceID), metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -290,7 +352,12 @@ func (p *Provider) deleteRef(ctx context.Context, ref objectRef) error {
 // then the tracking ConfigMap itself. A missing tracking object means there
 // is nothing to delete.
 func (p *Provider) DeleteManifests(ctx context.Context, instanceID id.ID) error {
-	refs, err := p.readTracking(ctx, instanceID)
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	refs, err := p.readTracking(ctx, instanceID, ns)
 	if err != nil {
 		return err
 	}
@@ -305,12 +372,14 @@ func (p *Provider) DeleteManifests(ctx context.Context, instanceID id.ID) error
 		}
 	}
 
-	err = p.dynamic.Resource(configMapGVR).Namespace(p.cfg.Namespace).
+	err = p.dynamic.Resource(configMapGVR).Namespace(ns).
 		Delete(ctx, trackingName(instanceID), metav1.DeleteOptions{})
 	if err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("kubernetes: delete manifest tracking: %w", err)
 	}
 
+	p.forgetNamespace(instanceID)
+
 	return nil
 }
 
@@ -319,7 +388,12 @@ func (p *Provider) DeleteManifests(ctx context.Context, instanceID id.ID) error
 // Running; any missing yields Provisioning. Deep readiness of arbitrary
 // resources is out of scope for this iteration.
 func (p *Provider) ManifestStatus(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
-	refs, err := p.readTracking(ctx, instanceID)
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := p.readTracking(ctx, instanceID, ns)
 	if err != nil {
 		return nil, err
 	}
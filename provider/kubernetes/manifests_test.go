@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"slices"
 	"testing"
 
@@ -14,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 
+	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/provider"
 )
@@ -66,7 +68,7 @@ func TestApplyObject_CreateThenUpdate(t *testing.T) {
 	p := newManifestTestProvider()
 	ctx := context.Background()
 
-	if err := p.applyObject(ctx, cmObject("cm1", map[string]any{"k": "v1"})); err != nil {
+	if err := p.applyObject(ctx, cmObject("cm1", map[string]any{"k": "v1"}), "default"); err != nil {
 		t.Fatalf("create: %v", err)
 	}
 
@@ -79,7 +81,7 @@ func TestApplyObject_CreateThenUpdate(t *testing.T) {
 		t.Errorf("data.k = %q, want v1", data)
 	}
 
-	if err := p.applyObject(ctx, cmObject("cm1", map[string]any{"k": "v2"})); err != nil {
+	if err := p.applyObject(ctx, cmObject("cm1", map[string]any{"k": "v2"}), "default"); err != nil {
 		t.Fatalf("update: %v", err)
 	}
 
@@ -167,6 +169,30 @@ func TestApplyManifests(t *testing.T) {
 	}
 }
 
+// TestApplyManifests_ForeignNamespace verifies a manifest naming a
+// namespace other than the instance's is refused before anything is
+// applied.
+func TestApplyManifests_ForeignNamespace(t *testing.T) {
+	p := newManifestTestProvider()
+	ctx := context.Background()
+
+	req := manifestReq(id.New(id.PrefixInstance))
+	req.Manifests.Docs = append(req.Manifests.Docs,
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm2\n  namespace: kube-system\n")
+
+	if _, err := p.ApplyManifests(ctx, req); !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Fatalf("err = %v, want ErrInvalidConfig", err)
+	}
+
+	if _, err := p.dynamic.Resource(configMapGVR).Namespace("default").Get(ctx, "cm1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("cm1 applied despite the refused manifest: err = %v", err)
+	}
+
+	if _, err := p.dynamic.Resource(configMapGVR).Namespace("kube-system").Get(ctx, "cm2", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("cm2 applied to kube-system: err = %v", err)
+	}
+}
+
 func TestDeleteManifests(t *testing.T) {
 	p := newManifestTestProvider()
 	ctx := context.Background()
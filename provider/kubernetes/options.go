@@ -100,6 +100,21 @@ func WithImagePullSecrets(secrets ...string) Option {
 	}
 }
 
+// WithTenantNamespaces isolates each tenant in its own namespace. See
+// Config.TenantNamespaces. ingressNamespace names the ingress
+// controller's namespace; empty keeps the default.
+func WithTenantNamespaces(ingressNamespace string) Option {
+	return func(p *Provider) error {
+		p.cfg.TenantNamespaces = true
+
+		if ingressNamespace != "" {
+			p.cfg.IngressNamespace = ingressNamespace
+		}
+
+		return nil
+	}
+}
+
 // WithConfig applies all non-zero fields from a Config struct.
 // This is useful when loading configuration from files or environment variables.
 func WithConfig(cfg Config) Option {
@@ -140,6 +155,14 @@ func WithConfig(cfg Config) Option {
 			p.cfg.ImagePullSecrets = cfg.ImagePullSecrets
 		}
 
+		if cfg.TenantNamespaces {
+			p.cfg.TenantNamespaces = true
+		}
+
+		if cfg.IngressNamespace != "" {
+			p.cfg.IngressNamespace = cfg.IngressNamespace
+		}
+
 		return nil
 	}
 }
@@ -0,0 +1,91 @@
+package kubernetes
+
+import (
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// applyPlacement translates a workload's placement onto its pod spec:
+// NodeSelector as is, Required as one node affinity term (all
+// requirements ANDed), each Preferred requirement as a weighted
+// preferred term, Tolerations as is, and each spread as a topology
+// spread constraint over the instance's own pods.
+func applyPlacement(spec *corev1.PodSpec, instanceID id.ID, pl *provider.Placement) {
+	if pl == nil {
+		return
+	}
+
+	if len(pl.NodeSelector) > 0 {
+		spec.NodeSelector = maps.Clone(pl.NodeSelector)
+	}
+
+	if len(pl.Required) > 0 || len(pl.Preferred) > 0 {
+		na := &corev1.NodeAffinity{}
+
+		if len(pl.Required) > 0 {
+			exprs := make([]corev1.NodeSelectorRequirement, 0, len(pl.Required))
+			for _, r := range pl.Required {
+				exprs = append(exprs, nodeRequirement(r))
+			}
+
+			na.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: exprs}},
+			}
+		}
+
+		for _, r := range pl.Preferred {
+			na.PreferredDuringSchedulingIgnoredDuringExecution = append(na.PreferredDuringSchedulingIgnoredDuringExecution,
+				corev1.PreferredSchedulingTerm{
+					Weight:     clampInt32(r.Weight),
+					Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{nodeRequirement(r.NodeRequirement)}},
+				})
+		}
+
+		spec.Affinity = &corev1.Affinity{NodeAffinity: na}
+	}
+
+	for _, t := range pl.Tolerations {
+		op := corev1.TolerationOpEqual
+		if t.Operator == provider.TolerationExists {
+			op = corev1.TolerationOpExists
+		}
+
+		spec.Tolerations = append(spec.Tolerations, corev1.Toleration{
+			Key:      t.Key,
+			Operator: op,
+			Value:    t.Value,
+			Effect:   corev1.TaintEffect(t.Effect),
+		})
+	}
+
+	for _, s := range pl.Spread {
+		when := corev1.ScheduleAnyway
+		if s.Required {
+			when = corev1.DoNotSchedule
+		}
+
+		spec.TopologySpreadConstraints = append(spec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           clampInt32(max(s.MaxSkew, 1)),
+			TopologyKey:       s.Key,
+			WhenUnsatisfiable: when,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{labelInstanceID: instanceID.String()},
+			},
+		})
+	}
+}
+
+// nodeRequirement maps a provider requirement onto a node selector
+// requirement; the operator names are shared.
+func nodeRequirement(r provider.NodeRequirement) corev1.NodeSelectorRequirement {
+	return corev1.NodeSelectorRequirement{
+		Key:      r.Key,
+		Operator: corev1.NodeSelectorOperator(r.Operator),
+		Values:   r.Values,
+	}
+}
@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestProvision_Placement verifies the Main service's placement lands
+// on the pod spec as node selector, affinity, tolerations and spread.
+func TestProvision_Placement(t *testing.T) {
+	ctx := context.Background()
+	instID := id.New(id.PrefixInstance)
+	p := &Provider{cfg: Config{Namespace: "default"}, client: k8sfake.NewSimpleClientset()}
+
+	req := provider.ProvisionRequest{
+		InstanceID: instID,
+		TenantID:   "ten_abc",
+		Services: []provider.ServiceSpec{{
+			Name:      "web",
+			Image:     "web:1",
+			Role:      provider.RoleMain,
+			Resources: provider.ResourceSpec{Replicas: 3},
+			Placement: &provider.Placement{
+				NodeSelector: map[string]string{"pool": "gpu"},
+				Required: []provider.NodeRequirement{
+					{Key: "topology.kubernetes.io/zone", Operator: provider.NodeOpIn, Values: []string{"a", "b"}},
+				},
+				Preferred: []provider.WeightedRequirement{
+					{NodeRequirement: provider.NodeRequirement{Key: "disk", Operator: provider.NodeOpExists}, Weight: 30},
+				},
+				Tolerations: []provider.Toleration{{Key: "nvidia.com/gpu", Operator: provider.TolerationExists, Effect: "NoSchedule"}},
+				Spread:      []provider.SpreadConstraint{{Key: "topology.kubernetes.io/zone", Required: true}},
+			},
+		}},
+	}
+
+	if _, err := p.Provision(ctx, req); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	dep, err := p.client.AppsV1().Deployments("default").Get(ctx, deploymentName(instID), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+
+	spec := dep.Spec.Template.Spec
+
+	if spec.NodeSelector["pool"] != "gpu" {
+		t.Errorf("node selector = %v", spec.NodeSelector)
+	}
+
+	na := spec.Affinity.NodeAffinity
+
+	terms := na.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || terms[0].MatchExpressions[0].Operator != corev1.NodeSelectorOpIn ||
+		len(terms[0].MatchExpressions[0].Values) != 2 {
+		t.Errorf("required affinity = %+v", terms)
+	}
+
+	pref := na.PreferredDuringSchedulingIgnoredDuringExecution
+	if len(pref) != 1 || pref[0].Weight != 30 || pref[0].Preference.MatchExpressions[0].Operator != corev1.NodeSelectorOpExists {
+		t.Errorf("preferred affinity = %+v", pref)
+	}
+
+	if len(spec.Tolerations) != 1 || spec.Tolerations[0].Operator != corev1.TolerationOpExists ||
+		spec.Tolerations[0].Effect != corev1.TaintEffectNoSchedule {
+		t.Errorf("tolerations = %+v", spec.Tolerations)
+	}
+
+	if len(spec.TopologySpreadConstraints) != 1 {
+		t.Fatalf("spread constraints = %+v", spec.TopologySpreadConstraints)
+	}
+
+	tsc := spec.TopologySpreadConstraints[0]
+	if tsc.MaxSkew != 1 || tsc.WhenUnsatisfiable != corev1.DoNotSchedule ||
+		tsc.LabelSelector.MatchLabels[labelInstanceID] != instID.String() {
+		t.Errorf("spread constraint = %+v", tsc)
+	}
+}
+
+func TestProvision_InvalidPlacement(t *testing.T) {
+	p := &Provider{cfg: Config{Namespace: "default"}, client: k8sfake.NewSimpleClientset()}
+
+	_, err := p.Provision(context.Background(), provider.ProvisionRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services: []provider.ServiceSpec{
+			{Name: "web", Image: "web:1"},
+			{Name: "proxy", Image: "envoy:1", Role: provider.RoleSidecar, Placement: &provider.Placement{
+				NodeSelector: map[string]string{"pool": "edge"},
+			}},
+		},
+	})
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Errorf("err = %v, want ErrInvalidConfig", err)
+	}
+}
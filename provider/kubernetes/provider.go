@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"io"
 	"maps"
+	"slices"
+	"sync"
 
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/discovery/cached/memory"
@@ -18,16 +21,24 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
 
 	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/provider"
 )
 
-// Compile-time check that Provider implements provider.Provider and provider.HealthChecker.
+// Compile-time check that Provider implements provider.Provider, provider.HealthChecker,
+// provider.Rollbacker, provider.Watcher, provider.TenantIsolator,
+// provider.TrackDeployer and provider.InstanceServiceAware.
 var (
-	_ provider.Provider      = (*Provider)(nil)
-	_ provider.HealthChecker = (*Provider)(nil)
+	_ provider.Provider             = (*Provider)(nil)
+	_ provider.HealthChecker        = (*Provider)(nil)
+	_ provider.Rollbacker           = (*Provider)(nil)
+	_ provider.Watcher              = (*Provider)(nil)
+	_ provider.TenantIsolator       = (*Provider)(nil)
+	_ provider.TrackDeployer        = (*Provider)(nil)
+	_ provider.InstanceServiceAware = (*Provider)(nil)
 )
 
 // Provider is a Kubernetes-based infrastructure provider.
@@ -35,14 +46,24 @@ var (
 // helmConfig and loadChart are injectable seams: production wiring builds a
 // cluster-backed action.Configuration and a repo/OCI chart loader, while
 // tests inject an in-memory storage driver and an in-memory chart.
+// newExecutor is the same kind of seam for pods/exec streams; nil means
+// the SPDY executor against restConfig.
 type Provider struct {
-	cfg        Config
-	client     kubernetes.Interface
-	dynamic    dynamic.Interface
-	mapper     meta.RESTMapper
-	restConfig *rest.Config
-	helmConfig func(namespace string) (*action.Configuration, error)
-	loadChart  func(src provider.RenderedHelm) (*chart.Chart, error)
+	cfg         Config
+	client      kubernetes.Interface
+	dynamic     dynamic.Interface
+	mapper      meta.RESTMapper
+	restConfig  *rest.Config
+	helmConfig  func(namespace string) (*action.Configuration, error)
+	loadChart   func(src provider.RenderedHelm) (*chart.Chart, error)
+	newExecutor func(namespace, pod string, opts *corev1.PodExecOptions) (remotecommand.Executor, error)
+
+	// mu guards quotas and namespaces, which only matter with
+	// Config.TenantNamespaces set, and services.
+	mu         sync.RWMutex
+	quotas     provider.TenantQuotaResolver
+	namespaces map[id.ID]string
+	services   provider.InstanceServiceResolver
 }
 
 // New creates a new Kubernetes provider with the given options.
@@ -52,10 +73,11 @@ type Provider struct {
 func New(opts ...Option) (*Provider, error) {
 	p := &Provider{
 		cfg: Config{
-			Namespace: "default",
-			Region:    "local",
-			Country:   "Local",
-			City:      "Localhost",
+			Namespace:        "default",
+			Region:           "local",
+			Country:          "Local",
+			City:             "Localhost",
+			IngressNamespace: "ingress-nginx",
 		},
 	}
 
@@ -124,9 +146,13 @@ func (p *Provider) Capabilities() []provider.Capability {
 		provider.CapProvision,
 		provider.CapDeploy,
 		provider.CapScale,
+		provider.CapAutoScale,
+		provider.CapPlacement,
 		provider.CapLogs,
 		provider.CapExec,
 		provider.CapRolling,
+		provider.CapBlueGreen,
+		provider.CapCanary,
 		provider.CapVolumes,
 		provider.CapManifests,
 		provider.CapHelm,
@@ -144,6 +170,10 @@ func (p *Provider) Capabilities() []provider.Capability {
 // (ClusterIP=None) + volumeClaimTemplates per persistent volume + per-
 // service ConfigMaps. Each replica gets its own PVC by name.
 func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+	if _, err := provider.WorkloadPlacement(req.Services); err != nil {
+		return nil, fmt.Errorf("kubernetes: %w", err)
+	}
+
 	// Per-instance labels (req.Labels — e.g. a caller's workspace/component
 	// tags) are layered onto the provider's static cfg labels so the pods
 	// are identifiable and queryable by the caller's own scheme, not just
@@ -154,7 +184,11 @@ func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest)
 	maps.Copy(extra, p.cfg.Labels)
 	maps.Copy(extra, req.Labels)
 	labels := instanceLabels(req.InstanceID, req.TenantID, extra)
-	ns := p.cfg.Namespace
+
+	ns, err := p.provisionNamespace(ctx, req.InstanceID, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create per-service ConfigMaps before the controller object so the
 	// pods can mount them on first start.
@@ -164,10 +198,24 @@ func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest)
 		}
 	}
 
+	// Per-instance registry credentials (resolved from the services'
+	// registry secrets) join the static pull secrets.
 	pullSecrets := p.cfg.ImagePullSecrets
 
+	if creds := provisionCredentials(req.Services); len(creds) > 0 {
+		if err := p.writeRegistrySecret(ctx, req.InstanceID, labels, creds); err != nil {
+			return nil, err
+		}
+
+		pullSecrets = append(slices.Clone(pullSecrets), registrySecretName(req.InstanceID))
+	}
+
+	targetKind := "Deployment"
+
 	switch req.Kind {
 	case provider.KindStatefulSet:
+		targetKind = "StatefulSet"
+
 		ss := buildStatefulSet(req, ns, labels, pullSecrets)
 		if _, err := p.client.AppsV1().StatefulSets(ns).Create(ctx, ss, metav1.CreateOptions{}); err != nil {
 			return nil, fmt.Errorf("kubernetes: create statefulset: %w", err)
@@ -192,6 +240,15 @@ func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest)
 		}
 	}
 
+	// The HPA owns the replica count from here on; the controller
+	// object's initial count is already clamped into its bounds.
+	if a := mainAutoscale(req); a != nil {
+		hpa := buildHPA(req.InstanceID, ns, targetKind, labels, *a)
+		if _, err := p.client.AutoscalingV2().HorizontalPodAutoscalers(ns).Create(ctx, hpa, metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("kubernetes: create autoscaler: %w", err)
+		}
+	}
+
 	// Build per-service refs for the result. Each service-name maps to
 	// the workload-level provider ref plus the service name — k8s
 	// doesn't have stable per-container IDs at provision time (Pod
@@ -219,7 +276,10 @@ func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest)
 
 // Deprovision tears down all resources for an instance.
 func (p *Provider) Deprovision(ctx context.Context, instanceID id.ID) error {
-	ns := p.cfg.Namespace
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
 	name := deploymentName(instanceID)
 	propagation := metav1.DeletePropagationForeground
 
@@ -239,8 +299,14 @@ func (p *Provider) Deprovision(ctx context.Context, instanceID id.ID) error {
 		return fmt.Errorf("kubernetes: delete workload: deployment: %w; statefulset: %w", depErr, ssErr)
 	}
 
-	// Delete Service (NotFound = already gone, fine).
+	// Delete the HPA, Service and the registry pull Secret (NotFound =
+	// already gone, fine).
+	_ = p.client.AutoscalingV2().HorizontalPodAutoscalers(ns).Delete(ctx, name, metav1.DeleteOptions{})
 	_ = p.client.CoreV1().Services(ns).Delete(ctx, serviceName(instanceID), metav1.DeleteOptions{})
+	_ = p.client.CoreV1().Secrets(ns).Delete(ctx, registrySecretName(instanceID), metav1.DeleteOptions{})
+
+	// A canary track left behind by an interrupted rollout goes too.
+	_ = p.removeTracks(ctx, ns, instanceID)
 
 	// Delete every per-service ConfigMap matching our label selector.
 	cms, listErr := p.client.CoreV1().ConfigMaps(ns).List(ctx, metav1.ListOptions{
@@ -252,6 +318,8 @@ func (p *Provider) Deprovision(ctx context.Context, instanceID id.ID) error {
 		}
 	}
 
+	p.forgetNamespace(instanceID)
+
 	return nil
 }
 
@@ -291,7 +359,10 @@ func (p *Provider) Stop(ctx context.Context, instanceID id.ID) error {
 
 // Restart performs a rollout restart by updating a pod template annotation.
 func (p *Provider) Restart(ctx context.Context, instanceID id.ID) error {
-	ns := p.cfg.Namespace
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
 	name := deploymentName(instanceID)
 
 	dep, err := p.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
@@ -314,41 +385,116 @@ func (p *Provider) Restart(ctx context.Context, instanceID id.ID) error {
 
 // Status returns the current runtime status of an instance.
 func (p *Provider) Status(ctx context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
-	ns := p.cfg.Namespace
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
 	name := deploymentName(instanceID)
 
+	var st *provider.InstanceStatus
+
 	dep, err := p.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
-	if err != nil {
+	switch {
+	case err == nil:
+		st = deploymentStatus(dep)
+	case apierrors.IsNotFound(err):
+		ss, ssErr := p.client.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+		if ssErr != nil {
+			return nil, fmt.Errorf("kubernetes: get statefulset status: %w", ssErr)
+		}
+
+		st = statefulSetStatus(ss)
+	default:
 		return nil, fmt.Errorf("kubernetes: get deployment status: %w", err)
 	}
 
-	state := deploymentState(dep)
+	st.Autoscale = p.autoscaleStatus(ctx, instanceID)
+
+	return st, nil
+}
+
+// deploymentStatus derives the instance status from a Deployment.
+// Shared by Status and Watch, which gets the object from its
+// informer instead of a Get.
+func deploymentStatus(dep *appsv1.Deployment) *provider.InstanceStatus {
+	// A nil spec.replicas means the API server's default of one.
+	desired := 1
+	if dep.Spec.Replicas != nil {
+		desired = int(*dep.Spec.Replicas)
+	}
 
 	return &provider.InstanceStatus{
-		State:   state,
-		Ready:   dep.Status.ReadyReplicas > 0,
-		Message: deploymentMessage(dep),
-	}, nil
+		State:           deploymentState(dep),
+		Ready:           dep.Status.ReadyReplicas > 0,
+		Message:         deploymentMessage(dep),
+		Replicas:        int(dep.Status.Replicas),
+		ReadyReplicas:   int(dep.Status.ReadyReplicas),
+		DesiredReplicas: desired,
+	}
+}
+
+// statefulSetStatus derives the instance status from a StatefulSet,
+// for Status and Watch. StatefulSets carry no Progressing condition, so
+// a stuck rollout reads as starting rather than failed.
+func statefulSetStatus(ss *appsv1.StatefulSet) *provider.InstanceStatus {
+	desired := 1
+	if ss.Spec.Replicas != nil {
+		desired = int(*ss.Spec.Replicas)
+	}
+
+	state := provider.StateProvisioning
+
+	switch {
+	case desired == 0:
+		state = provider.StateStopped
+	case ss.Status.ReadyReplicas > 0 && ss.Status.ReadyReplicas == ss.Status.Replicas:
+		state = provider.StateRunning
+	case ss.Status.UpdatedReplicas > 0 || ss.Status.CurrentReplicas > 0:
+		state = provider.StateStarting
+	}
+
+	return &provider.InstanceStatus{
+		State:           state,
+		Ready:           ss.Status.ReadyReplicas > 0,
+		Replicas:        int(ss.Status.Replicas),
+		ReadyReplicas:   int(ss.Status.ReadyReplicas),
+		DesiredReplicas: desired,
+	}
 }
 
 // Deploy pushes a new release by updating each targeted service's
-// container image and ConfigMap. Services not listed in req.Services
+// container image, env ConfigMap and config-file ConfigMap. Services not listed in req.Services
 // are left at their current image — Kubernetes performs a rolling
 // update only on the changed containers.
 func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*provider.DeployResult, error) {
-	ns := p.cfg.Namespace
+	ns, err := p.namespaceFor(ctx, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
 	name := deploymentName(req.InstanceID)
 
 	// Try Deployment first; fall through to StatefulSet if not present.
 	dep, depErr := p.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
 	if depErr == nil {
 		applyServiceUpdates(dep.Spec.Template.Spec.Containers, dep.Spec.Template.Spec.InitContainers, req.Services)
+		applyConfigFileUpdates(&dep.Spec.Template, req.InstanceID, req.Services)
+
+		// Config files first, so pods of the new revision find the
+		// ConfigMap they mount.
+		if err := p.writeConfigFiles(ctx, req.InstanceID, dep.Labels, req.Services); err != nil {
+			return nil, err
+		}
+
+		if err := p.applyRegistryAuth(ctx, req.InstanceID, dep.Labels, &dep.Spec.Template.Spec, req.Services); err != nil {
+			return nil, err
+		}
 
 		if dep.Annotations == nil {
 			dep.Annotations = make(map[string]string)
 		}
 
 		dep.Annotations[annotationReleaseID] = req.ReleaseID.String()
+		setTemplateRelease(&dep.Spec.Template, req.ReleaseID)
 
 		if _, err := p.client.AppsV1().Deployments(ns).Update(ctx, dep, metav1.UpdateOptions{}); err != nil {
 			return nil, fmt.Errorf("kubernetes: update deployment: %w", err)
@@ -360,12 +506,22 @@ func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*pro
 		}
 
 		applyServiceUpdates(ss.Spec.Template.Spec.Containers, ss.Spec.Template.Spec.InitContainers, req.Services)
+		applyConfigFileUpdates(&ss.Spec.Template, req.InstanceID, req.Services)
+
+		if err := p.writeConfigFiles(ctx, req.InstanceID, ss.Labels, req.Services); err != nil {
+			return nil, err
+		}
+
+		if err := p.applyRegistryAuth(ctx, req.InstanceID, ss.Labels, &ss.Spec.Template.Spec, req.Services); err != nil {
+			return nil, err
+		}
 
 		if ss.Annotations == nil {
 			ss.Annotations = make(map[string]string)
 		}
 
 		ss.Annotations[annotationReleaseID] = req.ReleaseID.String()
+		setTemplateRelease(&ss.Spec.Template, req.ReleaseID)
 
 		if _, err := p.client.AppsV1().StatefulSets(ns).Update(ctx, ss, metav1.UpdateOptions{}); err != nil {
 			return nil, fmt.Errorf("kubernetes: update statefulset: %w", err)
@@ -418,11 +574,6 @@ func applyServiceUpdates(containers, initContainers []corev1.Container, updates
 	}
 }
 
-// Rollback reverts to a previous release (no-op: ctrlplane handles release state).
-func (p *Provider) Rollback(_ context.Context, _ id.ID, _ id.ID) error {
-	return nil
-}
-
 // Scale adjusts the instance's resource allocation. A ScaleRequest may
 // carry CPU/memory changes, a replica change, or both: resource changes
 // patch the pod template's container resources (rolling the pods),
@@ -434,6 +585,16 @@ func (p *Provider) Scale(ctx context.Context, instanceID id.ID, spec provider.Re
 		}
 	}
 
+	// An enabled autoscaler owns the replica count, so Replicas is
+	// ignored; a spec without one removes any HPA left from before.
+	if spec.Autoscale.Enabled() {
+		return p.applyAutoscaler(ctx, instanceID, *spec.Autoscale)
+	}
+
+	if err := p.deleteAutoscaler(ctx, instanceID); err != nil {
+		return err
+	}
+
 	if spec.Replicas > 0 {
 		return p.scaleReplicas(ctx, instanceID, int32(min(spec.Replicas, int(^int32(0))))) //nolint:gosec // clamped to int32 range via min
 	}
@@ -449,7 +610,10 @@ func (p *Provider) Scale(ctx context.Context, instanceID id.ID, spec provider.Re
 // untouched. The Deployment path is tried first, then StatefulSet —
 // same dispatch as Deploy.
 func (p *Provider) applyResources(ctx context.Context, instanceID id.ID, spec provider.ResourceSpec) error {
-	ns := p.cfg.Namespace
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
 	name := deploymentName(instanceID)
 
 	dep, depErr := p.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
@@ -505,22 +669,30 @@ func setContainerResources(containers []corev1.Container, spec provider.Resource
 // error — the metrics poller treats that as a missing sample, so
 // the dashboard shows "—" rather than perpetual errors.
 func (p *Provider) Resources(ctx context.Context, instanceID id.ID) (*provider.ResourceUsage, error) {
-	return fetchPodMetrics(ctx, p.client, p.cfg.Namespace, instanceID)
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchPodMetrics(ctx, p.client, ns, instanceID)
 }
 
 // Logs streams logs for the instance.
 func (p *Provider) Logs(ctx context.Context, instanceID id.ID, opts provider.LogOptions) (io.ReadCloser, error) {
-	return streamLogs(ctx, p.client, p.cfg.Namespace, instanceID, opts)
-}
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
 
-// Exec runs a command inside the instance (stub).
-func (p *Provider) Exec(_ context.Context, _ id.ID, _ provider.ExecRequest) (*provider.ExecResult, error) {
-	return &provider.ExecResult{ExitCode: 0}, nil
+	return streamLogs(ctx, p.client, ns, instanceID, opts)
 }
 
 // scaleReplicas patches the deployment to the desired replica count.
 func (p *Provider) scaleReplicas(ctx context.Context, instanceID id.ID, replicas int32) error {
-	ns := p.cfg.Namespace
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
 	name := deploymentName(instanceID)
 
 	scale, err := p.client.AppsV1().Deployments(ns).GetScale(ctx, name, metav1.GetOptions{})
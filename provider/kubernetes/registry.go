@@ -0,0 +1,178 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/secrets"
+)
+
+// registrySecretSuffix is appended to the per-instance
+// kubernetes.io/dockerconfigjson Secret holding the services' registry
+// credentials.
+const registrySecretSuffix = "-registry"
+
+// dockerConfigJSON is the .dockerconfigjson payload kubelet reads
+// image pull credentials from.
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth"`
+}
+
+// registrySecretName returns the instance's pull Secret name.
+func registrySecretName(instanceID id.ID) string {
+	return deploymentName(instanceID) + registrySecretSuffix
+}
+
+// provisionCredentials collects the resolved registry credentials of
+// every service in a provision request.
+func provisionCredentials(services []provider.ServiceSpec) []*secrets.RegistryCredential {
+	var creds []*secrets.RegistryCredential
+
+	for i := range services {
+		if services[i].RegistryAuth != nil {
+			creds = append(creds, services[i].RegistryAuth)
+		}
+	}
+
+	return creds
+}
+
+// updateCredentials collects the resolved registry credentials of
+// every deploy update.
+func updateCredentials(updates []provider.ServiceDeploySpec) []*secrets.RegistryCredential {
+	var creds []*secrets.RegistryCredential
+
+	for _, u := range updates {
+		if u.RegistryAuth != nil {
+			creds = append(creds, u.RegistryAuth)
+		}
+	}
+
+	return creds
+}
+
+// mergeDockerConfig adds creds to an existing .dockerconfigjson
+// payload (which may be empty), one entry per registry server. A
+// credential replaces any earlier entry for its server; entries for
+// other servers are kept, since a deploy only carries the services it
+// changes.
+func mergeDockerConfig(existing []byte, creds []*secrets.RegistryCredential) ([]byte, error) {
+	cfg := dockerConfigJSON{Auths: make(map[string]dockerConfigEntry, len(creds))}
+
+	if len(existing) > 0 {
+		if err := json.Unmarshal(existing, &cfg); err != nil {
+			return nil, fmt.Errorf("decode dockerconfigjson: %w", err)
+		}
+
+		if cfg.Auths == nil {
+			cfg.Auths = make(map[string]dockerConfigEntry, len(creds))
+		}
+	}
+
+	for _, c := range creds {
+		cfg.Auths[c.Server] = dockerConfigEntry{
+			Username: c.Username,
+			Password: c.Password,
+			Email:    c.Email,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password)),
+		}
+	}
+
+	return json.Marshal(cfg)
+}
+
+// writeRegistrySecret creates or updates the instance's pull Secret
+// with creds. No-op when creds is empty.
+func (p *Provider) writeRegistrySecret(ctx context.Context, instanceID id.ID, labels map[string]string, creds []*secrets.RegistryCredential) error {
+	if len(creds) == 0 {
+		return nil
+	}
+
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	name := registrySecretName(instanceID)
+
+	secret, err := p.client.CoreV1().Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		data, mergeErr := mergeDockerConfig(nil, creds)
+		if mergeErr != nil {
+			return fmt.Errorf("kubernetes: registry secret %s: %w", name, mergeErr)
+		}
+
+		if _, err := p.client.CoreV1().Secrets(ns).Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: data},
+		}, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("kubernetes: create registry secret %s: %w", name, err)
+		}
+	case err != nil:
+		return fmt.Errorf("kubernetes: get registry secret %s: %w", name, err)
+	default:
+		data, mergeErr := mergeDockerConfig(secret.Data[corev1.DockerConfigJsonKey], creds)
+		if mergeErr != nil {
+			return fmt.Errorf("kubernetes: registry secret %s: %w", name, mergeErr)
+		}
+
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte, 1)
+		}
+
+		secret.Data[corev1.DockerConfigJsonKey] = data
+
+		if _, err := p.client.CoreV1().Secrets(ns).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("kubernetes: update registry secret %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ensurePullSecret adds name to a pod spec's imagePullSecrets unless
+// it's already listed.
+func ensurePullSecret(spec *corev1.PodSpec, name string) {
+	if slices.ContainsFunc(spec.ImagePullSecrets, func(r corev1.LocalObjectReference) bool { return r.Name == name }) {
+		return
+	}
+
+	spec.ImagePullSecrets = append(spec.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+}
+
+// applyRegistryAuth writes the updates' credentials to the instance's
+// pull Secret and makes sure the pod template references it. Called
+// by Deploy and RollbackRelease before the workload update, so the
+// new pods can pull.
+func (p *Provider) applyRegistryAuth(ctx context.Context, instanceID id.ID, labels map[string]string, spec *corev1.PodSpec, updates []provider.ServiceDeploySpec) error {
+	creds := updateCredentials(updates)
+	if len(creds) == 0 {
+		return nil
+	}
+
+	if err := p.writeRegistrySecret(ctx, instanceID, labels, creds); err != nil {
+		return err
+	}
+
+	ensurePullSecret(spec, registrySecretName(instanceID))
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/secrets"
+)
+
+// TestProvision_CreatesRegistryPullSecret verifies resolved registry
+// credentials land in a per-instance dockerconfigjson Secret that the
+// pod template lists next to the static pull secrets.
+func TestProvision_CreatesRegistryPullSecret(t *testing.T) {
+	t.Parallel()
+
+	client := k8sfake.NewSimpleClientset()
+	p := &Provider{cfg: Config{Namespace: "default", ImagePullSecrets: []string{"static"}}, client: client}
+	iid := id.New(id.PrefixInstance)
+
+	if _, err := p.Provision(context.Background(), provider.ProvisionRequest{
+		InstanceID: iid,
+		TenantID:   "ten_test",
+		Kind:       provider.KindDeployment,
+		Services: []provider.ServiceSpec{{
+			Name:         "main",
+			Image:        "ghcr.io/acme/api:v1",
+			Role:         provider.RoleMain,
+			RegistryAuth: &secrets.RegistryCredential{Server: "ghcr.io", Username: "bot", Password: "pw"},
+		}},
+	}); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("default").Get(context.Background(), registrySecretName(iid), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get registry secret: %v", err)
+	}
+
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Fatalf("secret type: got %s", secret.Type)
+	}
+
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &cfg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if e := cfg.Auths["ghcr.io"]; e.Username != "bot" || e.Auth != "Ym90OnB3" {
+		t.Fatalf("auth entry: %+v", cfg.Auths)
+	}
+
+	dep, err := client.AppsV1().Deployments("default").Get(context.Background(), deploymentName(iid), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+
+	var names []string
+	for _, r := range dep.Spec.Template.Spec.ImagePullSecrets {
+		names = append(names, r.Name)
+	}
+
+	if !slices.Equal(names, []string{"static", registrySecretName(iid)}) {
+		t.Fatalf("imagePullSecrets: got %v", names)
+	}
+}
+
+// TestMergeDockerConfig_KeepsOtherRegistries verifies a deploy's
+// credential replaces its own registry's entry without dropping the
+// entries of services it didn't touch.
+func TestMergeDockerConfig_KeepsOtherRegistries(t *testing.T) {
+	t.Parallel()
+
+	first, err := mergeDockerConfig(nil, []*secrets.RegistryCredential{
+		{Server: "ghcr.io", Username: "old", Password: "pw"},
+		{Server: "quay.io", Username: "q", Password: "pw"},
+	})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	merged, err := mergeDockerConfig(first, []*secrets.RegistryCredential{{Server: "ghcr.io", Username: "new", Password: "pw"}})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal(merged, &cfg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if len(cfg.Auths) != 2 || cfg.Auths["ghcr.io"].Username != "new" || cfg.Auths["quay.io"].Username != "q" {
+		t.Fatalf("auths: %+v", cfg.Auths)
+	}
+}
@@ -82,21 +82,41 @@ func instanceSelector(instanceID id.ID) string {
 // replicaCountFor returns the desired replica count for a workload's
 // Deployment/StatefulSet. The Main service's Resources.Replicas is
 // authoritative; non-Main services don't carry a count (they always
-// run alongside Main).
+// run alongside Main). With autoscaling enabled the count is clamped
+// into the autoscaler's bounds so the HPA starts from a valid value.
 func replicaCountFor(req provider.ProvisionRequest) int32 {
 	const maxInt32 = int(^uint32(0) >> 1)
 
 	for i := range req.Services {
 		if req.Services[i].Role == provider.RoleMain || req.Services[i].Role == "" {
-			n := min(max(req.Services[i].Resources.Replicas, 1), maxInt32)
+			res := req.Services[i].Resources
+			n := max(res.Replicas, 1)
 
-			return int32(n) //nolint:gosec // clamped to [1, maxInt32] above
+			if a := res.Autoscale; a.Enabled() {
+				n = min(max(n, a.MinReplicas), a.MaxReplicas)
+			}
+
+			return int32(min(n, maxInt32)) //nolint:gosec // clamped to [1, maxInt32] above
 		}
 	}
 
 	return 1
 }
 
+// podAnnotations returns the pod-template annotations for a workload.
+// The default-container annotation names the Main service so Exec (and
+// kubectl) target it when no container is given, regardless of where
+// Main sits in the Services slice.
+func podAnnotations(req provider.ProvisionRequest) map[string]string {
+	for i := range req.Services {
+		if req.Services[i].Role == provider.RoleMain || req.Services[i].Role == "" {
+			return map[string]string{annotationDefaultContainer: req.Services[i].Name}
+		}
+	}
+
+	return nil
+}
+
 // buildPodSpec assembles a PodSpec from a ProvisionRequest's Services.
 // Init services land in InitContainers (run-once before main start);
 // Main and Sidecar services land in Containers and run for the pod's
@@ -149,12 +169,18 @@ func buildPodSpec(req provider.ProvisionRequest, imagePullSecrets []string) core
 		pullSecretRefs = append(pullSecretRefs, corev1.LocalObjectReference{Name: s})
 	}
 
-	return corev1.PodSpec{
+	spec := corev1.PodSpec{
 		Containers:       containers,
 		InitContainers:   initContainers,
 		Volumes:          podVolumes,
 		ImagePullSecrets: pullSecretRefs,
 	}
+
+	// Provision has already validated the placement.
+	placement, _ := provider.WorkloadPlacement(req.Services)
+	applyPlacement(&spec, req.InstanceID, placement)
+
+	return spec
 }
 
 // buildContainer translates one ServiceSpec into a Kubernetes Container.
@@ -192,7 +218,7 @@ func buildContainer(instanceID id.ID, svc provider.ServiceSpec) corev1.Container
 func buildDeployment(req provider.ProvisionRequest, namespace string, labels map[string]string, imagePullSecrets []string) *appsv1.Deployment {
 	replicas := replicaCountFor(req)
 
-	return &appsv1.Deployment{
+	dep := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      deploymentName(req.InstanceID),
 			Namespace: namespace,
@@ -207,12 +233,17 @@ func buildDeployment(req provider.ProvisionRequest, namespace string, labels map
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: podAnnotations(req),
 				},
 				Spec: buildPodSpec(req, imagePullSecrets),
 			},
 		},
 	}
+
+	provisionConfigFiles(&dep.Spec.Template, req)
+
+	return dep
 }
 
 // buildStatefulSet creates a StatefulSet + headless Service for a
@@ -268,7 +299,7 @@ func buildStatefulSet(req provider.ProvisionRequest, namespace string, labels ma
 
 	podSpec.Volumes = filtered
 
-	return &appsv1.StatefulSet{
+	ss := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      deploymentName(req.InstanceID),
 			Namespace: namespace,
@@ -284,13 +315,18 @@ func buildStatefulSet(req provider.ProvisionRequest, namespace string, labels ma
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: podAnnotations(req),
 				},
 				Spec: podSpec,
 			},
 			VolumeClaimTemplates: templates,
 		},
 	}
+
+	provisionConfigFiles(&ss.Spec.Template, req)
+
+	return ss
 }
 
 // buildService creates a Kubernetes Service exposing every service's
@@ -343,7 +379,8 @@ func buildService(req provider.ProvisionRequest, namespace string, labels map[st
 	}
 }
 
-// buildConfigMaps creates one ConfigMap per service that has env vars.
+// buildConfigMaps creates one env ConfigMap per service that has env
+// vars and one config-file ConfigMap per service that has ConfigFiles.
 // Per-service maps keep service A's env out of service B's container
 // (a single shared map with EnvFrom would expose everything to
 // everyone).
@@ -352,6 +389,11 @@ func buildConfigMaps(req provider.ProvisionRequest, namespace string, labels map
 
 	for i := range req.Services {
 		svc := req.Services[i]
+
+		if len(svc.ConfigFiles) > 0 {
+			maps = append(maps, buildConfigFilesMap(req.InstanceID, svc.Name, namespace, labels, svc.ConfigFiles))
+		}
+
 		if len(svc.Env) == 0 {
 			continue
 		}
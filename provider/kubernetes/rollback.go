@@ -0,0 +1,240 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// annotationRevision is the revision number the Deployment controller
+// stamps on each ReplicaSet it owns — the history `kubectl rollout
+// undo --to-revision` walks.
+const annotationRevision = "deployment.kubernetes.io/revision"
+
+// Rollback reverts the instance to releaseID using the cluster's own
+// revision history only. Without the Release snapshot there's no
+// fallback when the ReplicaSet has been pruned by
+// revisionHistoryLimit; deploy.Service calls RollbackRelease with the
+// snapshot instead.
+func (p *Provider) Rollback(ctx context.Context, instanceID id.ID, releaseID id.ID) error {
+	_, err := p.RollbackRelease(ctx, provider.RollbackRequest{
+		InstanceID: instanceID,
+		ReleaseID:  releaseID,
+	})
+
+	return err
+}
+
+// RollbackRelease reverts the instance to the target Release the way
+// `kubectl rollout undo` does: the Deployment's pod template is
+// replaced with the template of the ReplicaSet that ran that Release
+// (Deploy stamps the release ID on every pod template, so each
+// ReplicaSet records which Release it belongs to). That's a single
+// Update; the Deployment controller then scales the old ReplicaSet
+// back up under its usual rollout guarantees.
+//
+// When the ReplicaSet is gone (history limit) or the workload is a
+// StatefulSet, the snapshot's images are applied to the live
+// template instead. Either way each service's env and config-file
+// ConfigMaps are restored from the snapshot, since both live outside
+// the template.
+func (p *Provider) RollbackRelease(ctx context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	ns, err := p.namespaceFor(ctx, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	name := deploymentName(req.InstanceID)
+
+	dep, depErr := p.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	if depErr == nil {
+		if err := p.rollbackDeployment(ctx, dep, req); err != nil {
+			return nil, err
+		}
+	} else {
+		ss, ssErr := p.client.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+		if ssErr != nil {
+			return nil, fmt.Errorf("kubernetes: get workload for rollback: deployment: %w; statefulset: %w", depErr, ssErr)
+		}
+
+		if len(req.Services) == 0 {
+			return nil, fmt.Errorf("kubernetes: rollback statefulset %s to release %s: snapshot required", name, req.ReleaseID)
+		}
+
+		applyServiceUpdates(ss.Spec.Template.Spec.Containers, ss.Spec.Template.Spec.InitContainers, snapshotUpdates(req.Services))
+		applyConfigFileUpdates(&ss.Spec.Template, req.InstanceID, snapshotUpdates(req.Services))
+		setWorkloadRelease(&ss.ObjectMeta, &ss.Spec.Template, req.ReleaseID)
+
+		if err := p.writeConfigFiles(ctx, req.InstanceID, ss.Labels, snapshotUpdates(req.Services)); err != nil {
+			return nil, err
+		}
+
+		if err := p.applyRegistryAuth(ctx, req.InstanceID, ss.Labels, &ss.Spec.Template.Spec, snapshotUpdates(req.Services)); err != nil {
+			return nil, err
+		}
+
+		if _, err := p.client.AppsV1().StatefulSets(ns).Update(ctx, ss, metav1.UpdateOptions{}); err != nil {
+			return nil, fmt.Errorf("kubernetes: rollback statefulset: %w", err)
+		}
+	}
+
+	if err := p.restoreServiceEnv(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return &provider.DeployResult{
+		ProviderRef: providerRef(ns, req.InstanceID),
+		Status:      "rolled_back",
+	}, nil
+}
+
+// rollbackDeployment swaps the Deployment's template for the target
+// Release's ReplicaSet template, or applies the snapshot when no
+// ReplicaSet for that Release survives.
+func (p *Provider) rollbackDeployment(ctx context.Context, dep *appsv1.Deployment, req provider.RollbackRequest) error {
+	rsList, err := p.client.AppsV1().ReplicaSets(dep.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: instanceSelector(req.InstanceID),
+	})
+	if err != nil {
+		return fmt.Errorf("kubernetes: list replicasets for rollback: %w", err)
+	}
+
+	if rs := releaseReplicaSet(dep, rsList.Items, req.ReleaseID); rs != nil {
+		tmpl := rs.Spec.Template.DeepCopy()
+		// The hash label is the ReplicaSet's own identity; the
+		// Deployment controller re-derives it from the template.
+		delete(tmpl.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+		dep.Spec.Template = *tmpl
+	} else {
+		if len(req.Services) == 0 {
+			return fmt.Errorf("kubernetes: rollback deployment %s: no revision for release %s and no snapshot", dep.Name, req.ReleaseID)
+		}
+
+		applyServiceUpdates(dep.Spec.Template.Spec.Containers, dep.Spec.Template.Spec.InitContainers, snapshotUpdates(req.Services))
+		applyConfigFileUpdates(&dep.Spec.Template, req.InstanceID, snapshotUpdates(req.Services))
+	}
+
+	setWorkloadRelease(&dep.ObjectMeta, &dep.Spec.Template, req.ReleaseID)
+
+	// The config-file ConfigMap isn't versioned by the cluster; put the
+	// Release's files back before the rolled-back pods mount it.
+	if err := p.writeConfigFiles(ctx, req.InstanceID, dep.Labels, snapshotUpdates(req.Services)); err != nil {
+		return err
+	}
+
+	// Older images may live in a private registry too; a restored
+	// ReplicaSet template may predate the pull Secret.
+	if err := p.applyRegistryAuth(ctx, req.InstanceID, dep.Labels, &dep.Spec.Template.Spec, snapshotUpdates(req.Services)); err != nil {
+		return err
+	}
+
+	if _, err := p.client.AppsV1().Deployments(dep.Namespace).Update(ctx, dep, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("kubernetes: rollback deployment: %w", err)
+	}
+
+	return nil
+}
+
+// restoreServiceEnv writes each snapshot's env back into the
+// service's ConfigMap, replacing it outright so variables added since
+// the Release are dropped — an empty snapshot empties it. Mirrors
+// Deploy in only updating ConfigMaps that exist.
+func (p *Provider) restoreServiceEnv(ctx context.Context, req provider.RollbackRequest) error {
+	ns, err := p.namespaceFor(ctx, req.InstanceID)
+	if err != nil {
+		return err
+	}
+
+	for _, snap := range req.Services {
+		cmName := configMapNameFor(req.InstanceID, snap.Name)
+
+		cm, err := p.client.CoreV1().ConfigMaps(ns).Get(ctx, cmName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("kubernetes: get configmap %s: %w", cmName, err)
+		}
+
+		cm.Data = snap.Env
+		if _, err := p.client.CoreV1().ConfigMaps(ns).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("kubernetes: restore configmap %s: %w", cmName, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseReplicaSet returns the ReplicaSet owned by dep whose pod
+// template carries releaseID, preferring the highest revision when a
+// Release ran more than once (an earlier rollback to it). Nil when
+// none matches.
+func releaseReplicaSet(dep *appsv1.Deployment, items []appsv1.ReplicaSet, releaseID id.ID) *appsv1.ReplicaSet {
+	var (
+		best    *appsv1.ReplicaSet
+		bestRev int64 = -1
+	)
+
+	for i := range items {
+		rs := &items[i]
+		if !metav1.IsControlledBy(rs, dep) {
+			continue
+		}
+
+		if rs.Spec.Template.Annotations[annotationReleaseID] != releaseID.String() {
+			continue
+		}
+
+		rev, err := strconv.ParseInt(rs.Annotations[annotationRevision], 10, 64)
+		if err != nil {
+			rev = 0
+		}
+
+		if rev > bestRev {
+			best, bestRev = rs, rev
+		}
+	}
+
+	return best
+}
+
+// snapshotUpdates converts Release snapshots into the deploy specs
+// applyServiceUpdates consumes.
+func snapshotUpdates(snaps []provider.ServiceSnapshot) []provider.ServiceDeploySpec {
+	out := make([]provider.ServiceDeploySpec, len(snaps))
+	for i, s := range snaps {
+		out[i] = provider.ServiceDeploySpec{Name: s.Name, Image: s.Image, Env: s.Env, ConfigFiles: s.ConfigFiles, RegistryAuth: s.RegistryAuth}
+	}
+
+	return out
+}
+
+// setTemplateRelease stamps the release ID on a pod template so the
+// ReplicaSet created from it records which Release it runs — the
+// lookup key RollbackRelease uses.
+func setTemplateRelease(tmpl *corev1.PodTemplateSpec, releaseID id.ID) {
+	if tmpl.Annotations == nil {
+		tmpl.Annotations = make(map[string]string, 1)
+	}
+
+	tmpl.Annotations[annotationReleaseID] = releaseID.String()
+}
+
+// setWorkloadRelease records releaseID on both the workload object
+// and its pod template.
+func setWorkloadRelease(meta *metav1.ObjectMeta, tmpl *corev1.PodTemplateSpec, releaseID id.ID) {
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string, 1)
+	}
+
+	meta.Annotations[annotationReleaseID] = releaseID.String()
+	setTemplateRelease(tmpl, releaseID)
+}
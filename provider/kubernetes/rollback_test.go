@@ -0,0 +1,204 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// rollbackFixture returns a Deployment currently on image v2 plus a
+// ReplicaSet it owns that ran release relV1 on image v1.
+func rollbackFixture(instanceID, relV1 id.ID) (*appsv1.Deployment, *appsv1.ReplicaSet) {
+	name := deploymentName(instanceID)
+	labels := instanceLabels(instanceID, "ten_test", nil)
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("dep-uid"), Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "myapp:v2"}}},
+			},
+		},
+	}
+
+	rsLabels := instanceLabels(instanceID, "ten_test", map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "abc123"})
+	isController := true
+
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name + "-abc123",
+			Namespace:   "default",
+			Labels:      rsLabels,
+			Annotations: map[string]string{annotationRevision: "1"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "Deployment", Name: name, UID: dep.UID, Controller: &isController,
+			}},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      rsLabels,
+					Annotations: map[string]string{annotationReleaseID: relV1.String()},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "myapp:v1"}}},
+			},
+		},
+	}
+
+	return dep, rs
+}
+
+// TestRollbackRelease_RestoresReplicaSetTemplate verifies the
+// Deployment's template is swapped for the ReplicaSet that ran the
+// target Release, minus the pod-template-hash label, and that the
+// snapshot's env is written back to the service ConfigMap.
+func TestRollbackRelease_RestoresReplicaSetTemplate(t *testing.T) {
+	t.Parallel()
+
+	instanceID := id.New(id.PrefixInstance)
+	relV1 := id.New(id.PrefixRelease)
+	dep, rs := rollbackFixture(instanceID, relV1)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapNameFor(instanceID, "main"), Namespace: "default"},
+		Data:       map[string]string{"MODE": "v2"},
+	}
+
+	client := k8sfake.NewSimpleClientset(dep, rs, cm)
+	p := &Provider{cfg: Config{Namespace: "default"}, client: client}
+
+	if _, err := p.RollbackRelease(context.Background(), provider.RollbackRequest{
+		InstanceID: instanceID,
+		ReleaseID:  relV1,
+		Services:   []provider.ServiceSnapshot{{Name: "main", Image: "myapp:v1", Env: map[string]string{"MODE": "v1"}}},
+	}); err != nil {
+		t.Fatalf("RollbackRelease: %v", err)
+	}
+
+	got, err := client.AppsV1().Deployments("default").Get(context.Background(), dep.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+
+	if img := got.Spec.Template.Spec.Containers[0].Image; img != "myapp:v1" {
+		t.Fatalf("image: want myapp:v1, got %s", img)
+	}
+
+	if _, ok := got.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+		t.Fatal("template still carries the ReplicaSet's pod-template-hash label")
+	}
+
+	if got.Annotations[annotationReleaseID] != relV1.String() {
+		t.Fatalf("release annotation: want %s, got %q", relV1, got.Annotations[annotationReleaseID])
+	}
+
+	gotCM, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), cm.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get configmap: %v", err)
+	}
+
+	if gotCM.Data["MODE"] != "v1" {
+		t.Fatalf("configmap env: want MODE=v1, got %v", gotCM.Data)
+	}
+}
+
+// TestRollbackRelease_FallsBackToSnapshot verifies that when no
+// ReplicaSet for the Release survives, the snapshot's images are
+// applied — and that without a snapshot the rollback fails rather
+// than silently doing nothing.
+func TestRollbackRelease_FallsBackToSnapshot(t *testing.T) {
+	t.Parallel()
+
+	instanceID := id.New(id.PrefixInstance)
+	dep, _ := rollbackFixture(instanceID, id.New(id.PrefixRelease))
+	target := id.New(id.PrefixRelease)
+
+	client := k8sfake.NewSimpleClientset(dep)
+	p := &Provider{cfg: Config{Namespace: "default"}, client: client}
+
+	if err := p.Rollback(context.Background(), instanceID, target); err == nil {
+		t.Fatal("Rollback without history or snapshot: want error, got nil")
+	}
+
+	if _, err := p.RollbackRelease(context.Background(), provider.RollbackRequest{
+		InstanceID: instanceID,
+		ReleaseID:  target,
+		Services:   []provider.ServiceSnapshot{{Name: "main", Image: "myapp:v0"}},
+	}); err != nil {
+		t.Fatalf("RollbackRelease: %v", err)
+	}
+
+	got, err := client.AppsV1().Deployments("default").Get(context.Background(), dep.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+
+	if img := got.Spec.Template.Spec.Containers[0].Image; img != "myapp:v0" {
+		t.Fatalf("image: want myapp:v0, got %s", img)
+	}
+
+	if got.Spec.Template.Annotations[annotationReleaseID] != target.String() {
+		t.Fatal("pod template should carry the target release ID")
+	}
+}
+
+// TestRollbackRelease_ReplacesServiceEnv verifies a snapshot without
+// env empties the service's ConfigMap, a service without one is
+// skipped, and a ConfigMap that can't be read fails the rollback.
+func TestRollbackRelease_ReplacesServiceEnv(t *testing.T) {
+	t.Parallel()
+
+	instanceID := id.New(id.PrefixInstance)
+	relV1 := id.New(id.PrefixRelease)
+	dep, rs := rollbackFixture(instanceID, relV1)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapNameFor(instanceID, "main"), Namespace: "default"},
+		Data:       map[string]string{"ADDED_LATER": "1"},
+	}
+
+	client := k8sfake.NewSimpleClientset(dep, rs, cm)
+	p := &Provider{cfg: Config{Namespace: "default"}, client: client}
+
+	req := provider.RollbackRequest{
+		InstanceID: instanceID,
+		ReleaseID:  relV1,
+		Services: []provider.ServiceSnapshot{
+			{Name: "main", Image: "myapp:v1"},
+			{Name: "worker", Image: "worker:v1", Env: map[string]string{"MODE": "v1"}},
+		},
+	}
+
+	if _, err := p.RollbackRelease(context.Background(), req); err != nil {
+		t.Fatalf("RollbackRelease: %v", err)
+	}
+
+	gotCM, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), cm.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get configmap: %v", err)
+	}
+
+	if len(gotCM.Data) != 0 {
+		t.Fatalf("configmap env: want empty, got %v", gotCM.Data)
+	}
+
+	client.PrependReactor("get", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("apiserver unavailable")
+	})
+
+	if _, err := p.RollbackRelease(context.Background(), req); err == nil {
+		t.Fatal("RollbackRelease with an unreadable configmap: want error, got nil")
+	}
+}
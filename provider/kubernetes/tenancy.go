@@ -0,0 +1,454 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+const (
+	// tenantQuotaName names the ResourceQuota in a tenant namespace.
+	tenantQuotaName = "ctrlplane-quota"
+
+	// tenantLimitRangeName names the LimitRange that gives containers
+	// without explicit resources the requests a CPU/memory quota needs.
+	tenantLimitRangeName = "ctrlplane-defaults"
+
+	// tenantNetworkPolicyName names the tenant namespace's NetworkPolicy.
+	tenantNetworkPolicyName = "ctrlplane-isolation"
+
+	// labelNamespaceName is the label the API server stamps on every
+	// namespace with its own name.
+	labelNamespaceName = "kubernetes.io/metadata.name"
+
+	// maxNamespaceLen is the DNS-1123 label limit namespaces are held to.
+	maxNamespaceLen = 63
+)
+
+// Container defaults the tenant LimitRange applies. A CPU or memory
+// quota makes the API server reject pods that don't declare both, and
+// a service with no Resources declares neither.
+var (
+	defaultContainerRequest = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	}
+	defaultContainerLimit = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("512Mi"),
+	}
+)
+
+// tenantNamespace derives the namespace for a tenant: the configured
+// namespace, a dash, and the tenant ID lowercased with anything outside
+// [a-z0-9] turned into a dash. Names over the 63-character limit are
+// cut and suffixed with a hash of the ID so they stay distinct.
+func (p *Provider) tenantNamespace(tenantID string) string {
+	var b strings.Builder
+
+	b.WriteString(p.cfg.Namespace)
+	b.WriteByte('-')
+
+	for _, r := range strings.ToLower(tenantID) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+
+	name := strings.TrimRight(b.String(), "-")
+	if len(name) <= maxNamespaceLen {
+		return name
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tenantID))
+	suffix := "-" + strconv.FormatUint(uint64(h.Sum32()), 36)
+
+	return strings.TrimRight(name[:maxNamespaceLen-len(suffix)], "-") + suffix
+}
+
+// SetTenantQuotaResolver sets where tenant quotas are read from when a
+// tenant namespace is set up. Without one, tenant namespaces get no
+// ResourceQuota.
+func (p *Provider) SetTenantQuotaResolver(r provider.TenantQuotaResolver) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.quotas = r
+}
+
+// DeleteTenant deletes the tenant's namespace, and with it every
+// instance still in it. A no-op unless TenantNamespaces is set;
+// a namespace that's already gone is success.
+func (p *Provider) DeleteTenant(ctx context.Context, tenantID string) error {
+	if !p.cfg.TenantNamespaces {
+		return nil
+	}
+
+	ns := p.tenantNamespace(tenantID)
+	propagation := metav1.DeletePropagationForeground
+
+	err := p.client.CoreV1().Namespaces().Delete(ctx, ns, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("kubernetes: delete tenant namespace %s: %w", ns, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for instanceID, cached := range p.namespaces {
+		if cached == ns {
+			delete(p.namespaces, instanceID)
+		}
+	}
+
+	return nil
+}
+
+// namespaceFor returns the namespace an instance's resources live in.
+// With TenantNamespaces off that's always the configured namespace.
+// Otherwise it's remembered from Provision, or found by looking up the
+// instance's controller object or Helm release across namespaces — an
+// instance found nowhere (deleted, or provisioned before tenant
+// namespaces were turned on) maps to the configured namespace.
+func (p *Provider) namespaceFor(ctx context.Context, instanceID id.ID) (string, error) {
+	if !p.cfg.TenantNamespaces {
+		return p.cfg.Namespace, nil
+	}
+
+	p.mu.RLock()
+	ns, ok := p.namespaces[instanceID]
+	p.mu.RUnlock()
+
+	if ok {
+		return ns, nil
+	}
+
+	ns, err := p.findNamespace(ctx, instanceID)
+	if err != nil {
+		return "", err
+	}
+
+	if ns == "" {
+		return p.cfg.Namespace, nil
+	}
+
+	p.rememberNamespace(instanceID, ns)
+
+	return ns, nil
+}
+
+// findNamespace looks for the instance's Deployment, StatefulSet,
+// manifest-tracking ConfigMap or Helm release storage across all
+// namespaces and returns the namespace of the first match, or "" when
+// there is none.
+func (p *Provider) findNamespace(ctx context.Context, instanceID id.ID) (string, error) {
+	byInstance := metav1.ListOptions{LabelSelector: instanceSelector(instanceID), Limit: 1}
+
+	deps, err := p.client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, byInstance)
+	if err != nil {
+		return "", fmt.Errorf("kubernetes: find instance namespace: %w", err)
+	}
+
+	if len(deps.Items) > 0 {
+		return deps.Items[0].Namespace, nil
+	}
+
+	sets, err := p.client.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, byInstance)
+	if err != nil {
+		return "", fmt.Errorf("kubernetes: find instance namespace: %w", err)
+	}
+
+	if len(sets.Items) > 0 {
+		return sets.Items[0].Namespace, nil
+	}
+
+	// Manifests are applied through the dynamic client, and so is
+	// their tracking ConfigMap.
+	if p.dynamic != nil {
+		cms, err := p.dynamic.Resource(configMapGVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+			FieldSelector: "metadata.name=" + trackingName(instanceID),
+			LabelSelector: instanceSelector(instanceID),
+		})
+		if err != nil {
+			return "", fmt.Errorf("kubernetes: find instance namespace: %w", err)
+		}
+
+		if len(cms.Items) > 0 {
+			return cms.Items[0].GetNamespace(), nil
+		}
+	}
+
+	// Helm stores each release revision as a Secret labeled with the
+	// release name, in the release's namespace.
+	releases, err := p.client.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: "owner=helm,name=" + releaseName(instanceID),
+		Limit:         1,
+	})
+	if err != nil {
+		return "", fmt.Errorf("kubernetes: find instance namespace: %w", err)
+	}
+
+	if len(releases.Items) > 0 {
+		return releases.Items[0].Namespace, nil
+	}
+
+	return "", nil
+}
+
+// rememberNamespace records the namespace an instance lives in.
+func (p *Provider) rememberNamespace(instanceID id.ID, ns string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.namespaces == nil {
+		p.namespaces = make(map[id.ID]string)
+	}
+
+	p.namespaces[instanceID] = ns
+}
+
+// forgetNamespace drops an instance's remembered namespace.
+func (p *Provider) forgetNamespace(instanceID id.ID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.namespaces, instanceID)
+}
+
+// provisionNamespace returns the namespace a new instance goes in:
+// the configured namespace, or the tenant's namespace — set up or
+// brought up to date first — when TenantNamespaces is set.
+func (p *Provider) provisionNamespace(ctx context.Context, instanceID id.ID, tenantID string) (string, error) {
+	if !p.cfg.TenantNamespaces {
+		return p.cfg.Namespace, nil
+	}
+
+	ns, err := p.ensureTenantNamespace(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+
+	p.rememberNamespace(instanceID, ns)
+
+	return ns, nil
+}
+
+// ensureTenantNamespace creates the tenant's namespace if it doesn't
+// exist and applies its quota, network policy and pull secrets. It runs
+// on every provision so quota changes reach the cluster with the
+// tenant's next instance.
+func (p *Provider) ensureTenantNamespace(ctx context.Context, tenantID string) (string, error) {
+	if tenantID == "" {
+		return "", fmt.Errorf("kubernetes: %w: tenant namespaces require a tenant ID", ctrlplane.ErrInvalidConfig)
+	}
+
+	name := p.tenantNamespace(tenantID)
+	labels := map[string]string{
+		labelManagedBy: labelManagedByValue,
+		labelTenantID:  tenantID,
+	}
+
+	_, err := p.client.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("kubernetes: create tenant namespace %s: %w", name, err)
+	}
+
+	if err := p.applyTenantQuota(ctx, name, tenantID, labels); err != nil {
+		return "", err
+	}
+
+	netpol := buildTenantNetworkPolicy(name, p.cfg.IngressNamespace, labels)
+	if err := applyTyped(ctx, p.client.NetworkingV1().NetworkPolicies(name), netpol); err != nil {
+		return "", fmt.Errorf("kubernetes: apply tenant network policy: %w", err)
+	}
+
+	if err := p.copyPullSecrets(ctx, name, labels); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// applyTenantQuota writes the tenant's ResourceQuota (and the
+// LimitRange a CPU/memory quota needs) into ns, or removes them when
+// the tenant has no quota.
+func (p *Provider) applyTenantQuota(ctx context.Context, ns, tenantID string, labels map[string]string) error {
+	p.mu.RLock()
+	resolver := p.quotas
+	p.mu.RUnlock()
+
+	var quota *provider.TenantQuota
+
+	if resolver != nil {
+		q, err := resolver.TenantQuota(ctx, tenantID)
+		if err != nil {
+			return fmt.Errorf("kubernetes: resolve tenant quota: %w", err)
+		}
+
+		quota = q
+	}
+
+	quotas := p.client.CoreV1().ResourceQuotas(ns)
+	limits := p.client.CoreV1().LimitRanges(ns)
+
+	hard := tenantQuotaHard(quota)
+	if len(hard) == 0 {
+		if err := quotas.Delete(ctx, tenantQuotaName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("kubernetes: delete tenant quota: %w", err)
+		}
+
+		if err := limits.Delete(ctx, tenantLimitRangeName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("kubernetes: delete tenant limit range: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := applyTyped(ctx, quotas, &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: tenantQuotaName, Namespace: ns, Labels: labels},
+		Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+	}); err != nil {
+		return fmt.Errorf("kubernetes: apply tenant quota: %w", err)
+	}
+
+	if err := applyTyped(ctx, limits, &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: tenantLimitRangeName, Namespace: ns, Labels: labels},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type:           corev1.LimitTypeContainer,
+			Default:        defaultContainerLimit,
+			DefaultRequest: defaultContainerRequest,
+		}}},
+	}); err != nil {
+		return fmt.Errorf("kubernetes: apply tenant limit range: %w", err)
+	}
+
+	return nil
+}
+
+// tenantQuotaHard maps a tenant quota onto ResourceQuota hard limits.
+// MaxInstances caps Deployments and StatefulSets separately — an
+// instance is one or the other, and a quota can't sum across kinds —
+// so it's a backstop for the control plane's own instance count.
+func tenantQuotaHard(q *provider.TenantQuota) corev1.ResourceList {
+	hard := corev1.ResourceList{}
+	if q == nil {
+		return hard
+	}
+
+	if q.MaxInstances > 0 {
+		n := *resource.NewQuantity(int64(q.MaxInstances), resource.DecimalSI)
+		hard["count/deployments.apps"] = n
+		hard["count/statefulsets.apps"] = n
+	}
+
+	if q.MaxCPUMillis > 0 {
+		cpu := *resource.NewMilliQuantity(int64(q.MaxCPUMillis), resource.DecimalSI)
+		hard[corev1.ResourceRequestsCPU] = cpu
+		hard[corev1.ResourceLimitsCPU] = cpu
+	}
+
+	if q.MaxMemoryMB > 0 {
+		mem := *resource.NewQuantity(int64(q.MaxMemoryMB)*1024*1024, resource.BinarySI)
+		hard[corev1.ResourceRequestsMemory] = mem
+		hard[corev1.ResourceLimitsMemory] = mem
+	}
+
+	if q.MaxDiskMB > 0 {
+		hard[corev1.ResourceRequestsStorage] = *resource.NewQuantity(int64(q.MaxDiskMB)*1024*1024, resource.BinarySI)
+	}
+
+	return hard
+}
+
+// buildTenantNetworkPolicy denies ingress to every pod in ns except
+// from pods in the same namespace and from the ingress controller's
+// namespace. Egress stays open: pods need DNS and outside services,
+// and other tenants' policies already refuse cross-tenant traffic.
+func buildTenantNetworkPolicy(ns, ingressNamespace string, labels map[string]string) *networkingv1.NetworkPolicy {
+	from := []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}
+
+	if ingressNamespace != "" {
+		from = append(from, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{labelNamespaceName: ingressNamespace}},
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: tenantNetworkPolicyName, Namespace: ns, Labels: labels},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: from}},
+		},
+	}
+}
+
+// copyPullSecrets copies the configured ImagePullSecrets from the
+// configured namespace into ns, where the tenant's pods can use them.
+// A secret missing from the source is skipped, the same as a pod
+// referencing it in the shared namespace would find nothing.
+func (p *Provider) copyPullSecrets(ctx context.Context, ns string, labels map[string]string) error {
+	for _, name := range p.cfg.ImagePullSecrets {
+		src, err := p.client.CoreV1().Secrets(p.cfg.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("kubernetes: read pull secret %s: %w", name, err)
+		}
+
+		if err := applyTyped(ctx, p.client.CoreV1().Secrets(ns), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels},
+			Type:       src.Type,
+			Data:       src.Data,
+		}); err != nil {
+			return fmt.Errorf("kubernetes: copy pull secret %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// typedClient is the create-or-update surface shared by the typed
+// clientset's namespaced resource clients.
+type typedClient[T metav1.Object] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	Create(ctx context.Context, obj T, opts metav1.CreateOptions) (T, error)
+	Update(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error)
+}
+
+// applyTyped creates obj, or replaces the existing object of the same
+// name with it.
+func applyTyped[T metav1.Object](ctx context.Context, c typedClient[T], obj T) error {
+	existing, err := c.Get(ctx, obj.GetName(), metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = c.Create(ctx, obj, metav1.CreateOptions{})
+	case err != nil:
+		return err
+	default:
+		obj.SetResourceVersion(existing.GetResourceVersion())
+		_, err = c.Update(ctx, obj, metav1.UpdateOptions{})
+	}
+
+	return err
+}
@@ -0,0 +1,317 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// quotaStub serves a fixed quota for every tenant.
+type quotaStub struct {
+	quota *provider.TenantQuota
+}
+
+func (s quotaStub) TenantQuota(context.Context, string) (*provider.TenantQuota, error) {
+	return s.quota, nil
+}
+
+// newTenantTestProvider builds a Provider in tenant-namespace mode over
+// a fake clientset holding a pull secret in the base namespace.
+func newTenantTestProvider(quota *provider.TenantQuota) *Provider {
+	p := &Provider{
+		cfg: Config{
+			Namespace:        "ctrlplane",
+			TenantNamespaces: true,
+			IngressNamespace: "ingress-nginx",
+			ImagePullSecrets: []string{"regcred"},
+		},
+		client: k8sfake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "regcred", Namespace: "ctrlplane"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+		}),
+	}
+	p.SetTenantQuotaResolver(quotaStub{quota: quota})
+
+	return p
+}
+
+func tenantRequest(instID id.ID, tenantID string) provider.ProvisionRequest {
+	return provider.ProvisionRequest{
+		InstanceID: instID,
+		TenantID:   tenantID,
+		Services: []provider.ServiceSpec{{
+			Name:      "web",
+			Image:     "web:1",
+			Role:      provider.RoleMain,
+			Resources: provider.ResourceSpec{Replicas: 1},
+		}},
+	}
+}
+
+func TestTenantNamespace(t *testing.T) {
+	p := &Provider{cfg: Config{Namespace: "ctrlplane"}}
+
+	if got := p.tenantNamespace("Ten_ABC.1"); got != "ctrlplane-ten-abc-1" {
+		t.Errorf("tenantNamespace = %q, want ctrlplane-ten-abc-1", got)
+	}
+
+	long := strings.Repeat("a", 80)
+	got := p.tenantNamespace(long)
+
+	if len(got) > maxNamespaceLen {
+		t.Errorf("len = %d, want <= %d", len(got), maxNamespaceLen)
+	}
+
+	if other := p.tenantNamespace(long + "b"); other == got {
+		t.Errorf("truncated names collide: %q", got)
+	}
+}
+
+// TestProvision_TenantNamespace verifies the first provision for a
+// tenant creates its namespace with quota, defaults, network policy
+// and pull secret, and places the workload there.
+func TestProvision_TenantNamespace(t *testing.T) {
+	ctx := context.Background()
+	instID := id.New(id.PrefixInstance)
+	p := newTenantTestProvider(&provider.TenantQuota{MaxInstances: 5, MaxCPUMillis: 2000, MaxMemoryMB: 1024})
+
+	res, err := p.Provision(ctx, tenantRequest(instID, "ten_abc"))
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	const ns = "ctrlplane-ten-abc"
+
+	if res.ProviderRef != providerRef(ns, instID) {
+		t.Errorf("ProviderRef = %q, want the tenant namespace", res.ProviderRef)
+	}
+
+	nsObj, err := p.client.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get namespace: %v", err)
+	}
+
+	if nsObj.Labels[labelTenantID] != "ten_abc" || nsObj.Labels[labelManagedBy] != labelManagedByValue {
+		t.Errorf("namespace labels = %v", nsObj.Labels)
+	}
+
+	if _, err := p.client.AppsV1().Deployments(ns).Get(ctx, deploymentName(instID), metav1.GetOptions{}); err != nil {
+		t.Fatalf("deployment not in tenant namespace: %v", err)
+	}
+
+	quota, err := p.client.CoreV1().ResourceQuotas(ns).Get(ctx, tenantQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get quota: %v", err)
+	}
+
+	want := map[corev1.ResourceName]string{
+		"count/deployments.apps":      "5",
+		corev1.ResourceLimitsCPU:      "2",
+		corev1.ResourceRequestsMemory: "1Gi",
+	}
+	for name, v := range want {
+		got := quota.Spec.Hard[name]
+		if got.Cmp(resource.MustParse(v)) != 0 {
+			t.Errorf("quota %s = %s, want %s", name, got.String(), v)
+		}
+	}
+
+	if _, ok := quota.Spec.Hard[corev1.ResourceRequestsStorage]; ok {
+		t.Error("storage capped without a disk quota")
+	}
+
+	if _, err := p.client.CoreV1().LimitRanges(ns).Get(ctx, tenantLimitRangeName, metav1.GetOptions{}); err != nil {
+		t.Errorf("get limit range: %v", err)
+	}
+
+	netpol, err := p.client.NetworkingV1().NetworkPolicies(ns).Get(ctx, tenantNetworkPolicyName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get network policy: %v", err)
+	}
+
+	if len(netpol.Spec.PolicyTypes) != 1 || netpol.Spec.PolicyTypes[0] != networkingv1.PolicyTypeIngress {
+		t.Errorf("policy types = %v, want ingress only", netpol.Spec.PolicyTypes)
+	}
+
+	from := netpol.Spec.Ingress[0].From
+	if len(from) != 2 || from[0].PodSelector == nil ||
+		from[1].NamespaceSelector.MatchLabels[labelNamespaceName] != "ingress-nginx" {
+		t.Errorf("ingress peers = %+v, want same namespace and ingress-nginx", from)
+	}
+
+	secret, err := p.client.CoreV1().Secrets(ns).Get(ctx, "regcred", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("pull secret not copied: %v", err)
+	}
+
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Errorf("secret type = %s", secret.Type)
+	}
+}
+
+// TestTenantNamespace_Lookup verifies a provider that didn't provision
+// an instance — after a restart, say — finds it in its tenant namespace.
+func TestTenantNamespace_Lookup(t *testing.T) {
+	ctx := context.Background()
+	instID := id.New(id.PrefixInstance)
+	p := newTenantTestProvider(nil)
+
+	if _, err := p.Provision(ctx, tenantRequest(instID, "ten_abc")); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	restarted := &Provider{cfg: p.cfg, client: p.client}
+
+	ns, err := restarted.namespaceFor(ctx, instID)
+	if err != nil {
+		t.Fatalf("namespaceFor: %v", err)
+	}
+
+	if ns != "ctrlplane-ten-abc" {
+		t.Errorf("namespace = %q, want ctrlplane-ten-abc", ns)
+	}
+
+	if err := restarted.Deprovision(ctx, instID); err != nil {
+		t.Fatalf("Deprovision: %v", err)
+	}
+
+	_, err = p.client.AppsV1().Deployments(ns).Get(ctx, deploymentName(instID), metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("deployment after deprovision: err = %v, want NotFound", err)
+	}
+
+	if _, err := p.client.CoreV1().ResourceQuotas(ns).Get(ctx, tenantQuotaName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("quota without tenant quota: err = %v, want NotFound", err)
+	}
+}
+
+func TestProvision_TenantNamespaceRequiresTenant(t *testing.T) {
+	p := newTenantTestProvider(nil)
+
+	_, err := p.Provision(context.Background(), tenantRequest(id.New(id.PrefixInstance), ""))
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Errorf("err = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestDeleteTenant(t *testing.T) {
+	ctx := context.Background()
+	instID := id.New(id.PrefixInstance)
+	p := newTenantTestProvider(nil)
+
+	if _, err := p.Provision(ctx, tenantRequest(instID, "ten_abc")); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	if err := p.DeleteTenant(ctx, "ten_abc"); err != nil {
+		t.Fatalf("DeleteTenant: %v", err)
+	}
+
+	_, err := p.client.CoreV1().Namespaces().Get(ctx, "ctrlplane-ten-abc", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("namespace after DeleteTenant: err = %v, want NotFound", err)
+	}
+
+	if _, ok := p.namespaces[instID]; ok {
+		t.Error("instance namespace still remembered")
+	}
+
+	// Already gone is success.
+	if err := p.DeleteTenant(ctx, "ten_abc"); err != nil {
+		t.Errorf("second DeleteTenant: %v", err)
+	}
+}
+
+// TestDeleteTenant_SharedNamespace verifies DeleteTenant leaves the
+// shared namespace alone when tenant namespaces are off.
+func TestDeleteTenant_SharedNamespace(t *testing.T) {
+	ctx := context.Background()
+	p := &Provider{
+		cfg:    Config{Namespace: "default"},
+		client: k8sfake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default-ten-abc"}}),
+	}
+
+	if err := p.DeleteTenant(ctx, "ten_abc"); err != nil {
+		t.Fatalf("DeleteTenant: %v", err)
+	}
+
+	if _, err := p.client.CoreV1().Namespaces().Get(ctx, "default-ten-abc", metav1.GetOptions{}); err != nil {
+		t.Errorf("namespace deleted in shared mode: %v", err)
+	}
+}
+
+// TestApplyManifests_TenantNamespace verifies manifests and their
+// tracking land in the tenant namespace, a restarted provider finds and
+// deletes them there, and they can't target another namespace.
+func TestApplyManifests_TenantNamespace(t *testing.T) {
+	ctx := context.Background()
+	instID := id.New(id.PrefixInstance)
+	p := newTenantTestProvider(nil)
+	p.dynamic = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{configMapGVR: "ConfigMapList"})
+	p.mapper = testMapper()
+
+	if _, err := p.ApplyManifests(ctx, manifestReq(instID)); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	if _, err := p.dynamic.Resource(configMapGVR).Namespace("ctrlplane-ten-1").Get(ctx, "cm1", metav1.GetOptions{}); err != nil {
+		t.Errorf("cm1 not in tenant namespace: %v", err)
+	}
+
+	if _, err := p.dynamic.Resource(configMapGVR).Namespace("ctrlplane-ten-1").Get(ctx, trackingName(instID), metav1.GetOptions{}); err != nil {
+		t.Errorf("tracking configmap not in tenant namespace: %v", err)
+	}
+
+	restarted := &Provider{cfg: p.cfg, client: p.client, dynamic: p.dynamic, mapper: p.mapper}
+
+	st, err := restarted.ManifestStatus(ctx, instID)
+	if err != nil {
+		t.Fatalf("ManifestStatus: %v", err)
+	}
+
+	if !st.Ready {
+		t.Errorf("status after restart: %s (%s), want ready", st.State, st.Message)
+	}
+
+	if err := restarted.DeleteManifests(ctx, instID); err != nil {
+		t.Fatalf("DeleteManifests: %v", err)
+	}
+
+	if _, err := p.dynamic.Resource(configMapGVR).Namespace("ctrlplane-ten-1").Get(ctx, "cm1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("cm1 after delete: err = %v, want NotFound", err)
+	}
+
+	req := manifestReq(id.New(id.PrefixInstance))
+	req.Manifests.Docs = []string{"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm2\n  namespace: kube-system\n"}
+
+	if _, err := p.ApplyManifests(ctx, req); !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Errorf("foreign namespace: err = %v, want ErrInvalidConfig", err)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("ctrlplane-ten-1")
+
+	if err := p.confineToNamespace(obj, "ctrlplane-ten-1"); err != nil {
+		t.Errorf("own namespace: %v", err)
+	}
+}
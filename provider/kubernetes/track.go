@@ -0,0 +1,310 @@
+package kubernetes
+
+// track.go runs a release on a track beside the live workload, for
+// canary and blue-green rollouts. A track is its own Deployment and Service named
+// "<instance>-<track>", a copy of the live ones with the release
+// applied. Its pods carry labelTrackOf instead of labelInstanceID, so
+// the live Deployment and Service never select them, and the pod
+// counts, logs and watch of the instance only ever see the live pods.
+//
+// Env changes are set inline on the track's containers, where they
+// take precedence over the live services' env ConfigMaps, which the
+// track keeps referencing. Config-file changes would have to replace
+// the live ConfigMaps and are refused.
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+const (
+	// labelTrack names the track a workload runs on.
+	labelTrack = "ctrlplane.io/track"
+
+	// labelTrackOf holds the instance ID on track resources, in place
+	// of labelInstanceID.
+	labelTrackOf = "ctrlplane.io/track-of"
+)
+
+// trackName returns the Deployment and Service name of a track.
+func trackName(instanceID id.ID, track string) string {
+	return deploymentName(instanceID) + "-" + track
+}
+
+// trackLabels turns a live label set into the track's.
+func trackLabels(live map[string]string, instanceID id.ID, track string) map[string]string {
+	labels := maps.Clone(live)
+	if labels == nil {
+		labels = make(map[string]string, 2)
+	}
+
+	delete(labels, labelInstanceID)
+
+	labels[labelTrackOf] = instanceID.String()
+	labels[labelTrack] = track
+
+	return labels
+}
+
+// trackSelector matches the pods of one track.
+func trackSelector(instanceID id.ID, track string) map[string]string {
+	return map[string]string{
+		labelTrackOf: instanceID.String(),
+		labelTrack:   track,
+	}
+}
+
+// DeployTrack starts the track from the live Deployment with the
+// release applied, or moves a running track to the release.
+func (p *Provider) DeployTrack(ctx context.Context, track string, req provider.DeployRequest) (*provider.DeployResult, error) {
+	for _, sd := range req.Services {
+		if len(sd.ConfigFiles) > 0 {
+			return nil, fmt.Errorf("kubernetes: service %q: config files can't change on a %s track: %w", sd.Name, track, ctrlplane.ErrInvalidConfig)
+		}
+	}
+
+	ns, err := p.namespaceFor(ctx, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := p.client.AppsV1().Deployments(ns).Get(ctx, deploymentName(req.InstanceID), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("kubernetes: %s track needs a Deployment workload: %w", track, ctrlplane.ErrInvalidConfig)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: get deployment: %w", err)
+	}
+
+	dep := buildTrackDeployment(live, track, req)
+
+	if err := p.applyRegistryAuth(ctx, req.InstanceID, live.Labels, &dep.Spec.Template.Spec, req.Services); err != nil {
+		return nil, err
+	}
+
+	deployments := p.client.AppsV1().Deployments(ns)
+
+	existing, err := deployments.Get(ctx, dep.Name, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err := deployments.Create(ctx, dep, metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("kubernetes: create %s deployment: %w", track, err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("kubernetes: get %s deployment: %w", track, err)
+	default:
+		existing.Labels = dep.Labels
+		existing.Annotations = dep.Annotations
+		existing.Spec.Replicas = dep.Spec.Replicas
+		existing.Spec.Template = dep.Spec.Template
+
+		if _, err := deployments.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return nil, fmt.Errorf("kubernetes: update %s deployment: %w", track, err)
+		}
+	}
+
+	endpoints, err := p.ensureTrackService(ctx, ns, req.InstanceID, track)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.DeployResult{
+		ProviderRef: fmt.Sprintf("k8s:%s/%s", ns, dep.Name),
+		Status:      "deployed",
+		Endpoints:   endpoints,
+	}, nil
+}
+
+// TrackStatus returns the status of the track's Deployment.
+func (p *Provider) TrackStatus(ctx context.Context, instanceID id.ID, track string) (*provider.InstanceStatus, error) {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	dep, err := p.client.AppsV1().Deployments(ns).Get(ctx, trackName(instanceID, track), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: get %s deployment: %w", track, err)
+	}
+
+	return deploymentStatus(dep), nil
+}
+
+// RemoveTrack deletes the track's Deployment and Service.
+func (p *Provider) RemoveTrack(ctx context.Context, instanceID id.ID, track string) error {
+	ns, err := p.namespaceFor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	return p.deleteTrack(ctx, ns, trackName(instanceID, track))
+}
+
+// removeTracks deletes every track of an instance.
+func (p *Provider) removeTracks(ctx context.Context, ns string, instanceID id.ID) error {
+	list, err := p.client.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", labelTrackOf, instanceID),
+	})
+	if err != nil {
+		return fmt.Errorf("kubernetes: list tracks: %w", err)
+	}
+
+	for i := range list.Items {
+		if err := p.deleteTrack(ctx, ns, list.Items[i].Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) deleteTrack(ctx context.Context, ns, name string) error {
+	propagation := metav1.DeletePropagationForeground
+
+	err := p.client.AppsV1().Deployments(ns).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if isRealK8sError(err) {
+		return fmt.Errorf("kubernetes: delete deployment %s: %w", name, err)
+	}
+
+	err = p.client.CoreV1().Services(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if isRealK8sError(err) {
+		return fmt.Errorf("kubernetes: delete service %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// buildTrackDeployment copies the live Deployment onto the track and
+// applies the release: images, inline env and the release stamp. The
+// track runs as many replicas as the live workload asks for.
+func buildTrackDeployment(live *appsv1.Deployment, track string, req provider.DeployRequest) *appsv1.Deployment {
+	tmpl := *live.Spec.Template.DeepCopy()
+	tmpl.Labels = trackLabels(tmpl.Labels, req.InstanceID, track)
+
+	applyServiceUpdates(tmpl.Spec.Containers, tmpl.Spec.InitContainers, req.Services)
+	setTrackEnv(tmpl.Spec.Containers, req.Services)
+	setTrackEnv(tmpl.Spec.InitContainers, req.Services)
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      trackName(req.InstanceID, track),
+			Namespace: live.Namespace,
+			Labels:    trackLabels(live.Labels, req.InstanceID, track),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: live.Spec.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: trackSelector(req.InstanceID, track)},
+			Template: tmpl,
+		},
+	}
+
+	setWorkloadRelease(&dep.ObjectMeta, &dep.Spec.Template, req.ReleaseID)
+
+	return dep
+}
+
+// setTrackEnv sets each updated service's env on its container,
+// replacing same-named variables. Keys are sorted so the pod template
+// is stable across calls.
+func setTrackEnv(containers []corev1.Container, updates []provider.ServiceDeploySpec) {
+	for _, u := range updates {
+		if len(u.Env) == 0 {
+			continue
+		}
+
+		for i := range containers {
+			c := &containers[i]
+			if c.Name != u.Name {
+				continue
+			}
+
+			for _, k := range slices.Sorted(maps.Keys(u.Env)) {
+				c.Env = slices.DeleteFunc(c.Env, func(e corev1.EnvVar) bool { return e.Name == k })
+				c.Env = append(c.Env, corev1.EnvVar{Name: k, Value: u.Env[k]})
+			}
+		}
+	}
+}
+
+// ensureTrackService creates the track's Service from the live one
+// and returns the track's endpoints. A live workload without a
+// Service publishes no ports, so neither does the track.
+func (p *Provider) ensureTrackService(ctx context.Context, ns string, instanceID id.ID, track string) ([]provider.Endpoint, error) {
+	services := p.client.CoreV1().Services(ns)
+
+	live, err := services.Get(ctx, serviceName(instanceID), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes: get service: %w", err)
+	}
+
+	name := trackName(instanceID, track)
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    trackLabels(live.Labels, instanceID, track),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: trackSelector(instanceID, track),
+			Ports:    live.Spec.Ports,
+			Type:     corev1.ServiceTypeClusterIP,
+		},
+	}
+
+	if _, err := services.Create(ctx, svc, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("kubernetes: create %s service: %w", track, err)
+	}
+
+	return trackEndpoints(live.Spec.Ports, ns, name, track), nil
+}
+
+// trackEndpoints mirrors buildEndpoints for a track's Service: one
+// endpoint per service, at its first port. Service port names are
+// "<service>-<index>".
+func trackEndpoints(ports []corev1.ServicePort, ns, svcName, track string) []provider.Endpoint {
+	var endpoints []provider.Endpoint
+
+	seen := make(map[string]bool, len(ports))
+
+	for _, sp := range ports {
+		service := sp.Name
+		if i := strings.LastIndex(service, "-"); i > 0 {
+			service = service[:i]
+		}
+
+		if seen[service] {
+			continue
+		}
+
+		seen[service] = true
+
+		endpoints = append(endpoints, provider.Endpoint{
+			ServiceName: service,
+			Track:       track,
+			URL:         fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", svcName, ns, sp.Port),
+			Port:        int(sp.Port),
+			Protocol:    "TCP",
+		})
+	}
+
+	return endpoints
+}
@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestDeployTrack verifies the canary track runs the release in its
+// own Deployment and Service, which the live ones never select, and
+// that RemoveTrack takes both away again.
+func TestDeployTrack(t *testing.T) {
+	ctx := context.Background()
+	instID := id.New(id.PrefixInstance)
+	p := &Provider{cfg: Config{Namespace: "default"}, client: k8sfake.NewSimpleClientset()}
+
+	_, err := p.Provision(ctx, provider.ProvisionRequest{
+		InstanceID: instID,
+		TenantID:   "ten_abc",
+		Services: []provider.ServiceSpec{{
+			Name:      "web",
+			Image:     "web:1",
+			Role:      provider.RoleMain,
+			Env:       map[string]string{"MODE": "live"},
+			Ports:     []provider.PortSpec{{Container: 8080}},
+			Resources: provider.ResourceSpec{Replicas: 3},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	res, err := p.DeployTrack(ctx, provider.TrackCanary, provider.DeployRequest{
+		InstanceID: instID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   []provider.ServiceDeploySpec{{Name: "web", Image: "web:2", Env: map[string]string{"MODE": "canary"}}},
+	})
+	if err != nil {
+		t.Fatalf("DeployTrack: %v", err)
+	}
+
+	name := trackName(instID, provider.TrackCanary)
+
+	dep, err := p.client.AppsV1().Deployments("default").Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get track deployment: %v", err)
+	}
+
+	if _, ok := dep.Spec.Template.Labels[labelInstanceID]; ok {
+		t.Error("track pods carry the instance label the live selectors match")
+	}
+
+	if *dep.Spec.Replicas != 3 || dep.Spec.Selector.MatchLabels[labelTrack] != provider.TrackCanary {
+		t.Errorf("track spec: replicas %d, selector %v", *dep.Spec.Replicas, dep.Spec.Selector.MatchLabels)
+	}
+
+	c := dep.Spec.Template.Spec.Containers[0]
+	if c.Image != "web:2" || len(c.Env) != 1 || c.Env[0].Value != "canary" {
+		t.Errorf("track container: image %s, env %v", c.Image, c.Env)
+	}
+
+	live, _ := p.client.AppsV1().Deployments("default").Get(ctx, deploymentName(instID), metav1.GetOptions{})
+	if img := live.Spec.Template.Spec.Containers[0].Image; img != "web:1" {
+		t.Errorf("live image = %s, want it untouched", img)
+	}
+
+	svc, err := p.client.CoreV1().Services("default").Get(ctx, name, metav1.GetOptions{})
+	if err != nil || svc.Spec.Selector[labelTrackOf] != instID.String() {
+		t.Fatalf("track service: %v, %+v", err, svc)
+	}
+
+	if len(res.Endpoints) != 1 || res.Endpoints[0].ServiceName != "web" ||
+		res.Endpoints[0].Track != provider.TrackCanary || res.Endpoints[0].Port != 8080 {
+		t.Errorf("endpoints = %+v", res.Endpoints)
+	}
+
+	if _, err := p.TrackStatus(ctx, instID, provider.TrackCanary); err != nil {
+		t.Errorf("TrackStatus: %v", err)
+	}
+
+	if err := p.RemoveTrack(ctx, instID, provider.TrackCanary); err != nil {
+		t.Fatalf("RemoveTrack: %v", err)
+	}
+
+	if _, err := p.client.AppsV1().Deployments("default").Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("track deployment after remove: %v", err)
+	}
+
+	if err := p.RemoveTrack(ctx, instID, provider.TrackCanary); err != nil {
+		t.Errorf("second RemoveTrack: %v", err)
+	}
+}
+
+func TestDeployTrack_RejectsConfigFiles(t *testing.T) {
+	p := &Provider{cfg: Config{Namespace: "default"}, client: k8sfake.NewSimpleClientset()}
+
+	_, err := p.DeployTrack(context.Background(), provider.TrackCanary, provider.DeployRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services: []provider.ServiceDeploySpec{{
+			Name:        "web",
+			Image:       "web:2",
+			ConfigFiles: []provider.ConfigFile{{Path: "/etc/app.yaml", Content: "x: 1"}},
+		}},
+	})
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Errorf("err = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,181 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// reasonOOMKilled is the terminated-container reason the kubelet
+// records for a memory-limit kill.
+const reasonOOMKilled = "OOMKilled"
+
+// Watch streams state changes from shared informers on the
+// namespace's Deployments, StatefulSets and Pods, restricted to objects
+// carrying the ctrlplane instance label. Deployment and StatefulSet
+// updates report the same aggregate state Status derives; pod updates
+// report container restarts and OOM kills. Informers resync from a fresh list on
+// every (re)connect, so each instance's current state is reported
+// once at the start of a watch. With TenantNamespaces set the
+// informers span all namespaces.
+func (p *Provider) Watch(ctx context.Context) (<-chan provider.StateChange, error) {
+	ns := p.cfg.Namespace
+	if p.cfg.TenantNamespaces {
+		ns = metav1.NamespaceAll
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(p.client, 0,
+		informers.WithNamespace(ns),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = labelInstanceID
+		}),
+	)
+
+	deployments := factory.Apps().V1().Deployments().Informer()
+	statefulSets := factory.Apps().V1().StatefulSets().Informer()
+	pods := factory.Core().V1().Pods().Informer()
+	stream := provider.NewWatchStream(64)
+
+	controllers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { sendController(ctx, stream, obj, false) },
+		UpdateFunc: func(_, obj any) { sendController(ctx, stream, obj, false) },
+		DeleteFunc: func(obj any) { sendController(ctx, stream, obj, true) },
+	}
+
+	if _, err := deployments.AddEventHandler(controllers); err != nil {
+		return nil, fmt.Errorf("kubernetes: watch deployments: %w", err)
+	}
+
+	if _, err := statefulSets.AddEventHandler(controllers); err != nil {
+		return nil, fmt.Errorf("kubernetes: watch statefulsets: %w", err)
+	}
+
+	if _, err := pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, obj any) {
+			oldPod, ok1 := oldObj.(*corev1.Pod)
+			pod, ok2 := obj.(*corev1.Pod)
+
+			if ok1 && ok2 {
+				for _, c := range podChanges(oldPod, pod) {
+					stream.Send(ctx, c)
+				}
+			}
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("kubernetes: watch pods: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), deployments.HasSynced, statefulSets.HasSynced, pods.HasSynced) {
+		factory.Shutdown()
+
+		return nil, fmt.Errorf("kubernetes: watch: informer cache did not sync: %w", context.Cause(ctx))
+	}
+
+	go func() {
+		<-ctx.Done()
+		// Shutdown waits for the informer goroutines, so no handler
+		// sends after the stream is closed.
+		factory.Shutdown()
+		stream.Close()
+	}()
+
+	return stream.C(), nil
+}
+
+// sendController reports the state of a Deployment or StatefulSet the
+// informer saw added, updated or deleted.
+func sendController(ctx context.Context, stream *provider.WatchStream, obj any, deleted bool) {
+	if tomb, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tomb.Obj
+	}
+
+	var (
+		labels map[string]string
+		status *provider.InstanceStatus
+	)
+
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		labels, status = o.Labels, deploymentStatus(o)
+	case *appsv1.StatefulSet:
+		labels, status = o.Labels, statefulSetStatus(o)
+	default:
+		return
+	}
+
+	instanceID, err := id.Parse(labels[labelInstanceID])
+	if err != nil {
+		return
+	}
+
+	if deleted {
+		status = &provider.InstanceStatus{State: provider.StateDestroyed}
+	}
+
+	stream.Send(ctx, provider.StateChange{
+		Kind:       provider.ChangeState,
+		InstanceID: instanceID,
+		TenantID:   labels[labelTenantID],
+		Status:     status,
+	})
+}
+
+// podChanges compares two versions of a pod's container statuses and
+// returns a restart for every container whose restart count went up,
+// an OOM kill when the restart was one. Pure function so it's
+// testable without an informer.
+func podChanges(oldPod, pod *corev1.Pod) []provider.StateChange {
+	instanceID, err := id.Parse(pod.Labels[labelInstanceID])
+	if err != nil {
+		return nil
+	}
+
+	before := make(map[string]int32, len(oldPod.Status.ContainerStatuses))
+	for _, cs := range oldPod.Status.ContainerStatuses {
+		before[cs.Name] = cs.RestartCount
+	}
+
+	var out []provider.StateChange
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.RestartCount <= before[cs.Name] {
+			continue
+		}
+
+		c := provider.StateChange{
+			Kind:       provider.ChangeRestart,
+			InstanceID: instanceID,
+			TenantID:   pod.Labels[labelTenantID],
+			Service:    cs.Name,
+			Restarts:   int(cs.RestartCount),
+			Message:    "container restarted",
+			At:         time.Now().UTC(),
+		}
+
+		if term := cs.LastTerminationState.Terminated; term != nil {
+			c.Message = fmt.Sprintf("container exited with code %d (%s)", term.ExitCode, term.Reason)
+			if !term.FinishedAt.IsZero() {
+				c.At = term.FinishedAt.UTC()
+			}
+
+			if term.Reason == reasonOOMKilled {
+				c.Kind = provider.ChangeOOMKilled
+			}
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
@@ -0,0 +1,176 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestPodChanges_ReportsRestartsAndOOMKills verifies a restart-count
+// increase is reported per container, as an OOM kill when the last
+// termination says so.
+func TestPodChanges_ReportsRestartsAndOOMKills(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	pod := func(apiRestarts, workerRestarts int32, workerReason string) *corev1.Pod {
+		worker := corev1.ContainerStatus{Name: "worker", RestartCount: workerRestarts}
+		if workerReason != "" {
+			worker.LastTerminationState.Terminated = &corev1.ContainerStateTerminated{ExitCode: 137, Reason: workerReason}
+		}
+
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Labels: instanceLabels(instID, "tenant-1", nil)},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "api", RestartCount: apiRestarts},
+				worker,
+			}},
+		}
+	}
+
+	if got := podChanges(pod(0, 0, ""), pod(0, 0, "")); len(got) != 0 {
+		t.Fatalf("unchanged pod: %+v", got)
+	}
+
+	got := podChanges(pod(0, 0, ""), pod(1, 1, reasonOOMKilled))
+	if len(got) != 2 {
+		t.Fatalf("changes = %+v, want 2", got)
+	}
+
+	if got[0].Kind != provider.ChangeRestart || got[0].Service != "api" || got[0].Restarts != 1 {
+		t.Fatalf("api change = %+v", got[0])
+	}
+
+	if got[1].Kind != provider.ChangeOOMKilled || got[1].Service != "worker" || got[1].InstanceID != instID || got[1].TenantID != "tenant-1" {
+		t.Fatalf("worker change = %+v", got[1])
+	}
+}
+
+// TestWatch_ReportsDeploymentState verifies the informer reports an
+// existing Deployment's state on start, a change to it, and its
+// deletion.
+func TestWatch_ReportsDeploymentState(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName(instID),
+			Namespace: "default",
+			Labels:    instanceLabels(instID, "tenant-1", nil),
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 1, ReadyReplicas: 1, AvailableReplicas: 1, UpdatedReplicas: 1},
+	}
+
+	client := k8sfake.NewSimpleClientset(dep)
+	p := &Provider{cfg: Config{Namespace: "default"}, client: client}
+
+	changes, err := p.Watch(t.Context())
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	next := func() provider.StateChange {
+		t.Helper()
+
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("no state change within 5s")
+
+			return provider.StateChange{}
+		}
+	}
+
+	first := next()
+	if first.InstanceID != instID || first.TenantID != "tenant-1" || first.Status == nil {
+		t.Fatalf("initial change = %+v", first)
+	}
+
+	if err := client.AppsV1().Deployments("default").Delete(t.Context(), dep.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if c := next(); c.Status == nil || c.Status.State != provider.StateDestroyed {
+		t.Fatalf("after delete = %+v, want destroyed", c)
+	}
+}
+
+// TestWatch_ReportsStatefulSetState verifies a StatefulSet instance's
+// state is reported on start, on a change and on deletion, and that
+// Status reads it too.
+func TestWatch_ReportsStatefulSetState(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	replicas := int32(2)
+	ss := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName(instID),
+			Namespace: "default",
+			Labels:    instanceLabels(instID, "tenant-1", nil),
+		},
+		Spec:   appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status: appsv1.StatefulSetStatus{Replicas: 2, ReadyReplicas: 2, CurrentReplicas: 2, UpdatedReplicas: 2},
+	}
+
+	client := k8sfake.NewSimpleClientset(ss)
+	p := &Provider{cfg: Config{Namespace: "default"}, client: client}
+
+	changes, err := p.Watch(t.Context())
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	next := func() provider.StateChange {
+		t.Helper()
+
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("no state change within 5s")
+
+			return provider.StateChange{}
+		}
+	}
+
+	first := next()
+	if first.InstanceID != instID || first.TenantID != "tenant-1" || first.Status == nil || first.Status.State != provider.StateRunning {
+		t.Fatalf("initial change = %+v, want running", first)
+	}
+
+	st, err := p.Status(t.Context(), instID)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+
+	if st.State != provider.StateRunning || st.DesiredReplicas != 2 {
+		t.Fatalf("Status = %+v, want running with 2 desired", st)
+	}
+
+	ss.Status.ReadyReplicas = 1
+	if _, err := client.AppsV1().StatefulSets("default").Update(t.Context(), ss, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if c := next(); c.Status == nil || c.Status.State != provider.StateStarting || c.Status.ReadyReplicas != 1 {
+		t.Fatalf("after update = %+v, want starting with 1 ready", c)
+	}
+
+	if err := client.AppsV1().StatefulSets("default").Delete(t.Context(), ss.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if c := next(); c.Status == nil || c.Status.State != provider.StateDestroyed {
+		t.Fatalf("after delete = %+v, want destroyed", c)
+	}
+}
@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/xraph/ctrlplane/id"
+)
+
+// InstanceLabelResolver looks up an instance's current labels, for
+// provider wrappers that select instances by label but are only handed
+// an instance ID. An instance it can't find is ctrlplane.ErrNotFound.
+type InstanceLabelResolver interface {
+	InstanceLabels(ctx context.Context, instanceID id.ID) (map[string]string, error)
+}
+
+// InstanceLabelAware is an optional interface for provider wrappers
+// that select instances by label, such as fault injection. The control
+// plane hands every such layer of a registered provider a resolver
+// over its instance store.
+type InstanceLabelAware interface {
+	SetInstanceLabelResolver(r InstanceLabelResolver)
+}
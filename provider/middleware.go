@@ -0,0 +1,505 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+)
+
+// Method names passed to middleware in Call.Method. They match the
+// Go method names, so a middleware configured by name ("Status")
+// reads naturally.
+const (
+	MethodProvision       = "Provision"
+	MethodDeprovision     = "Deprovision"
+	MethodStart           = "Start"
+	MethodStop            = "Stop"
+	MethodRestart         = "Restart"
+	MethodStatus          = "Status"
+	MethodDeploy          = "Deploy"
+	MethodRollback        = "Rollback"
+	MethodRollbackRelease = "RollbackRelease"
+	MethodDeployTrack     = "DeployTrack"
+	MethodTrackStatus     = "TrackStatus"
+	MethodRemoveTrack     = "RemoveTrack"
+	MethodScale           = "Scale"
+	MethodResources       = "Resources"
+	MethodLogs            = "Logs"
+	MethodExec            = "Exec"
+	MethodHealthCheck     = "HealthCheck"
+	MethodApplyManifests  = "ApplyManifests"
+	MethodDeleteManifests = "DeleteManifests"
+	MethodManifestStatus  = "ManifestStatus"
+	MethodHelmInstall     = "HelmInstall"
+	MethodHelmUpgrade     = "HelmUpgrade"
+	MethodHelmUninstall   = "HelmUninstall"
+	MethodHelmStatus      = "HelmStatus"
+	MethodArgoApply       = "ArgoApply"
+	MethodArgoDelete      = "ArgoDelete"
+	MethodArgoStatus      = "ArgoStatus"
+	MethodWatch           = "Watch"
+)
+
+// Call describes one provider call passing through a middleware
+// chain.
+type Call struct {
+	// Provider is the name the provider is registered under.
+	Provider string
+
+	// Method is one of the Method* constants.
+	Method string
+
+	// InstanceID is the instance the call is about; zero for calls
+	// that aren't about one (HealthCheck).
+	InstanceID id.ID
+}
+
+// Idempotent reports whether the call only reads, so repeating it
+// after a failure can't change anything. Retry middleware retries
+// only these.
+func (c Call) Idempotent() bool {
+	switch c.Method {
+	case MethodStatus, MethodResources, MethodLogs, MethodHealthCheck, MethodTrackStatus,
+		MethodManifestStatus, MethodHelmStatus, MethodArgoStatus:
+		return true
+	default:
+		return false
+	}
+}
+
+// Streaming reports whether the call's result outlives the call: a
+// Logs or Watch stream keeps reading on the call's context after it
+// returns, so a middleware must not cancel that context on return.
+func (c Call) Streaming() bool {
+	return c.Method == MethodLogs || c.Method == MethodWatch
+}
+
+// Invoker performs the rest of a call: the next middleware, or the
+// provider itself. The result is the provider method's first return
+// value (nil for methods that only return an error).
+type Invoker func(ctx context.Context) (any, error)
+
+// Middleware intercepts provider calls. It may run code around next,
+// call it several times (retries), or not at all (an open circuit).
+// A middleware that abandons a call still running in the background
+// should close its result if it turns out to be an io.Closer, so a
+// late Logs stream doesn't leak.
+type Middleware func(ctx context.Context, call Call, next Invoker) (any, error)
+
+// errNoEngine is returned by engine methods of a chained provider
+// whose underlying provider doesn't implement the engine. The
+// dispatcher gates engines on Capabilities, which pass through
+// unchanged, so it never sees this.
+var errNoEngine = errors.New("provider does not implement this engine")
+
+// Chain wraps p so every call passes through mws, the first
+// outermost. The result implements HealthChecker, Rollbacker and
+// TrackDeployer exactly when p does, so callers' type assertions
+// behave as they would on p; the engine interfaces are always present
+// and gated by p's Capabilities. Watcher is always present too: Watch
+// on a provider that can't watch fails with ctrlplane.ErrNotImplemented.
+// Chain with no middleware returns p.
+func Chain(name string, p Provider, mws ...Middleware) Provider {
+	if len(mws) == 0 {
+		return p
+	}
+
+	c := &chained{name: name, next: p, mws: slices.Clone(mws)}
+
+	_, hc := p.(HealthChecker)
+	_, rb := p.(Rollbacker)
+	_, td := p.(TrackDeployer)
+	tracks := chainedTracks{c}
+
+	switch {
+	case hc && rb && td:
+		return &chainedHealthRollbackTracker{&chainedHealthRollbacker{c}, tracks}
+	case hc && rb:
+		return &chainedHealthRollbacker{c}
+	case hc && td:
+		return &chainedHealthTracker{&chainedHealthChecker{c}, tracks}
+	case hc:
+		return &chainedHealthChecker{c}
+	case rb && td:
+		return &chainedRollbackTracker{&chainedRollbacker{c}, tracks}
+	case rb:
+		return &chainedRollbacker{c}
+	case td:
+		return &chainedTracker{c, tracks}
+	default:
+		return c
+	}
+}
+
+// chained is the provider Chain returns when p implements none of
+// HealthChecker, Rollbacker and TrackDeployer; the other shapes embed
+// it.
+type chained struct {
+	name string
+	next Provider
+	mws  []Middleware
+}
+
+type chainedHealthChecker struct{ *chained }
+
+type chainedRollbacker struct{ *chained }
+
+type chainedHealthRollbacker struct{ *chained }
+
+// chainedTracks holds the TrackDeployer methods; the tracker shapes
+// add it to the shape p has otherwise.
+type chainedTracks struct{ c *chained }
+
+type chainedTracker struct {
+	*chained
+	chainedTracks
+}
+
+type chainedHealthTracker struct {
+	*chainedHealthChecker
+	chainedTracks
+}
+
+type chainedRollbackTracker struct {
+	*chainedRollbacker
+	chainedTracks
+}
+
+type chainedHealthRollbackTracker struct {
+	*chainedHealthRollbacker
+	chainedTracks
+}
+
+// Compile-time interface checks.
+var (
+	_ Provider       = (*chained)(nil)
+	_ ManifestEngine = (*chained)(nil)
+	_ HelmEngine     = (*chained)(nil)
+	_ ArgoEngine     = (*chained)(nil)
+	_ Watcher        = (*chained)(nil)
+	_ HealthChecker  = (*chainedHealthChecker)(nil)
+	_ Rollbacker     = (*chainedRollbacker)(nil)
+	_ HealthChecker  = (*chainedHealthRollbacker)(nil)
+	_ Rollbacker     = (*chainedHealthRollbacker)(nil)
+	_ TrackDeployer  = (*chainedTracker)(nil)
+	_ HealthChecker  = (*chainedHealthTracker)(nil)
+	_ TrackDeployer  = (*chainedHealthTracker)(nil)
+	_ Rollbacker     = (*chainedRollbackTracker)(nil)
+	_ TrackDeployer  = (*chainedRollbackTracker)(nil)
+	_ HealthChecker  = (*chainedHealthRollbackTracker)(nil)
+	_ Rollbacker     = (*chainedHealthRollbackTracker)(nil)
+	_ TrackDeployer  = (*chainedHealthRollbackTracker)(nil)
+)
+
+// Unwrap returns the provider the chain wraps.
+func (c *chained) Unwrap() Provider { return c.next }
+
+// Unwrap peels every wrapper — a middleware chain, fault injection —
+// off p and returns the provider underneath, for reaching optional
+// interfaces the wrappers don't forward.
+func Unwrap(p Provider) Provider {
+	for {
+		w, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			return p
+		}
+
+		p = w.Unwrap()
+	}
+}
+
+// invoke runs fn through the middleware chain and converts the result
+// back to its static type.
+func invoke[T any](ctx context.Context, c *chained, method string, instanceID id.ID, fn func(ctx context.Context) (T, error)) (T, error) {
+	call := Call{Provider: c.name, Method: method, InstanceID: instanceID}
+
+	next := func(ctx context.Context) (any, error) { return fn(ctx) }
+	for i := len(c.mws) - 1; i >= 0; i-- {
+		mw, inner := c.mws[i], next
+		next = func(ctx context.Context) (any, error) { return mw(ctx, call, inner) }
+	}
+
+	var zero T
+
+	res, err := next(ctx)
+	if err != nil {
+		return zero, err
+	}
+
+	out, ok := res.(T)
+	if !ok && res != nil {
+		return zero, fmt.Errorf("provider: %s middleware returned %T, want %T", method, res, zero)
+	}
+
+	return out, nil
+}
+
+// invokeErr is invoke for methods that only return an error.
+func invokeErr(ctx context.Context, c *chained, method string, instanceID id.ID, fn func(ctx context.Context) error) error {
+	_, err := invoke(ctx, c, method, instanceID, func(ctx context.Context) (any, error) { return nil, fn(ctx) })
+
+	return err
+}
+
+func (c *chained) Info() ProviderInfo { return c.next.Info() }
+
+func (c *chained) Capabilities() []Capability { return c.next.Capabilities() }
+
+func (c *chained) Provision(ctx context.Context, req ProvisionRequest) (*ProvisionResult, error) {
+	return invoke(ctx, c, MethodProvision, req.InstanceID, func(ctx context.Context) (*ProvisionResult, error) {
+		return c.next.Provision(ctx, req)
+	})
+}
+
+func (c *chained) Deprovision(ctx context.Context, instanceID id.ID) error {
+	return invokeErr(ctx, c, MethodDeprovision, instanceID, func(ctx context.Context) error {
+		return c.next.Deprovision(ctx, instanceID)
+	})
+}
+
+func (c *chained) Start(ctx context.Context, instanceID id.ID) error {
+	return invokeErr(ctx, c, MethodStart, instanceID, func(ctx context.Context) error {
+		return c.next.Start(ctx, instanceID)
+	})
+}
+
+func (c *chained) Stop(ctx context.Context, instanceID id.ID) error {
+	return invokeErr(ctx, c, MethodStop, instanceID, func(ctx context.Context) error {
+		return c.next.Stop(ctx, instanceID)
+	})
+}
+
+func (c *chained) Restart(ctx context.Context, instanceID id.ID) error {
+	return invokeErr(ctx, c, MethodRestart, instanceID, func(ctx context.Context) error {
+		return c.next.Restart(ctx, instanceID)
+	})
+}
+
+func (c *chained) Status(ctx context.Context, instanceID id.ID) (*InstanceStatus, error) {
+	return invoke(ctx, c, MethodStatus, instanceID, func(ctx context.Context) (*InstanceStatus, error) {
+		return c.next.Status(ctx, instanceID)
+	})
+}
+
+func (c *chained) Deploy(ctx context.Context, req DeployRequest) (*DeployResult, error) {
+	return invoke(ctx, c, MethodDeploy, req.InstanceID, func(ctx context.Context) (*DeployResult, error) {
+		return c.next.Deploy(ctx, req)
+	})
+}
+
+func (c *chained) Rollback(ctx context.Context, instanceID id.ID, releaseID id.ID) error {
+	return invokeErr(ctx, c, MethodRollback, instanceID, func(ctx context.Context) error {
+		return c.next.Rollback(ctx, instanceID, releaseID)
+	})
+}
+
+func (c *chained) Scale(ctx context.Context, instanceID id.ID, spec ResourceSpec) error {
+	return invokeErr(ctx, c, MethodScale, instanceID, func(ctx context.Context) error {
+		return c.next.Scale(ctx, instanceID, spec)
+	})
+}
+
+func (c *chained) Resources(ctx context.Context, instanceID id.ID) (*ResourceUsage, error) {
+	return invoke(ctx, c, MethodResources, instanceID, func(ctx context.Context) (*ResourceUsage, error) {
+		return c.next.Resources(ctx, instanceID)
+	})
+}
+
+func (c *chained) Logs(ctx context.Context, instanceID id.ID, opts LogOptions) (io.ReadCloser, error) {
+	return invoke(ctx, c, MethodLogs, instanceID, func(ctx context.Context) (io.ReadCloser, error) {
+		return c.next.Logs(ctx, instanceID, opts)
+	})
+}
+
+func (c *chained) Exec(ctx context.Context, instanceID id.ID, cmd ExecRequest) (*ExecResult, error) {
+	return invoke(ctx, c, MethodExec, instanceID, func(ctx context.Context) (*ExecResult, error) {
+		return c.next.Exec(ctx, instanceID, cmd)
+	})
+}
+
+func (c *chainedHealthChecker) HealthCheck(ctx context.Context) (*HealthStatus, error) {
+	return c.healthCheck(ctx)
+}
+
+func (c *chainedHealthRollbacker) HealthCheck(ctx context.Context) (*HealthStatus, error) {
+	return c.healthCheck(ctx)
+}
+
+func (c *chained) healthCheck(ctx context.Context) (*HealthStatus, error) {
+	hc, _ := c.next.(HealthChecker)
+
+	return invoke(ctx, c, MethodHealthCheck, id.Nil, func(ctx context.Context) (*HealthStatus, error) {
+		return hc.HealthCheck(ctx)
+	})
+}
+
+func (c *chainedRollbacker) RollbackRelease(ctx context.Context, req RollbackRequest) (*DeployResult, error) {
+	return c.rollbackRelease(ctx, req)
+}
+
+func (c *chainedHealthRollbacker) RollbackRelease(ctx context.Context, req RollbackRequest) (*DeployResult, error) {
+	return c.rollbackRelease(ctx, req)
+}
+
+func (c *chained) rollbackRelease(ctx context.Context, req RollbackRequest) (*DeployResult, error) {
+	rb, _ := c.next.(Rollbacker)
+
+	return invoke(ctx, c, MethodRollbackRelease, req.InstanceID, func(ctx context.Context) (*DeployResult, error) {
+		return rb.RollbackRelease(ctx, req)
+	})
+}
+
+func (t chainedTracks) DeployTrack(ctx context.Context, track string, req DeployRequest) (*DeployResult, error) {
+	td, _ := t.c.next.(TrackDeployer)
+
+	return invoke(ctx, t.c, MethodDeployTrack, req.InstanceID, func(ctx context.Context) (*DeployResult, error) {
+		return td.DeployTrack(ctx, track, req)
+	})
+}
+
+func (t chainedTracks) TrackStatus(ctx context.Context, instanceID id.ID, track string) (*InstanceStatus, error) {
+	td, _ := t.c.next.(TrackDeployer)
+
+	return invoke(ctx, t.c, MethodTrackStatus, instanceID, func(ctx context.Context) (*InstanceStatus, error) {
+		return td.TrackStatus(ctx, instanceID, track)
+	})
+}
+
+func (t chainedTracks) RemoveTrack(ctx context.Context, instanceID id.ID, track string) error {
+	td, _ := t.c.next.(TrackDeployer)
+
+	return invokeErr(ctx, t.c, MethodRemoveTrack, instanceID, func(ctx context.Context) error {
+		return td.RemoveTrack(ctx, instanceID, track)
+	})
+}
+
+// noEngine reports an engine the underlying provider lacks.
+func (c *chained) noEngine(method string) error {
+	return fmt.Errorf("provider: %s on %s: %w", method, c.name, errNoEngine)
+}
+
+func (c *chained) ApplyManifests(ctx context.Context, req ManifestApplyRequest) (*ProvisionResult, error) {
+	eng, ok := c.next.(ManifestEngine)
+	if !ok {
+		return nil, c.noEngine(MethodApplyManifests)
+	}
+
+	return invoke(ctx, c, MethodApplyManifests, req.InstanceID, func(ctx context.Context) (*ProvisionResult, error) {
+		return eng.ApplyManifests(ctx, req)
+	})
+}
+
+func (c *chained) DeleteManifests(ctx context.Context, instanceID id.ID) error {
+	eng, ok := c.next.(ManifestEngine)
+	if !ok {
+		return c.noEngine(MethodDeleteManifests)
+	}
+
+	return invokeErr(ctx, c, MethodDeleteManifests, instanceID, func(ctx context.Context) error {
+		return eng.DeleteManifests(ctx, instanceID)
+	})
+}
+
+func (c *chained) ManifestStatus(ctx context.Context, instanceID id.ID) (*InstanceStatus, error) {
+	eng, ok := c.next.(ManifestEngine)
+	if !ok {
+		return nil, c.noEngine(MethodManifestStatus)
+	}
+
+	return invoke(ctx, c, MethodManifestStatus, instanceID, func(ctx context.Context) (*InstanceStatus, error) {
+		return eng.ManifestStatus(ctx, instanceID)
+	})
+}
+
+func (c *chained) HelmInstall(ctx context.Context, req HelmInstallRequest) (*ProvisionResult, error) {
+	eng, ok := c.next.(HelmEngine)
+	if !ok {
+		return nil, c.noEngine(MethodHelmInstall)
+	}
+
+	return invoke(ctx, c, MethodHelmInstall, req.InstanceID, func(ctx context.Context) (*ProvisionResult, error) {
+		return eng.HelmInstall(ctx, req)
+	})
+}
+
+func (c *chained) HelmUpgrade(ctx context.Context, req HelmUpgradeRequest) (*DeployResult, error) {
+	eng, ok := c.next.(HelmEngine)
+	if !ok {
+		return nil, c.noEngine(MethodHelmUpgrade)
+	}
+
+	return invoke(ctx, c, MethodHelmUpgrade, req.InstanceID, func(ctx context.Context) (*DeployResult, error) {
+		return eng.HelmUpgrade(ctx, req)
+	})
+}
+
+func (c *chained) HelmUninstall(ctx context.Context, instanceID id.ID) error {
+	eng, ok := c.next.(HelmEngine)
+	if !ok {
+		return c.noEngine(MethodHelmUninstall)
+	}
+
+	return invokeErr(ctx, c, MethodHelmUninstall, instanceID, func(ctx context.Context) error {
+		return eng.HelmUninstall(ctx, instanceID)
+	})
+}
+
+func (c *chained) HelmStatus(ctx context.Context, instanceID id.ID) (*InstanceStatus, error) {
+	eng, ok := c.next.(HelmEngine)
+	if !ok {
+		return nil, c.noEngine(MethodHelmStatus)
+	}
+
+	return invoke(ctx, c, MethodHelmStatus, instanceID, func(ctx context.Context) (*InstanceStatus, error) {
+		return eng.HelmStatus(ctx, instanceID)
+	})
+}
+
+func (c *chained) ArgoApply(ctx context.Context, req ArgoApplyRequest) (*ProvisionResult, error) {
+	eng, ok := c.next.(ArgoEngine)
+	if !ok {
+		return nil, c.noEngine(MethodArgoApply)
+	}
+
+	return invoke(ctx, c, MethodArgoApply, req.InstanceID, func(ctx context.Context) (*ProvisionResult, error) {
+		return eng.ArgoApply(ctx, req)
+	})
+}
+
+func (c *chained) ArgoDelete(ctx context.Context, instanceID id.ID) error {
+	eng, ok := c.next.(ArgoEngine)
+	if !ok {
+		return c.noEngine(MethodArgoDelete)
+	}
+
+	return invokeErr(ctx, c, MethodArgoDelete, instanceID, func(ctx context.Context) error {
+		return eng.ArgoDelete(ctx, instanceID)
+	})
+}
+
+func (c *chained) ArgoStatus(ctx context.Context, instanceID id.ID) (*InstanceStatus, error) {
+	eng, ok := c.next.(ArgoEngine)
+	if !ok {
+		return nil, c.noEngine(MethodArgoStatus)
+	}
+
+	return invoke(ctx, c, MethodArgoStatus, instanceID, func(ctx context.Context) (*InstanceStatus, error) {
+		return eng.ArgoStatus(ctx, instanceID)
+	})
+}
+
+func (c *chained) Watch(ctx context.Context) (<-chan StateChange, error) {
+	w, ok := c.next.(Watcher)
+	if !ok {
+		return nil, fmt.Errorf("provider: watch %s: %w", c.name, ctrlplane.ErrNotImplemented)
+	}
+
+	return invoke(ctx, c, MethodWatch, id.Nil, func(ctx context.Context) (<-chan StateChange, error) {
+		return w.Watch(ctx)
+	})
+}
@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its
+// circuit is open. It wraps ctrlplane.ErrProviderUnavail.
+var ErrCircuitOpen = fmt.Errorf("circuit open: %w", ctrlplane.ErrProviderUnavail)
+
+// State is a circuit's position.
+type State string
+
+const (
+	// StateClosed passes calls through and counts failures.
+	StateClosed State = "closed"
+
+	// StateOpen rejects calls with ErrCircuitOpen.
+	StateOpen State = "open"
+
+	// StateHalfOpen lets a single probe call through; its outcome
+	// closes or re-opens the circuit.
+	StateHalfOpen State = "half_open"
+)
+
+// CircuitStatus is a snapshot of one provider's circuit.
+type CircuitStatus struct {
+	Provider string    `json:"provider"`
+	State    State     `json:"state"`
+	Failures int       `json:"failures"`
+	LastErr  string    `json:"last_error,omitempty"`
+	OpenedAt time.Time `json:"opened_at,omitzero"`
+}
+
+// BreakerOption configures a Breaker.
+type BreakerOption func(*Breaker) error
+
+// WithFailureThreshold sets how many consecutive failures open a
+// circuit. Default: 5.
+func WithFailureThreshold(n int) BreakerOption {
+	return func(b *Breaker) error {
+		if n < 1 {
+			return fmt.Errorf("middleware: failure threshold %d: %w", n, ctrlplane.ErrInvalidConfig)
+		}
+
+		b.threshold = n
+
+		return nil
+	}
+}
+
+// WithOpenDuration sets how long a circuit stays open before a probe
+// is let through. Default: 30s.
+func WithOpenDuration(d time.Duration) BreakerOption {
+	return func(b *Breaker) error {
+		if d <= 0 {
+			return fmt.Errorf("middleware: open duration %s: %w", d, ctrlplane.ErrInvalidConfig)
+		}
+
+		b.openFor = d
+
+		return nil
+	}
+}
+
+// Breaker keeps one circuit per provider name. Failures that say
+// something about the backend (errors, timeouts) count toward opening
+// it; errors about the request (not found, invalid input, a caller
+// cancelling) don't. Thread-safe.
+type Breaker struct {
+	threshold int
+	openFor   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit is one provider's state.
+type circuit struct {
+	state    State
+	failures int
+	lastErr  string
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker creates a Breaker with all circuits closed.
+func NewBreaker(opts ...BreakerOption) (*Breaker, error) {
+	b := &Breaker{
+		threshold: 5,
+		openFor:   30 * time.Second,
+		now:       time.Now,
+		circuits:  make(map[string]*circuit),
+	}
+
+	for _, opt := range opts {
+		if err := opt(b); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// Middleware returns the provider.Middleware that consults and feeds
+// this breaker.
+func (b *Breaker) Middleware() provider.Middleware {
+	return func(ctx context.Context, call provider.Call, next provider.Invoker) (any, error) {
+		if !b.allow(call.Provider) {
+			return nil, fmt.Errorf("%s %s: %w", call.Provider, call.Method, ErrCircuitOpen)
+		}
+
+		val, err := next(ctx)
+		b.record(call.Provider, err)
+
+		return val, err
+	}
+}
+
+// CircuitOpen reports whether name's circuit is rejecting calls, and
+// why. Half-open counts as open: only the probe gets through.
+func (b *Breaker) CircuitOpen(name string) (reason string, open bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[name]
+	if !ok || c.state == StateClosed {
+		return "", false
+	}
+
+	return fmt.Sprintf("circuit %s after %d consecutive failures: %s", c.state, c.failures, c.lastErr), true
+}
+
+// Status returns name's circuit.
+func (b *Breaker) Status(name string) CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.statusLocked(name)
+}
+
+// Statuses returns every circuit that has seen a call.
+func (b *Breaker) Statuses() []CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make([]CircuitStatus, 0, len(b.circuits))
+	for name := range b.circuits {
+		out = append(out, b.statusLocked(name))
+	}
+
+	return out
+}
+
+// Reset closes name's circuit.
+func (b *Breaker) Reset(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.circuits, name)
+}
+
+func (b *Breaker) statusLocked(name string) CircuitStatus {
+	c, ok := b.circuits[name]
+	if !ok {
+		return CircuitStatus{Provider: name, State: StateClosed}
+	}
+
+	return CircuitStatus{
+		Provider: name,
+		State:    c.state,
+		Failures: c.failures,
+		LastErr:  c.lastErr,
+		OpenedAt: c.openedAt,
+	}
+}
+
+// allow reports whether a call to name may proceed, moving an open
+// circuit whose wait has elapsed to half-open and admitting one
+// probe.
+func (b *Breaker) allow(name string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(name)
+
+	switch c.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		if b.now().Sub(c.openedAt) < b.openFor {
+			return false
+		}
+
+		c.state = StateHalfOpen
+	}
+
+	if c.probing {
+		return false
+	}
+
+	c.probing = true
+
+	return true
+}
+
+// record feeds a call's outcome into name's circuit.
+func (b *Breaker) record(name string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(name)
+	wasProbe := c.state == StateHalfOpen
+	if wasProbe {
+		c.probing = false
+	}
+
+	if errors.Is(err, context.Canceled) {
+		// The caller gave up; that says nothing about the backend.
+		return
+	}
+
+	if err == nil || isCallerError(err) {
+		// A caller error still proves the backend answered.
+		c.state, c.failures, c.lastErr, c.openedAt = StateClosed, 0, "", time.Time{}
+
+		return
+	}
+
+	c.failures++
+	c.lastErr = err.Error()
+
+	if wasProbe || c.failures >= b.threshold {
+		c.state = StateOpen
+		c.openedAt = b.now()
+	}
+}
+
+func (b *Breaker) circuit(name string) *circuit {
+	c, ok := b.circuits[name]
+	if !ok {
+		c = &circuit{state: StateClosed}
+		b.circuits[name] = c
+	}
+
+	return c
+}
@@ -0,0 +1,27 @@
+// Package middleware provides the provider.Middleware implementations
+// the control plane installs on its provider.Registry: per-method
+// timeouts, exponential-backoff retries for idempotent calls, a
+// circuit breaker per provider name, and call metrics.
+//
+// Without them a call into a flapping backend can block a user request
+// for as long as the backend's API holds the connection open. With the
+// default chain:
+//
+//	breaker := middleware.NewBreaker()
+//	registry.Use(
+//	    middleware.Metrics(factory),
+//	    middleware.Retry(middleware.DefaultRetryPolicy()),
+//	    breaker.Middleware(),
+//	    middleware.Timeout(middleware.DefaultTimeouts()),
+//	)
+//
+// every call is counted and timed once (Metrics is outermost), each
+// retry attempt is judged by the breaker and bounded by its own
+// timeout, and once a provider has failed enough times in a row the
+// breaker answers for it with ErrCircuitOpen until a probe succeeds.
+//
+// Only idempotent calls (provider.Call.Idempotent: Status, Resources,
+// Logs, HealthCheck and the engine status reads) are retried; a
+// Deploy that timed out may have been applied, and repeating it is the
+// caller's decision.
+package middleware
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	gu "github.com/xraph/go-utils/metrics"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Call outcomes recorded in the "outcome" metric label.
+const (
+	OutcomeOK          = "ok"
+	OutcomeError       = "error"
+	OutcomeTimeout     = "timeout"
+	OutcomeCircuitOpen = "circuit_open"
+	OutcomeCanceled    = "canceled"
+)
+
+// callMetrics are the instruments for one provider/method/outcome.
+type callMetrics struct {
+	calls    gu.Counter
+	duration gu.Timer
+}
+
+// metricKey identifies one label set.
+type metricKey struct {
+	provider, method, outcome string
+}
+
+// Metrics records every call in factory: a "ctrlplane.provider.calls"
+// counter and a "ctrlplane.provider.call.duration" timer, both
+// labelled provider, method and outcome. Install it outermost so a
+// retried call is recorded once, with its total duration.
+func Metrics(factory gu.MetricFactory) provider.Middleware {
+	var (
+		mu    sync.Mutex
+		cache = make(map[metricKey]*callMetrics)
+	)
+
+	get := func(k metricKey) *callMetrics {
+		mu.Lock()
+		defer mu.Unlock()
+
+		m, ok := cache[k]
+		if !ok {
+			labels := gu.WithLabels(map[string]string{
+				"provider": k.provider,
+				"method":   k.method,
+				"outcome":  k.outcome,
+			})
+
+			m = &callMetrics{
+				calls:    factory.Counter("ctrlplane.provider.calls", labels),
+				duration: factory.Timer("ctrlplane.provider.call.duration", labels),
+			}
+			cache[k] = m
+		}
+
+		return m
+	}
+
+	return func(ctx context.Context, call provider.Call, next provider.Invoker) (any, error) {
+		start := time.Now()
+		val, err := next(ctx)
+
+		m := get(metricKey{provider: call.Provider, method: call.Method, outcome: outcome(err)})
+		m.calls.Inc()
+		m.duration.Record(time.Since(start))
+
+		return val, err
+	}
+}
+
+// outcome classifies err for the outcome label.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, ErrCircuitOpen):
+		return OutcomeCircuitOpen
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	default:
+		return OutcomeError
+	}
+}
@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gu "github.com/xraph/go-utils/metrics"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+var (
+	statusCall = provider.Call{Provider: "nomad", Method: provider.MethodStatus}
+	deployCall = provider.Call{Provider: "nomad", Method: provider.MethodDeploy}
+	logsCall   = provider.Call{Provider: "nomad", Method: provider.MethodLogs}
+)
+
+// failing returns an invoker that fails the first n calls with err,
+// then succeeds, counting every call.
+func failing(n int32, err error, calls *atomic.Int32) provider.Invoker {
+	return func(context.Context) (any, error) {
+		if calls.Add(1) <= n {
+			return nil, err
+		}
+
+		return "ok", nil
+	}
+}
+
+// TestTimeout_AbandonsCallIgnoringContext verifies a call that never
+// returns is cut off at its method's deadline, and a streaming
+// result delivered late is closed.
+func TestTimeout_AbandonsCallIgnoringContext(t *testing.T) {
+	t.Parallel()
+
+	mw := Timeout(Timeouts{provider.MethodStatus: 20 * time.Millisecond, provider.MethodLogs: 20 * time.Millisecond})
+	block := make(chan struct{})
+	defer close(block)
+
+	start := time.Now()
+
+	_, err := mw(context.Background(), statusCall, func(context.Context) (any, error) {
+		<-block
+
+		return nil, nil
+	})
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want ErrTimeout", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("returned after %s", elapsed)
+	}
+
+	late := &closeRecorder{}
+	release := make(chan struct{})
+
+	_, err = mw(context.Background(), logsCall, func(context.Context) (any, error) {
+		<-release
+
+		return late, nil
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("logs err = %v, want ErrTimeout", err)
+	}
+
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for !late.closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("late Logs stream was not closed")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestTimeout_StreamKeepsCallerContext verifies a Logs stream isn't
+// bound to the open deadline once it has been returned.
+func TestTimeout_StreamKeepsCallerContext(t *testing.T) {
+	t.Parallel()
+
+	mw := Timeout(Timeouts{provider.MethodLogs: 10 * time.Millisecond})
+
+	var streamCtx context.Context
+
+	if _, err := mw(context.Background(), logsCall, func(ctx context.Context) (any, error) {
+		streamCtx = ctx
+
+		return &closeRecorder{}, nil
+	}); err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if err := streamCtx.Err(); err != nil {
+		t.Fatalf("stream context ended: %v", err)
+	}
+}
+
+// TestRetry_RetriesIdempotentCallsOnly verifies Status is retried
+// until it succeeds, while Deploy and not-found errors are not.
+func TestRetry_RetriesIdempotentCallsOnly(t *testing.T) {
+	t.Parallel()
+
+	mw := Retry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	boom := errors.New("connection reset")
+
+	var calls atomic.Int32
+	if val, err := mw(context.Background(), statusCall, failing(2, boom, &calls)); err != nil || val != "ok" || calls.Load() != 3 {
+		t.Fatalf("Status: val=%v err=%v calls=%d, want ok after 3", val, err, calls.Load())
+	}
+
+	calls.Store(0)
+	if _, err := mw(context.Background(), deployCall, failing(1, boom, &calls)); !errors.Is(err, boom) || calls.Load() != 1 {
+		t.Fatalf("Deploy: err=%v calls=%d, want one failed attempt", err, calls.Load())
+	}
+
+	calls.Store(0)
+	if _, err := mw(context.Background(), statusCall, failing(5, ctrlplane.ErrNotFound, &calls)); !errors.Is(err, ctrlplane.ErrNotFound) || calls.Load() != 1 {
+		t.Fatalf("NotFound: err=%v calls=%d, want no retry", err, calls.Load())
+	}
+}
+
+// TestBreaker_OpensAndRecovers verifies consecutive failures open the
+// circuit, an open circuit rejects without calling the provider, and
+// a successful probe after the wait closes it. Caller errors don't
+// count.
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	t.Parallel()
+
+	b, err := NewBreaker(WithFailureThreshold(2), WithOpenDuration(time.Minute))
+	if err != nil {
+		t.Fatalf("NewBreaker: %v", err)
+	}
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	mw := b.Middleware()
+	boom := errors.New("nomad: 503")
+
+	var calls atomic.Int32
+
+	for range 3 {
+		_, _ = mw(context.Background(), statusCall, failing(0, ctrlplane.ErrNotFound, &calls))
+	}
+
+	if st := b.Status("nomad"); st.State != StateClosed {
+		t.Fatalf("after caller errors: %+v, want closed", st)
+	}
+
+	for range 2 {
+		_, _ = mw(context.Background(), deployCall, failing(100, boom, &calls))
+	}
+
+	if reason, open := b.CircuitOpen("nomad"); !open || !strings.Contains(reason, "503") {
+		t.Fatalf("CircuitOpen = %q, %v; want open with last error", reason, open)
+	}
+
+	calls.Store(0)
+	if _, err := mw(context.Background(), statusCall, failing(0, nil, &calls)); !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ctrlplane.ErrProviderUnavail) || calls.Load() != 0 {
+		t.Fatalf("open circuit: err=%v calls=%d", err, calls.Load())
+	}
+
+	if _, open := b.CircuitOpen("docker"); open {
+		t.Fatal("circuits are not per provider")
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if _, err := mw(context.Background(), statusCall, failing(0, nil, &calls)); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+
+	if st := b.Status("nomad"); st.State != StateClosed || st.Failures != 0 {
+		t.Fatalf("after probe: %+v, want closed", st)
+	}
+}
+
+// TestBreaker_FailedProbeReopens verifies a failed half-open probe
+// re-opens the circuit straight away.
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	t.Parallel()
+
+	b, err := NewBreaker(WithFailureThreshold(1), WithOpenDuration(time.Second))
+	if err != nil {
+		t.Fatalf("NewBreaker: %v", err)
+	}
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	mw := b.Middleware()
+	boom := errors.New("down")
+
+	var calls atomic.Int32
+
+	_, _ = mw(context.Background(), statusCall, failing(100, boom, &calls))
+	now = now.Add(2 * time.Second)
+	_, _ = mw(context.Background(), statusCall, failing(100, boom, &calls))
+
+	if st := b.Status("nomad"); st.State != StateOpen || !st.OpenedAt.Equal(now) {
+		t.Fatalf("after failed probe: %+v, want re-opened now", st)
+	}
+}
+
+// TestMetrics_CountsCallsByOutcome verifies each call is counted
+// under its provider, method and outcome.
+func TestMetrics_CountsCallsByOutcome(t *testing.T) {
+	t.Parallel()
+
+	factory := &labelFactory{counters: make(map[string]gu.Counter)}
+	mw := Metrics(factory)
+
+	var calls atomic.Int32
+
+	_, _ = mw(context.Background(), statusCall, failing(0, nil, &calls))
+	_, _ = mw(context.Background(), statusCall, failing(0, nil, &calls))
+	_, _ = mw(context.Background(), statusCall, func(context.Context) (any, error) { return nil, ErrTimeout })
+
+	if got := factory.value("nomad", provider.MethodStatus, OutcomeOK); got != 2 {
+		t.Fatalf("ok calls = %v, want 2", got)
+	}
+
+	if got := factory.value("nomad", provider.MethodStatus, OutcomeTimeout); got != 1 {
+		t.Fatalf("timeout calls = %v, want 1", got)
+	}
+}
+
+// labelFactory keeps one counter per label set, as a Prometheus-backed
+// factory does.
+type labelFactory struct {
+	gu.MetricFactory
+
+	mu       sync.Mutex
+	counters map[string]gu.Counter
+}
+
+func (f *labelFactory) Counter(name string, opts ...gu.MetricOption) gu.Counter {
+	var o gu.MetricOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := gu.NewCounter(name, opts...)
+	l := o.Labels
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.counters[l["provider"]+"/"+l["method"]+"/"+l["outcome"]] = c
+
+	return c
+}
+
+func (f *labelFactory) Timer(name string, opts ...gu.MetricOption) gu.Timer {
+	return gu.NewTimer(name, opts...)
+}
+
+func (f *labelFactory) value(providerName, method, outcome string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.counters[providerName+"/"+method+"/"+outcome]
+	if !ok {
+		return 0
+	}
+
+	return c.Value()
+}
+
+// closeRecorder is a Logs stream that records Close.
+type closeRecorder struct {
+	closed atomic.Bool
+}
+
+func (c *closeRecorder) Read([]byte) (int, error) { return 0, io.EOF }
+
+func (c *closeRecorder) Close() error {
+	c.closed.Store(true)
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the wait before the second attempt; each later
+	// wait doubles, up to MaxDelay. The actual wait is drawn
+	// uniformly from [delay/2, delay] so callers that failed together
+	// don't retry together.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the wait between attempts.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns three attempts with 200ms, then 400ms,
+// of (jittered) backoff — enough to ride out a leader election or a
+// dropped connection without holding a user request much longer.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// delay returns the jittered wait before attempt n (n >= 1 is the
+// first retry).
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+
+	return half + rand.N(d-half+1) //nolint:gosec // jitter, not security
+}
+
+// Retry retries failed idempotent calls (provider.Call.Idempotent)
+// with exponential backoff. Calls that change state are passed
+// through untouched, as are errors a retry can't fix: a cancelled or
+// expired caller context, ErrNotFound, invalid input, and an open
+// circuit.
+func Retry(p RetryPolicy) provider.Middleware {
+	return func(ctx context.Context, call provider.Call, next provider.Invoker) (any, error) {
+		if !call.Idempotent() || p.MaxAttempts < 2 {
+			return next(ctx)
+		}
+
+		var (
+			val any
+			err error
+		)
+
+		for attempt := range p.MaxAttempts {
+			if attempt > 0 {
+				timer := time.NewTimer(p.delay(attempt))
+
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+
+					return nil, err
+				case <-timer.C:
+				}
+			}
+
+			val, err = next(ctx)
+			if err == nil || !retryable(ctx, err) {
+				return val, err
+			}
+		}
+
+		return val, err
+	}
+}
+
+// retryable reports whether err may go away on its own.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return !isCallerError(err) && !errors.Is(err, ErrCircuitOpen)
+}
+
+// isCallerError reports errors caused by the request rather than the
+// provider's health. They are neither retried nor counted against
+// the circuit.
+func isCallerError(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, ctrlplane.ErrNotFound) ||
+		errors.Is(err, ctrlplane.ErrAlreadyExists) ||
+		errors.Is(err, ctrlplane.ErrInvalidState) ||
+		errors.Is(err, ctrlplane.ErrInvalidConfig) ||
+		errors.Is(err, ctrlplane.ErrInvalidSource) ||
+		errors.Is(err, ctrlplane.ErrUnsupportedSource) ||
+		errors.Is(err, ctrlplane.ErrNotImplemented)
+}
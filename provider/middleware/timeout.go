@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// ErrTimeout is returned when a provider call outlives its deadline.
+// It wraps context.DeadlineExceeded, so callers checking for that
+// keep working.
+var ErrTimeout = fmt.Errorf("provider call timed out: %w", context.DeadlineExceeded)
+
+// Timeouts maps provider method names (provider.Method*) to the
+// longest a single call may take. Methods not listed use the ""
+// entry; a zero or missing duration means no deadline.
+type Timeouts map[string]time.Duration
+
+// DefaultTimeouts returns deadlines sized for real backends: calls
+// that create or roll out workloads may wait on image pulls and
+// rollouts, lifecycle calls on graceful shutdowns, and reads should
+// answer quickly. Exec runs user commands of arbitrary length and has
+// no deadline.
+func DefaultTimeouts() Timeouts {
+	const (
+		rollout   = 15 * time.Minute
+		lifecycle = 5 * time.Minute
+		read      = 30 * time.Second
+	)
+
+	return Timeouts{
+		provider.MethodProvision:       rollout,
+		provider.MethodDeploy:          rollout,
+		provider.MethodRollback:        rollout,
+		provider.MethodRollbackRelease: rollout,
+		provider.MethodDeployTrack:     rollout,
+		provider.MethodApplyManifests:  rollout,
+		provider.MethodHelmInstall:     rollout,
+		provider.MethodHelmUpgrade:     rollout,
+		provider.MethodArgoApply:       rollout,
+		provider.MethodDeprovision:     lifecycle,
+		provider.MethodStart:           lifecycle,
+		provider.MethodStop:            lifecycle,
+		provider.MethodRestart:         lifecycle,
+		provider.MethodScale:           lifecycle,
+		provider.MethodDeleteManifests: lifecycle,
+		provider.MethodHelmUninstall:   lifecycle,
+		provider.MethodArgoDelete:      lifecycle,
+		provider.MethodRemoveTrack:     lifecycle,
+		provider.MethodStatus:          read,
+		provider.MethodResources:       read,
+		provider.MethodLogs:            read,
+		provider.MethodHealthCheck:     read,
+		provider.MethodManifestStatus:  read,
+		provider.MethodHelmStatus:      read,
+		provider.MethodArgoStatus:      read,
+		provider.MethodTrackStatus:     read,
+		provider.MethodExec:            0,
+	}
+}
+
+// For returns the deadline for method.
+func (t Timeouts) For(method string) time.Duration {
+	if d, ok := t[method]; ok {
+		return d
+	}
+
+	return t[""]
+}
+
+// result carries a call's return values across the goroutine
+// boundary in Timeout.
+type result struct {
+	val any
+	err error
+}
+
+// Timeout bounds each call by its method's deadline in t.
+//
+// The deadline is enforced two ways: the call's context is cancelled,
+// and the caller stops waiting when it passes even if the provider
+// ignores its context (a blocked HTTP read, an SDK without context
+// support). In the second case the call is left to finish in the
+// background and a late io.Closer result (a Logs stream) is closed.
+//
+// For streaming calls (Logs) the deadline covers opening the stream
+// only; the stream itself keeps the caller's context.
+func Timeout(t Timeouts) provider.Middleware {
+	return func(ctx context.Context, call provider.Call, next provider.Invoker) (any, error) {
+		d := t.For(call.Method)
+		if d <= 0 {
+			return next(ctx)
+		}
+
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if !call.Streaming() {
+			callCtx, cancel = context.WithTimeout(ctx, d)
+		}
+
+		done := make(chan result, 1)
+
+		go func() {
+			val, err := next(callCtx)
+			done <- result{val: val, err: err}
+		}()
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case r := <-done:
+			cancel()
+
+			if r.err != nil && callCtx.Err() != nil && ctx.Err() == nil {
+				return nil, fmt.Errorf("%s %s after %s: %w", call.Provider, call.Method, d, ErrTimeout)
+			}
+
+			return r.val, r.err
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+
+		cancel()
+
+		go func() {
+			if c, ok := (<-done).val.(io.Closer); ok {
+				_ = c.Close()
+			}
+		}()
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%s %s after %s: %w", call.Provider, call.Method, d, ErrTimeout)
+	}
+}
@@ -0,0 +1,228 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/xraph/ctrlplane/id"
+)
+
+// chainFake succeeds at everything and reports a running instance.
+type chainFake struct{}
+
+func (chainFake) Info() ProviderInfo         { return ProviderInfo{Name: "fake"} }
+func (chainFake) Capabilities() []Capability { return nil }
+func (chainFake) Provision(context.Context, ProvisionRequest) (*ProvisionResult, error) {
+	return &ProvisionResult{ProviderRef: "fake"}, nil
+}
+func (chainFake) Deprovision(context.Context, id.ID) error { return nil }
+func (chainFake) Start(context.Context, id.ID) error       { return nil }
+func (chainFake) Stop(context.Context, id.ID) error        { return nil }
+func (chainFake) Restart(context.Context, id.ID) error     { return nil }
+func (chainFake) Status(context.Context, id.ID) (*InstanceStatus, error) {
+	return &InstanceStatus{State: StateRunning}, nil
+}
+func (chainFake) Deploy(context.Context, DeployRequest) (*DeployResult, error) {
+	return &DeployResult{Status: "deployed"}, nil
+}
+func (chainFake) Rollback(context.Context, id.ID, id.ID) error     { return nil }
+func (chainFake) Scale(context.Context, id.ID, ResourceSpec) error { return nil }
+func (chainFake) Resources(context.Context, id.ID) (*ResourceUsage, error) {
+	return &ResourceUsage{}, nil
+}
+func (chainFake) Logs(context.Context, id.ID, LogOptions) (io.ReadCloser, error) {
+	return nil, errors.New("no logs")
+}
+func (chainFake) Exec(context.Context, id.ID, ExecRequest) (*ExecResult, error) {
+	return &ExecResult{}, nil
+}
+
+// healthChainFake adds HealthChecker.
+type healthChainFake struct{ chainFake }
+
+func (healthChainFake) HealthCheck(context.Context) (*HealthStatus, error) {
+	return &HealthStatus{Healthy: true}, nil
+}
+
+// trackChainFake adds TrackDeployer to healthChainFake.
+type trackChainFake struct{ healthChainFake }
+
+func (trackChainFake) DeployTrack(context.Context, string, DeployRequest) (*DeployResult, error) {
+	return &DeployResult{Status: "deployed"}, nil
+}
+
+func (trackChainFake) TrackStatus(context.Context, id.ID, string) (*InstanceStatus, error) {
+	return &InstanceStatus{Ready: true}, nil
+}
+
+func (trackChainFake) RemoveTrack(context.Context, id.ID, string) error { return nil }
+
+// record returns a middleware appending its tag and the call's method
+// to log.
+func record(tag string, log *[]string) Middleware {
+	return func(ctx context.Context, call Call, next Invoker) (any, error) {
+		*log = append(*log, tag+":"+call.Method)
+
+		return next(ctx)
+	}
+}
+
+// TestChain_RunsMiddlewareInOrder verifies the first middleware is
+// outermost, every call carries the registered name and instance,
+// and results keep their static type.
+func TestChain_RunsMiddlewareInOrder(t *testing.T) {
+	t.Parallel()
+
+	var (
+		log  []string
+		seen Call
+	)
+
+	capture := func(ctx context.Context, call Call, next Invoker) (any, error) {
+		seen = call
+
+		return next(ctx)
+	}
+
+	p := Chain("docker", chainFake{}, record("a", &log), record("b", &log), capture)
+	instID := id.New(id.PrefixInstance)
+
+	st, err := p.Status(context.Background(), instID)
+	if err != nil || st.State != StateRunning {
+		t.Fatalf("Status = %+v, %v", st, err)
+	}
+
+	if want := []string{"a:Status", "b:Status"}; !slices.Equal(log, want) {
+		t.Fatalf("order = %v, want %v", log, want)
+	}
+
+	if seen.Provider != "docker" || seen.InstanceID != instID || !seen.Idempotent() {
+		t.Fatalf("call = %+v", seen)
+	}
+
+	if _, err := p.Logs(context.Background(), instID, LogOptions{}); err == nil {
+		t.Fatal("Logs: provider error was swallowed")
+	}
+}
+
+// TestChain_PreservesOptionalInterfaces verifies the chained provider
+// implements HealthChecker and Rollbacker exactly when the wrapped
+// one does.
+func TestChain_PreservesOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+
+	noop := func(ctx context.Context, _ Call, next Invoker) (any, error) { return next(ctx) }
+
+	if _, ok := Chain("plain", chainFake{}, noop).(HealthChecker); ok {
+		t.Fatal("plain provider gained HealthChecker")
+	}
+
+	p := Chain("health", healthChainFake{}, noop)
+
+	hc, ok := p.(HealthChecker)
+	if !ok {
+		t.Fatal("HealthChecker lost through Chain")
+	}
+
+	if hs, err := hc.HealthCheck(context.Background()); err != nil || !hs.Healthy {
+		t.Fatalf("HealthCheck = %+v, %v", hs, err)
+	}
+
+	if _, ok := p.(Rollbacker); ok {
+		t.Fatal("provider gained Rollbacker")
+	}
+
+	if _, err := p.(ManifestEngine).ManifestStatus(context.Background(), id.New(id.PrefixInstance)); err == nil {
+		t.Fatal("missing engine did not error")
+	}
+}
+
+// TestChain_ForwardsTracks verifies the chained provider implements
+// TrackDeployer exactly when the wrapped one does, alongside its other
+// optional interfaces, and runs track calls through the middleware.
+func TestChain_ForwardsTracks(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+
+	if _, ok := Chain("health", healthChainFake{}, record("a", &log)).(TrackDeployer); ok {
+		t.Fatal("provider gained TrackDeployer")
+	}
+
+	p := Chain("tracks", trackChainFake{}, record("a", &log))
+
+	if _, ok := p.(HealthChecker); !ok {
+		t.Fatal("HealthChecker lost through Chain")
+	}
+
+	td, ok := p.(TrackDeployer)
+	if !ok {
+		t.Fatal("TrackDeployer lost through Chain")
+	}
+
+	instID := id.New(id.PrefixInstance)
+
+	if _, err := td.DeployTrack(context.Background(), TrackGreen, DeployRequest{InstanceID: instID}); err != nil {
+		t.Fatalf("DeployTrack: %v", err)
+	}
+
+	if st, err := td.TrackStatus(context.Background(), instID, TrackGreen); err != nil || !st.Ready {
+		t.Fatalf("TrackStatus = %+v, %v", st, err)
+	}
+
+	if err := td.RemoveTrack(context.Background(), instID, TrackGreen); err != nil {
+		t.Fatalf("RemoveTrack: %v", err)
+	}
+
+	if want := []string{"a:DeployTrack", "a:TrackStatus", "a:RemoveTrack"}; !slices.Equal(log, want) {
+		t.Fatalf("calls = %v, want %v", log, want)
+	}
+}
+
+// TestUnwrap_PeelsNestedChains verifies Unwrap reaches the provider
+// under any number of chains, and returns an unwrapped one as is.
+func TestUnwrap_PeelsNestedChains(t *testing.T) {
+	t.Parallel()
+
+	noop := func(ctx context.Context, _ Call, next Invoker) (any, error) { return next(ctx) }
+	base := &healthChainFake{}
+
+	if got := Unwrap(Chain("outer", Chain("inner", base, noop), noop)); got != base {
+		t.Fatalf("Unwrap = %T, want the base provider", got)
+	}
+
+	if got := Unwrap(base); got != base {
+		t.Fatalf("Unwrap(base) = %T, want base", got)
+	}
+}
+
+// TestRegistry_UseWrapsExistingAndLaterProviders verifies Use applies
+// to providers registered before and after it.
+func TestRegistry_UseWrapsExistingAndLaterProviders(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+
+	r := NewRegistry()
+	r.Register("before", chainFake{})
+	r.Use(record("mw", &log))
+	r.Register("after", chainFake{})
+
+	for _, name := range []string{"before", "after"} {
+		p, err := r.Get(name)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", name, err)
+		}
+
+		if err := p.Start(context.Background(), id.New(id.PrefixInstance)); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	}
+
+	if want := []string{"mw:Start", "mw:Start"}; !slices.Equal(log, want) {
+		t.Fatalf("log = %v, want %v", log, want)
+	}
+}
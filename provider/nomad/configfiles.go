@@ -0,0 +1,112 @@
+package nomad
+
+// configfiles.go materialises ServiceSpec.ConfigFiles as template
+// stanzas. Each file is rendered by Nomad into the task's local/
+// directory and bind-mounted read-only at its Path via the docker
+// driver's mount config. The content travels in the job spec, so every
+// job version carries the files it ran with and a revert restores them
+// along with the images. ChangeMode "restart" restarts the task when a
+// new job version changes a file.
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+const (
+	// configFilesDir is the task-relative directory config files are
+	// rendered into.
+	configFilesDir = "local/ctrlplane-config"
+
+	// Config files are verbatim content, not consul-template templates.
+	// Delimiters no real file contains keep `{{ }}` in (say) a Helm or
+	// Go template file from being evaluated by Nomad.
+	configFileLeftDelim  = "[[ctrlplane-config:"
+	configFileRightDelim = ":ctrlplane-config]]"
+)
+
+// invalidConfigFileName matches characters kept out of rendered file
+// names.
+var invalidConfigFileName = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// configFileDest returns the task-relative path a file is rendered to:
+// its Name, or the base of its Path when unnamed.
+func configFileDest(f provider.ConfigFile) string {
+	name := f.Name
+	if name == "" {
+		name = path.Base(f.Path)
+	}
+
+	return configFilesDir + "/" + invalidConfigFileName.ReplaceAllString(name, "_")
+}
+
+// setTaskConfigFiles replaces a task's config files with files: one
+// template stanza and one read-only bind mount per file. Templates and
+// mounts of a previous file set are dropped first, so files removed
+// from the set stop being rendered; anything else the task templates
+// or mounts is kept.
+func setTaskConfigFiles(task *nomadTask, files []provider.ConfigFile) {
+	task.Templates = slices.DeleteFunc(task.Templates, func(t *nomadTemplate) bool {
+		return strings.HasPrefix(t.DestPath, configFilesDir+"/")
+	})
+
+	if task.Config == nil {
+		task.Config = make(map[string]any, 1)
+	}
+
+	// Mounts read back from the API decode as []any of maps, those
+	// built here as []map[string]any; normalise to the former.
+	var mounts []any
+
+	switch existing := task.Config["mount"].(type) {
+	case []any:
+		mounts = existing
+	case []map[string]any:
+		for _, m := range existing {
+			mounts = append(mounts, m)
+		}
+	}
+
+	mounts = slices.DeleteFunc(mounts, func(m any) bool {
+		mm, ok := m.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		src, _ := mm["source"].(string)
+
+		return strings.HasPrefix(src, configFilesDir+"/")
+	})
+
+	for _, f := range files {
+		dest := configFileDest(f)
+
+		task.Templates = append(task.Templates, &nomadTemplate{
+			EmbeddedTmpl: f.Content,
+			DestPath:     dest,
+			ChangeMode:   "restart",
+			Perms:        "0644",
+			LeftDelim:    configFileLeftDelim,
+			RightDelim:   configFileRightDelim,
+		})
+
+		mounts = append(mounts, map[string]any{
+			"type":     "bind",
+			"source":   dest,
+			"target":   f.Path,
+			"readonly": true,
+		})
+	}
+
+	if len(mounts) == 0 {
+		delete(task.Config, "mount")
+
+		return
+	}
+
+	task.Config["mount"] = mounts
+}
@@ -0,0 +1,65 @@
+package nomad
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestSetTaskConfigFiles_TemplatesAndMounts verifies each config file
+// becomes a verbatim template stanza plus a read-only bind mount, and
+// that a later file set replaces the earlier one without touching
+// unrelated mounts.
+func TestSetTaskConfigFiles_TemplatesAndMounts(t *testing.T) {
+	t.Parallel()
+
+	task := buildTask(provider.ServiceSpec{
+		Name:        "web",
+		Image:       "nginx:1",
+		ConfigFiles: []provider.ConfigFile{{Name: "nginx.conf", Path: "/etc/nginx/nginx.conf", Content: "worker_processes {{ auto }};"}},
+	})
+
+	if len(task.Templates) != 1 {
+		t.Fatalf("templates: want 1, got %d", len(task.Templates))
+	}
+
+	tmpl := task.Templates[0]
+	if tmpl.DestPath != "local/ctrlplane-config/nginx.conf" || tmpl.EmbeddedTmpl != "worker_processes {{ auto }};" ||
+		tmpl.LeftDelim != configFileLeftDelim || tmpl.ChangeMode != "restart" {
+		t.Fatalf("template: %+v", tmpl)
+	}
+
+	// Round-trip through JSON, as a job fetched from the API would be,
+	// and add an unrelated mount that must survive.
+	raw, err := json.Marshal(task)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var fetched nomadTask
+	if err := json.Unmarshal(raw, &fetched); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	fetched.Config["mount"] = append(fetched.Config["mount"].([]any), map[string]any{"type": "bind", "source": "/srv/data", "target": "/data"})
+
+	setTaskConfigFiles(&fetched, []provider.ConfigFile{{Path: "/etc/app/app.yaml", Content: "v: 2"}})
+
+	if len(fetched.Templates) != 1 || fetched.Templates[0].DestPath != "local/ctrlplane-config/app.yaml" {
+		t.Fatalf("templates after update: %+v", fetched.Templates)
+	}
+
+	mounts := fetched.Config["mount"].([]any)
+	if len(mounts) != 2 {
+		t.Fatalf("mounts: want 2, got %v", mounts)
+	}
+
+	if src := mounts[0].(map[string]any)["source"]; src != "/srv/data" {
+		t.Fatalf("unrelated mount dropped: %v", mounts)
+	}
+
+	if m := mounts[1].(map[string]any); m["target"] != "/etc/app/app.yaml" || m["readonly"] != true {
+		t.Fatalf("config mount: %v", m)
+	}
+}
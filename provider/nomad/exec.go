@@ -0,0 +1,242 @@
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/coder/websocket"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// execReadLimit caps one inbound exec frame. Nomad chunks output
+// well below this; the websocket library's 32KiB default is too
+// tight once base64 + JSON framing inflate a full output buffer.
+const execReadLimit = 1 << 20
+
+// execStdinChunk is the read size for pumping cmd.Stdin into stdin
+// frames.
+const execStdinChunk = 4096
+
+// execIOFrame mirrors Nomad's ExecStreamingIOOperation: a chunk of
+// stream data (base64 on the wire) or a close marker.
+type execIOFrame struct {
+	Data  []byte `json:"data,omitempty"`
+	Close bool   `json:"close,omitempty"`
+}
+
+// execTTYSize mirrors Nomad's TerminalSize.
+type execTTYSize struct {
+	Height int `json:"height"`
+	Width  int `json:"width"`
+}
+
+// execInputFrame is one client→agent message on the exec socket.
+type execInputFrame struct {
+	Stdin   *execIOFrame `json:"stdin,omitempty"`
+	TTYSize *execTTYSize `json:"tty_size,omitempty"`
+}
+
+// execOutputFrame is one agent→client message on the exec socket.
+// The session ends with Exited=true and the command's Result.
+type execOutputFrame struct {
+	Stdout *execIOFrame    `json:"stdout,omitempty"`
+	Stderr *execIOFrame    `json:"stderr,omitempty"`
+	Exited bool            `json:"exited,omitempty"`
+	Result *execExitResult `json:"result,omitempty"`
+}
+
+// execExitResult mirrors Nomad's ExecStreamingExitResult.
+type execExitResult struct {
+	ExitCode int `json:"exit_code"`
+}
+
+// Exec runs a command inside one task of the instance's allocation
+// (cmd.ServiceName, defaulting to the Main task) over the agent's
+// /v1/client/allocation/:id/exec WebSocket — the same protocol
+// `nomad alloc exec` speaks — and blocks until it exits.
+//
+// cmd.Stdin is forwarded as stdin frames and closed on EOF;
+// cmd.Resize is forwarded as tty_size frames when cmd.TTY is set.
+// The exit code comes from the agent's final "exited" frame; a
+// socket that closes without one is an error, never a silent 0.
+func (p *Provider) Exec(ctx context.Context, instanceID id.ID, cmd provider.ExecRequest) (*provider.ExecResult, error) {
+	if len(cmd.Command) == 0 {
+		return nil, errors.New("nomad: exec requires a command")
+	}
+
+	allocID, task, err := p.resolveTask(ctx, instanceID, cmd.ServiceName, "exec")
+	if err != nil {
+		return nil, err
+	}
+
+	command, err := json.Marshal(cmd.Command)
+	if err != nil {
+		return nil, fmt.Errorf("nomad: exec: encode command: %w", err)
+	}
+
+	q := url.Values{}
+	q.Set("task", task)
+	q.Set("tty", strconv.FormatBool(cmd.TTY))
+	q.Set("command", string(command))
+	p.setScope(q)
+
+	endpoint := strings.TrimRight(p.cfg.Address, "/") + "/v1/client/allocation/" + url.PathEscape(allocID) + "/exec?" + q.Encode()
+
+	header := http.Header{}
+	if p.cfg.Token != "" {
+		header.Set("X-Nomad-Token", p.cfg.Token)
+	}
+
+	conn, resp, err := websocket.Dial(ctx, endpoint, &websocket.DialOptions{
+		HTTPClient: &http.Client{Transport: p.client.Transport},
+		HTTPHeader: header,
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("nomad: exec %s/%s: %w", allocID, task, notFoundError{url: endpoint})
+		}
+
+		return nil, fmt.Errorf("nomad: exec %s/%s: dial: %w", allocID, task, err)
+	}
+	defer conn.CloseNow() //nolint:errcheck // best-effort teardown
+
+	res, err := runExecSession(ctx, conn, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("nomad: exec %s/%s: %w", allocID, task, err)
+	}
+
+	return res, nil
+}
+
+// runExecSession drives one exec socket to completion: it pumps
+// stdin and resize events out while collecting stdout/stderr until
+// the agent reports the exit. Split from Exec so the protocol
+// handling is testable against an httptest WebSocket stand-in.
+func runExecSession(ctx context.Context, conn *websocket.Conn, cmd provider.ExecRequest) (*provider.ExecResult, error) {
+	conn.SetReadLimit(execReadLimit)
+
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go pumpExecStdin(sessionCtx, conn, cmd.Stdin)
+
+	if cmd.TTY && cmd.Resize != nil {
+		go pumpExecResize(sessionCtx, conn, cmd.Resize)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	for {
+		_, data, err := conn.Read(sessionCtx)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+
+			// Nomad reports failures (unknown task, exec denied) as
+			// a close frame whose reason carries the message.
+			if status := websocket.CloseStatus(err); status != -1 {
+				return nil, fmt.Errorf("session closed before exit (status %d): %w", status, err)
+			}
+
+			return nil, fmt.Errorf("read: %w", err)
+		}
+
+		var frame execOutputFrame
+		if err := json.Unmarshal(data, &frame); err != nil {
+			return nil, fmt.Errorf("decode frame: %w", err)
+		}
+
+		if frame.Stdout != nil {
+			stdout.Write(frame.Stdout.Data)
+		}
+
+		if frame.Stderr != nil {
+			stderr.Write(frame.Stderr.Data)
+		}
+
+		if frame.Exited {
+			exitCode := 0
+			if frame.Result != nil {
+				exitCode = frame.Result.ExitCode
+			}
+
+			_ = conn.Close(websocket.StatusNormalClosure, "")
+
+			return &provider.ExecResult{
+				ExitCode: exitCode,
+				Stdout:   stdout.Bytes(),
+				Stderr:   stderr.Bytes(),
+			}, nil
+		}
+	}
+}
+
+// pumpExecStdin forwards stdin as stdin frames, then sends the close
+// marker so commands reading to end-of-input terminate. A nil stdin
+// closes immediately for the same reason.
+func pumpExecStdin(ctx context.Context, conn *websocket.Conn, stdin io.Reader) {
+	if stdin != nil {
+		buf := make([]byte, execStdinChunk)
+
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				if werr := writeExecFrame(ctx, conn, execInputFrame{
+					Stdin: &execIOFrame{Data: buf[:n]},
+				}); werr != nil {
+					return
+				}
+			}
+
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	_ = writeExecFrame(ctx, conn, execInputFrame{Stdin: &execIOFrame{Close: true}})
+}
+
+// pumpExecResize forwards terminal size changes until the session
+// ends or the channel closes.
+func pumpExecResize(ctx context.Context, conn *websocket.Conn, resize <-chan provider.TerminalSize) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case size, ok := <-resize:
+			if !ok {
+				return
+			}
+
+			if err := writeExecFrame(ctx, conn, execInputFrame{
+				TTYSize: &execTTYSize{Height: int(size.Height), Width: int(size.Width)},
+			}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeExecFrame sends one JSON text message. websocket.Conn
+// serialises concurrent writers, so the stdin and resize pumps can
+// share the socket.
+func writeExecFrame(ctx context.Context, conn *websocket.Conn, frame execInputFrame) error {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	return conn.Write(ctx, websocket.MessageText, data)
+}
@@ -0,0 +1,166 @@
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// execStandIn serves the job/allocation lookups plus an exec socket
+// that behaves like `cat`: it echoes stdin frames back as stdout,
+// records tty_size frames, and on stdin close reports exit code 7.
+func execStandIn(t *testing.T, instID id.ID, sizes chan<- execTTYSize) *httptest.Server {
+	t.Helper()
+
+	name := jobName(instID)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/job/" + name:
+			_ = json.NewEncoder(w).Encode(nomadJob{
+				ID:         name,
+				TaskGroups: []*nomadTaskGroup{{Name: taskGroupName, Tasks: []*nomadTask{{Name: "web"}}}},
+			})
+		case "/v1/job/" + name + "/allocations":
+			_ = json.NewEncoder(w).Encode([]nomadAlloc{{ID: "a1", ClientStatus: "running"}})
+		case "/v1/client/allocation/a1/exec":
+			q := r.URL.Query()
+			if q.Get("task") != "web" || q.Get("command") != `["cat"]` {
+				http.Error(w, "bad query "+r.URL.RawQuery, http.StatusBadRequest)
+
+				return
+			}
+
+			conn, err := websocket.Accept(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.CloseNow() //nolint:errcheck // test teardown
+
+			ctx := r.Context()
+
+			for {
+				_, data, err := conn.Read(ctx)
+				if err != nil {
+					return
+				}
+
+				var in execInputFrame
+				if err := json.Unmarshal(data, &in); err != nil {
+					return
+				}
+
+				if in.TTYSize != nil && sizes != nil {
+					sizes <- *in.TTYSize
+				}
+
+				if in.Stdin == nil {
+					continue
+				}
+
+				if in.Stdin.Close {
+					out, _ := json.Marshal(execOutputFrame{Exited: true, Result: &execExitResult{ExitCode: 7}})
+					_ = conn.Write(ctx, websocket.MessageText, out)
+
+					return
+				}
+
+				out, _ := json.Marshal(execOutputFrame{Stdout: &execIOFrame{Data: in.Stdin.Data}})
+				_ = conn.Write(ctx, websocket.MessageText, out)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// TestExec_StreamsStdinAndReportsExitCode verifies stdin is sent as
+// frames and closed on EOF, output frames are collected, resize
+// events are forwarded for TTY sessions, and the exit code comes from
+// the agent's exited frame.
+func TestExec_StreamsStdinAndReportsExitCode(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	sizes := make(chan execTTYSize, 1)
+
+	srv := execStandIn(t, instID, sizes)
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	resize := make(chan provider.TerminalSize, 1)
+	resize <- provider.TerminalSize{Width: 120, Height: 40}
+
+	res, err := p.Exec(context.Background(), instID, provider.ExecRequest{
+		Command: []string{"cat"},
+		Stdin:   strings.NewReader("hello"),
+		TTY:     true,
+		Resize:  resize,
+	})
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	if res.ExitCode != 7 {
+		t.Fatalf("exit code: want 7, got %d", res.ExitCode)
+	}
+
+	if !bytes.Equal(res.Stdout, []byte("hello")) {
+		t.Fatalf("stdout: want %q, got %q", "hello", res.Stdout)
+	}
+
+	// The resize may race the stdin close; when it arrives it must
+	// carry the requested geometry.
+	select {
+	case got := <-sizes:
+		if got.Width != 120 || got.Height != 40 {
+			t.Fatalf("tty size: want 120x40, got %dx%d", got.Width, got.Height)
+		}
+	default:
+	}
+}
+
+// TestRunExecSession_CloseWithoutExitIsError verifies a socket that
+// closes before the exited frame fails the exec instead of reporting
+// success with exit code 0.
+func TestRunExecSession_CloseWithoutExitIsError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		_ = conn.Close(websocket.StatusInternalError, "task not running")
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.Dial(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.CloseNow() //nolint:errcheck // test teardown
+
+	res, err := runExecSession(context.Background(), conn, provider.ExecRequest{Command: []string{"true"}})
+	if err == nil {
+		t.Fatalf("want error, got result %+v", res)
+	}
+
+	if !strings.Contains(err.Error(), "task not running") {
+		t.Fatalf("error should carry the close reason, got %v", err)
+	}
+}
@@ -15,6 +15,12 @@ package nomad
 //   - Volumes declared on services map onto group-level Nomad volumes
 //     mounted into each task. Persistent volumes for KindStatefulSet
 //     get their own per-allocation volume mount via host_volume.
+//   - A service's resolved RegistryAuth becomes the docker driver's
+//     auth block.
+//   - ConfigFiles become template stanzas rendered into local/ and
+//     bind-mounted at their Path (see configfiles.go).
+//   - The Main service's Placement becomes the group's constraint,
+//     affinity and spread stanzas (see placement.go).
 //
 // Wire format: we emit the Nomad HTTP-API JSON shape (the
 // `nomadJobRequest` envelope) — no external SDK. The builder produces
@@ -43,20 +49,24 @@ type nomadJob struct {
 	Namespace   string            `json:"Namespace,omitempty"`
 	Datacenters []string          `json:"Datacenters,omitempty"`
 	Stop        bool              `json:"Stop,omitempty"`
+	Version     uint64            `json:"Version,omitempty"`
 	Meta        map[string]string `json:"Meta,omitempty"`
 	TaskGroups  []*nomadTaskGroup `json:"TaskGroups"`
 }
 
 type nomadTaskGroup struct {
-	Name             string            `json:"Name"`
-	Count            int               `json:"Count"`
-	Tasks            []*nomadTask      `json:"Tasks"`
-	Networks         []*nomadNetwork   `json:"Networks,omitempty"`
-	Services         []*nomadService   `json:"Services,omitempty"`
-	Volumes          map[string]any    `json:"Volumes,omitempty"`
-	RestartPolicy    *nomadRestart     `json:"RestartPolicy,omitempty"`
-	ReschedulePolicy *nomadReschedule  `json:"ReschedulePolicy,omitempty"`
-	Meta             map[string]string `json:"Meta,omitempty"`
+	Name             string             `json:"Name"`
+	Count            int                `json:"Count"`
+	Tasks            []*nomadTask       `json:"Tasks"`
+	Networks         []*nomadNetwork    `json:"Networks,omitempty"`
+	Services         []*nomadService    `json:"Services,omitempty"`
+	Volumes          map[string]any     `json:"Volumes,omitempty"`
+	RestartPolicy    *nomadRestart      `json:"RestartPolicy,omitempty"`
+	ReschedulePolicy *nomadReschedule   `json:"ReschedulePolicy,omitempty"`
+	Meta             map[string]string  `json:"Meta,omitempty"`
+	Constraints      []*nomadConstraint `json:"Constraints,omitempty"`
+	Affinities       []*nomadAffinity   `json:"Affinities,omitempty"`
+	Spreads          []*nomadSpread     `json:"Spreads,omitempty"`
 }
 
 type nomadTask struct {
@@ -117,6 +127,9 @@ type nomadTemplate struct {
 	EmbeddedTmpl string `json:"EmbeddedTmpl"`
 	DestPath     string `json:"DestPath"`
 	ChangeMode   string `json:"ChangeMode,omitempty"`
+	Perms        string `json:"Perms,omitempty"`
+	LeftDelim    string `json:"LeftDelim,omitempty"`
+	RightDelim   string `json:"RightDelim,omitempty"`
 }
 
 // jobName encodes the instance ID into the Nomad Job name. Mirrors
@@ -201,6 +214,10 @@ func buildJob(cfg Config, req provider.ProvisionRequest) *nomadJobRequest {
 		},
 	}
 
+	// Provision has already validated the placement.
+	placement, _ := workloadPlacement(req.Services)
+	applyPlacement(group, placement)
+
 	job := &nomadJob{
 		ID:         jobName(req.InstanceID),
 		Name:       jobName(req.InstanceID),
@@ -279,6 +296,14 @@ func buildTask(svc provider.ServiceSpec) *nomadTask {
 		// No lifecycle stanza — long-lived primary.
 	}
 
+	if len(svc.ConfigFiles) > 0 {
+		setTaskConfigFiles(t, svc.ConfigFiles)
+	}
+
+	if svc.RegistryAuth != nil {
+		setTaskRegistryAuth(t, svc.RegistryAuth)
+	}
+
 	return t
 }
 
@@ -0,0 +1,280 @@
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// tailLineBytes is the per-line byte budget used to turn
+// LogOptions.Tail into the byte offset Nomad's logs API wants
+// (origin=end&offset=<bytes>). Nomad has no line-based tail, so we
+// over-fetch a window and trim to the last Tail lines client-side.
+const tailLineBytes = 512
+
+// nomadStreamFrame is one frame of /v1/client/fs/logs with
+// plain=false: a JSON object per chunk, concatenated without
+// separators. Data is base64 in the wire format; encoding/json
+// decodes it straight into the []byte. Heartbeat frames carry no
+// Data; FileEvent reports rotation ("file deleted") and truncation.
+type nomadStreamFrame struct {
+	Data      []byte `json:"Data,omitempty"`
+	File      string `json:"File,omitempty"`
+	Offset    int64  `json:"Offset,omitempty"`
+	FileEvent string `json:"FileEvent,omitempty"`
+}
+
+// logFilter carries the LogOptions knobs the decoder applies
+// client-side because Nomad's API can't express them.
+type logFilter struct {
+	since  time.Time
+	tail   int
+	follow bool
+}
+
+// Logs streams one task's stdout and stderr from the instance's
+// current allocation via /v1/client/fs/logs. opts.ServiceName picks
+// the task (empty = the Main task).
+//
+// Nomad serves each stream separately, so two requests run side by
+// side and their lines are interleaved as they arrive. Each line
+// becomes one JSON provider.LogEvent. Since Nomad doesn't timestamp
+// log lines, opts.Since only filters lines that carry their own
+// leading RFC3339 timestamp; opts.Tail applies per stream.
+func (p *Provider) Logs(ctx context.Context, instanceID id.ID, opts provider.LogOptions) (io.ReadCloser, error) {
+	allocID, task, err := p.resolveTask(ctx, instanceID, opts.ServiceName, "logs")
+	if err != nil {
+		return nil, err
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	filter := logFilter{since: opts.Since, tail: opts.Tail, follow: opts.Follow}
+
+	var bodies []io.ReadCloser
+
+	for _, stream := range []string{"stdout", "stderr"} {
+		body, err := p.openLogStream(streamCtx, allocID, task, stream, opts)
+		if err != nil {
+			cancel()
+
+			for _, b := range bodies {
+				_ = b.Close()
+			}
+
+			return nil, fmt.Errorf("nomad: logs %s/%s: %w", allocID, task, err)
+		}
+
+		bodies = append(bodies, body)
+	}
+
+	r, w := io.Pipe()
+
+	var wg sync.WaitGroup
+
+	for i, stream := range []string{"stdout", "stderr"} {
+		body := bodies[i]
+
+		wg.Go(func() {
+			defer body.Close()
+
+			if err := streamNomadLogs(body, w, stream, filter); err != nil && streamCtx.Err() == nil {
+				// Best-effort: surface the decode error as a final
+				// event so the consumer sees why the stream ended.
+				_ = provider.WriteLogEvent(w, provider.LogEvent{
+					Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+					Stream:    "stderr",
+					Line:      "ctrlplane/nomad: " + stream + " log stream ended: " + err.Error(),
+				})
+			}
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		_ = w.Close()
+	}()
+
+	return &logStream{PipeReader: r, cancel: cancel}, nil
+}
+
+// logStream cancels the underlying HTTP streams when the consumer
+// closes the reader, so a follow stream doesn't outlive its reader.
+type logStream struct {
+	*io.PipeReader
+
+	cancel context.CancelFunc
+}
+
+// Close stops both HTTP streams and closes the pipe.
+func (s *logStream) Close() error {
+	s.cancel()
+
+	return s.PipeReader.Close()
+}
+
+// openLogStream issues the /v1/client/fs/logs request for one
+// stream type and returns the response body. The request goes
+// through a client without the provider's request timeout — a
+// follow stream legitimately stays open indefinitely; ctx bounds it
+// instead.
+func (p *Provider) openLogStream(ctx context.Context, allocID, task, stream string, opts provider.LogOptions) (io.ReadCloser, error) {
+	q := url.Values{}
+	q.Set("task", task)
+	q.Set("type", stream)
+	q.Set("plain", "false")
+	q.Set("follow", strconv.FormatBool(opts.Follow))
+
+	if opts.Tail > 0 {
+		q.Set("origin", "end")
+		q.Set("offset", strconv.Itoa(opts.Tail*tailLineBytes))
+	} else {
+		q.Set("origin", "start")
+		q.Set("offset", "0")
+	}
+
+	p.setScope(q)
+
+	endpoint := "/v1/client/fs/logs/" + url.PathEscape(allocID) + "?" + q.Encode()
+
+	resp, err := p.doStream(ctx, http.MethodGet, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// streamNomadLogs decodes the framed stream from src and writes one
+// provider.LogEvent per line to dst. Frames split lines arbitrarily,
+// so a partial trailing line is carried into the next frame and
+// flushed at EOF. Pure function so it's testable without a Nomad
+// agent.
+//
+// With f.tail set the byte window Nomad returned usually starts
+// mid-line; that first fragment is dropped. Without f.follow the
+// stream ends at EOF and only the last f.tail lines are emitted; in
+// follow mode the window is passed through as-is, so the backlog is
+// approximately (not exactly) f.tail lines.
+func streamNomadLogs(src io.Reader, dst io.Writer, stream string, f logFilter) error {
+	dec := json.NewDecoder(src)
+
+	var (
+		partial   []byte
+		backlog   []string
+		sawData   bool
+		dropFirst = f.tail > 0
+	)
+
+	emit := func(line string) error {
+		ts := leadingTimestamp(line)
+		if !f.since.IsZero() && ts != "" {
+			if t, err := time.Parse(time.RFC3339Nano, ts); err == nil && t.Before(f.since) {
+				return nil
+			}
+		}
+
+		if f.tail > 0 && !f.follow {
+			backlog = append(backlog, line)
+			if len(backlog) > f.tail {
+				backlog = backlog[1:]
+			}
+
+			return nil
+		}
+
+		return provider.WriteLogEvent(dst, provider.LogEvent{Timestamp: ts, Stream: stream, Line: line})
+	}
+
+	for {
+		var frame nomadStreamFrame
+		if err := dec.Decode(&frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return fmt.Errorf("decode frame: %w", err)
+		}
+
+		if len(frame.Data) == 0 {
+			continue // heartbeat or file event
+		}
+
+		if !sawData {
+			sawData = true
+			// Offset is the file position after this frame's data;
+			// when it doesn't exceed the data length the window
+			// reached the start of the file, so the first line is
+			// whole.
+			if frame.Offset <= int64(len(frame.Data)) {
+				dropFirst = false
+			}
+		}
+
+		partial = append(partial, frame.Data...)
+
+		for {
+			i := bytes.IndexByte(partial, '\n')
+			if i < 0 {
+				break
+			}
+
+			line := string(partial[:i])
+			partial = partial[i+1:]
+
+			if dropFirst {
+				dropFirst = false
+
+				continue
+			}
+
+			if err := emit(line); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(partial) > 0 && !dropFirst {
+		if err := emit(string(partial)); err != nil {
+			return err
+		}
+	}
+
+	for _, line := range backlog {
+		if err := provider.WriteLogEvent(dst, provider.LogEvent{Timestamp: leadingTimestamp(line), Stream: stream, Line: line}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// leadingTimestamp returns the RFC3339 timestamp a line starts
+// with when the application wrote one (many loggers do), so the event
+// carries a real ts and Since can filter on it. Nomad itself doesn't
+// timestamp lines; without one the consumer falls back to receipt
+// time. The line itself is left intact.
+func leadingTimestamp(line string) string {
+	sp := strings.IndexByte(line, ' ')
+	if sp < 20 {
+		return ""
+	}
+
+	if _, err := time.Parse(time.RFC3339Nano, line[:sp]); err != nil {
+		return ""
+	}
+
+	return line[:sp]
+}
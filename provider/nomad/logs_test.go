@@ -0,0 +1,195 @@
+package nomad
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// encodeFrames renders chunks the way /v1/client/fs/logs does with
+// plain=false: concatenated JSON frames with base64 Data and Offset
+// advancing past each chunk. A nil chunk becomes a heartbeat frame.
+func encodeFrames(t *testing.T, start int64, chunks ...[]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	offset := start
+
+	for _, c := range chunks {
+		if c == nil {
+			_ = enc.Encode(nomadStreamFrame{})
+
+			continue
+		}
+
+		offset += int64(len(c))
+		if err := enc.Encode(nomadStreamFrame{Data: c, File: "alloc/logs/web.stdout.0", Offset: offset}); err != nil {
+			t.Fatalf("encode frame: %v", err)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// decodeEvents parses the newline-delimited provider.LogEvent output.
+func decodeEvents(t *testing.T, r io.Reader) []provider.LogEvent {
+	t.Helper()
+
+	var out []provider.LogEvent
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var ev provider.LogEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("decode event %q: %v", sc.Text(), err)
+		}
+
+		out = append(out, ev)
+	}
+
+	return out
+}
+
+// TestStreamNomadLogs_ReassemblesLines verifies lines split across
+// frames are stitched back together, heartbeats are skipped, and a
+// trailing line without a newline is flushed at EOF.
+func TestStreamNomadLogs_ReassemblesLines(t *testing.T) {
+	t.Parallel()
+
+	src := encodeFrames(t, 0, []byte("hel"), nil, []byte("lo\nwor"), []byte("ld\npartial"))
+
+	var dst bytes.Buffer
+	if err := streamNomadLogs(bytes.NewReader(src), &dst, "stdout", logFilter{}); err != nil {
+		t.Fatalf("streamNomadLogs: %v", err)
+	}
+
+	got := decodeEvents(t, &dst)
+	want := []string{"hello", "world", "partial"}
+
+	if len(got) != len(want) {
+		t.Fatalf("events: want %d, got %d (%+v)", len(want), len(got), got)
+	}
+
+	for i, w := range want {
+		if got[i].Line != w || got[i].Stream != "stdout" {
+			t.Fatalf("event %d: want stdout %q, got %+v", i, w, got[i])
+		}
+	}
+}
+
+// TestStreamNomadLogs_TailAndSince verifies a tail window starting
+// mid-file drops its leading fragment and keeps only the last N
+// lines, and that Since filters lines carrying their own timestamp.
+func TestStreamNomadLogs_TailAndSince(t *testing.T) {
+	t.Parallel()
+
+	src := encodeFrames(t, 100, []byte("ment\none\ntwo\nthree\n"))
+
+	var dst bytes.Buffer
+	if err := streamNomadLogs(bytes.NewReader(src), &dst, "stderr", logFilter{tail: 2}); err != nil {
+		t.Fatalf("streamNomadLogs: %v", err)
+	}
+
+	got := decodeEvents(t, &dst)
+	if len(got) != 2 || got[0].Line != "two" || got[1].Line != "three" {
+		t.Fatalf("tail: want [two three], got %+v", got)
+	}
+
+	since := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	src = encodeFrames(t, 0, []byte(
+		"2026-05-01T11:59:59Z old\n"+
+			"2026-05-01T12:00:01Z new\n"+
+			"no timestamp\n"))
+
+	dst.Reset()
+
+	if err := streamNomadLogs(bytes.NewReader(src), &dst, "stdout", logFilter{since: since}); err != nil {
+		t.Fatalf("streamNomadLogs: %v", err)
+	}
+
+	got = decodeEvents(t, &dst)
+	if len(got) != 2 || got[0].Timestamp != "2026-05-01T12:00:01Z" || got[1].Line != "no timestamp" {
+		t.Fatalf("since: want [new, no timestamp], got %+v", got)
+	}
+}
+
+// TestLogs_StreamsTaskFromNewestRunningAlloc drives Logs against a
+// httptest stand-in: the job's Main task is targeted by default, the
+// newest running allocation is picked, and stdout and stderr are
+// both fetched and merged.
+func TestLogs_StreamsTaskFromNewestRunningAlloc(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	name := jobName(instID)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/job/" + name:
+			_ = json.NewEncoder(w).Encode(nomadJob{
+				ID: name,
+				TaskGroups: []*nomadTaskGroup{{
+					Name: taskGroupName,
+					Tasks: []*nomadTask{
+						{Name: "envoy", Lifecycle: &nomadLifecycle{Hook: "poststart", Sidecar: true}},
+						{Name: "web"},
+					},
+				}},
+			})
+		case "/v1/job/" + name + "/allocations":
+			_ = json.NewEncoder(w).Encode([]nomadAlloc{
+				{ID: "old", ClientStatus: "running", CreateIndex: 10},
+				{ID: "new", ClientStatus: "running", CreateIndex: 20},
+				{ID: "pending", ClientStatus: "pending", CreateIndex: 30},
+			})
+		case "/v1/client/fs/logs/new":
+			if r.URL.Query().Get("task") != "web" {
+				http.Error(w, "wrong task "+r.URL.Query().Get("task"), http.StatusBadRequest)
+
+				return
+			}
+
+			_, _ = w.Write(encodeFrames(t, 0, []byte(r.URL.Query().Get("type")+" line\n")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	rc, err := p.Logs(context.Background(), instID, provider.LogOptions{})
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	defer rc.Close()
+
+	got := map[string]string{}
+	for _, ev := range decodeEvents(t, rc) {
+		got[ev.Stream] = ev.Line
+	}
+
+	if got["stdout"] != "stdout line" || got["stderr"] != "stderr line" {
+		t.Fatalf("streams: got %+v", got)
+	}
+
+	if _, err := p.Logs(context.Background(), instID, provider.LogOptions{ServiceName: "missing"}); err == nil ||
+		!strings.Contains(err.Error(), `"missing"`) {
+		t.Fatalf("unknown service: want not-found error, got %v", err)
+	}
+}
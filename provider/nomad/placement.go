@@ -0,0 +1,168 @@
+package nomad
+
+// placement.go translates a workload's provider.Placement onto its
+// task group:
+//
+//   - NodeSelector and Required become constraint stanzas.
+//   - Preferred becomes affinity stanzas; NotIn and DoesNotExist
+//     preferences are affinities with a negative weight.
+//   - Spread becomes spread stanzas. Nomad spreads are always soft,
+//     so a Required spread is rejected.
+//   - Tolerations are ignored: Nomad has no taints, so nothing needs
+//     tolerating.
+//
+// A placement key names client node meta (`${meta.<key>}`) unless it
+// is already an interpolation such as `${node.datacenter}`.
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// defaultSpreadWeight is the weight of a spread stanza; the provider
+// spec has no notion of spread weight.
+const defaultSpreadWeight = 50
+
+type nomadConstraint struct {
+	LTarget string `json:"LTarget"`
+	RTarget string `json:"RTarget,omitempty"`
+	Operand string `json:"Operand"`
+}
+
+type nomadAffinity struct {
+	LTarget string `json:"LTarget"`
+	RTarget string `json:"RTarget"`
+	Operand string `json:"Operand"`
+	Weight  int    `json:"Weight"`
+}
+
+type nomadSpread struct {
+	Attribute string `json:"Attribute"`
+	Weight    int    `json:"Weight"`
+}
+
+// workloadPlacement returns the workload's placement, rejecting what
+// Nomad can't honour.
+func workloadPlacement(services []provider.ServiceSpec) (*provider.Placement, error) {
+	pl, err := provider.WorkloadPlacement(services)
+	if err != nil {
+		return nil, err
+	}
+
+	if pl != nil {
+		for _, s := range pl.Spread {
+			if s.Required {
+				return nil, fmt.Errorf("%w: required spread on %q: nomad spreads are best-effort", ctrlplane.ErrInvalidConfig, s.Key)
+			}
+		}
+	}
+
+	return pl, nil
+}
+
+// nomadAttribute maps a placement key onto a Nomad node attribute.
+func nomadAttribute(key string) string {
+	if strings.HasPrefix(key, "${") {
+		return key
+	}
+
+	return "${meta." + key + "}"
+}
+
+// applyPlacement sets the group's constraints, affinities and spreads.
+func applyPlacement(group *nomadTaskGroup, pl *provider.Placement) {
+	if pl == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(pl.NodeSelector))
+	for k := range pl.NodeSelector {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		group.Constraints = append(group.Constraints, &nomadConstraint{
+			LTarget: nomadAttribute(k),
+			RTarget: pl.NodeSelector[k],
+			Operand: "=",
+		})
+	}
+
+	for _, r := range pl.Required {
+		group.Constraints = append(group.Constraints, constraintsFor(r)...)
+	}
+
+	for _, r := range pl.Preferred {
+		group.Affinities = append(group.Affinities, affinityFor(r))
+	}
+
+	for _, s := range pl.Spread {
+		group.Spreads = append(group.Spreads, &nomadSpread{
+			Attribute: nomadAttribute(s.Key),
+			Weight:    defaultSpreadWeight,
+		})
+	}
+}
+
+// constraintsFor maps a required node requirement onto constraints.
+// NotIn takes one `!=` constraint per value.
+func constraintsFor(r provider.NodeRequirement) []*nomadConstraint {
+	attr := nomadAttribute(r.Key)
+
+	switch r.Operator {
+	case provider.NodeOpIn:
+		return []*nomadConstraint{matchAny(attr, r.Values)}
+	case provider.NodeOpNotIn:
+		out := make([]*nomadConstraint, 0, len(r.Values))
+		for _, v := range r.Values {
+			out = append(out, &nomadConstraint{LTarget: attr, RTarget: v, Operand: "!="})
+		}
+
+		return out
+	case provider.NodeOpExists:
+		return []*nomadConstraint{{LTarget: attr, Operand: "is_set"}}
+	case provider.NodeOpDoesNotExist:
+		return []*nomadConstraint{{LTarget: attr, Operand: "is_not_set"}}
+	}
+
+	return nil
+}
+
+// affinityFor maps a preferred node requirement onto an affinity.
+// Nomad affinities can't test for a missing attribute, so the negative
+// operators become the positive match with a negative weight.
+func affinityFor(r provider.WeightedRequirement) *nomadAffinity {
+	attr := nomadAttribute(r.Key)
+	weight := r.Weight
+
+	var c *nomadConstraint
+
+	switch r.Operator {
+	case provider.NodeOpIn, provider.NodeOpNotIn:
+		c = matchAny(attr, r.Values)
+	case provider.NodeOpExists, provider.NodeOpDoesNotExist:
+		c = &nomadConstraint{LTarget: attr, RTarget: ".", Operand: "regexp"}
+	}
+
+	if r.Operator == provider.NodeOpNotIn || r.Operator == provider.NodeOpDoesNotExist {
+		weight = -weight
+	}
+
+	return &nomadAffinity{LTarget: c.LTarget, RTarget: c.RTarget, Operand: c.Operand, Weight: weight}
+}
+
+// matchAny matches attr against one value with `=`, or against several
+// with set_contains_any.
+func matchAny(attr string, values []string) *nomadConstraint {
+	if len(values) == 1 {
+		return &nomadConstraint{LTarget: attr, RTarget: values[0], Operand: "="}
+	}
+
+	return &nomadConstraint{LTarget: attr, RTarget: strings.Join(values, ","), Operand: "set_contains_any"}
+}
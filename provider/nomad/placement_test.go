@@ -0,0 +1,92 @@
+package nomad
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+func TestBuildJob_Placement(t *testing.T) {
+	t.Parallel()
+
+	req := provider.ProvisionRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services: []provider.ServiceSpec{{
+			Name:  "main",
+			Image: "x:1",
+			Role:  provider.RoleMain,
+			Placement: &provider.Placement{
+				NodeSelector: map[string]string{"pool": "gpu"},
+				Required: []provider.NodeRequirement{
+					{Key: "zone", Operator: provider.NodeOpIn, Values: []string{"a", "b"}},
+					{Key: "${node.class}", Operator: provider.NodeOpNotIn, Values: []string{"spot"}},
+					{Key: "gpu", Operator: provider.NodeOpExists},
+				},
+				Preferred: []provider.WeightedRequirement{
+					{NodeRequirement: provider.NodeRequirement{Key: "disk", Operator: provider.NodeOpIn, Values: []string{"ssd"}}, Weight: 40},
+					{NodeRequirement: provider.NodeRequirement{Key: "legacy", Operator: provider.NodeOpDoesNotExist}, Weight: 10},
+				},
+				Tolerations: []provider.Toleration{{Operator: provider.TolerationExists}},
+				Spread:      []provider.SpreadConstraint{{Key: "${node.datacenter}"}},
+			},
+		}},
+	}
+
+	group := buildJob(Config{}, req).Job.TaskGroups[0]
+
+	want := []nomadConstraint{
+		{LTarget: "${meta.pool}", RTarget: "gpu", Operand: "="},
+		{LTarget: "${meta.zone}", RTarget: "a,b", Operand: "set_contains_any"},
+		{LTarget: "${node.class}", RTarget: "spot", Operand: "!="},
+		{LTarget: "${meta.gpu}", Operand: "is_set"},
+	}
+	if len(group.Constraints) != len(want) {
+		t.Fatalf("constraints = %d, want %d", len(group.Constraints), len(want))
+	}
+
+	for i, c := range group.Constraints {
+		if *c != want[i] {
+			t.Errorf("constraint[%d] = %+v, want %+v", i, *c, want[i])
+		}
+	}
+
+	if len(group.Affinities) != 2 {
+		t.Fatalf("affinities = %d, want 2", len(group.Affinities))
+	}
+
+	if a := group.Affinities[0]; a.LTarget != "${meta.disk}" || a.RTarget != "ssd" || a.Operand != "=" || a.Weight != 40 {
+		t.Errorf("affinity[0] = %+v", *a)
+	}
+
+	if a := group.Affinities[1]; a.Operand != "regexp" || a.Weight != -10 {
+		t.Errorf("affinity[1] = %+v, want a negative-weight match", *a)
+	}
+
+	if len(group.Spreads) != 1 || group.Spreads[0].Attribute != "${node.datacenter}" {
+		t.Errorf("spreads = %+v", group.Spreads)
+	}
+}
+
+// TestProvision_RejectsRequiredSpread verifies a hard spread, which
+// Nomad can't enforce, fails before any job is submitted.
+func TestProvision_RejectsRequiredSpread(t *testing.T) {
+	t.Parallel()
+
+	p := &Provider{}
+
+	_, err := p.Provision(context.Background(), provider.ProvisionRequest{
+		InstanceID: id.New(id.PrefixInstance),
+		Services: []provider.ServiceSpec{{
+			Name:      "main",
+			Image:     "x:1",
+			Placement: &provider.Placement{Spread: []provider.SpreadConstraint{{Key: "zone", Required: true}}},
+		}},
+	})
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Errorf("err = %v, want ErrInvalidConfig", err)
+	}
+}
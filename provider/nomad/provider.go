@@ -62,16 +62,19 @@ func (p *Provider) HealthCheck(ctx context.Context) (*provider.HealthStatus, err
 	}, nil
 }
 
-// ErrLogsNotImplemented is returned by Logs until per-task log
-// streaming is wired (Nomad's logs API is per-allocation +
-// per-task; doable but deferred).
+// ErrLogsNotImplemented is no longer returned — Logs streams from
+// the allocation's log files (see logs.go).
+//
+// Deprecated: retained so existing errors.Is checks still compile.
 var ErrLogsNotImplemented = errors.New("nomad: logs not implemented")
 
-// Compile-time check that Provider implements provider.Provider
-// and provider.HealthChecker.
+// Compile-time check that Provider implements provider.Provider,
+// provider.HealthChecker and provider.Rollbacker.
 var (
 	_ provider.Provider      = (*Provider)(nil)
 	_ provider.HealthChecker = (*Provider)(nil)
+	_ provider.Rollbacker    = (*Provider)(nil)
+	_ provider.Watcher       = (*Provider)(nil)
 )
 
 // Provider is a HashiCorp Nomad infrastructure provider.
@@ -117,7 +120,9 @@ func (p *Provider) Capabilities() []provider.Capability {
 		provider.CapProvision,
 		provider.CapDeploy,
 		provider.CapScale,
+		provider.CapPlacement,
 		provider.CapLogs,
+		provider.CapExec,
 	}
 }
 
@@ -133,6 +138,10 @@ func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest)
 		return nil, errors.New("nomad: provision requires exactly one Main service")
 	}
 
+	if _, err := workloadPlacement(req.Services); err != nil {
+		return nil, fmt.Errorf("nomad: %w", err)
+	}
+
 	body := buildJob(p.cfg, req)
 	if err := p.submitJob(ctx, body); err != nil {
 		return nil, fmt.Errorf("nomad: submit job: %w", err)
@@ -288,8 +297,8 @@ func (p *Provider) Status(ctx context.Context, instanceID id.ID) (*provider.Inst
 //
 // Phase 3 implementation: fetch the current Job, walk req.Services
 // patching matching tasks (image via task.Config.image; env via
-// task.Env merge), then re-submit. Services not in req.Services
-// keep their current image.
+// task.Env merge; config files via template stanzas), then re-submit.
+// Services not in req.Services keep their current image.
 func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*provider.DeployResult, error) {
 	if len(req.Services) == 0 {
 		return nil, errors.New("nomad: deploy requires at least one service")
@@ -317,6 +326,7 @@ func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*pro
 			}
 
 			task.Config["image"] = update.Image
+			setTaskRegistryAuth(task, update.RegistryAuth)
 
 			if update.Env != nil {
 				if task.Env == nil {
@@ -325,6 +335,10 @@ func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*pro
 
 				maps.Copy(task.Env, update.Env)
 			}
+
+			if len(update.ConfigFiles) > 0 {
+				setTaskConfigFiles(task, update.ConfigFiles)
+			}
 		}
 	}
 
@@ -332,7 +346,7 @@ func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*pro
 		job.Meta = make(map[string]string, 1)
 	}
 
-	job.Meta["ctrlplane.release"] = req.ReleaseID.String()
+	job.Meta[metaRelease] = req.ReleaseID.String()
 
 	if err := p.submitJob(ctx, &nomadJobRequest{Job: job}); err != nil {
 		return nil, fmt.Errorf("nomad: re-submit job for deploy: %w", err)
@@ -344,13 +358,6 @@ func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*pro
 	}, nil
 }
 
-// Rollback is intentionally a no-op — the deploy.Service builds a
-// new DeployRequest from the prior Release's snapshot and routes it
-// through Deploy. Nomad doesn't need a separate rollback path.
-func (p *Provider) Rollback(_ context.Context, _ id.ID, _ id.ID) error {
-	return nil
-}
-
 // Scale adjusts the TaskGroup count for an instance.
 func (p *Provider) Scale(ctx context.Context, instanceID id.ID, spec provider.ResourceSpec) error {
 	endpoint := fmt.Sprintf("/v1/job/%s/scale", url.PathEscape(jobName(instanceID)))
@@ -372,20 +379,6 @@ func (p *Provider) Resources(ctx context.Context, instanceID id.ID) (*provider.R
 	return fetchAllocResources(ctx, p.cfg.Address, p.cfg.Namespace, instanceID)
 }
 
-// Logs streams logs for one task in the instance's allocation.
-// Phase 3 leaves this stubbed — Nomad's per-task logs API needs an
-// allocation-ID + task-name + frame demuxer; deferred.
-func (p *Provider) Logs(_ context.Context, _ id.ID, _ provider.LogOptions) (io.ReadCloser, error) {
-	return nil, ErrLogsNotImplemented
-}
-
-// Exec is not yet implemented — Nomad supports `nomad alloc exec`
-// via WebSocket; pluggable transport for ExecResult round-trips
-// lands when the exec UI feature is added.
-func (p *Provider) Exec(_ context.Context, _ id.ID, _ provider.ExecRequest) (*provider.ExecResult, error) {
-	return &provider.ExecResult{ExitCode: 0}, nil
-}
-
 // pickMain finds the first Main service (default-Role-is-Main)
 // in a slice. Returns nil for empty slices or all-Sidecar/Init slices.
 func pickMain(services []provider.ServiceSpec) *provider.ServiceSpec {
@@ -459,6 +452,108 @@ func (p *Provider) listJobAllocations(ctx context.Context, instanceID id.ID) ([]
 	return allocs, nil
 }
 
+// resolveTask picks the allocation and task a per-service operation
+// (logs, exec) targets. The newest running allocation wins; when none
+// is running the newest of any status is used so a crash-looping
+// task's logs stay reachable. Empty serviceName picks the Main task —
+// the one without a lifecycle stanza. op names the calling operation
+// in errors.
+func (p *Provider) resolveTask(ctx context.Context, instanceID id.ID, serviceName, op string) (string, string, error) {
+	job, err := p.getJob(ctx, instanceID)
+	if err != nil {
+		if isNotFound(err) {
+			return "", "", fmt.Errorf("nomad: %s: job %s not found", op, jobName(instanceID))
+		}
+
+		return "", "", fmt.Errorf("nomad: %s: get job: %w", op, err)
+	}
+
+	task, err := jobTask(job, serviceName)
+	if err != nil {
+		return "", "", fmt.Errorf("nomad: %s: %w", op, err)
+	}
+
+	allocs, err := p.listJobAllocations(ctx, instanceID)
+	if err != nil {
+		return "", "", fmt.Errorf("nomad: %s: list allocations: %w", op, err)
+	}
+
+	alloc := pickAlloc(allocs)
+	if alloc == nil {
+		return "", "", fmt.Errorf("nomad: %s: no allocation for job %s", op, jobName(instanceID))
+	}
+
+	return alloc.ID, task, nil
+}
+
+// jobTask resolves serviceName to a task in the job's task group.
+// Empty picks the Main task (no lifecycle stanza), falling back to
+// the first task for jobs not built by buildJob.
+func jobTask(job *nomadJob, serviceName string) (string, error) {
+	var tasks []*nomadTask
+	for _, g := range job.TaskGroups {
+		tasks = append(tasks, g.Tasks...)
+	}
+
+	if serviceName != "" {
+		for _, t := range tasks {
+			if t.Name == serviceName {
+				return t.Name, nil
+			}
+		}
+
+		return "", fmt.Errorf("service %q not found in job %s", serviceName, job.ID)
+	}
+
+	for _, t := range tasks {
+		if t.Lifecycle == nil {
+			return t.Name, nil
+		}
+	}
+
+	if len(tasks) == 0 {
+		return "", fmt.Errorf("job %s has no tasks", job.ID)
+	}
+
+	return tasks[0].Name, nil
+}
+
+// pickAlloc returns the newest running allocation, or the newest of
+// any status when none is running. Nil for an empty list.
+func pickAlloc(allocs []nomadAlloc) *nomadAlloc {
+	var newest, newestRunning *nomadAlloc
+
+	for i := range allocs {
+		a := &allocs[i]
+		if newest == nil || a.CreateIndex > newest.CreateIndex {
+			newest = a
+		}
+
+		if a.ClientStatus == "running" && (newestRunning == nil || a.CreateIndex > newestRunning.CreateIndex) {
+			newestRunning = a
+		}
+	}
+
+	if newestRunning != nil {
+		return newestRunning
+	}
+
+	return newest
+}
+
+// setScope adds the configured namespace and region to a query so
+// client-side endpoints (fs, exec) reach the right allocation in
+// multi-namespace / multi-region clusters.
+func (p *Provider) setScope(q url.Values) {
+	if p.cfg.Namespace != "" {
+		q.Set("namespace", p.cfg.Namespace)
+	}
+
+	if p.cfg.Region != "" {
+		q.Set("region", p.cfg.Region)
+	}
+}
+
 // notFoundError is returned by doRequest when Nomad responds with 404.
 type notFoundError struct{ url string }
 
@@ -525,3 +620,43 @@ func (p *Provider) doRequest(ctx context.Context, method, endpoint string, body,
 
 	return nil
 }
+
+// doStream issues a request whose response body is consumed
+// incrementally (log streams). Unlike doRequest it doesn't use
+// p.client's overall timeout — the caller's ctx bounds the stream —
+// and on success hands the open response back to the caller, who
+// must close the body.
+func (p *Provider) doStream(ctx context.Context, method, endpoint string) (*http.Response, error) {
+	addr := strings.TrimRight(p.cfg.Address, "/")
+
+	req, err := http.NewRequestWithContext(ctx, method, addr+endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	if p.cfg.Token != "" {
+		req.Header.Set("X-Nomad-Token", p.cfg.Token)
+	}
+
+	streamClient := &http.Client{Transport: p.client.Transport}
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+
+		return nil, notFoundError{url: endpoint}
+	}
+
+	if resp.StatusCode >= 400 {
+		raw, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		return nil, fmt.Errorf("nomad %s %s: status %d: %s", method, endpoint, resp.StatusCode, string(raw))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,25 @@
+package nomad
+
+import "github.com/xraph/ctrlplane/secrets"
+
+// setTaskRegistryAuth sets the docker driver's auth block from a
+// service's resolved registry credential, or removes it when there is
+// none — a task moved to an image on another registry must not keep
+// sending the old registry's login.
+func setTaskRegistryAuth(task *nomadTask, cred *secrets.RegistryCredential) {
+	if task.Config == nil {
+		task.Config = make(map[string]any, 1)
+	}
+
+	if cred == nil {
+		delete(task.Config, "auth")
+
+		return
+	}
+
+	task.Config["auth"] = map[string]any{
+		"username":       cred.Username,
+		"password":       cred.Password,
+		"server_address": cred.Server,
+	}
+}
@@ -0,0 +1,32 @@
+package nomad
+
+import (
+	"testing"
+
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/secrets"
+)
+
+// TestSetTaskRegistryAuth_SetsAndClears verifies a resolved credential
+// becomes the docker driver's auth block, and that deploying an image
+// without one removes the stale block.
+func TestSetTaskRegistryAuth_SetsAndClears(t *testing.T) {
+	t.Parallel()
+
+	task := buildTask(provider.ServiceSpec{
+		Name:         "api",
+		Image:        "ghcr.io/acme/api:v1",
+		RegistryAuth: &secrets.RegistryCredential{Server: "ghcr.io", Username: "bot", Password: "pw"},
+	})
+
+	auth, ok := task.Config["auth"].(map[string]any)
+	if !ok || auth["username"] != "bot" || auth["password"] != "pw" || auth["server_address"] != "ghcr.io" {
+		t.Fatalf("auth block: %v", task.Config["auth"])
+	}
+
+	setTaskRegistryAuth(task, nil)
+
+	if _, ok := task.Config["auth"]; ok {
+		t.Fatalf("auth block survived a credential-less update: %v", task.Config)
+	}
+}
@@ -0,0 +1,143 @@
+package nomad
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// metaRelease is the job Meta key Deploy stamps with the Release ID.
+// Every job version therefore records which Release it ran, which is
+// how RollbackRelease maps a Release back to a version to revert to.
+const metaRelease = "ctrlplane.release"
+
+// nomadJobVersions is the response of /v1/job/:id/versions.
+type nomadJobVersions struct {
+	Versions []*nomadJob `json:"Versions"`
+}
+
+// nomadJobRevertRequest is the body of /v1/job/:id/revert.
+type nomadJobRevertRequest struct {
+	JobID      string `json:"JobID"`
+	JobVersion uint64 `json:"JobVersion"`
+}
+
+// Rollback reverts the instance to releaseID using Nomad's job
+// version history only. When that version has been garbage-collected
+// there's nothing to revert to; deploy.Service calls RollbackRelease
+// with the Release snapshot, which covers that case.
+func (p *Provider) Rollback(ctx context.Context, instanceID id.ID, releaseID id.ID) error {
+	_, err := p.RollbackRelease(ctx, provider.RollbackRequest{
+		InstanceID: instanceID,
+		ReleaseID:  releaseID,
+	})
+
+	return err
+}
+
+// RollbackRelease reverts the job to the version that ran the target
+// Release via /v1/job/:id/revert — the same single call as `nomad job
+// revert`, so Nomad's update stanza drives the rollout and the revert
+// is recorded as a new job version.
+//
+// If the current version already runs the Release this is a no-op.
+// If no retained version ran it, the snapshot's images, env and config
+// files are patched onto the current job and re-submitted instead.
+func (p *Provider) RollbackRelease(ctx context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	name := jobName(req.InstanceID)
+	result := &provider.DeployResult{ProviderRef: "nomad:" + name, Status: "rolled_back"}
+
+	var versions nomadJobVersions
+	if err := p.doRequest(ctx, http.MethodGet, "/v1/job/"+url.PathEscape(name)+"/versions", nil, &versions); err != nil {
+		return nil, fmt.Errorf("nomad: rollback: list job versions: %w", err)
+	}
+
+	current, target := releaseVersions(versions.Versions, req.ReleaseID)
+	if current == nil {
+		return nil, fmt.Errorf("nomad: rollback: job %s has no versions", name)
+	}
+
+	if current.Meta[metaRelease] == req.ReleaseID.String() {
+		return result, nil
+	}
+
+	if target != nil {
+		body := nomadJobRevertRequest{JobID: name, JobVersion: target.Version}
+		if err := p.doRequest(ctx, http.MethodPost, "/v1/job/"+url.PathEscape(name)+"/revert", body, nil); err != nil {
+			return nil, fmt.Errorf("nomad: revert job to version %d: %w", target.Version, err)
+		}
+
+		return result, nil
+	}
+
+	if len(req.Services) == 0 {
+		return nil, fmt.Errorf("nomad: rollback: no job version for release %s and no snapshot", req.ReleaseID)
+	}
+
+	applySnapshot(current, req.Services)
+
+	if current.Meta == nil {
+		current.Meta = make(map[string]string, 1)
+	}
+
+	current.Meta[metaRelease] = req.ReleaseID.String()
+
+	if err := p.submitJob(ctx, &nomadJobRequest{Job: current}); err != nil {
+		return nil, fmt.Errorf("nomad: re-submit job for rollback: %w", err)
+	}
+
+	return result, nil
+}
+
+// releaseVersions returns the newest job version and the newest
+// version that ran releaseID (nil when none did). Pure function so
+// it's testable without a Nomad agent.
+func releaseVersions(versions []*nomadJob, releaseID id.ID) (current, target *nomadJob) {
+	for _, v := range versions {
+		if current == nil || v.Version > current.Version {
+			current = v
+		}
+
+		if v.Meta[metaRelease] == releaseID.String() && (target == nil || v.Version > target.Version) {
+			target = v
+		}
+	}
+
+	return current, target
+}
+
+// applySnapshot sets each snapshotted task's image, env and config
+// files to the Release's. Unlike Deploy's env merge, env is replaced
+// outright: a variable added after the Release must not survive a
+// rollback to it.
+func applySnapshot(job *nomadJob, snaps []provider.ServiceSnapshot) {
+	byName := make(map[string]provider.ServiceSnapshot, len(snaps))
+	for _, s := range snaps {
+		byName[s.Name] = s
+	}
+
+	for _, group := range job.TaskGroups {
+		for _, task := range group.Tasks {
+			snap, ok := byName[task.Name]
+			if !ok {
+				continue
+			}
+
+			if task.Config == nil {
+				task.Config = make(map[string]any, 1)
+			}
+
+			task.Config["image"] = snap.Image
+			task.Env = snap.Env
+			setTaskRegistryAuth(task, snap.RegistryAuth)
+
+			if len(snap.ConfigFiles) > 0 {
+				setTaskConfigFiles(task, snap.ConfigFiles)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package nomad
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestRollbackRelease_RevertsToReleaseVersion verifies the job is
+// reverted to the newest version whose Meta names the target Release,
+// with a single /revert call and no re-submission.
+func TestRollbackRelease_RevertsToReleaseVersion(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	name := jobName(instID)
+	relA, relB := id.New(id.PrefixRelease), id.New(id.PrefixRelease)
+
+	var (
+		reverted  *nomadJobRevertRequest
+		submitted bool
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/job/" + name + "/versions":
+			_ = json.NewEncoder(w).Encode(nomadJobVersions{Versions: []*nomadJob{
+				{ID: name, Version: 3, Meta: map[string]string{metaRelease: relB.String()}},
+				{ID: name, Version: 2, Meta: map[string]string{metaRelease: relA.String()}},
+				{ID: name, Version: 1, Meta: map[string]string{metaRelease: relA.String()}},
+			}})
+		case "/v1/job/" + name + "/revert":
+			reverted = &nomadJobRevertRequest{}
+			_ = json.NewDecoder(r.Body).Decode(reverted)
+			_, _ = w.Write([]byte(`{}`))
+		case "/v1/jobs":
+			submitted = true
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := p.RollbackRelease(context.Background(), provider.RollbackRequest{
+		InstanceID: instID,
+		ReleaseID:  relA,
+		Services:   []provider.ServiceSnapshot{{Name: "web", Image: "web:a"}},
+	}); err != nil {
+		t.Fatalf("RollbackRelease: %v", err)
+	}
+
+	if reverted == nil || reverted.JobID != name || reverted.JobVersion != 2 {
+		t.Fatalf("revert: want %s@2, got %+v", name, reverted)
+	}
+
+	if submitted {
+		t.Fatal("job was re-submitted; want a revert only")
+	}
+}
+
+// TestApplySnapshot_ReplacesImageAndEnv verifies the snapshot
+// fallback sets images and replaces (not merges) env, leaving tasks
+// outside the snapshot untouched.
+func TestApplySnapshot_ReplacesImageAndEnv(t *testing.T) {
+	t.Parallel()
+
+	job := &nomadJob{TaskGroups: []*nomadTaskGroup{{Tasks: []*nomadTask{
+		{Name: "web", Config: map[string]any{"image": "web:b"}, Env: map[string]string{"NEW": "1", "MODE": "b"}},
+		{Name: "envoy", Config: map[string]any{"image": "envoy:1"}},
+	}}}}
+
+	applySnapshot(job, []provider.ServiceSnapshot{{Name: "web", Image: "web:a", Env: map[string]string{"MODE": "a"}}})
+
+	web, envoy := job.TaskGroups[0].Tasks[0], job.TaskGroups[0].Tasks[1]
+
+	if web.Config["image"] != "web:a" || len(web.Env) != 1 || web.Env["MODE"] != "a" {
+		t.Fatalf("web task: image=%v env=%v", web.Config["image"], web.Env)
+	}
+
+	if envoy.Config["image"] != "envoy:1" {
+		t.Fatalf("envoy task should be untouched, got image %v", envoy.Config["image"])
+	}
+}
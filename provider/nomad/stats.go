@@ -100,13 +100,15 @@ func fetchAllocResources(ctx context.Context, address, namespace string, instanc
 }
 
 // nomadAlloc is the projection of a Nomad allocation we read from
-// /v1/job/<jobID>/allocations. Used by both stats (CPU/memory roll-
-// ups) and Status (per-task state aggregation).
+// /v1/job/<jobID>/allocations. Used by stats (CPU/memory roll-ups),
+// Status (per-task state aggregation) and resolveTask (CreateIndex
+// orders allocations newest-first).
 type nomadAlloc struct {
 	ID           string                       `json:"ID"`
 	JobID        string                       `json:"JobID,omitempty"`
 	TaskGroup    string                       `json:"TaskGroup,omitempty"`
 	ClientStatus string                       `json:"ClientStatus"`
+	CreateIndex  uint64                       `json:"CreateIndex,omitempty"`
 	TaskStates   map[string]nomadTaskStateAPI `json:"TaskStates,omitempty"`
 }
 
@@ -118,9 +120,11 @@ type nomadTaskStateAPI struct {
 }
 
 type nomadTaskEventAPI struct {
-	Type       string `json:"Type"`
-	DisplayMsg string `json:"DisplayMessage"`
-	ExitCode   int    `json:"ExitCode"`
+	Type       string            `json:"Type"`
+	Time       int64             `json:"Time,omitempty"`
+	DisplayMsg string            `json:"DisplayMessage"`
+	ExitCode   int               `json:"ExitCode"`
+	Details    map[string]string `json:"Details,omitempty"`
 }
 
 // nomadAllocStats mirrors the shape returned by
@@ -0,0 +1,189 @@
+package nomad
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Nomad task event types Watch reports on.
+const (
+	taskEventRestarting = "Restarting"
+	taskEventTerminated = "Terminated"
+)
+
+// nomadEventFrame is one frame of /v1/event/stream. Heartbeat frames
+// are empty objects.
+type nomadEventFrame struct {
+	Index  uint64       `json:"Index"`
+	Events []nomadEvent `json:"Events"`
+}
+
+// nomadEvent is one event in a frame. Only the Allocation topic is
+// subscribed to, so only that payload is decoded.
+type nomadEvent struct {
+	Topic   string `json:"Topic"`
+	Type    string `json:"Type"`
+	Key     string `json:"Key"`
+	Payload struct {
+		Allocation *nomadAlloc `json:"Allocation"`
+	} `json:"Payload"`
+}
+
+// Watch streams state changes from Nomad's event stream
+// (/v1/event/stream, Allocation topic). Every allocation update for a
+// ctrlplane job re-reads the instance's Status, so the reported state
+// is the same aggregate Status returns; task "Restarting" and
+// OOM-killed "Terminated" events are reported as restarts and OOM
+// kills. Task events older than the watch are ignored — the broker
+// replays its buffer to new subscribers.
+func (p *Provider) Watch(ctx context.Context) (<-chan provider.StateChange, error) {
+	q := url.Values{}
+	q.Set("topic", "Allocation")
+	p.setScope(q)
+
+	resp, err := p.doStream(ctx, http.MethodGet, "/v1/event/stream?"+q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("nomad: watch event stream: %w", err)
+	}
+
+	stream := provider.NewWatchStream(64)
+	since := time.Now()
+
+	go func() {
+		defer stream.Close()
+		defer resp.Body.Close()
+
+		var (
+			dec     = json.NewDecoder(resp.Body)
+			seen    = make(map[string]int64)
+			tenants = make(map[id.ID]string)
+		)
+
+		for {
+			var frame nomadEventFrame
+			if err := dec.Decode(&frame); err != nil {
+				return
+			}
+
+			for _, ev := range frame.Events {
+				alloc := ev.Payload.Allocation
+				if alloc == nil {
+					continue
+				}
+
+				instanceID, ok := jobInstance(alloc.JobID)
+				if !ok {
+					continue
+				}
+
+				tenantID, ok := tenants[instanceID]
+				if !ok {
+					tenantID = p.jobTenant(ctx, alloc.JobID)
+					tenants[instanceID] = tenantID
+				}
+
+				for _, c := range taskChanges(alloc, since, seen) {
+					c.InstanceID, c.TenantID = instanceID, tenantID
+					if !stream.Send(ctx, c) {
+						return
+					}
+				}
+
+				status, err := p.Status(ctx, instanceID)
+				if err != nil {
+					continue
+				}
+
+				if !stream.Send(ctx, provider.StateChange{
+					Kind:       provider.ChangeState,
+					InstanceID: instanceID,
+					TenantID:   tenantID,
+					Status:     status,
+				}) {
+					return
+				}
+
+				if status.State == provider.StateDestroyed {
+					delete(tenants, instanceID)
+				}
+			}
+		}
+	}()
+
+	return stream.C(), nil
+}
+
+// jobInstance returns the instance a ctrlplane job (jobName) runs.
+func jobInstance(jobID string) (id.ID, bool) {
+	raw, ok := strings.CutPrefix(jobID, "cp-")
+	if !ok {
+		return id.Nil, false
+	}
+
+	instanceID, err := id.Parse(raw)
+	if err != nil {
+		return id.Nil, false
+	}
+
+	return instanceID, true
+}
+
+// jobTenant reads the tenant the job was provisioned for from its
+// Meta. Empty when the job is gone or carries none.
+func (p *Provider) jobTenant(ctx context.Context, jobID string) string {
+	var job nomadJob
+	if err := p.doRequest(ctx, http.MethodGet, "/v1/job/"+url.PathEscape(jobID), nil, &job); err != nil {
+		return ""
+	}
+
+	return job.Meta["ctrlplane.tenant"]
+}
+
+// taskChanges returns a restart or OOM kill for every task event in
+// alloc newer than both since and the last event seen for that task,
+// and records what it saw. Pure function so it's testable without a
+// Nomad agent.
+func taskChanges(alloc *nomadAlloc, since time.Time, seen map[string]int64) []provider.StateChange {
+	var out []provider.StateChange
+
+	for task, ts := range alloc.TaskStates {
+		key := alloc.ID + "/" + task
+		last := max(seen[key], since.UnixNano())
+
+		for _, ev := range ts.Events {
+			if ev.Time <= last {
+				continue
+			}
+
+			seen[key] = max(seen[key], ev.Time)
+
+			c := provider.StateChange{
+				Service:  task,
+				Restarts: ts.Restarts,
+				Message:  ev.DisplayMsg,
+				At:       time.Unix(0, ev.Time).UTC(),
+			}
+
+			switch {
+			case ev.Type == taskEventTerminated && ev.Details["oom_killed"] == "true":
+				c.Kind = provider.ChangeOOMKilled
+			case ev.Type == taskEventRestarting:
+				c.Kind = provider.ChangeRestart
+			default:
+				continue
+			}
+
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
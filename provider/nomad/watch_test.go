@@ -0,0 +1,97 @@
+package nomad
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestTaskChanges_ReportsNewRestartsAndOOMKills verifies only task
+// events after the watch started are reported, each once.
+func TestTaskChanges_ReportsNewRestartsAndOOMKills(t *testing.T) {
+	t.Parallel()
+
+	since := time.Unix(100, 0)
+	alloc := &nomadAlloc{ID: "a1", TaskStates: map[string]nomadTaskStateAPI{
+		"web": {Restarts: 2, Events: []nomadTaskEventAPI{
+			{Type: taskEventRestarting, Time: since.Add(-time.Second).UnixNano()},
+			{Type: taskEventTerminated, Time: since.Add(time.Second).UnixNano(), Details: map[string]string{"oom_killed": "true"}},
+			{Type: taskEventRestarting, Time: since.Add(2 * time.Second).UnixNano()},
+			{Type: "Started", Time: since.Add(3 * time.Second).UnixNano()},
+		}},
+	}}
+
+	seen := make(map[string]int64)
+
+	got := taskChanges(alloc, since, seen)
+	if len(got) != 2 || got[0].Kind != provider.ChangeOOMKilled || got[1].Kind != provider.ChangeRestart {
+		t.Fatalf("changes = %+v, want OOM kill then restart", got)
+	}
+
+	if got[1].Service != "web" || got[1].Restarts != 2 {
+		t.Fatalf("restart = %+v", got[1])
+	}
+
+	if again := taskChanges(alloc, since, seen); len(again) != 0 {
+		t.Fatalf("replayed events reported again: %+v", again)
+	}
+}
+
+// TestWatch_ReportsAllocationState verifies an allocation event on the
+// stream is reported as the instance's state, with the tenant read
+// from the job's Meta.
+func TestWatch_ReportsAllocationState(t *testing.T) {
+	t.Parallel()
+
+	instID := id.New(id.PrefixInstance)
+	name := jobName(instID)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/event/stream":
+			if r.URL.Query().Get("topic") != "Allocation" {
+				http.Error(w, "wrong topic", http.StatusBadRequest)
+
+				return
+			}
+
+			frame := nomadEventFrame{Index: 1, Events: []nomadEvent{{Topic: "Allocation", Type: "AllocationUpdated"}}}
+			frame.Events[0].Payload.Allocation = &nomadAlloc{ID: "a1", JobID: name, ClientStatus: "failed"}
+
+			_ = json.NewEncoder(w).Encode(frame)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/v1/job/" + name:
+			_ = json.NewEncoder(w).Encode(nomadJob{ID: name, Meta: map[string]string{"ctrlplane.tenant": "tenant-1"}})
+		case "/v1/job/" + name + "/allocations":
+			_ = json.NewEncoder(w).Encode([]nomadAlloc{{ID: "a1", ClientStatus: "failed"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	p, err := New(WithAddress(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	changes, err := p.Watch(t.Context())
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	select {
+	case c := <-changes:
+		if c.Kind != provider.ChangeState || c.InstanceID != instID || c.TenantID != "tenant-1" || c.Status.State != provider.StateFailed {
+			t.Fatalf("change = %+v (status %+v)", c, c.Status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no state change within 5s")
+	}
+}
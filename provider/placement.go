@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"fmt"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// Placement constrains which nodes a workload's replicas run on. Node
+// keys are node labels on Kubernetes and client node meta on Nomad.
+//
+// NodeSelector, Required and a Required spread are hard: a provider
+// that can't honour them rejects the request rather than ignore them.
+// Preferred terms, soft spreads and Tolerations only steer or permit
+// scheduling, so providers without the concept may ignore them.
+//
+// Services in a workload are co-scheduled, so placement is per
+// workload: it's read from the Main service, and setting it on any
+// other service is a validation error.
+type Placement struct {
+	// NodeSelector requires nodes carrying every label with the given
+	// value.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+
+	// Required requirements must all hold on the chosen node.
+	Required []NodeRequirement `json:"required,omitempty"`
+
+	// Preferred requirements favour nodes matching them, by weight.
+	Preferred []WeightedRequirement `json:"preferred,omitempty"`
+
+	// Tolerations let replicas run on nodes tainted to repel
+	// everything else, such as dedicated GPU or tenant pools.
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+
+	// Spread distributes replicas across the values of node keys,
+	// such as zones.
+	Spread []SpreadConstraint `json:"spread,omitempty"`
+}
+
+// NodeOperator relates a node key to a requirement's values.
+type NodeOperator string
+
+const (
+	// NodeOpIn requires the key's value to be one of Values.
+	NodeOpIn NodeOperator = "In"
+
+	// NodeOpNotIn requires the key's value to be none of Values (or
+	// the key to be absent).
+	NodeOpNotIn NodeOperator = "NotIn"
+
+	// NodeOpExists requires the key to be set.
+	NodeOpExists NodeOperator = "Exists"
+
+	// NodeOpDoesNotExist requires the key to be absent.
+	NodeOpDoesNotExist NodeOperator = "DoesNotExist"
+)
+
+// NodeRequirement is one condition on a node key.
+type NodeRequirement struct {
+	Key      string       `json:"key"`
+	Operator NodeOperator `json:"operator"`
+	Values   []string     `json:"values,omitempty"`
+}
+
+// WeightedRequirement is a preferred node requirement. Weight, 1 to
+// 100, ranks it against the workload's other preferences.
+type WeightedRequirement struct {
+	NodeRequirement
+
+	Weight int `json:"weight"`
+}
+
+// TolerationOperator selects how a toleration matches a taint's value.
+type TolerationOperator string
+
+const (
+	// TolerationEqual matches taints with the same key and value.
+	TolerationEqual TolerationOperator = "Equal"
+
+	// TolerationExists matches every taint with the key, or every
+	// taint when the key is empty.
+	TolerationExists TolerationOperator = "Exists"
+)
+
+// Toleration permits scheduling onto nodes with a matching taint. An
+// empty Operator means Equal; an empty Effect matches every effect.
+type Toleration struct {
+	Key      string             `json:"key,omitempty"`
+	Operator TolerationOperator `json:"operator,omitempty"`
+	Value    string             `json:"value,omitempty"`
+	Effect   string             `json:"effect,omitempty"`
+}
+
+// SpreadConstraint distributes replicas evenly across the values of a
+// node key. MaxSkew is the largest allowed difference in replica count
+// between two values (default 1); Required refuses to schedule a
+// replica that would exceed it instead of only preferring not to.
+type SpreadConstraint struct {
+	Key      string `json:"key"`
+	MaxSkew  int    `json:"max_skew,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// Hard reports whether the placement has constraints a provider must
+// honour or reject.
+func (p *Placement) Hard() bool {
+	if p == nil {
+		return false
+	}
+
+	if len(p.NodeSelector) > 0 || len(p.Required) > 0 {
+		return true
+	}
+
+	for _, s := range p.Spread {
+		if s.Required {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks that every term is well formed.
+func (p *Placement) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	for k := range p.NodeSelector {
+		if k == "" {
+			return fmt.Errorf("%w: placement node_selector has an empty key", ctrlplane.ErrInvalidConfig)
+		}
+	}
+
+	for _, r := range p.Required {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range p.Preferred {
+		if err := r.validate(); err != nil {
+			return err
+		}
+
+		if r.Weight < 1 || r.Weight > 100 {
+			return fmt.Errorf("%w: placement preference on %q has weight %d, want 1-100", ctrlplane.ErrInvalidConfig, r.Key, r.Weight)
+		}
+	}
+
+	for _, t := range p.Tolerations {
+		switch t.Operator {
+		case "", TolerationEqual:
+			if t.Key == "" {
+				return fmt.Errorf("%w: placement toleration with operator Equal needs a key", ctrlplane.ErrInvalidConfig)
+			}
+		case TolerationExists:
+			if t.Value != "" {
+				return fmt.Errorf("%w: placement toleration with operator Exists takes no value", ctrlplane.ErrInvalidConfig)
+			}
+		default:
+			return fmt.Errorf("%w: placement toleration operator %q", ctrlplane.ErrInvalidConfig, t.Operator)
+		}
+	}
+
+	for _, s := range p.Spread {
+		if s.Key == "" {
+			return fmt.Errorf("%w: placement spread needs a key", ctrlplane.ErrInvalidConfig)
+		}
+
+		if s.MaxSkew < 0 {
+			return fmt.Errorf("%w: placement spread on %q has negative max_skew", ctrlplane.ErrInvalidConfig, s.Key)
+		}
+	}
+
+	return nil
+}
+
+func (r NodeRequirement) validate() error {
+	if r.Key == "" {
+		return fmt.Errorf("%w: placement requirement needs a key", ctrlplane.ErrInvalidConfig)
+	}
+
+	switch r.Operator {
+	case NodeOpIn, NodeOpNotIn:
+		if len(r.Values) == 0 {
+			return fmt.Errorf("%w: placement requirement %s on %q needs values", ctrlplane.ErrInvalidConfig, r.Operator, r.Key)
+		}
+	case NodeOpExists, NodeOpDoesNotExist:
+		if len(r.Values) > 0 {
+			return fmt.Errorf("%w: placement requirement %s on %q takes no values", ctrlplane.ErrInvalidConfig, r.Operator, r.Key)
+		}
+	default:
+		return fmt.Errorf("%w: placement operator %q on %q", ctrlplane.ErrInvalidConfig, r.Operator, r.Key)
+	}
+
+	return nil
+}
+
+// WorkloadPlacement returns the workload's placement — the Main
+// service's — after checking it's valid and no other service sets one.
+// Nil means no constraints.
+func WorkloadPlacement(services []ServiceSpec) (*Placement, error) {
+	var placement *Placement
+
+	for i := range services {
+		svc := &services[i]
+		if svc.Placement == nil {
+			continue
+		}
+
+		if svc.Role != RoleMain && svc.Role != "" {
+			return nil, fmt.Errorf("%w: service %q: placement applies to the whole workload and must be set on the Main service",
+				ctrlplane.ErrInvalidConfig, svc.Name)
+		}
+
+		placement = svc.Placement
+	}
+
+	if err := placement.Validate(); err != nil {
+		return nil, err
+	}
+
+	return placement, nil
+}
+
+// RejectHardPlacement fails with ErrInvalidConfig when the workload has
+// hard placement constraints, for providers with no notion of nodes
+// to place on. Soft placement is ignored.
+func RejectHardPlacement(services []ServiceSpec) error {
+	placement, err := WorkloadPlacement(services)
+	if err != nil {
+		return err
+	}
+
+	if placement.Hard() {
+		return fmt.Errorf("%w: placement constraints are not supported by this provider", ctrlplane.ErrInvalidConfig)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+func TestPlacement_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		p       *Placement
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"full", &Placement{
+			NodeSelector: map[string]string{"pool": "gpu"},
+			Required:     []NodeRequirement{{Key: "zone", Operator: NodeOpIn, Values: []string{"a", "b"}}},
+			Preferred:    []WeightedRequirement{{NodeRequirement: NodeRequirement{Key: "ssd", Operator: NodeOpExists}, Weight: 20}},
+			Tolerations:  []Toleration{{Key: "dedicated", Value: "tenant-a", Effect: "NoSchedule"}, {Operator: TolerationExists}},
+			Spread:       []SpreadConstraint{{Key: "zone", Required: true}},
+		}, false},
+		{"in without values", &Placement{Required: []NodeRequirement{{Key: "zone", Operator: NodeOpIn}}}, true},
+		{"exists with values", &Placement{Required: []NodeRequirement{{Key: "zone", Operator: NodeOpExists, Values: []string{"a"}}}}, true},
+		{"unknown operator", &Placement{Required: []NodeRequirement{{Key: "zone", Operator: "Gt", Values: []string{"1"}}}}, true},
+		{"weight out of range", &Placement{Preferred: []WeightedRequirement{{NodeRequirement: NodeRequirement{Key: "ssd", Operator: NodeOpExists}, Weight: 101}}}, true},
+		{"equal toleration without key", &Placement{Tolerations: []Toleration{{Value: "x"}}}, true},
+		{"spread without key", &Placement{Spread: []SpreadConstraint{{MaxSkew: 1}}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.p.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		if err != nil && !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", tt.name, err)
+		}
+	}
+}
+
+func TestPlacement_Hard(t *testing.T) {
+	t.Parallel()
+
+	soft := &Placement{
+		Preferred:   []WeightedRequirement{{NodeRequirement: NodeRequirement{Key: "ssd", Operator: NodeOpExists}, Weight: 1}},
+		Tolerations: []Toleration{{Operator: TolerationExists}},
+		Spread:      []SpreadConstraint{{Key: "zone"}},
+	}
+	if soft.Hard() {
+		t.Error("preferences, tolerations and soft spread reported hard")
+	}
+
+	for _, p := range []*Placement{
+		{NodeSelector: map[string]string{"pool": "gpu"}},
+		{Required: []NodeRequirement{{Key: "gpu", Operator: NodeOpExists}}},
+		{Spread: []SpreadConstraint{{Key: "zone", Required: true}}},
+	} {
+		if !p.Hard() {
+			t.Errorf("%+v not reported hard", p)
+		}
+	}
+}
+
+func TestWorkloadPlacement(t *testing.T) {
+	t.Parallel()
+
+	pl := &Placement{NodeSelector: map[string]string{"pool": "gpu"}}
+
+	got, err := WorkloadPlacement([]ServiceSpec{
+		{Name: "init", Role: RoleInit},
+		{Name: "web", Placement: pl},
+	})
+	if err != nil || got != pl {
+		t.Fatalf("WorkloadPlacement = %v, %v; want the Main service's placement", got, err)
+	}
+
+	_, err = WorkloadPlacement([]ServiceSpec{
+		{Name: "web", Role: RoleMain},
+		{Name: "proxy", Role: RoleSidecar, Placement: pl},
+	})
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Errorf("sidecar placement: err = %v, want ErrInvalidConfig", err)
+	}
+
+	err = RejectHardPlacement([]ServiceSpec{{Name: "web", Placement: pl}})
+	if !errors.Is(err, ctrlplane.ErrInvalidConfig) {
+		t.Errorf("RejectHardPlacement: err = %v, want ErrInvalidConfig", err)
+	}
+
+	soft := &Placement{Tolerations: []Toleration{{Operator: TolerationExists}}}
+	if err := RejectHardPlacement([]ServiceSpec{{Name: "web", Placement: soft}}); err != nil {
+		t.Errorf("RejectHardPlacement(soft) = %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package process
+
+import "time"
+
+// Config holds configuration for the process provider.
+type Config struct {
+	// WorkDir is the directory each instance's working directories
+	// are created under. Empty uses <os.TempDir()>/ctrlplane-process.
+	WorkDir string `env:"CP_PROCESS_WORK_DIR" json:"work_dir,omitempty"`
+
+	// StopTimeout is how long a process gets to exit after an
+	// interrupt before it is killed.
+	StopTimeout time.Duration `default:"10s" env:"CP_PROCESS_STOP_TIMEOUT" json:"stop_timeout"`
+
+	// MaxRestartBackoff caps the delay between restarts of a process
+	// that keeps exiting.
+	MaxRestartBackoff time.Duration `default:"30s" env:"CP_PROCESS_MAX_RESTART_BACKOFF" json:"max_restart_backoff"`
+
+	// LogLines is how many lines of output are kept per process for
+	// Logs.
+	LogLines int `default:"1000" env:"CP_PROCESS_LOG_LINES" json:"log_lines"`
+}
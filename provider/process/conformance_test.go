@@ -0,0 +1,25 @@
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/testutil"
+)
+
+// TestConformance certifies the process provider against the shared
+// provider contract, with the test binary as both services.
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	agent := helperService("agent", "serve", nil)
+	agent.Role = provider.RoleSidecar
+
+	testutil.RunProviderConformance(t, testutil.ProviderConformance{
+		Provider:     newTestProvider(t),
+		Services:     []provider.ServiceSpec{helperService("web", "serve", nil), agent},
+		Timeout:      10 * time.Second,
+		PollInterval: 50 * time.Millisecond,
+	})
+}
@@ -0,0 +1,27 @@
+// Package process is a provider.Provider that runs each ServiceSpec as
+// a supervised OS process on the local machine. It needs no Docker
+// daemon or cluster, so the whole control plane — deploy strategies,
+// health checks, workloads — runs on a laptop or in CI, and
+// end-to-end tests stay hermetic.
+//
+// Mapping from the container model:
+//
+//   - The program is Command[0] with Command[1:] and Args as its
+//     arguments. With no Command, Image names the program, so a
+//     Deploy that changes Image swaps the binary.
+//   - Env is layered over the control plane's own environment. Each
+//     PortSpec gets a host port on 127.0.0.1 (PortSpec.Host when set,
+//     otherwise a free one) exported as PORT_<container>, and the
+//     first as PORT. Siblings find each other through
+//     CTRLPLANE_<SERVICE>_ADDR, the local stand-in for a network
+//     alias.
+//   - Each replica runs in its own working directory under
+//     Config.WorkDir. Init services run to completion, in order,
+//     before anything else starts; Main and Sidecar processes are
+//     restarted with exponential backoff whenever they exit.
+//   - Logs are kept in a per-process ring buffer and streamed in the
+//     same JSON-lines shape as the docker and nomad providers.
+//
+// Resource limits (CPU, memory, GPU), volumes and config files have no
+// local equivalent and are ignored; Resources reports zero usage.
+package process
@@ -0,0 +1,116 @@
+package process
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// allocatePorts binds each PortSpec to a host port: PortSpec.Host when
+// set and fixed is true (replica 0), otherwise a free port picked by
+// the kernel. The probe listener is closed before the process starts,
+// so another program could grab the port in between — acceptable for
+// a development provider.
+func allocatePorts(specs []provider.PortSpec, fixed bool) ([]portBinding, error) {
+	ports := make([]portBinding, 0, len(specs))
+
+	for _, ps := range specs {
+		host := ps.Host
+		if host == 0 || !fixed {
+			free, err := freePort()
+			if err != nil {
+				return nil, err
+			}
+
+			host = free
+		}
+
+		ports = append(ports, portBinding{Container: ps.Container, Host: host, Protocol: ps.Protocol})
+	}
+
+	return ports, nil
+}
+
+// freePort asks the kernel for an unused loopback TCP port.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("find free port: %w", err)
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// envName upper-snakes a service name for use in a variable name.
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// siblingEnv returns CTRLPLANE_<SERVICE>_ADDR for every service whose
+// first replica listens on a port — what a network alias gives a
+// container on the other providers.
+func siblingEnv(procs map[string][]*process) []string {
+	var env []string
+
+	for _, name := range slices.Sorted(maps.Keys(procs)) {
+		reps := procs[name]
+		if len(reps) == 0 || len(reps[0].ports) == 0 {
+			continue
+		}
+
+		env = append(env, "CTRLPLANE_"+envName(name)+"_ADDR=127.0.0.1:"+strconv.Itoa(reps[0].ports[0].Host))
+	}
+
+	return env
+}
+
+// processEnv returns the provider-computed environment for one
+// replica: the control plane's own environment, sibling addresses,
+// identity and the replica's ports.
+func processEnv(instanceID id.ID, pr *process, siblings []string) []string {
+	env := append(os.Environ(), siblings...)
+	env = append(env,
+		"CTRLPLANE_INSTANCE_ID="+instanceID.String(),
+		"CTRLPLANE_SERVICE="+pr.service,
+		"CTRLPLANE_REPLICA="+strconv.Itoa(pr.replica),
+	)
+
+	for i, b := range pr.ports {
+		if i == 0 {
+			env = append(env, "PORT="+strconv.Itoa(b.Host))
+		}
+
+		env = append(env, "PORT_"+strconv.Itoa(b.Container)+"="+strconv.Itoa(b.Host))
+	}
+
+	return env
+}
+
+// mergeEnv layers a service's Env over base. exec.Cmd uses the last
+// value of a duplicated key, so appending is enough.
+func mergeEnv(base []string, overlay map[string]string) []string {
+	env := slices.Clone(base)
+
+	for _, k := range slices.Sorted(maps.Keys(overlay)) {
+		env = append(env, k+"="+overlay[k])
+	}
+
+	return env
+}
@@ -0,0 +1,53 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Exec runs a command alongside a service's first replica: in its
+// working directory, with its environment. A non-zero exit is
+// reported in ExitCode, not as an error. There's no terminal to
+// attach to, so TTY and Resize are ignored.
+func (p *Provider) Exec(ctx context.Context, instanceID id.ID, cmd provider.ExecRequest) (*provider.ExecResult, error) {
+	if len(cmd.Command) == 0 {
+		return nil, errors.New("process: exec requires a command")
+	}
+
+	pr, err := p.resolveProcess(instanceID, cmd.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("process: exec: %w", err)
+	}
+
+	if pr.status().State != provider.StateRunning {
+		return nil, fmt.Errorf("process: exec: service %q: %w", pr.service, errNotRunning)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	c := exec.CommandContext(ctx, cmd.Command[0], cmd.Command[1:]...) //nolint:gosec // running operator-supplied commands is the point of Exec
+	c.Dir = pr.dir
+	c.Env = mergeEnv(pr.env, pr.currentSpec().Env)
+	c.Stdin = cmd.Stdin
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("process: exec: %w", err)
+		}
+	}
+
+	return &provider.ExecResult{
+		ExitCode: c.ProcessState.ExitCode(),
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+	}, nil
+}
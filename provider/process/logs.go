@@ -0,0 +1,177 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// followBuffer is how many events a follower may fall behind before
+// further events are dropped for it. Dropping beats blocking: a slow
+// log reader must never stall the process writing its output.
+const followBuffer = 256
+
+// logBuffer keeps the last max lines a process wrote, across restarts,
+// and fans new lines out to followers.
+type logBuffer struct {
+	mu        sync.Mutex
+	max       int
+	lines     []provider.LogEvent
+	times     []time.Time
+	followers map[chan provider.LogEvent]struct{}
+}
+
+func newLogBuffer(maxLines int) *logBuffer {
+	return &logBuffer{max: maxLines, followers: make(map[chan provider.LogEvent]struct{})}
+}
+
+// add records one line.
+func (b *logBuffer) add(stream, line string) {
+	now := time.Now().UTC()
+	ev := provider.LogEvent{Timestamp: now.Format(time.RFC3339Nano), Stream: stream, Line: line}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines = append(b.lines, ev)
+	b.times = append(b.times, now)
+
+	if over := len(b.lines) - b.max; over > 0 {
+		b.lines = append(b.lines[:0:0], b.lines[over:]...)
+		b.times = append(b.times[:0:0], b.times[over:]...)
+	}
+
+	for ch := range b.followers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// snapshot returns the buffered lines matching opts' Since and Tail,
+// and — when follow is set — a channel receiving every later line,
+// registered under the same lock so no line falls between the two.
+func (b *logBuffer) snapshot(opts provider.LogOptions, follow bool) ([]provider.LogEvent, chan provider.LogEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []provider.LogEvent
+
+	for i, ev := range b.lines {
+		if !opts.Since.IsZero() && b.times[i].Before(opts.Since) {
+			continue
+		}
+
+		out = append(out, ev)
+	}
+
+	if opts.Tail > 0 && len(out) > opts.Tail {
+		out = out[len(out)-opts.Tail:]
+	}
+
+	if !follow {
+		return out, nil
+	}
+
+	ch := make(chan provider.LogEvent, followBuffer)
+	b.followers[ch] = struct{}{}
+
+	return out, ch
+}
+
+// unfollow stops delivering lines to ch.
+func (b *logBuffer) unfollow(ch chan provider.LogEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.followers, ch)
+}
+
+// writer returns an io.Writer feeding one stream of a process's output
+// into the buffer line by line.
+func (b *logBuffer) writer(stream string) io.Writer {
+	return &lineWriter{buf: b, stream: stream}
+}
+
+// lineWriter splits writes into lines. A trailing partial line is held
+// until its newline arrives; exec.Cmd copies output through a single
+// goroutine per stream, so no locking is needed here.
+type lineWriter struct {
+	buf     *logBuffer
+	stream  string
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.buf.add(w.stream, string(bytes.TrimSuffix(data[:i], []byte("\r"))))
+		data = data[i+1:]
+	}
+
+	w.partial = append(w.partial[:0], data...)
+
+	return len(p), nil
+}
+
+// Logs streams a service's output as JSON lines. Without Follow the
+// reader holds the buffered lines and then ends; with Follow it keeps
+// delivering new lines until ctx ends or the reader is closed. For a
+// scaled service the first replica's output is streamed.
+func (p *Provider) Logs(ctx context.Context, instanceID id.ID, opts provider.LogOptions) (io.ReadCloser, error) {
+	proc, err := p.resolveProcess(instanceID, opts.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("process: logs: %w", err)
+	}
+
+	backlog, ch := proc.logs.snapshot(opts, opts.Follow)
+
+	if !opts.Follow {
+		var buf bytes.Buffer
+		for _, ev := range backlog {
+			_ = provider.WriteLogEvent(&buf, ev)
+		}
+
+		return io.NopCloser(&buf), nil
+	}
+
+	r, w := io.Pipe()
+
+	go func() {
+		defer proc.logs.unfollow(ch)
+
+		for _, ev := range backlog {
+			if err := provider.WriteLogEvent(w, ev); err != nil {
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				_ = w.CloseWithError(ctx.Err())
+
+				return
+			case ev := <-ch:
+				if err := provider.WriteLogEvent(w, ev); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return r, nil
+}
@@ -0,0 +1,74 @@
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// TestLogBuffer_KeepsLastLinesAndSplitsWrites verifies writes are
+// split into lines, a partial line waits for its newline, the buffer
+// keeps only the newest lines, and Tail trims the snapshot.
+func TestLogBuffer_KeepsLastLinesAndSplitsWrites(t *testing.T) {
+	t.Parallel()
+
+	b := newLogBuffer(3)
+	w := b.writer("stdout")
+
+	_, _ = w.Write([]byte("one\ntwo\nthr"))
+	_, _ = w.Write([]byte("ee\r\nfour\n"))
+
+	lines, _ := b.snapshot(provider.LogOptions{}, false)
+
+	var got []string
+	for _, ev := range lines {
+		got = append(got, ev.Line)
+	}
+
+	if len(got) != 3 || got[0] != "two" || got[1] != "three" || got[2] != "four" {
+		t.Fatalf("lines = %q, want [two three four]", got)
+	}
+
+	tail, _ := b.snapshot(provider.LogOptions{Tail: 1}, false)
+	if len(tail) != 1 || tail[0].Line != "four" {
+		t.Fatalf("tail = %+v, want [four]", tail)
+	}
+
+	since, _ := b.snapshot(provider.LogOptions{Since: time.Now().Add(time.Hour)}, false)
+	if len(since) != 0 {
+		t.Fatalf("since in the future returned %d lines", len(since))
+	}
+}
+
+// TestLogBuffer_FollowerSeesNewLines verifies a follower receives
+// lines written after it subscribed and nothing after unfollow.
+func TestLogBuffer_FollowerSeesNewLines(t *testing.T) {
+	t.Parallel()
+
+	b := newLogBuffer(10)
+	b.add("stdout", "before")
+
+	backlog, ch := b.snapshot(provider.LogOptions{}, true)
+	if len(backlog) != 1 {
+		t.Fatalf("backlog = %d lines, want 1", len(backlog))
+	}
+
+	b.add("stderr", "after")
+
+	select {
+	case ev := <-ch:
+		if ev.Line != "after" || ev.Stream != "stderr" {
+			t.Fatalf("event = %+v, want stderr after", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("follower got no event")
+	}
+
+	b.unfollow(ch)
+	b.add("stdout", "late")
+
+	if len(ch) != 0 {
+		t.Fatal("event delivered after unfollow")
+	}
+}
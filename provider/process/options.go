@@ -0,0 +1,90 @@
+package process
+
+import (
+	"fmt"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+)
+
+// Option configures a process provider.
+type Option func(*Provider) error
+
+// WithWorkDir sets the directory instance working directories are
+// created under.
+func WithWorkDir(dir string) Option {
+	return func(p *Provider) error {
+		if dir == "" {
+			return fmt.Errorf("process: %w: work dir must not be empty", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.WorkDir = dir
+
+		return nil
+	}
+}
+
+// WithStopTimeout sets how long a process gets to exit after an
+// interrupt before it is killed.
+func WithStopTimeout(d time.Duration) Option {
+	return func(p *Provider) error {
+		if d <= 0 {
+			return fmt.Errorf("process: %w: stop timeout must be positive", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.StopTimeout = d
+
+		return nil
+	}
+}
+
+// WithMaxRestartBackoff caps the delay between restarts of a process
+// that keeps exiting.
+func WithMaxRestartBackoff(d time.Duration) Option {
+	return func(p *Provider) error {
+		if d <= 0 {
+			return fmt.Errorf("process: %w: max restart backoff must be positive", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.MaxRestartBackoff = d
+
+		return nil
+	}
+}
+
+// WithLogLines sets how many lines of output are kept per process.
+func WithLogLines(n int) Option {
+	return func(p *Provider) error {
+		if n <= 0 {
+			return fmt.Errorf("process: %w: log lines must be positive", ctrlplane.ErrInvalidConfig)
+		}
+
+		p.cfg.LogLines = n
+
+		return nil
+	}
+}
+
+// WithConfig applies all non-zero fields from a Config struct.
+// This is useful when loading configuration from files or environment variables.
+func WithConfig(cfg Config) Option {
+	return func(p *Provider) error {
+		if cfg.WorkDir != "" {
+			p.cfg.WorkDir = cfg.WorkDir
+		}
+
+		if cfg.StopTimeout > 0 {
+			p.cfg.StopTimeout = cfg.StopTimeout
+		}
+
+		if cfg.MaxRestartBackoff > 0 {
+			p.cfg.MaxRestartBackoff = cfg.MaxRestartBackoff
+		}
+
+		if cfg.LogLines > 0 {
+			p.cfg.LogLines = cfg.LogLines
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,684 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Compile-time interface checks.
+var (
+	_ provider.Provider      = (*Provider)(nil)
+	_ provider.HealthChecker = (*Provider)(nil)
+	_ provider.Rollbacker    = (*Provider)(nil)
+)
+
+// Provider runs instances as supervised local processes. All state
+// lives in memory: a restarted control plane has no record of the
+// processes a previous one started, which is the point of Close.
+type Provider struct {
+	cfg Config
+
+	mu        sync.Mutex
+	instances map[id.ID]*instance
+}
+
+// instance is one provisioned instance's processes.
+type instance struct {
+	// mu serialises lifecycle operations on the instance and guards
+	// services.
+	mu sync.Mutex
+
+	id       id.ID
+	dir      string
+	release  id.ID
+	order    []string
+	services map[string][]*process
+
+	// siblings is the CTRLPLANE_<SERVICE>_ADDR environment shared by
+	// every replica, so replicas added by Scale see the same view.
+	siblings []string
+}
+
+// New creates a process provider.
+func New(opts ...Option) (*Provider, error) {
+	p := &Provider{
+		cfg: Config{
+			StopTimeout:       10 * time.Second,
+			MaxRestartBackoff: 30 * time.Second,
+			LogLines:          1000,
+		},
+		instances: make(map[id.ID]*instance),
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Info returns metadata about this provider.
+func (p *Provider) Info() provider.ProviderInfo {
+	return provider.ProviderInfo{
+		Name:    "process",
+		Version: "1.0.0",
+		Region:  "local",
+		Location: &provider.Location{
+			Country: "Local",
+			City:    "Localhost",
+		},
+	}
+}
+
+// Capabilities returns the set of features this provider supports.
+func (p *Provider) Capabilities() []provider.Capability {
+	return []provider.Capability{
+		provider.CapProvision,
+		provider.CapDeploy,
+		provider.CapScale,
+		provider.CapLogs,
+		provider.CapExec,
+	}
+}
+
+// HealthCheck reports whether the work directory is usable — the only
+// thing the provider depends on.
+func (p *Provider) HealthCheck(_ context.Context) (*provider.HealthStatus, error) {
+	start := time.Now()
+	err := os.MkdirAll(p.workRoot(), 0o755)
+	latency := time.Since(start)
+
+	now := time.Now().UTC()
+	if err != nil {
+		return &provider.HealthStatus{
+			Healthy:   false,
+			Message:   fmt.Sprintf("work dir unusable: %v", err),
+			Latency:   latency,
+			CheckedAt: now,
+		}, nil
+	}
+
+	return &provider.HealthStatus{
+		Healthy:   true,
+		Message:   "work dir writable",
+		Latency:   latency,
+		CheckedAt: now,
+	}, nil
+}
+
+// Provision allocates ports, creates working directories, runs the
+// Init services in order and starts the remaining services. The Main
+// service's Resources.Replicas sets how many copies of every
+// long-lived service run; Inits run once.
+//
+// Re-provisioning the same instance ID stops and replaces whatever
+// the previous provision started. An Init failure fails the provision
+// but leaves the instance registered, so its Status and Logs explain
+// what went wrong.
+func (p *Provider) Provision(ctx context.Context, req provider.ProvisionRequest) (*provider.ProvisionResult, error) {
+	if len(req.Services) == 0 {
+		return nil, errors.New("process: provision requires at least one service")
+	}
+
+	main := pickMainService(req.Services)
+	if main == nil {
+		return nil, errors.New("process: provision requires exactly one Main service")
+	}
+
+	if err := provider.RejectHardPlacement(req.Services); err != nil {
+		return nil, fmt.Errorf("process: %w", err)
+	}
+
+	if err := p.Deprovision(ctx, req.InstanceID); err != nil {
+		return nil, err
+	}
+
+	inst := &instance{
+		id:       req.InstanceID,
+		dir:      p.instanceDir(req.InstanceID),
+		services: make(map[string][]*process, len(req.Services)),
+	}
+
+	replicas := max(main.Resources.Replicas, 1)
+
+	for _, svc := range req.Services {
+		n := replicas
+		if svc.Role == provider.RoleInit {
+			n = 1
+		}
+
+		inst.order = append(inst.order, svc.Name)
+
+		for i := range n {
+			pr, err := p.newProcess(inst, svc, i)
+			if err != nil {
+				return nil, fmt.Errorf("process: provision %s: %w", svc.Name, err)
+			}
+
+			inst.services[svc.Name] = append(inst.services[svc.Name], pr)
+		}
+	}
+
+	inst.siblings = siblingEnv(inst.services)
+
+	for _, reps := range inst.services {
+		for _, pr := range reps {
+			if err := p.prepare(inst, pr); err != nil {
+				return nil, fmt.Errorf("process: provision: %w", err)
+			}
+		}
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	p.mu.Lock()
+	p.instances[req.InstanceID] = inst
+	p.mu.Unlock()
+
+	for _, name := range inst.order {
+		for _, pr := range inst.services[name] {
+			if pr.role != provider.RoleInit {
+				continue
+			}
+
+			if err := pr.runOnce(ctx, p.cfg.StopTimeout); err != nil {
+				return nil, fmt.Errorf("process: provision: %w", err)
+			}
+		}
+	}
+
+	inst.startAll(p.cfg)
+
+	refs := make(map[string]string, len(inst.order))
+	for _, name := range inst.order {
+		refs[name] = instanceRef(req.InstanceID) + "/" + name
+	}
+
+	return &provider.ProvisionResult{
+		ProviderRef: instanceRef(req.InstanceID),
+		ServiceRefs: refs,
+		Endpoints:   inst.endpoints(),
+	}, nil
+}
+
+// pickMainService returns the spec for the Main service (the first
+// service with an empty or "main" role).
+func pickMainService(services []provider.ServiceSpec) *provider.ServiceSpec {
+	for i := range services {
+		if services[i].Role == provider.RoleMain || services[i].Role == "" {
+			return &services[i]
+		}
+	}
+
+	return nil
+}
+
+// Deprovision stops every process of the instance and removes its
+// working directories. An unknown instance is treated as success so
+// deprovision is convergent.
+func (p *Provider) Deprovision(_ context.Context, instanceID id.ID) error {
+	p.mu.Lock()
+	inst := p.instances[instanceID]
+	delete(p.instances, instanceID)
+	p.mu.Unlock()
+
+	if inst != nil {
+		inst.mu.Lock()
+		inst.stopAll()
+		inst.mu.Unlock()
+	}
+
+	dir := p.instanceDir(instanceID)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("process: remove work dir %s: %w", dir, err)
+	}
+
+	return nil
+}
+
+// Start starts every long-lived process of the instance that isn't
+// already running. Inits are not re-run.
+func (p *Provider) Start(_ context.Context, instanceID id.ID) error {
+	inst, err := p.lookup(instanceID)
+	if err != nil {
+		return fmt.Errorf("process: start: %w", err)
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	inst.startAll(p.cfg)
+
+	return nil
+}
+
+// Stop stops every process of the instance, waiting for each to exit.
+func (p *Provider) Stop(_ context.Context, instanceID id.ID) error {
+	inst, err := p.lookup(instanceID)
+	if err != nil {
+		return fmt.Errorf("process: stop: %w", err)
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	inst.stopAll()
+
+	return nil
+}
+
+// Restart stops and starts every long-lived process of the instance.
+func (p *Provider) Restart(_ context.Context, instanceID id.ID) error {
+	inst, err := p.lookup(instanceID)
+	if err != nil {
+		return fmt.Errorf("process: restart: %w", err)
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	inst.stopAll()
+	inst.startAll(p.cfg)
+
+	return nil
+}
+
+// Status aggregates the state of every process worst-of, the way the
+// docker provider aggregates containers. An Init that completed
+// doesn't count against readiness. An unknown instance reports
+// StateDestroyed.
+func (p *Provider) Status(_ context.Context, instanceID id.ID) (*provider.InstanceStatus, error) {
+	inst, err := p.lookup(instanceID)
+	if err != nil {
+		return &provider.InstanceStatus{State: provider.StateDestroyed}, nil //nolint:nilerr // gone is a state, not an error
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	var (
+		state    = provider.StateRunning
+		ready    = true
+		restarts int
+		services = make(map[string]provider.ServiceStatus, len(inst.services))
+		mainName string
+	)
+
+	for _, name := range inst.order {
+		for _, pr := range inst.services[name] {
+			st := pr.status()
+			services[name] = addReplicaStatus(services[name], st)
+			restarts += st.Restarts
+
+			if mainName == "" && (pr.role == provider.RoleMain || pr.role == "") {
+				mainName = name
+			}
+
+			if pr.completed() {
+				continue
+			}
+
+			if !st.Ready {
+				ready = false
+			}
+
+			if stateSeverity(st.State) > stateSeverity(state) {
+				state = st.State
+			}
+		}
+	}
+
+	return &provider.InstanceStatus{
+		State:         state,
+		Ready:         ready,
+		Restarts:      restarts,
+		Endpoints:     inst.endpoints(),
+		Services:      services,
+		Replicas:      services[mainName].Replicas,
+		ReadyReplicas: services[mainName].ReadyReplicas,
+	}, nil
+}
+
+// Deploy restarts each targeted service with its new image and env,
+// one replica at a time. Services not listed keep running. Image
+// replaces the program only when the service has no Command; an
+// empty Env keeps the current env. A targeted Init is re-run.
+func (p *Provider) Deploy(ctx context.Context, req provider.DeployRequest) (*provider.DeployResult, error) {
+	if len(req.Services) == 0 {
+		return nil, errors.New("process: deploy requires at least one service")
+	}
+
+	updates := make([]serviceUpdate, len(req.Services))
+	for i, u := range req.Services {
+		updates[i] = serviceUpdate{name: u.Name, image: u.Image, env: u.Env, replaceEnv: len(u.Env) > 0}
+	}
+
+	if err := p.apply(ctx, req.InstanceID, req.ReleaseID, updates); err != nil {
+		return nil, fmt.Errorf("process: deploy: %w", err)
+	}
+
+	return &provider.DeployResult{ProviderRef: instanceRef(req.InstanceID), Status: "deployed"}, nil
+}
+
+// Rollback needs the Release snapshot: there's no local revision
+// history to revert to.
+func (p *Provider) Rollback(_ context.Context, _ id.ID, releaseID id.ID) error {
+	return fmt.Errorf("process: rollback to release %s: snapshot required, use RollbackRelease", releaseID)
+}
+
+// RollbackRelease restarts each snapshotted service with the
+// Release's image and env. Unlike Deploy, env is replaced outright: a
+// variable added after the Release must not survive a rollback to it.
+func (p *Provider) RollbackRelease(ctx context.Context, req provider.RollbackRequest) (*provider.DeployResult, error) {
+	if len(req.Services) == 0 {
+		return nil, fmt.Errorf("process: rollback to release %s: snapshot required", req.ReleaseID)
+	}
+
+	updates := make([]serviceUpdate, len(req.Services))
+	for i, s := range req.Services {
+		updates[i] = serviceUpdate{name: s.Name, image: s.Image, env: s.Env, replaceEnv: true}
+	}
+
+	if err := p.apply(ctx, req.InstanceID, req.ReleaseID, updates); err != nil {
+		return nil, fmt.Errorf("process: rollback: %w", err)
+	}
+
+	return &provider.DeployResult{ProviderRef: instanceRef(req.InstanceID), Status: "rolled_back"}, nil
+}
+
+// serviceUpdate is the part of a deploy or snapshot the provider acts
+// on.
+type serviceUpdate struct {
+	name       string
+	image      string
+	env        map[string]string
+	replaceEnv bool
+}
+
+// apply restarts each updated service onto its new spec and records
+// the release.
+func (p *Provider) apply(ctx context.Context, instanceID, releaseID id.ID, updates []serviceUpdate) error {
+	inst, err := p.lookup(instanceID)
+	if err != nil {
+		return err
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	for _, u := range updates {
+		if _, ok := inst.services[u.name]; !ok {
+			return fmt.Errorf("service %q not found in instance %s", u.name, instanceID)
+		}
+	}
+
+	for _, u := range updates {
+		for _, pr := range inst.services[u.name] {
+			spec := pr.currentSpec()
+			if u.image != "" {
+				spec.Image = u.image
+			}
+
+			if u.replaceEnv {
+				spec.Env = u.env
+			}
+
+			if pr.role == provider.RoleInit {
+				pr.setSpec(spec)
+
+				if err := pr.runOnce(ctx, p.cfg.StopTimeout); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			pr.stop()
+			pr.setSpec(spec)
+			pr.start(p.cfg.StopTimeout, p.cfg.MaxRestartBackoff)
+		}
+	}
+
+	inst.release = releaseID
+
+	return nil
+}
+
+// Resources reports zero usage: the provider doesn't meter processes.
+func (p *Provider) Resources(_ context.Context, _ id.ID) (*provider.ResourceUsage, error) {
+	return &provider.ResourceUsage{}, nil
+}
+
+// Close stops every process the provider started. Working directories
+// are kept. Call it on shutdown so no process outlives the control
+// plane.
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	instances := make([]*instance, 0, len(p.instances))
+	for _, inst := range p.instances {
+		instances = append(instances, inst)
+	}
+	p.mu.Unlock()
+
+	for _, inst := range instances {
+		inst.mu.Lock()
+		inst.stopAll()
+		inst.mu.Unlock()
+	}
+
+	return nil
+}
+
+// newProcess builds the supervisor for one replica of svc. Only
+// replica 0 binds PortSpec.Host; further replicas get free ports so
+// they don't collide.
+func (p *Provider) newProcess(inst *instance, svc provider.ServiceSpec, replica int) (*process, error) {
+	ports, err := allocatePorts(svc.Ports, replica == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	role := svc.Role
+	if role == "" {
+		role = provider.RoleMain
+	}
+
+	dirName := svc.Name
+	if replica > 0 {
+		dirName += "-" + strconv.Itoa(replica)
+	}
+
+	return &process{
+		service: svc.Name,
+		replica: replica,
+		role:    role,
+		dir:     filepath.Join(inst.dir, dirName),
+		ports:   ports,
+		logs:    newLogBuffer(p.cfg.LogLines),
+		spec:    svc,
+		state:   provider.StateProvisioning,
+	}, nil
+}
+
+// prepare computes a replica's environment and creates its working
+// directory.
+func (p *Provider) prepare(inst *instance, pr *process) error {
+	pr.env = processEnv(inst.id, pr, inst.siblings)
+
+	if err := os.MkdirAll(pr.dir, 0o755); err != nil {
+		return fmt.Errorf("create work dir %s: %w", pr.dir, err)
+	}
+
+	return nil
+}
+
+// lookup returns a provisioned instance.
+func (p *Provider) lookup(instanceID id.ID) (*instance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inst, ok := p.instances[instanceID]
+	if !ok {
+		return nil, fmt.Errorf("instance %s: %w", instanceID, ctrlplane.ErrNotFound)
+	}
+
+	return inst, nil
+}
+
+// resolveProcess returns replica 0 of serviceName, or of the Main
+// service when serviceName is empty.
+func (p *Provider) resolveProcess(instanceID id.ID, serviceName string) (*process, error) {
+	inst, err := p.lookup(instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	if serviceName == "" {
+		for _, name := range inst.order {
+			if reps := inst.services[name]; len(reps) > 0 && reps[0].role == provider.RoleMain {
+				return reps[0], nil
+			}
+		}
+	}
+
+	reps := inst.services[serviceName]
+	if len(reps) == 0 {
+		return nil, fmt.Errorf("service %q in instance %s: %w", serviceName, instanceID, ctrlplane.ErrNotFound)
+	}
+
+	return reps[0], nil
+}
+
+// workRoot returns the directory instances are created under.
+func (p *Provider) workRoot() string {
+	if p.cfg.WorkDir != "" {
+		return p.cfg.WorkDir
+	}
+
+	return filepath.Join(os.TempDir(), "ctrlplane-process")
+}
+
+// instanceDir returns an instance's working directory.
+func (p *Provider) instanceDir(instanceID id.ID) string {
+	return filepath.Join(p.workRoot(), instanceRef(instanceID))
+}
+
+// instanceRef is the instance's ProviderRef, also the name of its
+// working directory.
+func instanceRef(instanceID id.ID) string {
+	return "cp-" + instanceID.String()
+}
+
+// startAll starts every long-lived process. Callers hold inst.mu.
+func (inst *instance) startAll(cfg Config) {
+	for _, name := range inst.order {
+		for _, pr := range inst.services[name] {
+			if pr.role != provider.RoleInit {
+				pr.start(cfg.StopTimeout, cfg.MaxRestartBackoff)
+			}
+		}
+	}
+}
+
+// stopAll stops every process, waiting for each to exit. Callers hold
+// inst.mu.
+func (inst *instance) stopAll() {
+	var wg sync.WaitGroup
+
+	for _, reps := range inst.services {
+		for _, pr := range reps {
+			wg.Go(pr.stop)
+		}
+	}
+
+	wg.Wait()
+}
+
+// endpoints lists a loopback endpoint per port of every long-lived
+// replica. Callers hold inst.mu.
+func (inst *instance) endpoints() []provider.Endpoint {
+	var eps []provider.Endpoint
+
+	for _, name := range inst.order {
+		for _, pr := range inst.services[name] {
+			if pr.role == provider.RoleInit {
+				continue
+			}
+
+			for _, b := range pr.ports {
+				eps = append(eps, provider.Endpoint{
+					ServiceName: name,
+					URL:         "http://127.0.0.1:" + strconv.Itoa(b.Host),
+					Port:        b.Host,
+					Protocol:    b.Protocol,
+					Public:      true,
+				})
+			}
+		}
+	}
+
+	return eps
+}
+
+// addReplicaStatus folds one replica's status into a service's
+// aggregate: counts add up, the worst state and first message win.
+func addReplicaStatus(agg provider.ServiceStatus, replica provider.ServiceStatus) provider.ServiceStatus {
+	if agg.Replicas == 0 {
+		replica.Replicas = 1
+		if replica.Ready {
+			replica.ReadyReplicas = 1
+		}
+
+		return replica
+	}
+
+	agg.Replicas++
+	if replica.Ready {
+		agg.ReadyReplicas++
+	}
+
+	agg.Ready = agg.ReadyReplicas == agg.Replicas
+	agg.Restarts += replica.Restarts
+
+	if stateSeverity(replica.State) > stateSeverity(agg.State) {
+		agg.State = replica.State
+	}
+
+	if agg.Message == "" {
+		agg.Message = replica.Message
+	}
+
+	return agg
+}
+
+// stateSeverity orders states for worst-of aggregation.
+func stateSeverity(s provider.InstanceState) int {
+	switch s {
+	case provider.StateFailed:
+		return 4
+	case provider.StateStarting, provider.StateProvisioning:
+		return 3
+	case provider.StateStopped:
+		return 2
+	case provider.StateRunning:
+		return 1
+	default:
+		return 0
+	}
+}
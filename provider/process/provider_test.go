@@ -0,0 +1,391 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// helperEnv selects what the test binary does when a test runs it as
+// a supervised process.
+const helperEnv = "CP_PROCESS_TEST_HELPER"
+
+// TestMain doubles the test binary as the programs the tests
+// supervise, so they need nothing installed on the machine.
+func TestMain(m *testing.M) {
+	switch os.Getenv(helperEnv) {
+	case "":
+		os.Exit(m.Run())
+	case "serve":
+		serveGreeting()
+	case "crash":
+		fmt.Println("crashing")
+		os.Exit(3)
+	case "fail":
+		fmt.Fprintln(os.Stderr, "init failed")
+		os.Exit(1)
+	}
+}
+
+// serveGreeting answers every request on $PORT with $GREETING until
+// interrupted.
+func serveGreeting() {
+	fmt.Println("greeting=" + os.Getenv("GREETING"))
+
+	srv := &http.Server{
+		Addr:              "127.0.0.1:" + os.Getenv("PORT"),
+		ReadHeaderTimeout: time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, os.Getenv("GREETING"))
+		}),
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+
+	go func() {
+		<-stop
+		_ = srv.Close()
+	}()
+
+	_ = srv.ListenAndServe()
+
+	os.Exit(0)
+}
+
+func helperService(name, mode string, env map[string]string) provider.ServiceSpec {
+	merged := map[string]string{helperEnv: mode}
+	for k, v := range env {
+		merged[k] = v
+	}
+
+	return provider.ServiceSpec{
+		Name:    name,
+		Image:   "helper",
+		Command: []string{os.Args[0]},
+		Env:     merged,
+		Ports:   []provider.PortSpec{{Container: 8080, Protocol: "tcp"}},
+	}
+}
+
+func newTestProvider(t *testing.T) *Provider {
+	t.Helper()
+
+	p, err := New(WithWorkDir(t.TempDir()), WithStopTimeout(2*time.Second))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	t.Cleanup(func() { _ = p.Close() })
+
+	return p
+}
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// get returns the body served at url, or "" while it isn't answering.
+func get(url string) string {
+	resp, err := http.Get(url) //nolint:gosec,noctx // loopback test server
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	return string(body)
+}
+
+// TestProvision_ServesOnAllocatedPort verifies a provisioned service
+// runs, listens on the port it was given, reports running and ready,
+// keeps its output for Logs, and is gone after Deprovision.
+func TestProvision_ServesOnAllocatedPort(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := newTestProvider(t)
+	instID := id.New(id.PrefixInstance)
+
+	res, err := p.Provision(ctx, provider.ProvisionRequest{
+		InstanceID: instID,
+		Services:   []provider.ServiceSpec{helperService("app", "serve", map[string]string{"GREETING": "hello"})},
+	})
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	if len(res.Endpoints) != 1 || res.Endpoints[0].ServiceName != "app" {
+		t.Fatalf("endpoints = %+v, want one for app", res.Endpoints)
+	}
+
+	url := res.Endpoints[0].URL
+	waitFor(t, "greeting", func() bool { return get(url) == "hello" })
+
+	st, err := p.Status(ctx, instID)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+
+	if st.State != provider.StateRunning || !st.Ready || st.Replicas != 1 || st.ReadyReplicas != 1 {
+		t.Fatalf("status = %+v, want running and ready with 1/1 replicas", st)
+	}
+
+	if !strings.HasPrefix(st.Services["app"].ProviderRef, "pid:") {
+		t.Fatalf("provider ref = %q, want pid:N", st.Services["app"].ProviderRef)
+	}
+
+	rc, err := p.Logs(ctx, instID, provider.LogOptions{})
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+
+	logs, _ := io.ReadAll(rc)
+	_ = rc.Close()
+
+	if !strings.Contains(string(logs), `"line":"greeting=hello"`) || !strings.Contains(string(logs), `"stream":"stdout"`) {
+		t.Fatalf("logs = %s, want the greeting line on stdout", logs)
+	}
+
+	if err := p.Stop(ctx, instID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if st, _ := p.Status(ctx, instID); st.State != provider.StateStopped {
+		t.Fatalf("state after Stop = %s, want stopped", st.State)
+	}
+
+	if err := p.Deprovision(ctx, instID); err != nil {
+		t.Fatalf("Deprovision: %v", err)
+	}
+
+	if st, _ := p.Status(ctx, instID); st.State != provider.StateDestroyed {
+		t.Fatalf("state after Deprovision = %s, want destroyed", st.State)
+	}
+
+	if _, err := os.Stat(p.instanceDir(instID)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("work dir still present after Deprovision: %v", err)
+	}
+}
+
+// TestDeploy_RestartsWithNewEnv verifies a deploy restarts the
+// service with its new env on the same port, and rejects unknown
+// services.
+func TestDeploy_RestartsWithNewEnv(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := newTestProvider(t)
+	instID := id.New(id.PrefixInstance)
+
+	res, err := p.Provision(ctx, provider.ProvisionRequest{
+		InstanceID: instID,
+		Services:   []provider.ServiceSpec{helperService("app", "serve", map[string]string{"GREETING": "hello"})},
+	})
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	url := res.Endpoints[0].URL
+	waitFor(t, "first greeting", func() bool { return get(url) == "hello" })
+
+	_, err = p.Deploy(ctx, provider.DeployRequest{
+		InstanceID: instID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services: []provider.ServiceDeploySpec{{
+			Name:  "app",
+			Image: "helper",
+			Env:   map[string]string{helperEnv: "serve", "GREETING": "world"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	waitFor(t, "new greeting", func() bool { return get(url) == "world" })
+
+	_, err = p.Deploy(ctx, provider.DeployRequest{
+		InstanceID: instID,
+		Services:   []provider.ServiceDeploySpec{{Name: "missing", Image: "x"}},
+	})
+	if err == nil {
+		t.Fatal("Deploy of an unknown service succeeded")
+	}
+}
+
+// TestExec_ReportsExitCodeAndOutput verifies Exec runs in the
+// replica's working directory and reports a non-zero exit as a
+// result, not an error.
+func TestExec_ReportsExitCodeAndOutput(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := newTestProvider(t)
+	instID := id.New(id.PrefixInstance)
+
+	if _, err := p.Provision(ctx, provider.ProvisionRequest{
+		InstanceID: instID,
+		Services:   []provider.ServiceSpec{helperService("app", "serve", nil)},
+	}); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	waitFor(t, "running", func() bool {
+		st, _ := p.Status(ctx, instID)
+
+		return st.Ready
+	})
+
+	res, err := p.Exec(ctx, instID, provider.ExecRequest{
+		Command: []string{"sh", "-c", "pwd; echo oops >&2; exit 3"},
+	})
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	if res.ExitCode != 3 {
+		t.Fatalf("exit code = %d, want 3", res.ExitCode)
+	}
+
+	if got := strings.TrimSpace(string(res.Stdout)); !strings.HasSuffix(got, "app") {
+		t.Fatalf("stdout = %q, want the replica's work dir", got)
+	}
+
+	if strings.TrimSpace(string(res.Stderr)) != "oops" {
+		t.Fatalf("stderr = %q, want oops", res.Stderr)
+	}
+
+	if _, err := p.Exec(ctx, id.New(id.PrefixInstance), provider.ExecRequest{Command: []string{"true"}}); !errors.Is(err, ctrlplane.ErrNotFound) {
+		t.Fatalf("exec on unknown instance: err = %v, want ErrNotFound", err)
+	}
+}
+
+// TestSupervise_RestartsExitedProcess verifies a process that keeps
+// exiting is restarted and its restarts counted.
+func TestSupervise_RestartsExitedProcess(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := newTestProvider(t)
+	instID := id.New(id.PrefixInstance)
+
+	if _, err := p.Provision(ctx, provider.ProvisionRequest{
+		InstanceID: instID,
+		Services:   []provider.ServiceSpec{helperService("app", "crash", nil)},
+	}); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	waitFor(t, "two restarts", func() bool {
+		st, _ := p.Status(ctx, instID)
+
+		return st.Services["app"].Restarts >= 2
+	})
+
+	st, _ := p.Status(ctx, instID)
+	if st.Ready {
+		t.Fatalf("status = %+v, want not ready while crash-looping", st)
+	}
+}
+
+// TestProvision_InitFailureFailsProvision verifies a failing Init
+// fails the provision, keeps Main from starting, and leaves the
+// failure visible in Status.
+func TestProvision_InitFailureFailsProvision(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := newTestProvider(t)
+	instID := id.New(id.PrefixInstance)
+
+	migrate := helperService("migrate", "fail", nil)
+	migrate.Role = provider.RoleInit
+	migrate.Ports = nil
+
+	_, err := p.Provision(ctx, provider.ProvisionRequest{
+		InstanceID: instID,
+		Services:   []provider.ServiceSpec{migrate, helperService("app", "serve", nil)},
+	})
+	if err == nil {
+		t.Fatal("Provision succeeded despite a failing init")
+	}
+
+	st, err := p.Status(ctx, instID)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+
+	if st.State != provider.StateFailed {
+		t.Fatalf("state = %s, want failed", st.State)
+	}
+
+	if st.Services["app"].State == provider.StateRunning {
+		t.Fatal("main started after its init failed")
+	}
+}
+
+// TestScale_AddsAndRemovesReplicas verifies Scale starts replicas on
+// their own ports and stops the surplus.
+func TestScale_AddsAndRemovesReplicas(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := newTestProvider(t)
+	instID := id.New(id.PrefixInstance)
+
+	if _, err := p.Provision(ctx, provider.ProvisionRequest{
+		InstanceID: instID,
+		Services:   []provider.ServiceSpec{helperService("app", "serve", map[string]string{"GREETING": "hi"})},
+	}); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	if err := p.Scale(ctx, instID, provider.ResourceSpec{Replicas: 3}); err != nil {
+		t.Fatalf("Scale up: %v", err)
+	}
+
+	waitFor(t, "3 ready replicas", func() bool {
+		st, _ := p.Status(ctx, instID)
+
+		return st.ReadyReplicas == 3
+	})
+
+	st, _ := p.Status(ctx, instID)
+	if len(st.Endpoints) != 3 {
+		t.Fatalf("endpoints = %d, want 3", len(st.Endpoints))
+	}
+
+	for _, ep := range st.Endpoints {
+		waitFor(t, "replica at "+ep.URL, func() bool { return get(ep.URL) == "hi" })
+	}
+
+	if err := p.Scale(ctx, instID, provider.ResourceSpec{Replicas: 1}); err != nil {
+		t.Fatalf("Scale down: %v", err)
+	}
+
+	if st, _ := p.Status(ctx, instID); st.Replicas != 1 || len(st.Endpoints) != 1 {
+		t.Fatalf("after scale down: replicas = %d, endpoints = %d, want 1 and 1", st.Replicas, len(st.Endpoints))
+	}
+}
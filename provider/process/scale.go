@@ -0,0 +1,61 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// Scale sets how many replicas of every long-lived service run. New
+// replicas get free ports and their own working directory; removed
+// replicas are stopped, newest first, and their directory deleted.
+// CPU, memory and GPU have no local equivalent and are ignored, as is
+// a zero Replicas.
+func (p *Provider) Scale(_ context.Context, instanceID id.ID, spec provider.ResourceSpec) error {
+	inst, err := p.lookup(instanceID)
+	if err != nil {
+		return fmt.Errorf("process: scale: %w", err)
+	}
+
+	if spec.Replicas <= 0 {
+		return nil
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	for _, name := range inst.order {
+		reps := inst.services[name]
+		if len(reps) == 0 || reps[0].role == provider.RoleInit {
+			continue
+		}
+
+		for i := len(reps); i < spec.Replicas; i++ {
+			pr, err := p.newProcess(inst, reps[0].currentSpec(), i)
+			if err != nil {
+				return fmt.Errorf("process: scale %s: %w", name, err)
+			}
+
+			if err := p.prepare(inst, pr); err != nil {
+				return fmt.Errorf("process: scale %s: %w", name, err)
+			}
+
+			pr.start(p.cfg.StopTimeout, p.cfg.MaxRestartBackoff)
+			reps = append(reps, pr)
+		}
+
+		for len(reps) > spec.Replicas {
+			last := reps[len(reps)-1]
+			last.stop()
+			_ = os.RemoveAll(last.dir)
+			reps = reps[:len(reps)-1]
+		}
+
+		inst.services[name] = reps
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/xraph/ctrlplane/provider"
+)
+
+const (
+	// minRestartBackoff is the delay before the first restart of a
+	// process that exited; it doubles per consecutive exit up to
+	// Config.MaxRestartBackoff.
+	minRestartBackoff = 500 * time.Millisecond
+
+	// stableRunTime is how long a process must stay up for its
+	// restart backoff to reset.
+	stableRunTime = 10 * time.Second
+)
+
+// portBinding maps a PortSpec's container port to the host port the
+// process is told to listen on.
+type portBinding struct {
+	Container int
+	Host      int
+	Protocol  string
+}
+
+// process supervises one replica of one service: it (re)starts the OS
+// process, records its state and keeps its output. The spec can be
+// swapped between runs by Deploy.
+type process struct {
+	service string
+	replica int
+	role    provider.ServiceRole
+	dir     string
+	ports   []portBinding
+	logs    *logBuffer
+
+	// env is the environment the provider computed for this replica
+	// (ports, sibling addresses); spec.Env is layered on top per run.
+	env []string
+
+	mu        sync.Mutex
+	spec      provider.ServiceSpec
+	state     provider.InstanceState
+	message   string
+	restarts  int
+	exitCode  int
+	startedAt time.Time
+	pid       int
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// command builds the exec.Cmd for one run of spec.
+func (pr *process) command(ctx context.Context, spec provider.ServiceSpec, stopTimeout time.Duration) (*exec.Cmd, error) {
+	program, args := spec.Image, spec.Args
+	if len(spec.Command) > 0 {
+		program = spec.Command[0]
+		args = append(append([]string{}, spec.Command[1:]...), spec.Args...)
+	}
+
+	if program == "" {
+		return nil, fmt.Errorf("service %q: no command or image to run", pr.service)
+	}
+
+	cmd := exec.CommandContext(ctx, program, args...)
+	cmd.Dir = pr.dir
+	cmd.Env = mergeEnv(pr.env, spec.Env)
+	cmd.Stdout = pr.logs.writer("stdout")
+	cmd.Stderr = pr.logs.writer("stderr")
+
+	// Ask nicely first; WaitDelay kills the process if it ignores the
+	// interrupt.
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = stopTimeout
+
+	return cmd, nil
+}
+
+// runOnce runs an Init service to completion.
+func (pr *process) runOnce(ctx context.Context, stopTimeout time.Duration) error {
+	pr.mu.Lock()
+	spec := pr.spec
+	pr.state = provider.StateStarting
+	pr.mu.Unlock()
+
+	cmd, err := pr.command(ctx, spec, stopTimeout)
+	if err != nil {
+		pr.finish(provider.StateFailed, err.Error(), -1)
+
+		return err
+	}
+
+	pr.mu.Lock()
+	pr.startedAt = time.Now().UTC()
+	pr.mu.Unlock()
+
+	if err := cmd.Run(); err != nil {
+		pr.finish(provider.StateFailed, err.Error(), exitCode(cmd))
+
+		return fmt.Errorf("init service %q: %w", pr.service, err)
+	}
+
+	pr.finish(provider.StateStopped, "completed", 0)
+
+	return nil
+}
+
+// finish records the end of a run.
+func (pr *process) finish(state provider.InstanceState, message string, code int) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.state = state
+	pr.message = message
+	pr.exitCode = code
+	pr.pid = 0
+}
+
+// start launches the supervise loop unless it's already running.
+func (pr *process) start(stopTimeout, maxBackoff time.Duration) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if pr.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pr.cancel = cancel
+	pr.done = make(chan struct{})
+	pr.state = provider.StateStarting
+	pr.message = ""
+
+	go pr.supervise(ctx, pr.done, stopTimeout, maxBackoff)
+}
+
+// stop ends the supervise loop and waits for the process to exit.
+func (pr *process) stop() {
+	pr.mu.Lock()
+	cancel, done := pr.cancel, pr.done
+	pr.cancel, pr.done = nil, nil
+	pr.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
+
+// supervise runs the process until ctx ends, restarting it with
+// exponential backoff every time it exits.
+func (pr *process) supervise(ctx context.Context, done chan struct{}, stopTimeout, maxBackoff time.Duration) {
+	defer close(done)
+
+	backoff := minRestartBackoff
+
+	for {
+		pr.mu.Lock()
+		spec := pr.spec
+		pr.mu.Unlock()
+
+		ranFor, err := pr.runSupervised(ctx, spec, stopTimeout)
+
+		if ctx.Err() != nil {
+			pr.finish(provider.StateStopped, "", 0)
+
+			return
+		}
+
+		if ranFor >= stableRunTime {
+			backoff = minRestartBackoff
+		}
+
+		msg := "exited"
+		if err != nil {
+			msg = err.Error()
+		}
+
+		pr.mu.Lock()
+		pr.restarts++
+		pr.state = provider.StateFailed
+		pr.message = fmt.Sprintf("%s; restarting in %s", msg, backoff)
+		pr.pid = 0
+		pr.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			pr.finish(provider.StateStopped, "", 0)
+
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// runSupervised runs the process once, marking it running while it
+// is up, and reports how long it ran.
+func (pr *process) runSupervised(ctx context.Context, spec provider.ServiceSpec, stopTimeout time.Duration) (time.Duration, error) {
+	cmd, err := pr.command(ctx, spec, stopTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	started := time.Now().UTC()
+
+	pr.mu.Lock()
+	pr.state = provider.StateRunning
+	pr.message = ""
+	pr.startedAt = started
+	pr.pid = cmd.Process.Pid
+	pr.mu.Unlock()
+
+	err = cmd.Wait()
+
+	pr.mu.Lock()
+	pr.exitCode = exitCode(cmd)
+	pr.mu.Unlock()
+
+	return time.Since(started), err
+}
+
+// status reports the replica's current state.
+func (pr *process) status() provider.ServiceStatus {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	ref := ""
+	if pr.pid > 0 {
+		ref = fmt.Sprintf("pid:%d", pr.pid)
+	}
+
+	return provider.ServiceStatus{
+		State:       pr.state,
+		Ready:       pr.state == provider.StateRunning,
+		Restarts:    pr.restarts,
+		ProviderRef: ref,
+		Message:     pr.message,
+	}
+}
+
+// setSpec swaps the spec used for the next run.
+func (pr *process) setSpec(spec provider.ServiceSpec) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.spec = spec
+}
+
+// currentSpec returns the spec the process runs with.
+func (pr *process) currentSpec() provider.ServiceSpec {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	return pr.spec
+}
+
+// completed reports whether this is an Init that ran successfully.
+func (pr *process) completed() bool {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	return pr.role == provider.RoleInit && pr.state == provider.StateStopped && pr.exitCode == 0
+}
+
+// exitCode returns a finished command's exit code, or -1 when it
+// didn't exit normally (never started, killed by a signal).
+func exitCode(cmd *exec.Cmd) int {
+	if cmd.ProcessState == nil {
+		return -1
+	}
+
+	return cmd.ProcessState.ExitCode()
+}
+
+// errNotRunning is returned by operations that need a live process.
+var errNotRunning = errors.New("not running")
@@ -23,6 +23,18 @@ type HealthStatus struct {
 	CheckedAt time.Time     `db:"checked_at" json:"checked_at"`
 }
 
+// Rollbacker is an optional interface for providers that can revert
+// an instance to a prior Release as one native operation instead of a
+// strategy-driven redeploy of the snapshot. deploy.Service
+// type-asserts for it on Rollback and hands over the target Release's
+// snapshot, which Provider.Rollback's (instanceID, releaseID) shape
+// can't carry.
+type Rollbacker interface {
+	// RollbackRelease restores every service of the instance to the
+	// target Release.
+	RollbackRelease(ctx context.Context, req RollbackRequest) (*DeployResult, error)
+}
+
 // Provider is the unified interface for infrastructure operations.
 // Each cloud/orchestrator (K8s, Nomad, AWS ECS, Docker, etc.) implements this.
 type Provider interface {
@@ -62,7 +74,7 @@ type Provider interface {
 	// Resources returns current resource utilization.
 	Resources(ctx context.Context, instanceID id.ID) (*ResourceUsage, error)
 
-	// Logs streams logs for the instance.
+	// Logs streams logs for the instance as LogEvent lines.
 	Logs(ctx context.Context, instanceID id.ID, opts LogOptions) (io.ReadCloser, error)
 
 	// Exec runs a command inside the instance.
@@ -11,15 +11,23 @@ import (
 var ErrProviderNotFound = errors.New("ctrlplane: provider not registered")
 
 // Registry manages named providers. Thread-safe.
+//
+// Providers handed out by Get, Default and All are wrapped in the
+// registry's middleware chain (see Use), so every caller resolving a
+// provider through the registry gets the same timeout, retry and
+// circuit-breaking policy.
 type Registry struct {
-	mu        sync.RWMutex
-	providers map[string]Provider
-	fallback  string
+	mu          sync.RWMutex
+	raw         map[string]Provider
+	providers   map[string]Provider
+	middlewares []Middleware
+	fallback    string
 }
 
 // NewRegistry creates an empty provider registry.
 func NewRegistry() *Registry {
 	return &Registry{
+		raw:       make(map[string]Provider),
 		providers: make(map[string]Provider),
 	}
 }
@@ -29,7 +37,23 @@ func (r *Registry) Register(name string, p Provider) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.providers[name] = p
+	r.raw[name] = p
+	r.providers[name] = Chain(name, p, r.middlewares...)
+}
+
+// Use appends middleware to the chain every provider is wrapped in,
+// the first registered outermost. It applies to providers already
+// registered as well as later ones; callers holding a provider from
+// an earlier Get keep the chain they got.
+func (r *Registry) Use(mws ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middlewares = append(r.middlewares, mws...)
+
+	for name, p := range r.raw {
+		r.providers[name] = Chain(name, p, r.middlewares...)
+	}
 }
 
 // Get retrieves a provider by name.
@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/secrets"
+)
+
+// dockerHub is the canonical host of images named without a registry
+// ("nginx", "library/nginx").
+const dockerHub = "docker.io"
+
+// RegistryResolver resolves a registry-type secret reference into its
+// credential. secrets.Service implements it; instance.Service and
+// deploy.Service take one to fill ServiceSpec.RegistryAuth before
+// calling a provider.
+type RegistryResolver interface {
+	ResolveRegistry(ctx context.Context, instanceID id.ID, key string) (*secrets.RegistryCredential, error)
+}
+
+// RegistryHost returns the registry host an image reference pulls
+// from, following docker's rules: the first path component is a host
+// when it contains a "." or ":" or is "localhost", otherwise the image
+// is on Docker Hub.
+func RegistryHost(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if !found || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		return dockerHub
+	}
+
+	return normalizeRegistryHost(first)
+}
+
+// normalizeRegistryHost strips a scheme and path from a registry
+// server and folds Docker Hub's aliases onto "docker.io", so a
+// credential saved as "https://index.docker.io/v1/" still matches.
+func normalizeRegistryHost(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	server, _, _ = strings.Cut(server, "/")
+
+	switch server {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHub
+	}
+
+	return server
+}
+
+// CredentialMatchesImage reports whether cred is for the registry
+// image is pulled from.
+func CredentialMatchesImage(cred *secrets.RegistryCredential, image string) bool {
+	return cred != nil && normalizeRegistryHost(cred.Server) == RegistryHost(image)
+}
+
+// ResolveRegistryCredential returns the credential among refs'
+// registry-type secrets that matches image's registry, or nil when
+// none does. Other secret types are ignored. A referenced secret that
+// hasn't been set yet is skipped rather than failing — an instance can
+// be created before its credentials and pick them up on the next
+// deploy — but any other resolve error is returned.
+func ResolveRegistryCredential(ctx context.Context, r RegistryResolver, instanceID id.ID, refs []SecretRef, image string) (*secrets.RegistryCredential, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	for _, ref := range refs {
+		if ref.Type != secrets.SecretRegistry {
+			continue
+		}
+
+		cred, err := r.ResolveRegistry(ctx, instanceID, ref.Key)
+		if errors.Is(err, ctrlplane.ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if CredentialMatchesImage(cred, image) {
+			return cred, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ResolveServiceRegistryAuth returns a copy of services with each
+// one's RegistryAuth filled from its registry-type Secrets. The
+// caller's slice is not modified.
+func ResolveServiceRegistryAuth(ctx context.Context, r RegistryResolver, instanceID id.ID, services []ServiceSpec) ([]ServiceSpec, error) {
+	if r == nil {
+		return services, nil
+	}
+
+	out := make([]ServiceSpec, len(services))
+	copy(out, services)
+
+	for i := range out {
+		cred, err := ResolveRegistryCredential(ctx, r, instanceID, out[i].Secrets, out[i].Image)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", out[i].Name, err)
+		}
+
+		out[i].RegistryAuth = cred
+	}
+
+	return out, nil
+}
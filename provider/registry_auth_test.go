@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/secrets"
+)
+
+// fakeRegistryResolver resolves keys from a fixed map; missing keys
+// are ErrNotFound, like an unset secret.
+type fakeRegistryResolver map[string]*secrets.RegistryCredential
+
+func (f fakeRegistryResolver) ResolveRegistry(_ context.Context, _ id.ID, key string) (*secrets.RegistryCredential, error) {
+	cred, ok := f[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: secret %s", ctrlplane.ErrNotFound, key)
+	}
+
+	return cred, nil
+}
+
+func TestRegistryHost(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"nginx":                          "docker.io",
+		"library/nginx:1.25":             "docker.io",
+		"ghcr.io/acme/api:v2":            "ghcr.io",
+		"registry.example.com:5000/a/b":  "registry.example.com:5000",
+		"localhost/app":                  "localhost",
+		"index.docker.io/library/nginx":  "docker.io",
+		"myuser/app@sha256:0123456789ab": "docker.io",
+	}
+
+	for image, want := range tests {
+		if got := RegistryHost(image); got != want {
+			t.Errorf("RegistryHost(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+// TestResolveRegistryCredential_MatchesImageRegistry verifies only a
+// registry secret for the image's registry is used, unset secrets are
+// skipped, and non-registry refs are never resolved.
+func TestResolveRegistryCredential_MatchesImageRegistry(t *testing.T) {
+	t.Parallel()
+
+	ghcr := &secrets.RegistryCredential{Server: "https://ghcr.io/", Username: "bot", Password: "pw"}
+	hub := &secrets.RegistryCredential{Server: "index.docker.io", Username: "me", Password: "pw"}
+	r := fakeRegistryResolver{"ghcr": ghcr, "hub": hub}
+
+	refs := []SecretRef{
+		{Key: "unset", Type: secrets.SecretRegistry},
+		{Key: "DB_PASSWORD", Type: secrets.SecretEnvVar},
+		{Key: "hub", Type: secrets.SecretRegistry},
+		{Key: "ghcr", Type: secrets.SecretRegistry},
+	}
+
+	got, err := ResolveRegistryCredential(context.Background(), r, id.New(id.PrefixInstance), refs, "ghcr.io/acme/api:v2")
+	if err != nil || got != ghcr {
+		t.Fatalf("ghcr image: got %+v, %v", got, err)
+	}
+
+	got, err = ResolveRegistryCredential(context.Background(), r, id.New(id.PrefixInstance), refs, "nginx")
+	if err != nil || got != hub {
+		t.Fatalf("hub image: got %+v, %v", got, err)
+	}
+
+	got, err = ResolveRegistryCredential(context.Background(), r, id.New(id.PrefixInstance), refs, "quay.io/x/y")
+	if err != nil || got != nil {
+		t.Fatalf("unmatched registry: got %+v, %v", got, err)
+	}
+
+	failing := errResolver{err: errors.New("vault down")}
+	if _, err := ResolveRegistryCredential(context.Background(), failing, id.New(id.PrefixInstance), refs[:1], "nginx"); err == nil {
+		t.Fatal("resolver error: want error, got nil")
+	}
+}
+
+type errResolver struct{ err error }
+
+func (e errResolver) ResolveRegistry(context.Context, id.ID, string) (*secrets.RegistryCredential, error) {
+	return nil, e.err
+}
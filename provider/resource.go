@@ -1,17 +1,72 @@
 package provider
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
 )
 
 // ResourceSpec declares desired resources for an instance.
+//
+// Autoscale, when enabled, hands the replica count to the provider's
+// autoscaler; Replicas is then only the starting point.
 type ResourceSpec struct {
-	CPUMillis int    `json:"cpu_millis"`
-	MemoryMB  int    `json:"memory_mb"`
-	DiskMB    int    `json:"disk_mb,omitempty"`
-	Replicas  int    `json:"replicas"`
-	GPU       string `json:"gpu,omitempty"`
+	CPUMillis int            `json:"cpu_millis"`
+	MemoryMB  int            `json:"memory_mb"`
+	DiskMB    int            `json:"disk_mb,omitempty"`
+	Replicas  int            `json:"replicas"`
+	GPU       string         `json:"gpu,omitempty"`
+	Autoscale *AutoscaleSpec `json:"autoscale,omitempty"`
+}
+
+// AutoscaleSpec lets the provider scale a service between MinReplicas
+// and MaxReplicas to hold average utilization at the targets. Targets
+// are percentages of the requested CPU/memory; with neither set the
+// provider picks a CPU target. A zero MaxReplicas disables autoscaling.
+type AutoscaleSpec struct {
+	MinReplicas         int `json:"min_replicas"`
+	MaxReplicas         int `json:"max_replicas"`
+	TargetCPUPercent    int `json:"target_cpu_percent,omitempty"`
+	TargetMemoryPercent int `json:"target_memory_percent,omitempty"`
+}
+
+// Enabled reports whether the spec turns autoscaling on.
+func (a *AutoscaleSpec) Enabled() bool {
+	return a != nil && a.MaxReplicas > 0
+}
+
+// Validate checks the replica bounds and targets of an enabled spec.
+func (a *AutoscaleSpec) Validate() error {
+	if !a.Enabled() {
+		return nil
+	}
+
+	switch {
+	case a.MinReplicas < 1:
+		return fmt.Errorf("%w: autoscale min_replicas must be at least 1", ctrlplane.ErrInvalidConfig)
+	case a.MaxReplicas < a.MinReplicas:
+		return fmt.Errorf("%w: autoscale max_replicas %d is below min_replicas %d", ctrlplane.ErrInvalidConfig, a.MaxReplicas, a.MinReplicas)
+	case a.TargetCPUPercent < 0 || a.TargetMemoryPercent < 0:
+		return fmt.Errorf("%w: autoscale targets must not be negative", ctrlplane.ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// AutoscaleStatus reports what a provider's autoscaler is doing.
+// Utilization is the observed average as a percentage of requests;
+// nil when the provider has no sample yet.
+type AutoscaleStatus struct {
+	MinReplicas     int        `json:"min_replicas"`
+	MaxReplicas     int        `json:"max_replicas"`
+	CurrentReplicas int        `json:"current_replicas"`
+	DesiredReplicas int        `json:"desired_replicas"`
+	CPUPercent      *int       `json:"cpu_percent,omitempty"`
+	MemoryPercent   *int       `json:"memory_percent,omitempty"`
+	LastScaleTime   *time.Time `json:"last_scale_time,omitempty"`
 }
 
 // ResourceUsage reports actual resource utilization.
@@ -43,9 +98,12 @@ type VolumeSpec struct {
 //
 // ServiceName names the service inside the instance that owns this
 // endpoint — empty for legacy single-service instances. Routes can
-// target a specific service via its name.
+// target a specific service via its name. Track is set on endpoints of
+// a release running beside the live one (see TrackDeployer); empty
+// means the live services.
 type Endpoint struct {
 	ServiceName string `json:"service_name,omitempty"`
+	Track       string `json:"track,omitempty"`
 	URL         string `json:"url"`
 	Port        int    `json:"port"`
 	Protocol    string `json:"protocol"`
@@ -73,11 +131,56 @@ type LogOptions struct {
 	Tail        int       `json:"tail,omitempty"`
 }
 
+// LogEvent is one log line as Logs streams it: JSON, one object per
+// newline-terminated line, whichever provider produced it, so the
+// API's SSE handler and workload.StreamLogs read every provider the
+// same way. Adding fields is fine; removing them isn't.
+type LogEvent struct {
+	Timestamp string `json:"ts"`
+	Stream    string `json:"stream"` // "stdout" | "stderr"
+	Line      string `json:"line"`
+}
+
+// WriteLogEvent marshals ev and writes it to dst as one
+// newline-terminated line. A single Write per event keeps lines from
+// concurrent writers, such as a stdout and a stderr goroutine sharing
+// a pipe, from interleaving mid-line.
+func WriteLogEvent(dst io.Writer, ev LogEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshal log event: %w", err)
+	}
+
+	if _, err := dst.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write log event: %w", err)
+	}
+
+	return nil
+}
+
 // ExecRequest describes a command to run inside an instance.
+//
+// ServiceName picks one service inside the instance to run the
+// command in — empty defaults to the Main service, same as
+// LogOptions.ServiceName. Naming a Sidecar lets operators exec into
+// e.g. a proxy container without touching Main.
+//
+// Resize carries terminal size changes for a TTY session (a browser
+// terminal forwarding window resizes). Only read when TTY is set;
+// send the initial size first so full-screen programs start with
+// the right geometry. Providers stop reading once the command exits.
 type ExecRequest struct {
-	Command []string  `json:"command"`
-	Stdin   io.Reader `json:"-"`
-	TTY     bool      `json:"tty"`
+	ServiceName string              `json:"service_name,omitempty"`
+	Command     []string            `json:"command"`
+	Stdin       io.Reader           `json:"-"`
+	TTY         bool                `json:"tty"`
+	Resize      <-chan TerminalSize `json:"-"`
+}
+
+// TerminalSize is a TTY's geometry in character cells.
+type TerminalSize struct {
+	Width  uint16 `json:"width"`
+	Height uint16 `json:"height"`
 }
 
 // ExecResult holds the result of an exec operation.
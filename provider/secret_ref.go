@@ -1,6 +1,13 @@
 package provider
 
-import "github.com/xraph/ctrlplane/secrets"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"github.com/xraph/ctrlplane/secrets"
+)
 
 // SecretRef is a lightweight reference to a secret needed at deploy time.
 // The secret value itself is fetched from the vault by Key when a Workload
@@ -15,9 +22,38 @@ type SecretRef struct {
 
 // ConfigFile defines a configuration file (JSON/YAML/env/text) that is
 // stored in the vault and mounted into the container at deploy time.
+//
+// VaultKey and Checksum are filled in by deploy.Service once Content
+// has been written to the vault for a Release. A Release snapshot
+// keeps only those two and drops Content; the vault copy is what a
+// rollback mounts again.
 type ConfigFile struct {
-	Name    string `db:"name"    json:"name"`    // e.g. "app-config"
-	Path    string `db:"path"    json:"path"`    // mount path, e.g. "/etc/app/config.yaml"
-	Format  string `db:"format"  json:"format"`  // "json", "yaml", "env", "text"
-	Content string `db:"content" json:"content"` // file content (stored in vault at deploy time)
+	Name     string `db:"name"      json:"name"`                // e.g. "app-config"
+	Path     string `db:"path"      json:"path"`                // mount path, e.g. "/etc/app/config.yaml"
+	Format   string `db:"format"    json:"format"`              // "json", "yaml", "env", "text"
+	Content  string `db:"content"   json:"content,omitempty"`   // file content (stored in vault at deploy time)
+	VaultKey string `db:"vault_key" json:"vault_key,omitempty"` // vault key of the stored content
+	Checksum string `db:"checksum"  json:"checksum,omitempty"`  // hex SHA-256 of Content
+}
+
+// ConfigFileChecksum returns the hex SHA-256 of a config file's
+// content — the value stored in ConfigFile.Checksum.
+func ConfigFileChecksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// ConfigFilesChecksum returns one digest over a service's whole set of
+// config files, independent of their order. Providers stamp it on the
+// workload so a change to any file (or to the set) rolls the service.
+func ConfigFilesChecksum(files []ConfigFile) string {
+	entries := make([]string, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, f.Path+"\x00"+ConfigFileChecksum(f.Content))
+	}
+
+	slices.Sort(entries)
+
+	return ConfigFileChecksum(strings.Join(entries, "\n"))
 }
@@ -1,5 +1,12 @@
 package provider
 
+import (
+	"context"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/secrets"
+)
+
 // ServiceRole categorises a service inside a Workload by lifecycle.
 //
 //   - RoleMain: long-lived process; the workload's primary container.
@@ -81,6 +88,12 @@ type ServiceSpec struct {
 	// service's filesystem at deploy time.
 	ConfigFiles []ConfigFile `json:"config_files,omitempty"`
 
+	// RegistryAuth is the credential for pulling Image, resolved from a
+	// registry-type entry in Secrets just before the provider call. Nil
+	// pulls anonymously. Never serialised, so it can't leak into a
+	// stored instance or the API.
+	RegistryAuth *secrets.RegistryCredential `json:"-"`
+
 	// Annotations is per-service free-form metadata (e.g. k8s
 	// container annotations). Workload-level metadata lives on the
 	// Workload's Labels map.
@@ -91,6 +104,10 @@ type ServiceSpec struct {
 	// defaults in place.
 	Command []string `json:"command,omitempty"`
 	Args    []string `json:"args,omitempty"`
+
+	// Placement constrains which nodes the workload runs on. Read from
+	// the Main service only; see Placement.
+	Placement *Placement `json:"placement,omitempty"`
 }
 
 // ServiceStatus is the per-service runtime state reported back from the
@@ -101,6 +118,12 @@ type ServiceStatus struct {
 	Restarts    int           `json:"restarts"`
 	ProviderRef string        `json:"provider_ref,omitempty"`
 	Message     string        `json:"message,omitempty"`
+
+	// Replicas is how many copies of the service exist; ReadyReplicas
+	// how many of them are ready. Zero when the provider doesn't run
+	// per-service replicas.
+	Replicas      int `json:"replicas,omitempty"`
+	ReadyReplicas int `json:"ready_replicas,omitempty"`
 }
 
 // ServiceDeploySpec is a per-service slice of a Deploy operation. A
@@ -111,13 +134,49 @@ type ServiceDeploySpec struct {
 	Image       string            `json:"image"                  validate:"required"`
 	Env         map[string]string `json:"env,omitempty"`
 	HealthCheck *HealthCheckSpec  `json:"health_check,omitempty"`
+
+	// ConfigFiles replaces the service's mounted config files as a
+	// set. Empty leaves the current files in place, the same way an
+	// empty Env does.
+	ConfigFiles []ConfigFile `json:"config_files,omitempty"`
+
+	// RegistryAuth is the pull credential for Image, resolved by
+	// deploy.Service from the service's registry-type Secrets. Never
+	// serialised.
+	RegistryAuth *secrets.RegistryCredential `json:"-"`
 }
 
 // ServiceSnapshot is the per-service slice of a Release. Releases are
 // always self-contained — partial deploys produce a new Release whose
 // non-targeted services are inherited from the prior Release.
+//
+// ConfigFiles are references only (VaultKey + Checksum, no Content):
+// a file's content is written to the vault once, for the Release that
+// introduced it, and later Releases that inherit it share that copy.
 type ServiceSnapshot struct {
-	Name  string            `json:"name"`
-	Image string            `json:"image"`
-	Env   map[string]string `json:"env,omitempty"`
+	Name        string            `json:"name"`
+	Image       string            `json:"image"`
+	Env         map[string]string `json:"env,omitempty"`
+	ConfigFiles []ConfigFile      `json:"config_files,omitempty"`
+
+	// RegistryAuth is resolved at rollback time, like
+	// ServiceDeploySpec.RegistryAuth; a Release never records it.
+	RegistryAuth *secrets.RegistryCredential `json:"-"`
+}
+
+// InstanceServiceResolver looks up the services an instance was
+// provisioned with, for providers that need to tell them apart but are
+// only handed an instance ID. An instance it can't find is
+// ctrlplane.ErrNotFound.
+type InstanceServiceResolver interface {
+	InstanceServices(ctx context.Context, instanceID id.ID) ([]ServiceSpec, error)
+}
+
+// InstanceServiceAware is an optional interface for providers that
+// need an instance's services outside Provision, such as Kubernetes
+// finding the Main container in pods that don't name it. The control
+// plane hands every such layer of a registered provider a resolver over
+// its instance store.
+type InstanceServiceAware interface {
+	SetInstanceServiceResolver(r InstanceServiceResolver)
 }
@@ -24,6 +24,10 @@ const (
 
 	// SourceArgoCD delegates deployment to Argo CD via an Application CR.
 	SourceArgoCD SourceType = "argocd"
+
+	// SourceCompose deploys the services of a Docker Compose file,
+	// translated into the ServiceSpec model at render time.
+	SourceCompose SourceType = "compose"
 )
 
 // DeploymentSource is the typed union describing what a workload deploys.
@@ -34,6 +38,7 @@ type DeploymentSource struct {
 	Helm      *HelmSource     `json:"helm,omitempty"`
 	Manifests *ManifestSource `json:"manifests,omitempty"`
 	ArgoCD    *ArgoCDSource   `json:"argocd,omitempty"`
+	Compose   *ComposeSource  `json:"compose,omitempty"`
 }
 
 // HelmSource describes a Helm chart to install. Values are the base values
@@ -64,6 +69,13 @@ type KustomizeSource struct {
 	Root  string            `json:"root,omitempty"`
 }
 
+// ComposeSource describes a Docker Compose file. File is the YAML
+// document itself; it is templated with variables as a whole, then
+// each compose service becomes a ServiceSpec.
+type ComposeSource struct {
+	File string `json:"file" validate:"required"`
+}
+
 // ArgoCDSource describes an Argo CD Application that ctrlplane manages.
 type ArgoCDSource struct {
 	Project        string         `json:"project,omitempty"`
@@ -99,6 +111,10 @@ func (s DeploymentSource) Validate() error {
 		if len(s.Services) == 0 {
 			return fmt.Errorf("%w: services source requires services", ctrlplane.ErrInvalidSource)
 		}
+
+		if _, err := WorkloadPlacement(s.Services); err != nil {
+			return err
+		}
 	case SourceHelm:
 		if s.Helm == nil || strings.TrimSpace(s.Helm.Chart) == "" {
 			return fmt.Errorf("%w: helm source requires a chart", ctrlplane.ErrInvalidSource)
@@ -111,6 +127,10 @@ func (s DeploymentSource) Validate() error {
 		if s.ArgoCD == nil || strings.TrimSpace(s.ArgoCD.RepoURL) == "" {
 			return fmt.Errorf("%w: argocd source requires repo_url", ctrlplane.ErrInvalidSource)
 		}
+	case SourceCompose:
+		if s.Compose == nil || strings.TrimSpace(s.Compose.File) == "" {
+			return fmt.Errorf("%w: compose source requires a file", ctrlplane.ErrInvalidSource)
+		}
 	default:
 		return fmt.Errorf("%w: unknown source type %q", ctrlplane.ErrInvalidSource, s.Type)
 	}
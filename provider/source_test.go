@@ -34,6 +34,10 @@ func TestDeploymentSource_Validate(t *testing.T) {
 			name: "valid argocd",
 			src:  DeploymentSource{Type: SourceArgoCD, ArgoCD: &ArgoCDSource{RepoURL: "https://example.com/repo.git"}},
 		},
+		{
+			name: "valid compose",
+			src:  DeploymentSource{Type: SourceCompose, Compose: &ComposeSource{File: "services: {}"}},
+		},
 		{
 			name:    "services without services",
 			src:     DeploymentSource{Type: SourceServices},
@@ -54,6 +58,11 @@ func TestDeploymentSource_Validate(t *testing.T) {
 			src:     DeploymentSource{Type: SourceArgoCD, ArgoCD: &ArgoCDSource{}},
 			wantErr: true,
 		},
+		{
+			name:    "compose without file",
+			src:     DeploymentSource{Type: SourceCompose, Compose: &ComposeSource{File: "  "}},
+			wantErr: true,
+		},
 		{
 			name:    "unknown type",
 			src:     DeploymentSource{Type: SourceType("weird")},
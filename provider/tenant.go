@@ -0,0 +1,32 @@
+package provider
+
+import "context"
+
+// TenantQuota is the part of a tenant's quota a provider can enforce
+// on the backend. Zero fields are unlimited.
+type TenantQuota struct {
+	MaxInstances int `json:"max_instances,omitempty"`
+	MaxCPUMillis int `json:"max_cpu_millis,omitempty"`
+	MaxMemoryMB  int `json:"max_memory_mb,omitempty"`
+	MaxDiskMB    int `json:"max_disk_mb,omitempty"`
+}
+
+// TenantQuotaResolver looks up a tenant's quota when a provider sets
+// up the tenant's backend isolation. A nil quota with a nil error
+// means the tenant has none to enforce.
+type TenantQuotaResolver interface {
+	TenantQuota(ctx context.Context, tenantID string) (*TenantQuota, error)
+}
+
+// TenantIsolator is an optional interface for providers that keep
+// per-tenant state on the backend — a namespace per tenant, say —
+// beyond the instances themselves. The control plane hands it a quota
+// resolver at start and calls DeleteTenant once a tenant is deleted.
+type TenantIsolator interface {
+	// SetTenantQuotaResolver sets where the provider reads quotas from.
+	SetTenantQuotaResolver(r TenantQuotaResolver)
+
+	// DeleteTenant removes everything the provider keeps for the
+	// tenant. A tenant the provider holds nothing for is not an error.
+	DeleteTenant(ctx context.Context, tenantID string) error
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/xraph/ctrlplane/id"
+)
+
+const (
+	// TrackCanary names the track a canary rollout runs the new release on.
+	TrackCanary = "canary"
+
+	// TrackGreen names the track a blue-green rollout runs the new
+	// release on; the live services are blue.
+	TrackGreen = "green"
+)
+
+// TrackDeployer is an optional interface for providers that can run a
+// release on a named track beside an instance's live services, so
+// traffic can be split between the two. The live services are never
+// touched: a track is promoted by a regular Deploy of the same release
+// and then removed.
+//
+// Middleware chains and fault injection forward it, so track calls
+// pass through them like any other.
+type TrackDeployer interface {
+	// DeployTrack starts the track, or moves it to req's release when
+	// it already runs. The result's Endpoints carry the track name.
+	DeployTrack(ctx context.Context, track string, req DeployRequest) (*DeployResult, error)
+
+	// TrackStatus reports the track's runtime state.
+	TrackStatus(ctx context.Context, instanceID id.ID, track string) (*InstanceStatus, error)
+
+	// RemoveTrack tears the track down. A missing track is not an error.
+	RemoveTrack(ctx context.Context, instanceID id.ID, track string) error
+}
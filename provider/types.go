@@ -67,10 +67,24 @@ type DeployRequest struct {
 	Strategy   string              `json:"strategy"`
 }
 
-// DeployResult holds the result of a deploy operation.
+// RollbackRequest describes a native revert to a prior Release.
+// Services is the target Release's full per-service snapshot.
+// Providers with their own revision history (Kubernetes ReplicaSets,
+// Nomad job versions) locate the revision by ReleaseID first and
+// fall back to Services when that history has been pruned; providers
+// without history (Docker) rebuild from Services directly.
+type RollbackRequest struct {
+	InstanceID id.ID             `json:"instance_id"`
+	ReleaseID  id.ID             `json:"release_id"`
+	Services   []ServiceSnapshot `json:"services"`
+}
+
+// DeployResult holds the result of a deploy operation. Endpoints is
+// only set by TrackDeployer.DeployTrack.
 type DeployResult struct {
-	ProviderRef string `json:"provider_ref"`
-	Status      string `json:"status"`
+	ProviderRef string     `json:"provider_ref"`
+	Status      string     `json:"status"`
+	Endpoints   []Endpoint `json:"endpoints,omitempty"`
 }
 
 // InstanceStatus describes the current runtime state of an instance.
@@ -85,6 +99,18 @@ type InstanceStatus struct {
 	Endpoints []Endpoint               `json:"endpoints"`
 	Services  map[string]ServiceStatus `json:"services,omitempty"`
 	Metadata  map[string]string        `json:"metadata,omitempty"`
+
+	// Replicas and ReadyReplicas count the Main service's running
+	// copies. Zero means the provider doesn't report replica counts.
+	// DesiredReplicas is the count the provider is converging on; it
+	// runs ahead of Replicas while an autoscaler adds or drains pods.
+	Replicas        int `json:"replicas,omitempty"`
+	ReadyReplicas   int `json:"ready_replicas,omitempty"`
+	DesiredReplicas int `json:"desired_replicas,omitempty"`
+
+	// Autoscale is set when an autoscaler manages the Main service's
+	// replica count.
+	Autoscale *AutoscaleStatus `json:"autoscale,omitempty"`
 }
 
 // InstanceState represents the lifecycle state of an instance.
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+)
+
+// ChangeKind classifies a StateChange.
+type ChangeKind string
+
+const (
+	// ChangeState reports the instance's aggregate state moved (a
+	// container died, a pod became ready, the workload was deleted
+	// out of band). Status carries the new InstanceStatus.
+	ChangeState ChangeKind = "state"
+
+	// ChangeRestart reports a service exited unexpectedly; the
+	// backend's restart policy usually brings it back. Restarts is
+	// the service's restart count when the backend reports one.
+	ChangeRestart ChangeKind = "restart"
+
+	// ChangeOOMKilled reports a service killed for exceeding its
+	// memory limit.
+	ChangeOOMKilled ChangeKind = "oom_killed"
+)
+
+// StateChange is one runtime change a Watcher observed, keyed by the
+// instance it belongs to.
+type StateChange struct {
+	Kind       ChangeKind `json:"kind"`
+	InstanceID id.ID      `json:"instance_id"`
+
+	// TenantID is read from the labels the provider stamped at
+	// provision time. Empty when the backend object carries none.
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// Service names the service a restart or OOM kill happened to.
+	// Empty for instance-level changes.
+	Service string `json:"service,omitempty"`
+
+	// Status is the instance's status after the change. Set for
+	// ChangeState.
+	Status *InstanceStatus `json:"status,omitempty"`
+
+	Restarts int       `json:"restarts,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Watcher is implemented by providers that can push runtime state
+// changes instead of being polled with Status. Kubernetes uses pod
+// informers, Docker its events API, Nomad its event stream.
+//
+// Watch covers every instance the provider manages. It returns once
+// the stream is established; the channel is closed when ctx is done
+// or the backend stream breaks, and the caller is expected to call
+// Watch again (after a backoff) to resume. Changes that happened
+// while no watch was open are not replayed, so a consumer should
+// treat the first ChangeState per instance as authoritative rather
+// than as a delta.
+type Watcher interface {
+	Watch(ctx context.Context) (<-chan StateChange, error)
+}
+
+// WatchStream is the delivery side Watcher implementations share. It
+// suppresses ChangeState entries that repeat the last state reported
+// for an instance (backends emit many events per transition) and
+// never blocks the provider's event loop past ctx.
+type WatchStream struct {
+	out chan StateChange
+
+	mu   sync.Mutex
+	last map[id.ID]InstanceState
+}
+
+// NewWatchStream creates a stream buffering up to buffer changes.
+func NewWatchStream(buffer int) *WatchStream {
+	return &WatchStream{
+		out:  make(chan StateChange, buffer),
+		last: make(map[id.ID]InstanceState),
+	}
+}
+
+// C returns the channel consumers read from.
+func (w *WatchStream) C() <-chan StateChange {
+	return w.out
+}
+
+// Send delivers c unless it is a ChangeState repeating the
+// instance's last reported state. It reports false once ctx is done,
+// which is the producer's cue to stop.
+func (w *WatchStream) Send(ctx context.Context, c StateChange) bool {
+	if c.At.IsZero() {
+		c.At = time.Now().UTC()
+	}
+
+	if c.Kind == ChangeState {
+		if c.Status == nil {
+			return ctx.Err() == nil
+		}
+
+		w.mu.Lock()
+		prev, seen := w.last[c.InstanceID]
+
+		if c.Status.State == StateDestroyed {
+			delete(w.last, c.InstanceID)
+		} else {
+			w.last[c.InstanceID] = c.Status.State
+		}
+		w.mu.Unlock()
+
+		if seen && prev == c.Status.State {
+			return ctx.Err() == nil
+		}
+	}
+
+	select {
+	case w.out <- c:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Close closes the consumer channel. Call it exactly once, from the
+// producing goroutine, after the last Send.
+func (w *WatchStream) Close() {
+	close(w.out)
+}
@@ -34,8 +34,16 @@ type Cache struct {
 	pollInterval time.Duration
 	checkTimeout time.Duration
 
-	mu      sync.RWMutex
-	results map[string]Status
+	mu       sync.RWMutex
+	results  map[string]Status
+	circuits CircuitReader
+}
+
+// CircuitReader reports providers whose circuit breaker is currently
+// rejecting calls. middleware.Breaker implements it; the cache
+// declares the shape so it doesn't import the middleware package.
+type CircuitReader interface {
+	CircuitOpen(name string) (reason string, open bool)
 }
 
 // Config tunes the cache.
@@ -108,16 +116,33 @@ func (c *Cache) CheckNow(ctx context.Context) {
 	c.sweep(ctx)
 }
 
+// SetCircuits wires the circuit breaker guarding provider calls. An
+// open circuit marks its provider unhealthy in Get and Snapshot
+// straight away, without waiting for the next sweep: the breaker has
+// seen the failures of real traffic, which is fresher evidence than
+// the last probe.
+func (c *Cache) SetCircuits(r CircuitReader) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.circuits = r
+}
+
 // Get returns the last-known status for a provider. ok=false when
 // the cache hasn't seen this provider yet (cold cache, or provider
-// just registered).
+// just registered) and its circuit isn't open.
 func (c *Cache) Get(name string) (Status, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	s, ok := c.results[name]
+	if !ok {
+		s = Status{Name: name}
+	}
 
-	return s, ok
+	s, open := c.overlayCircuit(s)
+
+	return s, ok || open
 }
 
 // Snapshot returns a copy of every cached status. Stable iteration
@@ -128,12 +153,31 @@ func (c *Cache) Snapshot() []Status {
 
 	out := make([]Status, 0, len(c.results))
 	for _, s := range c.results {
+		s, _ = c.overlayCircuit(s)
 		out = append(out, s)
 	}
 
 	return out
 }
 
+// overlayCircuit marks s unhealthy when its provider's circuit is
+// open. Caller holds c.mu.
+func (c *Cache) overlayCircuit(s Status) (Status, bool) {
+	if c.circuits == nil {
+		return s, false
+	}
+
+	reason, open := c.circuits.CircuitOpen(s.Name)
+	if !open {
+		return s, false
+	}
+
+	s.Healthy = false
+	s.Message = reason
+
+	return s, true
+}
+
 // sweep runs HealthCheck against every registered provider in
 // parallel and writes the results into the cache. Providers that
 // don't implement HealthChecker get a synthetic "unknown" status
@@ -170,8 +170,53 @@ func TestCache_RunStopsOnContextCancel(t *testing.T) {
 	}
 }
 
+// TestCache_OpenCircuitMarksProviderUnhealthy asserts an open
+// circuit overrides a healthy cached probe in Get and Snapshot, and
+// surfaces a provider the sweep hasn't reached yet.
+func TestCache_OpenCircuitMarksProviderUnhealthy(t *testing.T) {
+	t.Parallel()
+
+	registry := provider.NewRegistry()
+	registry.Register("alpha", &fakeProvider{name: "alpha", healthy: true})
+
+	cache := NewCache(registry, Config{PollInterval: time.Hour, CheckTimeout: 100 * time.Millisecond})
+	cache.CheckNow(context.Background())
+
+	circuits := fakeCircuits{"alpha": "circuit open", "beta": "circuit open"}
+	cache.SetCircuits(circuits)
+
+	if status, ok := cache.Get("alpha"); !ok || status.Healthy || status.Message != "circuit open" {
+		t.Fatalf("alpha: ok=%v status=%+v, want unhealthy with circuit message", ok, status)
+	}
+
+	if status, ok := cache.Get("beta"); !ok || status.Healthy {
+		t.Fatalf("beta (never swept): ok=%v status=%+v, want unhealthy", ok, status)
+	}
+
+	snap := cache.Snapshot()
+	if len(snap) != 1 || snap[0].Healthy {
+		t.Fatalf("snapshot = %+v, want alpha unhealthy", snap)
+	}
+
+	delete(circuits, "alpha")
+
+	if status, _ := cache.Get("alpha"); !status.Healthy {
+		t.Fatalf("alpha after circuit closed: %+v, want the cached healthy probe", status)
+	}
+}
+
 // --- fakes ---
 
+// fakeCircuits maps provider names with an open circuit to the
+// reason.
+type fakeCircuits map[string]string
+
+func (f fakeCircuits) CircuitOpen(name string) (string, bool) {
+	reason, ok := f[name]
+
+	return reason, ok
+}
+
 type fakeProvider struct {
 	name    string
 	healthy bool
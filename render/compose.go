@@ -0,0 +1,648 @@
+package render
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/provider"
+	"github.com/xraph/ctrlplane/vars"
+)
+
+// Compose depends_on conditions. A service another one waits on with
+// composeCompleted is a one-shot job and becomes an Init service.
+const (
+	composeStarted   = "service_started"
+	composeCompleted = "service_completed_successfully"
+)
+
+// composeRoleKey is the service-level extension field that sets a
+// compose service's Role explicitly (main | sidecar | init).
+const composeRoleKey = "x-ctrlplane-role"
+
+// composeHTTPProbe finds a local HTTP URL in a healthcheck command, e.g.
+// `curl -f http://localhost:8080/healthz`, so the check can become an
+// HTTP HealthCheckSpec.
+var composeHTTPProbe = regexp.MustCompile(`https?://(?:localhost|127\.0\.0\.1|0\.0\.0\.0)(?::(\d+))?(/[^\s'"]*)?`)
+
+// renderCompose templates a compose file as a whole, like inline
+// manifests, then translates its services into ServiceSpecs (see
+// composeServiceSpecs). Compose's own ${VAR} interpolation is not
+// performed; use template variables instead.
+func renderCompose(src *provider.ComposeSource, scope vars.Scope) ([]provider.ServiceSpec, error) {
+	rendered, err := tmplString(src.File, scope)
+	if err != nil {
+		return nil, fmt.Errorf("compose file: %w", err)
+	}
+
+	var file composeFile
+	if err := yaml.Unmarshal([]byte(rendered), &file); err != nil {
+		return nil, fmt.Errorf("%w: compose file: %w", ctrlplane.ErrInvalidSource, err)
+	}
+
+	return composeServiceSpecs(file.Services)
+}
+
+// composeFile is the subset of the Compose specification ctrlplane
+// reads. Top-level networks, volumes, configs and secrets are ignored:
+// services share a network within an instance already, and named
+// volumes are declared by the services that mount them.
+type composeFile struct {
+	Services composeServices `yaml:"services"`
+}
+
+// composeService is one entry under services. Keys without a
+// ServiceSpec equivalent (build, restart, networks, labels, …) are
+// ignored.
+type composeService struct {
+	name string
+
+	Image       string              `yaml:"image"`
+	Entrypoint  composeCommand      `yaml:"entrypoint"`
+	Command     composeCommand      `yaml:"command"`
+	Environment composeEnv          `yaml:"environment"`
+	Ports       []composePort       `yaml:"ports"`
+	Volumes     []composeVolume     `yaml:"volumes"`
+	DependsOn   composeDependsOn    `yaml:"depends_on"`
+	Healthcheck *composeHealthcheck `yaml:"healthcheck"`
+	Deploy      composeDeploy       `yaml:"deploy"`
+	CPUs        string              `yaml:"cpus"`
+	MemLimit    string              `yaml:"mem_limit"`
+	Role        string              `yaml:"x-ctrlplane-role"`
+}
+
+// composeServices keeps services in file order, which picks the Main
+// service when none is marked explicitly.
+type composeServices []composeService
+
+func (s *composeServices) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return errors.New("services must be a mapping")
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		svc := composeService{name: node.Content[i].Value}
+		if err := node.Content[i+1].Decode(&svc); err != nil {
+			return fmt.Errorf("service %s: %w", svc.name, err)
+		}
+
+		*s = append(*s, svc)
+	}
+
+	return nil
+}
+
+// composeCommand is a command in either the list form or the string
+// form, which compose splits like a shell would.
+type composeCommand []string
+
+func (c *composeCommand) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		words, err := splitShellWords(node.Value)
+		if err != nil {
+			return err
+		}
+
+		*c = words
+
+		return nil
+	}
+
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+
+	*c = list
+
+	return nil
+}
+
+// composeEnv is environment in either the map form or the KEY=VALUE
+// list form. A variable without a value would be read from the shell
+// running compose; there is no such shell here, so it's an error.
+type composeEnv map[string]string
+
+func (e *composeEnv) UnmarshalYAML(node *yaml.Node) error {
+	env := make(composeEnv)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, val := node.Content[i].Value, node.Content[i+1]
+			if val.Tag == "!!null" {
+				return fmt.Errorf("environment %s has no value", key)
+			}
+
+			env[key] = val.Value
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, val, ok := strings.Cut(item.Value, "=")
+			if !ok {
+				return fmt.Errorf("environment %s has no value", key)
+			}
+
+			env[key] = val
+		}
+	default:
+		return errors.New("environment must be a mapping or a list")
+	}
+
+	*e = env
+
+	return nil
+}
+
+// composePort is a port in the short syntax ("[ip:][host:]container
+// [/protocol]") or the long syntax.
+type composePort provider.PortSpec
+
+func (p *composePort) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		spec, err := parseComposePort(node.Value)
+		if err != nil {
+			return err
+		}
+
+		*p = composePort(spec)
+
+		return nil
+	}
+
+	var long struct {
+		Target    int    `yaml:"target"`
+		Published string `yaml:"published"`
+		Protocol  string `yaml:"protocol"`
+	}
+
+	if err := node.Decode(&long); err != nil {
+		return err
+	}
+
+	if long.Target == 0 {
+		return errors.New("port requires a target")
+	}
+
+	host, err := composePortNumber(long.Published)
+	if err != nil {
+		return err
+	}
+
+	*p = composePort{Container: long.Target, Host: host, Protocol: composeProtocol(long.Protocol)}
+
+	return nil
+}
+
+func parseComposePort(s string) (provider.PortSpec, error) {
+	s, protocol, _ := strings.Cut(s, "/")
+	parts := strings.Split(s, ":")
+
+	container, err := composePortNumber(parts[len(parts)-1])
+	if err != nil {
+		return provider.PortSpec{}, err
+	}
+
+	if container == 0 {
+		return provider.PortSpec{}, fmt.Errorf("port %q requires a container port", s)
+	}
+
+	var host int
+	if len(parts) > 1 {
+		// The host IP of "ip:host:container" has no equivalent; the
+		// provider decides what a published port binds to.
+		if host, err = composePortNumber(parts[len(parts)-2]); err != nil {
+			return provider.PortSpec{}, err
+		}
+	}
+
+	return provider.PortSpec{Container: container, Host: host, Protocol: composeProtocol(protocol)}, nil
+}
+
+// composePortNumber parses one port number. Empty is zero; ranges are
+// not supported.
+func composePortNumber(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	if strings.Contains(s, "-") {
+		return 0, fmt.Errorf("port range %q is not supported", s)
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+
+	return n, nil
+}
+
+func composeProtocol(s string) string {
+	if s == "" {
+		return "tcp"
+	}
+
+	return strings.ToLower(s)
+}
+
+// composeVolume is a named volume mount in the short syntax
+// ("name:/path[:mode]") or the long syntax. Bind mounts reference the
+// host running compose and tmpfs mounts have no equivalent, so both
+// are rejected, as are anonymous volumes. Read-only modes are dropped.
+type composeVolume provider.VolumeSpec
+
+func (v *composeVolume) UnmarshalYAML(node *yaml.Node) error {
+	var typ, source, target string
+
+	if node.Kind == yaml.ScalarNode {
+		parts := strings.Split(node.Value, ":")
+		if len(parts) > 1 {
+			source, target = parts[0], parts[1]
+		} else {
+			target = parts[0]
+		}
+	} else {
+		var long struct {
+			Type   string `yaml:"type"`
+			Source string `yaml:"source"`
+			Target string `yaml:"target"`
+		}
+
+		if err := node.Decode(&long); err != nil {
+			return err
+		}
+
+		typ, source, target = long.Type, long.Source, long.Target
+	}
+
+	switch {
+	case typ == "bind" || strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~"):
+		return fmt.Errorf("bind mount %s is not supported; use a named volume", target)
+	case typ != "" && typ != "volume":
+		return fmt.Errorf("%s mount %s is not supported; use a named volume", typ, target)
+	case source == "":
+		return fmt.Errorf("anonymous volume %s is not supported; name it", target)
+	case !strings.HasPrefix(target, "/"):
+		return fmt.Errorf("volume %s requires an absolute target path", source)
+	}
+
+	*v = composeVolume{Name: source, MountPath: target}
+
+	return nil
+}
+
+// composeDependency is one depends_on entry.
+type composeDependency struct {
+	name      string
+	condition string
+}
+
+// composeDependsOn is depends_on in the list form (every condition is
+// service_started) or the map form.
+type composeDependsOn []composeDependency
+
+func (d *composeDependsOn) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			*d = append(*d, composeDependency{name: item.Value, condition: composeStarted})
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			var opts struct {
+				Condition string `yaml:"condition"`
+			}
+
+			if err := node.Content[i+1].Decode(&opts); err != nil {
+				return err
+			}
+
+			if opts.Condition == "" {
+				opts.Condition = composeStarted
+			}
+
+			*d = append(*d, composeDependency{name: node.Content[i].Value, condition: opts.Condition})
+		}
+	default:
+		return errors.New("depends_on must be a mapping or a list")
+	}
+
+	return nil
+}
+
+type composeHealthcheck struct {
+	Test     composeCommand `yaml:"test"`
+	Interval string         `yaml:"interval"`
+	Timeout  string         `yaml:"timeout"`
+	Retries  int            `yaml:"retries"`
+	Disable  bool           `yaml:"disable"`
+}
+
+type composeDeploy struct {
+	Replicas  *int `yaml:"replicas"`
+	Resources struct {
+		Limits struct {
+			CPUs   string `yaml:"cpus"`
+			Memory string `yaml:"memory"`
+		} `yaml:"limits"`
+	} `yaml:"resources"`
+}
+
+// composeServiceSpecs translates compose services into ServiceSpecs,
+// in file order:
+//
+//   - entrypoint and command become Command and Args.
+//   - A service another waits on with service_completed_successfully
+//     is a one-shot job and becomes an Init service. The first other
+//     service becomes Main and the rest Sidecars. x-ctrlplane-role
+//     overrides both.
+//   - depends_on becomes DependsOn, whatever the condition.
+//   - A healthcheck probing a local HTTP URL becomes an HTTP check on
+//     that port and path; any other test becomes a TCP check on the
+//     first port, as there's no portable way to run the command.
+//   - deploy.replicas and the CPU and memory limits (deploy or the
+//     legacy cpus / mem_limit keys) become Resources.
+func composeServiceSpecs(services composeServices) ([]provider.ServiceSpec, error) {
+	if len(services) == 0 {
+		return nil, fmt.Errorf("%w: compose file has no services", ctrlplane.ErrInvalidSource)
+	}
+
+	roles, err := composeRoles(services)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]provider.ServiceSpec, 0, len(services))
+
+	for _, svc := range services {
+		spec, err := composeServiceSpec(svc, roles[svc.name])
+		if err != nil {
+			return nil, fmt.Errorf("%w: compose service %s: %w", ctrlplane.ErrInvalidSource, svc.name, err)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// composeRoles assigns every service its Role (see
+// composeServiceSpecs) and checks depends_on names resolve.
+func composeRoles(services composeServices) (map[string]provider.ServiceRole, error) {
+	roles := make(map[string]provider.ServiceRole, len(services))
+	for _, svc := range services {
+		roles[svc.name] = ""
+	}
+
+	for _, svc := range services {
+		for _, dep := range svc.DependsOn {
+			if _, ok := roles[dep.name]; !ok {
+				return nil, fmt.Errorf("%w: compose service %s depends on unknown service %s", ctrlplane.ErrInvalidSource, svc.name, dep.name)
+			}
+
+			if dep.condition == composeCompleted {
+				roles[dep.name] = provider.RoleInit
+			}
+		}
+	}
+
+	haveMain := false
+
+	for _, svc := range services {
+		switch role := provider.ServiceRole(svc.Role); role {
+		case "":
+		case provider.RoleMain, provider.RoleSidecar, provider.RoleInit:
+			if role == provider.RoleMain {
+				if haveMain {
+					return nil, fmt.Errorf("%w: compose file marks more than one service %s: main", ctrlplane.ErrInvalidSource, composeRoleKey)
+				}
+
+				haveMain = true
+			}
+
+			roles[svc.name] = role
+		default:
+			return nil, fmt.Errorf("%w: compose service %s: unknown %s %q", ctrlplane.ErrInvalidSource, svc.name, composeRoleKey, svc.Role)
+		}
+	}
+
+	for _, svc := range services {
+		if roles[svc.name] != "" {
+			continue
+		}
+
+		if haveMain {
+			roles[svc.name] = provider.RoleSidecar
+
+			continue
+		}
+
+		roles[svc.name] = provider.RoleMain
+		haveMain = true
+	}
+
+	if !haveMain {
+		return nil, fmt.Errorf("%w: compose file has no long-lived service to run as main", ctrlplane.ErrInvalidSource)
+	}
+
+	return roles, nil
+}
+
+func composeServiceSpec(svc composeService, role provider.ServiceRole) (provider.ServiceSpec, error) {
+	if svc.Image == "" {
+		return provider.ServiceSpec{}, errors.New("image is required (build is not supported)")
+	}
+
+	spec := provider.ServiceSpec{
+		Name:    svc.name,
+		Image:   svc.Image,
+		Role:    role,
+		Command: svc.Entrypoint,
+		Args:    svc.Command,
+	}
+
+	if len(svc.Environment) > 0 {
+		spec.Env = svc.Environment
+	}
+
+	for _, p := range svc.Ports {
+		spec.Ports = append(spec.Ports, provider.PortSpec(p))
+	}
+
+	for _, v := range svc.Volumes {
+		spec.Volumes = append(spec.Volumes, provider.VolumeSpec(v))
+	}
+
+	for _, dep := range svc.DependsOn {
+		spec.DependsOn = append(spec.DependsOn, dep.name)
+	}
+
+	hc, err := composeHealthCheck(svc.Healthcheck, spec.Ports)
+	if err != nil {
+		return provider.ServiceSpec{}, err
+	}
+
+	spec.HealthCheck = hc
+
+	if svc.Deploy.Replicas != nil {
+		spec.Resources.Replicas = *svc.Deploy.Replicas
+	}
+
+	cpus := cmp.Or(svc.Deploy.Resources.Limits.CPUs, svc.CPUs)
+	if cpus != "" {
+		f, err := strconv.ParseFloat(cpus, 64)
+		if err != nil || f < 0 {
+			return provider.ServiceSpec{}, fmt.Errorf("invalid cpus %q", cpus)
+		}
+
+		spec.Resources.CPUMillis = int(math.Ceil(f * 1000))
+	}
+
+	memory := cmp.Or(svc.Deploy.Resources.Limits.Memory, svc.MemLimit)
+	if memory != "" {
+		mb, err := composeMemoryMB(memory)
+		if err != nil {
+			return provider.ServiceSpec{}, err
+		}
+
+		spec.Resources.MemoryMB = mb
+	}
+
+	return spec, nil
+}
+
+// composeHealthCheck maps a compose healthcheck (see
+// composeServiceSpecs). A disabled check, a NONE test or a non-HTTP
+// test on a service without ports maps to none.
+func composeHealthCheck(hc *composeHealthcheck, ports []provider.PortSpec) (*provider.HealthCheckSpec, error) {
+	if hc == nil || hc.Disable || len(hc.Test) == 0 || hc.Test[0] == "NONE" {
+		return nil, nil //nolint:nilnil // no check is a valid result
+	}
+
+	spec := &provider.HealthCheckSpec{Retries: hc.Retries}
+
+	if m := composeHTTPProbe.FindStringSubmatch(strings.Join(hc.Test, " ")); m != nil {
+		spec.Port = 80
+		if m[1] != "" {
+			spec.Port, _ = strconv.Atoi(m[1])
+		}
+
+		spec.Path = cmp.Or(m[2], "/")
+	} else {
+		if len(ports) == 0 {
+			return nil, nil //nolint:nilnil // no probe-able equivalent
+		}
+
+		spec.Port = ports[0].Container
+	}
+
+	var err error
+
+	if spec.Interval, err = composeDuration(hc.Interval); err != nil {
+		return nil, fmt.Errorf("healthcheck interval: %w", err)
+	}
+
+	if spec.Timeout, err = composeDuration(hc.Timeout); err != nil {
+		return nil, fmt.Errorf("healthcheck timeout: %w", err)
+	}
+
+	return spec, nil
+}
+
+func composeDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// composeMemoryMB parses a compose byte value ("512m", "1gb", "1048576")
+// into MiB, rounding up.
+func composeMemoryMB(s string) (int, error) {
+	v := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "b")
+
+	unit := 1.0
+
+	switch {
+	case strings.HasSuffix(v, "k"):
+		unit = 1 << 10
+	case strings.HasSuffix(v, "m"):
+		unit = 1 << 20
+	case strings.HasSuffix(v, "g"):
+		unit = 1 << 30
+	}
+
+	if unit != 1 {
+		v = v[:len(v)-1]
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid memory %q", s)
+	}
+
+	return int(math.Ceil(n * unit / (1 << 20))), nil
+}
+
+// splitShellWords splits a command string the way compose does:
+// on unquoted whitespace, with single quotes, double quotes and
+// backslash escapes.
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
@@ -0,0 +1,206 @@
+package render
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+func TestRender_Compose(t *testing.T) {
+	file := `services:
+  web:
+    image: registry.example.com/web:{{ .var.tag }}
+    command: serve --addr ":8080" --region {{ .region }}
+    environment:
+      TENANT: "{{ .tenant.id }}"
+      WORKERS: 4
+    ports:
+      - "443:8080"
+      - 9090/udp
+      - target: 7000
+        published: 7001
+    volumes:
+      - data:/var/lib/web
+    depends_on:
+      migrate:
+        condition: service_completed_successfully
+      cache:
+        condition: service_healthy
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost:8080/healthz"]
+      interval: 10s
+      timeout: 2s
+      retries: 3
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 512M
+  cache:
+    image: redis:7
+    entrypoint: ["redis-server"]
+    environment:
+      - MAXMEMORY=64mb
+    ports: ["6379"]
+    healthcheck:
+      test: redis-cli ping
+  migrate:
+    image: registry.example.com/web:{{ .var.tag }}
+    command: ["migrate", "up"]
+    restart: "no"
+volumes:
+  data: {}
+`
+
+	src := provider.DeploymentSource{Type: provider.SourceCompose, Compose: &provider.ComposeSource{File: file}}
+
+	out, err := Render(src, scopeWith(map[string]any{"tag": "1.4"}))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if out.Type != provider.SourceServices || len(out.Services) != 3 {
+		t.Fatalf("unexpected output: %+v", out)
+	}
+
+	web, cache, migrate := out.Services[0], out.Services[1], out.Services[2]
+
+	if web.Name != "web" || web.Role != provider.RoleMain || web.Image != "registry.example.com/web:1.4" {
+		t.Errorf("web = %s/%s/%s", web.Name, web.Role, web.Image)
+	}
+
+	if !slices.Equal(web.Args, []string{"serve", "--addr", ":8080", "--region", "us-east"}) || web.Command != nil {
+		t.Errorf("web command/args = %q/%q", web.Command, web.Args)
+	}
+
+	if web.Env["TENANT"] != "tnt_1" || web.Env["WORKERS"] != "4" {
+		t.Errorf("web env = %v", web.Env)
+	}
+
+	wantPorts := []provider.PortSpec{
+		{Container: 8080, Host: 443, Protocol: "tcp"},
+		{Container: 9090, Protocol: "udp"},
+		{Container: 7000, Host: 7001, Protocol: "tcp"},
+	}
+	if !slices.Equal(web.Ports, wantPorts) {
+		t.Errorf("web ports = %+v, want %+v", web.Ports, wantPorts)
+	}
+
+	if len(web.Volumes) != 1 || web.Volumes[0] != (provider.VolumeSpec{Name: "data", MountPath: "/var/lib/web"}) {
+		t.Errorf("web volumes = %+v", web.Volumes)
+	}
+
+	if !slices.Equal(web.DependsOn, []string{"migrate", "cache"}) {
+		t.Errorf("web depends_on = %v", web.DependsOn)
+	}
+
+	wantHC := provider.HealthCheckSpec{Path: "/healthz", Port: 8080, Interval: 10 * time.Second, Timeout: 2 * time.Second, Retries: 3}
+	if web.HealthCheck == nil || *web.HealthCheck != wantHC {
+		t.Errorf("web health check = %+v, want %+v", web.HealthCheck, wantHC)
+	}
+
+	if web.Resources != (provider.ResourceSpec{CPUMillis: 500, MemoryMB: 512, Replicas: 2}) {
+		t.Errorf("web resources = %+v", web.Resources)
+	}
+
+	if cache.Role != provider.RoleSidecar || !slices.Equal(cache.Command, []string{"redis-server"}) || cache.Env["MAXMEMORY"] != "64mb" {
+		t.Errorf("cache = %+v", cache)
+	}
+
+	if cache.HealthCheck == nil || cache.HealthCheck.Path != "" || cache.HealthCheck.Port != 6379 {
+		t.Errorf("cache health check = %+v, want a TCP check on 6379", cache.HealthCheck)
+	}
+
+	if migrate.Role != provider.RoleInit || !slices.Equal(migrate.Args, []string{"migrate", "up"}) {
+		t.Errorf("migrate = %+v", migrate)
+	}
+}
+
+func TestRender_ComposeExplicitRole(t *testing.T) {
+	file := `services:
+  proxy:
+    image: envoy:1
+  app:
+    image: app:1
+    x-ctrlplane-role: main
+`
+
+	out, err := Render(provider.DeploymentSource{Type: provider.SourceCompose, Compose: &provider.ComposeSource{File: file}}, scopeWith(nil))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if out.Services[0].Role != provider.RoleSidecar || out.Services[1].Role != provider.RoleMain {
+		t.Errorf("roles = %s/%s, want sidecar/main", out.Services[0].Role, out.Services[1].Role)
+	}
+}
+
+func TestRender_ComposeRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"no services", "services: {}\n"},
+		{"build only", "services:\n  web:\n    build: .\n"},
+		{"bind mount", "services:\n  web:\n    image: a\n    volumes: [\"./src:/app\"]\n"},
+		{"anonymous volume", "services:\n  web:\n    image: a\n    volumes: [\"/data\"]\n"},
+		{"port range", "services:\n  web:\n    image: a\n    ports: [\"8000-8010:8000-8010\"]\n"},
+		{"host env", "services:\n  web:\n    image: a\n    environment: [HOME]\n"},
+		{"unknown dependency", "services:\n  web:\n    image: a\n    depends_on: [db]\n"},
+		{"two mains", "services:\n  a:\n    image: a\n    x-ctrlplane-role: main\n  b:\n    image: b\n    x-ctrlplane-role: main\n"},
+		{"only init", "services:\n  a:\n    image: a\n    x-ctrlplane-role: init\n"},
+		{"bad yaml", "services: [\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render(provider.DeploymentSource{Type: provider.SourceCompose, Compose: &provider.ComposeSource{File: tt.file}}, scopeWith(nil))
+			if !errors.Is(err, ctrlplane.ErrInvalidSource) {
+				t.Errorf("err = %v, want ErrInvalidSource", err)
+			}
+		})
+	}
+}
+
+func TestComposeMemoryMB(t *testing.T) {
+	tests := map[string]int{
+		"512m":    512,
+		"512M":    512,
+		"1gb":     1024,
+		"1.5g":    1536,
+		"1048576": 1,
+		"100k":    1,
+	}
+
+	for in, want := range tests {
+		got, err := composeMemoryMB(in)
+		if err != nil || got != want {
+			t.Errorf("composeMemoryMB(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+
+	if _, err := composeMemoryMB("lots"); err == nil {
+		t.Error("composeMemoryMB(lots) succeeded")
+	}
+}
+
+func TestSplitShellWords(t *testing.T) {
+	got, err := splitShellWords(`sh -c 'echo "hi there"' a\ b "x\"y"`)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+
+	want := []string{"sh", "-c", `echo "hi there"`, "a b", `x"y`}
+	if !slices.Equal(got, want) {
+		t.Errorf("split = %q, want %q", got, want)
+	}
+
+	if _, err := splitShellWords(`echo "open`); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
@@ -1,7 +1,8 @@
 // Package render resolves a provider.DeploymentSource against a resolved
 // variable scope into a concrete provider.RenderedSource the provider can
-// apply. It templates services, helm values, manifest YAML, and argocd
-// fields with Go text/template, and builds kustomize sources in memory.
+// apply. It templates services, helm values, manifest YAML, compose files
+// and argocd fields with Go text/template, builds kustomize sources in
+// memory, and translates compose services into ServiceSpecs.
 //
 // Secret-typed variables are excluded from the scope by the vars resolver,
 // so any inline reference to one ({{ .var.<secret> }}) fails with a missing
@@ -12,7 +12,8 @@ import (
 
 // Render resolves a DeploymentSource against a variable scope into a
 // concrete RenderedSource the provider can apply. The source's Type
-// selects which payload is rendered.
+// selects which payload is rendered. A compose source renders to a
+// services source: providers only ever see the ServiceSpec model.
 func Render(src provider.DeploymentSource, scope vars.Scope) (provider.RenderedSource, error) {
 	switch src.Type {
 	case provider.SourceServices:
@@ -43,6 +44,13 @@ func Render(src provider.DeploymentSource, scope vars.Scope) (provider.RenderedS
 		}
 
 		return provider.RenderedSource{Type: provider.SourceArgoCD, ArgoCD: argo}, nil
+	case provider.SourceCompose:
+		services, err := renderCompose(src.Compose, scope)
+		if err != nil {
+			return provider.RenderedSource{}, err
+		}
+
+		return provider.RenderedSource{Type: provider.SourceServices, Services: services}, nil
 	default:
 		return provider.RenderedSource{}, fmt.Errorf("%w: %q", ctrlplane.ErrUnsupportedSource, src.Type)
 	}
@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/id"
+)
+
+// RegistryCredential is the value of a SecretRegistry secret: login
+// details for one container registry. Stored in the vault as JSON,
+//
+//	{"server": "ghcr.io", "username": "bot", "password": "..."}
+//
+// and handed to providers so they can pull private images. Never
+// serialised back out of a provider request.
+type RegistryCredential struct {
+	// Server is the registry host, e.g. "ghcr.io" or
+	// "registry.example.com:5000". Docker Hub is "docker.io".
+	Server   string `json:"server"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+}
+
+// ParseRegistryCredential decodes and validates a SecretRegistry
+// value. Server, Username and Password are required.
+func ParseRegistryCredential(value []byte) (*RegistryCredential, error) {
+	var cred RegistryCredential
+	if err := json.Unmarshal(value, &cred); err != nil {
+		return nil, fmt.Errorf("registry credential: %w", err)
+	}
+
+	if cred.Server == "" || cred.Username == "" || cred.Password == "" {
+		return nil, errors.New("registry credential: server, username and password are required")
+	}
+
+	return &cred, nil
+}
+
+// ResolveRegistry returns the registry credential stored under key for
+// an instance. The secret must be of type SecretRegistry.
+func (s *service) ResolveRegistry(ctx context.Context, instanceID id.ID, key string) (*RegistryCredential, error) {
+	claims, err := auth.RequireClaims(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolve registry secret: %w", err)
+	}
+
+	secret, err := s.store.GetSecretByKey(ctx, claims.TenantID, instanceID, key)
+	if err != nil {
+		return nil, fmt.Errorf("resolve registry secret %q: %w", key, err)
+	}
+
+	if secret.Type != SecretRegistry {
+		return nil, fmt.Errorf("resolve registry secret %q: type is %q, not %q", key, secret.Type, SecretRegistry)
+	}
+
+	value, err := s.vault.Retrieve(ctx, fmt.Sprintf("%s/%s/%s", claims.TenantID, instanceID, key))
+	if err != nil {
+		return nil, fmt.Errorf("resolve registry secret %q: retrieve: %w", key, err)
+	}
+
+	cred, err := ParseRegistryCredential(value)
+	if err != nil {
+		return nil, fmt.Errorf("resolve registry secret %q: %w", key, err)
+	}
+
+	return cred, nil
+}
@@ -22,6 +22,10 @@ type Service interface {
 
 	// Inject resolves all env-type secrets for an instance into a key-value map.
 	Inject(ctx context.Context, instanceID id.ID) (map[string]string, error)
+
+	// ResolveRegistry resolves a registry-type secret into the
+	// credential providers use to pull private images.
+	ResolveRegistry(ctx context.Context, instanceID id.ID, key string) (*RegistryCredential, error)
 }
 
 // SetRequest holds the parameters for creating or updating a secret.
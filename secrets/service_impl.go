@@ -42,6 +42,15 @@ func (s *service) Set(ctx context.Context, req SetRequest) (*Secret, error) {
 
 	// Try to find an existing secret with this key.
 	existing, err := s.store.GetSecretByKey(ctx, claims.TenantID, req.InstanceID, req.Key)
+
+	// Registry credentials are parsed at pull time; reject a malformed
+	// one now rather than on the next deploy.
+	if req.Type == SecretRegistry || (err == nil && existing != nil && existing.Type == SecretRegistry) {
+		if _, parseErr := ParseRegistryCredential([]byte(req.Value)); parseErr != nil {
+			return nil, fmt.Errorf("set secret: %w", parseErr)
+		}
+	}
+
 	if err == nil && existing != nil {
 		// Update existing secret.
 		existing.Version++
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/dgraph-io/badger/v4"
 
@@ -231,3 +232,117 @@ func (s *Store) NextReleaseVersion(_ context.Context, tenantID string, instanceI
 
 	return nextVersion, nil
 }
+
+func (s *Store) EnqueueJob(_ context.Context, j *deploy.Job) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		key := prefixDeployJob + idStr(j.DeploymentID)
+
+		exists, err := s.exists(txn, key)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrAlreadyExists, j.DeploymentID)
+		}
+
+		return s.set(txn, key, j)
+	})
+}
+
+// ClaimJob picks and leases the job in one transaction; a concurrent
+// claim of the same job makes one of them fail with a conflict.
+func (s *Store) ClaimJob(_ context.Context, owner string, lease time.Duration) (*deploy.Job, error) {
+	var claimed *deploy.Job
+
+	err := s.db.Update(func(txn *badger.Txn) error {
+		t := now()
+
+		err := s.iterate(txn, prefixDeployJob, func(_ string, val []byte) error {
+			var j deploy.Job
+			if err := json.Unmarshal(val, &j); err != nil {
+				return fmt.Errorf("badger: json unmarshal failed: %w", err)
+			}
+
+			if j.Claimable(t) && (claimed == nil || j.CreatedAt.Before(claimed.CreatedAt)) {
+				claimed = &j
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if claimed == nil {
+			return fmt.Errorf("%w: claimable deploy job", ctrlplane.ErrNotFound)
+		}
+
+		until := t.Add(lease)
+		claimed.Owner = owner
+		claimed.Attempts++
+		claimed.LeaseExpiresAt = &until
+
+		return s.set(txn, prefixDeployJob+idStr(claimed.DeploymentID), claimed)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+func (s *Store) RenewJob(_ context.Context, j *deploy.Job, lease time.Duration) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		key := prefixDeployJob + idStr(j.DeploymentID)
+
+		var held deploy.Job
+		if err := s.get(txn, key, &held); err != nil {
+			return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, j.DeploymentID)
+		}
+
+		if held.Owner != j.Owner || held.Attempts != j.Attempts {
+			return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, j.DeploymentID)
+		}
+
+		until := now().Add(lease)
+		held.LeaseExpiresAt = &until
+
+		if err := s.set(txn, key, &held); err != nil {
+			return err
+		}
+
+		j.CancelRequested = held.CancelRequested
+		j.CancelRollback = held.CancelRollback
+		j.CancelledBy = held.CancelledBy
+
+		return nil
+	})
+}
+
+func (s *Store) CancelJob(_ context.Context, deploymentID id.ID, rollback bool, by string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		key := prefixDeployJob + idStr(deploymentID)
+
+		var held deploy.Job
+		if err := s.get(txn, key, &held); err != nil {
+			return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, deploymentID)
+		}
+
+		if rollback && held.IsRollback() {
+			return fmt.Errorf("%w: deploy job %s runs a rollback, which can't be rolled back", ctrlplane.ErrInvalidConfig, deploymentID)
+		}
+
+		held.CancelRequested = true
+		held.CancelRollback = rollback
+		held.CancelledBy = by
+
+		return s.set(txn, key, &held)
+	})
+}
+
+func (s *Store) DeleteJob(_ context.Context, deploymentID id.ID) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return s.delete(txn, prefixDeployJob+idStr(deploymentID))
+	})
+}
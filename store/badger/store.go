@@ -34,6 +34,7 @@ const (
 	prefixTenantSlug     = "tslg:"
 	prefixReleaseVersion = "rlvr:"
 	prefixTemplate       = "tmpl:"
+	prefixDeployJob      = "djob:"
 )
 
 // Config holds the configuration for the Badger store.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/deploy"
@@ -171,3 +172,98 @@ func (s *Store) NextReleaseVersion(_ context.Context, tenantID string, instanceI
 
 	return maxVersion + 1, nil
 }
+
+func (s *Store) EnqueueJob(_ context.Context, j *deploy.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := idStr(j.DeploymentID)
+	if _, exists := s.deployJobs[key]; exists {
+		return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrAlreadyExists, key)
+	}
+
+	clone := *j
+	s.deployJobs[key] = &clone
+
+	return nil
+}
+
+func (s *Store) ClaimJob(_ context.Context, owner string, lease time.Duration) (*deploy.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+
+	var oldest *deploy.Job
+
+	for _, j := range s.deployJobs {
+		if j.Claimable(t) && (oldest == nil || j.CreatedAt.Before(oldest.CreatedAt)) {
+			oldest = j
+		}
+	}
+
+	if oldest == nil {
+		return nil, fmt.Errorf("%w: claimable deploy job", ctrlplane.ErrNotFound)
+	}
+
+	until := t.Add(lease)
+	oldest.Owner = owner
+	oldest.Attempts++
+	oldest.LeaseExpiresAt = &until
+
+	clone := *oldest
+
+	return &clone, nil
+}
+
+func (s *Store) RenewJob(_ context.Context, j *deploy.Job, lease time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := idStr(j.DeploymentID)
+
+	held, ok := s.deployJobs[key]
+	if !ok || held.Owner != j.Owner || held.Attempts != j.Attempts {
+		return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, key)
+	}
+
+	until := now().Add(lease)
+	held.LeaseExpiresAt = &until
+
+	j.CancelRequested = held.CancelRequested
+	j.CancelRollback = held.CancelRollback
+	j.CancelledBy = held.CancelledBy
+
+	return nil
+}
+
+func (s *Store) CancelJob(_ context.Context, deploymentID id.ID, rollback bool, by string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := idStr(deploymentID)
+
+	held, ok := s.deployJobs[key]
+	if !ok {
+		return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, key)
+	}
+
+	if rollback && held.IsRollback() {
+		return fmt.Errorf("%w: deploy job %s runs a rollback, which can't be rolled back", ctrlplane.ErrInvalidConfig, key)
+	}
+
+	held.CancelRequested = true
+	held.CancelRollback = rollback
+	held.CancelledBy = by
+
+	return nil
+}
+
+func (s *Store) DeleteJob(_ context.Context, deploymentID id.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.deployJobs, idStr(deploymentID))
+
+	return nil
+}
@@ -27,6 +27,7 @@ type Store struct {
 	instances   map[string]*instance.Instance // keyed by ID string
 	deployments map[string]*deploy.Deployment
 	releases    map[string]*deploy.Release
+	deployJobs  map[string]*deploy.Job // keyed by deployment ID string
 
 	healthChecks  map[string]*health.HealthCheck
 	healthResults map[string][]health.HealthResult // keyed by check ID string
@@ -59,6 +60,7 @@ func New() *Store {
 		instances:     make(map[string]*instance.Instance),
 		deployments:   make(map[string]*deploy.Deployment),
 		releases:      make(map[string]*deploy.Release),
+		deployJobs:    make(map[string]*deploy.Job),
 		healthChecks:  make(map[string]*health.HealthCheck),
 		healthResults: make(map[string][]health.HealthResult),
 		domains:       make(map[string]*network.Domain),
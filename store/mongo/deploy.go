@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 
@@ -190,3 +191,170 @@ func (s *Store) NextReleaseVersion(ctx context.Context, tenantID string, instanc
 
 	return model.Version + 1, nil
 }
+
+func (s *Store) EnqueueJob(ctx context.Context, j *deploy.Job) error {
+	_, err := s.mdb.NewInsert(toDeployJobModel(j)).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("mongo: insert deploy job failed: %w", err)
+	}
+
+	return nil
+}
+
+// claimCandidates is how many claimable jobs ClaimJob tries before
+// giving up on a poll; each is lost only to a runner claiming it first.
+const claimCandidates = 8
+
+// claimableFilter matches jobs without a lease or with one expired at t.
+func claimableFilter(t time.Time) bson.A {
+	return bson.A{
+		bson.M{"lease_expires_at": nil},
+		bson.M{"lease_expires_at": bson.M{"$lte": t}},
+	}
+}
+
+func (s *Store) ClaimJob(ctx context.Context, owner string, lease time.Duration) (*deploy.Job, error) {
+	t := now()
+
+	var models []deployJobModel
+
+	err := s.mdb.NewFind(&models).
+		Filter(bson.M{"$or": claimableFilter(t)}).
+		Sort(bson.D{{Key: "created_at", Value: 1}}).
+		Limit(claimCandidates).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: list deploy jobs failed: %w", err)
+	}
+
+	for i := range models {
+		m := &models[i]
+		attempts := m.Attempts
+		until := t.Add(lease)
+
+		m.Owner = owner
+		m.Attempts++
+		m.LeaseExpiresAt = &until
+
+		// The claim holds only if nobody claimed or renewed the job
+		// since it was read.
+		res, err := s.mdb.NewUpdate(m).
+			Filter(bson.M{"_id": m.DeploymentID, "attempts": attempts, "cancel_requested": m.CancelRequested, "$or": claimableFilter(t)}).
+			Exec(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("mongo: claim deploy job failed: %w", err)
+		}
+
+		if res.MatchedCount() == 1 {
+			return fromDeployJobModel(m), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: claimable deploy job", ctrlplane.ErrNotFound)
+}
+
+func (s *Store) RenewJob(ctx context.Context, j *deploy.Job, lease time.Duration) error {
+	// The update only matches the document as read, so a cancellation
+	// requested in between isn't overwritten; it is read again.
+	for range 2 {
+		model, err := s.getHeldJob(ctx, j)
+		if err != nil {
+			return err
+		}
+
+		until := now().Add(lease)
+		model.LeaseExpiresAt = &until
+
+		res, err := s.mdb.NewUpdate(model).
+			Filter(bson.M{"_id": model.DeploymentID, "owner": model.Owner, "attempts": model.Attempts, "cancel_requested": model.CancelRequested}).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("mongo: renew deploy job failed: %w", err)
+		}
+
+		if res.MatchedCount() == 1 {
+			j.CancelRequested = model.CancelRequested
+			j.CancelRollback = model.CancelRollback
+			j.CancelledBy = model.CancelledBy
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, j.DeploymentID)
+}
+
+// getHeldJob reads the job j describes, returning ctrlplane.ErrNotFound
+// when it is gone or has been claimed again since.
+func (s *Store) getHeldJob(ctx context.Context, j *deploy.Job) (*deployJobModel, error) {
+	var model deployJobModel
+
+	err := s.mdb.NewFind(&model).
+		Filter(bson.M{"_id": idStr(j.DeploymentID)}).
+		Scan(ctx)
+	if err != nil {
+		if isNoDocuments(err) {
+			return nil, fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, j.DeploymentID)
+		}
+
+		return nil, fmt.Errorf("mongo: get deploy job failed: %w", err)
+	}
+
+	if model.Owner != j.Owner || model.Attempts != j.Attempts {
+		return nil, fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, j.DeploymentID)
+	}
+
+	return &model, nil
+}
+
+func (s *Store) CancelJob(ctx context.Context, deploymentID id.ID, rollback bool, by string) error {
+	// A claim between the read and the write makes the update match
+	// nothing; the job is read again.
+	for range 3 {
+		var model deployJobModel
+
+		err := s.mdb.NewFind(&model).
+			Filter(bson.M{"_id": idStr(deploymentID)}).
+			Scan(ctx)
+		if err != nil {
+			if isNoDocuments(err) {
+				return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, deploymentID)
+			}
+
+			return fmt.Errorf("mongo: get deploy job failed: %w", err)
+		}
+
+		if rollback && model.RollbackReleaseID != "" {
+			return fmt.Errorf("%w: deploy job %s runs a rollback, which can't be rolled back", ctrlplane.ErrInvalidConfig, deploymentID)
+		}
+
+		owner, attempts := model.Owner, model.Attempts
+		model.CancelRequested = true
+		model.CancelRollback = rollback
+		model.CancelledBy = by
+
+		res, err := s.mdb.NewUpdate(&model).
+			Filter(bson.M{"_id": model.DeploymentID, "owner": owner, "attempts": attempts}).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("mongo: cancel deploy job failed: %w", err)
+		}
+
+		if res.MatchedCount() == 1 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("mongo: cancel deploy job %s: it keeps being claimed", deploymentID)
+}
+
+func (s *Store) DeleteJob(ctx context.Context, deploymentID id.ID) error {
+	_, err := s.mdb.NewDelete((*deployJobModel)(nil)).
+		Filter(bson.M{"_id": idStr(deploymentID)}).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("mongo: delete deploy job failed: %w", err)
+	}
+
+	return nil
+}
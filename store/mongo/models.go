@@ -193,6 +193,11 @@ type deploymentModel struct {
 	Strategy        string                       `bson:"strategy"                   grove:"strategy"`
 	Services        []provider.ServiceDeploySpec `bson:"services,omitempty"         grove:"services"`
 	ServiceProgress map[string]string            `bson:"service_progress,omitempty" grove:"service_progress"`
+	Canary          *deploy.CanaryConfig         `bson:"canary,omitempty"           grove:"canary"`
+	BlueGreen       *deploy.BlueGreenConfig      `bson:"blue_green,omitempty"       grove:"blue_green"`
+	Bake            *deploy.BakeConfig           `bson:"bake,omitempty" grove:"bake"`
+	Phase           string                       `bson:"phase,omitempty"            grove:"phase"`
+	Percent         int                          `bson:"percent"                    grove:"percent"`
 	ProviderRef     string                       `bson:"provider_ref,omitempty"     grove:"provider_ref"`
 	Error           string                       `bson:"error,omitempty"            grove:"error"`
 	Initiator       string                       `bson:"initiator,omitempty"        grove:"initiator"`
@@ -212,6 +217,11 @@ func toDeploymentModel(d *deploy.Deployment) *deploymentModel {
 		Strategy:        d.Strategy,
 		Services:        d.Services,
 		ServiceProgress: d.ServiceProgress,
+		Canary:          d.Canary,
+		BlueGreen:       d.BlueGreen,
+		Bake:            d.Bake,
+		Phase:           d.Phase,
+		Percent:         d.Percent,
 		ProviderRef:     d.ProviderRef,
 		Error:           d.Error,
 		Initiator:       d.Initiator,
@@ -236,6 +246,11 @@ func fromDeploymentModel(m *deploymentModel) *deploy.Deployment {
 		Strategy:        m.Strategy,
 		Services:        m.Services,
 		ServiceProgress: m.ServiceProgress,
+		Canary:          m.Canary,
+		BlueGreen:       m.BlueGreen,
+		Bake:            m.Bake,
+		Phase:           m.Phase,
+		Percent:         m.Percent,
 		ProviderRef:     m.ProviderRef,
 		Error:           m.Error,
 		Initiator:       m.Initiator,
@@ -246,6 +261,77 @@ func fromDeploymentModel(m *deploymentModel) *deploy.Deployment {
 	return out
 }
 
+// ── Deploy job ──────────────────────────────────────────────────────────────
+
+type deployJobModel struct {
+	grove.BaseModel `grove:"table:cp_deploy_jobs"`
+
+	DeploymentID    string     `bson:"_id"              grove:"deployment_id,pk"`
+	TenantID        string     `bson:"tenant_id"        grove:"tenant_id"`
+	Owner           string     `bson:"owner"            grove:"owner"`
+	Attempts        int        `bson:"attempts"         grove:"attempts"`
+	LeaseExpiresAt  *time.Time `bson:"lease_expires_at" grove:"lease_expires_at"`
+	CancelRequested bool       `bson:"cancel_requested" grove:"cancel_requested"`
+	CancelRollback  bool       `bson:"cancel_rollback" grove:"cancel_rollback"`
+	CancelledBy     string     `bson:"cancelled_by" grove:"cancelled_by"`
+	CreatedAt       time.Time  `bson:"created_at"       grove:"created_at"`
+
+	RollbackReleaseID string `bson:"rollback_release_id" grove:"rollback_release_id"`
+	RollbackNative    bool   `bson:"rollback_native"     grove:"rollback_native"`
+	RollbackOf        string `bson:"rollback_of"         grove:"rollback_of"`
+	RollbackTrigger   string `bson:"rollback_trigger"    grove:"rollback_trigger"`
+	RollbackReason    string `bson:"rollback_reason"     grove:"rollback_reason"`
+}
+
+func toDeployJobModel(j *deploy.Job) *deployJobModel {
+	return &deployJobModel{
+		DeploymentID:    idStr(j.DeploymentID),
+		TenantID:        j.TenantID,
+		Owner:           j.Owner,
+		Attempts:        j.Attempts,
+		LeaseExpiresAt:  j.LeaseExpiresAt,
+		CancelRequested: j.CancelRequested,
+		CancelRollback:  j.CancelRollback,
+		CancelledBy:     j.CancelledBy,
+		CreatedAt:       j.CreatedAt,
+
+		RollbackReleaseID: idStr(j.RollbackReleaseID),
+		RollbackNative:    j.RollbackNative,
+		RollbackOf:        idStr(j.RollbackOf),
+		RollbackTrigger:   j.RollbackTrigger,
+		RollbackReason:    j.RollbackReason,
+	}
+}
+
+func fromDeployJobModel(m *deployJobModel) *deploy.Job {
+	return &deploy.Job{
+		DeploymentID:    id.MustParse(m.DeploymentID),
+		TenantID:        m.TenantID,
+		Owner:           m.Owner,
+		Attempts:        m.Attempts,
+		LeaseExpiresAt:  m.LeaseExpiresAt,
+		CancelRequested: m.CancelRequested,
+		CancelRollback:  m.CancelRollback,
+		CancelledBy:     m.CancelledBy,
+		CreatedAt:       m.CreatedAt,
+
+		RollbackReleaseID: optionalID(m.RollbackReleaseID),
+		RollbackNative:    m.RollbackNative,
+		RollbackOf:        optionalID(m.RollbackOf),
+		RollbackTrigger:   m.RollbackTrigger,
+		RollbackReason:    m.RollbackReason,
+	}
+}
+
+// optionalID parses an ID column that is empty for id.Nil.
+func optionalID(s string) id.ID {
+	if s == "" {
+		return id.Nil
+	}
+
+	return id.MustParse(s)
+}
+
 // ── Release ─────────────────────────────────────────────────────────────────
 
 type releaseModel struct {
@@ -612,6 +698,7 @@ type routeModel struct {
 	Protocol    string    `bson:"protocol,omitempty" grove:"protocol"`
 	Weight      int       `bson:"weight"             grove:"weight"`
 	StripPrefix bool      `bson:"strip_prefix"       grove:"strip_prefix"`
+	Track       string    `bson:"track,omitempty"    grove:"track"`
 	CreatedAt   time.Time `bson:"created_at"         grove:"created_at"`
 	UpdatedAt   time.Time `bson:"updated_at"         grove:"updated_at"`
 }
@@ -626,6 +713,7 @@ func toRouteModel(r *network.Route) *routeModel {
 		Protocol:    r.Protocol,
 		Weight:      r.Weight,
 		StripPrefix: r.StripPrefix,
+		Track:       r.Track,
 		CreatedAt:   r.CreatedAt,
 		UpdatedAt:   r.UpdatedAt,
 	}
@@ -645,6 +733,7 @@ func fromRouteModel(m *routeModel) *network.Route {
 		Protocol:    m.Protocol,
 		Weight:      m.Weight,
 		StripPrefix: m.StripPrefix,
+		Track:       m.Track,
 	}
 }
 
@@ -777,6 +866,7 @@ type templateModel struct {
 	Description     string                    `bson:"description,omitempty"      grove:"description"`
 	DefaultKind     string                    `bson:"default_kind,omitempty"     grove:"default_kind"`
 	DefaultStrategy string                    `bson:"default_strategy,omitempty" grove:"default_strategy"`
+	DefaultBake     *deploy.BakeConfig        `bson:"default_bake,omitempty" grove:"default_bake"`
 	Services        []provider.ServiceSpec    `bson:"services,omitempty"`
 	Labels          map[string]string         `bson:"labels,omitempty"`
 	Notes           string                    `bson:"notes,omitempty"            grove:"notes"`
@@ -794,6 +884,7 @@ func toTemplateModel(t *template.Template) *templateModel {
 		Description:     t.Description,
 		DefaultKind:     string(t.DefaultKind),
 		DefaultStrategy: t.DefaultStrategy,
+		DefaultBake:     t.DefaultBake,
 		Services:        t.Services,
 		Labels:          t.Labels,
 		Notes:           t.Notes,
@@ -816,6 +907,7 @@ func fromTemplateModel(m *templateModel) *template.Template {
 		Description:     m.Description,
 		DefaultKind:     provider.WorkloadKind(m.DefaultKind),
 		DefaultStrategy: m.DefaultStrategy,
+		DefaultBake:     m.DefaultBake,
 		Services:        m.Services,
 		Labels:          m.Labels,
 		Notes:           m.Notes,
@@ -21,6 +21,7 @@ import (
 const (
 	colInstances         = "cp_instances"
 	colDeployments       = "cp_deployments"
+	colDeployJobs        = "cp_deploy_jobs"
 	colReleases          = "cp_releases"
 	colHealthChecks      = "cp_health_checks"
 	colHealthResults     = "cp_health_results"
@@ -130,6 +131,9 @@ func migrationIndexes() map[string][]mongo.IndexModel {
 		colDeployments: {
 			{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "instance_id", Value: 1}, {Key: "created_at", Value: -1}}},
 		},
+		colDeployJobs: {
+			{Keys: bson.D{{Key: "lease_expires_at", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
 		colReleases: {
 			{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "instance_id", Value: 1}, {Key: "version", Value: -1}}},
 		},
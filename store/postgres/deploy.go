@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	ctrlplane "github.com/xraph/ctrlplane"
 	"github.com/xraph/ctrlplane/deploy"
@@ -178,3 +179,177 @@ func (s *Store) NextReleaseVersion(ctx context.Context, tenantID string, instanc
 
 	return maxVersion + 1, nil
 }
+
+func (s *Store) EnqueueJob(ctx context.Context, j *deploy.Job) error {
+	_, err := s.pg.NewInsert(toDeployJobModel(j)).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres: insert deploy job failed: %w", err)
+	}
+
+	return nil
+}
+
+// claimCandidates is how many claimable jobs ClaimJob tries before
+// giving up on a poll; each is lost only to a runner claiming it first.
+const claimCandidates = 8
+
+func (s *Store) ClaimJob(ctx context.Context, owner string, lease time.Duration) (*deploy.Job, error) {
+	t := now()
+
+	var models []deployJobModel
+
+	err := s.pg.NewSelect(&models).
+		Where("lease_expires_at IS NULL OR lease_expires_at <= $1", t).
+		OrderExpr("created_at ASC").
+		Limit(claimCandidates).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: list deploy jobs failed: %w", err)
+	}
+
+	for i := range models {
+		m := &models[i]
+		attempts := m.Attempts
+		until := t.Add(lease)
+
+		m.Owner = owner
+		m.Attempts++
+		m.LeaseExpiresAt = &until
+
+		// The claim holds only if nobody claimed, renewed or cancelled
+		// the job since it was read.
+		res, err := s.pg.NewUpdate(m).
+			Where("deployment_id = ? AND attempts = ? AND cancel_requested = ? AND (lease_expires_at IS NULL OR lease_expires_at <= ?)", m.DeploymentID, attempts, m.CancelRequested, t).
+			Exec(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("postgres: claim deploy job failed: %w", err)
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("postgres: rows affected check failed: %w", err)
+		}
+
+		if rows == 1 {
+			return fromDeployJobModel(m), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: claimable deploy job", ctrlplane.ErrNotFound)
+}
+
+func (s *Store) RenewJob(ctx context.Context, j *deploy.Job, lease time.Duration) error {
+	// The update only matches the row as read, so a cancellation
+	// requested in between isn't overwritten; the row is read again.
+	for range 2 {
+		model, err := s.getHeldJob(ctx, j)
+		if err != nil {
+			return err
+		}
+
+		until := now().Add(lease)
+		model.LeaseExpiresAt = &until
+
+		res, err := s.pg.NewUpdate(model).
+			Where("deployment_id = ? AND owner = ? AND attempts = ? AND cancel_requested = ?", model.DeploymentID, model.Owner, model.Attempts, model.CancelRequested).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("postgres: renew deploy job failed: %w", err)
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("postgres: rows affected check failed: %w", err)
+		}
+
+		if rows == 1 {
+			j.CancelRequested = model.CancelRequested
+			j.CancelRollback = model.CancelRollback
+			j.CancelledBy = model.CancelledBy
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, j.DeploymentID)
+}
+
+// getHeldJob reads the job j describes, returning ctrlplane.ErrNotFound
+// when it is gone or has been claimed again since.
+func (s *Store) getHeldJob(ctx context.Context, j *deploy.Job) (*deployJobModel, error) {
+	var model deployJobModel
+
+	err := s.pg.NewSelect(&model).
+		Where("deployment_id = $1", j.DeploymentID.String()).
+		Scan(ctx)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, j.DeploymentID)
+		}
+
+		return nil, fmt.Errorf("postgres: get deploy job failed: %w", err)
+	}
+
+	if model.Owner != j.Owner || model.Attempts != j.Attempts {
+		return nil, fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, j.DeploymentID)
+	}
+
+	return &model, nil
+}
+
+func (s *Store) CancelJob(ctx context.Context, deploymentID id.ID, rollback bool, by string) error {
+	// A claim between the read and the write makes the update match
+	// nothing; the job is read again.
+	for range 3 {
+		var model deployJobModel
+
+		err := s.pg.NewSelect(&model).
+			Where("deployment_id = $1", deploymentID.String()).
+			Scan(ctx)
+		if err != nil {
+			if isNoRows(err) {
+				return fmt.Errorf("%w: deploy job %s", ctrlplane.ErrNotFound, deploymentID)
+			}
+
+			return fmt.Errorf("postgres: get deploy job failed: %w", err)
+		}
+
+		if rollback && model.RollbackReleaseID != "" {
+			return fmt.Errorf("%w: deploy job %s runs a rollback, which can't be rolled back", ctrlplane.ErrInvalidConfig, deploymentID)
+		}
+
+		owner, attempts := model.Owner, model.Attempts
+		model.CancelRequested = true
+		model.CancelRollback = rollback
+		model.CancelledBy = by
+
+		res, err := s.pg.NewUpdate(&model).
+			Where("deployment_id = ? AND owner = ? AND attempts = ?", model.DeploymentID, owner, attempts).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("postgres: cancel deploy job failed: %w", err)
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("postgres: rows affected check failed: %w", err)
+		}
+
+		if rows == 1 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("postgres: cancel deploy job %s: it keeps being claimed", deploymentID)
+}
+
+func (s *Store) DeleteJob(ctx context.Context, deploymentID id.ID) error {
+	_, err := s.pg.NewDelete((*deployJobModel)(nil)).
+		Where("deployment_id = $1", deploymentID.String()).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres: delete deploy job failed: %w", err)
+	}
+
+	return nil
+}
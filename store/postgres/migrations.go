@@ -843,6 +843,138 @@ CREATE INDEX IF NOT EXISTS idx_cp_workloads_state ON cp_workloads (state);
 			Down: func(ctx context.Context, exec migrate.Executor) error {
 				_, err := exec.Exec(ctx, `DROP TABLE IF EXISTS cp_workloads`)
 
+				return err
+			},
+		},
+		// Deployments run asynchronously: Deploy queues a job in
+		// cp_deploy_jobs and a leased runner executes it, persisting the
+		// strategy's phase and percent as it goes. The runner reloads the
+		// deployment from the store, so the canary and blue-green settings
+		// need columns too.
+		&migrate.Migration{
+			Name:    "add_deploy_jobs",
+			Version: "20240101000026",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE cp_deployments ADD COLUMN IF NOT EXISTS phase TEXT NOT NULL DEFAULT '';
+ALTER TABLE cp_deployments ADD COLUMN IF NOT EXISTS percent INT NOT NULL DEFAULT 0;
+ALTER TABLE cp_deployments ADD COLUMN IF NOT EXISTS canary JSONB;
+ALTER TABLE cp_deployments ADD COLUMN IF NOT EXISTS blue_green JSONB;
+
+CREATE TABLE IF NOT EXISTS cp_deploy_jobs (
+    deployment_id     TEXT PRIMARY KEY,
+    tenant_id         TEXT NOT NULL,
+    owner             TEXT NOT NULL DEFAULT '',
+    attempts          INT NOT NULL DEFAULT 0,
+    lease_expires_at  TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cp_deploy_jobs_claim ON cp_deploy_jobs (lease_expires_at, created_at);
+`)
+
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP TABLE IF EXISTS cp_deploy_jobs;
+ALTER TABLE cp_deployments DROP COLUMN IF EXISTS phase;
+ALTER TABLE cp_deployments DROP COLUMN IF EXISTS percent;
+ALTER TABLE cp_deployments DROP COLUMN IF EXISTS canary;
+ALTER TABLE cp_deployments DROP COLUMN IF EXISTS blue_green;
+`)
+
+				return err
+			},
+		},
+		// Cancel records its request on the deployment's job, where the
+		// runner holding it finds it on its next lease renewal.
+		&migrate.Migration{
+			Name:    "add_cancel_to_cp_deploy_jobs",
+			Version: "20240101000027",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE cp_deploy_jobs ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE cp_deploy_jobs ADD COLUMN IF NOT EXISTS cancel_rollback BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE cp_deploy_jobs ADD COLUMN IF NOT EXISTS cancelled_by TEXT NOT NULL DEFAULT '';
+`)
+
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE cp_deploy_jobs DROP COLUMN IF EXISTS cancel_requested;
+ALTER TABLE cp_deploy_jobs DROP COLUMN IF EXISTS cancel_rollback;
+ALTER TABLE cp_deploy_jobs DROP COLUMN IF EXISTS cancelled_by;
+`)
+
+				return err
+			},
+		},
+		// Bake windows: a deployment's own and the default a template
+		// hands to the workloads forked from it.
+		&migrate.Migration{
+			Name:    "add_bake_windows",
+			Version: "20240101000028",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE cp_deployments ADD COLUMN IF NOT EXISTS bake JSONB;
+ALTER TABLE cp_templates ADD COLUMN IF NOT EXISTS default_bake JSONB;
+`)
+
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE cp_deployments DROP COLUMN IF EXISTS bake;
+ALTER TABLE cp_templates DROP COLUMN IF EXISTS default_bake;
+`)
+
+				return err
+			},
+		},
+		// The canary or blue-green track a route sends traffic to.
+		// Without it a reloaded route falls back to the stable backends.
+		&migrate.Migration{
+			Name:    "add_track_to_cp_routes",
+			Version: "20240101000029",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `ALTER TABLE cp_routes ADD COLUMN IF NOT EXISTS track TEXT NOT NULL DEFAULT ''`)
+
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `ALTER TABLE cp_routes DROP COLUMN IF EXISTS track`)
+
+				return err
+			},
+		},
+		// Rollbacks are queued like deployments. Their job records the
+		// release restored, whether the provider reverts natively, and
+		// the deployment a bake rolls back with what set it off.
+		&migrate.Migration{
+			Name:    "add_rollback_to_cp_deploy_jobs",
+			Version: "20240101000030",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE cp_deploy_jobs ADD COLUMN IF NOT EXISTS rollback_release_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE cp_deploy_jobs ADD COLUMN IF NOT EXISTS rollback_native BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE cp_deploy_jobs ADD COLUMN IF NOT EXISTS rollback_of TEXT NOT NULL DEFAULT '';
+ALTER TABLE cp_deploy_jobs ADD COLUMN IF NOT EXISTS rollback_trigger TEXT NOT NULL DEFAULT '';
+ALTER TABLE cp_deploy_jobs ADD COLUMN IF NOT EXISTS rollback_reason TEXT NOT NULL DEFAULT '';
+`)
+
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE cp_deploy_jobs DROP COLUMN IF EXISTS rollback_release_id;
+ALTER TABLE cp_deploy_jobs DROP COLUMN IF EXISTS rollback_native;
+ALTER TABLE cp_deploy_jobs DROP COLUMN IF EXISTS rollback_of;
+ALTER TABLE cp_deploy_jobs DROP COLUMN IF EXISTS rollback_trigger;
+ALTER TABLE cp_deploy_jobs DROP COLUMN IF EXISTS rollback_reason;
+`)
+
 				return err
 			},
 		},
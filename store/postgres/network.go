@@ -357,6 +357,7 @@ func fromRouteModel(m *routeModel) *network.Route {
 		Protocol:    m.Protocol,
 		Weight:      m.Weight,
 		StripPrefix: m.StripPrefix,
+		Track:       m.Track,
 	}
 }
 
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/network"
+)
+
+// TestRouteModel_TrackRoundTrip guards the route's track: UpdateRoute
+// writes back a route read from the store, so a track lost on the way
+// sends a canary's weight to the stable backends.
+func TestRouteModel_TrackRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in := &network.Route{
+		Entity:     ctrlplane.NewEntity(id.PrefixRoute),
+		TenantID:   "tenant-x",
+		InstanceID: id.New(id.PrefixInstance),
+		Path:       "/",
+		Port:       8080,
+		Weight:     10,
+		Track:      "canary",
+	}
+
+	out := fromRouteModel(toRouteModel(in))
+	if out.Track != "canary" || out.Weight != 10 {
+		t.Fatalf("round-trip route: track=%q weight=%d, want canary at 10", out.Track, out.Weight)
+	}
+}
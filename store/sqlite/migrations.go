@@ -687,5 +687,175 @@ CREATE TABLE IF NOT EXISTS cp_bootstrap_workloads (
 				return err
 			},
 		},
+		// Asynchronous deployments. See the matching Postgres migration.
+		&migrate.Migration{
+			Name:    "add_deploy_jobs",
+			Version: "20240101000020",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				stmts := []string{
+					`ALTER TABLE cp_deployments ADD COLUMN phase TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE cp_deployments ADD COLUMN percent INTEGER NOT NULL DEFAULT 0`,
+					`ALTER TABLE cp_deployments ADD COLUMN canary BLOB`,
+					`ALTER TABLE cp_deployments ADD COLUMN blue_green BLOB`,
+					`
+CREATE TABLE IF NOT EXISTS cp_deploy_jobs (
+    deployment_id     TEXT PRIMARY KEY,
+    tenant_id         TEXT NOT NULL,
+    owner             TEXT NOT NULL DEFAULT '',
+    attempts          INTEGER NOT NULL DEFAULT 0,
+    lease_expires_at  TEXT,
+    created_at        TEXT NOT NULL DEFAULT (datetime('now'))
+);`,
+					`CREATE INDEX IF NOT EXISTS idx_cp_deploy_jobs_claim ON cp_deploy_jobs (lease_expires_at, created_at);`,
+				}
+
+				for _, stmt := range stmts {
+					if _, err := exec.Exec(ctx, stmt); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				stmts := []string{
+					`DROP TABLE IF EXISTS cp_deploy_jobs`,
+					`ALTER TABLE cp_deployments DROP COLUMN phase`,
+					`ALTER TABLE cp_deployments DROP COLUMN percent`,
+					`ALTER TABLE cp_deployments DROP COLUMN canary`,
+					`ALTER TABLE cp_deployments DROP COLUMN blue_green`,
+				}
+
+				for _, stmt := range stmts {
+					if _, err := exec.Exec(ctx, stmt); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		},
+		// Deployment cancellation. See the matching Postgres migration.
+		&migrate.Migration{
+			Name:    "add_cancel_to_cp_deploy_jobs",
+			Version: "20240101000021",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				stmts := []string{
+					`ALTER TABLE cp_deploy_jobs ADD COLUMN cancel_requested INTEGER NOT NULL DEFAULT 0`,
+					`ALTER TABLE cp_deploy_jobs ADD COLUMN cancel_rollback INTEGER NOT NULL DEFAULT 0`,
+					`ALTER TABLE cp_deploy_jobs ADD COLUMN cancelled_by TEXT NOT NULL DEFAULT ''`,
+				}
+
+				for _, stmt := range stmts {
+					if _, err := exec.Exec(ctx, stmt); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				stmts := []string{
+					`ALTER TABLE cp_deploy_jobs DROP COLUMN cancel_requested`,
+					`ALTER TABLE cp_deploy_jobs DROP COLUMN cancel_rollback`,
+					`ALTER TABLE cp_deploy_jobs DROP COLUMN cancelled_by`,
+				}
+
+				for _, stmt := range stmts {
+					if _, err := exec.Exec(ctx, stmt); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		},
+		// Bake windows. See the matching Postgres migration.
+		&migrate.Migration{
+			Name:    "add_bake_windows",
+			Version: "20240101000022",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				stmts := []string{
+					`ALTER TABLE cp_deployments ADD COLUMN bake BLOB`,
+					`ALTER TABLE cp_templates ADD COLUMN default_bake BLOB`,
+				}
+
+				for _, stmt := range stmts {
+					if _, err := exec.Exec(ctx, stmt); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				stmts := []string{
+					`ALTER TABLE cp_deployments DROP COLUMN bake`,
+					`ALTER TABLE cp_templates DROP COLUMN default_bake`,
+				}
+
+				for _, stmt := range stmts {
+					if _, err := exec.Exec(ctx, stmt); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		},
+		// Route tracks. See the matching Postgres migration.
+		&migrate.Migration{
+			Name:    "add_track_to_cp_routes",
+			Version: "20240101000023",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `ALTER TABLE cp_routes ADD COLUMN track TEXT NOT NULL DEFAULT ''`)
+
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `ALTER TABLE cp_routes DROP COLUMN track`)
+
+				return err
+			},
+		},
+		// Queued rollbacks. See the matching Postgres migration.
+		&migrate.Migration{
+			Name:    "add_rollback_to_cp_deploy_jobs",
+			Version: "20240101000024",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				stmts := []string{
+					`ALTER TABLE cp_deploy_jobs ADD COLUMN rollback_release_id TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE cp_deploy_jobs ADD COLUMN rollback_native INTEGER NOT NULL DEFAULT 0`,
+					`ALTER TABLE cp_deploy_jobs ADD COLUMN rollback_of TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE cp_deploy_jobs ADD COLUMN rollback_trigger TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE cp_deploy_jobs ADD COLUMN rollback_reason TEXT NOT NULL DEFAULT ''`,
+				}
+
+				for _, stmt := range stmts {
+					if _, err := exec.Exec(ctx, stmt); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				stmts := []string{
+					`ALTER TABLE cp_deploy_jobs DROP COLUMN rollback_release_id`,
+					`ALTER TABLE cp_deploy_jobs DROP COLUMN rollback_native`,
+					`ALTER TABLE cp_deploy_jobs DROP COLUMN rollback_of`,
+					`ALTER TABLE cp_deploy_jobs DROP COLUMN rollback_trigger`,
+					`ALTER TABLE cp_deploy_jobs DROP COLUMN rollback_reason`,
+				}
+
+				for _, stmt := range stmts {
+					if _, err := exec.Exec(ctx, stmt); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		},
 	)
 }
@@ -71,6 +71,11 @@ type deploymentModel struct {
 	Strategy        string     `grove:"strategy,notnull"`
 	Services        []byte     `grove:"services"`
 	ServiceProgress []byte     `grove:"service_progress"`
+	Canary          []byte     `grove:"canary"`
+	BlueGreen       []byte     `grove:"blue_green"`
+	Bake            []byte     `grove:"bake"`
+	Phase           string     `grove:"phase,notnull"`
+	Percent         int        `grove:"percent,notnull"`
 	ProviderRef     string     `grove:"provider_ref"`
 	Error           string     `grove:"error"`
 	Initiator       string     `grove:"initiator"`
@@ -80,6 +85,27 @@ type deploymentModel struct {
 	UpdatedAt       time.Time  `grove:"updated_at,notnull"`
 }
 
+// deployJobModel is the database model for deploy.Job.
+type deployJobModel struct {
+	grove.BaseModel `grove:"table:cp_deploy_jobs"`
+
+	DeploymentID    string     `grove:"deployment_id,pk"`
+	TenantID        string     `grove:"tenant_id,notnull"`
+	Owner           string     `grove:"owner,notnull"`
+	Attempts        int        `grove:"attempts,notnull"`
+	LeaseExpiresAt  *time.Time `grove:"lease_expires_at"`
+	CancelRequested bool       `grove:"cancel_requested,notnull"`
+	CancelRollback  bool       `grove:"cancel_rollback,notnull"`
+	CancelledBy     string     `grove:"cancelled_by,notnull"`
+	CreatedAt       time.Time  `grove:"created_at,notnull"`
+
+	RollbackReleaseID string `grove:"rollback_release_id,notnull"`
+	RollbackNative    bool   `grove:"rollback_native,notnull"`
+	RollbackOf        string `grove:"rollback_of,notnull"`
+	RollbackTrigger   string `grove:"rollback_trigger,notnull"`
+	RollbackReason    string `grove:"rollback_reason,notnull"`
+}
+
 // releaseModel is the database model for deploy.Release.
 type releaseModel struct {
 	grove.BaseModel `grove:"table:cp_releases"`
@@ -220,6 +246,7 @@ type routeModel struct {
 	Protocol    string    `grove:"protocol"`
 	Weight      int       `grove:"weight"`
 	StripPrefix bool      `grove:"strip_prefix"`
+	Track       string    `grove:"track"`
 	CreatedAt   time.Time `grove:"created_at,notnull"`
 	UpdatedAt   time.Time `grove:"updated_at,notnull"`
 }
@@ -275,6 +302,7 @@ type templateModel struct {
 	Description     string    `grove:"description"`
 	DefaultKind     string    `grove:"default_kind"`
 	DefaultStrategy string    `grove:"default_strategy"`
+	DefaultBake     []byte    `grove:"default_bake"`
 	Services        []byte    `grove:"services"`
 	Labels          []byte    `grove:"labels"`
 	Notes           string    `grove:"notes"`
@@ -343,6 +371,11 @@ func toDeploymentModel(d *deploy.Deployment) *deploymentModel {
 		Strategy:        d.Strategy,
 		Services:        marshalJSON(d.Services),
 		ServiceProgress: marshalJSON(d.ServiceProgress),
+		Canary:          marshalJSON(d.Canary),
+		BlueGreen:       marshalJSON(d.BlueGreen),
+		Bake:            marshalJSON(d.Bake),
+		Phase:           d.Phase,
+		Percent:         d.Percent,
 		ProviderRef:     d.ProviderRef,
 		Error:           d.Error,
 		Initiator:       d.Initiator,
@@ -365,6 +398,8 @@ func fromDeploymentModel(m *deploymentModel) *deploy.Deployment {
 		ReleaseID:   id.MustParse(m.ReleaseID),
 		State:       deploy.DeployState(m.State),
 		Strategy:    m.Strategy,
+		Phase:       m.Phase,
+		Percent:     m.Percent,
 		ProviderRef: m.ProviderRef,
 		Error:       m.Error,
 		Initiator:   m.Initiator,
@@ -374,10 +409,62 @@ func fromDeploymentModel(m *deploymentModel) *deploy.Deployment {
 
 	unmarshalJSON(m.Services, &out.Services)
 	unmarshalJSON(m.ServiceProgress, &out.ServiceProgress)
+	unmarshalJSON(m.Canary, &out.Canary)
+	unmarshalJSON(m.BlueGreen, &out.BlueGreen)
+	unmarshalJSON(m.Bake, &out.Bake)
 
 	return out
 }
 
+func toDeployJobModel(j *deploy.Job) *deployJobModel {
+	return &deployJobModel{
+		DeploymentID:    j.DeploymentID.String(),
+		TenantID:        j.TenantID,
+		Owner:           j.Owner,
+		Attempts:        j.Attempts,
+		LeaseExpiresAt:  j.LeaseExpiresAt,
+		CancelRequested: j.CancelRequested,
+		CancelRollback:  j.CancelRollback,
+		CancelledBy:     j.CancelledBy,
+		CreatedAt:       j.CreatedAt,
+
+		RollbackReleaseID: j.RollbackReleaseID.String(),
+		RollbackNative:    j.RollbackNative,
+		RollbackOf:        j.RollbackOf.String(),
+		RollbackTrigger:   j.RollbackTrigger,
+		RollbackReason:    j.RollbackReason,
+	}
+}
+
+func fromDeployJobModel(m *deployJobModel) *deploy.Job {
+	return &deploy.Job{
+		DeploymentID:    id.MustParse(m.DeploymentID),
+		TenantID:        m.TenantID,
+		Owner:           m.Owner,
+		Attempts:        m.Attempts,
+		LeaseExpiresAt:  m.LeaseExpiresAt,
+		CancelRequested: m.CancelRequested,
+		CancelRollback:  m.CancelRollback,
+		CancelledBy:     m.CancelledBy,
+		CreatedAt:       m.CreatedAt,
+
+		RollbackReleaseID: optionalID(m.RollbackReleaseID),
+		RollbackNative:    m.RollbackNative,
+		RollbackOf:        optionalID(m.RollbackOf),
+		RollbackTrigger:   m.RollbackTrigger,
+		RollbackReason:    m.RollbackReason,
+	}
+}
+
+// optionalID parses an ID column that is empty for id.Nil.
+func optionalID(s string) id.ID {
+	if s == "" {
+		return id.Nil
+	}
+
+	return id.MustParse(s)
+}
+
 func toReleaseModel(r *deploy.Release) *releaseModel {
 	return &releaseModel{
 		ID:         r.ID.String(),
@@ -534,6 +621,7 @@ func toRouteModel(route *network.Route) *routeModel {
 		Protocol:    route.Protocol,
 		Weight:      route.Weight,
 		StripPrefix: route.StripPrefix,
+		Track:       route.Track,
 		CreatedAt:   route.CreatedAt,
 		UpdatedAt:   route.UpdatedAt,
 	}
@@ -553,6 +641,7 @@ func fromRouteModel(m *routeModel) *network.Route {
 		Protocol:    m.Protocol,
 		Weight:      m.Weight,
 		StripPrefix: m.StripPrefix,
+		Track:       m.Track,
 	}
 }
 
@@ -626,6 +715,7 @@ func toTemplateModel(t *template.Template) *templateModel {
 		Description:     t.Description,
 		DefaultKind:     string(t.DefaultKind),
 		DefaultStrategy: t.DefaultStrategy,
+		DefaultBake:     marshalJSON(t.DefaultBake),
 		Services:        marshalJSON(t.Services),
 		Labels:          marshalJSON(t.Labels),
 		Notes:           t.Notes,
@@ -655,6 +745,7 @@ func fromTemplateModel(m *templateModel) *template.Template {
 	unmarshalJSON(m.Labels, &t.Labels)
 	unmarshalJSON(m.Variables, &t.Variables)
 	unmarshalJSON(m.Source, &t.Source)
+	unmarshalJSON(m.DefaultBake, &t.DefaultBake)
 
 	// Legacy rows predate the Source column — project Services onto a
 	// services Source so callers always see a populated Source.
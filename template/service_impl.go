@@ -86,6 +86,10 @@ func (s *service) Create(ctx context.Context, req CreateRequest) (*Template, err
 		return nil, fmt.Errorf("create template: %w", err)
 	}
 
+	if err := req.DefaultBake.Validate(); err != nil {
+		return nil, fmt.Errorf("create template: %w", err)
+	}
+
 	kind := req.DefaultKind
 	if kind == "" {
 		kind = provider.KindDeployment
@@ -98,6 +102,7 @@ func (s *service) Create(ctx context.Context, req CreateRequest) (*Template, err
 		Description:     req.Description,
 		DefaultKind:     kind,
 		DefaultStrategy: req.DefaultStrategy,
+		DefaultBake:     req.DefaultBake,
 		Services:        source.Services,
 		Labels:          req.Labels,
 		Notes:           req.Notes,
@@ -215,6 +220,14 @@ func (s *service) Update(ctx context.Context, templateID id.ID, req UpdateReques
 		tmpl.DefaultStrategy = *req.DefaultStrategy
 	}
 
+	if req.DefaultBake != nil {
+		if err := req.DefaultBake.Validate(); err != nil {
+			return nil, fmt.Errorf("update template: %w", err)
+		}
+
+		tmpl.DefaultBake = req.DefaultBake
+	}
+
 	if req.Services != nil {
 		if err := validateServices(req.Services); err != nil {
 			return nil, fmt.Errorf("update template: %w", err)
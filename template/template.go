@@ -2,6 +2,7 @@ package template
 
 import (
 	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/deploy"
 	"github.com/xraph/ctrlplane/provider"
 	"github.com/xraph/ctrlplane/vars"
 )
@@ -22,6 +23,8 @@ type ConfigFile = provider.ConfigFile
 // instantiation copies them onto the new Workload verbatim. Workload-
 // level fields (DefaultKind, DefaultStrategy, Labels) seed the workload
 // at creation time and may be overridden by the CreateRequest.
+// DefaultBake applies to every deploy of a workload forked from the
+// template that doesn't set its own bake window.
 type Template struct {
 	ctrlplane.Entity
 
@@ -30,6 +33,7 @@ type Template struct {
 	Description     string                 `db:"description"      json:"description,omitempty"`
 	DefaultKind     provider.WorkloadKind  `db:"default_kind"     json:"default_kind,omitempty"`
 	DefaultStrategy string                 `db:"default_strategy" json:"default_strategy,omitempty"`
+	DefaultBake     *deploy.BakeConfig     `db:"default_bake"     json:"default_bake,omitempty"`
 	Services        []provider.ServiceSpec `db:"services"         json:"services"`
 	Labels          map[string]string      `db:"labels"           json:"labels,omitempty"`
 	Notes           string                 `db:"notes"            json:"notes,omitempty"`
@@ -39,7 +43,7 @@ type Template struct {
 	Variables []vars.Definition `db:"variables" json:"variables,omitempty"`
 
 	// Source describes what the template deploys (services | helm |
-	// manifests | argocd). Legacy templates carry only Services; call
+	// manifests | argocd | compose). Legacy templates carry only Services; call
 	// NormalizeSource to project them onto a services Source.
 	Source provider.DeploymentSource `db:"source" json:"source,omitzero"`
 }
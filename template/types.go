@@ -3,6 +3,7 @@ package template
 import (
 	"context"
 
+	"github.com/xraph/ctrlplane/deploy"
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/provider"
 	"github.com/xraph/ctrlplane/vars"
@@ -18,6 +19,7 @@ type CreateRequest struct {
 	Description     string                    `json:"description,omitempty"`
 	DefaultKind     provider.WorkloadKind     `json:"default_kind,omitempty"`
 	DefaultStrategy string                    `json:"default_strategy,omitempty"`
+	DefaultBake     *deploy.BakeConfig        `json:"default_bake,omitempty"`
 	Services        []provider.ServiceSpec    `json:"services,omitempty"`
 	Labels          map[string]string         `json:"labels,omitempty"`
 	Notes           string                    `json:"notes,omitempty"`
@@ -32,6 +34,7 @@ type UpdateRequest struct {
 	Description     *string                    `json:"description,omitempty"`
 	DefaultKind     *provider.WorkloadKind     `json:"default_kind,omitempty"`
 	DefaultStrategy *string                    `json:"default_strategy,omitempty"`
+	DefaultBake     *deploy.BakeConfig         `json:"default_bake,omitempty"`
 	Services        []provider.ServiceSpec     `json:"services,omitempty"`
 	Labels          map[string]string          `json:"labels,omitempty"`
 	Notes           *string                    `json:"notes,omitempty"`
@@ -0,0 +1,601 @@
+package testutil
+
+// conformance.go is the provider conformance suite. A provider package
+// certifies itself with one test:
+//
+//	func TestConformance(t *testing.T) {
+//	    testutil.RunProviderConformance(t, testutil.ProviderConformance{
+//	        Provider: myprovider.New(...),
+//	        Services: []provider.ServiceSpec{
+//	            {Name: "web", Image: "nginx:1.25", Ports: []provider.PortSpec{{Container: 80}}},
+//	            {Name: "agent", Image: "busybox", Role: provider.RoleSidecar, Command: []string{"sleep", "3600"}},
+//	        },
+//	    })
+//	}
+//
+// and `go test -run TestConformance ./...` runs the whole contract
+// against it. Checks that need something the provider doesn't offer
+// (a capability it doesn't advertise, a second long-lived service, a
+// manifest to apply) are skipped, never failed, so the output lists
+// exactly what was certified.
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// conformanceEnv is the variable each conformance deploy changes, so
+// every deploy is a real change even when the image stays the same.
+const conformanceEnv = "CTRLPLANE_CONFORMANCE_RELEASE"
+
+// ProviderConformance configures RunProviderConformance.
+type ProviderConformance struct {
+	// Provider is the implementation under test. The suite provisions
+	// its own instances and deprovisions them when it's done.
+	Provider provider.Provider
+
+	// Services is the workload every lifecycle check provisions. It
+	// must contain exactly one Main service whose image runs until
+	// stopped on the provider's backend. A second long-lived service
+	// enables the partial-deploy checks.
+	Services []provider.ServiceSpec
+
+	// ExecCommand is run by the exec check. Defaults to ["true"].
+	ExecCommand []string
+
+	// Timeout bounds how long the suite waits for the provider to
+	// converge after each step. Defaults to two minutes.
+	Timeout time.Duration
+
+	// PollInterval is how often Status is polled while waiting.
+	// Defaults to 250ms.
+	PollInterval time.Duration
+
+	// Manifests, Helm and Argo are applied by the checks of the
+	// matching engine. Nil skips that engine's lifecycle; capability
+	// honesty is still checked.
+	Manifests *provider.RenderedManifests
+	Helm      *provider.RenderedHelm
+	Argo      *provider.ArgoCDSource
+}
+
+// RunProviderConformance certifies a provider against the contract
+// the control plane relies on:
+//
+//   - Lifecycle: Provision → Status → Deploy → Scale → Stop → Start →
+//     Restart → Deprovision converges at every step, and Provision
+//     returns a ServiceRef for every service.
+//   - Partial deploys: services left out of DeployRequest.Services are
+//     not touched.
+//   - Unknown instances: calls on an instance that was never
+//     provisioned fail, or report it destroyed, rather than pretend it
+//     exists; Deprovision is convergent.
+//   - Capability honesty: every advertised capability works, and the
+//     engine capabilities are backed by the engine interface.
+func RunProviderConformance(t *testing.T, c ProviderConformance) {
+	t.Helper()
+
+	if c.Provider == nil {
+		t.Fatal("conformance: Provider is required")
+	}
+
+	if mainService(c.Services) == nil {
+		t.Fatal("conformance: Services needs exactly one Main service")
+	}
+
+	if len(c.ExecCommand) == 0 {
+		c.ExecCommand = []string{"true"}
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = 2 * time.Minute
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = 250 * time.Millisecond
+	}
+
+	t.Run("Info", c.testInfo)
+	t.Run("CapabilityHonesty", c.testCapabilityHonesty)
+	t.Run("Lifecycle", c.testLifecycle)
+	t.Run("PartialDeploy", c.testPartialDeploy)
+	t.Run("UnknownInstance", c.testUnknownInstance)
+	t.Run("ManifestEngine", c.testManifestEngine)
+	t.Run("HelmEngine", c.testHelmEngine)
+	t.Run("ArgoEngine", c.testArgoEngine)
+}
+
+func (c ProviderConformance) testInfo(t *testing.T) {
+	if c.Provider.Info().Name == "" {
+		t.Fatal("Info().Name is empty")
+	}
+}
+
+// testCapabilityHonesty checks that engine capabilities are backed by
+// their interface. The behavioural capabilities (deploy, scale, logs,
+// exec) are exercised by the lifecycle when advertised.
+func (c ProviderConformance) testCapabilityHonesty(t *testing.T) {
+	p := c.Provider
+
+	engines := map[provider.Capability]bool{
+		provider.CapManifests: implements[provider.ManifestEngine](p),
+		provider.CapHelm:      implements[provider.HelmEngine](p),
+		provider.CapArgoCD:    implements[provider.ArgoEngine](p),
+	}
+
+	for _, capability := range slices.Sorted(maps.Keys(engines)) {
+		if provider.HasCapability(p, capability) && !engines[capability] {
+			t.Errorf("advertises %s but doesn't implement its engine interface", capability)
+		}
+	}
+}
+
+func implements[T any](p provider.Provider) bool {
+	_, ok := p.(T)
+
+	return ok
+}
+
+// testLifecycle drives one instance through every lifecycle step,
+// stopping at the first step that fails.
+func (c ProviderConformance) testLifecycle(t *testing.T) {
+	p := c.Provider
+	instID := c.provision(t)
+
+	steps := []struct {
+		name string
+		cap  provider.Capability
+		run  func(t *testing.T)
+	}{
+		{"Status", "", func(t *testing.T) {
+			st := c.waitReady(t, instID)
+			for _, svc := range c.Services {
+				if svc.Role == provider.RoleInit {
+					continue
+				}
+
+				if _, ok := st.Services[svc.Name]; !ok {
+					t.Errorf("Status has no entry for service %q", svc.Name)
+				}
+			}
+		}},
+		{"Deploy", provider.CapDeploy, func(t *testing.T) {
+			c.deploy(t, instID, c.Services, "1")
+			c.waitReady(t, instID)
+		}},
+		{"Scale", provider.CapScale, func(t *testing.T) {
+			if err := p.Scale(t.Context(), instID, provider.ResourceSpec{Replicas: 2}); err != nil {
+				t.Fatalf("Scale to 2: %v", err)
+			}
+
+			c.waitFor(t, instID, "2 ready replicas", func(st *provider.InstanceStatus) bool {
+				// Zero means the provider doesn't count replicas.
+				return st.Ready && (st.Replicas == 0 || st.ReadyReplicas == 2)
+			})
+
+			if err := p.Scale(t.Context(), instID, provider.ResourceSpec{Replicas: 1}); err != nil {
+				t.Fatalf("Scale to 1: %v", err)
+			}
+
+			c.waitFor(t, instID, "1 ready replica", func(st *provider.InstanceStatus) bool {
+				return st.Ready && (st.Replicas == 0 || st.Replicas == 1)
+			})
+		}},
+		{"Stop", "", func(t *testing.T) {
+			if err := p.Stop(t.Context(), instID); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+
+			c.waitFor(t, instID, "not ready", func(st *provider.InstanceStatus) bool { return !st.Ready })
+		}},
+		{"Start", "", func(t *testing.T) {
+			if err := p.Start(t.Context(), instID); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+
+			c.waitReady(t, instID)
+		}},
+		{"Restart", "", func(t *testing.T) {
+			if err := p.Restart(t.Context(), instID); err != nil {
+				t.Fatalf("Restart: %v", err)
+			}
+
+			c.waitReady(t, instID)
+		}},
+		{"Resources", "", func(t *testing.T) {
+			usage, err := p.Resources(t.Context(), instID)
+			if err != nil || usage == nil {
+				t.Fatalf("Resources = %v, %v; want usage", usage, err)
+			}
+		}},
+		{"Logs", provider.CapLogs, func(t *testing.T) { c.checkLogs(t, instID) }},
+		{"Exec", provider.CapExec, func(t *testing.T) {
+			res, err := p.Exec(t.Context(), instID, provider.ExecRequest{Command: c.ExecCommand})
+			if err != nil {
+				t.Fatalf("advertises exec but Exec failed: %v", err)
+			}
+
+			if res == nil || res.ExitCode != 0 {
+				t.Fatalf("Exec(%q) = %+v, want exit code 0", c.ExecCommand, res)
+			}
+		}},
+		{"RollbackRelease", "", func(t *testing.T) {
+			rb, ok := p.(provider.Rollbacker)
+			if !ok {
+				t.Skip("provider has no native rollback")
+			}
+
+			snaps := make([]provider.ServiceSnapshot, 0, len(c.Services))
+			for _, svc := range c.Services {
+				snaps = append(snaps, provider.ServiceSnapshot{Name: svc.Name, Image: svc.Image, Env: svc.Env})
+			}
+
+			if _, err := rb.RollbackRelease(t.Context(), provider.RollbackRequest{
+				InstanceID: instID,
+				ReleaseID:  id.New(id.PrefixRelease),
+				Services:   snaps,
+			}); err != nil {
+				t.Fatalf("RollbackRelease: %v", err)
+			}
+
+			c.waitReady(t, instID)
+		}},
+		{"Deprovision", "", func(t *testing.T) {
+			if err := p.Deprovision(t.Context(), instID); err != nil {
+				t.Fatalf("Deprovision: %v", err)
+			}
+
+			c.waitGone(t, instID)
+
+			if err := p.Deprovision(t.Context(), instID); err != nil {
+				t.Fatalf("second Deprovision: %v; want convergent success", err)
+			}
+		}},
+	}
+
+	for _, step := range steps {
+		ok := t.Run(step.name, func(t *testing.T) {
+			if step.cap != "" && !provider.HasCapability(p, step.cap) {
+				t.Skipf("%s not advertised", step.cap)
+			}
+
+			step.run(t)
+		})
+		if !ok {
+			return
+		}
+	}
+}
+
+// testPartialDeploy deploys only the Main service and checks every
+// other long-lived service kept running untouched.
+func (c ProviderConformance) testPartialDeploy(t *testing.T) {
+	if !provider.HasCapability(c.Provider, provider.CapDeploy) {
+		t.Skip("deploy not advertised")
+	}
+
+	main := mainService(c.Services)
+
+	var others []string
+
+	for _, svc := range c.Services {
+		if svc.Name != main.Name && svc.Role != provider.RoleInit {
+			others = append(others, svc.Name)
+		}
+	}
+
+	if len(others) == 0 {
+		t.Skip("needs a second long-lived service")
+	}
+
+	instID := c.provision(t)
+	before := c.waitReady(t, instID)
+
+	c.deploy(t, instID, []provider.ServiceSpec{*main}, "partial")
+	after := c.waitReady(t, instID)
+
+	for _, name := range others {
+		b, a := before.Services[name], after.Services[name]
+		if b.ProviderRef != "" && a.ProviderRef != b.ProviderRef {
+			t.Errorf("service %q was replaced by a deploy that didn't list it: ref %q → %q", name, b.ProviderRef, a.ProviderRef)
+		}
+
+		if a.Restarts > b.Restarts {
+			t.Errorf("service %q restarted during a deploy that didn't list it", name)
+		}
+	}
+}
+
+// testUnknownInstance checks calls on an instance that was never
+// provisioned. Lifecycle calls may fail or no-op, but must not make
+// the instance exist.
+func (c ProviderConformance) testUnknownInstance(t *testing.T) {
+	p := c.Provider
+	ctx := t.Context()
+	instID := id.New(id.PrefixInstance)
+
+	if err := p.Deprovision(ctx, instID); err != nil {
+		t.Errorf("Deprovision of unknown instance: %v; want convergent success", err)
+	}
+
+	if _, err := p.Deploy(ctx, provider.DeployRequest{
+		InstanceID: instID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   deploySpecs(c.Services, "unknown"),
+	}); err == nil {
+		t.Error("Deploy to unknown instance succeeded")
+	}
+
+	if provider.HasCapability(p, provider.CapExec) {
+		if _, err := p.Exec(ctx, instID, provider.ExecRequest{Command: c.ExecCommand}); err == nil {
+			t.Error("Exec in unknown instance succeeded")
+		}
+	}
+
+	_ = p.Start(ctx, instID)
+	_ = p.Stop(ctx, instID)
+	_ = p.Restart(ctx, instID)
+
+	st, err := p.Status(ctx, instID)
+	if err == nil && st.State != provider.StateDestroyed {
+		t.Errorf("Status of unknown instance = %s; want an error or %s", st.State, provider.StateDestroyed)
+	}
+}
+
+func (c ProviderConformance) testManifestEngine(t *testing.T) {
+	eng, ok := c.Provider.(provider.ManifestEngine)
+	if !ok || !provider.HasCapability(c.Provider, provider.CapManifests) {
+		t.Skip("manifests not supported")
+	}
+
+	if c.Manifests == nil {
+		t.Skip("no Manifests configured")
+	}
+
+	ctx := t.Context()
+	instID := id.New(id.PrefixInstance)
+
+	if _, err := eng.ApplyManifests(ctx, provider.ManifestApplyRequest{InstanceID: instID, Manifests: *c.Manifests}); err != nil {
+		t.Fatalf("ApplyManifests: %v", err)
+	}
+
+	if _, err := eng.ManifestStatus(ctx, instID); err != nil {
+		t.Errorf("ManifestStatus: %v", err)
+	}
+
+	for i := range 2 {
+		if err := eng.DeleteManifests(ctx, instID); err != nil {
+			t.Fatalf("DeleteManifests #%d: %v", i+1, err)
+		}
+	}
+}
+
+func (c ProviderConformance) testHelmEngine(t *testing.T) {
+	eng, ok := c.Provider.(provider.HelmEngine)
+	if !ok || !provider.HasCapability(c.Provider, provider.CapHelm) {
+		t.Skip("helm not supported")
+	}
+
+	if c.Helm == nil {
+		t.Skip("no Helm chart configured")
+	}
+
+	ctx := t.Context()
+	instID := id.New(id.PrefixInstance)
+
+	if _, err := eng.HelmInstall(ctx, provider.HelmInstallRequest{InstanceID: instID, Chart: *c.Helm}); err != nil {
+		t.Fatalf("HelmInstall: %v", err)
+	}
+
+	t.Cleanup(func() { _ = eng.HelmUninstall(context.Background(), instID) })
+
+	if _, err := eng.HelmStatus(ctx, instID); err != nil {
+		t.Errorf("HelmStatus: %v", err)
+	}
+
+	if _, err := eng.HelmUpgrade(ctx, provider.HelmUpgradeRequest{InstanceID: instID, Chart: *c.Helm}); err != nil {
+		t.Errorf("HelmUpgrade: %v", err)
+	}
+
+	if err := eng.HelmUninstall(ctx, instID); err != nil {
+		t.Fatalf("HelmUninstall: %v", err)
+	}
+}
+
+func (c ProviderConformance) testArgoEngine(t *testing.T) {
+	eng, ok := c.Provider.(provider.ArgoEngine)
+	if !ok || !provider.HasCapability(c.Provider, provider.CapArgoCD) {
+		t.Skip("argo cd not supported")
+	}
+
+	if c.Argo == nil {
+		t.Skip("no Argo source configured")
+	}
+
+	ctx := t.Context()
+	instID := id.New(id.PrefixInstance)
+
+	if _, err := eng.ArgoApply(ctx, provider.ArgoApplyRequest{InstanceID: instID, App: *c.Argo}); err != nil {
+		t.Fatalf("ArgoApply: %v", err)
+	}
+
+	if _, err := eng.ArgoStatus(ctx, instID); err != nil {
+		t.Errorf("ArgoStatus: %v", err)
+	}
+
+	for i := range 2 {
+		if err := eng.ArgoDelete(ctx, instID); err != nil {
+			t.Fatalf("ArgoDelete #%d: %v", i+1, err)
+		}
+	}
+}
+
+// provision provisions the configured workload as a fresh instance,
+// deprovisioned when the test ends, and checks the result.
+func (c ProviderConformance) provision(t *testing.T) id.ID {
+	t.Helper()
+
+	instID := id.New(id.PrefixInstance)
+
+	res, err := c.Provider.Provision(t.Context(), provider.ProvisionRequest{
+		InstanceID: instID,
+		TenantID:   "conformance",
+		Name:       "conformance-" + instID.String(),
+		Kind:       provider.KindDeployment,
+		Services:   slices.Clone(c.Services),
+		Labels:     map[string]string{"ctrlplane.io/conformance": "true"},
+	})
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	t.Cleanup(func() { _ = c.Provider.Deprovision(context.Background(), instID) })
+
+	if res == nil || res.ProviderRef == "" {
+		t.Fatalf("Provision result = %+v, want a ProviderRef", res)
+	}
+
+	for _, svc := range c.Services {
+		if res.ServiceRefs[svc.Name] == "" {
+			t.Errorf("Provision returned no ServiceRef for service %q", svc.Name)
+		}
+	}
+
+	return instID
+}
+
+// deploy deploys services with a changed env.
+func (c ProviderConformance) deploy(t *testing.T, instID id.ID, services []provider.ServiceSpec, release string) {
+	t.Helper()
+
+	res, err := c.Provider.Deploy(t.Context(), provider.DeployRequest{
+		InstanceID: instID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		Services:   deploySpecs(services, release),
+	})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	if res == nil {
+		t.Fatal("Deploy returned no result")
+	}
+}
+
+// deploySpecs turns services into deploy specs that change one env
+// variable.
+func deploySpecs(services []provider.ServiceSpec, release string) []provider.ServiceDeploySpec {
+	specs := make([]provider.ServiceDeploySpec, 0, len(services))
+
+	for _, svc := range services {
+		env := maps.Clone(svc.Env)
+		if env == nil {
+			env = make(map[string]string, 1)
+		}
+
+		env[conformanceEnv] = release
+
+		specs = append(specs, provider.ServiceDeploySpec{Name: svc.Name, Image: svc.Image, Env: env})
+	}
+
+	return specs
+}
+
+// checkLogs opens a non-following log stream and reads it to the end.
+func (c ProviderConformance) checkLogs(t *testing.T, instID id.ID) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(t.Context(), c.Timeout)
+	defer cancel()
+
+	rc, err := c.Provider.Logs(ctx, instID, provider.LogOptions{Tail: 10})
+	if err != nil {
+		t.Fatalf("advertises logs but Logs failed: %v", err)
+	}
+	defer rc.Close()
+
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		t.Fatalf("read logs: %v", err)
+	}
+}
+
+// waitReady waits for the instance to be running and ready.
+func (c ProviderConformance) waitReady(t *testing.T, instID id.ID) *provider.InstanceStatus {
+	t.Helper()
+
+	return c.waitFor(t, instID, "running and ready", func(st *provider.InstanceStatus) bool {
+		return st.State == provider.StateRunning && st.Ready
+	})
+}
+
+// waitGone waits for Status to fail or report the instance destroyed.
+func (c ProviderConformance) waitGone(t *testing.T, instID id.ID) {
+	t.Helper()
+
+	deadline := time.Now().Add(c.Timeout)
+
+	for {
+		st, err := c.Provider.Status(t.Context(), instID)
+		if err != nil || st.State == provider.StateDestroyed {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("instance still %s %s after Deprovision", st.State, c.Timeout)
+		}
+
+		time.Sleep(c.PollInterval)
+	}
+}
+
+// waitFor polls Status until cond holds.
+func (c ProviderConformance) waitFor(t *testing.T, instID id.ID, what string, cond func(*provider.InstanceStatus) bool) *provider.InstanceStatus {
+	t.Helper()
+
+	deadline := time.Now().Add(c.Timeout)
+
+	for attempt := 1; ; attempt++ {
+		st, err := c.Provider.Status(t.Context(), instID)
+		if err == nil && cond(st) {
+			return st
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				t.Fatalf("waiting for %s: Status: %v", what, err)
+			}
+
+			t.Fatalf("instance not %s after %s (%d polls): state=%s ready=%v replicas=%d/%d message=%q",
+				what, c.Timeout, attempt, st.State, st.Ready, st.ReadyReplicas, st.Replicas, st.Message)
+		}
+
+		time.Sleep(c.PollInterval)
+	}
+}
+
+// mainService returns the workload's Main service, or nil unless
+// there is exactly one.
+func mainService(services []provider.ServiceSpec) *provider.ServiceSpec {
+	var main *provider.ServiceSpec
+
+	for i := range services {
+		if services[i].Role != provider.RoleMain && services[i].Role != "" {
+			continue
+		}
+
+		if main != nil {
+			return nil
+		}
+
+		main = &services[i]
+	}
+
+	return main
+}
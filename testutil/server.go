@@ -66,13 +66,19 @@ func WithAuthProvider(p auth.Provider) ServerOption {
 // WithProvider registers an infrastructure provider (Docker, K8s, or
 // a fake). Tests that don't actually exercise instance lifecycle can
 // skip this; ctrlplane will accept the lack of providers and simply
-// reject Instances.Create calls.
-func WithProvider(name string, p provider.Provider) ServerOption {
+// reject Instances.Create calls. Wrappers decorate the provider as in
+// app.WithProvider — pass faultinject.Injector.Wrap to run the test
+// against a misbehaving backend.
+func WithProvider(name string, p provider.Provider, wrappers ...app.ProviderWrapper) ServerOption {
 	return func(c *serverConfig) {
 		if c.providers == nil {
 			c.providers = map[string]provider.Provider{}
 		}
 
+		for _, wrap := range wrappers {
+			p = wrap(p)
+		}
+
 		c.providers[name] = p
 	}
 }
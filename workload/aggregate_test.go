@@ -178,7 +178,7 @@ func (f *fakeDeploys) Deploy(context.Context, deploy.DeployRequest) (*deploy.Dep
 func (f *fakeDeploys) Rollback(context.Context, id.ID, id.ID) (*deploy.Deployment, error) {
 	panic("not used")
 }
-func (f *fakeDeploys) Cancel(context.Context, id.ID) error { panic("not used") }
+func (f *fakeDeploys) Cancel(context.Context, id.ID, deploy.CancelOptions) error { panic("not used") }
 func (f *fakeDeploys) GetDeployment(context.Context, id.ID) (*deploy.Deployment, error) {
 	panic("not used")
 }
@@ -0,0 +1,113 @@
+package workload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/auth"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+)
+
+// DeployEvents are the events HandleDeployEvent settles workloads on.
+var DeployEvents = []event.Type{
+	event.DeploySucceeded,
+	event.DeployFailed,
+	event.DeployCancelled,
+	event.DeployRolledBack,
+}
+
+// HandleDeployEvent settles a workload Deploy left in StateDeploying
+// once every replica's latest deployment has finished: StateActive,
+// with CurrentReleaseID set from the first replica, when all of them
+// succeeded, and StateFailed when any failed or was cancelled. Events
+// for instances outside a deploying workload are ignored.
+//
+// Subscribe it to DeployEvents on the bus the deploy service publishes
+// on; the control plane does.
+func (s *service) HandleDeployEvent(ctx context.Context, evt *event.Event) error {
+	if evt.InstanceID.IsNil() {
+		return nil
+	}
+
+	ctx = auth.WithClaims(ctx, &auth.Claims{SubjectID: evt.ActorID, TenantID: evt.TenantID})
+
+	inst, err := s.instances.Get(ctx, evt.InstanceID)
+	if errors.Is(err, ctrlplane.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("workload deploy event: %w", err)
+	}
+
+	workloadID, err := id.Parse(inst.Labels["ctrlplane.workload"])
+	if err != nil {
+		return nil //nolint:nilerr // not a workload replica
+	}
+
+	w, err := s.store.GetWorkloadByID(ctx, evt.TenantID, workloadID)
+	if errors.Is(err, ctrlplane.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("workload deploy event: get workload %s: %w", workloadID, err)
+	}
+
+	if w.State != StateDeploying {
+		return nil
+	}
+
+	replicas, err := s.ListInstances(ctx, workloadID)
+	if err != nil {
+		return fmt.Errorf("workload deploy event: %w", err)
+	}
+
+	var (
+		failed  bool
+		release id.ID
+	)
+
+	for i, r := range replicas {
+		res, err := s.deploys.ListDeployments(ctx, r.ID, deploy.ListOptions{Limit: 1})
+		if err != nil {
+			return fmt.Errorf("workload deploy event: list deployments of %s: %w", r.ID, err)
+		}
+
+		if len(res.Items) == 0 {
+			continue
+		}
+
+		latest := res.Items[0]
+
+		switch latest.State {
+		case deploy.DeployPending, deploy.DeployRunning:
+			// Still rolling out; a later event settles the workload.
+			return nil
+		case deploy.DeployFailed, deploy.DeployCancelled:
+			failed = true
+		case deploy.DeploySucceeded, deploy.DeployRolledBack:
+		}
+
+		if i == 0 {
+			release = latest.ReleaseID
+		}
+	}
+
+	if failed {
+		w.State = StateFailed
+	} else {
+		w.State = StateActive
+		w.CurrentReleaseID = release
+	}
+
+	if err := s.store.UpdateWorkload(ctx, w); err != nil {
+		return fmt.Errorf("workload deploy event: update workload %s: %w", workloadID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package workload
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	ctrlplane "github.com/xraph/ctrlplane"
+	"github.com/xraph/ctrlplane/deploy"
+	"github.com/xraph/ctrlplane/event"
+	"github.com/xraph/ctrlplane/id"
+	"github.com/xraph/ctrlplane/instance"
+	"github.com/xraph/ctrlplane/provider"
+)
+
+// eventInstances serves a fixed replica set.
+type eventInstances struct {
+	instance.Service
+
+	replicas []*instance.Instance
+}
+
+func (f *eventInstances) Get(_ context.Context, instanceID id.ID) (*instance.Instance, error) {
+	for _, r := range f.replicas {
+		if r.ID == instanceID {
+			return r, nil
+		}
+	}
+
+	return nil, ctrlplane.ErrNotFound
+}
+
+func (f *eventInstances) List(context.Context, instance.ListOptions) (*instance.ListResult, error) {
+	return &instance.ListResult{Items: f.replicas, Total: len(f.replicas)}, nil
+}
+
+// eventDeploys queues a pending deployment per Deploy and reports the
+// latest deployment of each instance.
+type eventDeploys struct {
+	deploy.Service
+
+	mu     sync.Mutex
+	latest map[id.ID]*deploy.Deployment
+}
+
+func (f *eventDeploys) Deploy(_ context.Context, req deploy.DeployRequest) (*deploy.Deployment, error) {
+	dep := &deploy.Deployment{
+		Entity:     ctrlplane.NewEntity(id.PrefixDeployment),
+		InstanceID: req.InstanceID,
+		ReleaseID:  id.New(id.PrefixRelease),
+		State:      deploy.DeployPending,
+	}
+
+	f.set(dep)
+
+	return dep, nil
+}
+
+func (f *eventDeploys) ListDeployments(_ context.Context, instanceID id.ID, _ deploy.ListOptions) (*deploy.DeployListResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dep, ok := f.latest[instanceID]
+	if !ok {
+		return &deploy.DeployListResult{}, nil
+	}
+
+	return &deploy.DeployListResult{Items: []*deploy.Deployment{dep}, Total: 1}, nil
+}
+
+func (f *eventDeploys) set(dep *deploy.Deployment) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.latest[dep.InstanceID] = dep
+}
+
+func (f *eventDeploys) finish(instanceID id.ID, state deploy.DeployState) *deploy.Deployment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dep := *f.latest[instanceID]
+	dep.State = state
+	f.latest[instanceID] = &dep
+
+	return &dep
+}
+
+// TestDeploy_SettlesOnDeployEvents verifies a workload Deploy only
+// queues its replicas' deployments and stays deploying, and that
+// deploy events move it to active once all of them succeeded, or to
+// failed once one failed.
+func TestDeploy_SettlesOnDeployEvents(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		states    []deploy.DeployState
+		wantState State
+	}{
+		{name: "all succeeded", states: []deploy.DeployState{deploy.DeploySucceeded, deploy.DeploySucceeded}, wantState: StateActive},
+		{name: "one still running", states: []deploy.DeployState{deploy.DeploySucceeded, deploy.DeployRunning}, wantState: StateDeploying},
+		{name: "one failed", states: []deploy.DeployState{deploy.DeployFailed, deploy.DeploySucceeded}, wantState: StateFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := adminCtxStream()
+			wid := id.New(id.PrefixWorkload)
+
+			store := newRestartFakeStore()
+			store.put(seedWorkload(wid, 2))
+
+			insts := &eventInstances{replicas: []*instance.Instance{newReplica(wid, 0), newReplica(wid, 1)}}
+			deploys := &eventDeploys{latest: make(map[id.ID]*deploy.Deployment)}
+
+			svc := &service{store: store, instances: insts, deploys: deploys, events: event.NewInMemoryBus()}
+
+			first, err := svc.Deploy(ctx, wid, DeployRequest{
+				Services: []provider.ServiceDeploySpec{{Name: "main", Image: "alpine:3.20"}},
+			})
+			if err != nil {
+				t.Fatalf("Deploy: %v", err)
+			}
+
+			if got := store.get(wid); got.State != StateDeploying || !got.CurrentReleaseID.IsNil() {
+				t.Fatalf("after Deploy: state=%s release=%s, want deploying without a release", got.State, got.CurrentReleaseID)
+			}
+
+			for i, r := range insts.replicas {
+				dep := deploys.finish(r.ID, tt.states[i])
+
+				err := svc.HandleDeployEvent(context.Background(), event.NewEvent(event.DeploySucceeded, "test-tenant").
+					WithInstance(dep.InstanceID))
+				if err != nil {
+					t.Fatalf("HandleDeployEvent: %v", err)
+				}
+			}
+
+			got := store.get(wid)
+			if got.State != tt.wantState {
+				t.Fatalf("workload state: want %s, got %s", tt.wantState, got.State)
+			}
+
+			if tt.wantState == StateActive && got.CurrentReleaseID != first.ReleaseID {
+				t.Fatalf("CurrentReleaseID: want %s, got %s", first.ReleaseID, got.CurrentReleaseID)
+			}
+		})
+	}
+}
@@ -50,8 +50,9 @@ type Service interface {
 
 	// Deploy creates a new Release from the Workload's current spec
 	// (or from req overrides) and rolls it out to all replicas via
-	// the chosen strategy. Returns the Deployment record so callers
-	// can poll its state.
+	// the chosen strategy. Returns the first replica's pending
+	// Deployment so callers can poll its state; the workload stays
+	// StateDeploying until every replica's deployment has finished.
 	Deploy(ctx context.Context, workloadID id.ID, req DeployRequest) (*deploy.Deployment, error)
 
 	// Delete tears down the Workload and all its replicas.
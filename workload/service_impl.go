@@ -504,6 +504,11 @@ func (s *service) Restart(ctx context.Context, workloadID id.ID) error {
 // Workload.Services is updated in-place to reflect the new images on
 // the targeted services so subsequent reads (and any newly-spawned
 // replicas) see the post-deploy spec.
+//
+// The replicas' deployments are queued for the deploy runner, so the
+// workload is left in StateDeploying; HandleDeployEvent moves it on
+// once they finish. StateFailed here only means a replica's
+// deployment couldn't be queued.
 func (s *service) Deploy(ctx context.Context, workloadID id.ID, req DeployRequest) (*deploy.Deployment, error) {
 	if len(req.Services) == 0 {
 		return nil, errors.New("deploy workload: at least one service required")
@@ -518,6 +523,16 @@ func (s *service) Deploy(ctx context.Context, workloadID id.ID, req DeployReques
 		return nil, fmt.Errorf("deploy workload: %w", err)
 	}
 
+	// Without a bake window of its own the rollout takes the one of the
+	// template the workload was forked from. A template deleted since
+	// leaves nothing to inherit.
+	bake := req.Bake
+	if bake == nil && !w.TemplateID.IsNil() && s.templates != nil {
+		if tmpl, terr := s.templates.Get(ctx, w.TemplateID); terr == nil {
+			bake = tmpl.DefaultBake
+		}
+	}
+
 	// Apply per-service image/env overrides to the workload's own spec.
 	// Services not listed in req keep their current spec.
 	known := make(map[string]int, len(w.Services))
@@ -568,6 +583,7 @@ func (s *service) Deploy(ctx context.Context, workloadID id.ID, req DeployReques
 			Services:   req.Services,
 			Strategy:   req.Strategy,
 			Notes:      req.Notes,
+			Bake:       bake,
 		})
 		if derr != nil {
 			w.State = StateFailed
@@ -581,15 +597,6 @@ func (s *service) Deploy(ctx context.Context, workloadID id.ID, req DeployReques
 		}
 	}
 
-	w.State = StateActive
-	if first != nil {
-		w.CurrentReleaseID = first.ReleaseID
-	}
-
-	if err := s.store.UpdateWorkload(ctx, w); err != nil {
-		return nil, fmt.Errorf("deploy workload: final update: %w", err)
-	}
-
 	claims, _ := auth.RequireClaims(ctx)
 
 	subjectID := ""
@@ -124,7 +124,9 @@ func (f *recordInitialFakeDeploys) Rollback(context.Context, id.ID, id.ID) (*dep
 	panic("Rollback not used")
 }
 
-func (f *recordInitialFakeDeploys) Cancel(context.Context, id.ID) error { panic("Cancel not used") }
+func (f *recordInitialFakeDeploys) Cancel(context.Context, id.ID, deploy.CancelOptions) error {
+	panic("Cancel not used")
+}
 
 func (f *recordInitialFakeDeploys) GetDeployment(context.Context, id.ID) (*deploy.Deployment, error) {
 	panic("GetDeployment not used")
@@ -1,6 +1,7 @@
 package workload
 
 import (
+	"github.com/xraph/ctrlplane/deploy"
 	"github.com/xraph/ctrlplane/id"
 	"github.com/xraph/ctrlplane/provider"
 )
@@ -54,9 +55,11 @@ type ListResult struct {
 
 // DeployRequest kicks off a new release rollout. Services lists only
 // the services being changed in this rollout — services not listed
-// inherit their snapshot from the prior Release.
+// inherit their snapshot from the prior Release. Bake defaults to the
+// source template's DefaultBake.
 type DeployRequest struct {
 	Services []provider.ServiceDeploySpec `json:"services"           validate:"required,min=1"`
 	Strategy string                       `json:"strategy,omitempty"` // "rolling" (default), "recreate", "blue_green", "canary"
 	Notes    string                       `json:"notes,omitempty"`
+	Bake     *deploy.BakeConfig           `json:"bake,omitempty"`
 }